@@ -5,17 +5,23 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"os"
+	"time"
 
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/accounts"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/admin"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/folders"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/mfa"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/sessions"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/sso"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/tokens"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/users"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/config"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/mailer"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/storage"
 	"github.com/redis/go-redis/v9"
 )
@@ -33,8 +39,8 @@ func main() {
 
 	dbRepo := sqlc.New(pool)
 
-	// Initialize Minio, Storage Handler
-	store, err := storage.NewMinioStorage(cfg.Minio)
+	// Initialize Storage (MinIO or local filesystem)
+	store, err := storage.New(cfg)
 	if err != nil {
 		log.Fatal("Failed to initialize storage", err)
 	}
@@ -53,26 +59,70 @@ func main() {
 
 	auditService := audit.NewService(dbRepo)
 
+	// Initialize Sessions Repository, Service, Handler
+	sessionRepo := sessions.NewRepository(dbRepo)
+	sessionService := sessions.NewService(sessionRepo, cfg.Server.JWTSecret, cfg, auditService)
+	sessionHandler := sessions.NewHandler(sessionService)
+
+	// Delete sessions a week after they expired or were revoked
+	go sessionService.RunSessionCleaner(context.Background(), 7*24*time.Hour, time.Hour)
+
+	// Initialize Two-Factor Authentication Repository, Service, Handler
+	mfaRepo := mfa.NewRepository(dbRepo)
+	mfaService := mfa.NewService(mfaRepo, auditService)
+	mfaHandler := mfa.NewHandler(mfaService, sessionService)
+
+	// Initialize Mailer (SMTP, or the log in development)
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		log.Fatal("Failed to initialize mailer", err)
+	}
+
+	// Initialize Accounts Repository, Service, Handler
+	accountRepo := accounts.NewRepository(dbRepo)
+	accountService := accounts.NewService(accountRepo, mail, cfg, auditService)
+	accountHandler := accounts.NewHandler(accountService)
+
 	// Initialize Users Repository, Service, Handler
 	userRepo := users.NewRepository(dbRepo)
-	userService := users.NewService(userRepo, os.Getenv("JWT_SECRET"), cfg, auditService)
-	userHandler := users.NewHandler(userService)
+	userService := users.NewService(userRepo, cfg, auditService)
+	userHandler := users.NewHandler(userService, sessionService, mfaService, accountService)
+
+	// Initialize Single Sign-On Repository, Service, Handler
+	ssoRepo := sso.NewRepository(dbRepo)
+	ssoService := sso.NewService(ssoRepo, cfg, auditService)
+	ssoHandler := sso.NewHandler(ssoService, sessionService, mfaService)
 
 	// Initialize Folders Repository, Service, Handler
 	folderRepo := folders.NewRepository(dbRepo)
-	folderService := folders.NewService(folderRepo, store)
+	folderService := folders.NewService(folderRepo, userRepo, auditService)
 	folderHandler := folders.NewHandler(folderService)
 
 	// Initialize Files Repository, Service, Handler
 	fileRepo := files.NewRepository(pool) // Initializing with pool to enable transactions
-	fileService := files.NewService(fileRepo, userRepo, folderRepo, store, auditService)
+	fileService := files.NewService(fileRepo, userRepo, folderRepo, store, auditService, cfg.Server.PublicURL)
 	fileHandler := files.NewFileHandler(fileService)
 
+	// Purge items that have been in the trash for longer than the retention period
+	trashRetention := time.Duration(cfg.Server.TrashRetentionDays) * 24 * time.Hour
+	go fileService.RunTrashPurger(context.Background(), trashRetention, time.Hour)
+
+	// Remove resumable and direct uploads that were abandoned before completion
+	go fileService.RunUploadCleaner(context.Background(), time.Hour)
+
+	// Extract the text of new documents for content search
+	go fileService.RunContentIndexer(context.Background(), time.Minute)
+
 	// Initialize Admin Service, Handler
 	adminService := admin.NewService(dbRepo)
 	adminHandler := admin.NewHandler(adminService)
 
-	server := api.NewServer(cfg, userHandler, fileHandler, folderHandler, adminHandler, redisClient, dbRepo)
+	// Initialize Tokens Repository, Service, Handler
+	tokenRepo := tokens.NewRepository(dbRepo)
+	tokenService := tokens.NewService(tokenRepo, userRepo, auditService)
+	tokenHandler := tokens.NewHandler(tokenService)
+
+	server := api.NewServer(cfg, userHandler, fileHandler, folderHandler, adminHandler, tokenHandler, tokenService, sessionHandler, sessionService, ssoHandler, mfaHandler, accountHandler, redisClient, dbRepo, store)
 
 	log.Printf("Server listening on :%s", cfg.Server.Port)
 	addr := fmt.Sprintf(":%s", cfg.Server.Port)
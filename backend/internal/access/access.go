@@ -0,0 +1,58 @@
+// Package access defines the permission levels users can hold on files and folders.
+package access
+
+// Permission is the access level a user has on a file or folder.
+// Levels are ordered, each one includes everything the previous levels allow.
+type Permission int
+
+const (
+	None    Permission = iota
+	Read               // download files and browse folders
+	Comment            // read, and comment on files
+	Write              // rename, and replace file contents
+	Reshare            // share with others and manage public links
+	Owner              // delete and move
+)
+
+// shareLevels maps the permissions stored in file_shares and folder_shares to their level.
+var shareLevels = map[string]Permission{
+	"read":    Read,
+	"comment": Comment,
+	"write":   Write,
+	"reshare": Reshare,
+}
+
+// ParseShare parses a permission that can be granted through a share.
+func ParseShare(s string) (Permission, bool) {
+	p, ok := shareLevels[s]
+	return p, ok
+}
+
+// Highest returns the highest of the given stored share permissions,
+// or None if there are none. Unknown values are ignored.
+func Highest(stored []string) Permission {
+	highest := None
+	for _, s := range stored {
+		if p, ok := shareLevels[s]; ok && p > highest {
+			highest = p
+		}
+	}
+	return highest
+}
+
+// String returns the name of the permission as stored in the share tables.
+func (p Permission) String() string {
+	switch p {
+	case Read:
+		return "read"
+	case Comment:
+		return "comment"
+	case Write:
+		return "write"
+	case Reshare:
+		return "reshare"
+	case Owner:
+		return "owner"
+	}
+	return "none"
+}
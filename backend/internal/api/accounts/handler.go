@@ -0,0 +1,90 @@
+package accounts
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apphandler"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/util"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handler is the HTTP handler for email verification and password reset endpoints.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new accounts Handler with the given service.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterPublicRoutes registers the routes opened from the links sent by
+// email, and the request of a password reset, reached without a session.
+func (h *Handler) RegisterPublicRoutes(r chi.Router) {
+	r.Post("/auth/email/verify", apphandler.MakeHTTPHandler(h.VerifyEmail))
+	r.Post("/auth/password/forgot", apphandler.MakeHTTPHandler(h.ForgotPassword))
+	r.Post("/auth/password/reset", apphandler.MakeHTTPHandler(h.ResetPassword))
+}
+
+// RegisterRoutes registers the routes of authenticated users. They must stay
+// reachable before the email address is verified.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Post("/auth/email/resend", apphandler.MakeHTTPHandler(h.ResendVerification))
+}
+
+// VerifyEmail handles POST /auth/email/verify.
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) error {
+	var req tokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierror.NewBadRequestError("Invalid request body")
+	}
+
+	if err := h.service.VerifyEmail(r.Context(), req.Token); err != nil {
+		return err
+	}
+
+	return util.WriteJSON(w, http.StatusOK, "Email address verified")
+}
+
+// ResendVerification handles POST /auth/email/resend.
+func (h *Handler) ResendVerification(w http.ResponseWriter, r *http.Request) error {
+	if err := h.service.ResendVerification(r.Context()); err != nil {
+		return err
+	}
+
+	return util.WriteJSON(w, http.StatusAccepted, "Verification email sent")
+}
+
+// ForgotPassword handles POST /auth/password/forgot.
+// The response is the same whether or not the email address has an account.
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) error {
+	var req forgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierror.NewBadRequestError("Invalid request body")
+	}
+	if req.Email == "" {
+		return apierror.NewBadRequestError("Email is required")
+	}
+
+	if err := h.service.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		return err
+	}
+
+	return util.WriteJSON(w, http.StatusAccepted, "If an account uses this email address, a password reset link has been sent to it")
+}
+
+// ResetPassword handles POST /auth/password/reset.
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) error {
+	var req resetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierror.NewBadRequestError("Invalid request body")
+	}
+
+	if err := h.service.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		return err
+	}
+
+	return util.WriteJSON(w, http.StatusOK, "Password reset, please sign in with your new password")
+}
@@ -0,0 +1,93 @@
+package accounts
+
+import (
+	"context"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+)
+
+// Repository describes the database operations used by the accounts service.
+// It is implemented on top of sqlc queries by NewRepository.
+type Repository interface {
+	GetUserByID(ctx context.Context, userID int64) (sqlc.User, error)
+	GetUserByEmailFold(ctx context.Context, email string) (sqlc.User, error)
+	VerifyUserEmail(ctx context.Context, userID int64) (int64, error)
+	UpdateUserPassword(ctx context.Context, arg sqlc.UpdateUserPasswordParams) error
+	RevokeAllSessions(ctx context.Context, userID int64) (int64, error)
+	RevokeAllPersonalAccessTokens(ctx context.Context, userID int64) (int64, error)
+	CreateEmailToken(ctx context.Context, arg sqlc.CreateEmailTokenParams) error
+	UseEmailToken(ctx context.Context, arg sqlc.UseEmailTokenParams) (sqlc.EmailToken, error)
+	DeleteEmailTokens(ctx context.Context, arg sqlc.DeleteEmailTokensParams) error
+	DeleteExpiredEmailTokens(ctx context.Context) (int64, error)
+	EmailTokenSentSince(ctx context.Context, arg sqlc.EmailTokenSentSinceParams) (bool, error)
+}
+
+// repository handles database operations related to email tokens, backed by sqlc queries.
+type repository struct {
+	queries *sqlc.Queries
+}
+
+// NewRepository creates a new Repository instance with the provided database queries.
+func NewRepository(db *sqlc.Queries) Repository {
+	return &repository{
+		queries: db,
+	}
+}
+
+// GetUserByID retrieves a user by their unique ID.
+func (r *repository) GetUserByID(ctx context.Context, userID int64) (sqlc.User, error) {
+	return r.queries.GetUserByID(ctx, userID)
+}
+
+// GetUserByEmailFold retrieves a user by their email address, ignoring case.
+func (r *repository) GetUserByEmailFold(ctx context.Context, email string) (sqlc.User, error) {
+	return r.queries.GetUserByEmailFold(ctx, email)
+}
+
+// VerifyUserEmail marks the email address of a user as verified.
+// Returns 0 if it already was.
+func (r *repository) VerifyUserEmail(ctx context.Context, userID int64) (int64, error) {
+	return r.queries.VerifyUserEmail(ctx, userID)
+}
+
+// UpdateUserPassword replaces the password hash of a user.
+func (r *repository) UpdateUserPassword(ctx context.Context, arg sqlc.UpdateUserPasswordParams) error {
+	return r.queries.UpdateUserPassword(ctx, arg)
+}
+
+// RevokeAllSessions revokes every active session of a user.
+func (r *repository) RevokeAllSessions(ctx context.Context, userID int64) (int64, error) {
+	return r.queries.RevokeAllSessions(ctx, userID)
+}
+
+// RevokeAllPersonalAccessTokens revokes every active personal access token of a user.
+func (r *repository) RevokeAllPersonalAccessTokens(ctx context.Context, userID int64) (int64, error) {
+	return r.queries.RevokeAllPersonalAccessTokens(ctx, userID)
+}
+
+// CreateEmailToken stores the hash of a token sent by email.
+func (r *repository) CreateEmailToken(ctx context.Context, arg sqlc.CreateEmailTokenParams) error {
+	return r.queries.CreateEmailToken(ctx, arg)
+}
+
+// UseEmailToken marks a token as used and returns it. Returns pgx.ErrNoRows
+// if it is unknown, used or expired.
+func (r *repository) UseEmailToken(ctx context.Context, arg sqlc.UseEmailTokenParams) (sqlc.EmailToken, error) {
+	return r.queries.UseEmailToken(ctx, arg)
+}
+
+// DeleteEmailTokens deletes the unused tokens of a user for a purpose.
+func (r *repository) DeleteEmailTokens(ctx context.Context, arg sqlc.DeleteEmailTokensParams) error {
+	return r.queries.DeleteEmailTokens(ctx, arg)
+}
+
+// DeleteExpiredEmailTokens deletes the expired tokens of all users.
+func (r *repository) DeleteExpiredEmailTokens(ctx context.Context) (int64, error) {
+	return r.queries.DeleteExpiredEmailTokens(ctx)
+}
+
+// EmailTokenSentSince reports whether a token was sent to a user for a
+// purpose after the given time.
+func (r *repository) EmailTokenSentSince(ctx context.Context, arg sqlc.EmailTokenSentSinceParams) (bool, error) {
+	return r.queries.EmailTokenSentSince(ctx, arg)
+}
@@ -0,0 +1,304 @@
+// Package accounts confirms the email address of new users and resets
+// forgotten passwords, with single-use links sent by email. Until they
+// confirm their address, users are restricted, see middleware.VerifiedOnly.
+package accounts
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/config"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/mailer"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Purposes of the tokens sent by email, a token is only accepted for its own.
+const (
+	PurposeVerifyEmail   = "verify_email"
+	PurposeResetPassword = "reset_password"
+)
+
+const (
+	// verificationTTL is how long a link confirming an email address works.
+	verificationTTL = 24 * time.Hour
+	// resetTTL is how long a password reset link works.
+	resetTTL = time.Hour
+	// resendInterval is the least time between two emails of the same purpose
+	// to a user, so the endpoints cannot be used to flood their inbox.
+	resendInterval = time.Minute
+)
+
+var (
+	// ErrInvalidToken is returned for unknown, used and expired links.
+	ErrInvalidToken = apierror.New(http.StatusBadRequest, "This link is invalid or has expired")
+	// ErrAlreadyVerified is returned when asking to verify an address that is.
+	ErrAlreadyVerified = apierror.New(http.StatusConflict, "Your email address is already verified")
+	// ErrTooSoon is returned when an email was sent less than resendInterval ago.
+	ErrTooSoon = apierror.New(http.StatusTooManyRequests, "An email was just sent, please wait a minute before asking for another")
+)
+
+// Service handles email verification and password resets.
+type Service struct {
+	repo        Repository
+	mailer      mailer.Mailer
+	frontendURL string
+	audit       audit.Service
+}
+
+// NewService creates a new instance of the accounts Service.
+// - repo: repository providing database operations for users and email tokens.
+// - mail: mailer sending the links.
+// - cfg: configuration struct containing the frontend URL the links point to.
+// - auditService: service used to record verifications and password resets.
+func NewService(repo Repository, mail mailer.Mailer, cfg *config.Config, auditService audit.Service) *Service {
+	return &Service{
+		repo:        repo,
+		mailer:      mail,
+		frontendURL: cfg.Server.FrontendURL,
+		audit:       auditService,
+	}
+}
+
+// hash returns the hex-encoded SHA-256 of a token, the form it is stored in.
+// Tokens are random, so a fast hash is enough.
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// link returns the URL of a frontend page with a token.
+func (s *Service) link(path, token string) string {
+	return s.frontendURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// issueToken creates a token for a user, replacing the unused ones of the
+// same purpose so only the latest email works.
+func (s *Service) issueToken(ctx context.Context, userID int64, purpose string, ttl time.Duration) (string, error) {
+	// Expired tokens are of no use, clean them up while we are at it
+	if _, err := s.repo.DeleteExpiredEmailTokens(ctx); err != nil {
+		log.Printf("Failed to delete expired email tokens: %v", err)
+	}
+	if err := s.repo.DeleteEmailTokens(ctx, sqlc.DeleteEmailTokensParams{UserID: userID, Purpose: purpose}); err != nil {
+		return "", err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	if err := s.repo.CreateEmailToken(ctx, sqlc.CreateEmailTokenParams{
+		TokenHash: hash(token),
+		UserID:    userID,
+		Purpose:   purpose,
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(ttl), Valid: true},
+	}); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// sentRecently reports whether an email of the purpose was sent to the user
+// less than resendInterval ago.
+func (s *Service) sentRecently(ctx context.Context, userID int64, purpose string) (bool, error) {
+	return s.repo.EmailTokenSentSince(ctx, sqlc.EmailTokenSentSinceParams{
+		UserID:  userID,
+		Purpose: purpose,
+		Since:   pgtype.Timestamptz{Time: time.Now().Add(-resendInterval), Valid: true},
+	})
+}
+
+// SendVerification emails a link confirming the address of a user, who is
+// restricted until they open it. Users whose address is verified get none.
+func (s *Service) SendVerification(ctx context.Context, user sqlc.User) error {
+	if user.EmailVerifiedAt.Valid {
+		return nil
+	}
+
+	token, err := s.issueToken(ctx, user.ID, PurposeVerifyEmail, verificationTTL)
+	if err != nil {
+		log.Printf("Failed to create verification token for user %d: %v", user.ID, err)
+		return apierror.NewInternalServerError("Failed to create verification link")
+	}
+
+	if err := s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your FileVault email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Please confirm your email address by opening this link:\n\n%s\n\n"+
+			"The link expires in 24 hours. If you did not create a FileVault account, you can ignore this email.\n",
+			user.Name, s.link("/verify-email", token)),
+	}); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+		return apierror.NewInternalServerError("Failed to send verification email")
+	}
+	return nil
+}
+
+// ResendVerification emails a new verification link to the authenticated
+// user, at most once per resendInterval.
+func (s *Service) ResendVerification(ctx context.Context) error {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return apierror.NewUnauthorizedError()
+	}
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return apierror.NewInternalServerError("Failed to fetch user")
+	}
+	if user.EmailVerifiedAt.Valid {
+		return ErrAlreadyVerified
+	}
+
+	recent, err := s.sentRecently(ctx, userID, PurposeVerifyEmail)
+	if err != nil {
+		return apierror.NewInternalServerError("Failed to fetch verification status")
+	}
+	if recent {
+		return ErrTooSoon
+	}
+	return s.SendVerification(ctx, user)
+}
+
+// VerifyEmail confirms the email address of the user a verification link
+// was sent to, lifting their restrictions.
+func (s *Service) VerifyEmail(ctx context.Context, token string) error {
+	used, err := s.repo.UseEmailToken(ctx, sqlc.UseEmailTokenParams{TokenHash: hash(token), Purpose: PurposeVerifyEmail})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrInvalidToken
+	} else if err != nil {
+		return apierror.NewInternalServerError("Failed to verify email address")
+	}
+
+	verified, err := s.repo.VerifyUserEmail(ctx, used.UserID)
+	if err != nil {
+		log.Printf("Failed to verify email address of user %d: %v", used.UserID, err)
+		return apierror.NewInternalServerError("Failed to verify email address")
+	}
+	if verified > 0 {
+		s.audit.Log(ctx, audit.LogParams{
+			UserID: used.UserID,
+			Action: "EMAIL_VERIFIED",
+		})
+	}
+	return nil
+}
+
+// RequestPasswordReset emails a password reset link to the user with the
+// given email address. It succeeds whether or not there is such a user, and
+// when a link was sent less than resendInterval ago, so the response does not
+// tell who has an account.
+func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.repo.GetUserByEmailFold(ctx, email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return apierror.NewInternalServerError("Failed to fetch user")
+	}
+
+	recent, err := s.sentRecently(ctx, user.ID, PurposeResetPassword)
+	if err != nil {
+		return apierror.NewInternalServerError("Failed to fetch password reset status")
+	}
+	if recent {
+		return nil
+	}
+
+	token, err := s.issueToken(ctx, user.ID, PurposeResetPassword, resetTTL)
+	if err != nil {
+		log.Printf("Failed to create password reset token for user %d: %v", user.ID, err)
+		return apierror.NewInternalServerError("Failed to create password reset link")
+	}
+
+	// A failure is only logged, telling the caller would tell them the account exists
+	if err := s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your FileVault password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Someone asked to reset the password of your FileVault account. To choose a new password, open this link:\n\n%s\n\n"+
+			"The link expires in 1 hour and works once. If you did not ask for it, you can ignore this email, your password is unchanged.\n",
+			user.Name, s.link("/reset-password", token)),
+	}); err != nil {
+		log.Printf("Failed to send password reset email to user %d: %v", user.ID, err)
+		return nil
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID: user.ID,
+		Action: "PASSWORD_RESET_REQUESTED",
+	})
+	return nil
+}
+
+// ResetPassword sets a new password for the user a reset link was sent to.
+// - The link also proves they own the email address, so it is verified.
+// - Other reset links of the user stop working.
+// - Every session and personal access token of the user is revoked, logging out whoever knew the old password.
+func (s *Service) ResetPassword(ctx context.Context, token, password string) error {
+	if password == "" {
+		return apierror.NewBadRequestError("Password is required")
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid password")
+	}
+
+	used, err := s.repo.UseEmailToken(ctx, sqlc.UseEmailTokenParams{TokenHash: hash(token), Purpose: PurposeResetPassword})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrInvalidToken
+	} else if err != nil {
+		return apierror.NewInternalServerError("Failed to reset password")
+	}
+
+	if err := s.repo.UpdateUserPassword(ctx, sqlc.UpdateUserPasswordParams{ID: used.UserID, Password: string(passwordHash)}); err != nil {
+		log.Printf("Failed to reset password of user %d: %v", used.UserID, err)
+		return apierror.NewInternalServerError("Failed to reset password")
+	}
+	if verified, err := s.repo.VerifyUserEmail(ctx, used.UserID); err != nil {
+		log.Printf("Failed to verify email address of user %d: %v", used.UserID, err)
+	} else if verified > 0 {
+		s.audit.Log(ctx, audit.LogParams{
+			UserID: used.UserID,
+			Action: "EMAIL_VERIFIED",
+		})
+	}
+	if err := s.repo.DeleteEmailTokens(ctx, sqlc.DeleteEmailTokensParams{UserID: used.UserID, Purpose: PurposeResetPassword}); err != nil {
+		log.Printf("Failed to delete password reset tokens of user %d: %v", used.UserID, err)
+	}
+	revoked, err := s.repo.RevokeAllSessions(ctx, used.UserID)
+	if err != nil {
+		log.Printf("Failed to revoke sessions of user %d: %v", used.UserID, err)
+		return apierror.NewInternalServerError("Failed to revoke sessions")
+	}
+	// Tokens made by whoever knew the old password would outlive the sessions otherwise
+	revokedTokens, err := s.repo.RevokeAllPersonalAccessTokens(ctx, used.UserID)
+	if err != nil {
+		log.Printf("Failed to revoke personal access tokens of user %d: %v", used.UserID, err)
+		return apierror.NewInternalServerError("Failed to revoke personal access tokens")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID: used.UserID,
+		Action: "PASSWORD_RESET",
+		Details: map[string]interface{}{
+			"revoked_sessions": revoked,
+			"revoked_tokens":   revokedTokens,
+		},
+	})
+	return nil
+}
@@ -0,0 +1,261 @@
+package accounts_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/accounts"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/sessions"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/tokens"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/config"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/memdb"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/mailer"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const frontendURL = "https://vault.example.com"
+
+// recordingAudit keeps the actions it is asked to log.
+type recordingAudit struct {
+	actions []string
+}
+
+func (a *recordingAudit) Log(ctx context.Context, params audit.LogParams) {
+	a.actions = append(a.actions, params.Action)
+}
+
+// outbox is a mailer keeping the emails it is asked to send.
+type outbox struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (o *outbox) Send(ctx context.Context, msg mailer.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+var linkPattern = regexp.MustCompile(`https://vault\.example\.com(/[a-z-]+)\?token=([A-Za-z0-9_-]+)`)
+
+// token returns the token of the link in the last email, after checking it
+// was sent to the given address and opens the given page.
+func (o *outbox) token(t *testing.T, to, page string) string {
+	t.Helper()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.messages) == 0 {
+		t.Fatal("no email was sent")
+	}
+	msg := o.messages[len(o.messages)-1]
+	match := linkPattern.FindStringSubmatch(msg.Body)
+	if msg.To != to || match == nil || match[1] != page {
+		t.Fatalf("email = %+v, want a link to %s sent to %s", msg, page, to)
+	}
+	return match[2]
+}
+
+func (o *outbox) count() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.messages)
+}
+
+type testEnv struct {
+	db      *memdb.DB
+	audit   *recordingAudit
+	outbox  *outbox
+	service *accounts.Service
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	db := memdb.New()
+	recorder := &recordingAudit{}
+	box := &outbox{}
+	cfg := &config.Config{Server: config.ServerConfig{FrontendURL: frontendURL}}
+	return &testEnv{db: db, audit: recorder, outbox: box, service: accounts.NewService(db, box, cfg, recorder)}
+}
+
+// signup creates a user who has not verified their email address.
+func (env *testEnv) signup(t *testing.T, email, password string) sqlc.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := env.db.CreateUser(context.Background(), email, email, string(hash), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func (env *testEnv) verified(t *testing.T, userID int64) bool {
+	t.Helper()
+	user, err := env.db.GetUserByID(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user.EmailVerifiedAt.Valid
+}
+
+func wantStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != status {
+		t.Errorf("error = %v, want status %d", err, status)
+	}
+}
+
+func TestVerification(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	user := env.signup(t, "alice@example.com", "password")
+	userCtx := userctx.SetUserID(ctx, user.ID)
+
+	if err := env.service.SendVerification(ctx, user); err != nil {
+		t.Fatalf("SendVerification: %v", err)
+	}
+	first := env.outbox.token(t, "alice@example.com", "/verify-email")
+
+	// asking again right away is refused, so the inbox is not flooded
+	wantStatus(t, env.service.ResendVerification(userCtx), http.StatusTooManyRequests)
+
+	// only the link sent last works
+	if err := env.service.SendVerification(ctx, user); err != nil {
+		t.Fatalf("SendVerification: %v", err)
+	}
+	latest := env.outbox.token(t, "alice@example.com", "/verify-email")
+	if err := env.service.VerifyEmail(ctx, first); !errors.Is(err, accounts.ErrInvalidToken) {
+		t.Errorf("VerifyEmail with a replaced link = %v, want ErrInvalidToken", err)
+	}
+	if err := env.service.ResetPassword(ctx, latest, "new password"); !errors.Is(err, accounts.ErrInvalidToken) {
+		t.Errorf("ResetPassword with a verification link = %v, want ErrInvalidToken", err)
+	}
+	if env.verified(t, user.ID) {
+		t.Fatal("email verified before the link was opened")
+	}
+
+	if err := env.service.VerifyEmail(ctx, latest); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if !env.verified(t, user.ID) {
+		t.Error("email not verified after opening the link")
+	}
+	if err := env.service.VerifyEmail(ctx, latest); !errors.Is(err, accounts.ErrInvalidToken) {
+		t.Errorf("VerifyEmail with a used link = %v, want ErrInvalidToken", err)
+	}
+	if !slices.Contains(env.audit.actions, "EMAIL_VERIFIED") {
+		t.Errorf("audit actions = %v, want EMAIL_VERIFIED", env.audit.actions)
+	}
+
+	// verified users get no more emails
+	sent := env.outbox.count()
+	if err := env.service.ResendVerification(userCtx); !errors.Is(err, accounts.ErrAlreadyVerified) {
+		t.Errorf("ResendVerification of a verified user = %v, want ErrAlreadyVerified", err)
+	}
+	verifiedUser, _ := env.db.GetUserByID(ctx, user.ID)
+	if err := env.service.SendVerification(ctx, verifiedUser); err != nil || env.outbox.count() != sent {
+		t.Errorf("SendVerification of a verified user = %v, sent %d emails", err, env.outbox.count()-sent)
+	}
+
+	// an expired link does not work
+	bob := env.signup(t, "bob@example.com", "password")
+	if err := env.service.SendVerification(ctx, bob); err != nil {
+		t.Fatalf("SendVerification: %v", err)
+	}
+	token := env.outbox.token(t, "bob@example.com", "/verify-email")
+	env.db.ExpireEmailTokens()
+	if err := env.service.VerifyEmail(ctx, token); !errors.Is(err, accounts.ErrInvalidToken) {
+		t.Errorf("VerifyEmail with an expired link = %v, want ErrInvalidToken", err)
+	}
+	if err := env.service.ResendVerification(userctx.SetUserID(ctx, bob.ID)); err != nil {
+		t.Errorf("ResendVerification after the link expired: %v", err)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	user := env.signup(t, "alice@example.com", "old password")
+	cfg := &config.Config{Server: config.ServerConfig{AccessTokenTTLMinutes: 15, SessionTTLDays: 30}}
+	sessionService := sessions.NewService(env.db, "secret", cfg, env.audit)
+	if _, err := sessionService.CreateSession(ctx, user.ID, "browser", "127.0.0.1", false); err != nil {
+		t.Fatal(err)
+	}
+	tokenService := tokens.NewService(env.db, env.db, env.audit)
+	pat, err := tokenService.CreateToken(userctx.SetUserID(ctx, user.ID), tokens.CreateTokenRequest{Name: "sync", Scopes: []string{"files:read", "files:write"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// unknown addresses succeed too, without an email
+	if err := env.service.RequestPasswordReset(ctx, "nobody@example.com"); err != nil || env.outbox.count() != 0 {
+		t.Errorf("RequestPasswordReset of an unknown address = %v, sent %d emails", err, env.outbox.count())
+	}
+
+	if err := env.service.RequestPasswordReset(ctx, "Alice@Example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	token := env.outbox.token(t, "alice@example.com", "/reset-password")
+
+	// asking again right away succeeds without sending another email
+	if err := env.service.RequestPasswordReset(ctx, "alice@example.com"); err != nil || env.outbox.count() != 1 {
+		t.Errorf("second RequestPasswordReset = %v, sent %d emails, want 1", err, env.outbox.count())
+	}
+
+	// a rejected password does not use up the link
+	wantStatus(t, env.service.ResetPassword(ctx, token, ""), http.StatusBadRequest)
+
+	if err := env.service.ResetPassword(ctx, token, "new password"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	updated, err := env.db.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(updated.Password), []byte("new password")) != nil {
+		t.Error("password was not changed")
+	}
+	if !updated.EmailVerifiedAt.Valid {
+		t.Error("email not verified by the reset link")
+	}
+	for _, s := range env.db.Sessions() {
+		if !s.RevokedAt.Valid {
+			t.Errorf("session %s still active after the reset", s.ID)
+		}
+	}
+	if _, _, err := tokenService.AuthenticateToken(ctx, pat.Secret); !errors.Is(err, tokens.ErrInvalidToken) {
+		t.Errorf("personal access token after the reset = %v, want ErrInvalidToken", err)
+	}
+	if err := env.service.ResetPassword(ctx, token, "another password"); !errors.Is(err, accounts.ErrInvalidToken) {
+		t.Errorf("ResetPassword with a used link = %v, want ErrInvalidToken", err)
+	}
+	for _, action := range []string{"PASSWORD_RESET_REQUESTED", "PASSWORD_RESET", "EMAIL_VERIFIED"} {
+		if !slices.Contains(env.audit.actions, action) {
+			t.Errorf("audit actions = %v, want %s", env.audit.actions, action)
+		}
+	}
+
+	// an expired link does not work
+	env.db.ExpireEmailTokens()
+	if err := env.service.RequestPasswordReset(ctx, "alice@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	token = env.outbox.token(t, "alice@example.com", "/reset-password")
+	env.db.ExpireEmailTokens()
+	if err := env.service.ResetPassword(ctx, token, "another password"); !errors.Is(err, accounts.ErrInvalidToken) {
+		t.Errorf("ResetPassword with an expired link = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,17 @@
+package accounts
+
+// tokenRequest is the body of POST /auth/email/verify.
+type tokenRequest struct {
+	Token string `json:"token"`
+}
+
+// forgotPasswordRequest is the body of POST /auth/password/forgot.
+type forgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// resetPasswordRequest is the body of POST /auth/password/reset.
+type resetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
@@ -8,14 +8,20 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/cors"
 
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/accounts"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/admin"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apphandler"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/folders"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/mfa"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/middleware"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/sessions"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/sso"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/tokens"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/users"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/config"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/storage"
 )
 
 type Server struct {
@@ -28,16 +34,25 @@ func NewServer(
 	fileHandler *files.FileHandler,
 	folderHandler *folders.Handler,
 	adminHandler *admin.Handler,
+	tokenHandler *tokens.Handler,
+	tokenService middleware.TokenAuthenticator,
+	sessionHandler *sessions.Handler,
+	sessionService middleware.SessionValidator,
+	ssoHandler *sso.Handler,
+	mfaHandler *mfa.Handler,
+	accountHandler *accounts.Handler,
 	redisClient *redis.Client,
 	repo *sqlc.Queries,
+	store storage.Storage,
 ) *Server {
 	r := chi.NewRouter()
 
 	// TODO: move corsoptions to env vars
 	corsOptions := cors.New(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH", "HEAD"},
 		AllowedHeaders:   []string{"*"},
+		ExposedHeaders:   []string{"Content-Disposition", "Content-Range", "Accept-Ranges", "ETag", "Last-Modified", "Location", "Tus-Resumable", "Tus-Version", "Tus-Extension", "Upload-Offset", "Upload-Length", "Upload-Expires"},
 		AllowCredentials: true,
 		MaxAge:           86400,
 	})
@@ -52,28 +67,75 @@ func NewServer(
 	r.Group(func(r chi.Router) {
 		r.Post("/auth/signup", userHandler.Signup)
 		r.Post("/auth/login", userHandler.Login)
-		r.Post("/auth/logout", userHandler.Logout)
+		r.Post("/auth/logout", sessionHandler.Logout)
+		sessionHandler.RegisterPublicRoutes(r)
+		ssoHandler.RegisterPublicRoutes(r)
+		mfaHandler.RegisterPublicRoutes(r)
+		accountHandler.RegisterPublicRoutes(r)
+
+		// Every request to a share link may try a password, each costing a bcrypt comparison
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.IPRateLimiter(redisClient, "public_link", cfg.Server.PublicLinkRateLimit, time.Minute))
+			fileHandler.RegisterPublicRoutes(r)
+		})
+
+		// Backends without their own HTTP endpoint serve signed blob URLs through the API
+		if local, ok := store.(*storage.LocalStorage); ok {
+			r.Method(http.MethodGet, storage.LocalBlobRoute, local)
+			r.Method(http.MethodHead, storage.LocalBlobRoute, local)
+			r.Method(http.MethodPut, storage.LocalBlobRoute, local)
+		}
 	})
 
 	// Protected routes
 	r.Group(func(r chi.Router) {
-		r.Use(middleware.AuthMiddleware(cfg.Server.JWTSecret))
+		r.Use(middleware.AuthMiddleware(cfg.Server.JWTSecret, sessionService, tokenService))
 
 		rateLimitWindow := time.Duration(cfg.Server.RateLimitWindowSeconds) * time.Second
 		r.Use(middleware.RateLimiter(redisClient, cfg.Server.RateLimit, rateLimitWindow))
 
-		fileHandler.RegisterRoutes(r)
-		folderHandler.RegisterRoutes(r)
-		userHandler.RegisterRoutes(r)
+		// Users who have not confirmed their email address only reach these
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireMethodScopes(tokens.ScopeFilesRead, tokens.ScopeFilesWrite))
+			userHandler.RegisterAccountRoutes(r)
+		})
+		accountHandler.RegisterRoutes(r)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.VerifiedOnly(repo))
+
+			// Personal access tokens need files:read or files:write for these,
+			// users are listed to pick whom to share with
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RequireMethodScopes(tokens.ScopeFilesRead, tokens.ScopeFilesWrite))
+				fileHandler.RegisterRoutes(r)
+				folderHandler.RegisterRoutes(r)
+				userHandler.RegisterRoutes(r)
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.SessionOnly)
+				tokenHandler.RegisterRoutes(r)
+				mfaHandler.RegisterRoutes(r)
+			})
+		})
+
+		// Unverified users may still see and end their sessions
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.SessionOnly)
+			sessionHandler.RegisterRoutes(r)
+		})
 	})
 
 	// Admin Routes
 	r.Route("/admin", func(r chi.Router) {
-		r.Use(middleware.AuthMiddleware(cfg.Server.JWTSecret))
+		r.Use(middleware.AuthMiddleware(cfg.Server.JWTSecret, sessionService, tokenService))
+		r.Use(middleware.RequireScope(tokens.ScopeAdmin))
 		r.Use(middleware.AdminMiddleware(repo))
 
 		r.Get("/files", apphandler.MakeHTTPHandler(fileHandler.ListAllFiles))
 		adminHandler.RegisterRoutes(r)
+		mfaHandler.RegisterAdminRoutes(r)
 	})
 	return &Server{Router: r}
 }
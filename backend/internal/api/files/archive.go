@@ -0,0 +1,319 @@
+package files
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/access"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/google/uuid"
+)
+
+// Archive formats accepted in an ArchiveRequest.
+const (
+	ArchiveZip   = "zip"
+	ArchiveTarGz = "tar.gz"
+)
+
+// Archive is a selection of folders and files the user is allowed to read,
+// laid out with relative paths. Nothing is fetched from storage until it is
+// written with WriteArchive.
+type Archive struct {
+	Format  string
+	name    string
+	entries []archiveEntry
+	request ArchiveRequest
+	userID  int64
+	target  uuid.UUID
+	size    int64
+	files   int
+}
+
+// archiveEntry is a folder or a file inside an archive.
+// Folders have no storage path and their path ends in a slash.
+type archiveEntry struct {
+	path        string
+	storagePath string
+	size        int64
+	modTime     time.Time
+}
+
+// Filename returns the name the archive is downloaded as, which is the name of
+// the folder when a single folder is selected.
+func (a *Archive) Filename() string {
+	return a.name + "." + a.Format
+}
+
+// ContentType returns the media type of the archive.
+func (a *Archive) ContentType() string {
+	if a.Format == ArchiveTarGz {
+		return "application/gzip"
+	}
+	return "application/zip"
+}
+
+// PrepareArchive checks that the current user can read every selected folder
+// and file and lays out the archive. Selected folders are placed at the top of
+// the archive with their trashed contents left out, and selected files next to
+// them. Names that are not safe as path components are replaced and names
+// that clash get a " (n)" suffix.
+func (s *Service) PrepareArchive(ctx context.Context, req ArchiveRequest) (*Archive, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return nil, apierror.NewUnauthorizedError()
+	}
+
+	if req.Format == "" {
+		req.Format = ArchiveZip
+	}
+	if req.Format != ArchiveZip && req.Format != ArchiveTarGz {
+		return nil, apierror.NewBadRequestError("Unsupported archive format")
+	}
+	req.FolderIDs, req.FileIDs = uniqueIDs(req.FolderIDs), uniqueIDs(req.FileIDs)
+	if len(req.FolderIDs)+len(req.FileIDs) == 0 {
+		return nil, apierror.NewBadRequestError("Select at least one folder or file")
+	}
+
+	archive := &Archive{
+		Format:  req.Format,
+		name:    "download",
+		request: req,
+		userID:  userID,
+		target:  uuid.New(),
+	}
+	layout := newArchiveLayout()
+	now := time.Now()
+
+	for i, folderID := range req.FolderIDs {
+		folder, err := s.folderRepo.GetFolderByID(ctx, folderID)
+		if err != nil {
+			return nil, apierror.NewNotFoundError("Folder")
+		}
+		permission, err := s.folderPermission(ctx, folder, userID)
+		if err != nil {
+			return nil, err
+		}
+		if permission < access.Read {
+			return nil, apierror.NewForbiddenError()
+		}
+
+		rows, err := s.folderRepo.ListFolderArchiveEntries(ctx, folder.ID)
+		if err != nil {
+			log.Printf("Failed to list contents of folder %s for an archive: %v", folder.ID, err)
+			return nil, apierror.NewInternalServerError("Failed to list folder contents")
+		}
+		for _, row := range rows {
+			dir := layout.dir(i, row.FolderPath, now, &archive.entries)
+			if !row.FileID.Valid {
+				continue
+			}
+			archive.entries = append(archive.entries, archiveEntry{
+				path:        layout.unique(dir, row.Filename.String, true),
+				storagePath: row.StoragePath.String,
+				size:        row.Size.Int64,
+				modTime:     row.UploadedAt.Time,
+			})
+			archive.size += row.Size.Int64
+			archive.files++
+		}
+		if len(req.FolderIDs) == 1 && len(req.FileIDs) == 0 {
+			archive.name = sanitizePathComponent(folder.Name)
+			archive.target = folder.ID
+		}
+	}
+
+	for _, fileID := range req.FileIDs {
+		file, _, err := s.authorizeFile(ctx, fileID, access.Read)
+		if err != nil {
+			return nil, err
+		}
+		blob, err := s.repo.GetBlobByID(ctx, file.BlobID)
+		if err != nil {
+			log.Printf("Failed to fetch blob %s of file %s: %v", file.BlobID, file.ID, err)
+			return nil, apierror.NewInternalServerError("Unable to fetch file")
+		}
+		archive.entries = append(archive.entries, archiveEntry{
+			path:        layout.unique("", file.Filename, true),
+			storagePath: blob.StoragePath,
+			size:        file.Size,
+			modTime:     file.UploadedAt.Time,
+		})
+		archive.size += file.Size
+		archive.files++
+		if len(req.FileIDs) == 1 && len(req.FolderIDs) == 0 {
+			archive.target = file.ID
+		}
+	}
+
+	return archive, nil
+}
+
+// WriteArchive streams the archive to w, reading each file from storage as it
+// is written, and records the download in the audit log once it is complete.
+// An error means the output is truncated and must not be used.
+func (s *Service) WriteArchive(ctx context.Context, w io.Writer, a *Archive) error {
+	var err error
+	if a.Format == ArchiveTarGz {
+		err = s.writeTarGz(ctx, w, a.entries)
+	} else {
+		err = s.writeZip(ctx, w, a.entries)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   a.userID,
+		Action:   "ARCHIVE_DOWNLOADED",
+		TargetID: a.target,
+		Details: map[string]interface{}{
+			"format":     a.Format,
+			"folder_ids": a.request.FolderIDs,
+			"file_ids":   a.request.FileIDs,
+			"file_count": a.files,
+			"size":       a.size,
+		},
+	})
+	return nil
+}
+
+func (s *Service) writeZip(ctx context.Context, w io.Writer, entries []archiveEntry) error {
+	zw := zip.NewWriter(w)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.path, Modified: e.modTime, Method: zip.Deflate}
+		if e.storagePath == "" {
+			header.Method = zip.Store
+		}
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if e.storagePath != "" {
+			if err := s.copyBlob(ctx, fw, e.storagePath); err != nil {
+				return err
+			}
+		}
+	}
+	return zw.Close()
+}
+
+func (s *Service) writeTarGz(ctx context.Context, w io.Writer, entries []archiveEntry) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.path, ModTime: e.modTime, Typeflag: tar.TypeDir, Mode: 0o755}
+		if e.storagePath != "" {
+			header.Typeflag, header.Mode, header.Size = tar.TypeReg, 0o644, e.size
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if e.storagePath != "" {
+			if err := s.copyBlob(ctx, tw, e.storagePath); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// copyBlob writes the content of a stored object to w.
+func (s *Service) copyBlob(ctx context.Context, w io.Writer, storagePath string) error {
+	body, err := s.storage.GetBlob(ctx, storagePath)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	_, err = io.Copy(w, body)
+	return err
+}
+
+// archiveLayout assigns unique relative paths to the entries of an archive.
+type archiveLayout struct {
+	used map[string]bool   // paths taken by folders and files
+	dirs map[string]string // archive path of each folder, by selection and folder names
+}
+
+func newArchiveLayout() *archiveLayout {
+	return &archiveLayout{used: map[string]bool{}, dirs: map[string]string{}}
+}
+
+// dir returns the archive path of the folder reached through names within the
+// selected folder with the given index, appending entries for folders that are
+// not in the archive yet. Sibling folders with the same name are merged.
+func (l *archiveLayout) dir(selection int, names []string, modTime time.Time, entries *[]archiveEntry) string {
+	key := strconv.Itoa(selection) + "\x00" + strings.Join(names, "\x00")
+	if p, ok := l.dirs[key]; ok {
+		return p
+	}
+	parent := ""
+	if len(names) > 1 {
+		parent = l.dir(selection, names[:len(names)-1], modTime, entries)
+	}
+	p := l.unique(parent, names[len(names)-1], false)
+	l.dirs[key] = p
+	*entries = append(*entries, archiveEntry{path: p + "/", modTime: modTime})
+	return p
+}
+
+// unique returns a path for name inside dir that is not used by another entry
+// yet, numbering the name before its extension for files if needed.
+func (l *archiveLayout) unique(dir, name string, isFile bool) string {
+	name = sanitizePathComponent(name)
+	base, ext := name, ""
+	if isFile {
+		ext = path.Ext(name)
+		if ext == name {
+			ext = "" // dotfiles such as ".env" have no extension
+		}
+		base = strings.TrimSuffix(name, ext)
+	}
+
+	p := path.Join(dir, name)
+	for n := 1; l.used[p]; n++ {
+		p = path.Join(dir, base+" ("+strconv.Itoa(n)+")"+ext)
+	}
+	l.used[p] = true
+	return p
+}
+
+// sanitizePathComponent makes a folder or file name safe to use as a single
+// path component, so archive entries can't escape the extraction directory.
+func sanitizePathComponent(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+	return name
+}
+
+// uniqueIDs returns ids without duplicates, keeping their order.
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,180 @@
+package files_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/folders"
+	"github.com/google/uuid"
+)
+
+// readArchive returns the entries of a ZIP or tar.gz archive by path,
+// with an empty string for directories.
+func readArchive(t *testing.T, format string, data []byte) map[string]string {
+	t.Helper()
+	entries := map[string]string{}
+	if format == files.ArchiveZip {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("zip.NewReader: %v", err)
+		}
+		for _, f := range zr.File {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatalf("open %s: %v", f.Name, err)
+			}
+			content, _ := io.ReadAll(rc)
+			rc.Close()
+			entries[f.Name] = string(content)
+		}
+		return entries
+	}
+
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatalf("tar.Next: %v", err)
+		}
+		content, _ := io.ReadAll(tr)
+		entries[header.Name] = string(content)
+	}
+}
+
+func TestWriteArchive(t *testing.T) {
+	env := newTestEnv(t)
+	_, ctx := env.createUser(t, "owner@example.com", 1<<20)
+	folderService := folders.NewService(env.db, env.db, nopAudit{})
+
+	mkdir := func(name string, parent *uuid.UUID) uuid.UUID {
+		folder, err := folderService.CreateFolder(ctx, folders.CreateFolderRequest{Name: name, ParentFolderID: parent})
+		if err != nil {
+			t.Fatalf("CreateFolder(%s): %v", name, err)
+		}
+		return folder.ID
+	}
+	put := func(name, content string, folderID *uuid.UUID) uuid.UUID {
+		file, err := env.service.UploadFile(ctx, strings.NewReader(content), name, "text/plain", folderID)
+		if err != nil {
+			t.Fatalf("UploadFile(%s): %v", name, err)
+		}
+		return file.ID
+	}
+
+	docs := mkdir("docs", nil)
+	sub := mkdir("sub", &docs)
+	mkdir("empty", &docs)
+	put("report.txt", "first", &docs)
+	put("report.txt", "second", &docs)
+	put("notes.txt", "notes", &sub)
+	if err := env.service.DeleteFile(ctx, put("old.txt", "old", &sub)); err != nil {
+		t.Fatalf("DeleteFile: %v", err)
+	}
+	readme := put("docs", "readme", nil)
+
+	want := map[string]string{
+		"docs/":               "",
+		"docs/empty/":         "",
+		"docs/report.txt":     "first",
+		"docs/report (1).txt": "second",
+		"docs/sub/":           "",
+		"docs/sub/notes.txt":  "notes",
+		"docs (1)":            "readme",
+	}
+
+	for _, format := range []string{files.ArchiveZip, files.ArchiveTarGz} {
+		t.Run(format, func(t *testing.T) {
+			archive, err := env.service.PrepareArchive(ctx, files.ArchiveRequest{
+				FolderIDs: []uuid.UUID{docs},
+				FileIDs:   []uuid.UUID{readme, readme},
+				Format:    format,
+			})
+			if err != nil {
+				t.Fatalf("PrepareArchive: %v", err)
+			}
+			if got := archive.Filename(); got != "download."+format {
+				t.Errorf("Filename = %q, want download.%s", got, format)
+			}
+
+			var buf bytes.Buffer
+			if err := env.service.WriteArchive(ctx, &buf, archive); err != nil {
+				t.Fatalf("WriteArchive: %v", err)
+			}
+			got := readArchive(t, format, buf.Bytes())
+			if len(got) != len(want) {
+				t.Errorf("archive has %d entries, want %d: %v", len(got), len(want), got)
+			}
+			for path, content := range want {
+				if c, ok := got[path]; !ok || c != content {
+					t.Errorf("entry %q = %q (present %v), want %q", path, c, ok, content)
+				}
+			}
+		})
+	}
+}
+
+func TestPrepareArchiveAccess(t *testing.T) {
+	env := newTestEnv(t)
+	_, ownerCtx := env.createUser(t, "owner@example.com", 1<<20)
+	friendID, friendCtx := env.createUser(t, "friend@example.com", 1<<20)
+	folderService := folders.NewService(env.db, env.db, nopAudit{})
+
+	docs, err := folderService.CreateFolder(ownerCtx, folders.CreateFolderRequest{Name: "docs"})
+	if err != nil {
+		t.Fatalf("CreateFolder: %v", err)
+	}
+	fileID, err := upload(ownerCtx, env.service, "private.txt", "secret")
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		req  files.ArchiveRequest
+		want int
+	}{
+		{name: "nothing selected", ctx: ownerCtx, req: files.ArchiveRequest{}, want: http.StatusBadRequest},
+		{name: "unknown format", ctx: ownerCtx, req: files.ArchiveRequest{FileIDs: []uuid.UUID{fileID}, Format: "rar"}, want: http.StatusBadRequest},
+		{name: "missing folder", ctx: ownerCtx, req: files.ArchiveRequest{FolderIDs: []uuid.UUID{uuid.New()}}, want: http.StatusNotFound},
+		{name: "folder not shared", ctx: friendCtx, req: files.ArchiveRequest{FolderIDs: []uuid.UUID{docs.ID}}, want: http.StatusForbidden},
+		{name: "file not shared", ctx: friendCtx, req: files.ArchiveRequest{FileIDs: []uuid.UUID{fileID}}, want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := env.service.PrepareArchive(tt.ctx, tt.req); statusOf(err) != tt.want {
+				t.Errorf("PrepareArchive error = %v, want %d", err, tt.want)
+			}
+		})
+	}
+
+	err = folderService.UpdateFolderShares(ownerCtx, folders.UpdateFolderSharesRequest{
+		FolderID: docs.ID,
+		Shares:   []folders.Share{{UserID: friendID, Permission: "read"}},
+	})
+	if err != nil {
+		t.Fatalf("UpdateFolderShares: %v", err)
+	}
+	archive, err := env.service.PrepareArchive(friendCtx, files.ArchiveRequest{FolderIDs: []uuid.UUID{docs.ID}})
+	if err != nil {
+		t.Fatalf("PrepareArchive of shared folder: %v", err)
+	}
+	if got := archive.Filename(); got != "docs.zip" {
+		t.Errorf("Filename = %q, want docs.zip", got)
+	}
+}
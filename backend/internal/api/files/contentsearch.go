@@ -0,0 +1,169 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/textextract"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+)
+
+// textIndexBatch is the number of blobs IndexPendingBlobs extracts text from at a time.
+const textIndexBatch = 50
+
+// Outcomes of extracting the text of a blob, as stored in blob_texts.
+const (
+	textIndexed     = "indexed"
+	textUnsupported = "unsupported"
+	textFailed      = "failed"
+)
+
+// snippetMarks restores the <mark> elements of a snippet after it was escaped.
+var snippetMarks = strings.NewReplacer("&lt;mark&gt;", "<mark>", "&lt;/mark&gt;", "</mark>")
+
+// SearchFileContents finds files by their text across everything the
+// authenticated user owns or can access through a share. Text is extracted in
+// the background, so files uploaded moments ago may not be found yet. Results
+// are ranked by relevance and carry their path and a highlighted snippet.
+func (s *Service) SearchFileContents(ctx context.Context, req ContentSearchRequest) (ContentSearchResponse, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return ContentSearchResponse{}, apierror.NewUnauthorizedError()
+	}
+
+	search := strings.TrimSpace(req.Search)
+	if search == "" {
+		return ContentSearchResponse{}, apierror.NewBadRequestError("Search term cannot be empty")
+	}
+
+	rows, err := s.repo.SearchFileContents(ctx, sqlc.SearchFileContentsParams{
+		UserID: userID,
+		Search: search,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	})
+	if err != nil {
+		log.Printf("Failed to search file contents of user %d: %v", userID, err)
+		return ContentSearchResponse{}, apierror.NewInternalServerError("Failed to search files")
+	}
+
+	response := ContentSearchResponse{Data: make([]ContentSearchResult, len(rows))}
+	if len(rows) > 0 {
+		response.TotalCount = rows[0].TotalCount
+	}
+	for i, r := range rows {
+		item := ContentItem{
+			ID:           r.ID,
+			ItemType:     "file",
+			Filename:     r.Filename,
+			Size:         &r.Size,
+			UploadedAt:   r.UploadedAt.Time,
+			UserOwnsFile: r.UserOwnsFile,
+		}
+		if r.ContentType.Valid {
+			item.ContentType = &r.ContentType.String
+		}
+
+		path := make([]PathSegment, len(r.PathIds))
+		for j, id := range r.PathIds {
+			path[j] = PathSegment{ID: id, Name: r.PathNames[j]}
+		}
+		response.Data[i] = ContentSearchResult{
+			SearchResult: SearchResult{ContentItem: item, Path: path, Rank: r.Rank},
+			// the text comes from the user's files, only the marks are markup
+			Snippet: snippetMarks.Replace(html.EscapeString(r.Snippet)),
+		}
+	}
+	return response, nil
+}
+
+// IndexPendingBlobs extracts the text of a batch of blobs that have not been
+// indexed yet and stores it for content search. Each blob is extracted once,
+// however many files share it. Blobs in formats without extractable text are
+// recorded as unsupported so they are not tried again.
+// Returns the number of blobs processed.
+func (s *Service) IndexPendingBlobs(ctx context.Context) (int, error) {
+	blobs, err := s.repo.ListBlobsPendingTextExtraction(ctx, textIndexBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, blob := range blobs {
+		if err := ctx.Err(); err != nil {
+			return processed, err
+		}
+		status, content := s.extractBlobText(ctx, blob)
+		if err := s.repo.SaveBlobText(ctx, sqlc.SaveBlobTextParams{
+			BlobID:  blob.ID,
+			Status:  status,
+			Content: content,
+		}); err != nil {
+			// the blob may have been deleted in the meantime
+			log.Printf("Failed to save the text of blob %s: %v", blob.ID, err)
+			continue
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// extractBlobText reads a blob from storage and returns the status to record
+// for it and its text.
+func (s *Service) extractBlobText(ctx context.Context, blob sqlc.ListBlobsPendingTextExtractionRow) (string, string) {
+	if !textextract.Supported(blob.MimeType.String, blob.Filename) {
+		return textUnsupported, ""
+	}
+
+	body, err := s.storage.GetBlob(ctx, blob.StoragePath)
+	if err != nil {
+		log.Printf("Failed to read blob %s for text extraction: %v", blob.ID, err)
+		return textFailed, ""
+	}
+	defer body.Close()
+
+	text, err := textextract.Extract(body, blob.MimeType.String, blob.Filename)
+	switch {
+	case errors.Is(err, textextract.ErrUnsupported), errors.Is(err, textextract.ErrTooLarge):
+		return textUnsupported, ""
+	case err != nil:
+		log.Printf("Failed to extract the text of blob %s: %v", blob.ID, err)
+		return textFailed, ""
+	}
+	return textIndexed, text
+}
+
+// RunContentIndexer extracts the text of new blobs every interval until ctx
+// is done, working through the backlog in batches.
+func (s *Service) RunContentIndexer(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := s.IndexPendingBlobs(ctx)
+			if err != nil {
+				log.Printf("Failed to index file contents: %v", err)
+				break
+			}
+			if processed > 0 {
+				log.Printf("Indexed the contents of %d blobs", processed)
+			}
+			if processed < textIndexBatch {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package files_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/memdb"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/google/uuid"
+)
+
+// TestIndexPendingBlobs uploads documents in several formats, two of them with
+// the same contents, and checks the text stored for each blob.
+func TestIndexPendingBlobs(t *testing.T) {
+	uploads := []struct {
+		filename    string
+		contentType string
+		content     string
+		wantStatus  string
+		wantText    string
+	}{
+		{filename: "notes.md", contentType: "text/markdown", content: "# Meeting notes", wantStatus: "indexed", wantText: "# Meeting notes"},
+		{filename: "notes copy.md", contentType: "text/markdown", content: "# Meeting notes", wantStatus: "indexed", wantText: "# Meeting notes"},
+		{filename: "data.csv", contentType: "text/csv", content: "city,rank\nVellore,1", wantStatus: "indexed", wantText: "city,rank\nVellore,1"},
+		{filename: "photo.png", contentType: "image/png", content: "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", wantStatus: "unsupported"},
+		{filename: "gone.txt", contentType: "text/plain", content: "lost in storage", wantStatus: "failed"},
+	}
+
+	env := newTestEnv(t)
+	_, ctx := env.createUser(t, "owner@example.com", 1<<20)
+	for _, u := range uploads {
+		if _, err := env.service.UploadFile(ctx, strings.NewReader(u.content), u.filename, u.contentType, nil); err != nil {
+			t.Fatalf("UploadFile(%s): %v", u.filename, err)
+		}
+	}
+	blobOf := make(map[string]uuid.UUID)
+	for _, f := range env.db.Files() {
+		blobOf[f.Filename] = f.BlobID
+	}
+	for _, b := range env.db.Blobs() {
+		if b.ID == blobOf["gone.txt"] {
+			if err := env.store.DeleteBlob(context.Background(), b.StoragePath); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	processed, err := env.service.IndexPendingBlobs(context.Background())
+	if err != nil {
+		t.Fatalf("IndexPendingBlobs: %v", err)
+	}
+	if processed != 4 {
+		t.Errorf("processed %d blobs, want 4 for 5 files sharing 4 blobs", processed)
+	}
+
+	texts := env.db.BlobTexts()
+	for _, u := range uploads {
+		text, ok := texts[blobOf[u.filename]]
+		if !ok {
+			t.Errorf("%s: no text stored", u.filename)
+			continue
+		}
+		if text.Status != u.wantStatus || text.Content != u.wantText {
+			t.Errorf("%s: stored %s %q, want %s %q", u.filename, text.Status, text.Content, u.wantStatus, u.wantText)
+		}
+	}
+
+	if processed, err := env.service.IndexPendingBlobs(context.Background()); err != nil || processed != 0 {
+		t.Errorf("second pass processed %d blobs (%v), want none", processed, err)
+	}
+}
+
+// contentSearchRepo answers SearchFileContents with fixed rows, since the
+// search itself runs in the database.
+type contentSearchRepo struct {
+	*memdb.DB
+	rows []sqlc.SearchFileContentsRow
+	got  *sqlc.SearchFileContentsParams
+}
+
+func (r *contentSearchRepo) SearchFileContents(ctx context.Context, arg sqlc.SearchFileContentsParams) ([]sqlc.SearchFileContentsRow, error) {
+	r.got = &arg
+	return r.rows, nil
+}
+
+func TestSearchFileContents(t *testing.T) {
+	row := sqlc.SearchFileContentsRow{
+		ID:         uuid.New(),
+		Filename:   "page.md",
+		Size:       42,
+		PathIds:    []uuid.UUID{},
+		PathNames:  []string{},
+		Rank:       0.5,
+		Snippet:    `use <script> for the <mark>budget</mark> & more`,
+		TotalCount: 1,
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantSearch  string
+		wantSnippet string
+	}{
+		{
+			name:        "escapes all but the marks",
+			query:       "q=+budget+&limit=5",
+			wantStatus:  http.StatusOK,
+			wantSearch:  "budget",
+			wantSnippet: "use &lt;script&gt; for the <mark>budget</mark> &amp; more",
+		},
+		{name: "missing term", query: "q=", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			userID, _ := env.createUser(t, "owner@example.com", 1<<20)
+			repo := &contentSearchRepo{DB: env.db, rows: []sqlc.SearchFileContentsRow{row}}
+			env.service = files.NewService(repo, env.db, env.db, env.store, nopAudit{}, "http://vault.test")
+
+			rec := httptest.NewRecorder()
+			newTestRouter(env, userID).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/search/content?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", rec.Code, rec.Body, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if repo.got != nil {
+					t.Errorf("searched with %+v, want no search", *repo.got)
+				}
+				return
+			}
+
+			want := sqlc.SearchFileContentsParams{UserID: userID, Search: tt.wantSearch, Limit: 5}
+			if *repo.got != want {
+				t.Errorf("searched with %+v, want %+v", *repo.got, want)
+			}
+			var res files.ContentSearchResponse
+			if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+			if res.TotalCount != 1 || len(res.Data) != 1 || res.Data[0].ID != row.ID {
+				t.Fatalf("response = %+v, want the one result", res)
+			}
+			if got := res.Data[0].Snippet; got != tt.wantSnippet {
+				t.Errorf("snippet = %q, want %q", got, tt.wantSnippet)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package files
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/access"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CopyFile copies a file into a folder of the current user, or to their root
+// if TargetFolderID is nil. The copy points at the blob of the original, so
+// nothing is written to storage, but its size is charged to the user's quota
+// like any other file. Only the current version is copied.
+// Requires read access to the file.
+func (s *Service) CopyFile(ctx context.Context, fileID uuid.UUID, req CopyFileRequest) (FileResponse, error) {
+	file, userID, err := s.authorizeFile(ctx, fileID, access.Read)
+	if err != nil {
+		return FileResponse{}, err
+	}
+	if err := s.checkUploadFolder(ctx, userID, req.TargetFolderID); err != nil {
+		return FileResponse{}, err
+	}
+
+	filename := req.Filename
+	if filename == "" {
+		filename = file.Filename
+	}
+	copied, err := s.copyFile(ctx, file, userID, userID, req.TargetFolderID, filename)
+	if err != nil {
+		return FileResponse{}, err
+	}
+	return newFileResponse(copied, userID), nil
+}
+
+// SendFileCopy places a copy of a file at the root of another user, who owns
+// the copy and is charged for it. Unlike a share, the recipient keeps the copy
+// when the original changes or is deleted.
+// Requires reshare access to the file, and the recipient must share a file or
+// folder with the current user, so nobody fills the quota of a stranger.
+func (s *Service) SendFileCopy(ctx context.Context, fileID uuid.UUID, req SendCopyRequest) (FileResponse, error) {
+	file, userID, err := s.authorizeFile(ctx, fileID, access.Reshare)
+	if err != nil {
+		return FileResponse{}, err
+	}
+	if req.UserID == userID {
+		return FileResponse{}, apierror.NewBadRequestError("A copy can't be sent to yourself")
+	}
+	if _, err := s.userRepo.GetUserByID(ctx, req.UserID); err != nil {
+		return FileResponse{}, apierror.NewNotFoundError("User")
+	}
+	// The recipient is charged for the copy, so only users who chose to share with the sender accept copies
+	shares, err := s.userRepo.UserSharesWith(ctx, sqlc.UserSharesWithParams{OwnerID: req.UserID, SharedWith: userID})
+	if err != nil {
+		log.Printf("Failed to check shares of user %d with user %d: %v", req.UserID, userID, err)
+		return FileResponse{}, apierror.NewInternalServerError("Failed to send copy")
+	}
+	if !shares {
+		return FileResponse{}, apierror.New(http.StatusForbidden, "Copies can only be sent to users who share something with you")
+	}
+
+	copied, err := s.copyFile(ctx, file, userID, req.UserID, nil, file.Filename)
+	if err != nil {
+		return FileResponse{}, err
+	}
+	return newFileResponse(copied, userID), nil
+}
+
+// copyFile inserts a files row for ownerID pointing at the blob of file, after
+// checking that it fits into the owner's remaining quota. The insert trigger
+// increments the blob refcount and the owner's storage usage.
+func (s *Service) copyFile(ctx context.Context, file sqlc.File, userID, ownerID int64, folderID *uuid.UUID, filename string) (sqlc.File, error) {
+	remaining, err := s.remainingQuota(ctx, ownerID)
+	if err != nil {
+		return sqlc.File{}, err
+	}
+	if file.Size > remaining {
+		return sqlc.File{}, apierror.New(http.StatusRequestEntityTooLarge, "Storage quota exceeded")
+	}
+
+	params := sqlc.CreateFileParams{
+		OwnerID:      ownerID,
+		BlobID:       file.BlobID,
+		Filename:     filename,
+		DeclaredMime: file.DeclaredMime,
+		Size:         file.Size,
+	}
+	if folderID != nil {
+		params.FolderID = pgtype.UUID{Bytes: *folderID, Valid: true}
+	}
+	copied, err := s.repo.CreateFile(ctx, params)
+	if err != nil {
+		log.Printf("Failed to copy file %s for user %d: %v", file.ID, ownerID, err)
+		return sqlc.File{}, apierror.NewInternalServerError("Failed to copy file")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "FILE_COPIED",
+		TargetID: file.ID,
+		Details: map[string]interface{}{
+			"filename":  copied.Filename,
+			"copy_id":   copied.ID.String(),
+			"folder_id": folderIDDetail(copied.FolderID),
+			"owner_id":  ownerID,
+			"size":      copied.Size,
+		},
+	})
+	return copied, nil
+}
@@ -0,0 +1,117 @@
+package files_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+)
+
+// TestCopyFile copies a file shared with a reader and a resharer. Copies point
+// at the blob of the original and are charged to the user who owns them.
+func TestCopyFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		as         string // owner, reader or resharer
+		send       bool   // send the copy to the recipient instead
+		recipient  int64  // overrides the recipient, 0 for the default one
+		unshared   bool   // the recipient shares nothing with the sender
+		quota      int64  // quota of the user the copy is charged to
+		wantStatus int
+	}{
+		{name: "owner copies", as: "owner", quota: 1 << 20},
+		{name: "reader copies into own space", as: "reader", quota: 1 << 20},
+		{name: "copy over quota", as: "reader", quota: 7, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "resharer sends a copy", as: "resharer", send: true, quota: 1 << 20},
+		{name: "reader sends a copy", as: "reader", send: true, quota: 1 << 20, wantStatus: http.StatusForbidden},
+		{name: "send to a user who shares nothing", as: "owner", send: true, unshared: true, quota: 1 << 20, wantStatus: http.StatusForbidden},
+		{name: "send to a missing user", as: "owner", send: true, recipient: 999, quota: 1 << 20, wantStatus: http.StatusNotFound},
+		{name: "send over quota", as: "owner", send: true, quota: 7, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			// the user the copy is charged to gets the quota of the test case
+			quotas := map[string]int64{"owner": 1 << 20, "reader": 1 << 20, "resharer": 1 << 20, "recipient": 1 << 20}
+			if tt.send {
+				quotas["recipient"] = tt.quota
+			} else {
+				quotas[tt.as] = tt.quota
+			}
+			ownerID, ownerCtx := env.createUser(t, "owner@example.com", quotas["owner"])
+			readerID, readerCtx := env.createUser(t, "reader@example.com", quotas["reader"])
+			resharerID, resharerCtx := env.createUser(t, "resharer@example.com", quotas["resharer"])
+			recipientID, _ := env.createUser(t, "recipient@example.com", quotas["recipient"])
+
+			fileID, err := upload(ownerCtx, env.service, "file.txt", "contents")
+			if err != nil {
+				t.Fatalf("UploadFile: %v", err)
+			}
+			if err := env.service.UpdateFileShares(ownerCtx, files.UpdateFileSharesRequest{
+				FileID: fileID,
+				Shares: []files.Share{{UserID: readerID, Permission: "read"}, {UserID: resharerID, Permission: "reshare"}},
+			}); err != nil {
+				t.Fatalf("seeding shares: %v", err)
+			}
+
+			ctx, chargedID := ownerCtx, ownerID
+			switch tt.as {
+			case "reader":
+				ctx, chargedID = readerCtx, readerID
+			case "resharer":
+				ctx, chargedID = resharerCtx, resharerID
+			}
+			if tt.send && !tt.unshared {
+				// only users who share something with the sender accept copies
+				inbox, err := env.db.CreateFolder(context.Background(), sqlc.CreateFolderParams{Name: "inbox", OwnerID: recipientID})
+				if err == nil {
+					err = env.db.ReplaceFolderShares(context.Background(), sqlc.ReplaceFolderSharesParams{FolderID: inbox.ID, UserIds: []int64{chargedID}, Permissions: []string{"read"}})
+				}
+				if err != nil {
+					t.Fatalf("seeding the share of the recipient: %v", err)
+				}
+			}
+			if tt.recipient != 0 {
+				recipientID = tt.recipient
+			}
+
+			var copied files.FileResponse
+			if tt.send {
+				copied, err = env.service.SendFileCopy(ctx, fileID, files.SendCopyRequest{UserID: recipientID})
+				chargedID = recipientID
+			} else {
+				copied, err = env.service.CopyFile(ctx, fileID, files.CopyFileRequest{Filename: "copy.txt"})
+			}
+			if got := statusOf(err); got != tt.wantStatus || (tt.wantStatus == 0 && err != nil) {
+				t.Fatalf("copy error = %v, want status %d", err, tt.wantStatus)
+			}
+			if tt.wantStatus != 0 {
+				if got := len(env.db.Files()); got != 1 {
+					t.Errorf("got %d files after a failed copy, want 1", got)
+				}
+				return
+			}
+
+			record, err := env.db.GetFileByUUID(context.Background(), copied.ID)
+			if err != nil {
+				t.Fatalf("GetFileByUUID(copy): %v", err)
+			}
+			if record.OwnerID != chargedID || record.FolderID.Valid {
+				t.Errorf("copy is owned by %d in folder %v, want user %d at the root", record.OwnerID, record.FolderID, chargedID)
+			}
+			if blobs := env.db.Blobs(); len(blobs) != 1 || blobs[0].Refcount != 2 || len(env.store.Keys()) != 1 {
+				t.Errorf("blobs = %+v, want one stored blob referenced twice", blobs)
+			}
+			want := int64(len("contents"))
+			if chargedID == ownerID {
+				want *= 2
+			}
+			if got := env.usedStorage(t, chargedID); got != want {
+				t.Errorf("storage used by user %d = %d, want %d", chargedID, got, want)
+			}
+		})
+	}
+}
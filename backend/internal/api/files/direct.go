@@ -0,0 +1,229 @@
+package files
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/util"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// directUploadExpiry is how long a presigned upload URL stays valid.
+	directUploadExpiry = time.Hour
+	// maxDirectUploadSize is the largest object that can be sent with a single presigned PUT.
+	// Larger files should use the resumable upload endpoints.
+	maxDirectUploadSize = 5 << 30
+)
+
+// InitiateDirectUpload prepares an upload that the client sends straight to the storage
+// backend. It checks the declared size against the user's quota, records the pending
+// upload and returns a presigned PUT URL for a staging key.
+func (s *Service) InitiateDirectUpload(ctx context.Context, req InitiateDirectUploadRequest) (InitiateDirectUploadResponse, error) {
+	ownerID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return InitiateDirectUploadResponse{}, apierror.NewUnauthorizedError()
+	}
+
+	if req.Filename == "" {
+		return InitiateDirectUploadResponse{}, apierror.NewBadRequestError("Filename cannot be empty")
+	}
+	if req.Size < 0 {
+		return InitiateDirectUploadResponse{}, apierror.NewBadRequestError("Invalid size")
+	}
+	if req.Size > maxDirectUploadSize {
+		return InitiateDirectUploadResponse{}, apierror.NewBadRequestError("File too large for a direct upload, use a resumable upload instead")
+	}
+	sha := strings.ToLower(req.SHA256)
+	if checksum, err := hex.DecodeString(sha); err != nil || len(checksum) != 32 {
+		return InitiateDirectUploadResponse{}, apierror.NewBadRequestError("Invalid sha256")
+	}
+	if err := s.checkUploadFolder(ctx, ownerID, req.FolderID); err != nil {
+		return InitiateDirectUploadResponse{}, err
+	}
+
+	remaining, err := s.remainingQuota(ctx, ownerID)
+	if err != nil {
+		return InitiateDirectUploadResponse{}, err
+	}
+	if req.Size > remaining {
+		return InitiateDirectUploadResponse{}, apierror.New(http.StatusRequestEntityTooLarge, "Storage quota exceeded")
+	}
+
+	stagingPath := fmt.Sprintf("staging/%s", uuid.New())
+	url, headers, err := s.storage.PresignUpload(ctx, stagingPath, sha, req.Size, directUploadExpiry)
+	if err != nil {
+		log.Printf("error while presigning upload: %v", err)
+		return InitiateDirectUploadResponse{}, apierror.NewInternalServerError("Failed to prepare upload")
+	}
+
+	params := sqlc.CreateDirectUploadParams{
+		OwnerID:      ownerID,
+		Filename:     req.Filename,
+		DeclaredMime: util.NewText(req.ContentType),
+		Size:         req.Size,
+		Sha256:       sha,
+		StagingPath:  stagingPath,
+		ExpiresAt:    pgtype.Timestamptz{Time: time.Now().Add(directUploadExpiry), Valid: true},
+	}
+	if req.FolderID != nil {
+		params.FolderID = pgtype.UUID{Bytes: *req.FolderID, Valid: true}
+	}
+
+	upload, err := s.repo.CreateDirectUpload(ctx, params)
+	if err != nil {
+		return InitiateDirectUploadResponse{}, apierror.NewInternalServerError("Failed to prepare upload")
+	}
+
+	return InitiateDirectUploadResponse{
+		UploadID:  upload.ID,
+		URL:       url,
+		Method:    http.MethodPut,
+		Headers:   headers,
+		ExpiresAt: upload.ExpiresAt.Time,
+	}, nil
+}
+
+// CompleteDirectUpload verifies that the staged object matches the size and SHA-256
+// declared when the upload was initiated, then dedups it against existing blobs and
+// creates the file record. Once the object has been received the pending upload is
+// claimed, so that concurrent completions cannot both create a file; from then on
+// an object that fails verification is deleted.
+func (s *Service) CompleteDirectUpload(ctx context.Context, uploadID uuid.UUID) (sqlc.File, error) {
+	upload, err := s.getDirectUpload(ctx, uploadID)
+	if err != nil {
+		return sqlc.File{}, err
+	}
+
+	info, err := s.storage.StatBlob(ctx, upload.StagingPath)
+	if err != nil {
+		return sqlc.File{}, apierror.NewBadRequestError("Upload has not been received")
+	}
+	if upload, err = s.claimDirectUpload(ctx, uploadID); err != nil {
+		return sqlc.File{}, err
+	}
+
+	if info.Size != upload.Size {
+		s.discardTempBlob(ctx, upload.StagingPath)
+		return sqlc.File{}, apierror.NewBadRequestError("Uploaded size does not match the declared size")
+	}
+
+	// Fall back to hashing the staged object if the backend did not verify a checksum
+	sha := info.SHA256
+	if sha == "" {
+		obj, err := s.storage.GetBlob(ctx, upload.StagingPath)
+		if err != nil {
+			s.discardTempBlob(ctx, upload.StagingPath)
+			return sqlc.File{}, apierror.NewInternalServerError("Failed to read upload")
+		}
+		hr := newHashingReader(obj)
+		_, err = io.Copy(io.Discard, hr)
+		obj.Close()
+		if err != nil {
+			s.discardTempBlob(ctx, upload.StagingPath)
+			return sqlc.File{}, apierror.NewInternalServerError("Failed to read upload")
+		}
+		sha = hr.Sum()
+	}
+	if sha != upload.Sha256 {
+		s.discardTempBlob(ctx, upload.StagingPath)
+		return sqlc.File{}, apierror.NewBadRequestError("Uploaded content does not match the declared sha256")
+	}
+
+	// quota was checked at initiation, but other uploads may have completed since
+	remaining, err := s.remainingQuota(ctx, upload.OwnerID)
+	if err != nil {
+		s.discardTempBlob(ctx, upload.StagingPath)
+		return sqlc.File{}, err
+	}
+	if upload.Size > remaining {
+		s.discardTempBlob(ctx, upload.StagingPath)
+		return sqlc.File{}, apierror.New(http.StatusRequestEntityTooLarge, "Storage quota exceeded")
+	}
+
+	sniffed, err := s.sniffObject(ctx, upload.StagingPath)
+	if err != nil {
+		s.discardTempBlob(ctx, upload.StagingPath)
+		return sqlc.File{}, apierror.NewInternalServerError("Failed to read upload")
+	}
+
+	blob, err := s.finalizeBlob(ctx, upload.StagingPath, upload.Sha256, upload.Size, verifyMIME(upload.DeclaredMime.String, sniffed))
+	if err != nil {
+		return sqlc.File{}, err
+	}
+
+	return s.createFileRecord(ctx, upload.OwnerID, blob, upload.Filename, upload.DeclaredMime.String, util.ToUUIDPtr(upload.FolderID))
+}
+
+// AbortDirectUpload cancels a pending direct upload, deleting anything already staged.
+func (s *Service) AbortDirectUpload(ctx context.Context, uploadID uuid.UUID) error {
+	upload, err := s.claimDirectUpload(ctx, uploadID)
+	if err != nil {
+		return err
+	}
+	s.discardTempBlob(ctx, upload.StagingPath)
+	return nil
+}
+
+// getDirectUpload returns an unexpired pending direct upload owned by the authenticated user.
+func (s *Service) getDirectUpload(ctx context.Context, uploadID uuid.UUID) (sqlc.DirectUpload, error) {
+	ownerID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return sqlc.DirectUpload{}, apierror.NewUnauthorizedError()
+	}
+
+	upload, err := s.repo.GetDirectUpload(ctx, uploadID)
+	if err != nil || upload.ClaimedAt.Valid || !upload.ExpiresAt.Time.After(time.Now()) {
+		return sqlc.DirectUpload{}, apierror.NewNotFoundError("Upload")
+	}
+	if upload.OwnerID != ownerID {
+		return sqlc.DirectUpload{}, apierror.NewForbiddenError()
+	}
+	return upload, nil
+}
+
+// claimDirectUpload claims a pending direct upload of the authenticated user and
+// returns it. Only one caller can claim an upload, others get a not found error.
+// The claimed upload is kept until it is purged, as its presigned URL may still
+// be used to PUT the staged object again.
+func (s *Service) claimDirectUpload(ctx context.Context, uploadID uuid.UUID) (sqlc.DirectUpload, error) {
+	upload, err := s.getDirectUpload(ctx, uploadID)
+	if err != nil {
+		return sqlc.DirectUpload{}, err
+	}
+
+	upload, err = s.repo.ClaimDirectUpload(ctx, sqlc.ClaimDirectUploadParams{ID: upload.ID, OwnerID: upload.OwnerID})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return sqlc.DirectUpload{}, apierror.NewNotFoundError("Upload")
+		}
+		return sqlc.DirectUpload{}, apierror.NewInternalServerError("Failed to claim upload")
+	}
+	return upload, nil
+}
+
+// purgeExpiredDirectUploads removes the expired direct uploads, completed or not,
+// deleting whatever is left at their staging keys. Uploads are kept for one more
+// expiry period, so that a PUT started just before its URL expired has landed by then.
+func (s *Service) purgeExpiredDirectUploads(ctx context.Context) (int, error) {
+	expiredBefore := pgtype.Timestamptz{Time: time.Now().Add(-directUploadExpiry), Valid: true}
+	uploads, err := s.repo.DeleteExpiredDirectUploads(ctx, expiredBefore)
+	if err != nil {
+		return 0, err
+	}
+	for _, upload := range uploads {
+		s.discardTempBlob(ctx, upload.StagingPath)
+	}
+	return len(uploads), nil
+}
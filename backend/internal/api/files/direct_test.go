@@ -0,0 +1,140 @@
+package files_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
+	"github.com/google/uuid"
+)
+
+// initiateDirectUpload starts a direct upload of content and returns its ID and staging key.
+func (e *testEnv) initiateDirectUpload(t *testing.T, ctx context.Context, content string) (uuid.UUID, string) {
+	t.Helper()
+	sum := sha256.Sum256([]byte(content))
+	resp, err := e.service.InitiateDirectUpload(ctx, files.InitiateDirectUploadRequest{
+		Filename:    "note.txt",
+		ContentType: "text/plain",
+		Size:        int64(len(content)),
+		SHA256:      hex.EncodeToString(sum[:]),
+	})
+	if err != nil {
+		t.Fatalf("InitiateDirectUpload: %v", err)
+	}
+	upload, err := e.db.GetDirectUpload(context.Background(), resp.UploadID)
+	if err != nil {
+		t.Fatalf("GetDirectUpload: %v", err)
+	}
+	return resp.UploadID, upload.StagingPath
+}
+
+// stage stores content at key, as the client's presigned PUT would.
+func (e *testEnv) stage(t *testing.T, key, content string) {
+	t.Helper()
+	if _, err := e.store.UploadBlob(context.Background(), strings.NewReader(content), key, int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("UploadBlob: %v", err)
+	}
+}
+
+func TestCompleteDirectUploadConcurrent(t *testing.T) {
+	const completions = 4
+	env := newTestEnv(t)
+	_, ctx := env.createUser(t, "alice@example.com", 1<<20)
+	uploadID, stagingPath := env.initiateDirectUpload(t, ctx, "hello")
+	env.stage(t, stagingPath, "hello")
+
+	errs := make(chan error, completions)
+	for range completions {
+		go func() {
+			_, err := env.service.CompleteDirectUpload(ctx, uploadID)
+			errs <- err
+		}()
+	}
+	var completed int
+	for range completions {
+		err := <-errs
+		switch {
+		case err == nil:
+			completed++
+		case statusOf(err) == http.StatusNotFound, statusOf(err) == http.StatusBadRequest:
+			// lost the claim, or the winner had already moved the staged object
+		default:
+			t.Fatalf("CompleteDirectUpload: %v", err)
+		}
+	}
+	if completed != 1 {
+		t.Errorf("%d completions succeeded, want 1", completed)
+	}
+	if got := len(env.db.Files()); got != 1 {
+		t.Errorf("got %d file records, want 1", got)
+	}
+}
+
+func TestDirectUploadExpiry(t *testing.T) {
+	env := newTestEnv(t)
+	_, ctx := env.createUser(t, "alice@example.com", 1<<20)
+	uploadID, stagingPath := env.initiateDirectUpload(t, ctx, "hello")
+	env.stage(t, stagingPath, "hello")
+
+	// expired half an hour ago: it can no longer be completed, but a PUT
+	// started before the URL expired may still be in flight
+	env.db.ExpireDirectUploads(90 * time.Minute)
+	if _, err := env.service.CompleteDirectUpload(ctx, uploadID); statusOf(err) != http.StatusNotFound {
+		t.Errorf("CompleteDirectUpload after expiry = %v, want status %d", err, http.StatusNotFound)
+	}
+	if purged, err := env.service.PurgeExpiredUploads(context.Background()); err != nil || purged != 0 {
+		t.Errorf("PurgeExpiredUploads = %d, %v; want 0 within the grace period", purged, err)
+	}
+	if _, ok := env.store.Object(stagingPath); !ok {
+		t.Error("staged object was deleted within the grace period")
+	}
+
+	env.db.ExpireDirectUploads(time.Hour)
+	if purged, err := env.service.PurgeExpiredUploads(context.Background()); err != nil || purged != 1 {
+		t.Errorf("PurgeExpiredUploads = %d, %v; want 1", purged, err)
+	}
+	if _, ok := env.store.Object(stagingPath); ok {
+		t.Error("staged object of the expired upload was not deleted")
+	}
+	if got := len(env.db.Files()); got != 0 {
+		t.Errorf("got %d file records, want 0", got)
+	}
+}
+
+// TestDirectUploadPutAfterCompletion puts the object again through the
+// presigned URL of a completed upload, which the purge must still clean up.
+func TestDirectUploadPutAfterCompletion(t *testing.T) {
+	env := newTestEnv(t)
+	_, ctx := env.createUser(t, "alice@example.com", 1<<20)
+	uploadID, stagingPath := env.initiateDirectUpload(t, ctx, "hello")
+	env.stage(t, stagingPath, "hello")
+	file, err := env.service.CompleteDirectUpload(ctx, uploadID)
+	if err != nil {
+		t.Fatalf("CompleteDirectUpload: %v", err)
+	}
+
+	env.stage(t, stagingPath, "hello")
+	if _, err := env.service.CompleteDirectUpload(ctx, uploadID); statusOf(err) != http.StatusNotFound {
+		t.Errorf("second CompleteDirectUpload = %v, want status %d", err, http.StatusNotFound)
+	}
+
+	env.db.ExpireDirectUploads(3 * time.Hour)
+	if purged, err := env.service.PurgeExpiredUploads(context.Background()); err != nil || purged != 1 {
+		t.Errorf("PurgeExpiredUploads = %d, %v; want 1", purged, err)
+	}
+	if _, ok := env.store.Object(stagingPath); ok {
+		t.Error("object put after completion was not deleted")
+	}
+	blob, err := env.db.GetBlobByID(context.Background(), file.BlobID)
+	if err != nil {
+		t.Fatalf("GetBlobByID: %v", err)
+	}
+	if data, _ := env.store.Object(blob.StoragePath); string(data) != "hello" {
+		t.Errorf("content of the completed upload = %q, want %q", data, "hello")
+	}
+}
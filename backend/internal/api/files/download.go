@@ -0,0 +1,105 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/storage"
+)
+
+// Download describes a file the user is allowed to read.
+// Content is opened lazily, nothing is fetched from storage until it is read.
+type Download struct {
+	File    sqlc.File
+	Blob    sqlc.Blob
+	Content io.ReadSeekCloser
+}
+
+// ETag returns a strong entity tag derived from the blob's SHA-256.
+// Blobs are content addressed and never modified, so the tag is stable.
+func (d *Download) ETag() string {
+	return `"` + d.Blob.Sha256 + `"`
+}
+
+// ModTime returns the time the file was uploaded, used for Last-Modified.
+func (d *Download) ModTime() time.Time {
+	return d.File.UploadedAt.Time
+}
+
+// blobReadSeeker is an io.ReadSeekCloser over a stored object.
+// Seeking is free; the next Read opens a range request starting at the new
+// position, so serving byte ranges never downloads the skipped parts.
+type blobReadSeeker struct {
+	ctx     context.Context
+	storage storage.Storage
+	path    string
+	size    int64
+
+	pos     int64         // position reported to the caller
+	body    io.ReadCloser // open range reader, or nil
+	bodyPos int64         // position of body within the object
+}
+
+func newBlobReadSeeker(ctx context.Context, store storage.Storage, blob sqlc.Blob) *blobReadSeeker {
+	return &blobReadSeeker{
+		ctx:     ctx,
+		storage: store,
+		path:    blob.StoragePath,
+		size:    blob.Size,
+	}
+}
+
+func (b *blobReadSeeker) Read(p []byte) (int, error) {
+	if b.pos >= b.size {
+		return 0, io.EOF
+	}
+	if b.body == nil || b.bodyPos != b.pos {
+		b.closeBody()
+		body, err := b.storage.GetBlobRange(b.ctx, b.path, b.pos, -1)
+		if err != nil {
+			return 0, err
+		}
+		b.body, b.bodyPos = body, b.pos
+	}
+
+	n, err := b.body.Read(p)
+	b.pos += int64(n)
+	b.bodyPos += int64(n)
+	if err == io.EOF && b.pos < b.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (b *blobReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += b.pos
+	case io.SeekEnd:
+		offset += b.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	b.pos = offset
+	return offset, nil
+}
+
+func (b *blobReadSeeker) Close() error {
+	return b.closeBody()
+}
+
+func (b *blobReadSeeker) closeBody() error {
+	if b.body == nil {
+		return nil
+	}
+	err := b.body.Close()
+	b.body = nil
+	return err
+}
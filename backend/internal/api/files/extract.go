@@ -0,0 +1,444 @@
+package files
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/access"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/storage"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// maxExtractEntries is the largest number of entries an archive may have to be extracted.
+	maxExtractEntries = 10000
+	// maxExtractedSize is the largest total size of the files in an extracted archive.
+	maxExtractedSize = 10 << 30
+	// maxCompressionRatio is the largest ratio of extracted to compressed bytes
+	// accepted beyond the first compressionRatioSlack bytes. Ordinary files stay
+	// far below it, zip bombs exceed it by orders of magnitude.
+	maxCompressionRatio   = 100
+	compressionRatioSlack = 1 << 20
+)
+
+// errCompressionRatio is returned while reading an archive that expands
+// suspiciously far, before the expanded data is stored anywhere.
+var errCompressionRatio = errors.New("archive exceeds the maximum compression ratio")
+
+// ExtractArchive unpacks a ZIP or tar.gz file into a new folder named after
+// it, created inside req.TargetFolderID. Without a target the folder is created
+// next to the archive if the user owns it, and at the root otherwise. Requires
+// read access to the archive and ownership of the target folder.
+//
+// The whole archive is checked before anything is stored: it may not have more
+// than maxExtractEntries entries or expand to more than maxExtractedSize bytes or
+// the user's remaining quota, entries may not have absolute paths or ".."
+// components, and reading stops as soon as the data expands beyond
+// maxCompressionRatio. Every file then goes through the same deduplicating
+// upload path as UploadFile. Entries that are neither files nor directories,
+// such as symlinks, are skipped and reported as failed.
+func (s *Service) ExtractArchive(ctx context.Context, fileID uuid.UUID, req ExtractRequest) (ExtractResponse, error) {
+	file, userID, err := s.authorizeFile(ctx, fileID, access.Read)
+	if err != nil {
+		return ExtractResponse{}, err
+	}
+	blob, err := s.repo.GetBlobByID(ctx, file.BlobID)
+	if err != nil {
+		log.Printf("Failed to fetch blob %s of file %s: %v", file.BlobID, file.ID, err)
+		return ExtractResponse{}, apierror.NewInternalServerError("Unable to fetch file")
+	}
+	format := extractFormat(blob.MimeType.String)
+	if format == "" {
+		return ExtractResponse{}, apierror.NewBadRequestError("File is not a ZIP or tar.gz archive")
+	}
+
+	targetID := req.TargetFolderID
+	if targetID == nil && file.OwnerID == userID && file.FolderID.Valid {
+		folderID := uuid.UUID(file.FolderID.Bytes)
+		targetID = &folderID
+	}
+	if err := s.checkUploadFolder(ctx, userID, targetID); err != nil {
+		return ExtractResponse{}, err
+	}
+
+	archive, err := s.openExtractArchive(ctx, format, blob)
+	if err != nil {
+		return ExtractResponse{}, err
+	}
+	defer archive.Close()
+	size, err := archive.scan()
+	if err != nil {
+		return ExtractResponse{}, err
+	}
+	remaining, err := s.remainingQuota(ctx, userID)
+	if err != nil {
+		return ExtractResponse{}, err
+	}
+	if size > remaining {
+		return ExtractResponse{}, apierror.New(http.StatusRequestEntityTooLarge, "Storage quota exceeded")
+	}
+
+	params := sqlc.CreateFolderParams{Name: extractFolderName(file.Filename), OwnerID: userID}
+	if targetID != nil {
+		params.ParentFolderID = pgtype.UUID{Bytes: *targetID, Valid: true}
+	}
+	folder, err := s.repo.CreateFolder(ctx, params)
+	if err != nil {
+		log.Printf("Failed to create folder for archive %s: %v", file.ID, err)
+		return ExtractResponse{}, apierror.NewInternalServerError("Failed to create folder")
+	}
+
+	response := ExtractResponse{FolderID: folder.ID, Results: []UploadResult{}}
+	failed := 0
+	err = archive.extract(func(name string, kind entryKind, r io.Reader) {
+		result := s.extractEntry(ctx, userID, folder.ID, name, kind, r)
+		if result.Status == UploadFailed {
+			failed++
+		}
+		response.Results = append(response.Results, result)
+	})
+	if err != nil {
+		// the archive passed the scan, so storage failed or the archive is corrupt
+		log.Printf("Failed to extract archive %s: %v", file.ID, err)
+		return ExtractResponse{}, apierror.NewInternalServerError("Failed to extract archive")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "ARCHIVE_EXTRACTED",
+		TargetID: file.ID,
+		Details: map[string]interface{}{
+			"filename":  file.Filename,
+			"format":    format,
+			"folder_id": folder.ID.String(),
+			"entries":   len(response.Results),
+			"failed":    failed,
+			"size":      size,
+		},
+	})
+	return response, nil
+}
+
+// extractEntry stores one entry of an archive below the folder rootID.
+func (s *Service) extractEntry(ctx context.Context, userID int64, rootID uuid.UUID, name string, kind entryKind, r io.Reader) UploadResult {
+	switch kind {
+	case entryFile:
+		result, err := s.UploadFileToPath(ctx, r, name, mime.TypeByExtension(path.Ext(name)), &rootID)
+		if err != nil {
+			return failedUploadResult(name, err)
+		}
+		return result
+	case entryDir:
+		dirs, last, err := splitUploadPath(name)
+		if err != nil {
+			return failedUploadResult(name, err)
+		}
+		parentID := &rootID
+		for _, dir := range append(dirs, last) {
+			folder, err := findOrCreateFolder(ctx, s.repo, userID, parentID, dir)
+			if err != nil {
+				log.Printf("Failed to create folder %q for archive entry %s: %v", dir, name, err)
+				return failedUploadResult(name, apierror.NewInternalServerError("Failed to create folder"))
+			}
+			parentID = &folder.ID
+		}
+		return UploadResult{Path: name, Status: UploadCreated}
+	}
+	return UploadResult{Path: name, Status: UploadFailed, Error: "Unsupported entry type"}
+}
+
+// extractFormat returns the archive format of a blob with the given verified
+// content type, or an empty string if it can't be extracted.
+// Formats built on ZIP such as Office documents are stored with their own type
+// and are not extracted.
+func extractFormat(contentType string) string {
+	switch mediaType(contentType) {
+	case "application/zip":
+		return ArchiveZip
+	case "application/gzip", "application/x-gzip":
+		return ArchiveTarGz
+	}
+	return ""
+}
+
+// extractFolderName returns the name of the folder an archive is extracted to,
+// which is its filename without the archive extension.
+func extractFolderName(filename string) string {
+	name := filename
+	lower := strings.ToLower(filename)
+	for _, ext := range []string{".tar.gz", ".tgz", ".gz", ".zip"} {
+		if strings.HasSuffix(lower, ext) {
+			name = filename[:len(filename)-len(ext)]
+			break
+		}
+	}
+	if name == "" {
+		return "archive"
+	}
+	return name
+}
+
+// entryKind is the type of an archive entry.
+type entryKind int
+
+const (
+	entryFile entryKind = iota
+	entryDir
+	entryOther // symlinks, devices and other special files
+)
+
+// extractArchive reads the entries of an archive.
+// scan checks the whole archive against the extraction limits and returns the
+// total size of its files; extract then calls fn for every entry in order.
+type extractArchive interface {
+	io.Closer
+	scan() (int64, error)
+	extract(fn func(name string, kind entryKind, r io.Reader)) error
+}
+
+func (s *Service) openExtractArchive(ctx context.Context, format string, blob sqlc.Blob) (extractArchive, error) {
+	if format == ArchiveTarGz {
+		return &tarGzArchive{ctx: ctx, storage: s.storage, blob: blob}, nil
+	}
+	content := newBlobReadSeeker(ctx, s.storage, blob)
+	zr, err := zip.NewReader(sequentialReaderAt{content}, blob.Size)
+	if err != nil {
+		content.Close()
+		return nil, apierror.NewBadRequestError("File is not a valid ZIP archive")
+	}
+	return zipArchive{zr: zr, content: content}, nil
+}
+
+// zipArchive extracts a ZIP archive. Its central directory lists every entry
+// with its sizes, so it is scanned without decompressing anything; archive/zip
+// fails reading an entry that expands beyond its listed size.
+type zipArchive struct {
+	zr      *zip.Reader
+	content io.Closer
+}
+
+func (a zipArchive) Close() error {
+	return a.content.Close()
+}
+
+func (a zipArchive) scan() (int64, error) {
+	if len(a.zr.File) > maxExtractEntries {
+		return 0, apierror.NewBadRequestError(fmt.Sprintf("Archive has more than %d entries", maxExtractEntries))
+	}
+	var total uint64
+	for _, f := range a.zr.File {
+		if err := checkEntryPath(f.Name); err != nil {
+			return 0, err
+		}
+		if f.Method != zip.Store && f.Method != zip.Deflate {
+			return 0, apierror.NewBadRequestError("Archive uses an unsupported compression method")
+		}
+		total += f.UncompressedSize64
+		if total > maxExtractedSize {
+			return 0, apierror.NewBadRequestError("Archive is too large to extract")
+		}
+		if f.UncompressedSize64 > compressionRatioSlack+maxCompressionRatio*f.CompressedSize64 {
+			return 0, apierror.NewBadRequestError("Archive is too highly compressed to extract")
+		}
+	}
+	return int64(total), nil
+}
+
+func (a zipArchive) extract(fn func(name string, kind entryKind, r io.Reader)) error {
+	for _, f := range a.zr.File {
+		switch mode := f.Mode(); {
+		case mode.IsDir():
+			fn(f.Name, entryDir, nil)
+		case !mode.IsRegular():
+			fn(f.Name, entryOther, nil)
+		default:
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			fn(f.Name, entryFile, rc)
+			rc.Close()
+		}
+	}
+	return nil
+}
+
+// tarGzArchive extracts a tar.gz archive. Its entries are only known by
+// decompressing it, so it is read twice: once to check it and once to store it.
+type tarGzArchive struct {
+	ctx     context.Context
+	storage storage.Storage
+	blob    sqlc.Blob
+}
+
+// Close does nothing, each pass opens and closes the archive itself.
+func (a *tarGzArchive) Close() error {
+	return nil
+}
+
+// open returns a tar reader over the archive that fails once the data expands
+// beyond the maximum compression ratio.
+func (a *tarGzArchive) open() (*tar.Reader, io.Closer, error) {
+	body, err := a.storage.GetBlob(a.ctx, a.blob.StoragePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	compressed := &countingReader{r: body}
+	gz, err := gzip.NewReader(compressed)
+	if err != nil {
+		body.Close()
+		return nil, nil, apierror.NewBadRequestError("File is not a valid tar.gz archive")
+	}
+	return tar.NewReader(&ratioReader{r: gz, compressed: compressed}), body, nil
+}
+
+func (a *tarGzArchive) scan() (int64, error) {
+	tr, body, err := a.open()
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	var total int64
+	for entries := 0; ; entries++ {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return 0, invalidTarGz(err)
+		}
+		if entries == maxExtractEntries {
+			return 0, apierror.NewBadRequestError(fmt.Sprintf("Archive has more than %d entries", maxExtractEntries))
+		}
+		if err := checkEntryPath(header.Name); err != nil {
+			return 0, err
+		}
+		if header.Typeflag == tar.TypeReg {
+			total += header.Size
+			if total > maxExtractedSize {
+				return 0, apierror.NewBadRequestError("Archive is too large to extract")
+			}
+		}
+		// decompressing the entry exposes a gzip bomb before anything is stored
+		if _, err := io.Copy(io.Discard, tr); err != nil {
+			return 0, invalidTarGz(err)
+		}
+	}
+}
+
+func (a *tarGzArchive) extract(fn func(name string, kind entryKind, r io.Reader)) error {
+	tr, body, err := a.open()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeReg:
+			fn(header.Name, entryFile, tr)
+		case tar.TypeDir:
+			fn(header.Name, entryDir, nil)
+		case tar.TypeXGlobalHeader:
+			// PAX metadata, not an entry
+		default:
+			fn(header.Name, entryOther, nil)
+		}
+	}
+}
+
+// invalidTarGz turns an error reading a tar.gz archive into an API error.
+func invalidTarGz(err error) error {
+	if errors.Is(err, errCompressionRatio) {
+		return apierror.NewBadRequestError("Archive is too highly compressed to extract")
+	}
+	var apiErr *apierror.APIError
+	if errors.As(err, &apiErr) {
+		return err
+	}
+	return apierror.NewBadRequestError("File is not a valid tar.gz archive")
+}
+
+// checkEntryPath rejects archive entries with absolute paths or ".." components,
+// which would place files outside the extraction folder.
+func checkEntryPath(name string) error {
+	name = strings.ReplaceAll(name, `\`, "/")
+	if strings.HasPrefix(name, "/") || (len(name) >= 2 && name[1] == ':') {
+		return apierror.NewBadRequestError("Archive contains an absolute path: " + name)
+	}
+	for _, component := range strings.Split(name, "/") {
+		if component == ".." {
+			return apierror.NewBadRequestError("Archive contains an unsafe path: " + name)
+		}
+	}
+	return nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ratioReader reads decompressed data and fails with errCompressionRatio once
+// it exceeds maxCompressionRatio times the compressed bytes consumed so far.
+type ratioReader struct {
+	r          io.Reader
+	compressed *countingReader
+	n          int64
+}
+
+func (r *ratioReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	if r.n > compressionRatioSlack+maxCompressionRatio*r.compressed.n {
+		return n, errCompressionRatio
+	}
+	return n, err
+}
+
+// sequentialReaderAt adapts a blobReadSeeker to io.ReaderAt for archive/zip.
+// Reads that continue where the previous one ended reuse the open range
+// request, so the entries of a ZIP archive are streamed front to back.
+type sequentialReaderAt struct {
+	rs io.ReadSeeker
+}
+
+func (r sequentialReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if _, err := r.rs.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(r.rs, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
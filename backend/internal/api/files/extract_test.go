@@ -0,0 +1,171 @@
+package files_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
+)
+
+// testEntry is a file in a test archive; names ending in a slash are directories.
+type testEntry struct {
+	name    string
+	content string
+}
+
+func buildZip(t *testing.T, entries []testEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Deflate})
+		if err != nil {
+			t.Fatalf("zip CreateHeader(%s): %v", e.name, err)
+		}
+		w.Write([]byte(e.content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip Close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func buildTarGz(t *testing.T, entries []testEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.content)), Typeflag: tar.TypeReg}
+		if strings.HasSuffix(e.name, "/") {
+			header.Typeflag, header.Mode = tar.TypeDir, 0o755
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("tar WriteHeader(%s): %v", e.name, err)
+		}
+		tw.Write([]byte(e.content))
+	}
+	tw.Close()
+	gw.Close()
+	return buf.Bytes()
+}
+
+func TestExtractArchive(t *testing.T) {
+	valid := []testEntry{
+		{name: "docs/"},
+		{name: "docs/a.txt", content: "hello"},
+		{name: "docs/sub/b.txt", content: "hello"},
+		{name: "empty/"},
+		{name: "./top.txt", content: "top"},
+	}
+	bomb := []testEntry{{name: "zeros.bin", content: strings.Repeat("\x00", 4<<20)}}
+	many := make([]testEntry, 10001)
+	for i := range many {
+		many[i] = testEntry{name: fmt.Sprintf("f%d.txt", i)}
+	}
+
+	tests := []struct {
+		name       string
+		filename   string
+		archive    func(*testing.T, []testEntry) []byte
+		entries    []testEntry
+		quota      int64
+		wantStatus int
+		wantFiles  map[string]string // filename -> folder
+	}{
+		{
+			name: "zip", filename: "bundle.zip", archive: buildZip, entries: valid,
+			wantFiles: map[string]string{"a.txt": "docs", "b.txt": "sub", "top.txt": "bundle"},
+		},
+		{
+			name: "tar.gz", filename: "bundle.tar.gz", archive: buildTarGz, entries: valid,
+			wantFiles: map[string]string{"a.txt": "docs", "b.txt": "sub", "top.txt": "bundle"},
+		},
+		{name: "zip traversal", filename: "evil.zip", archive: buildZip, entries: []testEntry{{name: "ok.txt", content: "ok"}, {name: "../evil.txt", content: "x"}}, wantStatus: http.StatusBadRequest},
+		{name: "tar.gz absolute path", filename: "evil.tgz", archive: buildTarGz, entries: []testEntry{{name: "/etc/evil", content: "x"}}, wantStatus: http.StatusBadRequest},
+		{name: "zip bomb", filename: "bomb.zip", archive: buildZip, entries: bomb, wantStatus: http.StatusBadRequest},
+		{name: "gzip bomb", filename: "bomb.tar.gz", archive: buildTarGz, entries: bomb, wantStatus: http.StatusBadRequest},
+		{name: "too many entries", filename: "many.zip", archive: buildZip, entries: many, wantStatus: http.StatusBadRequest},
+		{name: "over quota", filename: "big.zip", archive: buildZip, entries: []testEntry{{name: "big.txt", content: strings.Repeat("ab", 200)}}, quota: 400, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			quota := tt.quota
+			if quota == 0 {
+				quota = 1 << 30
+			}
+			_, ctx := env.createUser(t, "owner@example.com", quota)
+			archive, err := env.service.UploadFile(ctx, bytes.NewReader(tt.archive(t, tt.entries)), tt.filename, "", nil)
+			if err != nil {
+				t.Fatalf("UploadFile: %v", err)
+			}
+
+			res, err := env.service.ExtractArchive(ctx, archive.ID, files.ExtractRequest{})
+			if tt.wantStatus != 0 {
+				if statusOf(err) != tt.wantStatus {
+					t.Fatalf("ExtractArchive error = %v, want %d", err, tt.wantStatus)
+				}
+				if len(env.db.Folders()) != 0 || len(env.db.Files()) != 1 {
+					t.Errorf("rejected archive left %d folders and %d files behind", len(env.db.Folders()), len(env.db.Files())-1)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractArchive: %v", err)
+			}
+
+			for _, result := range res.Results {
+				if result.Status == files.UploadFailed {
+					t.Errorf("entry %s failed: %s", result.Path, result.Error)
+				}
+			}
+			folders := map[[16]byte]string{}
+			for _, f := range env.db.Folders() {
+				folders[f.ID] = f.Name
+			}
+			if len(folders) != 4 {
+				t.Errorf("got folders %v, want bundle, docs, sub and empty", folders)
+			}
+			for _, f := range env.db.Files() {
+				if f.ID == archive.ID {
+					continue
+				}
+				if got := folders[f.FolderID.Bytes]; got != tt.wantFiles[f.Filename] {
+					t.Errorf("file %s is in folder %q, want %q", f.Filename, got, tt.wantFiles[f.Filename])
+				}
+			}
+			if got := len(env.db.Files()); got != len(tt.wantFiles)+1 {
+				t.Errorf("got %d files, want %d", got, len(tt.wantFiles)+1)
+			}
+			download, err := env.service.DownloadFile(context.WithoutCancel(ctx), res.Results[2].File.ID)
+			if err != nil {
+				t.Fatalf("DownloadFile: %v", err)
+			}
+			download.Content.Close()
+			if download.Blob.Size != int64(len("hello")) {
+				t.Errorf("extracted file has %d bytes, want %d", download.Blob.Size, len("hello"))
+			}
+		})
+	}
+}
+
+func TestExtractArchiveRejectsOtherFiles(t *testing.T) {
+	env := newTestEnv(t)
+	_, ctx := env.createUser(t, "owner@example.com", 1<<20)
+	fileID, err := upload(ctx, env.service, "notes.txt", "not an archive")
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	if _, err := env.service.ExtractArchive(ctx, fileID, files.ExtractRequest{}); statusOf(err) != http.StatusBadRequest {
+		t.Errorf("ExtractArchive error = %v, want 400", err)
+	}
+}
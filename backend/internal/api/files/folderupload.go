@@ -0,0 +1,148 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// UploadFileToPath uploads a file to relPath, a path such as "a/b/c.txt"
+// relative to folderID, or to the root if folderID is nil. The folders along
+// the path are looked up by name and the missing ones are created together
+// with the file record in one transaction, so a failed upload leaves no empty
+// folders behind. The returned UploadResult tells whether the contents were
+// stored or deduplicated against identical contents uploaded before.
+func (s *Service) UploadFileToPath(ctx context.Context, r io.Reader, relPath, contentType string, folderID *uuid.UUID) (UploadResult, error) {
+	ownerID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return UploadResult{}, apierror.NewUnauthorizedError()
+	}
+	dirs, filename, err := splitUploadPath(relPath)
+	if err != nil {
+		return UploadResult{}, err
+	}
+	if err := s.checkUploadFolder(ctx, ownerID, folderID); err != nil {
+		return UploadResult{}, err
+	}
+
+	blob, err := s.storeUpload(ctx, ownerID, r, contentType)
+	if err != nil {
+		return UploadResult{}, err
+	}
+	// a new blob is not referenced until its first file record is inserted
+	status := UploadCreated
+	if blob.Refcount > 0 {
+		status = UploadDeduplicated
+	}
+	committed := false
+	defer func() {
+		// runs after the rollback, a new blob the file record never referenced is removed again
+		if !committed && status == UploadCreated {
+			if err := s.releaseBlob(context.WithoutCancel(ctx), blob.ID); err != nil {
+				log.Printf("Failed to remove blob %s of failed upload %s: %v", blob.ID, relPath, err)
+			}
+		}
+	}()
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return UploadResult{}, apierror.NewInternalServerError("could not start transaction")
+	}
+	defer tx.Rollback(ctx) // rollback on error
+	qtx := s.repo.WithTx(tx)
+
+	parentID := folderID
+	for _, name := range dirs {
+		folder, err := findOrCreateFolder(ctx, qtx, ownerID, parentID, name)
+		if err != nil {
+			log.Printf("Failed to create folder %q for upload %s: %v", name, relPath, err)
+			return UploadResult{}, apierror.NewInternalServerError("Failed to create folder")
+		}
+		parentID = &folder.ID
+	}
+
+	file, err := insertFileRecord(ctx, qtx, ownerID, blob, filename, contentType, parentID)
+	if err != nil {
+		log.Printf("Failed to create file record for upload %s: %v", relPath, err)
+		return UploadResult{}, apierror.NewInternalServerError("Failed to create file record")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return UploadResult{}, apierror.NewInternalServerError("Failed to create file record")
+	}
+	committed = true
+
+	s.logUpload(ctx, file, blob)
+	response := newFileResponse(file, ownerID)
+	return UploadResult{Path: relPath, Status: status, File: &response}, nil
+}
+
+// failedUploadResult reports a failed file, with the message of err if it is an APIError.
+func failedUploadResult(path string, err error) UploadResult {
+	result := UploadResult{Path: path, Status: UploadFailed, Error: "Upload failed"}
+	var apiErr *apierror.APIError
+	if errors.As(err, &apiErr) {
+		result.Error = apiErr.Message
+	}
+	return result
+}
+
+// findOrCreateFolder returns the owner's folder with the given name inside
+// parentID, or at the root if parentID is nil, creating it if there is none.
+// Uploads creating the same folder at once end up with one folder, as
+// CreateFolderIfNotExists lets only one of them insert it.
+func findOrCreateFolder(ctx context.Context, repo Repository, ownerID int64, parentID *uuid.UUID, name string) (sqlc.Folder, error) {
+	var parent pgtype.UUID
+	if parentID != nil {
+		parent = pgtype.UUID{Bytes: *parentID, Valid: true}
+	}
+	byName := sqlc.GetFolderByNameParams{
+		OwnerID:        ownerID,
+		Name:           name,
+		ParentFolderID: parent,
+	}
+
+	folder, err := repo.GetFolderByName(ctx, byName)
+	if err != pgx.ErrNoRows {
+		return folder, err
+	}
+	folder, err = repo.CreateFolderIfNotExists(ctx, sqlc.CreateFolderIfNotExistsParams{
+		Name:           name,
+		OwnerID:        ownerID,
+		ParentFolderID: parent,
+	})
+	if err != pgx.ErrNoRows {
+		return folder, err
+	}
+	// another upload created it since the lookup
+	return repo.GetFolderByName(ctx, byName)
+}
+
+// splitUploadPath splits the relative path of an uploaded file into the names
+// of the folders leading to it and its filename. Backslashes are treated as
+// separators and empty and "." components are skipped. Paths containing ".."
+// are rejected, so an upload can't leave its target folder.
+func splitUploadPath(relPath string) ([]string, string, error) {
+	var names []string
+	for _, name := range strings.Split(strings.ReplaceAll(relPath, `\`, "/"), "/") {
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			return nil, "", apierror.NewBadRequestError("Invalid path " + relPath)
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, "", apierror.NewBadRequestError("Missing filename")
+	}
+	return names[:len(names)-1], names[len(names)-1], nil
+}
@@ -0,0 +1,166 @@
+package files_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/memdb"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestUploadFolder(t *testing.T) {
+	env := newTestEnv(t)
+	userID, _ := env.createUser(t, "owner@example.com", 100)
+	router := newTestRouter(env, userID)
+
+	parts := []struct {
+		path       string
+		content    string
+		wantStatus string
+	}{
+		{path: "photos/2024/a.txt", content: "same", wantStatus: files.UploadCreated},
+		{path: "photos/2024/b.txt", content: "same", wantStatus: files.UploadDeduplicated},
+		{path: `photos\notes.txt`, content: "notes", wantStatus: files.UploadCreated},
+		{path: "../escape.txt", content: "out", wantStatus: files.UploadFailed},
+		{path: "photos/big.bin", content: strings.Repeat("x", 200), wantStatus: files.UploadFailed},
+		{path: "photos/2024/c.txt", content: "after", wantStatus: files.UploadCreated},
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, p := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="files"; filename=%q`, p.path))
+		header.Set("Content-Type", "text/plain")
+		w, err := mw.CreatePart(header)
+		if err != nil {
+			t.Fatalf("CreatePart: %v", err)
+		}
+		w.Write([]byte(p.content))
+	}
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/files/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("status = %d, want 207: %s", rec.Code, rec.Body)
+	}
+	var res struct {
+		Results []files.UploadResult `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(res.Results) != len(parts) {
+		t.Fatalf("got %d results, want %d", len(res.Results), len(parts))
+	}
+	for i, p := range parts {
+		if got := res.Results[i]; got.Path != p.path || got.Status != p.wantStatus {
+			t.Errorf("result %d = %s %s (%s), want %s %s", i, got.Path, got.Status, got.Error, p.path, p.wantStatus)
+		}
+	}
+
+	folderNames := map[string]int{}
+	for _, f := range env.db.Folders() {
+		folderNames[f.Name]++
+	}
+	if len(folderNames) != 2 || folderNames["photos"] != 1 || folderNames["2024"] != 1 {
+		t.Errorf("folders = %v, want one photos and one 2024 folder", folderNames)
+	}
+	for _, f := range env.db.Files() {
+		folder, err := env.db.GetFolderByID(req.Context(), f.FolderID.Bytes)
+		if err != nil {
+			t.Fatalf("file %s is not in a folder: %v", f.Filename, err)
+		}
+		wantFolder := "2024"
+		if f.Filename == "notes.txt" {
+			wantFolder = "photos"
+		}
+		if folder.Name != wantFolder {
+			t.Errorf("file %s is in folder %s, want %s", f.Filename, folder.Name, wantFolder)
+		}
+	}
+}
+
+// TestUploadFolderConcurrently uploads files below the same new folders at
+// once, which must all end up in one folder per name.
+func TestUploadFolderConcurrently(t *testing.T) {
+	env := newTestEnv(t)
+	_, ctx := env.createUser(t, "owner@example.com", 1<<20)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path := fmt.Sprintf("photos/2024/%d.txt", i)
+			if _, err := env.service.UploadFileToPath(ctx, strings.NewReader(path), path, "text/plain", nil); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("UploadFileToPath: %v", err)
+	}
+
+	folderNames := map[string]int{}
+	for _, f := range env.db.Folders() {
+		folderNames[f.Name]++
+	}
+	if len(folderNames) != 2 || folderNames["photos"] != 1 || folderNames["2024"] != 1 {
+		t.Errorf("folders = %v, want one photos and one 2024 folder", folderNames)
+	}
+}
+
+// failingFolderRepo fails to create folders, as a database going away in the
+// middle of an upload would.
+type failingFolderRepo struct {
+	*memdb.DB
+}
+
+func (r failingFolderRepo) CreateFolderIfNotExists(ctx context.Context, arg sqlc.CreateFolderIfNotExistsParams) (sqlc.Folder, error) {
+	return sqlc.Folder{}, errors.New("connection lost")
+}
+
+func (r failingFolderRepo) WithTx(tx pgx.Tx) files.Repository {
+	return r
+}
+
+// TestUploadFolderRollback checks that an upload failing after its contents
+// were stored leaves no blob behind, unless other files use it.
+func TestUploadFolderRollback(t *testing.T) {
+	env := newTestEnv(t)
+	_, ctx := env.createUser(t, "owner@example.com", 1<<20)
+	if _, err := upload(ctx, env.service, "kept.txt", "kept"); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	service := files.NewService(failingFolderRepo{env.db}, env.db, env.db, env.store, nopAudit{}, "http://vault.test")
+
+	for _, content := range []string{"new", "kept"} {
+		if _, err := service.UploadFileToPath(ctx, strings.NewReader(content), "photos/"+content+".txt", "text/plain", nil); statusOf(err) != http.StatusInternalServerError {
+			t.Errorf("upload of %q error = %v, want status %d", content, err, http.StatusInternalServerError)
+		}
+	}
+	if blobs := env.db.Blobs(); len(blobs) != 1 || blobs[0].Refcount != 1 || len(env.store.Keys()) != 1 {
+		t.Errorf("blobs = %+v with %d objects, want only the blob of kept.txt", blobs, len(env.store.Keys()))
+	}
+	env.assertNoTempObjects(t)
+}
@@ -1,13 +1,18 @@
 package files
 
 import (
+	"bytes"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
@@ -30,67 +35,202 @@ func NewFileHandler(service *Service) *FileHandler {
 // RegisterRoutes registers all file-related HTTP routes on the given router.
 func (h *FileHandler) RegisterRoutes(r chi.Router) {
 	r.Post("/files/upload", apphandler.MakeHTTPHandler(h.Upload))
+	h.registerTusRoutes(r)
+	r.Post("/files/direct-uploads", apphandler.MakeHTTPHandler(h.InitiateDirectUpload))
+	r.Post("/files/direct-uploads/{id}/complete", apphandler.MakeHTTPHandler(h.CompleteDirectUpload))
+	r.Delete("/files/direct-uploads/{id}", apphandler.MakeHTTPHandler(h.AbortDirectUpload))
 
 	r.Get("/files", apphandler.MakeHTTPHandler(h.ListContents))
 	r.Get("/files/url/{id}", apphandler.MakeHTTPHandler(h.GetURL))
+	r.Get("/files/search", apphandler.MakeHTTPHandler(h.Search))
+	r.Get("/files/search/content", apphandler.MakeHTTPHandler(h.SearchFileContents))
+	r.Get("/files/archive", apphandler.MakeHTTPHandler(h.DownloadArchive))
+	r.Post("/files/archive", apphandler.MakeHTTPHandler(h.DownloadArchive))
+	r.Get("/files/tags", apphandler.MakeHTTPHandler(h.SuggestTags))
+	r.Patch("/files/tags", apphandler.MakeHTTPHandler(h.UpdateTags))
+	r.Patch("/files/metadata", apphandler.MakeHTTPHandler(h.UpdateMetadata))
 
 	r.Get("/files/{id}", apphandler.MakeHTTPHandler(h.DownloadFile))
+	r.Head("/files/{id}", apphandler.MakeHTTPHandler(h.DownloadFile))
 	r.Patch("/files/{id}", apphandler.MakeHTTPHandler(h.UpdateFilename))
+	r.Put("/files/{id}/content", apphandler.MakeHTTPHandler(h.ReplaceFileContent))
+	r.Get("/files/{id}/thumbnail", apphandler.MakeHTTPHandler(h.GetThumbnail))
+
+	r.Get("/files/{id}/versions", apphandler.MakeHTTPHandler(h.ListFileVersions))
+	r.Post("/files/{id}/versions", apphandler.MakeHTTPHandler(h.ReplaceFileContent))
+	r.Delete("/files/{id}/versions", apphandler.MakeHTTPHandler(h.PruneFileVersions))
+	r.Get("/files/{id}/versions/{version}", apphandler.MakeHTTPHandler(h.DownloadFileVersion))
+	r.Head("/files/{id}/versions/{version}", apphandler.MakeHTTPHandler(h.DownloadFileVersion))
+	r.Post("/files/{id}/versions/{version}/restore", apphandler.MakeHTTPHandler(h.RestoreFileVersion))
+	r.Delete("/files/{id}/versions/{version}", apphandler.MakeHTTPHandler(h.DeleteFileVersion))
 	r.Delete("/files/{id}", apphandler.MakeHTTPHandler(h.DeleteFile))
 	r.Patch("/files/{id}/move", apphandler.MakeHTTPHandler(h.MoveFile))
+	r.Post("/files/{id}/copy", apphandler.MakeHTTPHandler(h.CopyFile))
+	r.Post("/files/{id}/send", apphandler.MakeHTTPHandler(h.SendFileCopy))
+	r.Post("/files/{id}/extract", apphandler.MakeHTTPHandler(h.ExtractArchive))
 
 	r.Get("/files/{id}/share-info", apphandler.MakeHTTPHandler(h.GetShareInfo))
 	r.Put("/files/{id}/shares", apphandler.MakeHTTPHandler(h.UpdateFileShares))
+
+	r.Get("/files/{id}/public-link", apphandler.MakeHTTPHandler(h.GetPublicLink))
+	r.Post("/files/{id}/public-link", apphandler.MakeHTTPHandler(h.CreatePublicLink))
+	r.Post("/files/{id}/public-link/rotate", apphandler.MakeHTTPHandler(h.RotatePublicLink))
+	r.Delete("/files/{id}/public-link", apphandler.MakeHTTPHandler(h.RevokePublicLink))
+
+	r.Get("/trash", apphandler.MakeHTTPHandler(h.ListTrash))
+	r.Delete("/trash", apphandler.MakeHTTPHandler(h.EmptyTrash))
+	r.Post("/trash/files/{id}/restore", apphandler.MakeHTTPHandler(h.RestoreFile))
+	r.Delete("/trash/files/{id}", apphandler.MakeHTTPHandler(h.PurgeFile))
+	r.Post("/trash/folders/{id}/restore", apphandler.MakeHTTPHandler(h.RestoreFolder))
+	r.Delete("/trash/folders/{id}", apphandler.MakeHTTPHandler(h.PurgeFolder))
 }
 
-// Upload processes one or multiple files uploaded via
-// multipart/form-data and saves them to storage and database.
+// RegisterPublicRoutes registers the file routes that do not require authentication.
+func (h *FileHandler) RegisterPublicRoutes(r chi.Router) {
+	r.Get("/s/{token}", apphandler.MakeHTTPHandler(h.PublicDownload))
+	r.Head("/s/{token}", apphandler.MakeHTTPHandler(h.PublicDownload))
+}
+
+// Upload processes one or multiple files uploaded via multipart/form-data and
+// saves them to storage and database. The body is read part by part with a
+// multipart.Reader and every file is streamed straight to storage, so nothing is
+// buffered in memory or spilled to temporary files. The optional folder_id may be
+// given as a query parameter or as a form field preceding the file parts.
+// The filename of a part may be a relative path such as "a/b/c.txt", the
+// folders along it are created below the target folder as needed, so a
+// dropped directory keeps its structure.
+// A failed file does not stop the upload, the response lists the result of
+// every file and has status 207 Multi-Status if any of them failed.
 func (h *FileHandler) Upload(w http.ResponseWriter, r *http.Request) error {
-	err := r.ParseMultipartForm(10 << 20)
+	reader, err := r.MultipartReader()
 	if err != nil {
-		log.Printf("Error parsing multipart form: %v", err)
+		log.Printf("Error reading multipart body: %v", err)
 		return apierror.NewBadRequestError("Could not parse form")
 	}
 
-	log.Print("Parsed multipart form")
-
-	files := r.MultipartForm.File["files"]
-	if len(files) == 0 {
-		return apierror.NewBadRequestError("No files uploaded")
-	}
-
 	var folderID *uuid.UUID
-	folderIDStr := r.FormValue("folder_id")
-	if folderIDStr != "" {
+	if folderIDStr := r.URL.Query().Get("folder_id"); folderIDStr != "" {
 		if parsedUUID, err := uuid.Parse(folderIDStr); err == nil {
 			folderID = &parsedUUID
 		}
 	}
 
-	for _, header := range files {
-		log.Printf("Processing file: %s", header.Filename)
-		file, err := header.Open()
+	results := []UploadResult{}
+	failed := 0
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
-			log.Printf("Error opening file %s: %v", header.Filename, err)
-			return apierror.NewInternalServerError("Failed to process file")
+			log.Printf("Error reading multipart part: %v", err)
+			return apierror.NewBadRequestError("Could not parse form")
 		}
-		// Use defer inside the loop to ensure each file is closed
-		defer file.Close()
 
-		// Call UploadFile service for each individual file
-		_, err = h.service.UploadFile(r.Context(), file, header, folderID)
-		if err != nil {
-			log.Printf("Upload failed for file %s: %v", header.Filename, err)
-			return err
+		switch part.FormName() {
+		case "folder_id":
+			value, err := io.ReadAll(io.LimitReader(part, 64))
+			if err != nil {
+				return apierror.NewBadRequestError("Could not parse form")
+			}
+			if parsedUUID, err := uuid.Parse(string(value)); err == nil {
+				folderID = &parsedUUID
+			}
+
+		case "files":
+			relPath := partPath(part)
+			if relPath == "" {
+				continue
+			}
+			log.Printf("Processing file: %s", relPath)
+
+			result, err := h.service.UploadFileToPath(r.Context(), part, relPath, part.Header.Get("Content-Type"), folderID)
+			part.Close()
+			if err != nil {
+				log.Printf("Upload failed for file %s: %v", relPath, err)
+				result = failedUploadResult(relPath, err)
+				failed++
+			}
+			results = append(results, result)
 		}
 	}
 
-	log.Printf("Successfully uploaded %d files", len(files))
-	return util.WriteJSON(w, http.StatusOK, map[string]string{
-		"message": fmt.Sprintf("Successfully uploaded %d file(s)", len(files)),
+	if len(results) == 0 {
+		return apierror.NewBadRequestError("No files uploaded")
+	}
+
+	uploaded := len(results) - failed
+	log.Printf("Successfully uploaded %d of %d files", uploaded, len(results))
+	status := http.StatusOK
+	if failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	return util.WriteJSON(w, status, map[string]interface{}{
+		"message": fmt.Sprintf("Successfully uploaded %d of %d file(s)", uploaded, len(results)),
+		"results": results,
 	})
 }
 
+// partPath returns the filename of a multipart file part including its
+// directories. Part.FileName strips them, so the Content-Disposition header
+// is parsed directly.
+func partPath(part *multipart.Part) string {
+	_, params, err := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+	if err != nil {
+		return part.FileName()
+	}
+	return params["filename"]
+}
+
+// InitiateDirectUpload handles POST /files/direct-uploads.
+// It returns a presigned URL the client uploads the file contents to directly,
+// bypassing the API server.
+func (h *FileHandler) InitiateDirectUpload(w http.ResponseWriter, r *http.Request) error {
+	var req InitiateDirectUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierror.NewBadRequestError("Invalid request body")
+	}
+
+	res, err := h.service.InitiateDirectUpload(r.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return util.WriteJSON(w, http.StatusCreated, res)
+}
+
+// CompleteDirectUpload handles POST /files/direct-uploads/{id}/complete.
+// It verifies the uploaded object and creates the file record.
+func (h *FileHandler) CompleteDirectUpload(w http.ResponseWriter, r *http.Request) error {
+	uploadID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid upload ID")
+	}
+
+	file, err := h.service.CompleteDirectUpload(r.Context(), uploadID)
+	if err != nil {
+		return err
+	}
+
+	return util.WriteJSON(w, http.StatusCreated, newFileResponse(file, file.OwnerID))
+}
+
+// AbortDirectUpload handles DELETE /files/direct-uploads/{id}.
+// It cancels a pending direct upload and removes any staged content.
+func (h *FileHandler) AbortDirectUpload(w http.ResponseWriter, r *http.Request) error {
+	uploadID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid upload ID")
+	}
+
+	if err := h.service.AbortDirectUpload(r.Context(), uploadID); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 // GetURL handles returning of the public or presigned URL for accessing a file given its UUID.
 func (h *FileHandler) GetURL(w http.ResponseWriter, r *http.Request) error {
 	fileID := chi.URLParam(r, "id")
@@ -108,76 +248,367 @@ func (h *FileHandler) GetURL(w http.ResponseWriter, r *http.Request) error {
 	})
 }
 
-// DownloadFile streams the requested file to the client,
-// ensuring access control and updating the download count.
+// DownloadFile streams the requested file to the client, ensuring access control
+// and updating the download count. The verified content type of the blob is served;
+// with ?inline=1 allowlisted types (images, PDFs, text) are shown in the browser,
+// everything else is still sent as an attachment. http.ServeContent answers Range and If-Range
+// (single and multiple ranges) as well as If-None-Match / If-Modified-Since with
+// 304 Not Modified, based on an ETag derived from the blob's SHA-256 and the
+// upload time as Last-Modified. Ranges are read from storage without fetching
+// the rest of the object.
 func (h *FileHandler) DownloadFile(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
-	fileID := uuid.MustParse(chi.URLParam(r, "id"))
+	fileID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid file ID")
+	}
 
-	blobReader, filename, err := h.service.DownloadFile(ctx, fileID)
+	download, err := h.service.DownloadFile(ctx, fileID)
+	if err != nil {
+		log.Printf("Error while opening file %s: %s", fileID, err)
+		return err
+	}
+	defer download.Content.Close()
+
+	if status := serveDownload(w, r, download); countsAsDownload(r, status) {
+		h.service.RecordDownload(ctx, download)
+	}
+	return nil
+}
+
+// GetThumbnail serves the preview of a file in the size given by ?size=:
+// a thumbnail for images and the first page for text files.
+// Previews never change, so clients may cache them and revalidate with the ETag.
+func (h *FileHandler) GetThumbnail(w http.ResponseWriter, r *http.Request) error {
+	fileID, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		log.Printf("Error while reading blob: %s", err)
-		return apierror.NewInternalServerError("Cannot read file")
+		return apierror.NewBadRequestError("Invalid file ID")
 	}
-	defer blobReader.Close()
 
-	// increment download count for file
-	h.service.IncrementDownloadCount(ctx, fileID)
+	p, err := h.service.GetThumbnail(r.Context(), fileID, r.URL.Query().Get("size"))
+	if err != nil {
+		return err
+	}
 
-	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
-	w.Header().Set("Content-Type", "application/octet-stream")
-	_, err = io.Copy(w, blobReader)
-	return err
+	w.Header().Set("ETag", p.ETag())
+	// private: the preview is only served to users who can read the file
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Header().Set("Content-Type", p.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", p.ModTime(), bytes.NewReader(p.Content))
+	return nil
 }
 
-// ListContents handles requests to retrieve a paginated list of files and folders,
-// supporting filtering by folder, MIME type, upload date, size, ownership, and sorting.
-// This is the main handler that returns the content data to the users.
-func (h *FileHandler) ListContents(w http.ResponseWriter, r *http.Request) error {
-	req := ListContentsRequest{
-		Search:   r.URL.Query().Get("search"),
-		MimeType: r.URL.Query().Get("content_type"),
-		Limit:    util.ParseInt32OrDefault(r.URL.Query().Get("limit"), 20),
-		Offset:   util.ParseInt32OrDefault(r.URL.Query().Get("offset"), 0),
+// serveDownload writes the content of d with its headers and returns the response status.
+func serveDownload(w http.ResponseWriter, r *http.Request, d *Download) int {
+	inline := r.URL.Query().Get("inline") == "1"
+	disposition, contentType := contentDisposition(d.File.Filename, d.Blob.MimeType.String, inline)
+
+	w.Header().Set("ETag", d.ETag())
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	http.ServeContent(rec, r, "", d.ModTime(), d.Content)
+	return rec.status
+}
+
+// DownloadArchive streams a ZIP or tar.gz archive of folders and files.
+// GET reads the selection from repeated folder_id and file_id query parameters
+// and the format from format; POST reads an ArchiveRequest body, for selections
+// too large for a URL. The archive is produced while it is sent, so there is no
+// Content-Length, and a storage error halfway through aborts the response.
+func (h *FileHandler) DownloadArchive(w http.ResponseWriter, r *http.Request) error {
+	var req ArchiveRequest
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return apierror.NewBadRequestError("Invalid request body")
+		}
+	} else {
+		query := r.URL.Query()
+		req.Format = query.Get("format")
+		for _, param := range []struct {
+			name string
+			ids  *[]uuid.UUID
+		}{{"folder_id", &req.FolderIDs}, {"file_id", &req.FileIDs}} {
+			for _, value := range query[param.name] {
+				id, err := uuid.Parse(value)
+				if err != nil {
+					return apierror.NewBadRequestError("Invalid " + param.name)
+				}
+				*param.ids = append(*param.ids, id)
+			}
+		}
 	}
 
-	if folderID := r.URL.Query().Get("folder_id"); folderID != "" {
-		f := uuid.MustParse(folderID)
-		req.FolderID = &f
+	archive, err := h.service.PrepareArchive(r.Context(), req)
+	if err != nil {
+		return err
 	}
 
-	if before := r.URL.Query().Get("uploaded_before"); before != "" {
-		if t, err := time.Parse(time.RFC3339, before); err == nil {
-			req.UploadedBefore = &t
+	disposition, contentType := contentDisposition(archive.Filename(), archive.ContentType(), false)
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if err := h.service.WriteArchive(r.Context(), w, archive); err != nil {
+		log.Printf("Error while writing %s archive: %v", archive.Format, err)
+		// the status is already sent, abort so the client sees a failed transfer
+		// instead of a truncated archive
+		panic(http.ErrAbortHandler)
+	}
+	return nil
+}
+
+// ExtractArchive unpacks a ZIP or tar.gz file into a new folder.
+// The optional JSON body selects the folder it is created in. Like Upload,
+// the response lists the result of every entry and has status 207
+// Multi-Status if any of them failed.
+func (h *FileHandler) ExtractArchive(w http.ResponseWriter, r *http.Request) error {
+	fileID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid file ID")
+	}
+
+	var req ExtractRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		return apierror.NewBadRequestError("Invalid request body")
+	}
+
+	res, err := h.service.ExtractArchive(r.Context(), fileID, req)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	for _, result := range res.Results {
+		if result.Status == UploadFailed {
+			status = http.StatusMultiStatus
+			break
 		}
 	}
+	return util.WriteJSON(w, status, res)
+}
 
-	if after := r.URL.Query().Get("uploaded_after"); after != "" {
-		if t, err := time.Parse(time.RFC3339, after); err == nil {
-			req.UploadedAfter = &t
+// PublicDownload serves a file through its public link without authentication.
+// The password of a protected link is sent in the X-Share-Password header.
+// Links without a download limit behave like DownloadFile. For limited links every
+// GET transfers the whole file and claims a download before anything is sent,
+// Range and date based conditions are ignored so a request can't fetch content
+// without using up a download; a matching If-None-Match is still answered with 304.
+func (h *FileHandler) PublicDownload(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	token, err := uuid.Parse(chi.URLParam(r, "token"))
+	if err != nil {
+		return apierror.NewNotFoundError("Link")
+	}
+
+	download, err := h.service.OpenPublicLink(ctx, token, r.Header.Get("X-Share-Password"))
+	if err != nil {
+		return err
+	}
+	defer download.Content.Close()
+
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	if !download.File.PublicMaxDownloads.Valid {
+		if status := serveDownload(w, r, download); countsAsDownload(r, status) {
+			if err := h.service.ClaimPublicDownload(ctx, download); err != nil {
+				log.Printf("Error while recording public download of %s: %s", download.File.ID, err)
+			}
 		}
+		return nil
 	}
 
-	if ownershipStatus := r.URL.Query().Get("user_owns_file"); ownershipStatus != "" {
-		req.OwnershipStatus = util.ParseInt32OrDefault(ownershipStatus, 0)
+	r = r.Clone(ctx)
+	r.Header.Del("Range")
+	r.Header.Del("If-Range")
+	r.Header.Del("If-Modified-Since")
+	if r.Method == http.MethodGet && !etagMatches(r.Header.Get("If-None-Match"), download.ETag()) {
+		if err := h.service.ClaimPublicDownload(ctx, download); err != nil {
+			return err
+		}
 	}
+	serveDownload(w, r, download)
+	return nil
+}
 
-	if minSizeStr := r.URL.Query().Get("min_size"); minSizeStr != "" {
-		if val, err := strconv.ParseInt(minSizeStr, 10, 64); err == nil {
-			req.MinSize = sql.NullInt64{Int64: val, Valid: true}
-		} else {
-			return apierror.NewBadRequestError("Invalid min_size parameter")
+// etagMatches reports whether an If-None-Match header value matches etag.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
 		}
 	}
+	return false
+}
 
-	if maxSizeStr := r.URL.Query().Get("max_size"); maxSizeStr != "" {
-		if val, err := strconv.ParseInt(maxSizeStr, 10, 64); err == nil {
-			req.MaxSize = sql.NullInt64{Int64: val, Valid: true}
-		} else {
-			return apierror.NewBadRequestError("Invalid max_size parameter")
+// countsAsDownload reports whether a served request should increment the download count.
+// Full responses count, and so does an open ended range from the first byte
+// ("bytes=0-", sent by media players), so seeking in a video or resuming a
+// download is not counted again.
+func countsAsDownload(r *http.Request, status int) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	switch status {
+	case http.StatusOK:
+		return true
+	case http.StatusPartialContent:
+		return strings.TrimSpace(r.Header.Get("Range")) == "bytes=0-"
+	}
+	return false
+}
+
+// statusRecorder remembers the status code written to the wrapped ResponseWriter.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// ReplaceFileContent uploads the raw request body as a new version of a file,
+// served on PUT /files/{id}/content and POST /files/{id}/versions.
+// The Content-Type header declares the type of the new content.
+func (h *FileHandler) ReplaceFileContent(w http.ResponseWriter, r *http.Request) error {
+	fileID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid file ID")
+	}
+
+	file, err := h.service.ReplaceFileContent(r.Context(), fileID, r.Body, r.Header.Get("Content-Type"))
+	if err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusOK, file)
+}
+
+// ListFileVersions returns all versions of a file, starting with the current one.
+func (h *FileHandler) ListFileVersions(w http.ResponseWriter, r *http.Request) error {
+	fileID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid file ID")
+	}
+
+	versions, err := h.service.ListFileVersions(r.Context(), fileID)
+	if err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusOK, versions)
+}
+
+// DownloadFileVersion streams a version of a file, with the same range and
+// conditional request support as DownloadFile.
+func (h *FileHandler) DownloadFileVersion(w http.ResponseWriter, r *http.Request) error {
+	fileID, version, err := parseFileVersion(r)
+	if err != nil {
+		return err
+	}
+
+	download, err := h.service.DownloadFileVersion(r.Context(), fileID, version)
+	if err != nil {
+		return err
+	}
+	defer download.Content.Close()
+
+	serveDownload(w, r, download)
+	return nil
+}
+
+// RestoreFileVersion makes a previous version of a file current again.
+func (h *FileHandler) RestoreFileVersion(w http.ResponseWriter, r *http.Request) error {
+	fileID, version, err := parseFileVersion(r)
+	if err != nil {
+		return err
+	}
+
+	file, err := h.service.RestoreFileVersion(r.Context(), fileID, version)
+	if err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusOK, file)
+}
+
+// DeleteFileVersion deletes a previous version of a file.
+func (h *FileHandler) DeleteFileVersion(w http.ResponseWriter, r *http.Request) error {
+	fileID, version, err := parseFileVersion(r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.DeleteFileVersion(r.Context(), fileID, version); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// PruneFileVersions deletes all but the newest ?keep= previous versions of a file.
+// Without keep, all previous versions are deleted.
+func (h *FileHandler) PruneFileVersions(w http.ResponseWriter, r *http.Request) error {
+	fileID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid file ID")
+	}
+
+	var keep int64
+	if keepStr := r.URL.Query().Get("keep"); keepStr != "" {
+		keep, err = strconv.ParseInt(keepStr, 10, 32)
+		if err != nil {
+			return apierror.NewBadRequestError("Invalid keep value")
 		}
 	}
 
+	deleted, err := h.service.PruneFileVersions(r.Context(), fileID, int32(keep))
+	if err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusOK, map[string]int{"deleted": deleted})
+}
+
+// parseFileVersion parses the file ID and version number from the URL.
+func parseFileVersion(r *http.Request) (uuid.UUID, int32, error) {
+	fileID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return uuid.Nil, 0, apierror.NewBadRequestError("Invalid file ID")
+	}
+	version, err := strconv.ParseInt(chi.URLParam(r, "version"), 10, 32)
+	if err != nil || version < 1 {
+		return uuid.Nil, 0, apierror.NewBadRequestError("Invalid version")
+	}
+	return fileID, int32(version), nil
+}
+
+// ListContents handles requests to retrieve a paginated list of files and folders,
+// supporting filtering by folder, MIME type, upload date, size, ownership, and sorting.
+// This is the main handler that returns the content data to the users.
+func (h *FileHandler) ListContents(w http.ResponseWriter, r *http.Request) error {
+	filters, err := parseContentFilters(r.URL.Query())
+	if err != nil {
+		return err
+	}
+	req := ListContentsRequest{
+		ContentFilters: filters,
+		Search:         r.URL.Query().Get("search"),
+		Limit:          util.ParseInt32OrDefault(r.URL.Query().Get("limit"), 20),
+		Offset:         util.ParseInt32OrDefault(r.URL.Query().Get("offset"), 0),
+	}
+	if req.Tags, req.Metadata, err = parseLabelFilters(r.URL.Query()); err != nil {
+		return err
+	}
+
+	if folderID := r.URL.Query().Get("folder_id"); folderID != "" {
+		f := uuid.MustParse(folderID)
+		req.FolderID = &f
+	}
+
 	sortBy := r.URL.Query().Get("sort_by")
 	sortOrder := r.URL.Query().Get("sort_order")
 
@@ -207,8 +638,166 @@ func (h *FileHandler) ListContents(w http.ResponseWriter, r *http.Request) error
 	return util.WriteJSON(w, http.StatusOK, contents)
 }
 
+// Search handles requests to search all files and folders the user owns or
+// can access through a share. The search term is given as "q" and "fuzzy=true"
+// also matches similar names; the filters are the ones of ListContents.
+// Results are ranked by how well their name matches and carry their path.
+func (h *FileHandler) Search(w http.ResponseWriter, r *http.Request) error {
+	filters, err := parseContentFilters(r.URL.Query())
+	if err != nil {
+		return err
+	}
+	fuzzy, _ := strconv.ParseBool(r.URL.Query().Get("fuzzy"))
+	req := SearchRequest{
+		ContentFilters: filters,
+		Search:         r.URL.Query().Get("q"),
+		Fuzzy:          fuzzy,
+		Limit:          util.ParseInt32OrDefault(r.URL.Query().Get("limit"), 20),
+		Offset:         util.ParseInt32OrDefault(r.URL.Query().Get("offset"), 0),
+	}
+
+	results, err := h.service.Search(r.Context(), req)
+	if err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusOK, results)
+}
+
+// SearchFileContents handles requests to search the text of all files the
+// user owns or can access through a share. The search term is given as "q".
+// Results are ranked by relevance and carry their path and a snippet.
+func (h *FileHandler) SearchFileContents(w http.ResponseWriter, r *http.Request) error {
+	req := ContentSearchRequest{
+		Search: r.URL.Query().Get("q"),
+		Limit:  util.ParseInt32OrDefault(r.URL.Query().Get("limit"), 20),
+		Offset: util.ParseInt32OrDefault(r.URL.Query().Get("offset"), 0),
+	}
+
+	results, err := h.service.SearchFileContents(r.Context(), req)
+	if err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusOK, results)
+}
+
+// parseContentFilters reads the filters shared by ListContents and Search from
+// the query string. Malformed dates are ignored, malformed sizes are rejected.
+func parseContentFilters(query url.Values) (ContentFilters, error) {
+	filters := ContentFilters{MimeType: query.Get("content_type")}
+
+	if before := query.Get("uploaded_before"); before != "" {
+		if t, err := time.Parse(time.RFC3339, before); err == nil {
+			filters.UploadedBefore = &t
+		}
+	}
+
+	if after := query.Get("uploaded_after"); after != "" {
+		if t, err := time.Parse(time.RFC3339, after); err == nil {
+			filters.UploadedAfter = &t
+		}
+	}
+
+	if ownershipStatus := query.Get("user_owns_file"); ownershipStatus != "" {
+		filters.OwnershipStatus = util.ParseInt32OrDefault(ownershipStatus, 0)
+	}
+
+	if minSizeStr := query.Get("min_size"); minSizeStr != "" {
+		if val, err := strconv.ParseInt(minSizeStr, 10, 64); err == nil {
+			filters.MinSize = sql.NullInt64{Int64: val, Valid: true}
+		} else {
+			return ContentFilters{}, apierror.NewBadRequestError("Invalid min_size parameter")
+		}
+	}
+
+	if maxSizeStr := query.Get("max_size"); maxSizeStr != "" {
+		if val, err := strconv.ParseInt(maxSizeStr, 10, 64); err == nil {
+			filters.MaxSize = sql.NullInt64{Int64: val, Valid: true}
+		} else {
+			return ContentFilters{}, apierror.NewBadRequestError("Invalid max_size parameter")
+		}
+	}
+	return filters, nil
+}
+
+// parseLabelFilters reads the tag and metadata filters of ListContents from
+// the query string. "tags" is a comma-separated list of tags and each
+// "metadata" parameter is a predicate: "key=value", "key!=value", "key~value"
+// for a value containing the given text, or a bare "key" for any value.
+func parseLabelFilters(query url.Values) ([]string, []MetadataPredicate, error) {
+	var tags []string
+	if list := query.Get("tags"); list != "" {
+		normalized, err := normalizeTags(strings.Split(list, ","))
+		if err != nil {
+			return nil, nil, err
+		}
+		tags = normalized
+	}
+
+	var predicates []MetadataPredicate
+	for _, param := range query["metadata"] {
+		var p MetadataPredicate
+		i := strings.IndexAny(param, "!~=")
+		switch {
+		case i < 0:
+			p = MetadataPredicate{Key: param, Op: MetadataExists}
+		case param[i] == '=':
+			p = MetadataPredicate{Key: param[:i], Op: MetadataEquals, Value: param[i+1:]}
+		case param[i] == '~':
+			p = MetadataPredicate{Key: param[:i], Op: MetadataContains, Value: param[i+1:]}
+		case strings.HasPrefix(param[i:], "!="):
+			p = MetadataPredicate{Key: param[:i], Op: MetadataNotEquals, Value: param[i+2:]}
+		default:
+			return nil, nil, apierror.NewBadRequestError("Invalid metadata filter " + strconv.Quote(param))
+		}
+		key, err := normalizeMetadataKey(p.Key)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.Key = key
+		predicates = append(predicates, p)
+	}
+	return tags, predicates, nil
+}
+
+// UpdateTags handles requests to add tags to and remove tags from a selection
+// of folders and files.
+func (h *FileHandler) UpdateTags(w http.ResponseWriter, r *http.Request) error {
+	var req UpdateTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierror.NewBadRequestError("Invalid request body")
+	}
+	if err := h.service.UpdateTags(r.Context(), req); err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusOK, map[string]string{"message": "Tags updated successfully"})
+}
+
+// UpdateMetadata handles requests to set metadata keys on and remove metadata
+// keys from a selection of folders and files.
+func (h *FileHandler) UpdateMetadata(w http.ResponseWriter, r *http.Request) error {
+	var req UpdateMetadataRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierror.NewBadRequestError("Invalid request body")
+	}
+	if err := h.service.UpdateMetadata(r.Context(), req); err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusOK, map[string]string{"message": "Metadata updated successfully"})
+}
+
+// SuggestTags handles requests to autocomplete tags. It returns the tags
+// starting with "prefix" the user can see, most used first.
+func (h *FileHandler) SuggestTags(w http.ResponseWriter, r *http.Request) error {
+	limit := util.ParseInt32OrDefault(r.URL.Query().Get("limit"), 10)
+	suggestions, err := h.service.SuggestTags(r.Context(), r.URL.Query().Get("prefix"), limit)
+	if err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusOK, suggestions)
+}
+
 // DeleteFile handles requests to delete a file by its UUID, performing ownership checks
-// and removing it from both storage and the database.
+// and moving it to the trash.
 func (h *FileHandler) DeleteFile(w http.ResponseWriter, r *http.Request) error {
 	fileID := chi.URLParam(r, "id")
 	if fileID == "" {
@@ -225,6 +814,80 @@ func (h *FileHandler) DeleteFile(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// ListTrash returns the files and folders the current user moved to the trash.
+func (h *FileHandler) ListTrash(w http.ResponseWriter, r *http.Request) error {
+	items, err := h.service.ListTrash(r.Context())
+	if err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusOK, items)
+}
+
+// EmptyTrash permanently deletes everything in the current user's trash.
+func (h *FileHandler) EmptyTrash(w http.ResponseWriter, r *http.Request) error {
+	purged, err := h.service.EmptyTrash(r.Context())
+	if err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusOK, map[string]int{"purged": purged})
+}
+
+// RestoreFile takes a file out of the trash and returns its metadata.
+func (h *FileHandler) RestoreFile(w http.ResponseWriter, r *http.Request) error {
+	fileID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid file ID")
+	}
+
+	file, err := h.service.RestoreFile(r.Context(), fileID)
+	if err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusOK, file)
+}
+
+// PurgeFile permanently deletes a file from the trash.
+func (h *FileHandler) PurgeFile(w http.ResponseWriter, r *http.Request) error {
+	fileID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid file ID")
+	}
+
+	if err := h.service.PurgeFile(r.Context(), fileID); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// RestoreFolder takes a folder and its contents out of the trash.
+func (h *FileHandler) RestoreFolder(w http.ResponseWriter, r *http.Request) error {
+	folderID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid folder ID")
+	}
+
+	if err := h.service.RestoreFolder(r.Context(), folderID); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// PurgeFolder permanently deletes a folder and its contents from the trash.
+func (h *FileHandler) PurgeFolder(w http.ResponseWriter, r *http.Request) error {
+	folderID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid folder ID")
+	}
+
+	if err := h.service.PurgeFolder(r.Context(), folderID); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 // UpdateFilename handles requests to rename an existing file, validating input
 // and returning the updated file metadata on success.
 func (h *FileHandler) UpdateFilename(w http.ResponseWriter, r *http.Request) error {
@@ -350,8 +1013,49 @@ func (h *FileHandler) MoveFile(w http.ResponseWriter, r *http.Request) error {
 	return h.service.MoveFile(r.Context(), fileID, req)
 }
 
+// CopyFile handles requests to copy a file into a folder of the current user.
+// The optional JSON body selects the target folder and the name of the copy.
+func (h *FileHandler) CopyFile(w http.ResponseWriter, r *http.Request) error {
+	fileID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid file ID")
+	}
+
+	var req CopyFileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		return apierror.NewBadRequestError("Invalid request body")
+	}
+
+	file, err := h.service.CopyFile(r.Context(), fileID, req)
+	if err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusCreated, file)
+}
+
+// SendFileCopy handles requests to send a copy of a file to another user.
+func (h *FileHandler) SendFileCopy(w http.ResponseWriter, r *http.Request) error {
+	fileID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid file ID")
+	}
+
+	var req SendCopyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierror.NewBadRequestError("Invalid request body")
+	}
+
+	file, err := h.service.SendFileCopy(r.Context(), fileID, req)
+	if err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusCreated, file)
+}
+
 // UpdateFileShares handles requests to update file sharing settings,
-// allowing the owner to modify which users have access.
+// allowing the owner or a resharer to modify which users have access and
+// with which permission. The body holds "shares" with a user_id and permission
+// each, or "user_ids" to keep existing permissions and give new users read access.
 // This is done in one atomic action to ensure database consistency.
 func (h *FileHandler) UpdateFileShares(w http.ResponseWriter, r *http.Request) error {
 	fileID, err := uuid.Parse(chi.URLParam(r, "id"))
@@ -365,8 +1069,11 @@ func (h *FileHandler) UpdateFileShares(w http.ResponseWriter, r *http.Request) e
 	}
 
 	req := UpdateFileSharesRequest{
-		FileID:  fileID,
-		UserIDs: payload.UserIDs,
+		FileID: fileID,
+		Shares: payload.Shares,
+	}
+	for _, userID := range payload.UserIDs {
+		req.Shares = append(req.Shares, Share{UserID: userID})
 	}
 
 	if err := h.service.UpdateFileShares(r.Context(), req); err != nil {
@@ -375,3 +1082,66 @@ func (h *FileHandler) UpdateFileShares(w http.ResponseWriter, r *http.Request) e
 
 	return util.WriteJSON(w, http.StatusOK, map[string]string{"message": "File sharing updated successfully"})
 }
+
+// GetPublicLink returns the public link of a file owned by the current user.
+func (h *FileHandler) GetPublicLink(w http.ResponseWriter, r *http.Request) error {
+	fileID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid file ID")
+	}
+
+	link, err := h.service.GetPublicLink(r.Context(), fileID)
+	if err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusOK, link)
+}
+
+// CreatePublicLink creates or updates the public link of a file with an optional
+// expiry, download limit and password. An empty body creates an unrestricted link.
+func (h *FileHandler) CreatePublicLink(w http.ResponseWriter, r *http.Request) error {
+	fileID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid file ID")
+	}
+
+	var req PublicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		return apierror.NewBadRequestError("Invalid request body")
+	}
+
+	link, err := h.service.CreatePublicLink(r.Context(), fileID, req)
+	if err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusOK, link)
+}
+
+// RotatePublicLink gives the public link of a file a new token, invalidating the old URL.
+func (h *FileHandler) RotatePublicLink(w http.ResponseWriter, r *http.Request) error {
+	fileID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid file ID")
+	}
+
+	link, err := h.service.RotatePublicLink(r.Context(), fileID)
+	if err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusOK, link)
+}
+
+// RevokePublicLink removes the public link of a file.
+func (h *FileHandler) RevokePublicLink(w http.ResponseWriter, r *http.Request) error {
+	fileID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid file ID")
+	}
+
+	if err := h.service.RevokePublicLink(r.Context(), fileID); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
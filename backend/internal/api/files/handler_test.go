@@ -0,0 +1,178 @@
+package files_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestDownloadFileRanges(t *testing.T) {
+	const content = "hello world"
+
+	env := newTestEnv(t)
+	userID, ctx := env.createUser(t, "alice@example.com", 1<<20)
+	fileID, err := upload(ctx, env.service, "greeting.txt", content)
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	download, err := env.service.DownloadFile(ctx, fileID)
+	if err != nil {
+		t.Fatalf("DownloadFile: %v", err)
+	}
+	etag := download.ETag()
+	download.Content.Close()
+
+	router := newTestRouter(env, userID)
+
+	tests := []struct {
+		name        string
+		method      string
+		headers     map[string]string
+		wantStatus  int
+		wantBody    string // checked unless wantParts is set
+		wantParts   []string
+		wantRange   string
+		wantCounted bool
+	}{
+		{name: "full", wantStatus: http.StatusOK, wantBody: content, wantCounted: true},
+		{name: "head", method: http.MethodHead, wantStatus: http.StatusOK, wantBody: ""},
+		{name: "single range", headers: map[string]string{"Range": "bytes=2-4"}, wantStatus: http.StatusPartialContent, wantBody: "llo", wantRange: "bytes 2-4/11"},
+		{name: "open range from start", headers: map[string]string{"Range": "bytes=0-"}, wantStatus: http.StatusPartialContent, wantBody: content, wantRange: "bytes 0-10/11", wantCounted: true},
+		{name: "closed range from start", headers: map[string]string{"Range": "bytes=0-4"}, wantStatus: http.StatusPartialContent, wantBody: "hello", wantRange: "bytes 0-4/11"},
+		{name: "suffix range", headers: map[string]string{"Range": "bytes=-5"}, wantStatus: http.StatusPartialContent, wantBody: "world", wantRange: "bytes 6-10/11"},
+		{name: "multiple ranges", headers: map[string]string{"Range": "bytes=0-1,6-7"}, wantStatus: http.StatusPartialContent, wantParts: []string{"Content-Range: bytes 0-1/11", "\r\n\r\nhe\r\n", "Content-Range: bytes 6-7/11", "\r\n\r\nwo\r\n"}},
+		{name: "unsatisfiable range", headers: map[string]string{"Range": "bytes=50-"}, wantStatus: http.StatusRequestedRangeNotSatisfiable, wantRange: "bytes */11"},
+		{name: "if-none-match", headers: map[string]string{"If-None-Match": etag}, wantStatus: http.StatusNotModified},
+		{name: "if-modified-since", headers: map[string]string{"If-Modified-Since": time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}, wantStatus: http.StatusNotModified},
+		{name: "if-range matches", headers: map[string]string{"Range": "bytes=6-", "If-Range": etag}, wantStatus: http.StatusPartialContent, wantBody: "world", wantRange: "bytes 6-10/11"},
+		{name: "if-range stale", headers: map[string]string{"Range": "bytes=6-", "If-Range": `"stale"`}, wantStatus: http.StatusOK, wantBody: content, wantCounted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := downloadCount(t, env, fileID)
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/files/"+fileID.String(), nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %q)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantParts != nil {
+				if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "multipart/byteranges") {
+					t.Errorf("Content-Type = %q, want multipart/byteranges", ct)
+				}
+				for _, part := range tt.wantParts {
+					if !strings.Contains(rec.Body.String(), part) {
+						t.Errorf("body %q does not contain part %q", rec.Body.String(), part)
+					}
+				}
+			} else if tt.wantStatus < 300 && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+			if got := rec.Header().Get("Content-Range"); got != tt.wantRange {
+				t.Errorf("Content-Range = %q, want %q", got, tt.wantRange)
+			}
+			if tt.wantStatus != http.StatusRequestedRangeNotSatisfiable && rec.Header().Get("ETag") != etag {
+				t.Errorf("ETag = %q, want %q", rec.Header().Get("ETag"), etag)
+			}
+
+			counted := downloadCount(t, env, fileID) > before
+			if counted != tt.wantCounted {
+				t.Errorf("download counted = %v, want %v", counted, tt.wantCounted)
+			}
+		})
+	}
+}
+
+func TestDownloadFileContentType(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+	html := "<!DOCTYPE html><html><script>alert(1)</script></html>"
+
+	tests := []struct {
+		name            string
+		content         string
+		declared        string
+		inline          bool
+		wantType        string
+		wantDisposition string
+	}{
+		{name: "declared matches", content: png, declared: "image/png", wantType: "image/png", wantDisposition: "attachment"},
+		{name: "declared is ignored when content disagrees", content: html, declared: "image/png", wantType: "text/html; charset=utf-8", wantDisposition: "attachment"},
+		{name: "inline image", content: png, declared: "image/png", inline: true, wantType: "image/png", wantDisposition: "inline"},
+		{name: "inline html is refused", content: html, declared: "text/html", inline: true, wantType: "text/html; charset=utf-8", wantDisposition: "attachment"},
+		{name: "csv is kept and shown as text", content: "a,b\n1,2\n", declared: "text/csv", inline: true, wantType: "text/plain; charset=utf-8", wantDisposition: "inline"},
+		{name: "zip based office document", content: "PK\x03\x04rest", declared: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", wantType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", wantDisposition: "attachment"},
+		{name: "unknown binary without declared type", content: "\x00\x01\x02", declared: "", wantType: "application/octet-stream", wantDisposition: "attachment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			userID, ctx := env.createUser(t, "alice@example.com", 1<<20)
+			file, err := env.service.UploadFile(ctx, strings.NewReader(tt.content), "file", tt.declared, nil)
+			if err != nil {
+				t.Fatalf("UploadFile: %v", err)
+			}
+
+			router := newTestRouter(env, userID)
+
+			url := "/files/" + file.ID.String()
+			if tt.inline {
+				url += "?inline=1"
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rec.Code)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if got := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(got, tt.wantDisposition+";") {
+				t.Errorf("Content-Disposition = %q, want %s", got, tt.wantDisposition)
+			}
+			if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+			}
+		})
+	}
+}
+
+// newTestRouter returns a router with the file routes, authenticated as userID.
+func newTestRouter(env *testEnv, userID int64) http.Handler {
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(userctx.SetUserID(r.Context(), userID)))
+		})
+	})
+	files.NewFileHandler(env.service).RegisterRoutes(router)
+	return router
+}
+
+func downloadCount(t *testing.T, env *testEnv, id uuid.UUID) int64 {
+	t.Helper()
+	file, err := env.db.GetFileByUUID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetFileByUUID(%s): %v", id, err)
+	}
+	return file.DownloadCount.Int64
+}
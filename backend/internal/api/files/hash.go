@@ -0,0 +1,51 @@
+package files
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+)
+
+// hashingReader wraps an io.Reader, computing the SHA-256 and byte count
+// of everything read through it. It lets uploads be hashed while they stream.
+// The first sniffLen bytes are kept so the content type can be detected.
+type hashingReader struct {
+	r      io.Reader
+	hasher hash.Hash
+	n      int64
+	head   []byte
+}
+
+// newHashingReader returns a hashingReader reading from r.
+func newHashingReader(r io.Reader) *hashingReader {
+	return &hashingReader{r: r, hasher: sha256.New()}
+}
+
+func (h *hashingReader) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	if n > 0 {
+		h.hasher.Write(p[:n])
+		h.n += int64(n)
+		if missing := sniffLen - len(h.head); missing > 0 {
+			h.head = append(h.head, p[:min(n, missing)]...)
+		}
+	}
+	return n, err
+}
+
+// Sum returns the hex-encoded SHA-256 of the bytes read so far.
+func (h *hashingReader) Sum() string {
+	return hex.EncodeToString(h.hasher.Sum(nil))
+}
+
+// Size returns the number of bytes read so far.
+func (h *hashingReader) Size() int64 {
+	return h.n
+}
+
+// Sniff returns the content type detected from the first bytes read.
+func (h *hashingReader) Sniff() string {
+	return http.DetectContentType(h.head)
+}
@@ -0,0 +1,321 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/access"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/google/uuid"
+)
+
+const (
+	maxTagLength           = 50
+	maxMetadataKeyLength   = 64
+	maxMetadataValueLength = 1024
+	// maxLabelItems is the largest selection tags and metadata can be updated on at once.
+	maxLabelItems = 500
+	// maxTagSuggestions is the largest number of tags suggested at once.
+	maxTagSuggestions = 50
+)
+
+// metadataKeyPattern matches valid metadata keys, once lowercased. Keys
+// cannot contain the operators of the metadata filters of ListContents.
+var metadataKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// normalizeTag trims and lowercases a tag and checks that it is valid.
+// Tags cannot contain commas, which separate them in listing filters.
+func normalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	switch {
+	case tag == "":
+		return "", apierror.NewBadRequestError("Tags cannot be empty")
+	case utf8.RuneCountInString(tag) > maxTagLength:
+		return "", apierror.NewBadRequestError(fmt.Sprintf("Tags cannot be longer than %d characters", maxTagLength))
+	case strings.ContainsRune(tag, ',') || strings.ContainsFunc(tag, unicode.IsControl):
+		return "", apierror.NewBadRequestError("Tags cannot contain commas or control characters")
+	}
+	return tag, nil
+}
+
+// normalizeTags normalizes a list of tags and drops the duplicates.
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
+
+// normalizeMetadataKey lowercases a metadata key and checks that it is valid.
+func normalizeMetadataKey(key string) (string, error) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	if len(key) > maxMetadataKeyLength || !metadataKeyPattern.MatchString(key) {
+		return "", apierror.NewBadRequestError(fmt.Sprintf(
+			"Metadata keys must be 1 to %d letters, digits, dots, dashes or underscores", maxMetadataKeyLength))
+	}
+	return key, nil
+}
+
+// UpdateTags adds tags to and removes tags from the selected folders and
+// files. The user needs write access to every one of them, and either all of
+// them are updated or none is. Tags are case-insensitive and stored lowercase.
+func (s *Service) UpdateTags(ctx context.Context, req UpdateTagsRequest) error {
+	userID, fileIDs, folderIDs, err := s.authorizeLabelTargets(ctx, req.FileIDs, req.FolderIDs)
+	if err != nil {
+		return err
+	}
+
+	add, err := normalizeTags(req.Add)
+	if err != nil {
+		return err
+	}
+	remove, err := normalizeTags(req.Remove)
+	if err != nil {
+		return err
+	}
+	if len(add)+len(remove) == 0 {
+		return apierror.NewBadRequestError("No tags to add or remove")
+	}
+	for _, tag := range add {
+		if slices.Contains(remove, tag) {
+			return apierror.NewBadRequestError(fmt.Sprintf("Tag %q cannot be both added and removed", tag))
+		}
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return apierror.NewInternalServerError("Failed to update tags")
+	}
+	defer tx.Rollback(ctx) // rollback on error
+	qtx := s.repo.WithTx(tx)
+
+	if len(remove) > 0 {
+		if err := qtx.RemoveItemTags(ctx, sqlc.RemoveItemTagsParams{
+			ItemIds: append(slices.Clone(fileIDs), folderIDs...),
+			Tags:    remove,
+		}); err != nil {
+			log.Printf("Failed to remove tags: %v", err)
+			return apierror.NewInternalServerError("Failed to update tags")
+		}
+	}
+	if len(add) > 0 {
+		if err := qtx.AddItemTags(ctx, sqlc.AddItemTagsParams{
+			FileIds:   fileIDs,
+			FolderIds: folderIDs,
+			Tags:      add,
+		}); err != nil {
+			log.Printf("Failed to add tags: %v", err)
+			return apierror.NewInternalServerError("Failed to update tags")
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return apierror.NewInternalServerError("Failed to update tags")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "TAGS_UPDATED",
+		TargetID: labelAuditTarget(fileIDs, folderIDs),
+		Details: map[string]interface{}{
+			"file_ids":   fileIDs,
+			"folder_ids": folderIDs,
+			"added":      add,
+			"removed":    remove,
+		},
+	})
+	return nil
+}
+
+// UpdateMetadata sets metadata keys on and removes metadata keys from the
+// selected folders and files, replacing the values already set for those
+// keys. The user needs write access to every one of them, and either all of
+// them are updated or none is. Keys are case-insensitive and stored lowercase.
+func (s *Service) UpdateMetadata(ctx context.Context, req UpdateMetadataRequest) error {
+	userID, fileIDs, folderIDs, err := s.authorizeLabelTargets(ctx, req.FileIDs, req.FolderIDs)
+	if err != nil {
+		return err
+	}
+
+	set := make(map[string]string, len(req.Set))
+	for key, value := range req.Set {
+		key, err := normalizeMetadataKey(key)
+		if err != nil {
+			return err
+		}
+		if _, ok := set[key]; ok {
+			return apierror.NewBadRequestError(fmt.Sprintf("Metadata key %q is set twice", key))
+		}
+		if value == "" || utf8.RuneCountInString(value) > maxMetadataValueLength {
+			return apierror.NewBadRequestError(fmt.Sprintf("Metadata values must be 1 to %d characters long", maxMetadataValueLength))
+		}
+		set[key] = value
+	}
+	remove := make([]string, 0, len(req.Remove))
+	for _, key := range req.Remove {
+		key, err := normalizeMetadataKey(key)
+		if err != nil {
+			return err
+		}
+		if _, ok := set[key]; ok {
+			return apierror.NewBadRequestError(fmt.Sprintf("Metadata key %q cannot be both set and removed", key))
+		}
+		if !slices.Contains(remove, key) {
+			remove = append(remove, key)
+		}
+	}
+	if len(set)+len(remove) == 0 {
+		return apierror.NewBadRequestError("No metadata to set or remove")
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return apierror.NewInternalServerError("Failed to update metadata")
+	}
+	defer tx.Rollback(ctx) // rollback on error
+	qtx := s.repo.WithTx(tx)
+
+	if len(remove) > 0 {
+		if err := qtx.RemoveItemMetadata(ctx, sqlc.RemoveItemMetadataParams{
+			ItemIds: append(slices.Clone(fileIDs), folderIDs...),
+			Keys:    remove,
+		}); err != nil {
+			log.Printf("Failed to remove metadata: %v", err)
+			return apierror.NewInternalServerError("Failed to update metadata")
+		}
+	}
+	if len(set) > 0 {
+		params := sqlc.SetItemMetadataParams{FileIds: fileIDs, FolderIds: folderIDs}
+		for _, key := range slices.Sorted(maps.Keys(set)) {
+			params.Keys = append(params.Keys, key)
+			params.Values = append(params.Values, set[key])
+		}
+		if err := qtx.SetItemMetadata(ctx, params); err != nil {
+			log.Printf("Failed to set metadata: %v", err)
+			return apierror.NewInternalServerError("Failed to update metadata")
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return apierror.NewInternalServerError("Failed to update metadata")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "METADATA_UPDATED",
+		TargetID: labelAuditTarget(fileIDs, folderIDs),
+		Details: map[string]interface{}{
+			"file_ids":   fileIDs,
+			"folder_ids": folderIDs,
+			"set":        set,
+			"removed":    remove,
+		},
+	})
+	return nil
+}
+
+// SuggestTags returns the tags starting with prefix on the folders and files
+// the user owns or can access through a share, most used first, to
+// autocomplete tags as they are typed. An empty prefix suggests the most used tags.
+func (s *Service) SuggestTags(ctx context.Context, prefix string, limit int32) ([]TagSuggestion, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return nil, apierror.NewUnauthorizedError()
+	}
+	if limit <= 0 || limit > maxTagSuggestions {
+		limit = maxTagSuggestions
+	}
+
+	rows, err := s.repo.ListTagSuggestions(ctx, sqlc.ListTagSuggestionsParams{
+		UserID: userID,
+		Prefix: strings.ToLower(strings.TrimSpace(prefix)),
+		Limit:  limit,
+	})
+	if err != nil {
+		log.Printf("Failed to list tag suggestions for user %d: %v", userID, err)
+		return nil, apierror.NewInternalServerError("Failed to list tags")
+	}
+
+	suggestions := make([]TagSuggestion, len(rows))
+	for i, r := range rows {
+		suggestions[i] = TagSuggestion{Tag: r.Tag, Uses: r.Uses}
+	}
+	return suggestions, nil
+}
+
+// authorizeLabelTargets checks that the current user can write to every
+// selected file and folder. It returns the user's ID along with the selected
+// file and folder IDs without duplicates.
+func (s *Service) authorizeLabelTargets(ctx context.Context, fileIDs, folderIDs []uuid.UUID) (int64, []uuid.UUID, []uuid.UUID, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return 0, nil, nil, apierror.NewUnauthorizedError()
+	}
+
+	fileIDs, folderIDs = uniqueIDs(fileIDs), uniqueIDs(folderIDs)
+	switch total := len(fileIDs) + len(folderIDs); {
+	case total == 0:
+		return 0, nil, nil, apierror.NewBadRequestError("Select at least one folder or file")
+	case total > maxLabelItems:
+		return 0, nil, nil, apierror.NewBadRequestError(fmt.Sprintf("Select at most %d folders and files", maxLabelItems))
+	}
+
+	for _, fileID := range fileIDs {
+		if _, _, err := s.authorizeFile(ctx, fileID, access.Write); err != nil {
+			return 0, nil, nil, err
+		}
+	}
+	for _, folderID := range folderIDs {
+		folder, err := s.folderRepo.GetFolderByID(ctx, folderID)
+		if err != nil {
+			return 0, nil, nil, apierror.NewNotFoundError("Folder")
+		}
+		permission, err := s.folderPermission(ctx, folder, userID)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+		if permission < access.Write {
+			return 0, nil, nil, apierror.NewForbiddenError()
+		}
+	}
+	return userID, fileIDs, folderIDs, nil
+}
+
+// labelAuditTarget returns the item an update of tags or metadata is logged
+// against: the selected item if there is only one, a new ID otherwise.
+func labelAuditTarget(fileIDs, folderIDs []uuid.UUID) uuid.UUID {
+	if ids := append(slices.Clone(fileIDs), folderIDs...); len(ids) == 1 {
+		return ids[0]
+	}
+	return uuid.New()
+}
+
+// decodeMetadata decodes the metadata of a listed item, aggregated as a JSON
+// object by the listing queries. Items without metadata get a nil map.
+func decodeMetadata(data []byte) map[string]string {
+	var metadata map[string]string
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		log.Printf("Failed to decode item metadata %q: %v", data, err)
+		return nil
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
@@ -0,0 +1,236 @@
+package files_test
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/folders"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/memdb"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/google/uuid"
+)
+
+// TestUpdateLabels tags a file and a folder shared with a reader and a
+// writer, and sets and removes their metadata.
+func TestUpdateLabels(t *testing.T) {
+	env := newTestEnv(t)
+	_, ownerCtx := env.createUser(t, "owner@example.com", 1<<20)
+	readerID, readerCtx := env.createUser(t, "reader@example.com", 1<<20)
+	writerID, writerCtx := env.createUser(t, "writer@example.com", 1<<20)
+	folderService := folders.NewService(env.db, env.db, nopAudit{})
+
+	docs, err := folderService.CreateFolder(ownerCtx, folders.CreateFolderRequest{Name: "docs"})
+	if err != nil {
+		t.Fatalf("CreateFolder: %v", err)
+	}
+	fileID, err := upload(ownerCtx, env.service, "report.pdf", "contents")
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	if err := folderService.UpdateFolderShares(ownerCtx, folders.UpdateFolderSharesRequest{
+		FolderID: docs.ID,
+		Shares:   []folders.Share{{UserID: readerID, Permission: "read"}, {UserID: writerID, Permission: "write"}},
+	}); err != nil {
+		t.Fatalf("UpdateFolderShares: %v", err)
+	}
+	if err := env.service.UpdateFileShares(ownerCtx, files.UpdateFileSharesRequest{
+		FileID: fileID,
+		Shares: []files.Share{{UserID: readerID, Permission: "read"}, {UserID: writerID, Permission: "write"}},
+	}); err != nil {
+		t.Fatalf("UpdateFileShares: %v", err)
+	}
+	both := func(add, remove []string) files.UpdateTagsRequest {
+		return files.UpdateTagsRequest{FileIDs: []uuid.UUID{fileID}, FolderIDs: []uuid.UUID{docs.ID}, Add: add, Remove: remove}
+	}
+
+	tagTests := []struct {
+		name       string
+		ctx        context.Context
+		req        files.UpdateTagsRequest
+		wantStatus int
+		wantTags   []string
+	}{
+		{name: "owner adds", ctx: ownerCtx, req: both([]string{" Urgent", "finance", "urgent"}, nil), wantTags: []string{"finance", "urgent"}},
+		{name: "reader cannot tag", ctx: readerCtx, req: both([]string{"mine"}, nil), wantStatus: http.StatusForbidden, wantTags: []string{"finance", "urgent"}},
+		{name: "writer adds and removes", ctx: writerCtx, req: both([]string{"q3"}, []string{"URGENT"}), wantTags: []string{"finance", "q3"}},
+		{name: "nothing selected", ctx: ownerCtx, req: files.UpdateTagsRequest{Add: []string{"x"}}, wantStatus: http.StatusBadRequest, wantTags: []string{"finance", "q3"}},
+		{name: "no tags", ctx: ownerCtx, req: both(nil, nil), wantStatus: http.StatusBadRequest, wantTags: []string{"finance", "q3"}},
+		{name: "tag with a comma", ctx: ownerCtx, req: both([]string{"a,b"}, nil), wantStatus: http.StatusBadRequest, wantTags: []string{"finance", "q3"}},
+		{name: "added and removed", ctx: ownerCtx, req: both([]string{"x"}, []string{"X"}), wantStatus: http.StatusBadRequest, wantTags: []string{"finance", "q3"}},
+		{name: "missing folder", ctx: ownerCtx, req: files.UpdateTagsRequest{FolderIDs: []uuid.UUID{uuid.New()}, Add: []string{"x"}}, wantStatus: http.StatusNotFound, wantTags: []string{"finance", "q3"}},
+	}
+	for _, tt := range tagTests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := env.service.UpdateTags(tt.ctx, tt.req)
+			if got := statusOf(err); got != tt.wantStatus || (tt.wantStatus == 0 && err != nil) {
+				t.Fatalf("UpdateTags error = %v, want status %d", err, tt.wantStatus)
+			}
+			for _, id := range []uuid.UUID{fileID, docs.ID} {
+				if got := env.db.Tags(id); !slices.Equal(got, tt.wantTags) {
+					t.Errorf("tags of %s = %v, want %v", id, got, tt.wantTags)
+				}
+			}
+		})
+	}
+
+	metadataTests := []struct {
+		name         string
+		ctx          context.Context
+		req          files.UpdateMetadataRequest
+		wantStatus   int
+		wantMetadata map[string]string
+	}{
+		{
+			name:         "owner sets",
+			ctx:          ownerCtx,
+			req:          files.UpdateMetadataRequest{FileIDs: []uuid.UUID{fileID}, Set: map[string]string{"Project": "apollo", "status": "draft"}},
+			wantMetadata: map[string]string{"project": "apollo", "status": "draft"},
+		},
+		{
+			name:         "writer replaces and removes",
+			ctx:          writerCtx,
+			req:          files.UpdateMetadataRequest{FileIDs: []uuid.UUID{fileID}, Set: map[string]string{"status": "final"}, Remove: []string{"project"}},
+			wantMetadata: map[string]string{"status": "final"},
+		},
+		{
+			name:         "reader cannot set",
+			ctx:          readerCtx,
+			req:          files.UpdateMetadataRequest{FileIDs: []uuid.UUID{fileID}, Set: map[string]string{"status": "mine"}},
+			wantStatus:   http.StatusForbidden,
+			wantMetadata: map[string]string{"status": "final"},
+		},
+		{
+			name:         "invalid key",
+			ctx:          ownerCtx,
+			req:          files.UpdateMetadataRequest{FileIDs: []uuid.UUID{fileID}, Set: map[string]string{"due=date": "soon"}},
+			wantStatus:   http.StatusBadRequest,
+			wantMetadata: map[string]string{"status": "final"},
+		},
+		{
+			name:         "empty value",
+			ctx:          ownerCtx,
+			req:          files.UpdateMetadataRequest{FileIDs: []uuid.UUID{fileID}, Set: map[string]string{"status": ""}},
+			wantStatus:   http.StatusBadRequest,
+			wantMetadata: map[string]string{"status": "final"},
+		},
+		{
+			name:         "key set twice",
+			ctx:          ownerCtx,
+			req:          files.UpdateMetadataRequest{FileIDs: []uuid.UUID{fileID}, Set: map[string]string{"status": "a", "Status": "b"}},
+			wantStatus:   http.StatusBadRequest,
+			wantMetadata: map[string]string{"status": "final"},
+		},
+		{
+			name:         "set and removed",
+			ctx:          ownerCtx,
+			req:          files.UpdateMetadataRequest{FileIDs: []uuid.UUID{fileID}, Set: map[string]string{"status": "a"}, Remove: []string{"status"}},
+			wantStatus:   http.StatusBadRequest,
+			wantMetadata: map[string]string{"status": "final"},
+		},
+	}
+	for _, tt := range metadataTests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := env.service.UpdateMetadata(tt.ctx, tt.req)
+			if got := statusOf(err); got != tt.wantStatus || (tt.wantStatus == 0 && err != nil) {
+				t.Fatalf("UpdateMetadata error = %v, want status %d", err, tt.wantStatus)
+			}
+			if got := env.db.Metadata(fileID); !maps.Equal(got, tt.wantMetadata) {
+				t.Errorf("metadata = %v, want %v", got, tt.wantMetadata)
+			}
+		})
+	}
+}
+
+// labelFilterRepo answers ListRootContents with fixed rows and records its
+// parameters, since the filtering itself runs in the database.
+type labelFilterRepo struct {
+	*memdb.DB
+	rows []sqlc.ListRootContentsRow
+	got  *sqlc.ListRootContentsParams
+}
+
+func (r *labelFilterRepo) ListRootContents(ctx context.Context, arg sqlc.ListRootContentsParams) ([]sqlc.ListRootContentsRow, error) {
+	r.got = &arg
+	return r.rows, nil
+}
+
+func TestListContentsLabelFilters(t *testing.T) {
+	row := sqlc.ListRootContentsRow{
+		ID:         uuid.New(),
+		Filename:   "report.pdf",
+		ItemType:   "file",
+		Tags:       []string{"finance", "q3"},
+		Metadata:   []byte(`{"status": "final"}`),
+		TotalCount: 1,
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantTags   []string
+		wantKeys   []string
+		wantOps    []string
+		wantValues []string
+	}{
+		{
+			name:       "tags and predicates",
+			query:      "tags=Finance,+q3&metadata=status%3Dfinal&metadata=owner!%3Dbob&metadata=Project~apo&metadata=due",
+			wantStatus: http.StatusOK,
+			wantTags:   []string{"finance", "q3"},
+			wantKeys:   []string{"status", "owner", "project", "due"},
+			wantOps:    []string{"eq", "ne", "contains", "exists"},
+			wantValues: []string{"final", "bob", "apo", ""},
+		},
+		{name: "no filters", query: "", wantStatus: http.StatusOK, wantKeys: []string{}, wantOps: []string{}, wantValues: []string{}},
+		{name: "bad operator", query: "metadata=status!final", wantStatus: http.StatusBadRequest},
+		{name: "empty key", query: "metadata=%3Dfinal", wantStatus: http.StatusBadRequest},
+		{name: "empty tag", query: "tags=finance,,q3", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			userID, _ := env.createUser(t, "owner@example.com", 1<<20)
+			repo := &labelFilterRepo{DB: env.db, rows: []sqlc.ListRootContentsRow{row}}
+			env.service = files.NewService(repo, env.db, env.db, env.store, nopAudit{}, "http://vault.test")
+
+			rec := httptest.NewRecorder()
+			newTestRouter(env, userID).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", rec.Code, rec.Body, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if repo.got != nil {
+					t.Errorf("listed with %+v, want no listing", *repo.got)
+				}
+				return
+			}
+
+			got := repo.got
+			if !slices.Equal(got.Tags, tt.wantTags) || !slices.Equal(got.MetadataKeys, tt.wantKeys) ||
+				!slices.Equal(got.MetadataOps, tt.wantOps) || !slices.Equal(got.MetadataValues, tt.wantValues) {
+				t.Errorf("listed with tags %v and metadata %v %v %v, want %v and %v %v %v",
+					got.Tags, got.MetadataKeys, got.MetadataOps, got.MetadataValues,
+					tt.wantTags, tt.wantKeys, tt.wantOps, tt.wantValues)
+			}
+			var res files.ListContentsResponse
+			if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Data) != 1 {
+				t.Fatalf("response = %+v, want one item", res)
+			}
+			item := res.Data[0]
+			if !slices.Equal(item.Tags, row.Tags) || !maps.Equal(item.Metadata, map[string]string{"status": "final"}) {
+				t.Errorf("item labels = %v %v, want %v and status final", item.Tags, item.Metadata, row.Tags)
+			}
+		})
+	}
+}
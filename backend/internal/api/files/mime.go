@@ -0,0 +1,134 @@
+package files
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// sniffLen is the number of bytes http.DetectContentType considers.
+const sniffLen = 512
+
+// zipContainerTypes are formats that are ZIP archives on the wire and are
+// therefore sniffed as application/zip.
+var zipContainerTypes = []string{
+	"application/vnd.openxmlformats-officedocument.",
+	"application/vnd.oasis.opendocument.",
+	"application/epub+zip",
+	"application/java-archive",
+	"application/vnd.android.package-archive",
+}
+
+// sniffableFamilies are type prefixes that http.DetectContentType recognises.
+// A declared type in one of these families is only trusted if sniffing agrees.
+var sniffableFamilies = []string{"image/", "audio/", "video/", "text/", "application/pdf", "application/zip", "application/x-gzip", "application/wasm"}
+
+// inlineTypes are the content types that may be rendered by the browser with ?inline=1.
+// Scriptable formats such as text/html and image/svg+xml are deliberately missing.
+var inlineTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"image/bmp":       true,
+	"image/x-icon":    true,
+	"application/pdf": true,
+	"text/plain":      true,
+	"audio/mpeg":      true,
+	"audio/ogg":       true,
+	"audio/wave":      true,
+	"video/mp4":       true,
+	"video/webm":      true,
+}
+
+// verifyMIME decides the content type stored for an upload from the type declared
+// by the client and the type sniffed from its first bytes. The declared type is
+// kept only when the content is consistent with it, otherwise the sniffed type wins.
+func verifyMIME(declared, sniffed string) string {
+	declaredType := mediaType(declared)
+	sniffedType := mediaType(sniffed)
+
+	switch {
+	case declaredType == "":
+		return sniffed
+	case declaredType == sniffedType:
+		return sniffed
+	case sniffedType == "application/zip" && hasAnyPrefix(declaredType, zipContainerTypes):
+		return declaredType
+	case sniffedType == "text/plain" && isTextual(declaredType):
+		return declaredType
+	case sniffedType == "application/octet-stream" && !hasAnyPrefix(declaredType, sniffableFamilies):
+		return declaredType
+	}
+	return sniffed
+}
+
+// isTextual reports whether a declared type may be plain text content.
+// HTML and XML are sniffed reliably, so they are not accepted for plain text.
+func isTextual(mediaType string) bool {
+	switch mediaType {
+	case "text/html", "text/xml", "image/svg+xml":
+		return false
+	case "application/json", "application/javascript", "application/x-yaml", "application/sql":
+		return true
+	}
+	return strings.HasPrefix(mediaType, "text/")
+}
+
+// mediaType returns the lower-cased media type of a Content-Type value without parameters.
+func mediaType(contentType string) string {
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return t
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// sniffObject detects the content type of a stored object from its first bytes.
+func (s *Service) sniffObject(ctx context.Context, path string) (string, error) {
+	obj, err := s.storage.GetBlobRange(ctx, path, 0, sniffLen)
+	if err != nil {
+		return "", err
+	}
+	defer obj.Close()
+
+	head, err := io.ReadAll(obj)
+	if err != nil {
+		return "", err
+	}
+	return http.DetectContentType(head), nil
+}
+
+// contentDisposition returns the Content-Disposition and Content-Type headers for
+// serving a file. Inline disposition is only granted to allowlisted types, text is
+// always served as plain text so that it cannot be interpreted as markup.
+func contentDisposition(filename, storedType string, inline bool) (disposition, contentType string) {
+	contentType = storedType
+	if mediaType(contentType) == "" {
+		contentType = "application/octet-stream"
+	}
+
+	disposition = "attachment"
+	if t := mediaType(contentType); inline && (inlineTypes[t] || isTextual(t)) {
+		disposition = "inline"
+		if isTextual(t) && t != "text/plain" {
+			contentType = "text/plain; charset=utf-8"
+		}
+	}
+
+	if header := mime.FormatMediaType(disposition, map[string]string{"filename": filename}); header != "" {
+		return header, contentType
+	}
+	return disposition, contentType
+}
@@ -0,0 +1,81 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/access"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// authorizeFile returns the file and the authenticated user's ID if the user
+// holds at least the required permission on it.
+func (s *Service) authorizeFile(ctx context.Context, fileID uuid.UUID, required access.Permission) (sqlc.File, int64, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return sqlc.File{}, 0, apierror.NewUnauthorizedError()
+	}
+
+	file, err := s.repo.GetFileByUUID(ctx, fileID)
+	if err != nil {
+		return sqlc.File{}, 0, apierror.NewNotFoundError("File")
+	}
+
+	permission, err := s.filePermission(ctx, file, userID)
+	if err != nil {
+		return sqlc.File{}, 0, err
+	}
+	if permission < required {
+		return sqlc.File{}, 0, apierror.NewForbiddenError()
+	}
+	return file, userID, nil
+}
+
+// filePermission returns the permission userID holds on file, which is the
+// highest of its direct share and the shares on the folders containing it.
+func (s *Service) filePermission(ctx context.Context, file sqlc.File, userID int64) (access.Permission, error) {
+	if file.OwnerID == userID {
+		return access.Owner, nil
+	}
+
+	var stored []string
+	direct, err := s.repo.GetSharePermission(ctx, file.ID, userID)
+	switch {
+	case err == nil:
+		stored = append(stored, direct)
+	case !errors.Is(err, pgx.ErrNoRows):
+		log.Printf("Failed to look up permission of user %d on %s: %v", userID, file.ID, err)
+		return access.None, apierror.NewInternalServerError("Failed to check permissions")
+	}
+
+	if file.FolderID.Valid {
+		inherited, err := s.folderRepo.GetInheritedFolderPermissions(ctx, file.FolderID.Bytes, userID)
+		if err != nil {
+			log.Printf("Failed to look up folder permissions of user %d on %s: %v", userID, file.ID, err)
+			return access.None, apierror.NewInternalServerError("Failed to check permissions")
+		}
+		stored = append(stored, inherited...)
+	}
+
+	return access.Highest(stored), nil
+}
+
+// folderPermission returns the permission userID holds on folder through
+// ownership or a share on the folder or one of its ancestors.
+func (s *Service) folderPermission(ctx context.Context, folder sqlc.Folder, userID int64) (access.Permission, error) {
+	if folder.OwnerID == userID {
+		return access.Owner, nil
+	}
+
+	inherited, err := s.folderRepo.GetInheritedFolderPermissions(ctx, folder.ID, userID)
+	if err != nil {
+		log.Printf("Failed to look up folder permissions of user %d on %s: %v", userID, folder.ID, err)
+		return access.None, apierror.NewInternalServerError("Failed to check permissions")
+	}
+	return access.Highest(inherited), nil
+}
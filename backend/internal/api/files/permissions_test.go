@@ -0,0 +1,263 @@
+package files_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
+	"github.com/google/uuid"
+)
+
+func TestFilePermissions(t *testing.T) {
+	operations := []struct {
+		name     string
+		required string // lowest permission that is allowed
+		run      func(ctx context.Context, env *testEnv, fileID uuid.UUID, otherID int64) error
+	}{
+		{"download", "read", func(ctx context.Context, env *testEnv, fileID uuid.UUID, _ int64) error {
+			d, err := env.service.DownloadFile(ctx, fileID)
+			if err == nil {
+				d.Content.Close()
+			}
+			return err
+		}},
+		{"rename", "write", func(ctx context.Context, env *testEnv, fileID uuid.UUID, _ int64) error {
+			_, err := env.service.UpdateFilename(ctx, "renamed.txt", fileID)
+			return err
+		}},
+		{"replace contents", "write", func(ctx context.Context, env *testEnv, fileID uuid.UUID, _ int64) error {
+			_, err := env.service.ReplaceFileContent(ctx, fileID, strings.NewReader("new contents"), "text/plain")
+			return err
+		}},
+		{"share", "reshare", func(ctx context.Context, env *testEnv, fileID uuid.UUID, otherID int64) error {
+			return env.service.UpdateFileShares(ctx, files.UpdateFileSharesRequest{
+				FileID: fileID,
+				Shares: []files.Share{{UserID: otherID, Permission: "read"}},
+			})
+		}},
+		{"create public link", "reshare", func(ctx context.Context, env *testEnv, fileID uuid.UUID, _ int64) error {
+			_, err := env.service.CreatePublicLink(ctx, fileID, files.PublicLinkRequest{})
+			return err
+		}},
+		{"move", "owner", func(ctx context.Context, env *testEnv, fileID uuid.UUID, _ int64) error {
+			return env.service.MoveFile(ctx, fileID, files.MoveFileRequest{})
+		}},
+		{"delete", "owner", func(ctx context.Context, env *testEnv, fileID uuid.UUID, _ int64) error {
+			return env.service.DeleteFile(ctx, fileID)
+		}},
+	}
+	levels := []string{"", "read", "comment", "write", "reshare", "owner"}
+	rank := func(level string) int {
+		for i, l := range levels {
+			if l == level {
+				return i
+			}
+		}
+		t.Fatalf("unknown level %q", level)
+		return 0
+	}
+
+	for _, op := range operations {
+		for _, level := range levels {
+			name := level
+			if name == "" {
+				name = "not shared"
+			}
+			t.Run(op.name+"/"+name, func(t *testing.T) {
+				env := newTestEnv(t)
+				ownerID, ownerCtx := env.createUser(t, "owner@example.com", 1<<20)
+				userID, userCtx := env.createUser(t, "user@example.com", 1<<20)
+				otherID, _ := env.createUser(t, "other@example.com", 1<<20)
+				fileID, err := upload(ownerCtx, env.service, "file.txt", "contents")
+				if err != nil {
+					t.Fatalf("UploadFile: %v", err)
+				}
+
+				ctx := userCtx
+				switch level {
+				case "owner":
+					ctx, userID = ownerCtx, ownerID
+				case "":
+				default:
+					if err := env.service.UpdateFileShares(ownerCtx, files.UpdateFileSharesRequest{
+						FileID: fileID,
+						Shares: []files.Share{{UserID: userID, Permission: level}},
+					}); err != nil {
+						t.Fatalf("UpdateFileShares: %v", err)
+					}
+				}
+
+				err = op.run(ctx, env, fileID, otherID)
+				wantStatus := 0
+				if rank(level) < rank(op.required) {
+					wantStatus = http.StatusForbidden
+				}
+				if got := statusOf(err); got != wantStatus || (wantStatus == 0 && err != nil) {
+					t.Errorf("error = %v, want status %d", err, wantStatus)
+				}
+			})
+		}
+	}
+}
+
+func TestUpdateFileShares(t *testing.T) {
+	tests := []struct {
+		name       string
+		asResharer bool
+		shares     []files.Share
+		wantStatus int
+		want       map[string]string // email -> permission
+	}{
+		{
+			name:   "new user without permission gets read",
+			shares: []files.Share{{UserID: 2}, {UserID: 3}},
+			want:   map[string]string{"writer@example.com": "write", "new@example.com": "read"},
+		},
+		{
+			name:   "explicit permission",
+			shares: []files.Share{{UserID: 2, Permission: "read"}, {UserID: 3, Permission: "comment"}},
+			want:   map[string]string{"writer@example.com": "read", "new@example.com": "comment"},
+		},
+		{
+			name:       "invalid permission",
+			shares:     []files.Share{{UserID: 3, Permission: "admin"}},
+			wantStatus: http.StatusBadRequest,
+			want:       map[string]string{"writer@example.com": "write", "resharer@example.com": "reshare"},
+		},
+		{
+			name:       "owner",
+			shares:     []files.Share{{UserID: 1, Permission: "read"}},
+			wantStatus: http.StatusBadRequest,
+			want:       map[string]string{"writer@example.com": "write", "resharer@example.com": "reshare"},
+		},
+		{
+			name:       "duplicate user",
+			shares:     []files.Share{{UserID: 3}, {UserID: 3, Permission: "write"}},
+			wantStatus: http.StatusBadRequest,
+			want:       map[string]string{"writer@example.com": "write", "resharer@example.com": "reshare"},
+		},
+		{
+			name:       "resharer adds to the existing shares",
+			asResharer: true,
+			shares:     []files.Share{{UserID: 3, Permission: "write"}},
+			want:       map[string]string{"writer@example.com": "write", "new@example.com": "write", "resharer@example.com": "reshare"},
+		},
+		{
+			name:       "resharer raises a share",
+			asResharer: true,
+			shares:     []files.Share{{UserID: 2, Permission: "reshare"}},
+			want:       map[string]string{"writer@example.com": "reshare", "resharer@example.com": "reshare"},
+		},
+		{
+			name:       "resharer cannot lower a share",
+			asResharer: true,
+			shares:     []files.Share{{UserID: 2, Permission: "read"}},
+			wantStatus: http.StatusForbidden,
+			want:       map[string]string{"writer@example.com": "write", "resharer@example.com": "reshare"},
+		},
+		{
+			name:       "resharer cannot lower their own share",
+			asResharer: true,
+			shares:     []files.Share{{UserID: 4, Permission: "read"}},
+			wantStatus: http.StatusForbidden,
+			want:       map[string]string{"writer@example.com": "write", "resharer@example.com": "reshare"},
+		},
+		{
+			name:   "owner removes a share",
+			shares: []files.Share{{UserID: 4}},
+			want:   map[string]string{"resharer@example.com": "reshare"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			// IDs are assigned in order: owner 1, writer 2, new 3, resharer 4.
+			_, ownerCtx := env.createUser(t, "owner@example.com", 1<<20)
+			writerID, _ := env.createUser(t, "writer@example.com", 1<<20)
+			env.createUser(t, "new@example.com", 1<<20)
+			resharerID, resharerCtx := env.createUser(t, "resharer@example.com", 1<<20)
+			fileID, err := upload(ownerCtx, env.service, "file.txt", "contents")
+			if err != nil {
+				t.Fatalf("UploadFile: %v", err)
+			}
+			if err := env.service.UpdateFileShares(ownerCtx, files.UpdateFileSharesRequest{
+				FileID: fileID,
+				Shares: []files.Share{{UserID: writerID, Permission: "write"}, {UserID: resharerID, Permission: "reshare"}},
+			}); err != nil {
+				t.Fatalf("seeding shares: %v", err)
+			}
+
+			ctx := ownerCtx
+			if tt.asResharer {
+				ctx = resharerCtx
+			}
+			err = env.service.UpdateFileShares(ctx, files.UpdateFileSharesRequest{FileID: fileID, Shares: tt.shares})
+			if got := statusOf(err); got != tt.wantStatus || (tt.wantStatus == 0 && err != nil) {
+				t.Fatalf("UpdateFileShares error = %v, want status %d", err, tt.wantStatus)
+			}
+
+			users, err := env.service.ListUsersWithAccessToFile(ownerCtx, fileID)
+			if err != nil {
+				t.Fatalf("ListUsersWithAccessToFile: %v", err)
+			}
+			got := map[string]string{}
+			for _, u := range users {
+				got[u.Email] = u.Permission
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("shares = %v, want %v", got, tt.want)
+			}
+			for email, permission := range tt.want {
+				if got[email] != permission {
+					t.Errorf("%s permission = %q, want %q", email, got[email], permission)
+				}
+			}
+		})
+	}
+}
+
+func TestReplaceFileContent(t *testing.T) {
+	env := newTestEnv(t)
+	ownerID, ownerCtx := env.createUser(t, "owner@example.com", 32)
+	writerID, writerCtx := env.createUser(t, "writer@example.com", 1<<20)
+	fileID, err := upload(ownerCtx, env.service, "notes.txt", "first draft")
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	if err := env.service.UpdateFileShares(ownerCtx, files.UpdateFileSharesRequest{
+		FileID: fileID,
+		Shares: []files.Share{{UserID: writerID, Permission: "write"}},
+	}); err != nil {
+		t.Fatalf("UpdateFileShares: %v", err)
+	}
+
+	// The owner's quota applies, and the old contents are kept as a version.
+	if _, err := env.service.ReplaceFileContent(writerCtx, fileID, strings.NewReader("this is far too long to fit"), "text/plain"); statusOf(err) != http.StatusRequestEntityTooLarge {
+		t.Fatalf("ReplaceFileContent over quota: error = %v, want 413", err)
+	}
+	res, err := env.service.ReplaceFileContent(writerCtx, fileID, strings.NewReader("second draft, longer"), "text/plain")
+	if err != nil {
+		t.Fatalf("ReplaceFileContent: %v", err)
+	}
+
+	if res.ID != fileID || res.Size != int64(len("second draft, longer")) {
+		t.Errorf("got file %s of %d bytes, want %s of %d bytes", res.ID, res.Size, fileID, len("second draft, longer"))
+	}
+	if want := res.Size + int64(len("first draft")); env.usedStorage(t, ownerID) != want {
+		t.Errorf("owner storage used = %d, want %d", env.usedStorage(t, ownerID), want)
+	}
+	if got := env.usedStorage(t, writerID); got != 0 {
+		t.Errorf("writer storage used = %d, want 0", got)
+	}
+	blobs := env.db.Blobs()
+	if len(blobs) != 2 || blobs[0].Refcount != 1 || blobs[1].Refcount != 1 {
+		t.Fatalf("blobs = %+v, want the old and new blob with refcount 1", blobs)
+	}
+	if got := len(env.store.Keys()); got != 2 {
+		t.Errorf("got %d objects in storage, want 2", got)
+	}
+	env.assertNoTempObjects(t)
+}
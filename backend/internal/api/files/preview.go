@@ -0,0 +1,144 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/access"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/preview"
+	"github.com/google/uuid"
+)
+
+// textPreviewSize names the text previews in storage, which are the same for
+// every thumbnail size.
+const textPreviewSize = "text"
+
+// maxPreviewJobs is the number of previews generated at the same time. Images
+// are decoded in memory, so generating many at once could exhaust it.
+const maxPreviewJobs = 4
+
+// Preview is a thumbnail or text preview of a file, ready to be served.
+type Preview struct {
+	Content     []byte
+	ContentType string
+	Blob        sqlc.Blob
+	Size        string
+}
+
+// ETag returns a strong entity tag derived from the blob's SHA-256 and the
+// size of the preview, which together determine its content.
+func (p *Preview) ETag() string {
+	return `"` + p.Blob.Sha256 + "-" + p.Size + `"`
+}
+
+// ModTime returns the time the blob was stored, used for Last-Modified.
+func (p *Preview) ModTime() time.Time {
+	return p.Blob.CreatedAt.Time
+}
+
+// previewKey returns the storage key of the preview of a blob in the given
+// size. Previews are keyed by the blob's SHA-256, so files with the same
+// contents share them.
+func previewKey(sha, size string) string {
+	return fmt.Sprintf("previews/%s_%s", sha, size)
+}
+
+// previewKeys returns the storage keys of every preview of a blob.
+func previewKeys(sha string) []string {
+	keys := []string{previewKey(sha, textPreviewSize)}
+	for _, size := range slices.Sorted(maps.Keys(preview.Sizes)) {
+		keys = append(keys, previewKey(sha, size))
+	}
+	return keys
+}
+
+// GetThumbnail returns the preview of a file in the given size: a thumbnail
+// for images and the first page for text files. The user needs read access to
+// the file, like for downloading it. Previews are generated the first time
+// they are requested and stored alongside the blobs.
+func (s *Service) GetThumbnail(ctx context.Context, fileID uuid.UUID, size string) (*Preview, error) {
+	if size == "" {
+		size = preview.DefaultSize
+	}
+	pixels, ok := preview.Sizes[size]
+	if !ok {
+		sizes := slices.Sorted(maps.Keys(preview.Sizes))
+		return nil, apierror.NewBadRequestError("Invalid size, must be one of " + strings.Join(sizes, ", "))
+	}
+
+	file, _, err := s.authorizeFile(ctx, fileID, access.Read)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := s.repo.GetBlobByID(ctx, file.BlobID)
+	if err != nil {
+		return nil, apierror.NewInternalServerError("Unable to fetch blob")
+	}
+
+	contentType := preview.ContentType(blob.MimeType.String, file.Filename)
+	if contentType == "" {
+		return nil, apierror.NewNotFoundError("Preview")
+	}
+	if preview.IsText(blob.MimeType.String, file.Filename) {
+		size = textPreviewSize
+	}
+	p := &Preview{ContentType: contentType, Blob: blob, Size: size}
+	key := previewKey(blob.Sha256, size)
+
+	if content, err := s.readObject(ctx, key); err == nil {
+		p.Content = content
+		return p, nil
+	}
+
+	select {
+	case s.previewJobs <- struct{}{}:
+		defer func() { <-s.previewJobs }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	content, err := s.generatePreview(ctx, blob, file.Filename, pixels)
+	switch {
+	case errors.Is(err, preview.ErrUnsupported), errors.Is(err, preview.ErrTooLarge):
+		return nil, apierror.NewNotFoundError("Preview")
+	case err != nil:
+		log.Printf("Failed to generate the %s preview of blob %s: %v", size, blob.ID, err)
+		return nil, apierror.NewInternalServerError("Failed to generate preview")
+	}
+
+	// a failure to store the preview only means it is generated again next time
+	if _, err := s.storage.UploadBlob(ctx, bytes.NewReader(content), key, int64(len(content)), contentType); err != nil {
+		log.Printf("Failed to store the %s preview of blob %s: %v", size, blob.ID, err)
+	}
+	p.Content = content
+	return p, nil
+}
+
+func (s *Service) generatePreview(ctx context.Context, blob sqlc.Blob, filename string, pixels int) ([]byte, error) {
+	body, err := s.storage.GetBlob(ctx, blob.StoragePath)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return preview.Generate(body, blob.MimeType.String, filename, pixels)
+}
+
+// readObject reads a whole object from storage.
+func (s *Service) readObject(ctx context.Context, key string) ([]byte, error) {
+	body, err := s.storage.GetBlob(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
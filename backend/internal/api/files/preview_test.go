@@ -0,0 +1,114 @@
+package files_test
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestGetThumbnail serves the thumbnails of two uploads of the same image,
+// which share them, and checks they are deleted along with the blob.
+func TestGetThumbnail(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 600, 300))); err != nil {
+		t.Fatal(err)
+	}
+
+	env := newTestEnv(t)
+	ownerID, ctx := env.createUser(t, "owner@example.com", 1<<20)
+	strangerID, _ := env.createUser(t, "stranger@example.com", 1<<20)
+	photoID, err := upload(ctx, env.service, "photo.png", buf.String())
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	copyID, err := upload(ctx, env.service, "photo copy.png", buf.String())
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	notesID, err := upload(ctx, env.service, "notes.txt", "line one\nline two\n")
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	archiveID, err := upload(ctx, env.service, "data.bin", "\x00\x01\x02\x03")
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		userID      int64
+		fileID      uuid.UUID
+		query       string
+		wantStatus  int
+		wantType    string
+		wantWidth   int
+		wantPreview string
+	}{
+		{name: "default size", userID: ownerID, fileID: photoID, wantStatus: http.StatusOK, wantType: "image/png", wantWidth: 256},
+		{name: "small", userID: ownerID, fileID: photoID, query: "?size=small", wantStatus: http.StatusOK, wantType: "image/png", wantWidth: 128},
+		{name: "same contents", userID: ownerID, fileID: copyID, query: "?size=small", wantStatus: http.StatusOK, wantType: "image/png", wantWidth: 128},
+		{name: "text", userID: ownerID, fileID: notesID, query: "?size=large", wantStatus: http.StatusOK, wantType: "text/plain; charset=utf-8", wantPreview: "line one\nline two\n"},
+		{name: "invalid size", userID: ownerID, fileID: photoID, query: "?size=huge", wantStatus: http.StatusBadRequest},
+		{name: "no preview", userID: ownerID, fileID: archiveID, wantStatus: http.StatusNotFound},
+		{name: "not shared", userID: strangerID, fileID: photoID, wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/files/"+tt.fileID.String()+"/thumbnail"+tt.query, nil)
+			newTestRouter(env, tt.userID).ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", rec.Code, rec.Body, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if rec.Header().Get("ETag") == "" || !strings.HasPrefix(rec.Header().Get("Cache-Control"), "private") {
+				t.Errorf("caching headers = %v, want an ETag and private caching", rec.Header())
+			}
+			if tt.wantWidth == 0 {
+				if got := rec.Body.String(); got != tt.wantPreview {
+					t.Errorf("preview = %q, want %q", got, tt.wantPreview)
+				}
+			} else if config, err := png.DecodeConfig(rec.Body); err != nil || config.Width != tt.wantWidth {
+				t.Errorf("thumbnail is %d pixels wide (%v), want %d", config.Width, err, tt.wantWidth)
+			}
+
+			// the ETag revalidates the cached preview
+			req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+			rec = httptest.NewRecorder()
+			newTestRouter(env, tt.userID).ServeHTTP(rec, req)
+			if rec.Code != http.StatusNotModified {
+				t.Errorf("revalidation status = %d, want %d", rec.Code, http.StatusNotModified)
+			}
+		})
+	}
+
+	previews := func() []string {
+		return slices.DeleteFunc(env.store.Keys(), func(key string) bool { return !strings.HasPrefix(key, "previews/") })
+	}
+	if got := previews(); len(got) != 3 {
+		t.Fatalf("stored previews = %v, want two thumbnails of the image and the text preview", got)
+	}
+	for _, id := range []uuid.UUID{photoID, copyID} {
+		if err := env.service.DeleteFile(ctx, id); err != nil {
+			t.Fatalf("DeleteFile: %v", err)
+		}
+		if err := env.service.PurgeFile(ctx, id); err != nil {
+			t.Fatalf("PurgeFile: %v", err)
+		}
+	}
+	if got := previews(); len(got) != 1 {
+		t.Errorf("stored previews after purging the image = %v, want the text preview", got)
+	}
+}
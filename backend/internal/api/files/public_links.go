@@ -0,0 +1,225 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/access"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// GetPublicLink returns the public link of a file the current user may reshare.
+func (s *Service) GetPublicLink(ctx context.Context, fileID uuid.UUID) (PublicLinkResponse, error) {
+	file, _, err := s.authorizeFile(ctx, fileID, access.Reshare)
+	if err != nil {
+		return PublicLinkResponse{}, err
+	}
+	if !file.IsPublic.Bool {
+		return PublicLinkResponse{}, apierror.NewNotFoundError("Public link")
+	}
+	return s.publicLinkResponse(file), nil
+}
+
+// CreatePublicLink makes a file the current user may reshare available without an account.
+// Calling it on a file that already has a link replaces the restrictions and resets
+// the link's download count, the token stays the same.
+func (s *Service) CreatePublicLink(ctx context.Context, fileID uuid.UUID, req PublicLinkRequest) (PublicLinkResponse, error) {
+	file, userID, err := s.authorizeFile(ctx, fileID, access.Reshare)
+	if err != nil {
+		return PublicLinkResponse{}, err
+	}
+
+	arg := sqlc.EnablePublicLinkParams{
+		Token: uuid.New(),
+		ID:    file.ID,
+	}
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			return PublicLinkResponse{}, apierror.NewBadRequestError("expires_at must be in the future")
+		}
+		arg.ExpiresAt = pgtype.Timestamptz{Time: *req.ExpiresAt, Valid: true}
+	}
+	if req.MaxDownloads != nil {
+		if *req.MaxDownloads <= 0 {
+			return PublicLinkResponse{}, apierror.NewBadRequestError("max_downloads must be positive")
+		}
+		arg.MaxDownloads = pgtype.Int4{Int32: *req.MaxDownloads, Valid: true}
+	}
+	if req.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return PublicLinkResponse{}, apierror.NewBadRequestError("Invalid password")
+		}
+		arg.PasswordHash = pgtype.Text{String: string(hash), Valid: true}
+	}
+
+	file, err = s.repo.EnablePublicLink(ctx, arg)
+	if err != nil {
+		log.Printf("Failed to enable public link for %s: %v", fileID, err)
+		return PublicLinkResponse{}, apierror.NewInternalServerError("Failed to create public link")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "PUBLIC_LINK_CREATED",
+		TargetID: file.ID,
+		Details: map[string]interface{}{
+			"filename":           file.Filename,
+			"expires_at":         req.ExpiresAt,
+			"max_downloads":      req.MaxDownloads,
+			"password_protected": arg.PasswordHash.Valid,
+		},
+	})
+
+	return s.publicLinkResponse(file), nil
+}
+
+// RotatePublicLink replaces the token of a file's public link, so the previous URL
+// stops working. The restrictions of the link are kept.
+func (s *Service) RotatePublicLink(ctx context.Context, fileID uuid.UUID) (PublicLinkResponse, error) {
+	file, userID, err := s.authorizeFile(ctx, fileID, access.Reshare)
+	if err != nil {
+		return PublicLinkResponse{}, err
+	}
+
+	file, err = s.repo.RotatePublicToken(ctx, sqlc.RotatePublicTokenParams{
+		Token: uuid.New(),
+		ID:    file.ID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return PublicLinkResponse{}, apierror.NewNotFoundError("Public link")
+	}
+	if err != nil {
+		log.Printf("Failed to rotate public link of %s: %v", fileID, err)
+		return PublicLinkResponse{}, apierror.NewInternalServerError("Failed to rotate public link")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "PUBLIC_LINK_ROTATED",
+		TargetID: file.ID,
+		Details:  map[string]interface{}{"filename": file.Filename},
+	})
+
+	return s.publicLinkResponse(file), nil
+}
+
+// RevokePublicLink removes the public link of a file the current user may reshare.
+func (s *Service) RevokePublicLink(ctx context.Context, fileID uuid.UUID) error {
+	file, userID, err := s.authorizeFile(ctx, fileID, access.Reshare)
+	if err != nil {
+		return err
+	}
+	if !file.IsPublic.Bool {
+		return apierror.NewNotFoundError("Public link")
+	}
+
+	if err := s.repo.DisablePublicLink(ctx, file.ID); err != nil {
+		log.Printf("Failed to revoke public link of %s: %v", fileID, err)
+		return apierror.NewInternalServerError("Failed to revoke public link")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "PUBLIC_LINK_REVOKED",
+		TargetID: file.ID,
+		Details: map[string]interface{}{
+			"filename":       file.Filename,
+			"download_count": file.PublicDownloadCount,
+		},
+	})
+	return nil
+}
+
+// OpenPublicLink returns a Download for the file linked with token, checking
+// that the link is still usable and that password matches, if one is set.
+// Like DownloadFile nothing is recorded, ClaimPublicDownload counts the download.
+func (s *Service) OpenPublicLink(ctx context.Context, token uuid.UUID, password string) (*Download, error) {
+	file, err := s.repo.GetFileByPublicToken(ctx, token)
+	if err != nil {
+		return nil, apierror.NewNotFoundError("Link")
+	}
+
+	if file.PublicExpiresAt.Valid && !file.PublicExpiresAt.Time.After(time.Now()) {
+		return nil, apierror.New(http.StatusGone, "Link has expired")
+	}
+	if file.PublicMaxDownloads.Valid && file.PublicDownloadCount >= file.PublicMaxDownloads.Int32 {
+		return nil, apierror.New(http.StatusGone, "Link has reached its download limit")
+	}
+	if file.PublicPasswordHash.Valid {
+		if password == "" {
+			return nil, apierror.New(http.StatusUnauthorized, "Password required")
+		}
+		if bcrypt.CompareHashAndPassword([]byte(file.PublicPasswordHash.String), []byte(password)) != nil {
+			return nil, apierror.New(http.StatusUnauthorized, "Invalid password")
+		}
+	}
+
+	blob, err := s.repo.GetBlobByID(ctx, file.BlobID)
+	if err != nil {
+		return nil, apierror.NewInternalServerError("Unable to fetch blob")
+	}
+
+	return &Download{
+		File:    file,
+		Blob:    blob,
+		Content: newBlobReadSeeker(ctx, s.storage, blob),
+	}, nil
+}
+
+// ClaimPublicDownload counts a download through the public link of d and records
+// it in the audit log. The link's expiry and download limit are checked again
+// atomically, so concurrent requests cannot exceed the limit.
+func (s *Service) ClaimPublicDownload(ctx context.Context, d *Download) error {
+	count, err := s.repo.ClaimPublicDownload(ctx, d.File.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return apierror.New(http.StatusGone, "Link is no longer available")
+	}
+	if err != nil {
+		log.Printf("Failed to claim public download of %s: %v", d.File.ID, err)
+		return apierror.NewInternalServerError("Failed to record download")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		Action:   "PUBLIC_LINK_USED",
+		TargetID: d.File.ID,
+		Details: map[string]interface{}{
+			"filename":       d.File.Filename,
+			"download_count": count,
+		},
+	})
+	return nil
+}
+
+// publicLinkURL returns the URL of a public link, or "" if the file has none.
+func (s *Service) publicLinkURL(file sqlc.File) string {
+	if !file.IsPublic.Bool || !file.PublicToken.Valid {
+		return ""
+	}
+	return s.publicURL + "/s/" + uuid.UUID(file.PublicToken.Bytes).String()
+}
+
+func (s *Service) publicLinkResponse(file sqlc.File) PublicLinkResponse {
+	res := PublicLinkResponse{
+		URL:           s.publicLinkURL(file),
+		Token:         file.PublicToken.Bytes,
+		DownloadCount: file.PublicDownloadCount,
+		HasPassword:   file.PublicPasswordHash.Valid,
+	}
+	if file.PublicExpiresAt.Valid {
+		res.ExpiresAt = &file.PublicExpiresAt.Time
+	}
+	if file.PublicMaxDownloads.Valid {
+		res.MaxDownloads = &file.PublicMaxDownloads.Int32
+	}
+	return res
+}
@@ -0,0 +1,203 @@
+package files_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestPublicDownload(t *testing.T) {
+	const content = "hello world"
+
+	type request struct {
+		headers    map[string]string
+		wantStatus int
+		wantBody   string // checked for 200 responses
+	}
+	limit := func(n int32) *int32 { return &n }
+
+	tests := []struct {
+		name        string
+		link        files.PublicLinkRequest
+		requests    []request
+		wantCounted int32
+	}{
+		{
+			name: "unrestricted",
+			requests: []request{
+				{wantStatus: http.StatusOK, wantBody: content},
+				{headers: map[string]string{"Range": "bytes=6-"}, wantStatus: http.StatusPartialContent},
+			},
+			wantCounted: 1,
+		},
+		{
+			name: "password",
+			link: files.PublicLinkRequest{Password: "s3cret"},
+			requests: []request{
+				{wantStatus: http.StatusUnauthorized},
+				{headers: map[string]string{"X-Share-Password": "guess"}, wantStatus: http.StatusUnauthorized},
+				{headers: map[string]string{"X-Share-Password": "s3cret"}, wantStatus: http.StatusOK, wantBody: content},
+			},
+			wantCounted: 1,
+		},
+		{
+			name: "download limit",
+			link: files.PublicLinkRequest{MaxDownloads: limit(2)},
+			requests: []request{
+				{wantStatus: http.StatusOK, wantBody: content},
+				{headers: map[string]string{"Range": "bytes=6-"}, wantStatus: http.StatusOK, wantBody: content},
+				{wantStatus: http.StatusGone},
+			},
+			wantCounted: 2,
+		},
+		{
+			name: "matching etag does not use a download",
+			link: files.PublicLinkRequest{MaxDownloads: limit(1)},
+			requests: []request{
+				{headers: map[string]string{"If-None-Match": `"b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"`}, wantStatus: http.StatusNotModified},
+				{wantStatus: http.StatusOK, wantBody: content},
+				{wantStatus: http.StatusGone},
+			},
+			wantCounted: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			_, ctx := env.createUser(t, "alice@example.com", 1<<20)
+			fileID, err := upload(ctx, env.service, "greeting.txt", content)
+			if err != nil {
+				t.Fatalf("UploadFile: %v", err)
+			}
+			link, err := env.service.CreatePublicLink(ctx, fileID, tt.link)
+			if err != nil {
+				t.Fatalf("CreatePublicLink: %v", err)
+			}
+
+			router := newPublicRouter(env)
+			for i, req := range tt.requests {
+				rec := publicGet(router, link.Token, req.headers)
+				if rec.Code != req.wantStatus {
+					t.Fatalf("request %d: status = %d, want %d (body %q)", i, rec.Code, req.wantStatus, rec.Body.String())
+				}
+				if rec.Code == http.StatusOK && rec.Body.String() != req.wantBody {
+					t.Errorf("request %d: body = %q, want %q", i, rec.Body.String(), req.wantBody)
+				}
+			}
+
+			file, err := env.db.GetFileByUUID(context.Background(), fileID)
+			if err != nil {
+				t.Fatalf("GetFileByUUID: %v", err)
+			}
+			if file.PublicDownloadCount != tt.wantCounted {
+				t.Errorf("link download count = %d, want %d", file.PublicDownloadCount, tt.wantCounted)
+			}
+			if file.DownloadCount.Int64 != int64(tt.wantCounted) {
+				t.Errorf("download count = %d, want %d", file.DownloadCount.Int64, tt.wantCounted)
+			}
+		})
+	}
+}
+
+func TestPublicLinkLifecycle(t *testing.T) {
+	env := newTestEnv(t)
+	_, ownerCtx := env.createUser(t, "owner@example.com", 1<<20)
+	_, otherCtx := env.createUser(t, "other@example.com", 1<<20)
+	fileID, err := upload(ownerCtx, env.service, "report.txt", "numbers")
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	router := newPublicRouter(env)
+
+	if _, err := env.service.CreatePublicLink(otherCtx, fileID, files.PublicLinkRequest{}); statusOf(err) != http.StatusForbidden {
+		t.Fatalf("CreatePublicLink by another user: error = %v, want 403", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if _, err := env.service.CreatePublicLink(ownerCtx, fileID, files.PublicLinkRequest{ExpiresAt: &past}); statusOf(err) != http.StatusBadRequest {
+		t.Fatalf("CreatePublicLink with past expiry: error = %v, want 400", err)
+	}
+
+	link, err := env.service.CreatePublicLink(ownerCtx, fileID, files.PublicLinkRequest{})
+	if err != nil {
+		t.Fatalf("CreatePublicLink: %v", err)
+	}
+	if want := "http://vault.test/s/" + link.Token.String(); link.URL != want {
+		t.Errorf("URL = %q, want %q", link.URL, want)
+	}
+	info, err := env.service.GetShareInfo(ownerCtx, fileID)
+	if err != nil {
+		t.Fatalf("GetShareInfo: %v", err)
+	}
+	if info.ShareURL != link.URL {
+		t.Errorf("share info URL = %q, want %q", info.ShareURL, link.URL)
+	}
+
+	updated, err := env.service.CreatePublicLink(ownerCtx, fileID, files.PublicLinkRequest{Password: "pw"})
+	if err != nil {
+		t.Fatalf("CreatePublicLink again: %v", err)
+	}
+	if updated.Token != link.Token || !updated.HasPassword {
+		t.Errorf("updating the link: token %s, has password %v; want token %s kept with a password", updated.Token, updated.HasPassword, link.Token)
+	}
+
+	rotated, err := env.service.RotatePublicLink(ownerCtx, fileID)
+	if err != nil {
+		t.Fatalf("RotatePublicLink: %v", err)
+	}
+	if rec := publicGet(router, link.Token, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("old token after rotation: status = %d, want 404", rec.Code)
+	}
+	if rec := publicGet(router, rotated.Token, map[string]string{"X-Share-Password": "pw"}); rec.Code != http.StatusOK {
+		t.Errorf("new token after rotation: status = %d, want 200", rec.Code)
+	}
+
+	// Links can't be created with an expiry in the past, set one directly.
+	if _, err := env.db.EnablePublicLink(context.Background(), sqlc.EnablePublicLinkParams{
+		ID:        fileID,
+		ExpiresAt: pgtype.Timestamptz{Time: past, Valid: true},
+	}); err != nil {
+		t.Fatalf("EnablePublicLink: %v", err)
+	}
+	if rec := publicGet(router, rotated.Token, nil); rec.Code != http.StatusGone {
+		t.Errorf("expired link: status = %d, want 410", rec.Code)
+	}
+
+	if err := env.service.RevokePublicLink(ownerCtx, fileID); err != nil {
+		t.Fatalf("RevokePublicLink: %v", err)
+	}
+	if rec := publicGet(router, rotated.Token, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("revoked link: status = %d, want 404", rec.Code)
+	}
+	if _, err := env.service.RotatePublicLink(ownerCtx, fileID); statusOf(err) != http.StatusNotFound {
+		t.Errorf("RotatePublicLink after revoke: error = %v, want 404", err)
+	}
+	if info, _ := env.service.GetShareInfo(ownerCtx, fileID); info.ShareURL != "" {
+		t.Errorf("share info URL after revoke = %q, want empty", info.ShareURL)
+	}
+}
+
+// newPublicRouter returns an unauthenticated router with the public file routes.
+func newPublicRouter(env *testEnv) http.Handler {
+	router := chi.NewRouter()
+	files.NewFileHandler(env.service).RegisterPublicRoutes(router)
+	return router
+}
+
+func publicGet(router http.Handler, token uuid.UUID, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/s/"+token.String(), nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
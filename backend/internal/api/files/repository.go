@@ -6,11 +6,78 @@ import (
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// Repository handles database operations related to files
-type Repository struct {
+// Repository describes the database operations used by the files service.
+// It is implemented on top of sqlc queries by NewRepository.
+type Repository interface {
+	BeginTx(ctx context.Context) (pgx.Tx, error)
+	WithTx(tx pgx.Tx) Repository
+	GetBlobByID(ctx context.Context, id uuid.UUID) (sqlc.Blob, error)
+	GetBlobBySha(ctx context.Context, sha string) (sqlc.Blob, error)
+	GetFileByUUID(ctx context.Context, id uuid.UUID) (sqlc.File, error)
+	CreateBlob(ctx context.Context, arg sqlc.CreateBlobParams) (sqlc.Blob, error)
+	CreateFile(ctx context.Context, arg sqlc.CreateFileParams) (sqlc.File, error)
+	DeleteFile(ctx context.Context, fileID uuid.UUID) error
+	UpdateFilename(ctx context.Context, arg sqlc.UpdateFilenameParams) (sqlc.File, error)
+	UpdateFileBlob(ctx context.Context, arg sqlc.UpdateFileBlobParams) (sqlc.File, error)
+	ListUsersWithAccessToFile(ctx context.Context, fileID uuid.UUID) ([]sqlc.ListUsersWithAccessToFileRow, error)
+	GetSharePermission(ctx context.Context, fileID uuid.UUID, userID int64) (string, error)
+	ListFolderContents(ctx context.Context, arg sqlc.ListFolderContentsParams) ([]sqlc.ListFolderContentsRow, error)
+	ListRootContents(ctx context.Context, arg sqlc.ListRootContentsParams) ([]sqlc.ListRootContentsRow, error)
+	SearchContents(ctx context.Context, arg sqlc.SearchContentsParams) ([]sqlc.SearchContentsRow, error)
+	IncrementDownloadCount(ctx context.Context, fileID uuid.UUID) error
+	GetFolderByID(ctx context.Context, folderID uuid.UUID) (sqlc.Folder, error)
+	GetFolderByName(ctx context.Context, arg sqlc.GetFolderByNameParams) (sqlc.Folder, error)
+	CreateFolder(ctx context.Context, arg sqlc.CreateFolderParams) (sqlc.Folder, error)
+	CreateFolderIfNotExists(ctx context.Context, arg sqlc.CreateFolderIfNotExistsParams) (sqlc.Folder, error)
+	ListAllFiles(ctx context.Context, arg sqlc.ListAllFilesParams) ([]sqlc.ListAllFilesRow, error)
+	DeleteBlobIfUnused(ctx context.Context, blobID uuid.UUID) (sqlc.DeleteBlobIfUnusedRow, error)
+	UpdateFileFolder(ctx context.Context, arg sqlc.UpdateFileFolderParams) error
+	DeleteAllSharesForFile(ctx context.Context, fileID uuid.UUID) error
+	AddSharesToFile(ctx context.Context, arg []sqlc.AddSharesToFileParams) (int64, error)
+	CreateUploadSession(ctx context.Context, arg sqlc.CreateUploadSessionParams) (sqlc.UploadSession, error)
+	GetUploadSession(ctx context.Context, id uuid.UUID) (sqlc.UploadSession, error)
+	AdvanceUploadSession(ctx context.Context, arg sqlc.AdvanceUploadSessionParams) (sqlc.UploadSession, error)
+	ClaimUploadSession(ctx context.Context, id uuid.UUID) (sqlc.UploadSession, error)
+	ReleaseUploadSession(ctx context.Context, id uuid.UUID) error
+	AbortUploadSession(ctx context.Context, id uuid.UUID) (sqlc.UploadSession, error)
+	DeleteUploadSession(ctx context.Context, id uuid.UUID) error
+	DeleteExpiredUploadSessions(ctx context.Context) ([]sqlc.UploadSession, error)
+	CreateDirectUpload(ctx context.Context, arg sqlc.CreateDirectUploadParams) (sqlc.DirectUpload, error)
+	GetDirectUpload(ctx context.Context, id uuid.UUID) (sqlc.DirectUpload, error)
+	DeleteDirectUpload(ctx context.Context, id uuid.UUID) error
+	ClaimDirectUpload(ctx context.Context, arg sqlc.ClaimDirectUploadParams) (sqlc.DirectUpload, error)
+	DeleteExpiredDirectUploads(ctx context.Context, expiredBefore pgtype.Timestamptz) ([]sqlc.DirectUpload, error)
+	EnablePublicLink(ctx context.Context, arg sqlc.EnablePublicLinkParams) (sqlc.File, error)
+	RotatePublicToken(ctx context.Context, arg sqlc.RotatePublicTokenParams) (sqlc.File, error)
+	DisablePublicLink(ctx context.Context, fileID uuid.UUID) error
+	GetFileByPublicToken(ctx context.Context, token uuid.UUID) (sqlc.File, error)
+	ClaimPublicDownload(ctx context.Context, fileID uuid.UUID) (int32, error)
+	ArchiveCurrentVersion(ctx context.Context, fileID uuid.UUID) (sqlc.FileVersion, error)
+	ListFileVersions(ctx context.Context, fileID uuid.UUID) ([]sqlc.FileVersion, error)
+	GetFileVersion(ctx context.Context, fileID uuid.UUID, version int32) (sqlc.FileVersion, error)
+	DeleteFileVersion(ctx context.Context, fileID uuid.UUID, version int32) (uuid.UUID, error)
+	PruneFileVersions(ctx context.Context, fileID uuid.UUID, keep int32) ([]uuid.UUID, error)
+	TrashFile(ctx context.Context, fileID uuid.UUID) error
+	GetTrashedFile(ctx context.Context, fileID uuid.UUID) (sqlc.File, error)
+	RestoreFile(ctx context.Context, fileID uuid.UUID) (sqlc.File, error)
+	ListTrash(ctx context.Context, ownerID int64) ([]sqlc.ListTrashRow, error)
+	ListExpiredTrash(ctx context.Context, before pgtype.Timestamptz) ([]sqlc.ListExpiredTrashRow, error)
+	ListBlobsPendingTextExtraction(ctx context.Context, limit int32) ([]sqlc.ListBlobsPendingTextExtractionRow, error)
+	SaveBlobText(ctx context.Context, arg sqlc.SaveBlobTextParams) error
+	SearchFileContents(ctx context.Context, arg sqlc.SearchFileContentsParams) ([]sqlc.SearchFileContentsRow, error)
+	AddItemTags(ctx context.Context, arg sqlc.AddItemTagsParams) error
+	RemoveItemTags(ctx context.Context, arg sqlc.RemoveItemTagsParams) error
+	SetItemMetadata(ctx context.Context, arg sqlc.SetItemMetadataParams) error
+	RemoveItemMetadata(ctx context.Context, arg sqlc.RemoveItemMetadataParams) error
+	ListTagSuggestions(ctx context.Context, arg sqlc.ListTagSuggestionsParams) ([]sqlc.ListTagSuggestionsRow, error)
+}
+
+// repository handles database operations related to files, backed by sqlc queries.
+type repository struct {
 	pool    *pgxpool.Pool
 	queries *sqlc.Queries
 }
@@ -18,22 +85,22 @@ type Repository struct {
 // NewRepository creates a new Repository instance with the provided database queries.
 // This Repository can be used to perform file related database operations.
 // It initializes with  *pgxpool.Pool instead of sqlc.Queries in order to perform database transactions.
-func NewRepository(pool *pgxpool.Pool) *Repository {
-	return &Repository{
+func NewRepository(pool *pgxpool.Pool) Repository {
+	return &repository{
 		pool:    pool,
 		queries: sqlc.New(pool),
 	}
 }
 
 // BeginTx starts a new database transaction.
-func (r *Repository) BeginTx(ctx context.Context) (pgx.Tx, error) {
+func (r *repository) BeginTx(ctx context.Context) (pgx.Tx, error) {
 	return r.pool.Begin(ctx)
 }
 
 // WithTx returns a new repository instance with its queries scoped to the provided transaction.
-func (r *Repository) WithTx(tx pgx.Tx) *Repository {
+func (r *repository) WithTx(tx pgx.Tx) Repository {
 	// We return a pointer to a new repository struct
-	return &Repository{
+	return &repository{
 		pool:    r.pool,
 		queries: r.queries.WithTx(tx),
 	}
@@ -41,115 +108,370 @@ func (r *Repository) WithTx(tx pgx.Tx) *Repository {
 
 // GetBlobByID retrieves a blob record by its UUID.
 // Returns an error if no blob is found.
-func (r *Repository) GetBlobByID(ctx context.Context, id uuid.UUID) (sqlc.Blob, error) {
+func (r *repository) GetBlobByID(ctx context.Context, id uuid.UUID) (sqlc.Blob, error) {
 	return r.queries.GetBlobByID(ctx, id)
 }
 
 // GetBlobBySha retrieves a blob record by its SHA checksum.
 // Returns an error if no blob is found.
-func (r *Repository) GetBlobBySha(ctx context.Context, sha string) (sqlc.Blob, error) {
+func (r *repository) GetBlobBySha(ctx context.Context, sha string) (sqlc.Blob, error) {
 	return r.queries.GetBlobBySha(ctx, sha)
 }
 
 // GetFileByUUID retrieves a file record by its UUID.
 // Returns an error if no file is found.
-func (r *Repository) GetFileByUUID(ctx context.Context, id uuid.UUID) (sqlc.File, error) {
+func (r *repository) GetFileByUUID(ctx context.Context, id uuid.UUID) (sqlc.File, error) {
 	return r.queries.GetFileByUUID(ctx, id)
 }
 
 // CreateBlob inserts a new blob record into the database.
 // Returns the created blob or an error if the operation fails
-func (r *Repository) CreateBlob(ctx context.Context, arg sqlc.CreateBlobParams) (sqlc.Blob, error) {
+func (r *repository) CreateBlob(ctx context.Context, arg sqlc.CreateBlobParams) (sqlc.Blob, error) {
 	return r.queries.CreateBlob(ctx, arg)
 }
 
 // CreateFile inserts a new file record into the database.
 // Returns the created file or an error if the operation fails.
-func (r *Repository) CreateFile(ctx context.Context, arg sqlc.CreateFileParams) (sqlc.File, error) {
+func (r *repository) CreateFile(ctx context.Context, arg sqlc.CreateFileParams) (sqlc.File, error) {
 	return r.queries.CreateFile(ctx, arg)
 }
 
 // DeleteFile removes a file record by its UUID.
 // Returns an error if the deletion fails.
-func (r *Repository) DeleteFile(ctx context.Context, fileID uuid.UUID) error {
+func (r *repository) DeleteFile(ctx context.Context, fileID uuid.UUID) error {
 	return r.queries.DeleteFile(ctx, fileID)
 }
 
 // UpdateFilename updates the filename of a file record.
 // Returns the updated file or an error if the operation fails.
-func (r *Repository) UpdateFilename(ctx context.Context, arg sqlc.UpdateFilenameParams) (sqlc.File, error) {
+func (r *repository) UpdateFilename(ctx context.Context, arg sqlc.UpdateFilenameParams) (sqlc.File, error) {
 	return r.queries.UpdateFilename(ctx, arg)
 }
 
+// UpdateFileBlob points a file at a different blob, replacing its contents.
+// The update trigger moves the blob refcounts and adjusts the owner's storage usage.
+func (r *repository) UpdateFileBlob(ctx context.Context, arg sqlc.UpdateFileBlobParams) (sqlc.File, error) {
+	return r.queries.UpdateFileBlob(ctx, arg)
+}
+
 // ListUsersWithAccessToFile returns all users who have access to a specific file.
 // Returns an error if the query fails.
-func (r *Repository) ListUsersWithAccessToFile(ctx context.Context, fileID uuid.UUID) ([]sqlc.ListUsersWithAccessToFileRow, error) {
+func (r *repository) ListUsersWithAccessToFile(ctx context.Context, fileID uuid.UUID) ([]sqlc.ListUsersWithAccessToFileRow, error) {
 	return r.queries.ListUsersWithAccessToFile(ctx, fileID)
 }
 
-// UserHasAccess checks if user owns the file / is shared the file
-// It takes the userID in its SharedWith Param, and returns a boolean value and an error if the query fails
-func (r *Repository) UserHasAccess(ctx context.Context, sharedWith int64, fileID uuid.UUID) (bool, error) {
-	return r.queries.UserHasAccess(ctx, sqlc.UserHasAccessParams{
-		SharedWith: sharedWith,
-		ID:         fileID,
+// GetSharePermission returns the permission a file has been shared with to a user.
+// Returns pgx.ErrNoRows if the file is not shared with the user.
+func (r *repository) GetSharePermission(ctx context.Context, fileID uuid.UUID, userID int64) (string, error) {
+	return r.queries.GetSharePermission(ctx, sqlc.GetSharePermissionParams{
+		FileID:     fileID,
+		SharedWith: userID,
 	})
 }
 
 // ListFolderContents returns the contents of a folder, sorted and paginated, with filters (including search) applied
 // It returns a slice of ListFolderContentsRow, and an error if the query fails
-func (r *Repository) ListFolderContents(ctx context.Context, arg sqlc.ListFolderContentsParams) ([]sqlc.ListFolderContentsRow, error) {
+func (r *repository) ListFolderContents(ctx context.Context, arg sqlc.ListFolderContentsParams) ([]sqlc.ListFolderContentsRow, error) {
 	return r.queries.ListFolderContents(ctx, arg)
 }
 
 // ListRootContents returns the contents of the root folder, sorted and paginated, with filters (including search) applied
 // It returns a slice of ListRootContentsRow, and an error if the query fails
 // Note that this function is simply ListFolderContents but for when the folder = nil (root folder)
-func (r *Repository) ListRootContents(ctx context.Context, arg sqlc.ListRootContentsParams) ([]sqlc.ListRootContentsRow, error) {
+func (r *repository) ListRootContents(ctx context.Context, arg sqlc.ListRootContentsParams) ([]sqlc.ListRootContentsRow, error) {
 	return r.queries.ListRootContents(ctx, arg)
 }
 
+// SearchContents searches all files and folders the user owns or can access through a share, ranked and paginated,
+// with filters applied. Each row holds the path of folders leading to the item.
+// It returns a slice of SearchContentsRow, and an error if the query fails
+func (r *repository) SearchContents(ctx context.Context, arg sqlc.SearchContentsParams) ([]sqlc.SearchContentsRow, error) {
+	return r.queries.SearchContents(ctx, arg)
+}
+
 // IncrementDownloadCount increments (by 1) the download count of the file in the database
 // it returns an error if the query fails
-func (r *Repository) IncrementDownloadCount(ctx context.Context, fileID uuid.UUID) error {
+func (r *repository) IncrementDownloadCount(ctx context.Context, fileID uuid.UUID) error {
 	return r.queries.IncrementFileDownloadCount(ctx, fileID)
 }
 
 // GetFolderByID retrieves a folder record by its UUID.
 // Returns an error if no folder is found.
-func (r *Repository) GetFolderByID(ctx context.Context, folderID uuid.UUID) (sqlc.Folder, error) {
+func (r *repository) GetFolderByID(ctx context.Context, folderID uuid.UUID) (sqlc.Folder, error) {
 	return r.queries.GetFolderByID(ctx, folderID)
 }
 
+// GetFolderByName retrieves a folder of the owner by its name within a parent
+// folder, or at the root. Returns pgx.ErrNoRows if there is no such folder.
+func (r *repository) GetFolderByName(ctx context.Context, arg sqlc.GetFolderByNameParams) (sqlc.Folder, error) {
+	return r.queries.GetFolderByName(ctx, arg)
+}
+
+// CreateFolder creates a new folder with the given parameters.
+func (r *repository) CreateFolder(ctx context.Context, arg sqlc.CreateFolderParams) (sqlc.Folder, error) {
+	return r.queries.CreateFolder(ctx, arg)
+}
+
+// CreateFolderIfNotExists creates a folder unless the owner already has one of
+// that name in the parent. Returns pgx.ErrNoRows if there is one.
+// The name stays locked until the transaction of the repository ends, so
+// concurrent uploads through the same new folder create it only once.
+func (r *repository) CreateFolderIfNotExists(ctx context.Context, arg sqlc.CreateFolderIfNotExistsParams) (sqlc.Folder, error) {
+	if err := r.queries.LockFolderName(ctx, sqlc.LockFolderNameParams{
+		OwnerID:        arg.OwnerID,
+		ParentFolderID: arg.ParentFolderID,
+		Name:           arg.Name,
+	}); err != nil {
+		return sqlc.Folder{}, err
+	}
+	return r.queries.CreateFolderIfNotExists(ctx, arg)
+}
+
 // ListAllFiles returns all file records matching the given parameters.
 // Supports filtering, pagination, or other criteria via ListAllFilesParams.
 // Used for Admin Routes
-func (r *Repository) ListAllFiles(ctx context.Context, arg sqlc.ListAllFilesParams) ([]sqlc.ListAllFilesRow, error) {
+func (r *repository) ListAllFiles(ctx context.Context, arg sqlc.ListAllFilesParams) ([]sqlc.ListAllFilesRow, error) {
 	return r.queries.ListAllFiles(ctx, arg)
 }
 
 // DeleteBlobIfUnused deletes a blob if its reference count is zero.
-// Returns the SHA of the deleted blob or an error if deletion fails.
-func (r *Repository) DeleteBlobIfUnused(ctx context.Context, blobID uuid.UUID) (string, error) {
+// Returns the storage path and SHA of the deleted blob or an error if deletion fails.
+func (r *repository) DeleteBlobIfUnused(ctx context.Context, blobID uuid.UUID) (sqlc.DeleteBlobIfUnusedRow, error) {
 	return r.queries.DeleteBlobIfUnused(ctx, blobID)
 }
 
 // UpdateFileFolder updates the parent folder of a file.
 // Returns an error if the operation fails.
-func (r *Repository) UpdateFileFolder(ctx context.Context, arg sqlc.UpdateFileFolderParams) error {
+func (r *repository) UpdateFileFolder(ctx context.Context, arg sqlc.UpdateFileFolderParams) error {
 	return r.queries.UpdateFileFolder(ctx, arg)
 }
 
 // DeleteAllSharesForFile removes all sharing records for a given file.
 // The operation is performed atomically within a transaction.
 // Returns an error if the operation fails.
-func (r *Repository) DeleteAllSharesForFile(ctx context.Context, fileID uuid.UUID) error {
+func (r *repository) DeleteAllSharesForFile(ctx context.Context, fileID uuid.UUID) error {
 	return r.queries.DeleteAllSharesForFile(ctx, fileID)
 }
 
 // AddSharesToFile adds new share records for a file to multiple users.
 // The operation is performed atomically within a transaction.
 // Returns the number of shares successfully added or an error.
-func (r *Repository) AddSharesToFile(ctx context.Context, arg []sqlc.AddSharesToFileParams) (int64, error) {
+func (r *repository) AddSharesToFile(ctx context.Context, arg []sqlc.AddSharesToFileParams) (int64, error) {
 	return r.queries.AddSharesToFile(ctx, arg)
 }
+
+// CreateUploadSession creates a new resumable upload session.
+// Returns the created session or an error if the operation fails.
+func (r *repository) CreateUploadSession(ctx context.Context, arg sqlc.CreateUploadSessionParams) (sqlc.UploadSession, error) {
+	return r.queries.CreateUploadSession(ctx, arg)
+}
+
+// GetUploadSession retrieves a resumable upload session by its UUID.
+// Returns an error if no session is found.
+func (r *repository) GetUploadSession(ctx context.Context, id uuid.UUID) (sqlc.UploadSession, error) {
+	return r.queries.GetUploadSession(ctx, id)
+}
+
+// AdvanceUploadSession moves the offset of an upload session forward by one chunk,
+// recording the key it was stored under and extending the session's expiry.
+// The update only applies if the stored offset still equals ExpectedOffset and
+// the session has not expired, otherwise pgx.ErrNoRows is returned.
+func (r *repository) AdvanceUploadSession(ctx context.Context, arg sqlc.AdvanceUploadSessionParams) (sqlc.UploadSession, error) {
+	return r.queries.AdvanceUploadSession(ctx, arg)
+}
+
+// ClaimUploadSession marks an unexpired upload session that received all of its
+// bytes as being completed. Returns pgx.ErrNoRows if it is incomplete, expired
+// or already claimed, so that only one caller completes an upload.
+func (r *repository) ClaimUploadSession(ctx context.Context, id uuid.UUID) (sqlc.UploadSession, error) {
+	return r.queries.ClaimUploadSession(ctx, id)
+}
+
+// ReleaseUploadSession drops the claim on an upload session whose completion
+// failed, so that it can be retried.
+func (r *repository) ReleaseUploadSession(ctx context.Context, id uuid.UUID) error {
+	return r.queries.ReleaseUploadSession(ctx, id)
+}
+
+// AbortUploadSession removes an upload session unless it is being completed.
+// Returns the removed session, or pgx.ErrNoRows if it is being completed.
+func (r *repository) AbortUploadSession(ctx context.Context, id uuid.UUID) (sqlc.UploadSession, error) {
+	return r.queries.AbortUploadSession(ctx, id)
+}
+
+// DeleteUploadSession removes a resumable upload session by its UUID.
+// Returns an error if the deletion fails.
+func (r *repository) DeleteUploadSession(ctx context.Context, id uuid.UUID) error {
+	return r.queries.DeleteUploadSession(ctx, id)
+}
+
+// DeleteExpiredUploadSessions removes the upload sessions past their expiry.
+// Returns the removed sessions so that their chunks can be deleted from storage.
+func (r *repository) DeleteExpiredUploadSessions(ctx context.Context) ([]sqlc.UploadSession, error) {
+	return r.queries.DeleteExpiredUploadSessions(ctx)
+}
+
+// CreateDirectUpload records a pending presigned upload.
+// Returns the created record or an error if the operation fails.
+func (r *repository) CreateDirectUpload(ctx context.Context, arg sqlc.CreateDirectUploadParams) (sqlc.DirectUpload, error) {
+	return r.queries.CreateDirectUpload(ctx, arg)
+}
+
+// GetDirectUpload retrieves a pending presigned upload by its UUID.
+// Returns an error if no upload is found.
+func (r *repository) GetDirectUpload(ctx context.Context, id uuid.UUID) (sqlc.DirectUpload, error) {
+	return r.queries.GetDirectUpload(ctx, id)
+}
+
+// DeleteDirectUpload removes a pending presigned upload by its UUID.
+// Returns an error if the deletion fails.
+func (r *repository) DeleteDirectUpload(ctx context.Context, id uuid.UUID) error {
+	return r.queries.DeleteDirectUpload(ctx, id)
+}
+
+// ClaimDirectUpload marks an unexpired pending presigned upload of the given
+// owner as claimed and returns it, so that only one caller can complete it.
+// Returns pgx.ErrNoRows if there is no such upload or it was claimed before.
+func (r *repository) ClaimDirectUpload(ctx context.Context, arg sqlc.ClaimDirectUploadParams) (sqlc.DirectUpload, error) {
+	return r.queries.ClaimDirectUpload(ctx, arg)
+}
+
+// DeleteExpiredDirectUploads removes the pending presigned uploads that expired
+// at or before expiredBefore. Returns the removed uploads so that their staged
+// objects can be deleted from storage.
+func (r *repository) DeleteExpiredDirectUploads(ctx context.Context, expiredBefore pgtype.Timestamptz) ([]sqlc.DirectUpload, error) {
+	return r.queries.DeleteExpiredDirectUploads(ctx, expiredBefore)
+}
+
+// EnablePublicLink makes a file reachable through its public token with the given settings.
+// An existing token is kept, otherwise Token is stored. The link's download count is reset.
+func (r *repository) EnablePublicLink(ctx context.Context, arg sqlc.EnablePublicLinkParams) (sqlc.File, error) {
+	return r.queries.EnablePublicLink(ctx, arg)
+}
+
+// RotatePublicToken replaces the public token of a file that has a public link.
+// Returns pgx.ErrNoRows if the file has no public link.
+func (r *repository) RotatePublicToken(ctx context.Context, arg sqlc.RotatePublicTokenParams) (sqlc.File, error) {
+	return r.queries.RotatePublicToken(ctx, arg)
+}
+
+// DisablePublicLink removes the public link of a file together with its settings.
+// Returns an error if the update fails.
+func (r *repository) DisablePublicLink(ctx context.Context, fileID uuid.UUID) error {
+	return r.queries.DisablePublicLink(ctx, fileID)
+}
+
+// GetFileByPublicToken retrieves a publicly linked file by its token.
+// Returns pgx.ErrNoRows if no file is linked with the token.
+func (r *repository) GetFileByPublicToken(ctx context.Context, token uuid.UUID) (sqlc.File, error) {
+	return r.queries.GetFileByPublicToken(ctx, pgtype.UUID{Bytes: token, Valid: true})
+}
+
+// ClaimPublicDownload atomically counts a download through the public link of a file,
+// as long as the link has not expired or reached its download limit.
+// Returns the new link download count, or pgx.ErrNoRows if the link can no longer be used.
+func (r *repository) ClaimPublicDownload(ctx context.Context, fileID uuid.UUID) (int32, error) {
+	return r.queries.ClaimPublicDownload(ctx, fileID)
+}
+
+// ArchiveCurrentVersion copies the current contents of a file into its version history,
+// locking the file row until the surrounding transaction ends.
+// Returns the archived version.
+func (r *repository) ArchiveCurrentVersion(ctx context.Context, fileID uuid.UUID) (sqlc.FileVersion, error) {
+	return r.queries.ArchiveCurrentVersion(ctx, fileID)
+}
+
+// ListFileVersions returns the retained previous versions of a file, newest first.
+func (r *repository) ListFileVersions(ctx context.Context, fileID uuid.UUID) ([]sqlc.FileVersion, error) {
+	return r.queries.ListFileVersions(ctx, fileID)
+}
+
+// GetFileVersion retrieves a retained version of a file by its number.
+// Returns pgx.ErrNoRows if the file has no such version.
+func (r *repository) GetFileVersion(ctx context.Context, fileID uuid.UUID, version int32) (sqlc.FileVersion, error) {
+	return r.queries.GetFileVersion(ctx, sqlc.GetFileVersionParams{FileID: fileID, Version: version})
+}
+
+// DeleteFileVersion deletes a retained version of a file and returns the blob it referenced.
+// Returns pgx.ErrNoRows if the file has no such version.
+func (r *repository) DeleteFileVersion(ctx context.Context, fileID uuid.UUID, version int32) (uuid.UUID, error) {
+	return r.queries.DeleteFileVersion(ctx, sqlc.DeleteFileVersionParams{FileID: fileID, Version: version})
+}
+
+// PruneFileVersions deletes all but the newest keep versions of a file
+// and returns the blobs the deleted versions referenced.
+func (r *repository) PruneFileVersions(ctx context.Context, fileID uuid.UUID, keep int32) ([]uuid.UUID, error) {
+	return r.queries.PruneFileVersions(ctx, sqlc.PruneFileVersionsParams{FileID: fileID, Keep: keep})
+}
+
+// TrashFile moves a file to the trash. Trashed files are hidden from every
+// query except the trash ones until they are restored or purged.
+func (r *repository) TrashFile(ctx context.Context, fileID uuid.UUID) error {
+	return r.queries.TrashFile(ctx, fileID)
+}
+
+// GetTrashedFile retrieves a file the user moved to the trash.
+// Returns pgx.ErrNoRows if the file is not in the trash, or was trashed along with its folder.
+func (r *repository) GetTrashedFile(ctx context.Context, fileID uuid.UUID) (sqlc.File, error) {
+	return r.queries.GetTrashedFile(ctx, fileID)
+}
+
+// RestoreFile takes a file out of the trash and returns it.
+// The file is restored to the root if its folder is in the trash.
+func (r *repository) RestoreFile(ctx context.Context, fileID uuid.UUID) (sqlc.File, error) {
+	return r.queries.RestoreFile(ctx, fileID)
+}
+
+// ListTrash returns the files and folders a user moved to the trash, most recently trashed first.
+func (r *repository) ListTrash(ctx context.Context, ownerID int64) ([]sqlc.ListTrashRow, error) {
+	return r.queries.ListTrash(ctx, ownerID)
+}
+
+// ListExpiredTrash returns the trashed files and folders of all users
+// that were moved to the trash before the given time.
+func (r *repository) ListExpiredTrash(ctx context.Context, before pgtype.Timestamptz) ([]sqlc.ListExpiredTrashRow, error) {
+	return r.queries.ListExpiredTrash(ctx, before)
+}
+
+// ListBlobsPendingTextExtraction returns up to limit blobs of current files
+// whose text has not been extracted yet, oldest first.
+func (r *repository) ListBlobsPendingTextExtraction(ctx context.Context, limit int32) ([]sqlc.ListBlobsPendingTextExtractionRow, error) {
+	return r.queries.ListBlobsPendingTextExtraction(ctx, limit)
+}
+
+// SaveBlobText stores the outcome of extracting the text of a blob, replacing any previous one.
+func (r *repository) SaveBlobText(ctx context.Context, arg sqlc.SaveBlobTextParams) error {
+	return r.queries.SaveBlobText(ctx, arg)
+}
+
+// SearchFileContents searches the extracted text of all files the user owns or can access
+// through a share, ranked and paginated. Each row holds a highlighted snippet of the text.
+func (r *repository) SearchFileContents(ctx context.Context, arg sqlc.SearchFileContentsParams) ([]sqlc.SearchFileContentsRow, error) {
+	return r.queries.SearchFileContents(ctx, arg)
+}
+
+// AddItemTags adds the tags to the files and folders, keeping the tags they already have.
+func (r *repository) AddItemTags(ctx context.Context, arg sqlc.AddItemTagsParams) error {
+	return r.queries.AddItemTags(ctx, arg)
+}
+
+// RemoveItemTags removes the tags from the files and folders with the given IDs.
+func (r *repository) RemoveItemTags(ctx context.Context, arg sqlc.RemoveItemTagsParams) error {
+	return r.queries.RemoveItemTags(ctx, arg)
+}
+
+// SetItemMetadata sets the metadata keys to their values on the files and folders.
+func (r *repository) SetItemMetadata(ctx context.Context, arg sqlc.SetItemMetadataParams) error {
+	return r.queries.SetItemMetadata(ctx, arg)
+}
+
+// RemoveItemMetadata removes the metadata keys from the files and folders with the given IDs.
+func (r *repository) RemoveItemMetadata(ctx context.Context, arg sqlc.RemoveItemMetadataParams) error {
+	return r.queries.RemoveItemMetadata(ctx, arg)
+}
+
+// ListTagSuggestions returns the most used tags starting with a prefix
+// on the files and folders the user can see.
+func (r *repository) ListTagSuggestions(ctx context.Context, arg sqlc.ListTagSuggestionsParams) ([]sqlc.ListTagSuggestionsRow, error) {
+	return r.queries.ListTagSuggestions(ctx, arg)
+}
@@ -0,0 +1,328 @@
+package files
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/storage"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/util"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// uploadSessionExpiry is how long a resumable upload is kept without receiving
+// a chunk. Every chunk extends it, abandoned uploads are removed with their chunks.
+const uploadSessionExpiry = 24 * time.Hour
+
+// CreateUploadSession starts a resumable upload for the authenticated user.
+// The declared length is checked against the user's remaining quota up front,
+// so an upload that cannot fit is rejected before any bytes are sent.
+func (s *Service) CreateUploadSession(ctx context.Context, req CreateUploadSessionRequest) (sqlc.UploadSession, error) {
+	ownerID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return sqlc.UploadSession{}, apierror.NewUnauthorizedError()
+	}
+
+	if req.Filename == "" {
+		return sqlc.UploadSession{}, apierror.NewBadRequestError("Filename cannot be empty")
+	}
+	if req.Length < 0 {
+		return sqlc.UploadSession{}, apierror.NewBadRequestError("Invalid upload length")
+	}
+	if err := s.checkUploadFolder(ctx, ownerID, req.FolderID); err != nil {
+		return sqlc.UploadSession{}, err
+	}
+
+	remaining, err := s.remainingQuota(ctx, ownerID)
+	if err != nil {
+		return sqlc.UploadSession{}, err
+	}
+	if req.Length > remaining {
+		return sqlc.UploadSession{}, apierror.New(http.StatusRequestEntityTooLarge, "Storage quota exceeded")
+	}
+
+	params := sqlc.CreateUploadSessionParams{
+		OwnerID:      ownerID,
+		Filename:     req.Filename,
+		DeclaredMime: util.NewText(req.ContentType),
+		UploadLength: req.Length,
+		ExpiresAt:    pgtype.Timestamptz{Time: time.Now().Add(uploadSessionExpiry), Valid: true},
+	}
+	if req.FolderID != nil {
+		params.FolderID = pgtype.UUID{Bytes: *req.FolderID, Valid: true}
+	}
+
+	return s.repo.CreateUploadSession(ctx, params)
+}
+
+// GetUploadSession returns an unexpired upload session owned by the authenticated user.
+func (s *Service) GetUploadSession(ctx context.Context, sessionID uuid.UUID) (sqlc.UploadSession, error) {
+	ownerID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return sqlc.UploadSession{}, apierror.NewUnauthorizedError()
+	}
+
+	session, err := s.repo.GetUploadSession(ctx, sessionID)
+	if err != nil || !session.ExpiresAt.Time.After(time.Now()) {
+		return sqlc.UploadSession{}, apierror.NewNotFoundError("Upload")
+	}
+	if session.OwnerID != ownerID {
+		return sqlc.UploadSession{}, apierror.NewForbiddenError()
+	}
+	return session, nil
+}
+
+// WriteUploadChunk appends the bytes read from r to the upload session, starting
+// at offset. The chunk is persisted as its own object in storage before the session
+// offset is advanced, so a dropped connection never leaves a partially counted chunk.
+// Each chunk is written under a new key, so when two requests race for the same
+// offset the one that loses only discards its own object.
+// When the final byte has been received the upload is completed and the blob and
+// file records are created.
+func (s *Service) WriteUploadChunk(ctx context.Context, sessionID uuid.UUID, offset int64, r io.Reader) (sqlc.UploadSession, error) {
+	session, err := s.GetUploadSession(ctx, sessionID)
+	if err != nil {
+		return sqlc.UploadSession{}, err
+	}
+	if offset != session.UploadOffset {
+		return sqlc.UploadSession{}, apierror.New(http.StatusConflict, "Upload-Offset does not match the current offset")
+	}
+
+	// A previous completion attempt may have failed after the last chunk was stored.
+	if session.UploadOffset == session.UploadLength {
+		_, err := s.completeUploadSession(ctx, session)
+		return session, err
+	}
+
+	remaining := session.UploadLength - session.UploadOffset
+	path := fmt.Sprintf("uploads/%s/%s", session.ID, uuid.New())
+	hr := newHashingReader(io.LimitReader(r, remaining+1))
+	if _, err := s.storage.UploadBlob(ctx, hr, path, -1, "application/offset+octet-stream"); err != nil {
+		log.Printf("error while storing chunk %s: %v", path, err)
+		s.discardTempBlob(ctx, path)
+		return sqlc.UploadSession{}, apierror.NewInternalServerError("Failed to store chunk")
+	}
+	if hr.Size() > remaining {
+		s.discardTempBlob(ctx, path)
+		return sqlc.UploadSession{}, apierror.NewBadRequestError("Chunk exceeds the declared Upload-Length")
+	}
+	if hr.Size() == 0 {
+		s.discardTempBlob(ctx, path)
+		return session, nil
+	}
+
+	session, err = s.repo.AdvanceUploadSession(ctx, sqlc.AdvanceUploadSessionParams{
+		ChunkSize:      hr.Size(),
+		ChunkKey:       path,
+		ExpiresAt:      pgtype.Timestamptz{Time: time.Now().Add(uploadSessionExpiry), Valid: true},
+		ID:             session.ID,
+		ExpectedOffset: offset,
+	})
+	if err != nil {
+		s.discardTempBlob(ctx, path)
+		if err == pgx.ErrNoRows {
+			return sqlc.UploadSession{}, apierror.New(http.StatusConflict, "Upload-Offset does not match the current offset")
+		}
+		return sqlc.UploadSession{}, apierror.NewInternalServerError("Failed to update upload")
+	}
+
+	if session.UploadOffset == session.UploadLength {
+		if _, err := s.completeUploadSession(ctx, session); err != nil {
+			return session, err
+		}
+	}
+	return session, nil
+}
+
+// TerminateUploadSession aborts an upload, removing the session record and its
+// stored chunks. An upload that is being completed can no longer be aborted.
+func (s *Service) TerminateUploadSession(ctx context.Context, sessionID uuid.UUID) error {
+	session, err := s.GetUploadSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	session, err = s.repo.AbortUploadSession(ctx, session.ID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return apierror.New(http.StatusConflict, "Upload is being completed")
+		}
+		return apierror.NewInternalServerError("Failed to delete upload")
+	}
+	if err := s.storage.DeleteBlobs(ctx, session.ChunkKeys); err != nil {
+		log.Printf("Failed to delete chunks of upload %s: %v", session.ID, err)
+	}
+	return nil
+}
+
+// completeUploadSession claims the session, so that a retried final chunk cannot
+// complete it a second time, then concatenates the stored chunks into a temporary
+// object while hashing them, finalizes or dedup-merges the blob and creates the
+// file record, the same way a single-shot upload does. The chunks and session are
+// removed afterwards. If completing fails before the file record is created the
+// claim is released, so that the upload can be completed by retrying.
+func (s *Service) completeUploadSession(ctx context.Context, session sqlc.UploadSession) (sqlc.File, error) {
+	session, err := s.repo.ClaimUploadSession(ctx, session.ID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return sqlc.File{}, apierror.New(http.StatusConflict, "Upload is already being completed")
+		}
+		return sqlc.File{}, apierror.NewInternalServerError("Failed to complete upload")
+	}
+	completed := false
+	defer func() {
+		if !completed {
+			if err := s.repo.ReleaseUploadSession(context.WithoutCancel(ctx), session.ID); err != nil {
+				log.Printf("Failed to release upload session %s: %v", session.ID, err)
+			}
+		}
+	}()
+
+	// the folder may have been deleted or shared away since the upload started
+	if err := s.checkUploadFolder(ctx, session.OwnerID, util.ToUUIDPtr(session.FolderID)); err != nil {
+		return sqlc.File{}, err
+	}
+
+	// quota was checked at creation time, but other uploads may have completed since
+	remaining, err := s.remainingQuota(ctx, session.OwnerID)
+	if err != nil {
+		return sqlc.File{}, err
+	}
+	if session.UploadLength > remaining {
+		return sqlc.File{}, apierror.New(http.StatusRequestEntityTooLarge, "Storage quota exceeded")
+	}
+
+	paths := session.ChunkKeys
+	tmpPath := fmt.Sprintf("tmp/%s", uuid.New())
+	cr := &chunkReader{ctx: ctx, storage: s.storage, paths: paths}
+	hr := newHashingReader(cr)
+	_, err = s.storage.UploadBlob(ctx, hr, tmpPath, -1, session.DeclaredMime.String)
+	cr.Close()
+	if err != nil {
+		log.Printf("error while assembling upload %s: %v", session.ID, err)
+		s.discardTempBlob(ctx, tmpPath)
+		return sqlc.File{}, apierror.NewInternalServerError("Failed to assemble upload")
+	}
+	if hr.Size() != session.UploadLength {
+		s.discardTempBlob(ctx, tmpPath)
+		return sqlc.File{}, apierror.NewInternalServerError("Assembled upload does not match the declared length")
+	}
+
+	blob, err := s.finalizeBlob(ctx, tmpPath, hr.Sum(), hr.Size(), verifyMIME(session.DeclaredMime.String, hr.Sniff()))
+	if err != nil {
+		return sqlc.File{}, err
+	}
+
+	file, err := s.createFileRecord(ctx, session.OwnerID, blob, session.Filename, session.DeclaredMime.String, util.ToUUIDPtr(session.FolderID))
+	if err != nil {
+		return sqlc.File{}, err
+	}
+	// the session stays claimed from here on, even if removing it fails below
+	completed = true
+
+	if err := s.storage.DeleteBlobs(context.WithoutCancel(ctx), paths); err != nil {
+		log.Printf("Failed to delete chunks of upload %s: %v", session.ID, err)
+	}
+	if err := s.repo.DeleteUploadSession(context.WithoutCancel(ctx), session.ID); err != nil {
+		log.Printf("Failed to delete upload session %s, it is purged once it expires: %v", session.ID, err)
+	}
+
+	log.Printf("Completed resumable upload %s as file %s", session.ID, file.ID)
+	return file, nil
+}
+
+// PurgeExpiredUploads removes the expired resumable uploads and direct uploads,
+// deleting their stored chunks and staged objects.
+// It returns the number of uploads removed.
+func (s *Service) PurgeExpiredUploads(ctx context.Context) (int, error) {
+	sessions, err := s.repo.DeleteExpiredUploadSessions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, session := range sessions {
+		if err := s.storage.DeleteBlobs(ctx, session.ChunkKeys); err != nil {
+			log.Printf("Failed to delete chunks of expired upload %s: %v", session.ID, err)
+		}
+	}
+
+	direct, err := s.purgeExpiredDirectUploads(ctx)
+	if err != nil {
+		return len(sessions), err
+	}
+	return len(sessions) + direct, nil
+}
+
+// RunUploadCleaner removes expired uploads every interval until ctx is done.
+func (s *Service) RunUploadCleaner(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.PurgeExpiredUploads(ctx)
+		if err != nil {
+			log.Printf("Failed to purge expired uploads: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d expired uploads", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// chunkReader reads a sequence of stored objects as one continuous stream,
+// opening each object only once the previous one has been fully read.
+type chunkReader struct {
+	ctx     context.Context
+	storage storage.Storage
+	paths   []string
+	current io.ReadCloser
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if c.current == nil {
+			if len(c.paths) == 0 {
+				return 0, io.EOF
+			}
+			obj, err := c.storage.GetBlob(c.ctx, c.paths[0])
+			if err != nil {
+				return 0, err
+			}
+			c.current = obj
+			c.paths = c.paths[1:]
+		}
+
+		n, err := c.current.Read(p)
+		if err == io.EOF {
+			c.current.Close()
+			c.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// Close closes the object currently being read, if any.
+func (c *chunkReader) Close() error {
+	if c.current == nil {
+		return nil
+	}
+	err := c.current.Close()
+	c.current = nil
+	return err
+}
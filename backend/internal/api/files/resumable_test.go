@@ -0,0 +1,206 @@
+package files_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/memdb"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/storage"
+)
+
+// chunkBarrierStorage holds every chunk upload until the given number of
+// requests have started one, so that they all write at the same offset.
+type chunkBarrierStorage struct {
+	*storage.MemoryStorage
+	arrived sync.WaitGroup
+}
+
+func (b *chunkBarrierStorage) UploadBlob(ctx context.Context, r io.Reader, fileName string, size int64, contentType string) (string, error) {
+	if strings.HasPrefix(fileName, "uploads/") {
+		b.arrived.Done()
+		b.arrived.Wait()
+	}
+	return b.MemoryStorage.UploadBlob(ctx, r, fileName, size, contentType)
+}
+
+func (e *testEnv) assertNoChunks(t *testing.T) {
+	t.Helper()
+	for _, key := range e.store.Keys() {
+		if strings.HasPrefix(key, "uploads/") {
+			t.Errorf("chunk %s was not cleaned up", key)
+		}
+	}
+}
+
+func TestWriteUploadChunkConcurrent(t *testing.T) {
+	const writers = 2
+	db := memdb.New()
+	store := &chunkBarrierStorage{MemoryStorage: storage.NewMemoryStorage()}
+	store.arrived.Add(writers)
+	env := &testEnv{db: db, store: store.MemoryStorage, service: files.NewService(db, db, db, store, nopAudit{}, "http://vault.test")}
+	_, ctx := env.createUser(t, "alice@example.com", 1<<20)
+
+	session, err := env.service.CreateUploadSession(ctx, files.CreateUploadSessionRequest{Filename: "note.txt", ContentType: "text/plain", Length: 5})
+	if err != nil {
+		t.Fatalf("CreateUploadSession: %v", err)
+	}
+
+	errs := make(chan error, writers)
+	for range writers {
+		go func() {
+			_, err := env.service.WriteUploadChunk(ctx, session.ID, 0, strings.NewReader("hello"))
+			errs <- err
+		}()
+	}
+	var conflicts int
+	for range writers {
+		err := <-errs
+		switch {
+		case err == nil:
+		case statusOf(err) == http.StatusConflict:
+			conflicts++
+		default:
+			t.Fatalf("WriteUploadChunk: %v", err)
+		}
+	}
+	if conflicts != writers-1 {
+		t.Errorf("got %d conflicting writes, want %d", conflicts, writers-1)
+	}
+
+	list := env.db.Files()
+	if len(list) != 1 {
+		t.Fatalf("got %d file records, want 1", len(list))
+	}
+	blob, err := env.db.GetBlobByID(context.Background(), list[0].BlobID)
+	if err != nil {
+		t.Fatalf("GetBlobByID: %v", err)
+	}
+	if data, _ := env.store.Object(blob.StoragePath); string(data) != "hello" {
+		t.Errorf("uploaded content = %q, want %q", data, "hello")
+	}
+	env.assertNoChunks(t)
+	env.assertNoTempObjects(t)
+}
+
+func TestPurgeExpiredUploads(t *testing.T) {
+	env := newTestEnv(t)
+	_, ctx := env.createUser(t, "alice@example.com", 1<<20)
+
+	session, err := env.service.CreateUploadSession(ctx, files.CreateUploadSessionRequest{Filename: "note.txt", Length: 5})
+	if err != nil {
+		t.Fatalf("CreateUploadSession: %v", err)
+	}
+	if _, err := env.service.WriteUploadChunk(ctx, session.ID, 0, strings.NewReader("he")); err != nil {
+		t.Fatalf("WriteUploadChunk: %v", err)
+	}
+	env.db.ExpireUploadSessions()
+
+	if _, err := env.service.WriteUploadChunk(ctx, session.ID, 2, strings.NewReader("llo")); statusOf(err) != http.StatusNotFound {
+		t.Errorf("WriteUploadChunk after expiry = %v, want status %d", err, http.StatusNotFound)
+	}
+
+	purged, err := env.service.PurgeExpiredUploads(context.Background())
+	if err != nil {
+		t.Fatalf("PurgeExpiredUploads: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("purged %d uploads, want 1", purged)
+	}
+	if _, err := env.db.GetUploadSession(context.Background(), session.ID); err == nil {
+		t.Error("expired upload session still exists")
+	}
+	if got := len(env.db.Files()); got != 0 {
+		t.Errorf("got %d file records, want 0", got)
+	}
+	env.assertNoChunks(t)
+}
+
+// assembleGateStorage holds the assembly of a completed upload until release
+// is closed, so that requests can be made while it is being completed.
+type assembleGateStorage struct {
+	*storage.MemoryStorage
+	assembling chan struct{}
+	release    chan struct{}
+}
+
+func (g *assembleGateStorage) UploadBlob(ctx context.Context, r io.Reader, fileName string, size int64, contentType string) (string, error) {
+	if strings.HasPrefix(fileName, "tmp/") {
+		close(g.assembling)
+		<-g.release
+	}
+	return g.MemoryStorage.UploadBlob(ctx, r, fileName, size, contentType)
+}
+
+// TestCompleteUploadSessionOnce retries the final chunk and aborts the upload
+// while it is being completed, neither of which may touch it.
+func TestCompleteUploadSessionOnce(t *testing.T) {
+	db := memdb.New()
+	store := &assembleGateStorage{MemoryStorage: storage.NewMemoryStorage(), assembling: make(chan struct{}), release: make(chan struct{})}
+	env := &testEnv{db: db, store: store.MemoryStorage, service: files.NewService(db, db, db, store, nopAudit{}, "http://vault.test")}
+	_, ctx := env.createUser(t, "alice@example.com", 1<<20)
+
+	session, err := env.service.CreateUploadSession(ctx, files.CreateUploadSessionRequest{Filename: "note.txt", ContentType: "text/plain", Length: 5})
+	if err != nil {
+		t.Fatalf("CreateUploadSession: %v", err)
+	}
+	done := make(chan error)
+	go func() {
+		_, err := env.service.WriteUploadChunk(ctx, session.ID, 0, strings.NewReader("hello"))
+		done <- err
+	}()
+	<-store.assembling
+
+	if _, err := env.service.WriteUploadChunk(ctx, session.ID, 5, strings.NewReader("")); statusOf(err) != http.StatusConflict {
+		t.Errorf("retried final chunk during completion = %v, want status %d", err, http.StatusConflict)
+	}
+	if err := env.service.TerminateUploadSession(ctx, session.ID); statusOf(err) != http.StatusConflict {
+		t.Errorf("TerminateUploadSession during completion = %v, want status %d", err, http.StatusConflict)
+	}
+	close(store.release)
+	if err := <-done; err != nil {
+		t.Fatalf("WriteUploadChunk: %v", err)
+	}
+
+	if _, err := env.service.WriteUploadChunk(ctx, session.ID, 5, strings.NewReader("")); statusOf(err) != http.StatusNotFound {
+		t.Errorf("retried final chunk after completion = %v, want status %d", err, http.StatusNotFound)
+	}
+	if got := len(env.db.Files()); got != 1 {
+		t.Errorf("got %d file records, want 1", got)
+	}
+	env.assertNoChunks(t)
+	env.assertNoTempObjects(t)
+}
+
+// TestCompleteUploadSessionFolderGone checks that an upload into a folder
+// trashed since it started is not completed, and can be retried.
+func TestCompleteUploadSessionFolderGone(t *testing.T) {
+	env := newTestEnv(t)
+	userID, ctx := env.createUser(t, "alice@example.com", 1<<20)
+	folder, err := env.db.CreateFolder(ctx, sqlc.CreateFolderParams{Name: "docs", OwnerID: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := env.service.CreateUploadSession(ctx, files.CreateUploadSessionRequest{Filename: "note.txt", Length: 5, FolderID: &folder.ID})
+	if err != nil {
+		t.Fatalf("CreateUploadSession: %v", err)
+	}
+	if err := env.db.TrashFolder(ctx, folder.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.service.WriteUploadChunk(ctx, session.ID, 0, strings.NewReader("hello")); statusOf(err) != http.StatusNotFound {
+		t.Errorf("WriteUploadChunk into a trashed folder = %v, want status %d", err, http.StatusNotFound)
+	}
+	if got := len(env.db.Files()); got != 0 {
+		t.Errorf("got %d file records, want 0", got)
+	}
+	if _, err := env.db.ClaimUploadSession(context.Background(), session.ID); err != nil {
+		t.Errorf("upload session still claimed after failing: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package files
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/util"
+)
+
+// Search finds files and folders by name across everything the authenticated
+// user owns or can access through a share, at any depth. Results are ranked by
+// how well their name matches the search term and carry the path of folders
+// leading to them, so they can be located in the tree.
+func (s *Service) Search(ctx context.Context, req SearchRequest) (SearchResponse, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return SearchResponse{}, apierror.NewUnauthorizedError()
+	}
+
+	search := strings.TrimSpace(req.Search)
+	if search == "" {
+		return SearchResponse{}, apierror.NewBadRequestError("Search term cannot be empty")
+	}
+
+	rows, err := s.repo.SearchContents(ctx, sqlc.SearchContentsParams{
+		UserID:          userID,
+		Search:          search,
+		Fuzzy:           req.Fuzzy,
+		MimeType:        req.MimeType,
+		OwnershipStatus: req.OwnershipStatus,
+		MinSize:         req.MinSize,
+		MaxSize:         req.MaxSize,
+		UploadedAfter:   util.ToPgTimestamptz(req.UploadedAfter),
+		UploadedBefore:  util.ToPgTimestamptz(req.UploadedBefore),
+		Limit:           req.Limit,
+		Offset:          req.Offset,
+	})
+	if err != nil {
+		log.Printf("Failed to search contents of user %d: %v", userID, err)
+		return SearchResponse{}, apierror.NewInternalServerError("Failed to search files")
+	}
+
+	response := SearchResponse{Data: make([]SearchResult, len(rows))}
+	if len(rows) > 0 {
+		response.TotalCount = rows[0].TotalCount
+	}
+	for i, r := range rows {
+		item := ContentItem{
+			ID:           r.ID,
+			ItemType:     r.ItemType,
+			Filename:     r.Filename,
+			UploadedAt:   r.UploadedAt.Time,
+			UserOwnsFile: r.UserOwnsFile,
+		}
+		// Safely assign nullable fields
+		if r.Size.Valid {
+			item.Size = &r.Size.Int64
+		}
+		if r.ContentType.Valid {
+			item.ContentType = &r.ContentType.String
+		}
+		if r.DownloadCount.Valid {
+			item.DownloadCount = &r.DownloadCount.Int64
+		}
+
+		path := make([]PathSegment, len(r.PathIds))
+		for j, id := range r.PathIds {
+			path[j] = PathSegment{ID: id, Name: r.PathNames[j]}
+		}
+		response.Data[i] = SearchResult{ContentItem: item, Path: path, Rank: r.Rank}
+	}
+	return response, nil
+}
@@ -0,0 +1,101 @@
+package files_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/memdb"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/google/uuid"
+)
+
+// searchRepo answers SearchContents with fixed rows and records its parameters,
+// since the search itself runs in the database.
+type searchRepo struct {
+	*memdb.DB
+	rows []sqlc.SearchContentsRow
+	got  *sqlc.SearchContentsParams
+}
+
+func (r *searchRepo) SearchContents(ctx context.Context, arg sqlc.SearchContentsParams) ([]sqlc.SearchContentsRow, error) {
+	r.got = &arg
+	return r.rows, nil
+}
+
+func TestSearch(t *testing.T) {
+	docsID, workID := uuid.New(), uuid.New()
+	row := sqlc.SearchContentsRow{
+		ID:         uuid.New(),
+		Filename:   "report.pdf",
+		ItemType:   "file",
+		Size:       sql.NullInt64{Int64: 42, Valid: true},
+		PathIds:    []uuid.UUID{docsID, workID},
+		PathNames:  []string{"docs", "work"},
+		Rank:       2.5,
+		TotalCount: 1,
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantParams sqlc.SearchContentsParams
+	}{
+		{
+			name:       "term and filters",
+			query:      "q=+report+&fuzzy=true&content_type=application/pdf&min_size=10&limit=5",
+			wantStatus: http.StatusOK,
+			wantParams: sqlc.SearchContentsParams{
+				Search:   "report",
+				Fuzzy:    true,
+				MimeType: "application/pdf",
+				MinSize:  sql.NullInt64{Int64: 10, Valid: true},
+				Limit:    5,
+			},
+		},
+		{name: "missing term", query: "q=+", wantStatus: http.StatusBadRequest},
+		{name: "invalid size", query: "q=report&max_size=big", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			userID, _ := env.createUser(t, "owner@example.com", 1<<20)
+			repo := &searchRepo{DB: env.db, rows: []sqlc.SearchContentsRow{row}}
+			env.service = files.NewService(repo, env.db, env.db, env.store, nopAudit{}, "http://vault.test")
+
+			rec := httptest.NewRecorder()
+			newTestRouter(env, userID).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/search?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", rec.Code, rec.Body, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if repo.got != nil {
+					t.Errorf("searched with %+v, want no search", *repo.got)
+				}
+				return
+			}
+
+			tt.wantParams.UserID = userID
+			if *repo.got != tt.wantParams {
+				t.Errorf("searched with %+v, want %+v", *repo.got, tt.wantParams)
+			}
+			var res files.SearchResponse
+			if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+			if res.TotalCount != 1 || len(res.Data) != 1 {
+				t.Fatalf("response = %+v, want the one result", res)
+			}
+			path := res.Data[0].Path
+			if len(path) != 2 || path[0] != (files.PathSegment{ID: docsID, Name: "docs"}) || path[1] != (files.PathSegment{ID: workID, Name: "work"}) {
+				t.Errorf("path = %+v, want docs/work", path)
+			}
+		})
+	}
+}
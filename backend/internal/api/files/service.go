@@ -1,16 +1,13 @@
 package files
 
 import (
-	"bytes"
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"io"
 	"log"
-	"mime/multipart"
 	"net/http"
 
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/access"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/folders"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/users"
@@ -27,105 +24,133 @@ import (
 // Service provides file-related operations, including uploading and managing files,
 // managing file metadata, and interacting with storage and related repositories.
 type Service struct {
-	userRepo   *users.Repository
-	folderRepo *folders.Repository
-	repo       *Repository
+	userRepo   users.Repository
+	folderRepo folders.Repository
+	repo       Repository
 	storage    storage.Storage
 	audit      audit.Service
+	publicURL  string
+	// previewJobs holds a token for every preview being generated.
+	previewJobs chan struct{}
 }
 
 // NewService constructs a new Service instance with the provided repositories and storage.
-func NewService(filesRepo *Repository, userRepo *users.Repository, folderRepo *folders.Repository, storage storage.Storage, auditService audit.Service) *Service {
+// publicURL is the base URL of the API, used to build public share links.
+func NewService(filesRepo Repository, userRepo users.Repository, folderRepo folders.Repository, storage storage.Storage, auditService audit.Service, publicURL string) *Service {
 	return &Service{
 		repo:       filesRepo,
 		userRepo:   userRepo,
 		folderRepo: folderRepo,
 		storage:    storage,
 		audit:      auditService,
+		publicURL:  publicURL,
+
+		previewJobs: make(chan struct{}, maxPreviewJobs),
 	}
 }
 
-// UploadFile handles uploading a file to the storage backend and creating
-// the corresponding database records. It performs ownership checks, computes
-// a SHA-256 hash for deduplication, and updates blob reference counts (using a database trigger).
+// UploadFile streams a file to the storage backend and creates the corresponding
+// database records. The content is written to a temporary object while its SHA-256
+// is computed and its content type sniffed, so memory use stays bounded regardless
+// of file size. The declared contentType is only trusted if the content agrees. Once the hash is
+// known the temporary object is either finalized as a new blob or discarded in favour
+// of an existing blob with the same hash (deduplication). Blob reference counts are
+// updated by a database trigger.
 // Returns the created File record or an error.
-func (s *Service) UploadFile(ctx context.Context, file multipart.File, header *multipart.FileHeader, folderID *uuid.UUID) (sqlc.File, error) {
+func (s *Service) UploadFile(ctx context.Context, r io.Reader, filename, contentType string, folderID *uuid.UUID) (sqlc.File, error) {
 	// Ownership checks
 	ownerID, ok := userctx.GetUserID(ctx)
 	if !ok {
 		return sqlc.File{}, apierror.NewUnauthorizedError()
 	}
-	if folderID != nil {
-		folder, err := s.repo.GetFolderByID(ctx, *folderID)
-		if err != nil {
-			return sqlc.File{}, apierror.NewNotFoundError("Folder")
-		}
-		if folder.OwnerID != ownerID {
-			return sqlc.File{}, apierror.NewForbiddenError()
-		}
+	if err := s.checkUploadFolder(ctx, ownerID, folderID); err != nil {
+		return sqlc.File{}, err
 	}
 
-	// Compute hash (sha256)
-	hasher := sha256.New()
-	buf, err := io.ReadAll(io.TeeReader(file, hasher))
+	blob, err := s.storeUpload(ctx, ownerID, r, contentType)
 	if err != nil {
 		return sqlc.File{}, err
 	}
-	sha := hex.EncodeToString(hasher.Sum(nil))
 
-	var blob sqlc.Blob
+	return s.createFileRecord(ctx, ownerID, blob, filename, contentType, folderID)
+}
 
-	// Check if blob exists
-	existingBlob, err := s.repo.GetBlobBySha(ctx, sha)
-	if err != nil && err != pgx.ErrNoRows {
-		return sqlc.File{}, apierror.NewInternalServerError("Failed to check for existing blob")
+// storeUpload streams the contents of an upload to storage and returns the blob
+// holding them, which is an existing blob if the same contents were stored before.
+// The upload is rejected if it does not fit into the owner's remaining quota.
+func (s *Service) storeUpload(ctx context.Context, ownerID int64, r io.Reader, contentType string) (sqlc.Blob, error) {
+	remaining, err := s.remainingQuota(ctx, ownerID)
+	if err != nil {
+		return sqlc.Blob{}, err
 	}
 
-	if err == nil {
-		// Existing blob: update refcount
-		log.Print("blob already exists, updating refcount")
-		blob = existingBlob
-	} else {
-		user, err := s.userRepo.GetUserByID(ctx, ownerID)
-		if err != nil {
-			return sqlc.File{}, apierror.NewInternalServerError("Could not retrieve user data")
-		}
-		newBlobSize := int64(len(buf))
-		log.Println("storage quota and blobsize:")
-		log.Print(user.StorageQuota, newBlobSize)
-		if user.StorageUsed+newBlobSize > user.StorageQuota {
-			return sqlc.File{}, apierror.New(http.StatusRequestEntityTooLarge, "Storage quota exceeded")
-		}
+	// Stream into a temporary object, hashing on the way through.
+	// Reading one byte past the remaining quota is enough to know the upload does not fit.
+	tmpPath := fmt.Sprintf("tmp/%s", uuid.New())
+	hr := newHashingReader(io.LimitReader(r, remaining+1))
+	if _, err := s.storage.UploadBlob(ctx, hr, tmpPath, -1, contentType); err != nil {
+		log.Printf("error while streaming upload to %s: %v", tmpPath, err)
+		s.discardTempBlob(ctx, tmpPath)
+		return sqlc.Blob{}, apierror.NewInternalServerError("Failed to store file")
+	}
+	if hr.Size() > remaining {
+		s.discardTempBlob(ctx, tmpPath)
+		return sqlc.Blob{}, apierror.New(http.StatusRequestEntityTooLarge, "Storage quota exceeded")
+	}
 
-		// Upload to MinIO
-		storagePath := fmt.Sprintf("%s_%s", sha, header.Filename)
-		reader := bytes.NewReader(buf)
-		_, err = s.storage.UploadBlob(ctx, reader, storagePath, newBlobSize, header.Header.Get("Content-Type"))
-		if err != nil {
-			return sqlc.File{}, err
-		}
-		log.Print("Uploaded Blob to storage")
+	return s.finalizeBlob(ctx, tmpPath, hr.Sum(), hr.Size(), verifyMIME(contentType, hr.Sniff()))
+}
 
-		// Create blob record in DB with refcount = 0 (default). The trigger will increment it.
-		blobParams := sqlc.CreateBlobParams{
-			Sha256:      sha,
-			StoragePath: storagePath,
-			Size:        newBlobSize,
-			MimeType:    util.NewText(header.Header.Get("Content-Type")),
-		}
-		newBlob, err := s.repo.CreateBlob(ctx, blobParams)
-		if err != nil {
-			return sqlc.File{}, err
-		}
-		log.Print("Created Blob record in db")
-		blob = newBlob
+// checkUploadFolder verifies that the target folder of an upload exists and is
+// owned by the uploader. A nil folderID refers to the root folder and always passes.
+func (s *Service) checkUploadFolder(ctx context.Context, ownerID int64, folderID *uuid.UUID) error {
+	if folderID == nil {
+		return nil
+	}
+	folder, err := s.repo.GetFolderByID(ctx, *folderID)
+	if err != nil {
+		return apierror.NewNotFoundError("Folder")
+	}
+	if folder.OwnerID != ownerID {
+		return apierror.NewForbiddenError()
+	}
+	return nil
+}
+
+// remainingQuota returns how many more bytes the user may store before
+// reaching their storage quota.
+func (s *Service) remainingQuota(ctx context.Context, userID int64) (int64, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return 0, apierror.NewInternalServerError("Could not retrieve user data")
+	}
+	remaining := user.StorageQuota - user.StorageUsed
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, nil
+}
+
+// createFileRecord inserts the files row pointing at blob and records the upload
+// in the audit log. The insert trigger increments the blob refcount and the owner's
+// storage usage.
+func (s *Service) createFileRecord(ctx context.Context, ownerID int64, blob sqlc.Blob, filename, contentType string, folderID *uuid.UUID) (sqlc.File, error) {
+	fileRecord, err := insertFileRecord(ctx, s.repo, ownerID, blob, filename, contentType, folderID)
+	if err != nil {
+		return sqlc.File{}, err
 	}
+	s.logUpload(ctx, fileRecord, blob)
+	return fileRecord, nil
+}
 
+// insertFileRecord inserts the files row pointing at blob using repo, which
+// may be scoped to a transaction.
+func insertFileRecord(ctx context.Context, repo Repository, ownerID int64, blob sqlc.Blob, filename, contentType string, folderID *uuid.UUID) (sqlc.File, error) {
 	fileParams := sqlc.CreateFileParams{
 		OwnerID:      ownerID,
 		BlobID:       blob.ID,
-		Filename:     header.Filename,
-		DeclaredMime: util.NewText(header.Header.Get("Content-Type")),
+		Filename:     filename,
+		DeclaredMime: util.NewText(contentType),
 		Size:         blob.Size,
 	}
 	if folderID != nil {
@@ -134,47 +159,85 @@ func (s *Service) UploadFile(ctx context.Context, file multipart.File, header *m
 
 	// Create the file record, which triggers blob refcount update
 	log.Println("Creating file record with params:", fileParams)
-	fileRecord, err := s.repo.CreateFile(ctx, fileParams)
-	if err != nil {
-		return sqlc.File{}, err
-	}
-
-	details := map[string]interface{}{
-		"filename":  fileRecord.Filename,
-		"size":      fileRecord.Size,
-		"mime_type": fileRecord.DeclaredMime.String,
-	}
+	return repo.CreateFile(ctx, fileParams)
+}
 
-	// Record the audit entry for file upload
+// logUpload records the audit entry for an uploaded file.
+func (s *Service) logUpload(ctx context.Context, file sqlc.File, blob sqlc.Blob) {
 	s.audit.Log(ctx, audit.LogParams{
-		UserID:   ownerID,
+		UserID:   file.OwnerID,
 		Action:   "FILE_UPLOADED",
-		TargetID: fileRecord.ID,
-		Details:  details,
+		TargetID: file.ID,
+		Details: map[string]interface{}{
+			"filename":  file.Filename,
+			"size":      file.Size,
+			"mime_type": blob.MimeType.String,
+		},
 	})
+}
 
-	return fileRecord, nil
+// finalizeBlob turns a fully written temporary object into a blob record.
+// If a blob with the same SHA-256 already exists the temporary object is deleted
+// and the existing blob is returned (dedup-merge). Otherwise the object is moved
+// to a key of its own under the content hash and a new blob record is created
+// with refcount 0; the files insert trigger increments it. The key is unique per
+// upload so that a concurrent upload of the same content that loses the race on
+// the blob record only ever deletes its own object. mimeType is the verified
+// content type stored on the blob and served on download.
+func (s *Service) finalizeBlob(ctx context.Context, tmpPath, sha string, size int64, mimeType string) (sqlc.Blob, error) {
+	existingBlob, err := s.repo.GetBlobBySha(ctx, sha)
+	if err != nil && err != pgx.ErrNoRows {
+		s.discardTempBlob(ctx, tmpPath)
+		return sqlc.Blob{}, apierror.NewInternalServerError("Failed to check for existing blob")
+	}
+	if err == nil {
+		log.Print("blob already exists, discarding temporary object")
+		s.discardTempBlob(ctx, tmpPath)
+		return existingBlob, nil
+	}
+
+	storagePath := fmt.Sprintf("%s/%s", sha, uuid.New())
+	if err := s.storage.MoveBlob(ctx, tmpPath, storagePath); err != nil {
+		log.Printf("error while finalizing object %s: %v", tmpPath, err)
+		s.discardTempBlob(ctx, tmpPath)
+		return sqlc.Blob{}, apierror.NewInternalServerError("Failed to store file")
+	}
+	log.Print("Finalized Blob in storage")
 
+	newBlob, err := s.repo.CreateBlob(ctx, sqlc.CreateBlobParams{
+		Sha256:      sha,
+		StoragePath: storagePath,
+		Size:        size,
+		MimeType:    util.NewText(mimeType),
+	})
+	if err != nil {
+		// A concurrent upload of the same content may have created the blob first.
+		if existingBlob, lookupErr := s.repo.GetBlobBySha(ctx, sha); lookupErr == nil {
+			s.discardTempBlob(ctx, storagePath)
+			return existingBlob, nil
+		}
+		return sqlc.Blob{}, err
+	}
+	log.Print("Created Blob record in db")
+	return newBlob, nil
 }
 
-// GetFileURL returns a signed URL for accessing the file identified by fileID.
-// It ensures the requesting user owns the file and fetches the corresponding blob from storage.
-func (s *Service) GetFileURL(ctx context.Context, fileID uuid.UUID) (string, error) {
-	userID, ok := userctx.GetUserID(ctx)
-	if !ok {
-		return "", apierror.NewInternalServerError("Failed to get UserID")
+// discardTempBlob removes an object that will not be referenced by any blob record.
+// It is detached from ctx cancellation so that aborted uploads are still cleaned up.
+func (s *Service) discardTempBlob(ctx context.Context, path string) {
+	if err := s.storage.DeleteBlob(context.WithoutCancel(ctx), path); err != nil {
+		log.Printf("Failed to delete temporary object %s: %v", path, err)
 	}
+}
 
-	file, err := s.repo.GetFileByUUID(ctx, fileID)
+// GetFileURL returns a signed URL for accessing the file identified by fileID.
+// It ensures the requesting user may read the file and fetches the corresponding blob from storage.
+func (s *Service) GetFileURL(ctx context.Context, fileID uuid.UUID) (string, error) {
+	file, _, err := s.authorizeFile(ctx, fileID, access.Read)
 	if err != nil {
 		return "", err
 	}
 
-	// Ownership check
-	if file.OwnerID != userID {
-		return "", apierror.NewForbiddenError()
-	}
-
 	blob, err := s.repo.GetBlobByID(ctx, file.BlobID)
 	if err != nil {
 		return "", apierror.NewInternalServerError("Unable to fetch blob")
@@ -188,89 +251,63 @@ func (s *Service) GetFileByUUID(ctx context.Context, fileID uuid.UUID) (sqlc.Fil
 	return s.repo.GetFileByUUID(ctx, fileID)
 }
 
-// DownloadFile returns a ReadCloser for the file content along with its filename.
-// It checks if the user owns or has access to the file and fetches the corresponding blob.
-func (s *Service) DownloadFile(ctx context.Context, fileID uuid.UUID) (io.ReadCloser, string, error) {
-
-	ownerID, ok := userctx.GetUserID(ctx)
-	if !ok {
-		return nil, "", apierror.NewUnauthorizedError()
+// DownloadFile checks that the current user has read access to the file and
+// returns a Download describing it. The content is opened lazily so callers can
+// answer conditional and range requests without transferring the whole object.
+// Nothing is recorded until RecordDownload is called.
+func (s *Service) DownloadFile(ctx context.Context, fileID uuid.UUID) (*Download, error) {
+	file, userID, err := s.authorizeFile(ctx, fileID, access.Read)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Printf("received request from user %d to download file %s", ownerID, fileID)
+	log.Printf("received request from user %d to download file %s", userID, fileID)
 
-	// Check if the user owns the file / is shared the file
-	userHasAccess, err := s.repo.UserHasAccess(ctx, ownerID, fileID)
-	if !userHasAccess || err != nil {
-		log.Printf("no access")
-		return nil, "", apierror.NewForbiddenError()
-	}
-
-	file, err := s.GetFileByUUID(ctx, fileID)
+	blob, err := s.repo.GetBlobByID(ctx, file.BlobID)
 	if err != nil {
-		return nil, "", apierror.NewInternalServerError("File not found")
+		return nil, apierror.NewInternalServerError("Unable to fetch blob")
 	}
 
-	blobReader, err := s.GetBlobReader(ctx, file)
-	if err != nil {
-		return nil, "", err
+	return &Download{
+		File:    file,
+		Blob:    blob,
+		Content: newBlobReadSeeker(ctx, s.storage, blob),
+	}, nil
+}
+
+// RecordDownload increments the download counter of the file and
+// records the download in the audit log.
+func (s *Service) RecordDownload(ctx context.Context, d *Download) {
+	userID, _ := userctx.GetUserID(ctx)
+
+	if err := s.repo.IncrementDownloadCount(ctx, d.File.ID); err != nil {
+		log.Printf("Failed to increment download count of %s: %v", d.File.ID, err)
 	}
 
 	// Record the audit entry for download
 	s.audit.Log(ctx, audit.LogParams{
-		UserID:   ownerID,
+		UserID:   userID,
 		Action:   "FILE_DOWNLOADED",
-		TargetID: file.ID,
-		Details:  map[string]interface{}{"filename": file.Filename},
+		TargetID: d.File.ID,
+		Details:  map[string]interface{}{"filename": d.File.Filename},
 	})
-
-	return blobReader, file.Filename, nil
 }
 
-// DeleteFile deletes a file record and its associated blob from storage if no other references exist.
-// The blob record's refcount is automatically decremented and deleted through a database trigger.
+// DeleteFile moves a file to the trash, from where it can be restored until it is purged.
+// The file keeps its blob, versions and shares, and keeps counting towards the owner's quota.
 // Only the owner of the file can perform this action.
 func (s *Service) DeleteFile(ctx context.Context, fileID uuid.UUID) error {
-	userID, ok := userctx.GetUserID(ctx)
-	if !ok {
-		return apierror.NewUnauthorizedError()
-	}
-
-	file, err := s.repo.GetFileByUUID(ctx, fileID)
+	file, userID, err := s.authorizeFile(ctx, fileID, access.Owner)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return apierror.NewNotFoundError("File")
-		}
 		return err
 	}
 
-	// Ownership check
-	if file.OwnerID != userID {
-		return apierror.NewForbiddenError()
+	if err := s.repo.TrashFile(ctx, fileID); err != nil {
+		log.Printf("error while trying to trash file: %v", err)
+		return apierror.NewInternalServerError("Failed to move file to the trash")
 	}
 
-	// delete the file record
-	if err := s.repo.DeleteFile(ctx, fileID); err != nil {
-		log.Printf("error while trying to delete file: %v", err)
-		return apierror.NewInternalServerError("Failed to delete file record")
-	}
-
-	storagePath, err := s.repo.DeleteBlobIfUnused(ctx, file.BlobID)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			log.Printf("Blob %s is still referenced, not deleting from storage.", file.BlobID)
-			return nil
-		}
-		return apierror.NewInternalServerError("Failed to clean up blob record")
-	}
-
-	log.Printf("Blob %s is unreferenced, deleting object %s from storage.", file.BlobID, storagePath)
-	if err := s.storage.DeleteBlob(ctx, storagePath); err != nil {
-		// Critical error: The DB record is gone, but the physical file remains.
-		log.Printf("CRITICAL: Failed to delete object %s from storage: %v", storagePath, err)
-	}
-
-	log.Printf("Successfully deleted file %s", fileID)
+	log.Printf("Moved file %s to the trash", fileID)
 
 	// Record the audit entry for file deletion
 	s.audit.Log(ctx, audit.LogParams{
@@ -282,31 +319,11 @@ func (s *Service) DeleteFile(ctx context.Context, fileID uuid.UUID) error {
 	return nil
 }
 
-// GetBlobReader returns a ReadCloser for the blob content corresponding to the given file.
-// It fetches the blob from storage using the blob's storage path.
-func (s *Service) GetBlobReader(ctx context.Context, file sqlc.File) (io.ReadCloser, error) {
-	blob, err := s.repo.GetBlobByID(ctx, file.BlobID)
-	blobFileName := blob.StoragePath
-	log.Printf("Looking up object: key=%s", blobFileName)
-	obj, err := s.storage.GetBlob(ctx, blobFileName)
-	if err != nil {
-		return nil, err
-	}
-
-	// we dont need to check if the object exists here as the storage layer already does that for us
-	return obj, nil
-}
-
-// UpdateFilename renames a file owned by the current user.
+// UpdateFilename renames a file the current user has write access to.
 // Returns the updated FileResponse or an error if the user
 // is unauthorized, forbidden, or the update fails.
 func (s *Service) UpdateFilename(ctx context.Context, newFilename string, fileID uuid.UUID) (FileResponse, error) {
-	userID, ok := userctx.GetUserID(ctx)
-	if !ok {
-		return FileResponse{}, apierror.NewUnauthorizedError()
-	}
-
-	file, err := s.repo.GetFileByUUID(ctx, fileID)
+	file, userID, err := s.authorizeFile(ctx, fileID, access.Write)
 	if err != nil {
 		return FileResponse{}, err
 	}
@@ -314,11 +331,6 @@ func (s *Service) UpdateFilename(ctx context.Context, newFilename string, fileID
 	// getting the old name (to audit log)
 	oldName := file.Filename
 
-	// Ownership check
-	if file.OwnerID != userID {
-		return FileResponse{}, apierror.NewForbiddenError()
-	}
-
 	file, err = s.repo.UpdateFilename(ctx, sqlc.UpdateFilenameParams{
 		Filename: newFilename,
 		ID:       fileID,
@@ -335,6 +347,85 @@ func (s *Service) UpdateFilename(ctx context.Context, newFilename string, fileID
 		Details:  map[string]interface{}{"old_name": oldName, "new_name": file.Filename},
 	})
 
+	return newFileResponse(file, userID), nil
+
+}
+
+// ReplaceFileContent uploads a new version of a file the current user has write
+// access to, keeping its ID, name, shares, public link and download count. The new
+// content is stored like an upload and counts against the owner's quota. The
+// previous contents are kept in the file's version history, and keep counting
+// towards the quota, until they are deleted or pruned. Uploading the current
+// contents again does not create a version.
+func (s *Service) ReplaceFileContent(ctx context.Context, fileID uuid.UUID, r io.Reader, contentType string) (FileResponse, error) {
+	file, userID, err := s.authorizeFile(ctx, fileID, access.Write)
+	if err != nil {
+		return FileResponse{}, err
+	}
+
+	blob, err := s.storeUpload(ctx, file.OwnerID, r, contentType)
+	if err != nil {
+		return FileResponse{}, err
+	}
+
+	if blob.ID == file.BlobID {
+		return newFileResponse(file, userID), nil
+	}
+
+	updated, err := s.createVersion(ctx, file.ID, sqlc.UpdateFileBlobParams{
+		BlobID:       blob.ID,
+		Size:         blob.Size,
+		DeclaredMime: util.NewText(contentType),
+		ID:           file.ID,
+	})
+	if err != nil {
+		log.Printf("Failed to replace contents of %s: %v", fileID, err)
+		s.releaseBlob(ctx, blob.ID)
+		return FileResponse{}, apierror.NewInternalServerError("Failed to replace file")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "FILE_REPLACED",
+		TargetID: file.ID,
+		Details: map[string]interface{}{
+			"filename":  file.Filename,
+			"old_size":  file.Size,
+			"size":      updated.Size,
+			"mime_type": blob.MimeType.String,
+			"version":   updated.CurrentVersion,
+		},
+	})
+
+	return newFileResponse(updated, userID), nil
+}
+
+// releaseBlob deletes a blob record and its object from storage if no file references it anymore,
+// along with the previews of the blob.
+func (s *Service) releaseBlob(ctx context.Context, blobID uuid.UUID) error {
+	deleted, err := s.repo.DeleteBlobIfUnused(ctx, blobID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			log.Printf("Blob %s is still referenced, not deleting from storage.", blobID)
+			return nil
+		}
+		return apierror.NewInternalServerError("Failed to clean up blob record")
+	}
+
+	log.Printf("Blob %s is unreferenced, deleting object %s from storage.", blobID, deleted.StoragePath)
+	if err := s.storage.DeleteBlob(ctx, deleted.StoragePath); err != nil {
+		// Critical error: The DB record is gone, but the physical file remains.
+		log.Printf("CRITICAL: Failed to delete object %s from storage: %v", deleted.StoragePath, err)
+	}
+	if err := s.storage.DeleteBlobs(ctx, previewKeys(deleted.Sha256)); err != nil {
+		log.Printf("Failed to delete the previews of blob %s from storage: %v", blobID, err)
+	}
+	return nil
+}
+
+// newFileResponse converts a file record into the FileResponse returned
+// to the user identified by userID.
+func newFileResponse(file sqlc.File, userID int64) FileResponse {
 	return FileResponse{
 		ID:            file.ID,
 		Filename:      file.Filename,
@@ -343,25 +434,18 @@ func (s *Service) UpdateFilename(ctx context.Context, newFilename string, fileID
 		UploadedAt:    file.UploadedAt.Time,
 		UserOwnsFile:  file.OwnerID == userID,
 		DownloadCount: &file.DownloadCount.Int64,
+		Version:       file.CurrentVersion,
 		ItemType:      "file",
-	}, nil
-
+	}
 }
 
 // ListUsersWithAccesToFile returns all users who currently
 // have access to a given file, this includes the owner,
 // and the users the file is shared with.
-// Returns a slice of User or an error if the caller lacks access.
+// Returns a slice of User or an error if the caller may not reshare the file.
 func (s *Service) ListUsersWithAccessToFile(ctx context.Context, fileID uuid.UUID) ([]User, error) {
-	userID, ok := userctx.GetUserID(ctx)
-	if !ok {
-		return nil, apierror.NewUnauthorizedError()
-	}
-
-	file, _ := s.repo.GetFileByUUID(ctx, fileID)
-
-	if file.OwnerID != userID {
-		return nil, apierror.NewForbiddenError()
+	if _, _, err := s.authorizeFile(ctx, fileID, access.Reshare); err != nil {
+		return nil, err
 	}
 
 	userRows, err := s.repo.ListUsersWithAccessToFile(ctx, fileID)
@@ -395,13 +479,26 @@ func (s *Service) ListContents(ctx context.Context, req ListContentsRequest) (Li
 
 	log.Printf("Retrieving contents of folder: %v, with sort: %s %s", req.FolderID, req.SortBy, req.SortOrder)
 
+	// the metadata predicates are passed as parallel arrays, all of which must hold
+	metadataKeys := make([]string, len(req.Metadata))
+	metadataOps := make([]string, len(req.Metadata))
+	metadataValues := make([]string, len(req.Metadata))
+	for i, p := range req.Metadata {
+		metadataKeys[i], metadataOps[i], metadataValues[i] = p.Key, p.Op, p.Value
+	}
+
 	if req.FolderID != nil {
 		// --- Handle Listing a Specific Folder ---
 		folder, err := s.repo.GetFolderByID(ctx, *req.FolderID)
 		if err != nil {
 			return ListContentsResponse{}, apierror.NewNotFoundError("Folder")
 		}
-		if folder.OwnerID != userID {
+		// the owner and anyone the folder or one of its ancestors is shared with can browse it
+		permission, err := s.folderPermission(ctx, folder, userID)
+		if err != nil {
+			return ListContentsResponse{}, err
+		}
+		if permission < access.Read {
 			return ListContentsResponse{}, apierror.NewForbiddenError()
 		}
 
@@ -418,6 +515,10 @@ func (s *Service) ListContents(ctx context.Context, req ListContentsRequest) (Li
 			Offset:         req.Offset,
 			UploadedAfter:  util.ToPgTimestamptz(req.UploadedAfter),
 			UploadedBefore: util.ToPgTimestamptz(req.UploadedBefore),
+			Tags:           req.Tags,
+			MetadataKeys:   metadataKeys,
+			MetadataOps:    metadataOps,
+			MetadataValues: metadataValues,
 		}
 		rows, repoErr := s.repo.ListFolderContents(ctx, params)
 		if repoErr != nil {
@@ -444,6 +545,10 @@ func (s *Service) ListContents(ctx context.Context, req ListContentsRequest) (Li
 			Offset:          req.Offset,
 			UploadedAfter:   util.ToPgTimestamptz(req.UploadedAfter),
 			UploadedBefore:  util.ToPgTimestamptz(req.UploadedBefore),
+			Tags:            req.Tags,
+			MetadataKeys:    metadataKeys,
+			MetadataOps:     metadataOps,
+			MetadataValues:  metadataValues,
 		}
 		rows, repoErr := s.repo.ListRootContents(ctx, params)
 		if repoErr != nil {
@@ -486,6 +591,10 @@ func mapListFolderContentsRows(rows []sqlc.ListFolderContentsRow) []ContentItem
 		if r.DownloadCount.Valid {
 			item.DownloadCount = &r.DownloadCount.Int64
 		}
+		if len(r.Tags) > 0 {
+			item.Tags = r.Tags
+		}
+		item.Metadata = decodeMetadata(r.Metadata)
 		items[i] = item
 	}
 	return items
@@ -514,6 +623,10 @@ func mapListRootContentsRows(rows []sqlc.ListRootContentsRow) []ContentItem {
 		if r.DownloadCount.Valid {
 			item.DownloadCount = &r.DownloadCount.Int64
 		}
+		if len(r.Tags) > 0 {
+			item.Tags = r.Tags
+		}
+		item.Metadata = decodeMetadata(r.Metadata)
 		items[i] = item
 	}
 	return items
@@ -536,31 +649,11 @@ func (s *Service) ListAllFiles(ctx context.Context, limit, offset int32, sortBy,
 	})
 }
 
-// GetShareInfo returns sharing details for a file owned by
-// the current user, including its share URL and the list
-// of users who have been granted access.
+// GetShareInfo returns sharing details for a file the current
+// user may reshare, including its public link URL (empty if
+// the file has none) and the list of users who have been granted access.
 func (s *Service) GetShareInfo(ctx context.Context, fileID uuid.UUID) (ShareInfoResponse, error) {
-	userID, ok := userctx.GetUserID(ctx)
-	if !ok {
-		return ShareInfoResponse{}, apierror.NewUnauthorizedError()
-	}
-
-	// Ownership check
-	file, err := s.repo.GetFileByUUID(ctx, fileID)
-	if err != nil {
-		return ShareInfoResponse{}, apierror.NewNotFoundError("File")
-	}
-	if file.OwnerID != userID {
-		return ShareInfoResponse{}, apierror.NewForbiddenError()
-	}
-
-	// Get the blob, the blob's storagePath is the share URL
-	blob, err := s.repo.GetBlobByID(ctx, file.BlobID)
-	if err != nil {
-		return ShareInfoResponse{}, apierror.NewInternalServerError("Unable to fetch blob")
-	}
-
-	shareURL, err := s.storage.GetBlobURL(ctx, blob.StoragePath)
+	file, userID, err := s.authorizeFile(ctx, fileID, access.Reshare)
 	if err != nil {
 		return ShareInfoResponse{}, err
 	}
@@ -589,6 +682,10 @@ func (s *Service) GetShareInfo(ctx context.Context, fileID uuid.UUID) (ShareInfo
 
 	allUsers := make([]User, 0, len(allUsersRows))
 	for _, r := range allUsersRows {
+		// The owner is listed to resharers, but can't be shared with
+		if r.ID == file.OwnerID {
+			continue
+		}
 		allUsers = append(allUsers, User{
 			ID:    r.ID,
 			Name:  r.Name,
@@ -598,7 +695,7 @@ func (s *Service) GetShareInfo(ctx context.Context, fileID uuid.UUID) (ShareInfo
 
 	// Bundle and return response
 	return ShareInfoResponse{
-		ShareURL:   shareURL,
+		ShareURL:   s.publicLinkURL(file),
 		SharedWith: sharedWith,
 		AllUsers:   allUsers,
 	}, nil
@@ -608,19 +705,11 @@ func (s *Service) GetShareInfo(ctx context.Context, fileID uuid.UUID) (ShareInfo
 // the caller owns both the file and the target folder (if provided).
 // If the target folder is not provided, it is moved to the root Folder.
 func (s *Service) MoveFile(ctx context.Context, fileID uuid.UUID, req MoveFileRequest) error {
-	userID, ok := userctx.GetUserID(ctx)
-	if !ok {
-		return apierror.NewUnauthorizedError()
-	}
-
-	// Get file, ownership check
-	file, err := s.repo.GetFileByUUID(ctx, fileID)
+	// Files are moved within the owner's folders, so only the owner may move them
+	_, userID, err := s.authorizeFile(ctx, fileID, access.Owner)
 	if err != nil {
 		return err
 	}
-	if file.OwnerID != userID {
-		return apierror.NewForbiddenError()
-	}
 
 	if req.TargetFolderID != nil {
 		// verify folder exists and is owned by user
@@ -644,22 +733,61 @@ func (s *Service) MoveFile(ctx context.Context, fileID uuid.UUID, req MoveFileRe
 	return s.repo.UpdateFileFolder(ctx, params)
 }
 
-// UpdateFileShares updates the list of users a file is shared with.
-// It removes all existing shares for the file, then inserts the new list
-// of user IDs in a single transaction to ensure atomicity.
+// UpdateFileShares updates the users a file is shared with and their permissions.
+// The owner replaces the whole list. Users the file is shared with for resharing
+// can only add users and raise permissions: shares they leave out are kept, and
+// lowering a share is forbidden. All existing shares for the file are removed and
+// the resulting list is inserted in a single transaction to ensure atomicity.
 func (s *Service) UpdateFileShares(ctx context.Context, req UpdateFileSharesRequest) error {
-	userID, ok := userctx.GetUserID(ctx)
-	if !ok {
-		return apierror.NewUnauthorizedError()
+	file, userID, err := s.authorizeFile(ctx, req.FileID, access.Reshare)
+	if err != nil {
+		return err
 	}
 
-	// Ownership Check
-	file, err := s.repo.GetFileByUUID(ctx, req.FileID)
+	current, err := s.repo.ListUsersWithAccessToFile(ctx, req.FileID)
 	if err != nil {
-		return apierror.NewNotFoundError("File")
+		return apierror.NewInternalServerError("could not update shares")
 	}
-	if file.OwnerID != userID {
-		return apierror.NewForbiddenError()
+	existing := make(map[int64]string, len(current))
+	for _, r := range current {
+		existing[r.ID] = r.Permission
+	}
+
+	permissions := make(map[int64]string, len(req.Shares))
+	for _, share := range req.Shares {
+		if share.UserID == file.OwnerID {
+			return apierror.NewBadRequestError("A file can't be shared with its owner")
+		}
+		if _, duplicate := permissions[share.UserID]; duplicate {
+			return apierror.NewBadRequestError(fmt.Sprintf("User %d is listed more than once", share.UserID))
+		}
+
+		permission := share.Permission
+		if permission == "" {
+			permission = existing[share.UserID]
+		}
+		if permission == "" {
+			permission = access.Read.String()
+		}
+		if _, ok := access.ParseShare(permission); !ok {
+			return apierror.NewBadRequestError(fmt.Sprintf("Invalid permission %q", share.Permission))
+		}
+		permissions[share.UserID] = permission
+	}
+	// only the owner may remove or lower shares, access inherited
+	// from a folder is managed on the folder instead
+	if userID != file.OwnerID {
+		for targetUserID, current := range existing {
+			requested, listed := permissions[targetUserID]
+			if !listed {
+				permissions[targetUserID] = current
+				continue
+			}
+			requestedLevel, _ := access.ParseShare(requested)
+			if currentLevel, _ := access.ParseShare(current); requestedLevel < currentLevel {
+				return apierror.New(http.StatusForbidden, "Only the owner can lower the permission of a share")
+			}
+		}
 	}
 
 	// Starting a database transaction
@@ -679,22 +807,32 @@ func (s *Service) UpdateFileShares(ctx context.Context, req UpdateFileSharesRequ
 	}
 
 	// if there are new users to share with, perform a bulk insert
-	// everything succeeded, commit the transaction.
-	if len(req.UserIDs) > 0 {
-		params := make([]sqlc.AddSharesToFileParams, len(req.UserIDs))
-
-		for i, targetUserID := range req.UserIDs {
-			params[i] = sqlc.AddSharesToFileParams{
+	if len(permissions) > 0 {
+		params := make([]sqlc.AddSharesToFileParams, 0, len(permissions))
+		for targetUserID, permission := range permissions {
+			params = append(params, sqlc.AddSharesToFileParams{
 				FileID:     req.FileID,
 				SharedWith: targetUserID,
-			}
+				Permission: permission,
+			})
 		}
 
-		_, err = qtx.AddSharesToFile(ctx, params)
-		if err != nil {
+		if _, err := qtx.AddSharesToFile(ctx, params); err != nil {
+			log.Printf("Failed to add shares to %s: %v", req.FileID, err)
 			return apierror.NewInternalServerError("could not add new shares")
 		}
 	}
-	return tx.Commit(ctx)
 
+	// everything succeeded, commit the transaction.
+	if err := tx.Commit(ctx); err != nil {
+		return apierror.NewInternalServerError("could not update shares")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "FILE_SHARED",
+		TargetID: file.ID,
+		Details:  map[string]interface{}{"filename": file.Filename, "shares": permissions},
+	})
+	return nil
 }
@@ -0,0 +1,366 @@
+package files_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/memdb"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/storage"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/google/uuid"
+)
+
+type nopAudit struct{}
+
+func (nopAudit) Log(ctx context.Context, params audit.LogParams) {}
+
+type testEnv struct {
+	db      *memdb.DB
+	store   *storage.MemoryStorage
+	service *files.Service
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	db := memdb.New()
+	store := storage.NewMemoryStorage()
+	return &testEnv{
+		db:      db,
+		store:   store,
+		service: files.NewService(db, db, db, store, nopAudit{}, "http://vault.test"),
+	}
+}
+
+// createUser adds a user with the given quota and returns a context authenticated as them.
+func (e *testEnv) createUser(t *testing.T, email string, quota int64) (int64, context.Context) {
+	t.Helper()
+	user, err := e.db.CreateUser(context.Background(), email, email, "hash", quota)
+	if err != nil {
+		t.Fatalf("CreateUser(%s): %v", email, err)
+	}
+	return user.ID, userctx.SetUserID(context.Background(), user.ID)
+}
+
+func (e *testEnv) usedStorage(t *testing.T, userID int64) int64 {
+	t.Helper()
+	user, err := e.db.GetUserByID(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("GetUserByID(%d): %v", userID, err)
+	}
+	return user.StorageUsed
+}
+
+// assertNoTempObjects fails if an upload left a temporary object behind.
+func (e *testEnv) assertNoTempObjects(t *testing.T) {
+	t.Helper()
+	for _, key := range e.store.Keys() {
+		if strings.HasPrefix(key, "tmp/") {
+			t.Errorf("temporary object %s was not cleaned up", key)
+		}
+	}
+}
+
+// statusOf returns the HTTP status of an apierror, or 0 for nil and other errors.
+func statusOf(err error) int {
+	var apiErr *apierror.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+func upload(ctx context.Context, svc *files.Service, name, content string) (uuid.UUID, error) {
+	file, err := svc.UploadFile(ctx, strings.NewReader(content), name, "text/plain", nil)
+	return file.ID, err
+}
+
+func TestUploadFileDedup(t *testing.T) {
+	type step struct {
+		user    string
+		content string
+	}
+	tests := []struct {
+		name         string
+		uploads      []step
+		wantBlobs    int
+		wantRefcount map[string]int32 // content -> refcount
+	}{
+		{
+			name:         "same content twice by one user",
+			uploads:      []step{{"alice", "hello"}, {"alice", "hello"}},
+			wantBlobs:    1,
+			wantRefcount: map[string]int32{"hello": 2},
+		},
+		{
+			name:         "same content by two users",
+			uploads:      []step{{"alice", "hello"}, {"bob", "hello"}},
+			wantBlobs:    1,
+			wantRefcount: map[string]int32{"hello": 2},
+		},
+		{
+			name:         "different content",
+			uploads:      []step{{"alice", "hello"}, {"alice", "world"}},
+			wantBlobs:    2,
+			wantRefcount: map[string]int32{"hello": 1, "world": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ids := map[string]int64{}
+			ctxs := map[string]context.Context{}
+			wantUsed := map[string]int64{}
+			for _, u := range []string{"alice", "bob"} {
+				ids[u], ctxs[u] = env.createUser(t, u+"@example.com", 1<<20)
+			}
+
+			for _, s := range tt.uploads {
+				if _, err := upload(ctxs[s.user], env.service, "note.txt", s.content); err != nil {
+					t.Fatalf("UploadFile: %v", err)
+				}
+				wantUsed[s.user] += int64(len(s.content))
+			}
+
+			blobs := env.db.Blobs()
+			if len(blobs) != tt.wantBlobs {
+				t.Fatalf("got %d blobs, want %d", len(blobs), tt.wantBlobs)
+			}
+			for _, blob := range blobs {
+				data, ok := env.store.Object(blob.StoragePath)
+				if !ok {
+					t.Fatalf("blob %s has no object in storage", blob.ID)
+				}
+				if want := tt.wantRefcount[string(data)]; blob.Refcount != want {
+					t.Errorf("blob %q refcount = %d, want %d", data, blob.Refcount, want)
+				}
+			}
+			if got := len(env.store.Keys()); got != tt.wantBlobs {
+				t.Errorf("got %d objects in storage, want %d", got, tt.wantBlobs)
+			}
+			if got := len(env.db.Files()); got != len(tt.uploads) {
+				t.Errorf("got %d file records, want %d", got, len(tt.uploads))
+			}
+			for user, want := range wantUsed {
+				if got := env.usedStorage(t, ids[user]); got != want {
+					t.Errorf("%s storage used = %d, want %d", user, got, want)
+				}
+			}
+			env.assertNoTempObjects(t)
+		})
+	}
+}
+
+// barrierStorage holds every MoveBlob until the given number of uploads have
+// reached it, so that they all miss the blob lookup before any record exists.
+type barrierStorage struct {
+	*storage.MemoryStorage
+	arrived sync.WaitGroup
+}
+
+func (b *barrierStorage) MoveBlob(ctx context.Context, srcPath, dstPath string) error {
+	b.arrived.Done()
+	b.arrived.Wait()
+	return b.MemoryStorage.MoveBlob(ctx, srcPath, dstPath)
+}
+
+func TestUploadFileConcurrentDedup(t *testing.T) {
+	const uploads = 2
+	db := memdb.New()
+	store := &barrierStorage{MemoryStorage: storage.NewMemoryStorage()}
+	store.arrived.Add(uploads)
+	env := &testEnv{db: db, store: store.MemoryStorage, service: files.NewService(db, db, db, store, nopAudit{}, "http://vault.test")}
+	_, ctx := env.createUser(t, "alice@example.com", 1<<20)
+
+	errs := make(chan error, uploads)
+	for range uploads {
+		go func() {
+			_, err := upload(ctx, env.service, "note.txt", "hello")
+			errs <- err
+		}()
+	}
+	for range uploads {
+		if err := <-errs; err != nil {
+			t.Fatalf("UploadFile: %v", err)
+		}
+	}
+
+	blobs := env.db.Blobs()
+	if len(blobs) != 1 {
+		t.Fatalf("got %d blobs, want 1", len(blobs))
+	}
+	if data, ok := env.store.Object(blobs[0].StoragePath); !ok || string(data) != "hello" {
+		t.Fatalf("object of the surviving blob = %q, %v; want \"hello\"", data, ok)
+	}
+	if got := len(env.store.Keys()); got != 1 {
+		t.Errorf("got %d objects in storage, want 1", got)
+	}
+	if got := len(env.db.Files()); got != uploads {
+		t.Errorf("got %d file records, want %d", got, uploads)
+	}
+	env.assertNoTempObjects(t)
+}
+
+func TestUploadFileQuota(t *testing.T) {
+	tests := []struct {
+		name       string
+		quota      int64
+		existing   []string
+		content    string
+		wantStatus int
+	}{
+		{name: "fits exactly", quota: 5, content: "hello"},
+		{name: "one byte over", quota: 4, content: "hello", wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "over after earlier uploads", quota: 8, existing: []string{"abcd"}, content: "hello", wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "duplicate content still counts", quota: 8, existing: []string{"hello"}, content: "hello", wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "empty file on full quota", quota: 4, existing: []string{"abcd"}, content: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			userID, ctx := env.createUser(t, "alice@example.com", tt.quota)
+			for _, content := range tt.existing {
+				if _, err := upload(ctx, env.service, "existing.txt", content); err != nil {
+					t.Fatalf("seeding upload: %v", err)
+				}
+			}
+			usedBefore := env.usedStorage(t, userID)
+			filesBefore := len(env.db.Files())
+
+			_, err := upload(ctx, env.service, "new.txt", tt.content)
+
+			if got := statusOf(err); got != tt.wantStatus || (tt.wantStatus == 0 && err != nil) {
+				t.Fatalf("UploadFile error = %v, want status %d", err, tt.wantStatus)
+			}
+			wantUsed, wantFiles := usedBefore, filesBefore
+			if tt.wantStatus == 0 {
+				wantUsed += int64(len(tt.content))
+				wantFiles++
+			}
+			if got := env.usedStorage(t, userID); got != wantUsed {
+				t.Errorf("storage used = %d, want %d", got, wantUsed)
+			}
+			if got := len(env.db.Files()); got != wantFiles {
+				t.Errorf("got %d file records, want %d", got, wantFiles)
+			}
+			env.assertNoTempObjects(t)
+		})
+	}
+}
+
+func TestDownloadFileAccess(t *testing.T) {
+	tests := []struct {
+		name       string
+		requester  string
+		wantStatus int
+	}{
+		{name: "owner", requester: "owner"},
+		{name: "shared with", requester: "friend"},
+		{name: "not shared with", requester: "stranger", wantStatus: http.StatusForbidden},
+		{name: "unauthenticated", requester: "", wantStatus: http.StatusUnauthorized},
+	}
+
+	env := newTestEnv(t)
+	ctxs := map[string]context.Context{"": context.Background()}
+	var friendID int64
+	_, ctxs["owner"] = env.createUser(t, "owner@example.com", 1<<20)
+	friendID, ctxs["friend"] = env.createUser(t, "friend@example.com", 1<<20)
+	_, ctxs["stranger"] = env.createUser(t, "stranger@example.com", 1<<20)
+
+	fileID, err := upload(ctxs["owner"], env.service, "secret.txt", "top secret")
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	if err := env.service.UpdateFileShares(ctxs["owner"], files.UpdateFileSharesRequest{
+		FileID: fileID,
+		Shares: []files.Share{{UserID: friendID}},
+	}); err != nil {
+		t.Fatalf("UpdateFileShares: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			download, err := env.service.DownloadFile(ctxs[tt.requester], fileID)
+			if got := statusOf(err); got != tt.wantStatus || (tt.wantStatus == 0 && err != nil) {
+				t.Fatalf("DownloadFile error = %v, want status %d", err, tt.wantStatus)
+			}
+			if tt.wantStatus != 0 {
+				return
+			}
+			defer download.Content.Close()
+			filename := download.File.Filename
+			data, err := io.ReadAll(download.Content)
+			if err != nil {
+				t.Fatalf("reading download: %v", err)
+			}
+			if string(data) != "top secret" || filename != "secret.txt" {
+				t.Errorf("got %q (%s), want %q (secret.txt)", data, filename, "top secret")
+			}
+		})
+	}
+}
+
+func TestDeleteFileBlobCleanup(t *testing.T) {
+	tests := []struct {
+		name        string
+		copies      int  // files owned by the deleter with the same content
+		asOther     bool // delete as a user who does not own the file
+		wantStatus  int
+		wantObjects int
+	}{
+		{name: "last reference removes object", copies: 1, wantObjects: 0},
+		{name: "shared blob is kept", copies: 2, wantObjects: 1},
+		{name: "not the owner", copies: 1, asOther: true, wantStatus: http.StatusForbidden, wantObjects: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ownerID, ownerCtx := env.createUser(t, "owner@example.com", 1<<20)
+			_, otherCtx := env.createUser(t, "other@example.com", 1<<20)
+
+			var fileID uuid.UUID
+			for i := 0; i < tt.copies; i++ {
+				id, err := upload(ownerCtx, env.service, "report.txt", "quarterly numbers")
+				if err != nil {
+					t.Fatalf("UploadFile: %v", err)
+				}
+				fileID = id
+			}
+
+			ctx := ownerCtx
+			if tt.asOther {
+				ctx = otherCtx
+			}
+			err := env.service.DeleteFile(ctx, fileID)
+			if got := statusOf(err); got != tt.wantStatus || (tt.wantStatus == 0 && err != nil) {
+				t.Fatalf("DeleteFile error = %v, want status %d", err, tt.wantStatus)
+			}
+			if err == nil {
+				if err := env.service.PurgeFile(ctx, fileID); err != nil {
+					t.Fatalf("PurgeFile: %v", err)
+				}
+			}
+
+			if got := len(env.store.Keys()); got != tt.wantObjects {
+				t.Errorf("got %d objects in storage, want %d", got, tt.wantObjects)
+			}
+			if got := len(env.db.Blobs()); got != tt.wantObjects {
+				t.Errorf("got %d blob records, want %d", got, tt.wantObjects)
+			}
+			remaining := int64(len(env.db.Files())) * int64(len("quarterly numbers"))
+			if got := env.usedStorage(t, ownerID); got != remaining {
+				t.Errorf("storage used = %d, want %d", got, remaining)
+			}
+		})
+	}
+}
@@ -0,0 +1,339 @@
+package files
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ListTrash returns the files and folders the current user moved to the trash,
+// most recently trashed first. The contents of trashed folders are not listed
+// separately, they are restored and purged with their folder.
+func (s *Service) ListTrash(ctx context.Context) ([]TrashItem, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return nil, apierror.NewUnauthorizedError()
+	}
+
+	rows, err := s.repo.ListTrash(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to list trash of user %d: %v", userID, err)
+		return nil, apierror.NewInternalServerError("Failed to list trash")
+	}
+
+	items := make([]TrashItem, 0, len(rows))
+	for _, row := range rows {
+		item := TrashItem{
+			ID:        row.ID,
+			ItemType:  row.ItemType,
+			Filename:  row.Filename,
+			TrashedAt: row.TrashedAt.Time,
+		}
+		if row.Size.Valid {
+			item.Size = &row.Size.Int64
+		}
+		if row.ContentType.Valid {
+			item.ContentType = &row.ContentType.String
+		}
+		if row.FolderID.Valid {
+			folderID := uuid.UUID(row.FolderID.Bytes)
+			item.FolderID = &folderID
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// RestoreFile takes a file out of the trash and puts it back in its folder.
+// If that folder is in the trash itself, the file is restored to the root.
+// Only the owner of the file can perform this action.
+func (s *Service) RestoreFile(ctx context.Context, fileID uuid.UUID) (FileResponse, error) {
+	file, userID, err := s.getTrashedFile(ctx, fileID)
+	if err != nil {
+		return FileResponse{}, err
+	}
+
+	restored, err := s.repo.RestoreFile(ctx, fileID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return FileResponse{}, apierror.NewNotFoundError("File")
+		}
+		log.Printf("Failed to restore file %s: %v", fileID, err)
+		return FileResponse{}, apierror.NewInternalServerError("Failed to restore file")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "FILE_RESTORED",
+		TargetID: file.ID,
+		Details:  map[string]interface{}{"filename": file.Filename, "folder_id": folderIDDetail(restored.FolderID)},
+	})
+	return newFileResponse(restored, userID), nil
+}
+
+// RestoreFolder takes a folder and the contents that were trashed with it out
+// of the trash and puts the folder back in its parent. If the parent is in the
+// trash itself, the folder is restored to the root. Contents that were trashed
+// on their own before the folder stay in the trash.
+// Only the owner of the folder can perform this action.
+func (s *Service) RestoreFolder(ctx context.Context, folderID uuid.UUID) error {
+	folder, userID, err := s.getTrashedFolder(ctx, folderID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.folderRepo.RestoreFolder(ctx, folderID); err != nil {
+		log.Printf("Failed to restore folder %s: %v", folderID, err)
+		return apierror.NewInternalServerError("Failed to restore folder")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "FOLDER_RESTORED",
+		TargetID: folder.ID,
+		Details:  map[string]interface{}{"name": folder.Name},
+	})
+	return nil
+}
+
+// PurgeFile permanently deletes a file from the trash, along with its versions.
+// Blobs no longer referenced by any file are removed from storage.
+// Only the owner of the file can perform this action.
+func (s *Service) PurgeFile(ctx context.Context, fileID uuid.UUID) error {
+	file, userID, err := s.getTrashedFile(ctx, fileID)
+	if err != nil {
+		return err
+	}
+	return s.purgeFile(ctx, file, userID, false)
+}
+
+// PurgeFolder permanently deletes a folder from the trash, along with all of
+// its subfolders and files, including those that were trashed on their own.
+// Blobs no longer referenced by any file are removed from storage.
+// Only the owner of the folder can perform this action.
+func (s *Service) PurgeFolder(ctx context.Context, folderID uuid.UUID) error {
+	folder, userID, err := s.getTrashedFolder(ctx, folderID)
+	if err != nil {
+		return err
+	}
+	return s.purgeFolder(ctx, folder, userID, false)
+}
+
+// EmptyTrash permanently deletes everything the current user moved to the trash.
+// Returns the number of purged files and folders.
+func (s *Service) EmptyTrash(ctx context.Context) (int, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return 0, apierror.NewUnauthorizedError()
+	}
+
+	rows, err := s.repo.ListTrash(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to list trash of user %d: %v", userID, err)
+		return 0, apierror.NewInternalServerError("Failed to list trash")
+	}
+
+	purged := 0
+	for _, row := range rows {
+		if err := s.purgeItem(ctx, row.ID, row.ItemType, userID, false); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// PurgeExpiredTrash permanently deletes the trashed files and folders of all
+// users that were moved to the trash before the given time.
+// Returns the number of purged files and folders.
+func (s *Service) PurgeExpiredTrash(ctx context.Context, before time.Time) (int, error) {
+	rows, err := s.repo.ListExpiredTrash(ctx, pgtype.Timestamptz{Time: before, Valid: true})
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, row := range rows {
+		if err := s.purgeItem(ctx, row.ID, row.ItemType, row.OwnerID, true); err != nil {
+			// the item is retried on the next run
+			log.Printf("Failed to purge expired %s %s: %v", row.ItemType, row.ID, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// RunTrashPurger purges trashed items once they have been in the trash for
+// longer than retention, checking every interval until ctx is done.
+func (s *Service) RunTrashPurger(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.PurgeExpiredTrash(ctx, time.Now().Add(-retention))
+		if err != nil {
+			log.Printf("Failed to purge expired trash: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d expired items from the trash", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// getTrashedFile fetches a file the current user moved to the trash.
+func (s *Service) getTrashedFile(ctx context.Context, fileID uuid.UUID) (sqlc.File, int64, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return sqlc.File{}, 0, apierror.NewUnauthorizedError()
+	}
+
+	file, err := s.repo.GetTrashedFile(ctx, fileID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return sqlc.File{}, 0, apierror.NewNotFoundError("File")
+		}
+		return sqlc.File{}, 0, apierror.NewInternalServerError("Unable to fetch file")
+	}
+	if file.OwnerID != userID {
+		return sqlc.File{}, 0, apierror.NewForbiddenError()
+	}
+	return file, userID, nil
+}
+
+// getTrashedFolder fetches a folder the current user moved to the trash.
+func (s *Service) getTrashedFolder(ctx context.Context, folderID uuid.UUID) (sqlc.Folder, int64, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return sqlc.Folder{}, 0, apierror.NewUnauthorizedError()
+	}
+
+	folder, err := s.folderRepo.GetTrashedFolder(ctx, folderID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return sqlc.Folder{}, 0, apierror.NewNotFoundError("Folder")
+		}
+		return sqlc.Folder{}, 0, apierror.NewInternalServerError("Unable to fetch folder")
+	}
+	if folder.OwnerID != userID {
+		return sqlc.Folder{}, 0, apierror.NewForbiddenError()
+	}
+	return folder, userID, nil
+}
+
+// purgeItem permanently deletes a trashed file or folder owned by ownerID.
+func (s *Service) purgeItem(ctx context.Context, id uuid.UUID, itemType string, ownerID int64, expired bool) error {
+	if itemType == "folder" {
+		folder, err := s.folderRepo.GetTrashedFolder(ctx, id)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return nil // purged along with an earlier folder
+			}
+			return apierror.NewInternalServerError("Unable to fetch folder")
+		}
+		return s.purgeFolder(ctx, folder, ownerID, expired)
+	}
+
+	file, err := s.repo.GetTrashedFile(ctx, id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil // purged along with an earlier folder
+		}
+		return apierror.NewInternalServerError("Unable to fetch file")
+	}
+	return s.purgeFile(ctx, file, ownerID, expired)
+}
+
+// purgeFile deletes a file record with its versions and releases their blobs.
+// The blob refcounts and the owner's storage usage are updated by database triggers.
+func (s *Service) purgeFile(ctx context.Context, file sqlc.File, ownerID int64, expired bool) error {
+	// the versions are deleted along with the file, their blobs are released below
+	versions, err := s.repo.ListFileVersions(ctx, file.ID)
+	if err != nil {
+		return apierror.NewInternalServerError("Failed to list file versions")
+	}
+
+	if err := s.repo.DeleteFile(ctx, file.ID); err != nil {
+		log.Printf("error while trying to delete file: %v", err)
+		return apierror.NewInternalServerError("Failed to delete file record")
+	}
+
+	if err := s.releaseBlob(ctx, file.BlobID); err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if err := s.releaseBlob(ctx, v.BlobID); err != nil {
+			return err
+		}
+	}
+	log.Printf("Purged file %s from the trash", file.ID)
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   ownerID,
+		Action:   "TRASH_PURGED",
+		TargetID: file.ID,
+		Details: map[string]interface{}{
+			"item_type": "file",
+			"filename":  file.Filename,
+			"size":      file.Size,
+			"expired":   expired,
+		},
+	})
+	return nil
+}
+
+// purgeFolder deletes a folder record, which cascades to its subfolders and
+// files, and releases the blobs they referenced.
+func (s *Service) purgeFolder(ctx context.Context, folder sqlc.Folder, ownerID int64, expired bool) error {
+	// get all blobs of files within this folder and its subfolders
+	blobIDs, err := s.folderRepo.GetBlobIDsInFolderHierarchy(ctx, folder.ID)
+	if err != nil && err != pgx.ErrNoRows {
+		return apierror.NewInternalServerError("Could not retrieve files for deletion")
+	}
+
+	// ON DELETE CASCADE deletes all subfolders and file records automatically
+	if err := s.folderRepo.DeleteFolder(ctx, folder.ID); err != nil {
+		return apierror.NewInternalServerError("Failed to delete folder from database")
+	}
+
+	log.Printf("Checking %d blobs for cleanup...", len(blobIDs))
+	for _, blobID := range blobIDs {
+		if err := s.releaseBlob(ctx, blobID); err != nil {
+			log.Printf("Error during blob cleanup for %s: %v", blobID, err)
+		}
+	}
+	log.Printf("Purged folder %s and all its contents from the trash", folder.ID)
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   ownerID,
+		Action:   "TRASH_PURGED",
+		TargetID: folder.ID,
+		Details: map[string]interface{}{
+			"item_type": "folder",
+			"name":      folder.Name,
+			"expired":   expired,
+		},
+	})
+	return nil
+}
+
+// folderIDDetail formats a folder ID for audit details, with nil for the root.
+func folderIDDetail(folderID pgtype.UUID) interface{} {
+	if !folderID.Valid {
+		return nil
+	}
+	return uuid.UUID(folderID.Bytes).String()
+}
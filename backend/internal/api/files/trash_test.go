@@ -0,0 +1,140 @@
+package files_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/folders"
+)
+
+// TestTrashFolder trashes a folder holding a file, checks that both are hidden
+// but still count towards the quota, and restores them.
+func TestTrashFolder(t *testing.T) {
+	env := newTestEnv(t)
+	ownerID, ctx := env.createUser(t, "owner@example.com", 1<<20)
+	_, otherCtx := env.createUser(t, "other@example.com", 1<<20)
+	folderService := folders.NewService(env.db, env.db, nopAudit{})
+
+	docs, err := folderService.CreateFolder(ctx, folders.CreateFolderRequest{Name: "docs"})
+	if err != nil {
+		t.Fatalf("CreateFolder: %v", err)
+	}
+	file, err := env.service.UploadFile(ctx, strings.NewReader("report"), "report.txt", "text/plain", &docs.ID)
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+
+	if err := folderService.DeleteFolder(ctx, docs.ID); err != nil {
+		t.Fatalf("DeleteFolder: %v", err)
+	}
+	if _, err := env.service.DownloadFile(ctx, file.ID); statusOf(err) != http.StatusNotFound {
+		t.Errorf("DownloadFile of trashed file error = %v, want 404", err)
+	}
+	if _, err := env.service.UploadFile(ctx, strings.NewReader("late"), "late.txt", "text/plain", &docs.ID); err == nil {
+		t.Error("UploadFile into a trashed folder succeeded")
+	}
+	if got := env.usedStorage(t, ownerID); got != int64(len("report")) {
+		t.Errorf("storage used = %d, want %d", got, len("report"))
+	}
+
+	items, err := env.service.ListTrash(ctx)
+	if err != nil {
+		t.Fatalf("ListTrash: %v", err)
+	}
+	if len(items) != 1 || items[0].ID != docs.ID || items[0].ItemType != "folder" {
+		t.Fatalf("ListTrash = %+v, want only the docs folder", items)
+	}
+	// the file was trashed with its folder and is only restored with it
+	if _, err := env.service.RestoreFile(ctx, file.ID); statusOf(err) != http.StatusNotFound {
+		t.Errorf("RestoreFile of folder content error = %v, want 404", err)
+	}
+	if err := env.service.RestoreFolder(otherCtx, docs.ID); statusOf(err) != http.StatusForbidden {
+		t.Errorf("RestoreFolder as other user error = %v, want 403", err)
+	}
+
+	if err := env.service.RestoreFolder(ctx, docs.ID); err != nil {
+		t.Fatalf("RestoreFolder: %v", err)
+	}
+	download, err := env.service.DownloadFile(ctx, file.ID)
+	if err != nil {
+		t.Fatalf("DownloadFile after restore: %v", err)
+	}
+	download.Content.Close()
+	if items, _ := env.service.ListTrash(ctx); len(items) != 0 {
+		t.Errorf("ListTrash after restore = %+v, want empty", items)
+	}
+}
+
+// TestRestoreFileFromTrashedFolder restores a file whose folder was trashed
+// after it, which puts the file back at the root.
+func TestRestoreFileFromTrashedFolder(t *testing.T) {
+	env := newTestEnv(t)
+	_, ctx := env.createUser(t, "owner@example.com", 1<<20)
+	folderService := folders.NewService(env.db, env.db, nopAudit{})
+
+	docs, err := folderService.CreateFolder(ctx, folders.CreateFolderRequest{Name: "docs"})
+	if err != nil {
+		t.Fatalf("CreateFolder: %v", err)
+	}
+	file, err := env.service.UploadFile(ctx, strings.NewReader("report"), "report.txt", "text/plain", &docs.ID)
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	if err := env.service.DeleteFile(ctx, file.ID); err != nil {
+		t.Fatalf("DeleteFile: %v", err)
+	}
+	if err := folderService.DeleteFolder(ctx, docs.ID); err != nil {
+		t.Fatalf("DeleteFolder: %v", err)
+	}
+	if items, _ := env.service.ListTrash(ctx); len(items) != 2 {
+		t.Fatalf("ListTrash = %+v, want the file and the folder", items)
+	}
+
+	if _, err := env.service.RestoreFile(ctx, file.ID); err != nil {
+		t.Fatalf("RestoreFile: %v", err)
+	}
+	restored, err := env.db.GetFileByUUID(context.Background(), file.ID)
+	if err != nil {
+		t.Fatalf("GetFileByUUID after restore: %v", err)
+	}
+	if restored.FolderID.Valid {
+		t.Errorf("restored file is in folder %v, want the root", restored.FolderID)
+	}
+	if _, err := env.service.RestoreFile(ctx, file.ID); statusOf(err) != http.StatusNotFound {
+		t.Errorf("second RestoreFile error = %v, want 404", err)
+	}
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	env := newTestEnv(t)
+	ownerID, ctx := env.createUser(t, "owner@example.com", 1<<20)
+	fileID, err := upload(ctx, env.service, "old.txt", "stale data")
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	if err := env.service.DeleteFile(ctx, fileID); err != nil {
+		t.Fatalf("DeleteFile: %v", err)
+	}
+
+	purged, err := env.service.PurgeExpiredTrash(context.Background(), time.Now().Add(-time.Hour))
+	if err != nil || purged != 0 {
+		t.Fatalf("PurgeExpiredTrash before retention = %d, %v, want 0", purged, err)
+	}
+	if len(env.store.Keys()) != 1 || env.usedStorage(t, ownerID) != int64(len("stale data")) {
+		t.Fatal("trashed file was released before its retention ended")
+	}
+
+	purged, err = env.service.PurgeExpiredTrash(context.Background(), time.Now().Add(time.Minute))
+	if err != nil || purged != 1 {
+		t.Fatalf("PurgeExpiredTrash after retention = %d, %v, want 1", purged, err)
+	}
+	if got := env.usedStorage(t, ownerID); got != 0 {
+		t.Errorf("storage used = %d, want 0", got)
+	}
+	if len(env.db.Files()) != 0 || len(env.db.Blobs()) != 0 || len(env.store.Keys()) != 0 {
+		t.Errorf("got %d files, %d blobs and %d objects, want none", len(env.db.Files()), len(env.db.Blobs()), len(env.store.Keys()))
+	}
+}
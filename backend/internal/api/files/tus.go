@@ -0,0 +1,181 @@
+package files
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apphandler"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// tusVersion is the version of the tus resumable upload protocol implemented
+// by the /files/uploads endpoints (core + creation + expiration + termination extensions).
+const tusVersion = "1.0.0"
+
+// registerTusRoutes registers the resumable upload (tus) endpoints on the given router.
+func (h *FileHandler) registerTusRoutes(r chi.Router) {
+	r.Options("/files/uploads", h.TusOptions)
+	r.Post("/files/uploads", tusHandler(h.CreateResumableUpload))
+	r.Head("/files/uploads/{id}", tusHandler(h.GetResumableUploadOffset))
+	r.Patch("/files/uploads/{id}", tusHandler(h.PatchResumableUpload))
+	r.Delete("/files/uploads/{id}", tusHandler(h.TerminateResumableUpload))
+}
+
+// TusOptions handles OPTIONS /files/uploads, advertising
+// the supported protocol version and extensions.
+func (h *FileHandler) TusOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", "creation,expiration,termination")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateResumableUpload handles POST /files/uploads (tus creation extension).
+// It reads the Upload-Length and Upload-Metadata headers (filename, filetype
+// and folder_id are recognised) and responds with the Location of the new upload.
+func (h *FileHandler) CreateResumableUpload(w http.ResponseWriter, r *http.Request) error {
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil {
+		return apierror.NewBadRequestError("Missing or invalid Upload-Length header")
+	}
+
+	metadata, err := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid Upload-Metadata header")
+	}
+
+	req := CreateUploadSessionRequest{
+		Filename:    metadata["filename"],
+		ContentType: metadata["filetype"],
+		Length:      length,
+	}
+	if folderIDStr := metadata["folder_id"]; folderIDStr != "" {
+		folderID, err := uuid.Parse(folderIDStr)
+		if err != nil {
+			return apierror.NewBadRequestError("Invalid folder_id")
+		}
+		req.FolderID = &folderID
+	}
+
+	session, err := h.service.CreateUploadSession(r.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/files/uploads/"+session.ID.String())
+	w.Header().Set("Upload-Offset", "0")
+	w.Header().Set("Upload-Expires", session.ExpiresAt.Time.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+// GetResumableUploadOffset handles HEAD /files/uploads/{id}, reporting
+// how many bytes of the upload have been received so far.
+func (h *FileHandler) GetResumableUploadOffset(w http.ResponseWriter, r *http.Request) error {
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewNotFoundError("Upload")
+	}
+
+	session, err := h.service.GetUploadSession(r.Context(), sessionID)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(session.UploadOffset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(session.UploadLength, 10))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// PatchResumableUpload handles PATCH /files/uploads/{id}, appending the request
+// body to the upload at the given Upload-Offset. The file is created once the
+// final chunk has been received.
+func (h *FileHandler) PatchResumableUpload(w http.ResponseWriter, r *http.Request) error {
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewNotFoundError("Upload")
+	}
+
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		return apierror.New(http.StatusUnsupportedMediaType, "Content-Type must be application/offset+octet-stream")
+	}
+
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return apierror.NewBadRequestError("Missing or invalid Upload-Offset header")
+	}
+
+	session, err := h.service.WriteUploadChunk(r.Context(), sessionID, offset, r.Body)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(session.UploadOffset, 10))
+	w.Header().Set("Upload-Expires", session.ExpiresAt.Time.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// TerminateResumableUpload handles DELETE /files/uploads/{id} (tus termination
+// extension), discarding an unfinished upload and its stored chunks.
+func (h *FileHandler) TerminateResumableUpload(w http.ResponseWriter, r *http.Request) error {
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewNotFoundError("Upload")
+	}
+
+	if err := h.service.TerminateUploadSession(r.Context(), sessionID); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// tusHandler wraps a tus endpoint so that every response carries the Tus-Resumable
+// header, and requests made with an unsupported protocol version are rejected.
+func tusHandler(handler apphandler.AppHandler) http.HandlerFunc {
+	wrapped := apphandler.MakeHTTPHandler(handler)
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Tus-Resumable", tusVersion)
+		if r.Header.Get("Tus-Resumable") != tusVersion {
+			w.Header().Set("Tus-Version", tusVersion)
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		wrapped(w, r)
+	}
+}
+
+// parseUploadMetadata decodes a tus Upload-Metadata header, a comma separated list
+// of "key base64(value)" pairs where the value may be omitted.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if header == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		switch len(fields) {
+		case 1:
+			metadata[fields[0]] = ""
+		case 2:
+			value, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			metadata[fields[0]] = string(value)
+		default:
+			return nil, errors.New("malformed metadata pair")
+		}
+	}
+	return metadata, nil
+}
@@ -42,9 +42,20 @@ type FileResponse struct {
 	UploadedAt    time.Time `json:"uploaded_at"`
 	UserOwnsFile  bool      `json:"user_owns_file"`
 	DownloadCount *int64    `json:"download_count,omitempty"`
+	Version       int32     `json:"version,omitempty"`
 	ItemType      string    `json:"item_type"`
 }
 
+// FileVersionResponse describes one version of a file. The current version
+// is the one served by downloads, the others are retained history.
+type FileVersionResponse struct {
+	Version     int32     `json:"version"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	CreatedAt   time.Time `json:"created_at"`
+	Current     bool      `json:"current"`
+}
+
 // User represents a user who has access to files, including permissions.
 type User struct {
 	ID         int64  `json:"id"`
@@ -56,31 +67,98 @@ type User struct {
 // ContentItem is a standardized representation of a content node,
 // which may be a file or a folder, including metadata useful to the frontend.
 type ContentItem struct {
-	ID            uuid.UUID `json:"id"`
-	ItemType      string    `json:"item_type"` // "file" or "folder"
-	Filename      string    `json:"filename"`
-	Size          *int64    `json:"size,omitempty"`
-	ContentType   *string   `json:"content_type,omitempty"`
-	UploadedAt    time.Time `json:"uploaded_at"`
-	UserOwnsFile  bool      `json:"user_owns_file"`
-	DownloadCount *int64    `json:"download_count,omitempty"`
+	ID            uuid.UUID         `json:"id"`
+	ItemType      string            `json:"item_type"` // "file" or "folder"
+	Filename      string            `json:"filename"`
+	Size          *int64            `json:"size,omitempty"`
+	ContentType   *string           `json:"content_type,omitempty"`
+	UploadedAt    time.Time         `json:"uploaded_at"`
+	UserOwnsFile  bool              `json:"user_owns_file"`
+	DownloadCount *int64            `json:"download_count,omitempty"`
+	Tags          []string          `json:"tags,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
 }
 
-// ListContentsRequest defines the filter, pagination, and sort
-// parameters accepted when listing folder or root contents.
-type ListContentsRequest struct {
-	FolderID        *uuid.UUID    `json:"folder_id"`
-	Search          string        `json:"search"`
+// TrashItem is a file or folder in the trash. FolderID is the folder it was
+// deleted from, which it is restored to unless that folder is trashed too.
+type TrashItem struct {
+	ID          uuid.UUID  `json:"id"`
+	ItemType    string     `json:"item_type"` // "file" or "folder"
+	Filename    string     `json:"filename"`
+	Size        *int64     `json:"size,omitempty"`
+	ContentType *string    `json:"content_type,omitempty"`
+	FolderID    *uuid.UUID `json:"folder_id,omitempty"`
+	TrashedAt   time.Time  `json:"trashed_at"`
+}
+
+// ContentFilters are the MIME type, upload date, ownership and size filters
+// shared by listing and searching contents. Only files are filtered by upload
+// date and size.
+type ContentFilters struct {
 	MimeType        string        `json:"content_type"`
 	UploadedAfter   *time.Time    `json:"uploaded_after"`
 	UploadedBefore  *time.Time    `json:"uploaded_before"`
 	OwnershipStatus int32         `json:"user_owns_file"`
-	Limit           int32         `json:"limit"`
-	Offset          int32         `json:"offset"`
 	MinSize         sql.NullInt64 `json:"min_size"`
 	MaxSize         sql.NullInt64 `json:"max_size"`
-	SortBy          string        `json:"sort_by"`
-	SortOrder       string        `json:"sort_order"`
+}
+
+// ListContentsRequest defines the filter, pagination, and sort
+// parameters accepted when listing folder or root contents.
+type ListContentsRequest struct {
+	ContentFilters
+	FolderID  *uuid.UUID `json:"folder_id"`
+	Search    string     `json:"search"`
+	Limit     int32      `json:"limit"`
+	Offset    int32      `json:"offset"`
+	SortBy    string     `json:"sort_by"`
+	SortOrder string     `json:"sort_order"`
+	// Tags and Metadata restrict the listing to the items that have every
+	// tag and satisfy every metadata predicate.
+	Tags     []string            `json:"tags"`
+	Metadata []MetadataPredicate `json:"metadata"`
+}
+
+// Operators of a MetadataPredicate.
+const (
+	MetadataEquals    = "eq"
+	MetadataNotEquals = "ne"
+	MetadataContains  = "contains"
+	MetadataExists    = "exists"
+)
+
+// MetadataPredicate is a condition on the value of a metadata key, used to
+// filter listings. Contains matches case-insensitively; NotEquals also holds
+// for items without the key and Exists ignores Value.
+type MetadataPredicate struct {
+	Key   string `json:"key"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// UpdateTagsRequest adds tags to and removes tags from a selection of
+// folders and files.
+type UpdateTagsRequest struct {
+	FolderIDs []uuid.UUID `json:"folder_ids"`
+	FileIDs   []uuid.UUID `json:"file_ids"`
+	Add       []string    `json:"add"`
+	Remove    []string    `json:"remove"`
+}
+
+// UpdateMetadataRequest sets metadata keys on and removes metadata keys from
+// a selection of folders and files.
+type UpdateMetadataRequest struct {
+	FolderIDs []uuid.UUID       `json:"folder_ids"`
+	FileIDs   []uuid.UUID       `json:"file_ids"`
+	Set       map[string]string `json:"set"`
+	Remove    []string          `json:"remove"`
+}
+
+// TagSuggestion is a tag offered for autocompletion, with the number of
+// folders and files visible to the user that carry it.
+type TagSuggestion struct {
+	Tag  string `json:"tag"`
+	Uses int64  `json:"uses"`
 }
 
 // ListContentsResponse wraps the list of ContentItems returned
@@ -90,6 +168,63 @@ type ListContentsResponse struct {
 	TotalCount int64         `json:"totalCount"`
 }
 
+// SearchRequest defines the search term, filters and pagination accepted when
+// searching across all files and folders the user can access. Fuzzy also
+// matches names that are only similar to the search term, to allow for typos.
+type SearchRequest struct {
+	ContentFilters
+	Search string `json:"search"`
+	Fuzzy  bool   `json:"fuzzy"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+// SearchResult is a file or folder found by a search, along with the folders
+// leading to it. Path starts at the user's root for items they own and at the
+// topmost folder shared with them otherwise, and is empty for items at the root.
+type SearchResult struct {
+	ContentItem
+	Path []PathSegment `json:"path"`
+	Rank float32       `json:"rank"`
+}
+
+// PathSegment is a folder on the path to a search result.
+type PathSegment struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// SearchResponse wraps the ranked search results returned
+// along with the total count for pagination support.
+type SearchResponse struct {
+	Data       []SearchResult `json:"data"`
+	TotalCount int64          `json:"totalCount"`
+}
+
+// ContentSearchRequest defines the search term and pagination accepted when
+// searching the text of all files the user can access. The term supports
+// quoted phrases, OR and words excluded with a leading minus.
+type ContentSearchRequest struct {
+	Search string `json:"search"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+// ContentSearchResult is a file whose text matches a content search. Snippet
+// holds HTML-escaped excerpts of the text with the matching words wrapped in
+// <mark> elements.
+type ContentSearchResult struct {
+	SearchResult
+	Snippet string `json:"snippet"`
+}
+
+// ContentSearchResponse wraps the ranked content search results returned
+// along with the total count for pagination support.
+type ContentSearchResponse struct {
+	Data       []ContentSearchResult `json:"data"`
+	TotalCount int64                 `json:"totalCount"`
+}
+
 // ShareInfoResponse represents sharing details for a file,
 // including its share URL, current shared users, and all
 // available users the file can be shared with.
@@ -103,8 +238,16 @@ type ShareInfoResponse struct {
 // UpdateFileSharesRequest represents a request to update
 // the users a file is shared with, replacing any existing shares.
 type UpdateFileSharesRequest struct {
-	FileID  uuid.UUID
-	UserIDs []int64
+	FileID uuid.UUID
+	Shares []Share
+}
+
+// Share grants a user a permission on a file: read, comment, write or reshare.
+// An empty Permission keeps the permission the user already has,
+// users the file is not shared with yet get read access.
+type Share struct {
+	UserID     int64  `json:"user_id"`
+	Permission string `json:"permission"`
 }
 
 // UpdateFilenameRequest represents a request to update
@@ -141,8 +284,107 @@ type MoveFileRequest struct {
 	TargetFolderID *uuid.UUID `json:"target_folder_id"`
 }
 
+// CopyFileRequest represents a request to copy a file into a folder of the
+// current user. A nil TargetFolderID copies to the root and an empty Filename
+// keeps the name of the file.
+type CopyFileRequest struct {
+	TargetFolderID *uuid.UUID `json:"target_folder_id"`
+	Filename       string     `json:"filename"`
+}
+
+// SendCopyRequest represents a request to send a copy of a file to another
+// user, which is placed at their root.
+type SendCopyRequest struct {
+	UserID int64 `json:"user_id"`
+}
+
 // updateSharesPayload represents the JSON payload used to update
-// the list of user IDs a file is shared with.
+// the users a file is shared with. UserIDs is the short form of
+// Shares without a permission.
 type updateSharesPayload struct {
 	UserIDs []int64 `json:"user_ids"`
+	Shares  []Share `json:"shares"`
+}
+
+// CreateUploadSessionRequest represents a request to start a
+// resumable upload of Length bytes into an optional target folder.
+type CreateUploadSessionRequest struct {
+	Filename    string
+	ContentType string
+	FolderID    *uuid.UUID
+	Length      int64
+}
+
+// InitiateDirectUploadRequest represents a request to upload a file
+// directly to storage. Size and SHA256 are verified on completion.
+type InitiateDirectUploadRequest struct {
+	Filename    string     `json:"filename"`
+	ContentType string     `json:"content_type"`
+	Size        int64      `json:"size"`
+	SHA256      string     `json:"sha256"`
+	FolderID    *uuid.UUID `json:"folder_id"`
+}
+
+// InitiateDirectUploadResponse tells the client where and how to send
+// the file contents for a direct upload.
+type InitiateDirectUploadResponse struct {
+	UploadID  uuid.UUID         `json:"upload_id"`
+	URL       string            `json:"url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// PublicLinkRequest holds the optional restrictions of a public share link.
+// Omitted fields leave the link unrestricted.
+type PublicLinkRequest struct {
+	ExpiresAt    *time.Time `json:"expires_at"`
+	MaxDownloads *int32     `json:"max_downloads"`
+	Password     string     `json:"password"`
+}
+
+// PublicLinkResponse describes the public share link of a file.
+type PublicLinkResponse struct {
+	URL           string     `json:"url"`
+	Token         uuid.UUID  `json:"token"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	MaxDownloads  *int32     `json:"max_downloads"`
+	DownloadCount int32      `json:"download_count"`
+	HasPassword   bool       `json:"has_password"`
+}
+
+// ArchiveRequest selects the folders and files to download as one archive.
+// Format is "zip" (the default) or "tar.gz".
+type ArchiveRequest struct {
+	FolderIDs []uuid.UUID `json:"folder_ids"`
+	FileIDs   []uuid.UUID `json:"file_ids"`
+	Format    string      `json:"format"`
+}
+
+// Upload statuses reported in an UploadResult.
+const (
+	UploadCreated      = "created"
+	UploadDeduplicated = "deduplicated" // stored as a reference to identical contents
+	UploadFailed       = "failed"
+)
+
+// UploadResult reports what happened to one file of a multi-file upload.
+type UploadResult struct {
+	Path   string        `json:"path"`
+	Status string        `json:"status"`
+	File   *FileResponse `json:"file,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// ExtractRequest selects the folder an archive is extracted into.
+// A nil TargetFolderID extracts next to the archive.
+type ExtractRequest struct {
+	TargetFolderID *uuid.UUID `json:"target_folder_id"`
+}
+
+// ExtractResponse describes an extracted archive: the folder created for it
+// and the result of every entry.
+type ExtractResponse struct {
+	FolderID uuid.UUID      `json:"folder_id"`
+	Results  []UploadResult `json:"results"`
 }
@@ -0,0 +1,238 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/access"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// createVersion makes the given blob the next version of a file. The current
+// contents are archived into the version history and the file is pointed at
+// the new blob in one transaction. The version triggers keep the archived blob
+// referenced and counted towards the owner's storage.
+func (s *Service) createVersion(ctx context.Context, fileID uuid.UUID, params sqlc.UpdateFileBlobParams) (sqlc.File, error) {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return sqlc.File{}, err
+	}
+	defer tx.Rollback(ctx) // rollback on error
+	qtx := s.repo.WithTx(tx)
+
+	if _, err := qtx.ArchiveCurrentVersion(ctx, fileID); err != nil {
+		return sqlc.File{}, err
+	}
+	updated, err := qtx.UpdateFileBlob(ctx, params)
+	if err != nil {
+		return sqlc.File{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return sqlc.File{}, err
+	}
+	return updated, nil
+}
+
+// ListFileVersions returns all versions of a file, newest first, starting
+// with the current one. Requires read access.
+func (s *Service) ListFileVersions(ctx context.Context, fileID uuid.UUID) ([]FileVersionResponse, error) {
+	file, _, err := s.authorizeFile(ctx, fileID, access.Read)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.repo.ListFileVersions(ctx, fileID)
+	if err != nil {
+		log.Printf("Failed to list versions of %s: %v", fileID, err)
+		return nil, apierror.NewInternalServerError("Failed to list versions")
+	}
+
+	versions := make([]FileVersionResponse, 0, len(history)+1)
+	versions = append(versions, FileVersionResponse{
+		Version:     file.CurrentVersion,
+		Size:        file.Size,
+		ContentType: file.DeclaredMime.String,
+		CreatedAt:   file.UploadedAt.Time,
+		Current:     true,
+	})
+	for _, v := range history {
+		versions = append(versions, FileVersionResponse{
+			Version:     v.Version,
+			Size:        v.Size,
+			ContentType: v.DeclaredMime.String,
+			CreatedAt:   v.CreatedAt.Time,
+		})
+	}
+	return versions, nil
+}
+
+// DownloadFileVersion returns a Download of a version of a file, which may be
+// the current one. Requires read access. Downloads of versions are not counted.
+func (s *Service) DownloadFileVersion(ctx context.Context, fileID uuid.UUID, version int32) (*Download, error) {
+	file, _, err := s.authorizeFile(ctx, fileID, access.Read)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != file.CurrentVersion {
+		v, err := s.getVersion(ctx, fileID, version)
+		if err != nil {
+			return nil, err
+		}
+		file.BlobID = v.BlobID
+		file.Size = v.Size
+		file.DeclaredMime = v.DeclaredMime
+		file.UploadedAt = v.CreatedAt
+	}
+
+	blob, err := s.repo.GetBlobByID(ctx, file.BlobID)
+	if err != nil {
+		return nil, apierror.NewInternalServerError("Unable to fetch blob")
+	}
+
+	return &Download{
+		File:    file,
+		Blob:    blob,
+		Content: newBlobReadSeeker(ctx, s.storage, blob),
+	}, nil
+}
+
+// RestoreFileVersion makes the contents of a previous version current again.
+// The restored contents become a new version, so no history is lost: the
+// current contents are archived and the restored version stays in the history.
+// Requires write access, and the restored contents count against the owner's quota.
+func (s *Service) RestoreFileVersion(ctx context.Context, fileID uuid.UUID, version int32) (FileResponse, error) {
+	file, userID, err := s.authorizeFile(ctx, fileID, access.Write)
+	if err != nil {
+		return FileResponse{}, err
+	}
+	if version == file.CurrentVersion {
+		return FileResponse{}, apierror.NewBadRequestError("Version is already the current version")
+	}
+
+	v, err := s.getVersion(ctx, fileID, version)
+	if err != nil {
+		return FileResponse{}, err
+	}
+	if v.BlobID == file.BlobID {
+		return newFileResponse(file, userID), nil
+	}
+
+	remaining, err := s.remainingQuota(ctx, file.OwnerID)
+	if err != nil {
+		return FileResponse{}, err
+	}
+	if v.Size > remaining {
+		return FileResponse{}, apierror.New(http.StatusRequestEntityTooLarge, "Storage quota exceeded")
+	}
+
+	updated, err := s.createVersion(ctx, file.ID, sqlc.UpdateFileBlobParams{
+		BlobID:       v.BlobID,
+		Size:         v.Size,
+		DeclaredMime: v.DeclaredMime,
+		ID:           file.ID,
+	})
+	if err != nil {
+		log.Printf("Failed to restore version %d of %s: %v", version, fileID, err)
+		return FileResponse{}, apierror.NewInternalServerError("Failed to restore version")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "FILE_VERSION_RESTORED",
+		TargetID: file.ID,
+		Details: map[string]interface{}{
+			"filename":      file.Filename,
+			"restored_from": version,
+			"version":       updated.CurrentVersion,
+		},
+	})
+	return newFileResponse(updated, userID), nil
+}
+
+// DeleteFileVersion deletes a previous version of a file and releases its
+// storage. The current version can't be deleted. Only the owner can delete
+// versions, since they are charged for them.
+func (s *Service) DeleteFileVersion(ctx context.Context, fileID uuid.UUID, version int32) error {
+	file, userID, err := s.authorizeFile(ctx, fileID, access.Owner)
+	if err != nil {
+		return err
+	}
+	if version == file.CurrentVersion {
+		return apierror.NewBadRequestError("The current version can't be deleted")
+	}
+
+	blobID, err := s.repo.DeleteFileVersion(ctx, fileID, version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return apierror.NewNotFoundError("Version")
+	}
+	if err != nil {
+		log.Printf("Failed to delete version %d of %s: %v", version, fileID, err)
+		return apierror.NewInternalServerError("Failed to delete version")
+	}
+	if err := s.releaseBlob(ctx, blobID); err != nil {
+		return err
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "FILE_VERSION_DELETED",
+		TargetID: file.ID,
+		Details:  map[string]interface{}{"filename": file.Filename, "version": version},
+	})
+	return nil
+}
+
+// PruneFileVersions deletes all but the newest keep previous versions of a
+// file and releases their storage. The current version is always kept.
+// Only the owner can prune versions. Returns the number of deleted versions.
+func (s *Service) PruneFileVersions(ctx context.Context, fileID uuid.UUID, keep int32) (int, error) {
+	file, userID, err := s.authorizeFile(ctx, fileID, access.Owner)
+	if err != nil {
+		return 0, err
+	}
+	if keep < 0 {
+		return 0, apierror.NewBadRequestError("keep must not be negative")
+	}
+
+	blobIDs, err := s.repo.PruneFileVersions(ctx, fileID, keep)
+	if err != nil {
+		log.Printf("Failed to prune versions of %s: %v", fileID, err)
+		return 0, apierror.NewInternalServerError("Failed to prune versions")
+	}
+	for _, blobID := range blobIDs {
+		if err := s.releaseBlob(ctx, blobID); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(blobIDs) > 0 {
+		s.audit.Log(ctx, audit.LogParams{
+			UserID:   userID,
+			Action:   "FILE_VERSION_DELETED",
+			TargetID: file.ID,
+			Details:  map[string]interface{}{"filename": file.Filename, "pruned": len(blobIDs), "kept": keep},
+		})
+	}
+	return len(blobIDs), nil
+}
+
+// getVersion returns a retained version of a file, or a 404 if there is none.
+func (s *Service) getVersion(ctx context.Context, fileID uuid.UUID, version int32) (sqlc.FileVersion, error) {
+	v, err := s.repo.GetFileVersion(ctx, fileID, version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return sqlc.FileVersion{}, apierror.NewNotFoundError("Version")
+	}
+	if err != nil {
+		log.Printf("Failed to get version %d of %s: %v", version, fileID, err)
+		return sqlc.FileVersion{}, apierror.NewInternalServerError("Failed to get version")
+	}
+	return v, nil
+}
@@ -0,0 +1,152 @@
+package files_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
+)
+
+func TestFileVersions(t *testing.T) {
+	env := newTestEnv(t)
+	ownerID, ctx := env.createUser(t, "owner@example.com", 1<<20)
+	fileID, err := upload(ctx, env.service, "notes.txt", "v1")
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	for _, content := range []string{"v2 longer", "v3 longest"} {
+		if _, err := env.service.ReplaceFileContent(ctx, fileID, strings.NewReader(content), "text/plain"); err != nil {
+			t.Fatalf("ReplaceFileContent(%q): %v", content, err)
+		}
+	}
+
+	// uploading the current contents again is not a new version
+	res, err := env.service.ReplaceFileContent(ctx, fileID, strings.NewReader("v3 longest"), "text/plain")
+	if err != nil || res.Version != 3 {
+		t.Fatalf("ReplaceFileContent(same) = version %d, %v, want version 3", res.Version, err)
+	}
+
+	versions, err := env.service.ListFileVersions(ctx, fileID)
+	if err != nil {
+		t.Fatalf("ListFileVersions: %v", err)
+	}
+	var got []int32
+	for _, v := range versions {
+		got = append(got, v.Version)
+	}
+	if len(got) != 3 || got[0] != 3 || got[1] != 2 || got[2] != 1 || !versions[0].Current || versions[1].Current {
+		t.Fatalf("versions = %+v, want 3 (current), 2, 1", versions)
+	}
+	if want := int64(len("v1") + len("v2 longer") + len("v3 longest")); env.usedStorage(t, ownerID) != want {
+		t.Errorf("storage used = %d, want %d", env.usedStorage(t, ownerID), want)
+	}
+
+	readVersion := func(version int32) string {
+		t.Helper()
+		d, err := env.service.DownloadFileVersion(ctx, fileID, version)
+		if err != nil {
+			t.Fatalf("DownloadFileVersion(%d): %v", version, err)
+		}
+		defer d.Content.Close()
+		data, _ := io.ReadAll(d.Content)
+		return string(data)
+	}
+	if got := readVersion(1); got != "v1" {
+		t.Errorf("version 1 = %q, want v1", got)
+	}
+	if _, err := env.service.DownloadFileVersion(ctx, fileID, 7); statusOf(err) != http.StatusNotFound {
+		t.Errorf("DownloadFileVersion(7) error = %v, want 404", err)
+	}
+
+	// restoring keeps the history and adds the old contents as version 4
+	restored, err := env.service.RestoreFileVersion(ctx, fileID, 1)
+	if err != nil {
+		t.Fatalf("RestoreFileVersion: %v", err)
+	}
+	if restored.Version != 4 || readVersion(4) != "v1" || readVersion(1) != "v1" {
+		t.Errorf("restored = version %d, want 4 with the contents of version 1", restored.Version)
+	}
+
+	// pruning down to one previous version releases the others
+	deleted, err := env.service.PruneFileVersions(ctx, fileID, 1)
+	if err != nil || deleted != 2 {
+		t.Fatalf("PruneFileVersions = %d, %v, want 2 deleted", deleted, err)
+	}
+	if err := env.service.DeleteFileVersion(ctx, fileID, 4); statusOf(err) != http.StatusBadRequest {
+		t.Errorf("DeleteFileVersion(current) error = %v, want 400", err)
+	}
+	if err := env.service.DeleteFileVersion(ctx, fileID, 3); err != nil {
+		t.Fatalf("DeleteFileVersion(3): %v", err)
+	}
+	if want := int64(len("v1")); env.usedStorage(t, ownerID) != want {
+		t.Errorf("storage used = %d, want %d", env.usedStorage(t, ownerID), want)
+	}
+	if blobs := env.db.Blobs(); len(blobs) != 1 || len(env.store.Keys()) != 1 {
+		t.Errorf("got %d blobs and %d objects, want only the current one", len(blobs), len(env.store.Keys()))
+	}
+}
+
+func TestFileVersionsQuotaAndCleanup(t *testing.T) {
+	env := newTestEnv(t)
+	ownerID, ctx := env.createUser(t, "owner@example.com", 10)
+	fileID, err := upload(ctx, env.service, "notes.txt", "12345")
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	if _, err := env.service.ReplaceFileContent(ctx, fileID, strings.NewReader("abcd"), "text/plain"); err != nil {
+		t.Fatalf("ReplaceFileContent: %v", err)
+	}
+
+	// the retained version still counts, so restoring it doesn't fit
+	if _, err := env.service.RestoreFileVersion(ctx, fileID, 1); statusOf(err) != http.StatusRequestEntityTooLarge {
+		t.Errorf("RestoreFileVersion over quota error = %v, want 413", err)
+	}
+	if _, err := env.service.PruneFileVersions(ctx, fileID, -1); statusOf(err) != http.StatusBadRequest {
+		t.Errorf("PruneFileVersions(-1) error = %v, want 400", err)
+	}
+
+	// purging the file releases all of its versions
+	if err := env.service.DeleteFile(ctx, fileID); err != nil {
+		t.Fatalf("DeleteFile: %v", err)
+	}
+	if err := env.service.PurgeFile(ctx, fileID); err != nil {
+		t.Fatalf("PurgeFile: %v", err)
+	}
+	if got := env.usedStorage(t, ownerID); got != 0 {
+		t.Errorf("storage used = %d, want 0", got)
+	}
+	if blobs := env.db.Blobs(); len(blobs) != 0 || len(env.store.Keys()) != 0 {
+		t.Errorf("got %d blobs and %d objects, want none", len(blobs), len(env.store.Keys()))
+	}
+}
+
+func TestFileVersionPermissions(t *testing.T) {
+	env := newTestEnv(t)
+	_, ownerCtx := env.createUser(t, "owner@example.com", 1<<20)
+	readerID, readerCtx := env.createUser(t, "reader@example.com", 1<<20)
+	fileID, err := upload(ownerCtx, env.service, "notes.txt", "v1")
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	if _, err := env.service.ReplaceFileContent(ownerCtx, fileID, strings.NewReader("v2"), "text/plain"); err != nil {
+		t.Fatalf("ReplaceFileContent: %v", err)
+	}
+	if err := env.service.UpdateFileShares(ownerCtx, files.UpdateFileSharesRequest{
+		FileID: fileID,
+		Shares: []files.Share{{UserID: readerID, Permission: "read"}},
+	}); err != nil {
+		t.Fatalf("UpdateFileShares: %v", err)
+	}
+
+	if _, err := env.service.ListFileVersions(readerCtx, fileID); err != nil {
+		t.Errorf("ListFileVersions as reader: %v", err)
+	}
+	if _, err := env.service.RestoreFileVersion(readerCtx, fileID, 1); statusOf(err) != http.StatusForbidden {
+		t.Errorf("RestoreFileVersion as reader error = %v, want 403", err)
+	}
+	if err := env.service.DeleteFileVersion(readerCtx, fileID, 1); statusOf(err) != http.StatusForbidden {
+		t.Errorf("DeleteFileVersion as reader error = %v, want 403", err)
+	}
+}
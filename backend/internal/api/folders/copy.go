@@ -0,0 +1,141 @@
+package folders
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/access"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CopyFolder copies a folder with its subfolders and files into a folder of
+// the authenticated user, or to their root if TargetFolderID is nil.
+// - Requires read access to the folder; trashed contents are left out.
+// - The copied files point at the blobs of the originals, so nothing is written
+// to storage, but their sizes are charged to the user's quota like any other file.
+// Returns the new folder, or an error if the copy does not fit into the quota.
+func (s *Service) CopyFolder(ctx context.Context, folderID uuid.UUID, req CopyFolderRequest) (FolderResponse, error) {
+	folder, userID, err := s.authorizeFolder(ctx, folderID, access.Read)
+	if err != nil {
+		return FolderResponse{}, err
+	}
+
+	if req.TargetFolderID != nil {
+		target, err := s.repo.GetFolderByID(ctx, *req.TargetFolderID)
+		if err != nil {
+			return FolderResponse{}, apierror.NewNotFoundError("Folder")
+		}
+		if target.OwnerID != userID {
+			return FolderResponse{}, apierror.NewForbiddenError()
+		}
+	}
+
+	name := req.Name
+	if name == "" {
+		name = folder.Name
+	}
+	return s.copyFolder(ctx, folder, userID, userID, req.TargetFolderID, name)
+}
+
+// SendFolderCopy places a copy of a folder with its subfolders and files at the
+// root of another user, who owns the copy and is charged for it.
+// - Requires reshare access to the folder; trashed contents are left out.
+// - Unlike a share, the recipient keeps the copy when the original changes or is deleted.
+// - The recipient must share a file or folder with the user, so nobody fills the quota of a stranger.
+// Returns the new folder, or an error if the copy does not fit into the recipient's quota.
+func (s *Service) SendFolderCopy(ctx context.Context, folderID uuid.UUID, req SendCopyRequest) (FolderResponse, error) {
+	folder, userID, err := s.authorizeFolder(ctx, folderID, access.Reshare)
+	if err != nil {
+		return FolderResponse{}, err
+	}
+	if req.UserID == userID {
+		return FolderResponse{}, apierror.NewBadRequestError("A copy can't be sent to yourself")
+	}
+	if _, err := s.userRepo.GetUserByID(ctx, req.UserID); err != nil {
+		return FolderResponse{}, apierror.NewNotFoundError("User")
+	}
+	// The recipient is charged for the copy, so only users who chose to share with the sender accept copies
+	shares, err := s.userRepo.UserSharesWith(ctx, sqlc.UserSharesWithParams{OwnerID: req.UserID, SharedWith: userID})
+	if err != nil {
+		log.Printf("Failed to check shares of user %d with user %d: %v", req.UserID, userID, err)
+		return FolderResponse{}, apierror.NewInternalServerError("Failed to send copy")
+	}
+	if !shares {
+		return FolderResponse{}, apierror.New(http.StatusForbidden, "Copies can only be sent to users who share something with you")
+	}
+
+	return s.copyFolder(ctx, folder, userID, req.UserID, nil, folder.Name)
+}
+
+// copyFolder copies folder into parentID of ownerID after checking that the
+// files inside it fit into the owner's remaining quota. The insert trigger on
+// files increments the blob refcounts and the owner's storage usage.
+func (s *Service) copyFolder(ctx context.Context, folder sqlc.Folder, userID, ownerID int64, parentID *uuid.UUID, name string) (FolderResponse, error) {
+	entries, err := s.repo.ListFolderArchiveEntries(ctx, folder.ID)
+	if err != nil {
+		log.Printf("Failed to list contents of folder %s: %v", folder.ID, err)
+		return FolderResponse{}, apierror.NewInternalServerError("Failed to copy folder")
+	}
+	var size, files int64
+	for _, entry := range entries {
+		if entry.FileID.Valid {
+			size += entry.Size.Int64
+			files++
+		}
+	}
+
+	owner, err := s.userRepo.GetUserByID(ctx, ownerID)
+	if err != nil {
+		return FolderResponse{}, apierror.NewInternalServerError("Could not retrieve user data")
+	}
+	if size > owner.StorageQuota-owner.StorageUsed {
+		return FolderResponse{}, apierror.New(http.StatusRequestEntityTooLarge, "Storage quota exceeded")
+	}
+
+	params := sqlc.CopyFolderTreeParams{
+		Name:           name,
+		SourceFolderID: folder.ID,
+		OwnerID:        ownerID,
+	}
+	if parentID != nil {
+		params.TargetFolderID = pgtype.UUID{Bytes: *parentID, Valid: true}
+	}
+	copyID, err := s.repo.CopyFolderTree(ctx, params)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return FolderResponse{}, apierror.NewNotFoundError("Folder")
+		}
+		log.Printf("Failed to copy folder %s for user %d: %v", folder.ID, ownerID, err)
+		return FolderResponse{}, apierror.NewInternalServerError("Failed to copy folder")
+	}
+	copied, err := s.repo.GetFolderByID(ctx, copyID)
+	if err != nil {
+		return FolderResponse{}, apierror.NewInternalServerError("Failed to copy folder")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "FOLDER_COPIED",
+		TargetID: folder.ID,
+		Details: map[string]interface{}{
+			"name":     copied.Name,
+			"copy_id":  copied.ID.String(),
+			"owner_id": ownerID,
+			"files":    files,
+			"size":     size,
+		},
+	})
+	return FolderResponse{
+		ID:           copied.ID,
+		Filename:     copied.Name,
+		UploadedAt:   copied.CreatedAt.Time,
+		UserOwnsFile: copied.OwnerID == userID,
+		ItemType:     "Folder",
+	}, nil
+}
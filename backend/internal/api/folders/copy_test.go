@@ -0,0 +1,195 @@
+package folders_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/folders"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+)
+
+// TestCopyFolder copies docs/work/old with old/c.txt ("gamma") and checks
+// that the copy shares the blob of the original and is charged to its owner.
+func TestCopyFolder(t *testing.T) {
+	tests := []struct {
+		name        string
+		permission  string // permission of the friend on docs, empty for none
+		setup       func(t *testing.T, env *shareEnv)
+		run         func(env *shareEnv) (folders.FolderResponse, error)
+		wantStatus  int
+		wantFolders int
+		wantOwner   func(env *shareEnv) int64
+	}{
+		{
+			name: "into own root",
+			run: func(env *shareEnv) (folders.FolderResponse, error) {
+				return env.folders.CopyFolder(env.ownerCtx, env.docsID, folders.CopyFolderRequest{})
+			},
+			wantFolders: 6,
+		},
+		{
+			name: "into its own subfolder",
+			run: func(env *shareEnv) (folders.FolderResponse, error) {
+				return env.folders.CopyFolder(env.ownerCtx, env.docsID, folders.CopyFolderRequest{TargetFolderID: &env.oldID})
+			},
+			wantFolders: 6,
+		},
+		{
+			name:       "shared folder into own root",
+			permission: "read",
+			run: func(env *shareEnv) (folders.FolderResponse, error) {
+				return env.folders.CopyFolder(env.friendCtx, env.docsID, folders.CopyFolderRequest{Name: "mine"})
+			},
+			wantOwner:   func(env *shareEnv) int64 { return env.friendID },
+			wantFolders: 6,
+		},
+		{
+			name:       "into a folder of the owner",
+			permission: "read",
+			run: func(env *shareEnv) (folders.FolderResponse, error) {
+				return env.folders.CopyFolder(env.friendCtx, env.docsID, folders.CopyFolderRequest{TargetFolderID: &env.workID})
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "without access",
+			run: func(env *shareEnv) (folders.FolderResponse, error) {
+				return env.folders.CopyFolder(env.strangerCtx, env.docsID, folders.CopyFolderRequest{})
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "send to another user",
+			setup: func(t *testing.T, env *shareEnv) {
+				ownerID, _ := userctx.GetUserID(env.ownerCtx)
+				env.shareBack(t, env.strangerCtx, ownerID)
+			},
+			run: func(env *shareEnv) (folders.FolderResponse, error) {
+				return env.folders.SendFolderCopy(env.ownerCtx, env.workID, folders.SendCopyRequest{UserID: env.strangerID})
+			},
+			wantOwner:   func(env *shareEnv) int64 { return env.strangerID },
+			wantFolders: 6,
+		},
+		{
+			name: "send to a user who shares nothing",
+			run: func(env *shareEnv) (folders.FolderResponse, error) {
+				return env.folders.SendFolderCopy(env.ownerCtx, env.workID, folders.SendCopyRequest{UserID: env.strangerID})
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "send with reshare access",
+			permission: "reshare",
+			setup: func(t *testing.T, env *shareEnv) {
+				env.shareBack(t, env.strangerCtx, env.friendID)
+			},
+			run: func(env *shareEnv) (folders.FolderResponse, error) {
+				return env.folders.SendFolderCopy(env.friendCtx, env.docsID, folders.SendCopyRequest{UserID: env.strangerID})
+			},
+			wantOwner:   func(env *shareEnv) int64 { return env.strangerID },
+			wantFolders: 7,
+		},
+		{
+			name:       "send with read access",
+			permission: "read",
+			run: func(env *shareEnv) (folders.FolderResponse, error) {
+				return env.folders.SendFolderCopy(env.friendCtx, env.docsID, folders.SendCopyRequest{UserID: env.strangerID})
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "send to a missing user",
+			run: func(env *shareEnv) (folders.FolderResponse, error) {
+				return env.folders.SendFolderCopy(env.ownerCtx, env.docsID, folders.SendCopyRequest{UserID: 999})
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "over the recipient's quota",
+			setup: func(t *testing.T, env *shareEnv) {
+				full, err := env.db.CreateUser(context.Background(), "full@example.com", "full", "hash", 4)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ownerID, _ := userctx.GetUserID(env.ownerCtx)
+				env.shareBack(t, userctx.SetUserID(context.Background(), full.ID), ownerID)
+			},
+			run: func(env *shareEnv) (folders.FolderResponse, error) {
+				full, err := env.db.GetUserByEmail(context.Background(), "full@example.com")
+				if err != nil {
+					return folders.FolderResponse{}, err
+				}
+				return env.folders.SendFolderCopy(env.ownerCtx, env.docsID, folders.SendCopyRequest{UserID: full.ID})
+			},
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newShareEnv(t)
+			if tt.permission != "" {
+				env.share(t, env.docsID, folders.Share{UserID: env.friendID, Permission: tt.permission})
+			}
+			if tt.setup != nil {
+				tt.setup(t, env)
+			}
+			foldersBefore := len(env.db.Folders())
+
+			copied, err := tt.run(env)
+			if got := statusOf(err); got != tt.wantStatus || (tt.wantStatus == 0 && err != nil) {
+				t.Fatalf("error = %v, want status %d", err, tt.wantStatus)
+			}
+			if tt.wantStatus != 0 {
+				if len(env.db.Folders()) != foldersBefore || len(env.db.Files()) != 1 {
+					t.Errorf("got %d folders and %d files after a failed copy, want %d and 1", len(env.db.Folders()), len(env.db.Files()), foldersBefore)
+				}
+				return
+			}
+
+			ownerID, _ := userctx.GetUserID(env.ownerCtx)
+			if tt.wantOwner != nil {
+				ownerID = tt.wantOwner(env)
+			}
+			copiedFolder, err := env.db.GetFolderByID(context.Background(), copied.ID)
+			if err != nil || copiedFolder.OwnerID != ownerID || copied.ID == env.docsID || copied.ID == env.workID {
+				t.Fatalf("copy %+v is not a new folder of user %d", copied, ownerID)
+			}
+			if got := len(env.db.Folders()); got != tt.wantFolders {
+				t.Errorf("got %d folders, want %d", got, tt.wantFolders)
+			}
+			if got := len(env.db.Files()); got != 2 {
+				t.Errorf("got %d files, want 2", got)
+			}
+			if blobs := env.db.Blobs(); len(blobs) != 1 || blobs[0].Refcount != 2 {
+				t.Errorf("blobs = %+v, want one blob referenced twice", blobs)
+			}
+			user, err := env.db.GetUserByID(context.Background(), ownerID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantUsed := int64(len("gamma"))
+			if tt.wantOwner == nil {
+				wantUsed *= 2 // the owner of the original pays for both
+			}
+			if user.StorageUsed != wantUsed {
+				t.Errorf("storage used by user %d = %d, want %d", ownerID, user.StorageUsed, wantUsed)
+			}
+		})
+	}
+}
+
+// shareBack makes the user of ctx share a new folder with userID, so that
+// userID may send them copies.
+func (env *shareEnv) shareBack(t *testing.T, ctx context.Context, userID int64) {
+	t.Helper()
+	inbox, err := env.folders.CreateFolder(ctx, folders.CreateFolderRequest{Name: "inbox"})
+	if err != nil {
+		t.Fatalf("CreateFolder(inbox): %v", err)
+	}
+	err = env.folders.UpdateFolderShares(ctx, folders.UpdateFolderSharesRequest{FolderID: inbox.ID, Shares: []folders.Share{{UserID: userID, Permission: "read"}}})
+	if err != nil {
+		t.Fatalf("UpdateFolderShares(inbox): %v", err)
+	}
+}
@@ -4,6 +4,7 @@ package folders
 
 import (
 	"encoding/json"
+	"io"
 	"log"
 	"net/http"
 
@@ -30,8 +31,12 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 	r.Patch("/folders/{folderId}", apphandler.MakeHTTPHandler(h.UpdateFolder))
 	r.Delete("/folders/{folderId}", apphandler.MakeHTTPHandler(h.DeleteFolder))
 	r.Patch("/folders/{id}/move", apphandler.MakeHTTPHandler(h.MoveFolder))
+	r.Post("/folders/{id}/copy", apphandler.MakeHTTPHandler(h.CopyFolder))
+	r.Post("/folders/{id}/send", apphandler.MakeHTTPHandler(h.SendFolderCopy))
 	r.Get("/folders/{id}", apphandler.MakeHTTPHandler(h.GetSelectableFolders))
 	r.Get("/folders/", apphandler.MakeHTTPHandler(h.GetSelectableFolders))
+	r.Get("/folders/{id}/share-info", apphandler.MakeHTTPHandler(h.GetShareInfo))
+	r.Put("/folders/{id}/shares", apphandler.MakeHTTPHandler(h.UpdateFolderShares))
 }
 
 // CreateFolder handles POST /folders.
@@ -75,7 +80,7 @@ func (h *Handler) UpdateFolder(w http.ResponseWriter, r *http.Request) error {
 }
 
 // DeleteFolder handles DELETE /folders/{folderId}.
-// It deletes the specified folder along with its contents,
+// It moves the specified folder to the trash along with its contents,
 // including files and subfolders, recursively.
 func (h *Handler) DeleteFolder(w http.ResponseWriter, r *http.Request) error {
 	folderIDStr := chi.URLParam(r, "folderId")
@@ -110,6 +115,46 @@ func (h *Handler) MoveFolder(w http.ResponseWriter, r *http.Request) error {
 	return h.service.UpdateFolderParent(r.Context(), folderID, req)
 }
 
+// CopyFolder handles POST /folders/{id}/copy.
+// It copies a folder with its contents into a folder of the authenticated user.
+func (h *Handler) CopyFolder(w http.ResponseWriter, r *http.Request) error {
+	folderID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid folder ID")
+	}
+
+	var req CopyFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		return apierror.NewBadRequestError("Invalid request body")
+	}
+
+	folder, err := h.service.CopyFolder(r.Context(), folderID, req)
+	if err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusCreated, folder)
+}
+
+// SendFolderCopy handles POST /folders/{id}/send.
+// It places a copy of a folder with its contents at the root of another user.
+func (h *Handler) SendFolderCopy(w http.ResponseWriter, r *http.Request) error {
+	folderID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid folder ID")
+	}
+
+	var req SendCopyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierror.NewBadRequestError("Invalid request body")
+	}
+
+	folder, err := h.service.SendFolderCopy(r.Context(), folderID, req)
+	if err != nil {
+		return err
+	}
+	return util.WriteJSON(w, http.StatusCreated, folder)
+}
+
 // GetSelectableFolders handles GET /folders/{id} and GET /folders/.
 // It returns folders that the authenticated user move their folder to.
 // This includes the set of all folders the user owns, with the exception
@@ -133,3 +178,49 @@ func (h *Handler) GetSelectableFolders(w http.ResponseWriter, r *http.Request) e
 
 	return util.WriteJSON(w, http.StatusOK, folders)
 }
+
+// GetShareInfo handles GET /folders/{id}/share-info.
+// It returns the users the folder is shared with and the users it can be shared with.
+func (h *Handler) GetShareInfo(w http.ResponseWriter, r *http.Request) error {
+	folderID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid folder ID")
+	}
+
+	info, err := h.service.GetShareInfo(r.Context(), folderID)
+	if err != nil {
+		return err
+	}
+
+	return util.WriteJSON(w, http.StatusOK, info)
+}
+
+// UpdateFolderShares handles PUT /folders/{id}/shares.
+// It replaces the users the folder is shared with. The body holds "shares" with
+// a user_id and permission each, or "user_ids" to keep existing permissions and
+// give new users read access. Shares apply to everything inside the folder.
+func (h *Handler) UpdateFolderShares(w http.ResponseWriter, r *http.Request) error {
+	folderID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid folder ID")
+	}
+
+	var payload updateSharesPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return apierror.NewBadRequestError("Invalid request body")
+	}
+
+	req := UpdateFolderSharesRequest{
+		FolderID: folderID,
+		Shares:   payload.Shares,
+	}
+	for _, userID := range payload.UserIDs {
+		req.Shares = append(req.Shares, Share{UserID: userID})
+	}
+
+	if err := h.service.UpdateFolderShares(r.Context(), req); err != nil {
+		return err
+	}
+
+	return util.WriteJSON(w, http.StatusOK, map[string]string{"message": "Folder sharing updated successfully"})
+}
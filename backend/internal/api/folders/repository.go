@@ -7,60 +7,132 @@ import (
 	"github.com/google/uuid"
 )
 
-// Repository handles database operations related to folders
-type Repository struct {
+// Repository describes the database operations used by the folders service.
+// It is implemented on top of sqlc queries by NewRepository.
+type Repository interface {
+	CreateFolder(ctx context.Context, arg sqlc.CreateFolderParams) (sqlc.Folder, error)
+	GetFolderByID(ctx context.Context, folderID uuid.UUID) (sqlc.Folder, error)
+	UpdateFolder(ctx context.Context, arg sqlc.UpdateFolderParams) (sqlc.UpdateFolderRow, error)
+	DeleteFolder(ctx context.Context, folderID uuid.UUID) error
+	GetBlobIDsInFolderHierarchy(ctx context.Context, folderID uuid.UUID) ([]uuid.UUID, error)
+	ListFolderArchiveEntries(ctx context.Context, folderID uuid.UUID) ([]sqlc.ListFolderArchiveEntriesRow, error)
+	CopyFolderTree(ctx context.Context, arg sqlc.CopyFolderTreeParams) (uuid.UUID, error)
+	UpdateFolderParentFolder(ctx context.Context, arg sqlc.UpdateFolderParentFolderParams) error
+	ListSelectableFolders(ctx context.Context, args sqlc.ListSelectableFoldersParams) ([]sqlc.ListSelectableFoldersRow, error)
+	GetInheritedFolderPermissions(ctx context.Context, folderID uuid.UUID, userID int64) ([]string, error)
+	ListFolderShares(ctx context.Context, folderID uuid.UUID) ([]sqlc.ListFolderSharesRow, error)
+	ReplaceFolderShares(ctx context.Context, arg sqlc.ReplaceFolderSharesParams) error
+	TrashFolder(ctx context.Context, folderID uuid.UUID) error
+	GetTrashedFolder(ctx context.Context, folderID uuid.UUID) (sqlc.Folder, error)
+	RestoreFolder(ctx context.Context, folderID uuid.UUID) error
+}
+
+// repository handles database operations related to folders, backed by sqlc queries.
+type repository struct {
 	queries *sqlc.Queries
 }
 
 // NewRepository creates a new Repository instance with the provided database queries.
 // This Repository can be used to perform folder related database operations.
-func NewRepository(db *sqlc.Queries) *Repository {
-	return &Repository{
+func NewRepository(db *sqlc.Queries) Repository {
+	return &repository{
 		queries: db,
 	}
 }
 
 // CreateFolder creates a new folder in the db with the given parameters.
 // Returns the created Folder and an error if the creation fails.
-func (r *Repository) CreateFolder(ctx context.Context, arg sqlc.CreateFolderParams) (sqlc.Folder, error) {
+func (r *repository) CreateFolder(ctx context.Context, arg sqlc.CreateFolderParams) (sqlc.Folder, error) {
 	return r.queries.CreateFolder(ctx, arg)
 }
 
 // GetFolderByID fetches a folder by its UUID.
 // Returns an error if the folder does not exist.
-func (r *Repository) GetFolderByID(ctx context.Context, folderID uuid.UUID) (sqlc.Folder, error) {
+func (r *repository) GetFolderByID(ctx context.Context, folderID uuid.UUID) (sqlc.Folder, error) {
 	return r.queries.GetFolderByID(ctx, folderID)
 }
 
 // UpdateFolder renames a folder with the given filename
 // Returns a FolderRow, and an error if renaming fails.
-func (r *Repository) UpdateFolder(ctx context.Context, arg sqlc.UpdateFolderParams) (sqlc.UpdateFolderRow, error) {
+func (r *repository) UpdateFolder(ctx context.Context, arg sqlc.UpdateFolderParams) (sqlc.UpdateFolderRow, error) {
 	return r.queries.UpdateFolder(ctx, arg)
 }
 
 // DeleteFolder deletes the folder with the given ID.
 // Returns an error if the deletion fails.
-func (r *Repository) DeleteFolder(ctx context.Context, folderID uuid.UUID) error {
+func (r *repository) DeleteFolder(ctx context.Context, folderID uuid.UUID) error {
 	return r.queries.DeleteFolder(ctx, folderID)
 }
 
 // GetBlobIDsInFolderHierarchy returns all blob IDs contained within
 // the specified folder and all of its subfolders.
 // Returns a slice of UUIDs and an error if the query fails.
-func (r *Repository) GetBlobIDsInFolderHierarchy(ctx context.Context, folderID uuid.UUID) ([]uuid.UUID, error) {
+func (r *repository) GetBlobIDsInFolderHierarchy(ctx context.Context, folderID uuid.UUID) ([]uuid.UUID, error) {
 	return r.queries.GetBlobIDsInFolderHierarchy(ctx, folderID)
 }
 
+// ListFolderArchiveEntries returns the folders within the specified folder and
+// all of its subfolders, with their paths, along with the files they contain.
+// Folders without files are listed once with NULL file columns.
+func (r *repository) ListFolderArchiveEntries(ctx context.Context, folderID uuid.UUID) ([]sqlc.ListFolderArchiveEntriesRow, error) {
+	return r.queries.ListFolderArchiveEntries(ctx, folderID)
+}
+
+// CopyFolderTree copies a folder with its subfolders and files, leaving out
+// trashed ones, according to the provided parameters. The copied files share
+// the blobs of the originals. Returns the ID of the new folder.
+func (r *repository) CopyFolderTree(ctx context.Context, arg sqlc.CopyFolderTreeParams) (uuid.UUID, error) {
+	return r.queries.CopyFolderTree(ctx, arg)
+}
+
 // UpdateFolderParentFolder updates the parent folder of a folder
 // according to the provided parameters.
 // Returns an error if the update fails.
-func (r *Repository) UpdateFolderParentFolder(ctx context.Context, arg sqlc.UpdateFolderParentFolderParams) error {
+func (r *repository) UpdateFolderParentFolder(ctx context.Context, arg sqlc.UpdateFolderParentFolderParams) error {
 	return r.queries.UpdateFolderParentFolder(ctx, arg)
 }
 
 // ListSelectableFolders returns a list of folders that can be selected
 // for moving, based on the given parameters.
 // Returns a slice of ListSelectableFoldersRow and an error if the query fails.
-func (r *Repository) ListSelectableFolders(ctx context.Context, args sqlc.ListSelectableFoldersParams) ([]sqlc.ListSelectableFoldersRow, error) {
+func (r *repository) ListSelectableFolders(ctx context.Context, args sqlc.ListSelectableFoldersParams) ([]sqlc.ListSelectableFoldersRow, error) {
 	return r.queries.ListSelectableFolders(ctx, args)
 }
+
+// GetInheritedFolderPermissions returns the permissions userID has been granted
+// on the folder and on each of its ancestors.
+func (r *repository) GetInheritedFolderPermissions(ctx context.Context, folderID uuid.UUID, userID int64) ([]string, error) {
+	return r.queries.GetInheritedFolderPermissions(ctx, sqlc.GetInheritedFolderPermissionsParams{
+		FolderID: folderID,
+		UserID:   userID,
+	})
+}
+
+// ListFolderShares returns the users a folder is directly shared with,
+// along with their permissions.
+func (r *repository) ListFolderShares(ctx context.Context, folderID uuid.UUID) ([]sqlc.ListFolderSharesRow, error) {
+	return r.queries.ListFolderShares(ctx, folderID)
+}
+
+// ReplaceFolderShares replaces the shares of a folder with the given users and
+// permissions in a single statement. Users missing from the list lose access.
+func (r *repository) ReplaceFolderShares(ctx context.Context, arg sqlc.ReplaceFolderSharesParams) error {
+	return r.queries.ReplaceFolderShares(ctx, arg)
+}
+
+// TrashFolder moves a folder and all of its contents to the trash.
+func (r *repository) TrashFolder(ctx context.Context, folderID uuid.UUID) error {
+	return r.queries.TrashFolder(ctx, folderID)
+}
+
+// GetTrashedFolder retrieves a folder the user moved to the trash.
+// Returns pgx.ErrNoRows if the folder is not in the trash, or was trashed along with its parent.
+func (r *repository) GetTrashedFolder(ctx context.Context, folderID uuid.UUID) (sqlc.Folder, error) {
+	return r.queries.GetTrashedFolder(ctx, folderID)
+}
+
+// RestoreFolder takes a folder and the contents trashed with it out of the trash.
+// The folder is restored to the root if its parent is in the trash.
+func (r *repository) RestoreFolder(ctx context.Context, folderID uuid.UUID) error {
+	return r.queries.RestoreFolder(ctx, folderID)
+}
@@ -4,28 +4,31 @@ import (
 	"context"
 	"log"
 
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/access"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/users"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
-	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/storage"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/util"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // Service handles folder-related business logic, including creation, updating, deletion,
 // moving folders, and listing selectable folders.
 type Service struct {
-	repo    *Repository
-	storage storage.Storage
+	repo     Repository
+	userRepo users.Repository
+	audit    audit.Service
 }
 
 // NewService creates a new instance of the folder Service.
 // - repo: repository providing database operations for folders and files.
-// - storage: storage interface used for managing file blobs associated with folders.
-func NewService(repo *Repository, storage storage.Storage) *Service {
-	return &Service{repo: repo, storage: storage}
+// - userRepo: repository used to list the users a folder can be shared with.
+// - auditService: service used to record folder shares and deletions.
+func NewService(repo Repository, userRepo users.Repository, auditService audit.Service) *Service {
+	return &Service{repo: repo, userRepo: userRepo, audit: auditService}
 }
 
 // CreateFolder creates a new folder for the authenticated user.
@@ -65,22 +68,14 @@ func (s *Service) CreateFolder(ctx context.Context, req CreateFolderRequest) (sq
 }
 
 // UpdateFolder renames a folder for the authenticated user.
-// - Validates user ownership of the folder.
-// - Returns an error if the folder is not found, the user does not own it, or the name is empty.
+// - Requires write access to the folder, through ownership or a share.
+// - Returns an error if the folder is not found, the user can't write to it, or the name is empty.
 // - Note: This only handles renaming; moving folders is not handled here.
 // Returns the updated folder as FolderResponse.
 func (s *Service) UpdateFolder(ctx context.Context, folderID uuid.UUID, req UpdateFolderRequest) (FolderResponse, error) {
-	userID, ok := userctx.GetUserID(ctx)
-	if !ok {
-		return FolderResponse{}, apierror.NewUnauthorizedError()
-	}
-
-	folderToUpdate, err := s.repo.GetFolderByID(ctx, folderID)
+	folderToUpdate, userID, err := s.authorizeFolder(ctx, folderID, access.Write)
 	if err != nil {
-		return FolderResponse{}, apierror.NewInternalServerError("Folder not found")
-	}
-	if folderToUpdate.OwnerID != userID {
-		return FolderResponse{}, apierror.NewForbiddenError()
+		return FolderResponse{}, err
 	}
 
 	if req.Name == "" {
@@ -102,7 +97,7 @@ func (s *Service) UpdateFolder(ctx context.Context, folderID uuid.UUID, req Upda
 		ID:           res.ID,
 		Filename:     res.Filename,
 		UploadedAt:   res.CreatedAt.Time,
-		UserOwnsFile: true,
+		UserOwnsFile: res.OwnerID == userID,
 		ItemType:     "Folder",
 	}, nil
 }
@@ -151,11 +146,11 @@ func (s *Service) GetSelectableFolders(ctx context.Context, folderID *uuid.UUID)
 	return folders, nil
 }
 
-// DeleteFolder deletes a folder and all its contents from the database and storage.
+// DeleteFolder moves a folder and all of its contents to the trash.
 // - Validates user ownership of the folder.
-// - Deletes subfolders and file records automatically via ON DELETE CASCADE.
-// - Checks all blobs in the folder hierarchy for cleanup and deletes unreferenced blobs from storage.
-// Returns an error if the user is unauthorized, the folder does not exist, or deletion fails.
+// - The folder, its subfolders and their files are hidden until they are restored or purged.
+// - Their blobs are kept in storage and keep counting towards the owner's quota until they are purged.
+// Returns an error if the user is unauthorized, the folder does not exist, or trashing fails.
 func (s *Service) DeleteFolder(ctx context.Context, folderID uuid.UUID) error {
 	ownerID, ok := userctx.GetUserID(ctx)
 	if !ok {
@@ -172,38 +167,18 @@ func (s *Service) DeleteFolder(ctx context.Context, folderID uuid.UUID) error {
 		return apierror.NewForbiddenError()
 	}
 
-	// get all object keys for files within this folder and its subfolders
-	blobIDs, err := s.repo.GetBlobIDsInFolderHierarchy(ctx, folderID)
-	if err != nil && err != pgx.ErrNoRows {
-		return apierror.NewInternalServerError("Could not retrieve files for deletion")
+	if err = s.repo.TrashFolder(ctx, folderID); err != nil {
+		log.Printf("error while trying to trash folder: %v", err)
+		return apierror.NewInternalServerError("Failed to move folder to the trash")
 	}
+	log.Printf("Moved folder %s and all its contents to the trash.", folderID)
 
-	// Delete the folder record from the database
-	// ON DELETE CASCADE deletes all subfolders and file records automatically
-	if err = s.repo.DeleteFolder(ctx, folderID); err != nil {
-		return apierror.NewInternalServerError("Failed to delete folder from database")
-	}
-	log.Printf("Deleted folder %s and all its contents from database records.", folderID)
-
-	// Checking blobs for cleanup
-	log.Printf("Checking %d blobs for cleanup...", len(blobIDs))
-	for _, blobID := range blobIDs {
-		storagePath, err := s.repo.queries.DeleteBlobIfUnused(ctx, blobID)
-		if err != nil {
-			if err == pgx.ErrNoRows {
-				// the blob is still referenced by another file, we do nothing.
-				continue
-			}
-			log.Printf("Error during blob cleanup for %s: %v", blobID, err)
-			continue
-		}
-
-		// The blob record has been deleted, and we can safely delete from storage.
-		log.Printf("Blob %s is now unreferenced, deleting object %s from storage.", blobID, storagePath)
-		if err := s.storage.DeleteBlob(ctx, storagePath); err != nil {
-			log.Printf("CRITICAL: Failed to delete object %s from storage: %v", storagePath, err)
-		}
-	}
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   ownerID,
+		Action:   "FOLDER_DELETED",
+		TargetID: folder.ID,
+		Details:  map[string]interface{}{"name": folder.Name},
+	})
 	return nil
 }
 
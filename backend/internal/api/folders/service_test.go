@@ -0,0 +1,164 @@
+package folders_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/folders"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/memdb"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/storage"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/google/uuid"
+)
+
+type nopAudit struct{}
+
+func (nopAudit) Log(ctx context.Context, params audit.LogParams) {}
+
+func statusOf(err error) int {
+	var apiErr *apierror.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// TestDeleteFolder builds the tree
+//
+//	docs/            a.txt ("alpha")
+//	docs/work/       b.txt ("beta")
+//	docs/work/old/   c.txt ("gamma")
+//	/                root.txt (rootContent)
+//
+// and deletes docs as the given user, then purges it from the trash.
+func TestDeleteFolder(t *testing.T) {
+	tests := []struct {
+		name        string
+		rootContent string
+		asOther     bool
+		missing     bool
+		wantStatus  int
+		wantFolders int
+		wantFiles   int
+		wantObjects []string // contents that must remain in storage
+	}{
+		{
+			name:        "cascades to subfolders and files",
+			rootContent: "unrelated",
+			wantFolders: 0,
+			wantFiles:   1,
+			wantObjects: []string{"unrelated"},
+		},
+		{
+			name:        "keeps blobs referenced outside the folder",
+			rootContent: "beta",
+			wantFolders: 0,
+			wantFiles:   1,
+			wantObjects: []string{"beta"},
+		},
+		{
+			name:        "not the owner",
+			rootContent: "unrelated",
+			asOther:     true,
+			wantStatus:  http.StatusForbidden,
+			wantFolders: 3,
+			wantFiles:   4,
+			wantObjects: []string{"alpha", "beta", "gamma", "unrelated"},
+		},
+		{
+			name:        "missing folder",
+			rootContent: "unrelated",
+			missing:     true,
+			wantStatus:  http.StatusNotFound,
+			wantFolders: 3,
+			wantFiles:   4,
+			wantObjects: []string{"alpha", "beta", "gamma", "unrelated"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := memdb.New()
+			store := storage.NewMemoryStorage()
+			folderService := folders.NewService(db, db, nopAudit{})
+			fileService := files.NewService(db, db, db, store, nopAudit{}, "http://vault.test")
+
+			owner, err := db.CreateUser(context.Background(), "owner@example.com", "owner", "hash", 1<<20)
+			if err != nil {
+				t.Fatal(err)
+			}
+			other, err := db.CreateUser(context.Background(), "other@example.com", "other", "hash", 1<<20)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := userctx.SetUserID(context.Background(), owner.ID)
+
+			var parent *uuid.UUID
+			var docsID uuid.UUID
+			for _, f := range []struct{ folder, file, content string }{
+				{"docs", "a.txt", "alpha"},
+				{"work", "b.txt", "beta"},
+				{"old", "c.txt", "gamma"},
+			} {
+				folder, err := folderService.CreateFolder(ctx, folders.CreateFolderRequest{Name: f.folder, ParentFolderID: parent})
+				if err != nil {
+					t.Fatalf("CreateFolder(%s): %v", f.folder, err)
+				}
+				if parent == nil {
+					docsID = folder.ID
+				}
+				parent = &folder.ID
+				if _, err := fileService.UploadFile(ctx, strings.NewReader(f.content), f.file, "text/plain", &folder.ID); err != nil {
+					t.Fatalf("UploadFile(%s): %v", f.file, err)
+				}
+			}
+			if _, err := fileService.UploadFile(ctx, strings.NewReader(tt.rootContent), "root.txt", "text/plain", nil); err != nil {
+				t.Fatalf("UploadFile(root.txt): %v", err)
+			}
+
+			deleteCtx, target := ctx, docsID
+			if tt.asOther {
+				deleteCtx = userctx.SetUserID(context.Background(), other.ID)
+			}
+			if tt.missing {
+				target = uuid.New()
+			}
+			err = folderService.DeleteFolder(deleteCtx, target)
+			if got := statusOf(err); got != tt.wantStatus || (tt.wantStatus == 0 && err != nil) {
+				t.Fatalf("DeleteFolder error = %v, want status %d", err, tt.wantStatus)
+			}
+			if err == nil {
+				if err := fileService.PurgeFolder(deleteCtx, target); err != nil {
+					t.Fatalf("PurgeFolder: %v", err)
+				}
+			}
+
+			if got := len(db.Folders()); got != tt.wantFolders {
+				t.Errorf("got %d folders, want %d", got, tt.wantFolders)
+			}
+			if got := len(db.Files()); got != tt.wantFiles {
+				t.Errorf("got %d files, want %d", got, tt.wantFiles)
+			}
+
+			var contents []string
+			for _, key := range store.Keys() {
+				data, _ := store.Object(key)
+				contents = append(contents, string(data))
+			}
+			sort.Strings(contents)
+			if strings.Join(contents, ",") != strings.Join(tt.wantObjects, ",") {
+				t.Errorf("objects in storage = %v, want %v", contents, tt.wantObjects)
+			}
+			if got := len(db.Blobs()); got != len(tt.wantObjects) {
+				t.Errorf("got %d blob records, want %d", got, len(tt.wantObjects))
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package folders
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/access"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/google/uuid"
+)
+
+// authorizeFolder returns the folder and the authenticated user's ID if the user
+// holds at least the required permission on it. Shares on a folder apply to
+// all of its subfolders, so the permission is the highest one granted on the
+// folder or any of its ancestors.
+func (s *Service) authorizeFolder(ctx context.Context, folderID uuid.UUID, required access.Permission) (sqlc.Folder, int64, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return sqlc.Folder{}, 0, apierror.NewUnauthorizedError()
+	}
+
+	folder, err := s.repo.GetFolderByID(ctx, folderID)
+	if err != nil {
+		return sqlc.Folder{}, 0, apierror.NewNotFoundError("Folder")
+	}
+
+	permission := access.Owner
+	if folder.OwnerID != userID {
+		inherited, err := s.repo.GetInheritedFolderPermissions(ctx, folderID, userID)
+		if err != nil {
+			log.Printf("Failed to look up permission of user %d on folder %s: %v", userID, folderID, err)
+			return sqlc.Folder{}, 0, apierror.NewInternalServerError("Failed to check permissions")
+		}
+		permission = access.Highest(inherited)
+	}
+	if permission < required {
+		return sqlc.Folder{}, 0, apierror.NewForbiddenError()
+	}
+	return folder, userID, nil
+}
+
+// GetShareInfo returns the users a folder is shared with and the users it
+// can be shared with. Requires reshare access to the folder.
+func (s *Service) GetShareInfo(ctx context.Context, folderID uuid.UUID) (ShareInfoResponse, error) {
+	folder, userID, err := s.authorizeFolder(ctx, folderID, access.Reshare)
+	if err != nil {
+		return ShareInfoResponse{}, err
+	}
+
+	sharedWithRows, err := s.repo.ListFolderShares(ctx, folderID)
+	if err != nil {
+		return ShareInfoResponse{}, apierror.NewInternalServerError()
+	}
+
+	allUsersRows, err := s.userRepo.ListOtherUsers(ctx, userID)
+	if err != nil {
+		return ShareInfoResponse{}, apierror.NewInternalServerError()
+	}
+
+	sharedWith := make([]User, 0, len(sharedWithRows))
+	for _, r := range sharedWithRows {
+		sharedWith = append(sharedWith, User{
+			ID:         r.ID,
+			Name:       r.Name,
+			Email:      r.Email,
+			Permission: r.Permission,
+		})
+	}
+
+	allUsers := make([]User, 0, len(allUsersRows))
+	for _, r := range allUsersRows {
+		// The owner is listed to resharers, but can't be shared with
+		if r.ID == folder.OwnerID {
+			continue
+		}
+		allUsers = append(allUsers, User{
+			ID:    r.ID,
+			Name:  r.Name,
+			Email: r.Email,
+		})
+	}
+
+	return ShareInfoResponse{
+		SharedWith: sharedWith,
+		AllUsers:   allUsers,
+	}, nil
+}
+
+// UpdateFolderShares updates the users a folder is shared with and their
+// permissions, which then apply to every file and subfolder inside it.
+// The owner replaces the whole list: users missing from it lose their share in
+// the same statement that adds the new ones. Users with reshare access can only
+// add users and raise permissions; shares they leave out are kept, and lowering
+// a share is forbidden.
+func (s *Service) UpdateFolderShares(ctx context.Context, req UpdateFolderSharesRequest) error {
+	folder, userID, err := s.authorizeFolder(ctx, req.FolderID, access.Reshare)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.repo.ListFolderShares(ctx, req.FolderID)
+	if err != nil {
+		return apierror.NewInternalServerError("could not update shares")
+	}
+	existing := make(map[int64]string, len(current))
+	for _, r := range current {
+		existing[r.ID] = r.Permission
+	}
+
+	permissions := make(map[int64]string, len(req.Shares))
+	for _, share := range req.Shares {
+		if share.UserID == folder.OwnerID {
+			return apierror.NewBadRequestError("A folder can't be shared with its owner")
+		}
+		if _, duplicate := permissions[share.UserID]; duplicate {
+			return apierror.NewBadRequestError(fmt.Sprintf("User %d is listed more than once", share.UserID))
+		}
+
+		permission := share.Permission
+		if permission == "" {
+			permission = existing[share.UserID]
+		}
+		if permission == "" {
+			permission = access.Read.String()
+		}
+		if _, ok := access.ParseShare(permission); !ok {
+			return apierror.NewBadRequestError(fmt.Sprintf("Invalid permission %q", share.Permission))
+		}
+		permissions[share.UserID] = permission
+	}
+	// only the owner may remove or lower shares, access inherited
+	// from a parent folder is managed on that folder instead
+	if userID != folder.OwnerID {
+		for targetUserID, current := range existing {
+			requested, listed := permissions[targetUserID]
+			if !listed {
+				permissions[targetUserID] = current
+				continue
+			}
+			requestedLevel, _ := access.ParseShare(requested)
+			if currentLevel, _ := access.ParseShare(current); requestedLevel < currentLevel {
+				return apierror.New(http.StatusForbidden, "Only the owner can lower the permission of a share")
+			}
+		}
+	}
+
+	params := sqlc.ReplaceFolderSharesParams{
+		FolderID:    req.FolderID,
+		UserIds:     make([]int64, 0, len(permissions)),
+		Permissions: make([]string, 0, len(permissions)),
+	}
+	for targetUserID, permission := range permissions {
+		params.UserIds = append(params.UserIds, targetUserID)
+		params.Permissions = append(params.Permissions, permission)
+	}
+	if err := s.repo.ReplaceFolderShares(ctx, params); err != nil {
+		log.Printf("Failed to update shares of folder %s: %v", req.FolderID, err)
+		return apierror.NewInternalServerError("could not update shares")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "FOLDER_SHARED",
+		TargetID: folder.ID,
+		Details:  map[string]interface{}{"name": folder.Name, "shares": permissions},
+	})
+	return nil
+}
@@ -0,0 +1,225 @@
+package folders_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/folders"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/memdb"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/storage"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/google/uuid"
+)
+
+// shareEnv holds the tree docs/work/old, with old/c.txt, owned by owner.
+type shareEnv struct {
+	db      *memdb.DB
+	folders *folders.Service
+	files   *files.Service
+
+	ownerCtx               context.Context
+	friendID, strangerID   int64
+	friendCtx, strangerCtx context.Context
+	docsID, workID, oldID  uuid.UUID
+	fileID                 uuid.UUID
+}
+
+func newShareEnv(t *testing.T) *shareEnv {
+	t.Helper()
+	db := memdb.New()
+	store := storage.NewMemoryStorage()
+	env := &shareEnv{
+		db:      db,
+		folders: folders.NewService(db, db, nopAudit{}),
+		files:   files.NewService(db, db, db, store, nopAudit{}, "http://vault.test"),
+	}
+
+	var ids []int64
+	for _, email := range []string{"owner@example.com", "friend@example.com", "stranger@example.com"} {
+		user, err := db.CreateUser(context.Background(), email, email, "hash", 1<<20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, user.ID)
+	}
+	env.ownerCtx = userctx.SetUserID(context.Background(), ids[0])
+	env.friendID, env.friendCtx = ids[1], userctx.SetUserID(context.Background(), ids[1])
+	env.strangerID, env.strangerCtx = ids[2], userctx.SetUserID(context.Background(), ids[2])
+
+	var parent *uuid.UUID
+	for _, name := range []string{"docs", "work", "old"} {
+		folder, err := env.folders.CreateFolder(env.ownerCtx, folders.CreateFolderRequest{Name: name, ParentFolderID: parent})
+		if err != nil {
+			t.Fatalf("CreateFolder(%s): %v", name, err)
+		}
+		parent = &folder.ID
+	}
+	for _, f := range db.Folders() {
+		switch f.Name {
+		case "docs":
+			env.docsID = f.ID
+		case "work":
+			env.workID = f.ID
+		case "old":
+			env.oldID = f.ID
+		}
+	}
+
+	file, err := env.files.UploadFile(env.ownerCtx, strings.NewReader("gamma"), "c.txt", "text/plain", &env.oldID)
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	env.fileID = file.ID
+	return env
+}
+
+func (env *shareEnv) share(t *testing.T, folderID uuid.UUID, shares ...folders.Share) {
+	t.Helper()
+	err := env.folders.UpdateFolderShares(env.ownerCtx, folders.UpdateFolderSharesRequest{FolderID: folderID, Shares: shares})
+	if err != nil {
+		t.Fatalf("UpdateFolderShares: %v", err)
+	}
+}
+
+func TestInheritedFolderAccess(t *testing.T) {
+	tests := []struct {
+		name         string
+		shareOn      string // folder shared with the friend, empty for none
+		permission   string
+		wantDownload int
+		wantRename   int // renaming the work folder
+		wantDelete   int // deleting the file
+	}{
+		{name: "not shared", wantDownload: http.StatusForbidden, wantRename: http.StatusForbidden, wantDelete: http.StatusForbidden},
+		{name: "read on top folder", shareOn: "docs", permission: "read", wantRename: http.StatusForbidden, wantDelete: http.StatusForbidden},
+		{name: "write on top folder", shareOn: "docs", permission: "write", wantDelete: http.StatusForbidden},
+		{name: "write below renamed folder", shareOn: "old", permission: "write", wantRename: http.StatusForbidden, wantDelete: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newShareEnv(t)
+			if tt.shareOn != "" {
+				target := map[string]uuid.UUID{"docs": env.docsID, "old": env.oldID}[tt.shareOn]
+				env.share(t, target, folders.Share{UserID: env.friendID, Permission: tt.permission})
+			}
+
+			d, err := env.files.DownloadFile(env.friendCtx, env.fileID)
+			if err == nil {
+				d.Content.Close()
+			}
+			if got := statusOf(err); got != tt.wantDownload {
+				t.Errorf("DownloadFile status = %d (%v), want %d", got, err, tt.wantDownload)
+			}
+
+			_, err = env.folders.UpdateFolder(env.friendCtx, env.workID, folders.UpdateFolderRequest{Name: "renamed"})
+			if got := statusOf(err); got != tt.wantRename {
+				t.Errorf("UpdateFolder status = %d (%v), want %d", got, err, tt.wantRename)
+			}
+
+			err = env.files.DeleteFile(env.friendCtx, env.fileID)
+			if got := statusOf(err); got != tt.wantDelete {
+				t.Errorf("DeleteFile status = %d (%v), want %d", got, err, tt.wantDelete)
+			}
+
+			// access never leaks to users the folder isn't shared with
+			_, err = env.files.DownloadFile(env.strangerCtx, env.fileID)
+			if got := statusOf(err); got != http.StatusForbidden {
+				t.Errorf("stranger DownloadFile status = %d, want 403", got)
+			}
+		})
+	}
+}
+
+func TestUpdateFolderShares(t *testing.T) {
+	t.Run("revoking removes inherited access", func(t *testing.T) {
+		env := newShareEnv(t)
+		env.share(t, env.docsID, folders.Share{UserID: env.friendID})
+		env.share(t, env.docsID)
+
+		_, err := env.files.DownloadFile(env.friendCtx, env.fileID)
+		if got := statusOf(err); got != http.StatusForbidden {
+			t.Errorf("DownloadFile status = %d, want 403", got)
+		}
+	})
+
+	t.Run("resharer only adds and raises shares", func(t *testing.T) {
+		env := newShareEnv(t)
+		env.share(t, env.workID, folders.Share{UserID: env.friendID, Permission: "reshare"})
+
+		err := env.folders.UpdateFolderShares(env.friendCtx, folders.UpdateFolderSharesRequest{
+			FolderID: env.workID,
+			Shares:   []folders.Share{{UserID: env.strangerID}},
+		})
+		if err != nil {
+			t.Fatalf("UpdateFolderShares: %v", err)
+		}
+
+		info, err := env.folders.GetShareInfo(env.ownerCtx, env.workID)
+		if err != nil {
+			t.Fatalf("GetShareInfo: %v", err)
+		}
+		got := map[int64]string{}
+		for _, u := range info.SharedWith {
+			got[u.ID] = u.Permission
+		}
+		if got[env.friendID] != "reshare" || got[env.strangerID] != "read" || len(got) != 2 {
+			t.Errorf("shares = %v, want friend reshare and stranger read", got)
+		}
+
+		// leaving the stranger out keeps their share, lowering it is forbidden
+		err = env.folders.UpdateFolderShares(env.friendCtx, folders.UpdateFolderSharesRequest{FolderID: env.workID})
+		if err != nil {
+			t.Fatalf("UpdateFolderShares without entries: %v", err)
+		}
+		err = env.folders.UpdateFolderShares(env.friendCtx, folders.UpdateFolderSharesRequest{
+			FolderID: env.workID,
+			Shares:   []folders.Share{{UserID: env.friendID, Permission: "read"}},
+		})
+		if got := statusOf(err); got != http.StatusForbidden {
+			t.Errorf("lowering a share status = %d, want 403", got)
+		}
+		err = env.folders.UpdateFolderShares(env.friendCtx, folders.UpdateFolderSharesRequest{
+			FolderID: env.workID,
+			Shares:   []folders.Share{{UserID: env.strangerID, Permission: "write"}},
+		})
+		if err != nil {
+			t.Fatalf("raising a share: %v", err)
+		}
+		info, err = env.folders.GetShareInfo(env.ownerCtx, env.workID)
+		if err != nil {
+			t.Fatalf("GetShareInfo: %v", err)
+		}
+		got = map[int64]string{}
+		for _, u := range info.SharedWith {
+			got[u.ID] = u.Permission
+		}
+		if got[env.friendID] != "reshare" || got[env.strangerID] != "write" || len(got) != 2 {
+			t.Errorf("shares = %v, want friend reshare and stranger write", got)
+		}
+
+		// the reshare on work doesn't reach its parent
+		err = env.folders.UpdateFolderShares(env.friendCtx, folders.UpdateFolderSharesRequest{FolderID: env.docsID})
+		if got := statusOf(err); got != http.StatusForbidden {
+			t.Errorf("UpdateFolderShares on parent status = %d, want 403", got)
+		}
+	})
+
+	t.Run("rejects invalid shares", func(t *testing.T) {
+		env := newShareEnv(t)
+		ownerID, _ := userctx.GetUserID(env.ownerCtx)
+		for _, shares := range [][]folders.Share{
+			{{UserID: ownerID}},
+			{{UserID: env.friendID}, {UserID: env.friendID}},
+			{{UserID: env.friendID, Permission: "owner"}},
+		} {
+			err := env.folders.UpdateFolderShares(env.ownerCtx, folders.UpdateFolderSharesRequest{FolderID: env.docsID, Shares: shares})
+			if got := statusOf(err); got != http.StatusBadRequest {
+				t.Errorf("UpdateFolderShares(%v) status = %d, want 400", shares, got)
+			}
+		}
+	})
+}
@@ -42,3 +42,56 @@ type FolderResponse struct {
 type UpdateFolderParentRequest struct {
 	TargetFolderID *uuid.UUID `json:"target_folder_id"`
 }
+
+// CopyFolderRequest represents a request to copy a folder into a folder of the
+// current user. TargetFolderID is optional; if nil, the copy is placed at the
+// root level. An empty Name keeps the name of the folder.
+type CopyFolderRequest struct {
+	TargetFolderID *uuid.UUID `json:"target_folder_id"`
+	Name           string     `json:"name"`
+}
+
+// SendCopyRequest represents a request to send a copy of a folder to another
+// user, which is placed at their root level.
+type SendCopyRequest struct {
+	UserID int64 `json:"user_id"`
+}
+
+// ShareInfoResponse represents sharing details for a folder: the users it is
+// shared with and all users it can be shared with. Used to populate the Share Modal.
+type ShareInfoResponse struct {
+	SharedWith []User `json:"sharedWith"`
+	AllUsers   []User `json:"allUsers"`
+}
+
+// User is a user a folder is or can be shared with, along with
+// their permission on the folder for users it is shared with.
+type User struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Email      string `json:"email"`
+	Permission string `json:"permission"`
+}
+
+// UpdateFolderSharesRequest represents a request to update
+// the users a folder is shared with, replacing any existing shares.
+type UpdateFolderSharesRequest struct {
+	FolderID uuid.UUID
+	Shares   []Share
+}
+
+// Share grants a user a permission on a folder and everything inside it:
+// read, comment, write or reshare. An empty Permission keeps the permission
+// the user already has, users the folder is not shared with yet get read access.
+type Share struct {
+	UserID     int64  `json:"user_id"`
+	Permission string `json:"permission"`
+}
+
+// updateSharesPayload represents the JSON payload used to update
+// the users a folder is shared with. UserIDs is the short form of
+// Shares without a permission.
+type updateSharesPayload struct {
+	UserIDs []int64 `json:"user_ids"`
+	Shares  []Share `json:"shares"`
+}
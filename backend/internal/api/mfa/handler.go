@@ -0,0 +1,170 @@
+package mfa
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apphandler"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/sessions"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/util"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handler is the HTTP handler for two-factor authentication endpoints.
+type Handler struct {
+	service  *Service
+	sessions *sessions.Service
+}
+
+// NewHandler creates a new mfa Handler with the given service, and the
+// sessions service starting a session once a challenge is answered.
+func NewHandler(service *Service, sessionService *sessions.Service) *Handler {
+	return &Handler{service: service, sessions: sessionService}
+}
+
+// RegisterPublicRoutes registers the second step of the login, reached
+// before the user has a session.
+func (h *Handler) RegisterPublicRoutes(r chi.Router) {
+	r.Post("/auth/mfa/verify", apphandler.MakeHTTPHandler(h.Verify))
+}
+
+// RegisterRoutes registers the enrollment routes on the given router.
+// They should only be reachable with a session, see middleware.SessionOnly.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get("/auth/mfa", apphandler.MakeHTTPHandler(h.Status))
+	r.Post("/auth/mfa/enroll", apphandler.MakeHTTPHandler(h.Enroll))
+	r.Post("/auth/mfa/enable", apphandler.MakeHTTPHandler(h.Enable))
+	r.Post("/auth/mfa/disable", apphandler.MakeHTTPHandler(h.Disable))
+	r.Post("/auth/mfa/recovery-codes", apphandler.MakeHTTPHandler(h.RegenerateRecoveryCodes))
+}
+
+// RegisterAdminRoutes registers the policy routes on the admin router.
+func (h *Handler) RegisterAdminRoutes(r chi.Router) {
+	r.Get("/mfa-policies", apphandler.MakeHTTPHandler(h.ListPolicies))
+	r.Put("/mfa-policies/{role}", apphandler.MakeHTTPHandler(h.SetPolicy))
+}
+
+// decodeCode reads the code of a request body.
+func decodeCode(r *http.Request) (string, error) {
+	var req codeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return "", apierror.NewBadRequestError("Invalid request body")
+	}
+	return req.Code, nil
+}
+
+// Verify handles POST /auth/mfa/verify.
+// It answers the challenge of a password login with a code, and starts the
+// session of the user.
+func (h *Handler) Verify(w http.ResponseWriter, r *http.Request) error {
+	var req verifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierror.NewBadRequestError("Invalid request body")
+	}
+
+	userID, err := h.service.VerifyChallenge(r.Context(), req.Challenge, req.Code)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := h.sessions.CreateSession(r.Context(), userID, r.UserAgent(), util.ClientIP(r), true)
+	if err != nil {
+		return err
+	}
+
+	sessions.SetCookies(w, r, tokens)
+	return util.WriteJSON(w, http.StatusOK, "Login successful")
+}
+
+// Status handles GET /auth/mfa.
+func (h *Handler) Status(w http.ResponseWriter, r *http.Request) error {
+	status, err := h.service.Status(r.Context())
+	if err != nil {
+		return err
+	}
+
+	return util.WriteJSON(w, http.StatusOK, status)
+}
+
+// Enroll handles POST /auth/mfa/enroll.
+// It returns a new secret for the authenticator app of the user.
+func (h *Handler) Enroll(w http.ResponseWriter, r *http.Request) error {
+	enrollment, err := h.service.Enroll(r.Context())
+	if err != nil {
+		return err
+	}
+
+	return util.WriteJSON(w, http.StatusCreated, enrollment)
+}
+
+// Enable handles POST /auth/mfa/enable.
+// It confirms the enrollment with a first code and returns the recovery codes.
+func (h *Handler) Enable(w http.ResponseWriter, r *http.Request) error {
+	code, err := decodeCode(r)
+	if err != nil {
+		return err
+	}
+
+	codes, err := h.service.Enable(r.Context(), code)
+	if err != nil {
+		return err
+	}
+
+	return util.WriteJSON(w, http.StatusOK, codes)
+}
+
+// Disable handles POST /auth/mfa/disable.
+func (h *Handler) Disable(w http.ResponseWriter, r *http.Request) error {
+	code, err := decodeCode(r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.Disable(r.Context(), code); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// RegenerateRecoveryCodes handles POST /auth/mfa/recovery-codes.
+func (h *Handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) error {
+	code, err := decodeCode(r)
+	if err != nil {
+		return err
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(r.Context(), code)
+	if err != nil {
+		return err
+	}
+
+	return util.WriteJSON(w, http.StatusOK, codes)
+}
+
+// ListPolicies handles GET /admin/mfa-policies.
+func (h *Handler) ListPolicies(w http.ResponseWriter, r *http.Request) error {
+	policies, err := h.service.ListPolicies(r.Context())
+	if err != nil {
+		return err
+	}
+
+	return util.WriteJSON(w, http.StatusOK, policies)
+}
+
+// SetPolicy handles PUT /admin/mfa-policies/{role}.
+func (h *Handler) SetPolicy(w http.ResponseWriter, r *http.Request) error {
+	var req policyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierror.NewBadRequestError("Invalid request body")
+	}
+
+	policy, err := h.service.SetPolicy(r.Context(), chi.URLParam(r, "role"), req.Required)
+	if err != nil {
+		return err
+	}
+
+	return util.WriteJSON(w, http.StatusOK, policy)
+}
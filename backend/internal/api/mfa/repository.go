@@ -0,0 +1,152 @@
+package mfa
+
+import (
+	"context"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+)
+
+// Repository describes the database operations used by the mfa service.
+// It is implemented on top of sqlc queries by NewRepository.
+type Repository interface {
+	GetUserByID(ctx context.Context, userID int64) (sqlc.User, error)
+	GetUserMFA(ctx context.Context, userID int64) (sqlc.UserMfa, error)
+	StartMFAEnrollment(ctx context.Context, arg sqlc.StartMFAEnrollmentParams) (sqlc.UserMfa, error)
+	EnableUserMFA(ctx context.Context, userID int64) (int64, error)
+	DeleteUserMFA(ctx context.Context, userID int64) error
+	UseMFAStep(ctx context.Context, arg sqlc.UseMFAStepParams) (int64, error)
+	CountMFAAttempt(ctx context.Context, arg sqlc.CountMFAAttemptParams) (sqlc.UserMfa, error)
+	ResetMFAAttempts(ctx context.Context, userID int64) error
+	UserHasMFA(ctx context.Context, userID int64) (bool, error)
+	CreateMFARecoveryCode(ctx context.Context, arg sqlc.CreateMFARecoveryCodeParams) error
+	DeleteMFARecoveryCodes(ctx context.Context, userID int64) error
+	UseMFARecoveryCode(ctx context.Context, arg sqlc.UseMFARecoveryCodeParams) (int64, error)
+	CountMFARecoveryCodes(ctx context.Context, userID int64) (int64, error)
+	CreateMFAChallenge(ctx context.Context, arg sqlc.CreateMFAChallengeParams) error
+	AttemptMFAChallenge(ctx context.Context, arg sqlc.AttemptMFAChallengeParams) (sqlc.MfaChallenge, error)
+	DeleteMFAChallenge(ctx context.Context, tokenHash string) error
+	DeleteExpiredMFAChallenges(ctx context.Context) (int64, error)
+	ListMFAPolicies(ctx context.Context) ([]sqlc.MfaPolicy, error)
+	SetMFAPolicy(ctx context.Context, arg sqlc.SetMFAPolicyParams) (sqlc.MfaPolicy, error)
+	RoleRequiresMFA(ctx context.Context, role string) (bool, error)
+}
+
+// repository handles database operations related to two-factor authentication, backed by sqlc queries.
+type repository struct {
+	queries *sqlc.Queries
+}
+
+// NewRepository creates a new Repository instance with the provided database queries.
+func NewRepository(db *sqlc.Queries) Repository {
+	return &repository{
+		queries: db,
+	}
+}
+
+// GetUserByID retrieves a user by their unique ID.
+func (r *repository) GetUserByID(ctx context.Context, userID int64) (sqlc.User, error) {
+	return r.queries.GetUserByID(ctx, userID)
+}
+
+// GetUserMFA returns the two-factor authentication settings of a user,
+// whether or not they confirmed their enrollment.
+func (r *repository) GetUserMFA(ctx context.Context, userID int64) (sqlc.UserMfa, error) {
+	return r.queries.GetUserMFA(ctx, userID)
+}
+
+// StartMFAEnrollment stores a new secret for a user who has not enabled
+// two-factor authentication. Returns pgx.ErrNoRows if they have.
+func (r *repository) StartMFAEnrollment(ctx context.Context, arg sqlc.StartMFAEnrollmentParams) (sqlc.UserMfa, error) {
+	return r.queries.StartMFAEnrollment(ctx, arg)
+}
+
+// EnableUserMFA confirms the enrollment of a user.
+// Returns the number of updated rows, zero if there is no enrollment to confirm.
+func (r *repository) EnableUserMFA(ctx context.Context, userID int64) (int64, error) {
+	return r.queries.EnableUserMFA(ctx, userID)
+}
+
+// DeleteUserMFA removes the secret of a user, disabling two-factor authentication.
+func (r *repository) DeleteUserMFA(ctx context.Context, userID int64) error {
+	return r.queries.DeleteUserMFA(ctx, userID)
+}
+
+// UseMFAStep records the time step of an accepted code.
+// Returns the number of updated rows, zero if the step was already used.
+func (r *repository) UseMFAStep(ctx context.Context, arg sqlc.UseMFAStepParams) (int64, error) {
+	return r.queries.UseMFAStep(ctx, arg)
+}
+
+// CountMFAAttempt counts a code entered at login, locking the user out until
+// LockedUntil once MaxAttempts were counted since the last accepted code.
+// Returns pgx.ErrNoRows while the user is locked out.
+func (r *repository) CountMFAAttempt(ctx context.Context, arg sqlc.CountMFAAttemptParams) (sqlc.UserMfa, error) {
+	return r.queries.CountMFAAttempt(ctx, arg)
+}
+
+// ResetMFAAttempts forgets the codes counted since the last accepted one.
+func (r *repository) ResetMFAAttempts(ctx context.Context, userID int64) error {
+	return r.queries.ResetMFAAttempts(ctx, userID)
+}
+
+// UserHasMFA reports whether a user enabled two-factor authentication.
+func (r *repository) UserHasMFA(ctx context.Context, userID int64) (bool, error) {
+	return r.queries.UserHasMFA(ctx, userID)
+}
+
+// CreateMFARecoveryCode stores the hash of a recovery code of a user.
+func (r *repository) CreateMFARecoveryCode(ctx context.Context, arg sqlc.CreateMFARecoveryCodeParams) error {
+	return r.queries.CreateMFARecoveryCode(ctx, arg)
+}
+
+// DeleteMFARecoveryCodes deletes every recovery code of a user, used or not.
+func (r *repository) DeleteMFARecoveryCodes(ctx context.Context, userID int64) error {
+	return r.queries.DeleteMFARecoveryCodes(ctx, userID)
+}
+
+// UseMFARecoveryCode marks a recovery code as used.
+// Returns the number of updated codes, zero if the code is unknown or was already used.
+func (r *repository) UseMFARecoveryCode(ctx context.Context, arg sqlc.UseMFARecoveryCodeParams) (int64, error) {
+	return r.queries.UseMFARecoveryCode(ctx, arg)
+}
+
+// CountMFARecoveryCodes returns the number of unused recovery codes of a user.
+func (r *repository) CountMFARecoveryCodes(ctx context.Context, userID int64) (int64, error) {
+	return r.queries.CountMFARecoveryCodes(ctx, userID)
+}
+
+// CreateMFAChallenge stores the hash of a login challenge.
+func (r *repository) CreateMFAChallenge(ctx context.Context, arg sqlc.CreateMFAChallengeParams) error {
+	return r.queries.CreateMFAChallenge(ctx, arg)
+}
+
+// AttemptMFAChallenge counts an attempt at a challenge and returns it.
+// Returns pgx.ErrNoRows if it expired, ran out of attempts or does not exist.
+func (r *repository) AttemptMFAChallenge(ctx context.Context, arg sqlc.AttemptMFAChallengeParams) (sqlc.MfaChallenge, error) {
+	return r.queries.AttemptMFAChallenge(ctx, arg)
+}
+
+// DeleteMFAChallenge deletes a challenge once it has been answered.
+func (r *repository) DeleteMFAChallenge(ctx context.Context, tokenHash string) error {
+	return r.queries.DeleteMFAChallenge(ctx, tokenHash)
+}
+
+// DeleteExpiredMFAChallenges deletes the challenges that expired unanswered.
+func (r *repository) DeleteExpiredMFAChallenges(ctx context.Context) (int64, error) {
+	return r.queries.DeleteExpiredMFAChallenges(ctx)
+}
+
+// ListMFAPolicies returns the two-factor authentication policy of each role that has one.
+func (r *repository) ListMFAPolicies(ctx context.Context) ([]sqlc.MfaPolicy, error) {
+	return r.queries.ListMFAPolicies(ctx)
+}
+
+// SetMFAPolicy sets whether the members of a role must enable two-factor authentication.
+func (r *repository) SetMFAPolicy(ctx context.Context, arg sqlc.SetMFAPolicyParams) (sqlc.MfaPolicy, error) {
+	return r.queries.SetMFAPolicy(ctx, arg)
+}
+
+// RoleRequiresMFA reports whether the members of a role must enable two-factor authentication.
+func (r *repository) RoleRequiresMFA(ctx context.Context, role string) (bool, error) {
+	return r.queries.RoleRequiresMFA(ctx, role)
+}
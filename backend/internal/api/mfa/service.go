@@ -0,0 +1,453 @@
+// Package mfa adds two-factor authentication to password logins, with
+// time-based one-time passwords (RFC 6238) from an authenticator app and
+// one-time recovery codes for when the app is lost.
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// challengeTTL is how long the user has to type a code after their password.
+	challengeTTL = 5 * time.Minute
+	// maxChallengeAttempts is the number of codes tried per challenge, after
+	// which the user must log in with their password again.
+	maxChallengeAttempts = 5
+	// maxLoginAttempts is the number of codes a user can try at login, across
+	// challenges, before two-factor login is locked for loginLockout.
+	maxLoginAttempts = 10
+	loginLockout     = 15 * time.Minute
+	// recoveryCodeCount is the number of recovery codes generated at once.
+	recoveryCodeCount = 10
+)
+
+// PolicyRoles lists the roles a policy can be set for. Only the admin routes
+// enforce the policy, see middleware.AdminMiddleware.
+var PolicyRoles = []string{"admin"}
+
+var (
+	// ErrInvalidCode is returned for wrong, reused and malformed codes.
+	ErrInvalidCode = apierror.New(http.StatusUnauthorized, "Invalid two-factor authentication code")
+	// ErrInvalidChallenge is returned for unknown and expired login challenges,
+	// and those that ran out of attempts.
+	ErrInvalidChallenge = apierror.New(http.StatusUnauthorized, "Login expired, please sign in again")
+	// ErrLockedOut is returned for codes entered at login after too many wrong ones.
+	ErrLockedOut = apierror.New(http.StatusTooManyRequests, "Too many wrong codes, please try again later")
+)
+
+// recoveryEncoding encodes recovery codes in lowercase letters and digits.
+var recoveryEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// Service handles enrollment, login challenges and role policies of two-factor authentication.
+type Service struct {
+	repo  Repository
+	audit audit.Service
+}
+
+// NewService creates a new instance of the mfa Service.
+// - repo: repository providing database operations for secrets, recovery codes, challenges and policies.
+// - auditService: service used to record enrollments, used recovery codes and policy changes.
+func NewService(repo Repository, auditService audit.Service) *Service {
+	return &Service{repo: repo, audit: auditService}
+}
+
+// hash returns the hex-encoded SHA-256 of a challenge token or recovery code,
+// the form they are stored in. Both are random, so a fast hash is enough.
+func hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeRecoveryCode ignores the case and separators of a recovery code as typed.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// Status returns the two-factor authentication status of the authenticated user.
+func (s *Service) Status(ctx context.Context) (Status, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return Status{}, apierror.NewUnauthorizedError()
+	}
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return Status{}, apierror.NewInternalServerError("Failed to fetch user")
+	}
+
+	enabled, err := s.repo.UserHasMFA(ctx, userID)
+	if err != nil {
+		return Status{}, apierror.NewInternalServerError("Failed to fetch two-factor authentication status")
+	}
+	required, err := s.repo.RoleRequiresMFA(ctx, user.Role)
+	if err != nil {
+		return Status{}, apierror.NewInternalServerError("Failed to fetch two-factor authentication status")
+	}
+	remaining, err := s.repo.CountMFARecoveryCodes(ctx, userID)
+	if err != nil {
+		return Status{}, apierror.NewInternalServerError("Failed to fetch two-factor authentication status")
+	}
+	return Status{Enabled: enabled, Required: required, RecoveryCodesRemaining: remaining}, nil
+}
+
+// Enroll starts the enrollment of the authenticated user with a new secret,
+// which they add to their authenticator app and confirm with Enable.
+// Fails if two-factor authentication is already enabled.
+func (s *Service) Enroll(ctx context.Context) (Enrollment, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return Enrollment{}, apierror.NewUnauthorizedError()
+	}
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return Enrollment{}, apierror.NewInternalServerError("Failed to fetch user")
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return Enrollment{}, apierror.NewInternalServerError("Failed to generate secret")
+	}
+	if _, err := s.repo.StartMFAEnrollment(ctx, sqlc.StartMFAEnrollmentParams{UserID: userID, Secret: secret}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Enrollment{}, apierror.New(http.StatusConflict, "Two-factor authentication is already enabled")
+		}
+		log.Printf("Failed to start enrollment of user %d: %v", userID, err)
+		return Enrollment{}, apierror.NewInternalServerError("Failed to start enrollment")
+	}
+
+	return Enrollment{Secret: secret, ProvisioningURI: provisioningURI(secret, user.Email)}, nil
+}
+
+// Enable confirms the enrollment of the authenticated user with a code from
+// their authenticator app. Logins then need a code, or one of the returned
+// recovery codes.
+func (s *Service) Enable(ctx context.Context, code string) (RecoveryCodes, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return RecoveryCodes{}, apierror.NewUnauthorizedError()
+	}
+	settings, err := s.repo.GetUserMFA(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && settings.EnabledAt.Valid) {
+		return RecoveryCodes{}, apierror.New(http.StatusConflict, "Start the enrollment before enabling two-factor authentication")
+	} else if err != nil {
+		return RecoveryCodes{}, apierror.NewInternalServerError("Failed to fetch two-factor authentication status")
+	}
+
+	if err := s.checkTOTP(ctx, settings, code); err != nil {
+		return RecoveryCodes{}, err
+	}
+	if updated, err := s.repo.EnableUserMFA(ctx, userID); err != nil || updated == 0 {
+		return RecoveryCodes{}, apierror.NewInternalServerError("Failed to enable two-factor authentication")
+	}
+	codes, err := s.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return RecoveryCodes{}, err
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID: userID,
+		Action: "MFA_ENABLED",
+	})
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off for the authenticated user,
+// who proves they still have their authenticator app or a recovery code.
+// Fails if the role of the user requires two-factor authentication.
+func (s *Service) Disable(ctx context.Context, code string) error {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return apierror.NewUnauthorizedError()
+	}
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return apierror.NewInternalServerError("Failed to fetch user")
+	}
+	required, err := s.repo.RoleRequiresMFA(ctx, user.Role)
+	if err != nil {
+		return apierror.NewInternalServerError("Failed to fetch two-factor authentication policy")
+	}
+	if required {
+		return apierror.New(http.StatusForbidden, "Two-factor authentication is required for your role")
+	}
+
+	if err := s.checkCode(ctx, userID, code); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteUserMFA(ctx, userID); err != nil {
+		return apierror.NewInternalServerError("Failed to disable two-factor authentication")
+	}
+	if err := s.repo.DeleteMFARecoveryCodes(ctx, userID); err != nil {
+		log.Printf("Failed to delete recovery codes of user %d: %v", userID, err)
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID: userID,
+		Action: "MFA_DISABLED",
+	})
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the authenticated
+// user, who proves they have their authenticator app.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, code string) (RecoveryCodes, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return RecoveryCodes{}, apierror.NewUnauthorizedError()
+	}
+	settings, err := s.enabledSettings(ctx, userID)
+	if err != nil {
+		return RecoveryCodes{}, err
+	}
+	if err := s.checkTOTP(ctx, settings, code); err != nil {
+		return RecoveryCodes{}, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return RecoveryCodes{}, err
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID: userID,
+		Action: "MFA_RECOVERY_CODES_REGENERATED",
+	})
+	return codes, nil
+}
+
+// StartChallenge is called once a user logged in with their password. It
+// returns a challenge to answer with a code, or false if the user has not
+// enabled two-factor authentication and can be logged in right away.
+func (s *Service) StartChallenge(ctx context.Context, userID int64) (Challenge, bool, error) {
+	enabled, err := s.repo.UserHasMFA(ctx, userID)
+	if err != nil {
+		return Challenge{}, false, apierror.NewInternalServerError("Failed to fetch two-factor authentication status")
+	}
+	if !enabled {
+		return Challenge{}, false, nil
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return Challenge{}, false, apierror.NewInternalServerError("Failed to start login")
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	expiresAt := time.Now().Add(challengeTTL)
+
+	if _, err := s.repo.DeleteExpiredMFAChallenges(ctx); err != nil {
+		log.Printf("Failed to delete expired challenges: %v", err)
+	}
+	if err := s.repo.CreateMFAChallenge(ctx, sqlc.CreateMFAChallengeParams{
+		TokenHash: hash(token),
+		UserID:    userID,
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	}); err != nil {
+		log.Printf("Failed to create challenge for user %d: %v", userID, err)
+		return Challenge{}, false, apierror.NewInternalServerError("Failed to start login")
+	}
+
+	return Challenge{MFARequired: true, Challenge: token, ExpiresAt: expiresAt}, true, nil
+}
+
+// VerifyChallenge answers a login challenge with a code from the
+// authenticator app or a recovery code. Returns the ID of the user to log in.
+// Anyone with the password can start new challenges, so codes are also counted
+// per user and too many wrong ones in a row lock them out for a while.
+func (s *Service) VerifyChallenge(ctx context.Context, token, code string) (int64, error) {
+	tokenHash := hash(token)
+	challenge, err := s.repo.AttemptMFAChallenge(ctx, sqlc.AttemptMFAChallengeParams{
+		TokenHash:   tokenHash,
+		MaxAttempts: maxChallengeAttempts,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrInvalidChallenge
+	} else if err != nil {
+		return 0, apierror.NewInternalServerError("Failed to verify code")
+	}
+
+	if _, err := s.repo.CountMFAAttempt(ctx, sqlc.CountMFAAttemptParams{
+		MaxAttempts: maxLoginAttempts,
+		LockedUntil: pgtype.Timestamptz{Time: time.Now().Add(loginLockout), Valid: true},
+		UserID:      challenge.UserID,
+	}); errors.Is(err, pgx.ErrNoRows) {
+		if _, err := s.enabledSettings(ctx, challenge.UserID); err != nil {
+			return 0, err
+		}
+		return 0, ErrLockedOut
+	} else if err != nil {
+		return 0, apierror.NewInternalServerError("Failed to verify code")
+	}
+
+	if err := s.checkCode(ctx, challenge.UserID, code); err != nil {
+		return 0, err
+	}
+	if err := s.repo.ResetMFAAttempts(ctx, challenge.UserID); err != nil {
+		log.Printf("Failed to reset two-factor attempts of user %d: %v", challenge.UserID, err)
+	}
+	if err := s.repo.DeleteMFAChallenge(ctx, tokenHash); err != nil {
+		log.Printf("Failed to delete answered challenge: %v", err)
+	}
+	return challenge.UserID, nil
+}
+
+// enabledSettings returns the settings of a user who enabled two-factor authentication.
+func (s *Service) enabledSettings(ctx context.Context, userID int64) (sqlc.UserMfa, error) {
+	settings, err := s.repo.GetUserMFA(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !settings.EnabledAt.Valid) {
+		return sqlc.UserMfa{}, apierror.New(http.StatusConflict, "Two-factor authentication is not enabled")
+	} else if err != nil {
+		return sqlc.UserMfa{}, apierror.NewInternalServerError("Failed to fetch two-factor authentication status")
+	}
+	return settings, nil
+}
+
+// checkCode accepts a code from the authenticator app of a user who enabled
+// two-factor authentication, or one of their unused recovery codes.
+func (s *Service) checkCode(ctx context.Context, userID int64, code string) error {
+	settings, err := s.enabledSettings(ctx, userID)
+	if err != nil {
+		return err
+	}
+	code = strings.TrimSpace(code)
+	if len(code) == digits {
+		return s.checkTOTP(ctx, settings, code)
+	}
+
+	used, err := s.repo.UseMFARecoveryCode(ctx, sqlc.UseMFARecoveryCodeParams{
+		UserID:   userID,
+		CodeHash: hash(normalizeRecoveryCode(code)),
+	})
+	if err != nil {
+		return apierror.NewInternalServerError("Failed to verify code")
+	}
+	if used == 0 {
+		return ErrInvalidCode
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID: userID,
+		Action: "MFA_RECOVERY_CODE_USED",
+	})
+	return nil
+}
+
+// checkTOTP accepts a code from the authenticator app once.
+func (s *Service) checkTOTP(ctx context.Context, settings sqlc.UserMfa, code string) error {
+	matched, ok := matchStep(settings.Secret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return ErrInvalidCode
+	}
+	used, err := s.repo.UseMFAStep(ctx, sqlc.UseMFAStepParams{UserID: settings.UserID, LastUsedStep: matched})
+	if err != nil {
+		return apierror.NewInternalServerError("Failed to verify code")
+	}
+	if used == 0 {
+		// this code, or a later one, was already used
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// replaceRecoveryCodes generates new recovery codes for a user, invalidating
+// their previous ones.
+func (s *Service) replaceRecoveryCodes(ctx context.Context, userID int64) (RecoveryCodes, error) {
+	if err := s.repo.DeleteMFARecoveryCodes(ctx, userID); err != nil {
+		return RecoveryCodes{}, apierror.NewInternalServerError("Failed to generate recovery codes")
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return RecoveryCodes{}, apierror.NewInternalServerError("Failed to generate recovery codes")
+		}
+		code := recoveryEncoding.EncodeToString(b)
+		if err := s.repo.CreateMFARecoveryCode(ctx, sqlc.CreateMFARecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: hash(code),
+		}); err != nil {
+			log.Printf("Failed to store recovery code of user %d: %v", userID, err)
+			return RecoveryCodes{}, apierror.NewInternalServerError("Failed to generate recovery codes")
+		}
+		// shown in groups of four for readability, ignored when the code is typed
+		codes = append(codes, code[:4]+"-"+code[4:8]+"-"+code[8:12]+"-"+code[12:])
+	}
+	return RecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+// ListPolicies returns the two-factor authentication policy of each role
+// that can have one.
+func (s *Service) ListPolicies(ctx context.Context) ([]Policy, error) {
+	stored, err := s.repo.ListMFAPolicies(ctx)
+	if err != nil {
+		return nil, apierror.NewInternalServerError("Failed to fetch two-factor authentication policies")
+	}
+
+	policies := make([]Policy, 0, len(PolicyRoles))
+	for _, role := range PolicyRoles {
+		policy := Policy{Role: role}
+		for _, p := range stored {
+			if p.Role == role {
+				policy.Required = p.Required
+				policy.UpdatedAt = p.UpdatedAt.Time
+			}
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// SetPolicy sets whether the members of a role must enable two-factor
+// authentication. The admin requiring it must have enabled it, so they do not
+// lock themselves out of the admin routes.
+func (s *Service) SetPolicy(ctx context.Context, role string, required bool) (Policy, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return Policy{}, apierror.NewUnauthorizedError()
+	}
+	if !slices.Contains(PolicyRoles, role) {
+		return Policy{}, apierror.NewNotFoundError("Role")
+	}
+	if required {
+		enabled, err := s.repo.UserHasMFA(ctx, userID)
+		if err != nil {
+			return Policy{}, apierror.NewInternalServerError("Failed to fetch two-factor authentication status")
+		}
+		if !enabled {
+			return Policy{}, apierror.New(http.StatusConflict, "Enable two-factor authentication before requiring it")
+		}
+	}
+
+	policy, err := s.repo.SetMFAPolicy(ctx, sqlc.SetMFAPolicyParams{Role: role, Required: required})
+	if err != nil {
+		return Policy{}, apierror.NewInternalServerError("Failed to update two-factor authentication policy")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID: userID,
+		Action: "MFA_POLICY_CHANGED",
+		Details: map[string]interface{}{
+			"role":     role,
+			"required": required,
+		},
+	})
+	return Policy{Role: policy.Role, Required: policy.Required, UpdatedAt: policy.UpdatedAt.Time}, nil
+}
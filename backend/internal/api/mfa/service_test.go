@@ -0,0 +1,295 @@
+package mfa_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/mfa"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/sessions"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/users"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/config"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/memdb"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/go-chi/chi/v5"
+)
+
+// recordingAudit keeps the actions it is asked to log.
+type recordingAudit struct {
+	actions []string
+}
+
+func (a *recordingAudit) Log(ctx context.Context, params audit.LogParams) {
+	a.actions = append(a.actions, params.Action)
+}
+
+type testEnv struct {
+	db      *memdb.DB
+	audit   *recordingAudit
+	service *mfa.Service
+	users   *users.Service
+	router  http.Handler
+}
+
+// newTestEnv returns an mfa service and a router serving the password login
+// and the second step of the login, as NewServer does.
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	db := memdb.New()
+	recorder := &recordingAudit{}
+	cfg := &config.Config{Server: config.ServerConfig{DefaultStorageQuota: 1 << 20, AccessTokenTTLMinutes: 15, SessionTTLDays: 30}}
+	sessionService := sessions.NewService(db, "secret", cfg, recorder)
+	service := mfa.NewService(db, recorder)
+	userService := users.NewService(db, cfg, recorder)
+
+	router := chi.NewRouter()
+	router.Post("/auth/login", users.NewHandler(userService, sessionService, service, nil).Login)
+	mfa.NewHandler(service, sessionService).RegisterPublicRoutes(router)
+	return &testEnv{db: db, audit: recorder, service: service, users: userService, router: router}
+}
+
+// signup creates a user and returns a context authenticated as them.
+func (env *testEnv) signup(t *testing.T, email string) context.Context {
+	t.Helper()
+	user, err := env.users.Signup(context.Background(), email, email, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return userctx.SetUserID(context.Background(), user.ID)
+}
+
+// enable enrolls the user of ctx and returns their secret and recovery codes.
+func (env *testEnv) enable(t *testing.T, ctx context.Context) (string, []string) {
+	t.Helper()
+	enrollment, err := env.service.Enroll(ctx)
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	codes, err := env.service.Enable(ctx, code(t, enrollment.Secret, 0))
+	if err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+	return enrollment.Secret, codes.RecoveryCodes
+}
+
+// code returns the code of secret the given number of time steps from now.
+func code(t *testing.T, secret string, steps int) string {
+	t.Helper()
+	c, err := mfa.Code(secret, time.Now().Add(time.Duration(steps)*30*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func (env *testEnv) post(path string, body interface{}) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	rec := httptest.NewRecorder()
+	env.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(string(b))))
+	return rec
+}
+
+func statusOf(err error) int {
+	var apiErr *apierror.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+func hasSession(rec *httptest.ResponseRecorder) bool {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == sessions.RefreshCookie && c.Value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// TestCode checks codes against the SHA-1 test vectors of RFC 6238, of which
+// authenticator apps show the last six digits.
+func TestCode(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" // "12345678901234567890"
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := mfa.Code(secret, time.Unix(tt.unix, 0))
+		if err != nil || got != tt.want {
+			t.Errorf("Code at %d = %q, %v, want %q", tt.unix, got, err, tt.want)
+		}
+	}
+}
+
+func TestEnrollment(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := env.signup(t, "alice@example.com")
+
+	enrollment, err := env.service.Enroll(ctx)
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	uri, err := url.Parse(enrollment.ProvisioningURI)
+	if err != nil || uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/FileVault:alice@example.com" || uri.Query().Get("secret") != enrollment.Secret {
+		t.Errorf("provisioning URI = %q, want an otpauth URI of the secret", enrollment.ProvisioningURI)
+	}
+	// a code five minutes from now is outside the clock drift allowed
+	if _, err := env.service.Enable(ctx, code(t, enrollment.Secret, 10)); statusOf(err) != http.StatusUnauthorized {
+		t.Errorf("Enable with a wrong code error = %v, want %d", err, http.StatusUnauthorized)
+	}
+
+	codes, err := env.service.Enable(ctx, code(t, enrollment.Secret, 0))
+	if err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+	if len(codes.RecoveryCodes) != 10 {
+		t.Errorf("recovery codes = %v, want 10", codes.RecoveryCodes)
+	}
+	if _, err := env.service.Enroll(ctx); statusOf(err) != http.StatusConflict {
+		t.Errorf("Enroll when enabled error = %v, want %d", err, http.StatusConflict)
+	}
+
+	// a code is accepted once
+	if _, err := env.service.RegenerateRecoveryCodes(ctx, code(t, enrollment.Secret, 0)); statusOf(err) != http.StatusUnauthorized {
+		t.Errorf("reusing a code error = %v, want %d", err, http.StatusUnauthorized)
+	}
+	regenerated, err := env.service.RegenerateRecoveryCodes(ctx, code(t, enrollment.Secret, 1))
+	if err != nil {
+		t.Fatalf("RegenerateRecoveryCodes: %v", err)
+	}
+
+	// the previous recovery codes stopped working, the new ones work once
+	if err := env.service.Disable(ctx, codes.RecoveryCodes[0]); statusOf(err) != http.StatusUnauthorized {
+		t.Errorf("Disable with a replaced recovery code error = %v, want %d", err, http.StatusUnauthorized)
+	}
+	if err := env.service.Disable(ctx, strings.ToUpper(regenerated.RecoveryCodes[0])); err != nil {
+		t.Fatalf("Disable with a recovery code: %v", err)
+	}
+	status, err := env.service.Status(ctx)
+	if err != nil || status.Enabled || status.RecoveryCodesRemaining != 0 {
+		t.Errorf("status after disabling = %+v, %v, want disabled", status, err)
+	}
+
+	want := []string{"USER_REGISTERED", "MFA_ENABLED", "MFA_RECOVERY_CODES_REGENERATED", "MFA_RECOVERY_CODE_USED", "MFA_DISABLED"}
+	if strings.Join(env.audit.actions, ",") != strings.Join(want, ",") {
+		t.Errorf("audit actions = %v, want %v", env.audit.actions, want)
+	}
+}
+
+// TestLogin goes through the two steps of the login of a user with
+// two-factor authentication.
+func TestLogin(t *testing.T) {
+	env := newTestEnv(t)
+	credentials := map[string]string{"email": "alice@example.com", "password": "password"}
+
+	// without two-factor authentication, the password is enough
+	ctx := env.signup(t, "alice@example.com")
+	if rec := env.post("/auth/login", credentials); rec.Code != http.StatusOK || !hasSession(rec) {
+		t.Fatalf("login without MFA = %d %s, want a session", rec.Code, rec.Body)
+	}
+	secret, recoveryCodes := env.enable(t, ctx)
+
+	login := func() mfa.Challenge {
+		t.Helper()
+		rec := env.post("/auth/login", credentials)
+		var challenge mfa.Challenge
+		if rec.Code != http.StatusOK || hasSession(rec) || json.Unmarshal(rec.Body.Bytes(), &challenge) != nil || !challenge.MFARequired {
+			t.Fatalf("login with MFA = %d %s, want a challenge and no session", rec.Code, rec.Body)
+		}
+		return challenge
+	}
+
+	challenge := login()
+	if rec := env.post("/auth/mfa/verify", map[string]string{"challenge": "forged", "code": code(t, secret, 1)}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("verify with a forged challenge status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := env.post("/auth/mfa/verify", map[string]string{"challenge": challenge.Challenge, "code": code(t, secret, 1)}); rec.Code != http.StatusOK || !hasSession(rec) {
+		t.Fatalf("verify = %d %s, want a session", rec.Code, rec.Body)
+	}
+	if rec := env.post("/auth/mfa/verify", map[string]string{"challenge": challenge.Challenge, "code": code(t, secret, 1)}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("answering a challenge twice status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	// a recovery code logs in once
+	recovery := map[string]string{"challenge": login().Challenge, "code": recoveryCodes[0]}
+	if rec := env.post("/auth/mfa/verify", recovery); rec.Code != http.StatusOK || !hasSession(rec) {
+		t.Fatalf("verify with a recovery code = %d %s, want a session", rec.Code, rec.Body)
+	}
+	recovery["challenge"] = login().Challenge
+	if rec := env.post("/auth/mfa/verify", recovery); rec.Code != http.StatusUnauthorized {
+		t.Errorf("reusing a recovery code status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	// a challenge runs out of attempts
+	challenge = login()
+	for range 5 {
+		env.post("/auth/mfa/verify", map[string]string{"challenge": challenge.Challenge, "code": "not-a-code"})
+	}
+	if rec := env.post("/auth/mfa/verify", map[string]string{"challenge": challenge.Challenge, "code": recoveryCodes[1]}); rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "Login expired") {
+		t.Errorf("verify after too many attempts = %d %s, want the challenge expired", rec.Code, rec.Body)
+	}
+
+	// new challenges do not give more guesses, even a right code is refused once locked out
+	for range 2 {
+		challenge = login()
+		for range 5 {
+			env.post("/auth/mfa/verify", map[string]string{"challenge": challenge.Challenge, "code": "not-a-code"})
+		}
+	}
+	if rec := env.post("/auth/mfa/verify", map[string]string{"challenge": login().Challenge, "code": recoveryCodes[1]}); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("verify when locked out = %d %s, want %d", rec.Code, rec.Body, http.StatusTooManyRequests)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	env := newTestEnv(t)
+	adminCtx := env.signup(t, "admin@example.com")
+	adminID, _ := userctx.GetUserID(adminCtx)
+	env.db.SetUserRole(adminID, "admin")
+
+	if _, err := env.service.SetPolicy(adminCtx, "admin", true); statusOf(err) != http.StatusConflict {
+		t.Errorf("requiring MFA without it error = %v, want %d", err, http.StatusConflict)
+	}
+	if _, err := env.service.SetPolicy(adminCtx, "guest", false); statusOf(err) != http.StatusNotFound {
+		t.Errorf("policy of an unknown role error = %v, want %d", err, http.StatusNotFound)
+	}
+
+	secret, _ := env.enable(t, adminCtx)
+	if _, err := env.service.SetPolicy(adminCtx, "admin", true); err != nil {
+		t.Fatalf("SetPolicy: %v", err)
+	}
+	policies, err := env.service.ListPolicies(adminCtx)
+	if err != nil || len(policies) != 1 || !policies[0].Required {
+		t.Errorf("policies = %+v, %v, want admin required", policies, err)
+	}
+	status, err := env.service.Status(adminCtx)
+	if err != nil || !status.Required || !status.Enabled {
+		t.Errorf("status = %+v, %v, want enabled and required", status, err)
+	}
+	if err := env.service.Disable(adminCtx, code(t, secret, 1)); statusOf(err) != http.StatusForbidden {
+		t.Errorf("Disable when required error = %v, want %d", err, http.StatusForbidden)
+	}
+
+	// the policy of admins does not apply to other users
+	userCtx := env.signup(t, "user@example.com")
+	userSecret, _ := env.enable(t, userCtx)
+	if err := env.service.Disable(userCtx, code(t, userSecret, 1)); err != nil {
+		t.Errorf("Disable as a user: %v", err)
+	}
+}
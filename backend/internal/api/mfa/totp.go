@@ -0,0 +1,102 @@
+package mfa
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Issuer names the service in authenticator apps.
+	Issuer = "FileVault"
+	// period is how long a code is valid, and digits its length. These are the
+	// defaults of authenticator apps, which ignore other values.
+	period = 30 * time.Second
+	digits = 6
+	// skew is the number of time steps a code may be off by, allowing for
+	// clocks that drift and codes typed just as they change.
+	skew = 1
+	// secretSize is the size of a secret in bytes, 160 bits as RFC 4226 recommends.
+	secretSize = 20
+)
+
+// secretEncoding is how secrets are shown to users and stored.
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newSecret returns a random secret, base32 encoded.
+func newSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretEncoding.EncodeToString(b), nil
+}
+
+// step returns the time step of t.
+func step(t time.Time) int64 {
+	return t.Unix() / int64(period.Seconds())
+}
+
+// hotp returns the RFC 4226 one-time password of a key for a counter.
+func hotp(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", digits, value%uint32(math.Pow10(digits)))
+}
+
+// Code returns the code an authenticator app shows at time t for a base32
+// encoded secret, as defined by RFC 6238.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := secretEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, step(t)), nil
+}
+
+// matchStep returns the time step around now that a code is valid for, or
+// false if it is valid for none.
+func matchStep(secret, code string, now time.Time) (int64, bool) {
+	key, err := secretEncoding.DecodeString(secret)
+	if err != nil || len(code) != digits {
+		return 0, false
+	}
+	current := step(now)
+	for s := current - skew; s <= current+skew; s++ {
+		if hmac.Equal([]byte(hotp(key, s)), []byte(code)) {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+// provisioningURI returns the otpauth:// URI of a secret, which authenticator
+// apps read from a QR code.
+func provisioningURI(secret, account string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", Issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(digits))
+	query.Set("period", fmt.Sprint(int(period.Seconds())))
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + Issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
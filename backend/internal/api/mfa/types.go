@@ -0,0 +1,58 @@
+package mfa
+
+import "time"
+
+// Status describes the two-factor authentication of a user.
+// Enabled: whether logins need a code from an authenticator app.
+// Required: whether the role of the user requires it, so it cannot be disabled.
+// RecoveryCodesRemaining: the number of recovery codes not used yet.
+type Status struct {
+	Enabled                bool  `json:"enabled"`
+	Required               bool  `json:"required"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+// Enrollment is a new secret to add to an authenticator app, by scanning a
+// QR code of ProvisioningURI or typing Secret.
+type Enrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// RecoveryCodes are shown once, when they are generated.
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// Challenge is returned by a password login of a user with two-factor
+// authentication instead of a session. It is exchanged for a session, along
+// with a code, before ExpiresAt.
+type Challenge struct {
+	MFARequired bool      `json:"mfa_required"`
+	Challenge   string    `json:"challenge"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Policy says whether the members of a role must enable two-factor authentication.
+type Policy struct {
+	Role      string    `json:"role"`
+	Required  bool      `json:"required"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// codeRequest carries a code from an authenticator app, or a recovery code
+// where one is accepted.
+type codeRequest struct {
+	Code string `json:"code"`
+}
+
+// verifyRequest answers a login challenge.
+type verifyRequest struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+}
+
+// policyRequest changes the policy of a role.
+type policyRequest struct {
+	Required bool `json:"required"`
+}
@@ -9,7 +9,10 @@ import (
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/util"
 )
 
-// AdminMiddleware checks if the authenticated user has the 'admin' role.
+// AdminMiddleware checks if the authenticated user has the 'admin' role. If the
+// role requires two-factor authentication, the user must have enabled it and the
+// request must come from a session whose login answered a challenge, so logins
+// that skipped it and personal access tokens are refused.
 // It runs after the AuthMiddleware.
 func AdminMiddleware(repo sqlc.Querier) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -41,6 +44,30 @@ func AdminMiddleware(repo sqlc.Querier) func(http.Handler) http.Handler {
 				return
 			}
 
+			// Admins may be required to enable two-factor authentication
+			required, err := repo.RoleRequiresMFA(ctx, user.Role)
+			if err != nil {
+				errResponse := apierror.NewInternalServerError("could not retrieve two-factor authentication policy")
+				util.WriteError(w, errResponse.StatusCode, errResponse.Message)
+				return
+			}
+			if required {
+				enabled, err := repo.UserHasMFA(ctx, userID)
+				if err != nil {
+					errResponse := apierror.NewInternalServerError("could not retrieve two-factor authentication status")
+					util.WriteError(w, errResponse.StatusCode, errResponse.Message)
+					return
+				}
+				if !enabled {
+					util.WriteError(w, http.StatusForbidden, "Two-factor authentication is required for admins")
+					return
+				}
+				if !userctx.MFAVerified(ctx) {
+					util.WriteError(w, http.StatusForbidden, "Log in with your two-factor authentication code to use admin features")
+					return
+				}
+			}
+
 			// User is an admin, proceed to the next handler
 			next.ServeHTTP(w, r)
 		})
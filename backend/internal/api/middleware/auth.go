@@ -1,22 +1,56 @@
 package middleware
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/util"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-// AuthMiddleware returns an HTTP middleware that validates JWT tokens from cookies.
-// It checks the "jwt" cookie, verifies the token using the provided secret, and
-// injects the user ID into the request context for downstream handlers. Unauthorized
-// requests are responded to with HTTP 401.
-func AuthMiddleware(secret string) func(http.Handler) http.Handler {
+// SessionValidator checks that the session an access token was issued for
+// has not been revoked or expired.
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, userID int64, sessionID uuid.UUID) error
+}
+
+// TokenAuthenticator resolves personal access tokens to the ID of the user
+// they belong to and the scopes they carry.
+type TokenAuthenticator interface {
+	AuthenticateToken(ctx context.Context, token string) (int64, []string, error)
+}
+
+// AuthMiddleware returns an HTTP middleware that authenticates requests and
+// injects the user ID into the request context for downstream handlers.
+// Requests with an "Authorization: Bearer" header are authenticated with the
+// personal access token it carries, and restricted to the token's scopes.
+// Other requests must carry a "jwt" cookie with an access token, verified
+// using the provided secret, whose session is still active.
+// Unauthorized requests are responded to with HTTP 401.
+func AuthMiddleware(secret string, sessions SessionValidator, tokens TokenAuthenticator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Get Auth Header & Process
+			if header := r.Header.Get("Authorization"); header != "" {
+				scheme, token, _ := strings.Cut(header, " ")
+				if !strings.EqualFold(scheme, "Bearer") || token == "" {
+					util.WriteError(w, http.StatusUnauthorized, "Invalid Authorization header")
+					return
+				}
+				userID, scopes, err := tokens.AuthenticateToken(r.Context(), token)
+				if err != nil {
+					util.WriteError(w, http.StatusUnauthorized, "invalid token")
+					return
+				}
+				ctx := userctx.SetScopes(userctx.SetUserID(r.Context(), userID), scopes)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			// Get Auth Cookie & Process
 			cookie, err := r.Cookie("jwt")
 			if err != nil {
 				util.WriteError(w, http.StatusUnauthorized, "Missing JWT Cookie")
@@ -49,8 +83,22 @@ func AuthMiddleware(secret string) func(http.Handler) http.Handler {
 				return
 			}
 
+			// Access tokens issued before sessions existed carry no session ID
+			sid, _ := claims["sid"].(string)
+			sessionID, err := uuid.Parse(sid)
+			if err != nil {
+				util.WriteError(w, http.StatusUnauthorized, "invalid sid claim")
+				return
+			}
+			if err := sessions.ValidateSession(r.Context(), int64(userID), sessionID); err != nil {
+				util.WriteError(w, http.StatusUnauthorized, "session has ended")
+				return
+			}
+
 			// converting float64 to int64 to store in user context
-			ctx := userctx.SetUserID(r.Context(), int64(userID))
+			ctx := userctx.SetSessionID(userctx.SetUserID(r.Context(), int64(userID)), sessionID)
+			mfaVerified, _ := claims["mfa"].(bool)
+			ctx = userctx.SetMFAVerified(ctx, mfaVerified)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -30,23 +30,23 @@ func RateLimiter(redisClient *redis.Client, limit int, window time.Duration) fun
 
 			// Generate the Redis key for the user
 			key := fmt.Sprintf("rate_limit:%d", userID)
-
-			pipe := redisClient.TxPipeline()
-			count := pipe.Incr(ctx, key)
-			// Set the key to expire after the window duration
-			pipe.Expire(ctx, key, window)
-
-			_, err := pipe.Exec(ctx)
-			if err != nil {
-				log.Printf("Error executing Redis pipeline for rate limiting: %v", err)
-				next.ServeHTTP(w, r)
+			if !allowRequest(w, r, redisClient, key, limit, window) {
 				return
 			}
 
-			// Check if the count exceeds the limit, if it does, return 429
-			if count.Val() > int64(limit) {
-				errResponse := apierror.New(http.StatusTooManyRequests, "Rate limit exceeded")
-				util.WriteError(w, errResponse.StatusCode, errResponse.Message)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IPRateLimiter creates a middleware that limits the requests a client IP address
+// can make to the routes it wraps, counted separately for every scope. It guards
+// public endpoints where no user is known, such as password protected share links.
+func IPRateLimiter(redisClient *redis.Client, scope string, limit int, window time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := fmt.Sprintf("rate_limit:%s:%s", scope, util.ClientIP(r))
+			if !allowRequest(w, r, redisClient, key, limit, window) {
 				return
 			}
 
@@ -54,3 +54,29 @@ func RateLimiter(redisClient *redis.Client, limit int, window time.Duration) fun
 		})
 	}
 }
+
+// allowRequest counts a request against key and reports whether it is within the
+// limit, writing a 429 response if it is not. Requests are let through when Redis
+// cannot be reached.
+func allowRequest(w http.ResponseWriter, r *http.Request, redisClient *redis.Client, key string, limit int, window time.Duration) bool {
+	ctx := r.Context()
+
+	pipe := redisClient.TxPipeline()
+	count := pipe.Incr(ctx, key)
+	// Set the key to expire after the window duration
+	pipe.Expire(ctx, key, window)
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		log.Printf("Error executing Redis pipeline for rate limiting: %v", err)
+		return true
+	}
+
+	// Check if the count exceeds the limit, if it does, return 429
+	if count.Val() > int64(limit) {
+		errResponse := apierror.New(http.StatusTooManyRequests, "Rate limit exceeded")
+		util.WriteError(w, errResponse.StatusCode, errResponse.Message)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/util"
+)
+
+// RequireScope rejects requests authenticated with a personal access token
+// that does not carry scope with HTTP 403. Requests authenticated with a
+// session are not restricted. It runs after the AuthMiddleware.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !userctx.HasScope(r.Context(), scope) {
+				util.WriteError(w, http.StatusForbidden, "Token is missing the "+scope+" scope")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireMethodScopes is like RequireScope, with readScope required for GET,
+// HEAD and OPTIONS requests and writeScope for the others. The write scope
+// grants reading as well.
+func RequireMethodScopes(readScope, writeScope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				if !userctx.HasScope(ctx, readScope) && !userctx.HasScope(ctx, writeScope) {
+					util.WriteError(w, http.StatusForbidden, "Token is missing the "+readScope+" scope")
+					return
+				}
+			default:
+				if !userctx.HasScope(ctx, writeScope) {
+					util.WriteError(w, http.StatusForbidden, "Token is missing the "+writeScope+" scope")
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SessionOnly rejects requests authenticated with a personal access token
+// with HTTP 403, for routes such as token management that a leaked token must
+// not reach. It runs after the AuthMiddleware.
+func SessionOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := userctx.GetScopes(r.Context()); ok {
+			util.WriteError(w, http.StatusForbidden, "Personal access tokens cannot be used for this request")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
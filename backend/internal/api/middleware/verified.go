@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/util"
+)
+
+// VerifiedOnly restricts routes to users who confirmed their email address,
+// see accounts.Service.VerifyEmail. It runs after the AuthMiddleware.
+func VerifiedOnly(repo sqlc.Querier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			userID, ok := userctx.GetUserID(ctx)
+			if !ok {
+				errResponse := apierror.NewUnauthorizedError()
+				util.WriteError(w, errResponse.StatusCode, errResponse.Message)
+				return
+			}
+
+			user, err := repo.GetUserByID(ctx, userID)
+			if err != nil {
+				errResponse := apierror.NewInternalServerError("could not retrieve user")
+				util.WriteError(w, errResponse.StatusCode, errResponse.Message)
+				return
+			}
+			if !user.EmailVerifiedAt.Valid {
+				util.WriteError(w, http.StatusForbidden, "Please verify your email address to continue")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
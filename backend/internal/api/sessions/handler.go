@@ -0,0 +1,163 @@
+package sessions
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apphandler"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// AccessCookie holds the access token, RefreshCookie the refresh token.
+	AccessCookie  = "jwt"
+	RefreshCookie = "refresh_token"
+)
+
+// SetCookies stores the tokens of a session in HTTP-only cookies. The refresh
+// cookie is left as it is when no new refresh token was issued.
+func SetCookies(w http.ResponseWriter, r *http.Request, tokens Tokens) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     AccessCookie,
+		Value:    tokens.AccessToken,
+		Path:     "/",
+		Expires:  tokens.AccessExpiresAt,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	if tokens.RefreshToken == "" {
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     RefreshCookie,
+		Value:    tokens.RefreshToken,
+		Path:     "/",
+		Expires:  tokens.RefreshExpiresAt,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearCookies removes the session cookies, logging the browser out.
+func ClearCookies(w http.ResponseWriter, r *http.Request) {
+	for _, name := range []string{AccessCookie, RefreshCookie} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     "/",
+			Expires:  time.Unix(0, 0),
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+}
+
+// Handler is the HTTP handler for session endpoints.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new session Handler with the given service.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterPublicRoutes registers the routes reachable without an access
+// token, which has usually expired when the refresh token is used.
+func (h *Handler) RegisterPublicRoutes(r chi.Router) {
+	r.Post("/auth/refresh", apphandler.MakeHTTPHandler(h.Refresh))
+}
+
+// RegisterRoutes registers the session management routes on the given router.
+// They should only be reachable with a session, see middleware.SessionOnly.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get("/auth/sessions", apphandler.MakeHTTPHandler(h.ListSessions))
+	r.Delete("/auth/sessions", apphandler.MakeHTTPHandler(h.RevokeAllSessions))
+	r.Delete("/auth/sessions/{id}", apphandler.MakeHTTPHandler(h.RevokeSession))
+}
+
+// Refresh handles POST /auth/refresh.
+// It exchanges the refresh cookie for new session cookies. The cookies are
+// cleared if the refresh token is no longer valid.
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) error {
+	cookie, err := r.Cookie(RefreshCookie)
+	if err != nil {
+		return apierror.New(http.StatusUnauthorized, "Missing refresh token")
+	}
+
+	tokens, err := h.service.Refresh(r.Context(), cookie.Value)
+	if err != nil {
+		if errors.Is(err, ErrInvalidRefreshToken) {
+			ClearCookies(w, r)
+		}
+		return err
+	}
+
+	SetCookies(w, r, tokens)
+	return util.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"message":    "Session refreshed",
+		"expires_at": tokens.AccessExpiresAt,
+	})
+}
+
+// Logout ends the session of the refresh cookie, if any, and clears the
+// session cookies.
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(RefreshCookie); err == nil {
+		if err := h.service.EndSession(r.Context(), cookie.Value); err != nil {
+			log.Printf("Failed to end session on logout: %v", err)
+		}
+	}
+	ClearCookies(w, r)
+}
+
+// ListSessions handles GET /auth/sessions.
+// It returns the devices the authenticated user is logged in on.
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) error {
+	sessions, err := h.service.ListSessions(r.Context())
+	if err != nil {
+		return err
+	}
+
+	return util.WriteJSON(w, http.StatusOK, sessions)
+}
+
+// RevokeSession handles DELETE /auth/sessions/{id}.
+// It logs one device out, clearing the cookies if it is the current one.
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) error {
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid session ID")
+	}
+
+	if err := h.service.RevokeSession(r.Context(), sessionID); err != nil {
+		return err
+	}
+
+	if currentID, ok := userctx.GetSessionID(r.Context()); ok && currentID == sessionID {
+		ClearCookies(w, r)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// RevokeAllSessions handles DELETE /auth/sessions.
+// It logs every device out, the current one included.
+func (h *Handler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) error {
+	revoked, err := h.service.RevokeAllSessions(r.Context())
+	if err != nil {
+		return err
+	}
+
+	ClearCookies(w, r)
+	return util.WriteJSON(w, http.StatusOK, RevokeAllResponse{Revoked: revoked})
+}
@@ -0,0 +1,72 @@
+package sessions
+
+import (
+	"context"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Repository describes the database operations used by the sessions service.
+// It is implemented on top of sqlc queries by NewRepository.
+type Repository interface {
+	CreateSession(ctx context.Context, arg sqlc.CreateSessionParams) (sqlc.Session, error)
+	GetSession(ctx context.Context, sessionID uuid.UUID) (sqlc.Session, error)
+	ListActiveSessions(ctx context.Context, userID int64) ([]sqlc.Session, error)
+	RotateSessionRefreshToken(ctx context.Context, arg sqlc.RotateSessionRefreshTokenParams) (int64, error)
+	RevokeSession(ctx context.Context, arg sqlc.RevokeSessionParams) (int64, error)
+	RevokeAllSessions(ctx context.Context, userID int64) (int64, error)
+	DeleteStaleSessions(ctx context.Context, before pgtype.Timestamptz) (int64, error)
+}
+
+// repository handles database operations related to sessions, backed by sqlc queries.
+type repository struct {
+	queries *sqlc.Queries
+}
+
+// NewRepository creates a new Repository instance with the provided database queries.
+func NewRepository(db *sqlc.Queries) Repository {
+	return &repository{
+		queries: db,
+	}
+}
+
+// CreateSession stores a new session with the hash of its first refresh token.
+func (r *repository) CreateSession(ctx context.Context, arg sqlc.CreateSessionParams) (sqlc.Session, error) {
+	return r.queries.CreateSession(ctx, arg)
+}
+
+// GetSession returns a session, whether or not it is still active.
+func (r *repository) GetSession(ctx context.Context, sessionID uuid.UUID) (sqlc.Session, error) {
+	return r.queries.GetSession(ctx, sessionID)
+}
+
+// ListActiveSessions returns the sessions of a user that are neither revoked
+// nor expired, most recently used first.
+func (r *repository) ListActiveSessions(ctx context.Context, userID int64) ([]sqlc.Session, error) {
+	return r.queries.ListActiveSessions(ctx, userID)
+}
+
+// RotateSessionRefreshToken replaces the refresh token of an active session.
+// Returns the number of updated sessions, zero if the given refresh token is
+// no longer the current one.
+func (r *repository) RotateSessionRefreshToken(ctx context.Context, arg sqlc.RotateSessionRefreshTokenParams) (int64, error) {
+	return r.queries.RotateSessionRefreshToken(ctx, arg)
+}
+
+// RevokeSession revokes a session of a user.
+// Returns the number of revoked sessions, zero if the user has no such active session.
+func (r *repository) RevokeSession(ctx context.Context, arg sqlc.RevokeSessionParams) (int64, error) {
+	return r.queries.RevokeSession(ctx, arg)
+}
+
+// RevokeAllSessions revokes every session of a user and returns how many there were.
+func (r *repository) RevokeAllSessions(ctx context.Context, userID int64) (int64, error) {
+	return r.queries.RevokeAllSessions(ctx, userID)
+}
+
+// DeleteStaleSessions deletes the sessions that expired or were revoked before the given time.
+func (r *repository) DeleteStaleSessions(ctx context.Context, before pgtype.Timestamptz) (int64, error) {
+	return r.queries.DeleteStaleSessions(ctx, before)
+}
@@ -0,0 +1,364 @@
+// Package sessions manages browser logins. Each login is a session with a
+// short-lived access token, a JWT checked on every request, and a refresh
+// token that is exchanged for new tokens and rotated each time it is used.
+package sessions
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/config"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// rotationGracePeriod is how long the refresh token a rotation replaced is
+// still accepted, so that requests refreshing at the same time, from several
+// tabs for example, do not end the session.
+const rotationGracePeriod = 30 * time.Second
+
+var (
+	// ErrInvalidRefreshToken is returned for malformed and unknown refresh
+	// tokens and those of sessions that ended.
+	ErrInvalidRefreshToken = apierror.New(http.StatusUnauthorized, "invalid or expired refresh token")
+	// ErrSessionEnded is returned by ValidateSession for sessions that were revoked or have expired.
+	ErrSessionEnded = errors.New("session was revoked or has expired")
+)
+
+// Service handles the creation, refresh and revocation of sessions.
+type Service struct {
+	repo       Repository
+	jwtSecret  []byte
+	accessTTL  time.Duration
+	sessionTTL time.Duration
+	audit      audit.Service
+}
+
+// NewService creates a new instance of the sessions Service.
+// - repo: repository providing database operations for sessions.
+// - jwtSecret: secret key used for signing access tokens.
+// - cfg: configuration struct containing the access token and session lifetimes.
+// - auditService: service used to record logouts, revocations and reused refresh tokens.
+func NewService(repo Repository, jwtSecret string, cfg *config.Config, auditService audit.Service) *Service {
+	return &Service{
+		repo:       repo,
+		jwtSecret:  []byte(jwtSecret),
+		accessTTL:  time.Duration(cfg.Server.AccessTokenTTLMinutes) * time.Minute,
+		sessionTTL: time.Duration(cfg.Server.SessionTTLDays) * 24 * time.Hour,
+		audit:      auditService,
+	}
+}
+
+// newSecret returns the random part of a refresh token and its hash, the
+// form it is stored in. Secrets are random, so a fast hash is enough.
+func newSecret() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(b)
+	return secret, hashSecret(secret), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// A refresh token is "<session ID>.<secret>", so the session is found even
+// when the secret is stale.
+func refreshToken(sessionID uuid.UUID, secret string) string {
+	return sessionID.String() + "." + secret
+}
+
+func parseRefreshToken(token string) (uuid.UUID, string, bool) {
+	id, secret, ok := strings.Cut(token, ".")
+	if !ok || secret == "" {
+		return uuid.Nil, "", false
+	}
+	sessionID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, "", false
+	}
+	return sessionID, secret, true
+}
+
+func sameHash(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// CreateSession starts a session for a user who just logged in.
+// - userAgent and ipAddress: describe the device in the sessions list.
+// - mfaVerified: whether the login answered a two-factor authentication challenge.
+// Returns the access and refresh tokens of the new session.
+func (s *Service) CreateSession(ctx context.Context, userID int64, userAgent, ipAddress string, mfaVerified bool) (Tokens, error) {
+	secret, hash, err := newSecret()
+	if err != nil {
+		return Tokens{}, apierror.NewInternalServerError("Failed to start session")
+	}
+	expiresAt := time.Now().Add(s.sessionTTL)
+	session, err := s.repo.CreateSession(ctx, sqlc.CreateSessionParams{
+		UserID:           userID,
+		RefreshTokenHash: hash,
+		UserAgent:        userAgent,
+		IpAddress:        ipAddress,
+		ExpiresAt:        pgtype.Timestamptz{Time: expiresAt, Valid: true},
+		MfaVerified:      mfaVerified,
+	})
+	if err != nil {
+		log.Printf("Failed to create session for user %d: %v", userID, err)
+		return Tokens{}, apierror.NewInternalServerError("Failed to start session")
+	}
+
+	return s.issueTokens(session, refreshToken(session.ID, secret), expiresAt)
+}
+
+// Refresh exchanges a refresh token for a new access token and rotates the
+// refresh token. The refresh token a rotation replaced is accepted during
+// rotationGracePeriod, without rotating again. Presenting any older refresh
+// token means it was stolen and replayed, so the session is revoked.
+func (s *Service) Refresh(ctx context.Context, token string) (Tokens, error) {
+	sessionID, secret, ok := parseRefreshToken(token)
+	if !ok {
+		return Tokens{}, ErrInvalidRefreshToken
+	}
+	session, err := s.repo.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Tokens{}, ErrInvalidRefreshToken
+		}
+		return Tokens{}, apierror.NewInternalServerError("Failed to refresh session")
+	}
+	if session.RevokedAt.Valid || !session.ExpiresAt.Time.After(time.Now()) {
+		return Tokens{}, ErrInvalidRefreshToken
+	}
+
+	hash := hashSecret(secret)
+	if sameHash(hash, session.RefreshTokenHash) {
+		nextSecret, nextHash, err := newSecret()
+		if err != nil {
+			return Tokens{}, apierror.NewInternalServerError("Failed to refresh session")
+		}
+		expiresAt := time.Now().Add(s.sessionTTL)
+		rotated, err := s.repo.RotateSessionRefreshToken(ctx, sqlc.RotateSessionRefreshTokenParams{
+			NewRefreshTokenHash: nextHash,
+			ExpiresAt:           pgtype.Timestamptz{Time: expiresAt, Valid: true},
+			ID:                  session.ID,
+			RefreshTokenHash:    hash,
+		})
+		if err != nil {
+			log.Printf("Failed to rotate the refresh token of session %s: %v", session.ID, err)
+			return Tokens{}, apierror.NewInternalServerError("Failed to refresh session")
+		}
+		if rotated == 1 {
+			return s.issueTokens(session, refreshToken(session.ID, nextSecret), expiresAt)
+		}
+		// A concurrent refresh rotated the token first, it is now the previous one
+		return s.issueTokens(session, "", session.ExpiresAt.Time)
+	}
+	if session.PreviousRefreshTokenHash.Valid && sameHash(hash, session.PreviousRefreshTokenHash.String) &&
+		time.Since(session.RotatedAt.Time) < rotationGracePeriod {
+		return s.issueTokens(session, "", session.ExpiresAt.Time)
+	}
+
+	if _, err := s.repo.RevokeSession(ctx, sqlc.RevokeSessionParams{ID: session.ID, UserID: session.UserID}); err != nil {
+		log.Printf("Failed to revoke session %s after its refresh token was reused: %v", session.ID, err)
+		return Tokens{}, apierror.NewInternalServerError("Failed to refresh session")
+	}
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   session.UserID,
+		Action:   "REFRESH_TOKEN_REUSED",
+		TargetID: session.ID,
+	})
+	return Tokens{}, ErrInvalidRefreshToken
+}
+
+// issueTokens signs an access token for a session. The access token is a JWT
+// with the user ID, the session ID and whether the login of the session
+// answered a two-factor authentication challenge, checked by the AuthMiddleware.
+func (s *Service) issueTokens(session sqlc.Session, refresh string, refreshExpiresAt time.Time) (Tokens, error) {
+	expiresAt := time.Now().Add(s.accessTTL)
+	claims := jwt.MapClaims{
+		"user_id": session.UserID,
+		"sid":     session.ID.String(),
+		"mfa":     session.MfaVerified,
+		"exp":     expiresAt.Unix(),
+	}
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.jwtSecret)
+	if err != nil {
+		return Tokens{}, apierror.NewInternalServerError("Failed to generate token")
+	}
+
+	return Tokens{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  expiresAt,
+		RefreshToken:     refresh,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// ValidateSession checks that a session of the user is neither revoked nor
+// expired. The AuthMiddleware calls it for every access token, so revoking a
+// session logs it out at once rather than when its access token expires.
+func (s *Service) ValidateSession(ctx context.Context, userID int64, sessionID uuid.UUID) error {
+	session, err := s.repo.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrSessionEnded
+		}
+		return err
+	}
+	if session.UserID != userID || session.RevokedAt.Valid || !session.ExpiresAt.Time.After(time.Now()) {
+		return ErrSessionEnded
+	}
+	return nil
+}
+
+// EndSession revokes the session of a refresh token when its user logs out.
+// Tokens that are invalid or belong to sessions that already ended are ignored.
+func (s *Service) EndSession(ctx context.Context, token string) error {
+	sessionID, secret, ok := parseRefreshToken(token)
+	if !ok {
+		return nil
+	}
+	session, err := s.repo.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	hash := hashSecret(secret)
+	if !sameHash(hash, session.RefreshTokenHash) &&
+		!(session.PreviousRefreshTokenHash.Valid && sameHash(hash, session.PreviousRefreshTokenHash.String)) {
+		return nil
+	}
+
+	revoked, err := s.repo.RevokeSession(ctx, sqlc.RevokeSessionParams{ID: session.ID, UserID: session.UserID})
+	if err != nil {
+		return err
+	}
+	if revoked > 0 {
+		s.audit.Log(ctx, audit.LogParams{
+			UserID:   session.UserID,
+			Action:   "USER_LOGGED_OUT",
+			TargetID: session.ID,
+		})
+	}
+	return nil
+}
+
+// ListSessions returns the active sessions of the authenticated user, most
+// recently used first, marking the one of the request as current.
+func (s *Service) ListSessions(ctx context.Context) ([]Session, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return nil, apierror.NewUnauthorizedError()
+	}
+	currentID, _ := userctx.GetSessionID(ctx)
+
+	rows, err := s.repo.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, apierror.NewInternalServerError("Failed to list sessions")
+	}
+	sessions := make([]Session, len(rows))
+	for i, row := range rows {
+		sessions[i] = Session{
+			ID:         row.ID,
+			UserAgent:  row.UserAgent,
+			IPAddress:  row.IpAddress,
+			CreatedAt:  row.CreatedAt.Time,
+			LastUsedAt: row.LastUsedAt.Time,
+			ExpiresAt:  row.ExpiresAt.Time,
+			Current:    row.ID == currentID,
+		}
+	}
+	return sessions, nil
+}
+
+// RevokeSession revokes a session of the authenticated user, logging that
+// device out at once.
+func (s *Service) RevokeSession(ctx context.Context, sessionID uuid.UUID) error {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return apierror.NewUnauthorizedError()
+	}
+
+	revoked, err := s.repo.RevokeSession(ctx, sqlc.RevokeSessionParams{ID: sessionID, UserID: userID})
+	if err != nil {
+		return apierror.NewInternalServerError("Failed to revoke session")
+	}
+	if revoked == 0 {
+		return apierror.NewNotFoundError("Session")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "SESSION_REVOKED",
+		TargetID: sessionID,
+	})
+	return nil
+}
+
+// RevokeAllSessions revokes every session of the authenticated user, the
+// current one included, logging them out everywhere.
+// Returns the number of revoked sessions.
+func (s *Service) RevokeAllSessions(ctx context.Context) (int64, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return 0, apierror.NewUnauthorizedError()
+	}
+
+	revoked, err := s.repo.RevokeAllSessions(ctx, userID)
+	if err != nil {
+		return 0, apierror.NewInternalServerError("Failed to revoke sessions")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID: userID,
+		Action: "SESSION_REVOKED",
+		Details: map[string]interface{}{
+			"all":     true,
+			"revoked": revoked,
+		},
+	})
+	return revoked, nil
+}
+
+// RunSessionCleaner deletes the sessions that expired or were revoked longer
+// than retention ago, checking every interval until ctx is done.
+func (s *Service) RunSessionCleaner(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		before := pgtype.Timestamptz{Time: time.Now().Add(-retention), Valid: true}
+		deleted, err := s.repo.DeleteStaleSessions(ctx, before)
+		if err != nil {
+			log.Printf("Failed to delete stale sessions: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Deleted %d stale sessions", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
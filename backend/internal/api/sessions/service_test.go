@@ -0,0 +1,256 @@
+package sessions_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/middleware"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/sessions"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/config"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/memdb"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "secret"
+
+type nopAudit struct{}
+
+func (nopAudit) Log(ctx context.Context, params audit.LogParams) {}
+
+type testEnv struct {
+	db      *memdb.DB
+	service *sessions.Service
+	router  http.Handler
+}
+
+// newTestEnv returns a sessions service and a router serving the session
+// routes behind the AuthMiddleware, as NewServer does, along with /me which
+// answers 200 to authenticated requests.
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	db := memdb.New()
+	cfg := &config.Config{Server: config.ServerConfig{AccessTokenTTLMinutes: 15, SessionTTLDays: 30}}
+	service := sessions.NewService(db, testSecret, cfg, nopAudit{})
+	handler := sessions.NewHandler(service)
+
+	router := chi.NewRouter()
+	router.Post("/auth/logout", handler.Logout)
+	handler.RegisterPublicRoutes(router)
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware(testSecret, service, nil))
+		r.Get("/me", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+		handler.RegisterRoutes(r)
+	})
+	return &testEnv{db: db, service: service, router: router}
+}
+
+// login starts a session for the user with the given email, creating the
+// user on their first login, and returns the user ID and the tokens.
+func (env *testEnv) login(t *testing.T, email, userAgent string) (int64, sessions.Tokens) {
+	t.Helper()
+	user, err := env.db.GetUserByEmail(context.Background(), email)
+	if err != nil {
+		created, err := env.db.CreateUser(context.Background(), email, email, "hash", 1<<20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		user = &created
+	}
+	tokens, err := env.service.CreateSession(context.Background(), user.ID, userAgent, "192.0.2.1", false)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	return user.ID, tokens
+}
+
+// do sends a request with the cookies of tokens and returns the response.
+func (env *testEnv) do(method, path string, tokens sessions.Tokens) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if tokens.AccessToken != "" {
+		req.AddCookie(&http.Cookie{Name: sessions.AccessCookie, Value: tokens.AccessToken})
+	}
+	if tokens.RefreshToken != "" {
+		req.AddCookie(&http.Cookie{Name: sessions.RefreshCookie, Value: tokens.RefreshToken})
+	}
+	rec := httptest.NewRecorder()
+	env.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// cookie returns the value of a cookie set by a response, and whether it was set.
+func cookie(rec *httptest.ResponseRecorder, name string) (string, bool) {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == name {
+			return c.Value, true
+		}
+	}
+	return "", false
+}
+
+// TestRefreshRotation rotates a refresh token, replays it during the grace
+// period and after it, and checks the replay after it ends the session.
+func TestRefreshRotation(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	_, first := env.login(t, "user@example.com", "laptop")
+
+	second, err := env.service.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if second.RefreshToken == "" || second.RefreshToken == first.RefreshToken {
+		t.Fatalf("refresh token after rotation = %q, want a new one", second.RefreshToken)
+	}
+
+	// a concurrent refresh with the token just rotated gets an access token only
+	concurrent, err := env.service.Refresh(ctx, first.RefreshToken)
+	if err != nil || concurrent.AccessToken == "" || concurrent.RefreshToken != "" {
+		t.Fatalf("Refresh with the previous token = %+v, %v, want an access token only", concurrent, err)
+	}
+
+	third, err := env.service.Refresh(ctx, second.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if rec := env.do(http.MethodGet, "/me", third); rec.Code != http.StatusOK {
+		t.Fatalf("request with the refreshed access token status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	// the first token is two rotations old, so it was stolen
+	if _, err := env.service.Refresh(ctx, first.RefreshToken); !errors.Is(err, sessions.ErrInvalidRefreshToken) {
+		t.Fatalf("Refresh with a reused token error = %v, want %v", err, sessions.ErrInvalidRefreshToken)
+	}
+	if _, err := env.service.Refresh(ctx, third.RefreshToken); !errors.Is(err, sessions.ErrInvalidRefreshToken) {
+		t.Errorf("Refresh after reuse error = %v, want %v", err, sessions.ErrInvalidRefreshToken)
+	}
+	if rec := env.do(http.MethodGet, "/me", third); rec.Code != http.StatusUnauthorized {
+		t.Errorf("request after reuse status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	for _, token := range []string{"", "not-a-token", "00000000-0000-0000-0000-000000000000.secret"} {
+		if _, err := env.service.Refresh(ctx, token); !errors.Is(err, sessions.ErrInvalidRefreshToken) {
+			t.Errorf("Refresh(%q) error = %v, want %v", token, err, sessions.ErrInvalidRefreshToken)
+		}
+	}
+}
+
+func TestAccessTokens(t *testing.T) {
+	env := newTestEnv(t)
+	userID, tokens := env.login(t, "user@example.com", "laptop")
+
+	sign := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	claims, _, err := jwt.NewParser().ParseUnverified(tokens.AccessToken, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sid := claims.Claims.(jwt.MapClaims)["sid"]
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{name: "valid", token: tokens.AccessToken, wantStatus: http.StatusOK},
+		{name: "without session", token: sign(jwt.MapClaims{"user_id": userID, "exp": time.Now().Add(time.Hour).Unix()}), wantStatus: http.StatusUnauthorized},
+		{name: "other user", token: sign(jwt.MapClaims{"user_id": userID + 1, "sid": sid, "exp": time.Now().Add(time.Hour).Unix()}), wantStatus: http.StatusUnauthorized},
+		{name: "expired", token: sign(jwt.MapClaims{"user_id": userID, "sid": sid, "exp": time.Now().Add(-time.Minute).Unix()}), wantStatus: http.StatusUnauthorized},
+		{name: "missing", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := env.do(http.MethodGet, "/me", sessions.Tokens{AccessToken: tt.token}); rec.Code != tt.wantStatus {
+				t.Errorf("status = %d (%s), want %d", rec.Code, rec.Body, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// TestSessionRoutes refreshes, lists and revokes sessions over HTTP.
+func TestSessionRoutes(t *testing.T) {
+	env := newTestEnv(t)
+	userID, laptop := env.login(t, "user@example.com", "laptop")
+	_, phone := env.login(t, "user@example.com", "phone")
+	_, tablet := env.login(t, "user@example.com", "tablet")
+	_, stranger := env.login(t, "stranger@example.com", "laptop")
+
+	rec := env.do(http.MethodPost, "/auth/refresh", sessions.Tokens{RefreshToken: laptop.RefreshToken})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh status = %d (%s), want %d", rec.Code, rec.Body, http.StatusOK)
+	}
+	laptop.AccessToken, _ = cookie(rec, sessions.AccessCookie)
+	laptop.RefreshToken, _ = cookie(rec, sessions.RefreshCookie)
+	if rec := env.do(http.MethodPost, "/auth/refresh", sessions.Tokens{}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("refresh without a cookie status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	rec = env.do(http.MethodGet, "/auth/sessions", laptop)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list status = %d (%s), want %d", rec.Code, rec.Body, http.StatusOK)
+	}
+	var phoneID, strangerID string
+	for _, s := range env.db.Sessions() {
+		switch {
+		case s.UserID != userID:
+			strangerID = s.ID.String()
+		case s.UserAgent == "phone":
+			phoneID = s.ID.String()
+		}
+	}
+	if body := rec.Body.String(); !jsonHas(body, `"user_agent":"laptop"`, `"current":true`, phoneID) || jsonHas(body, strangerID) {
+		t.Errorf("sessions = %s, want the three sessions of the user with the laptop current", body)
+	}
+
+	if rec := env.do(http.MethodDelete, "/auth/sessions/"+strangerID, laptop); rec.Code != http.StatusNotFound {
+		t.Errorf("revoking another user's session status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := env.do(http.MethodDelete, "/auth/sessions/"+phoneID, laptop); rec.Code != http.StatusNoContent {
+		t.Fatalf("revoke status = %d (%s), want %d", rec.Code, rec.Body, http.StatusNoContent)
+	}
+	if rec := env.do(http.MethodGet, "/me", phone); rec.Code != http.StatusUnauthorized {
+		t.Errorf("request from the revoked session status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := env.do(http.MethodPost, "/auth/refresh", phone); rec.Code != http.StatusUnauthorized {
+		t.Errorf("refresh of the revoked session status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	rec = env.do(http.MethodPost, "/auth/logout", tablet)
+	if value, ok := cookie(rec, sessions.RefreshCookie); !ok || value != "" {
+		t.Errorf("refresh cookie after logout = %q, want it cleared", value)
+	}
+	if rec := env.do(http.MethodGet, "/me", tablet); rec.Code != http.StatusUnauthorized {
+		t.Errorf("request after logout status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	rec = env.do(http.MethodDelete, "/auth/sessions", laptop)
+	if rec.Code != http.StatusOK || !jsonHas(rec.Body.String(), `"revoked":1`) {
+		t.Fatalf("revoke all = %d %s, want the laptop session revoked", rec.Code, rec.Body)
+	}
+	if rec := env.do(http.MethodGet, "/me", laptop); rec.Code != http.StatusUnauthorized {
+		t.Errorf("request after revoking all status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := env.do(http.MethodGet, "/me", stranger); rec.Code != http.StatusOK {
+		t.Errorf("request from another user's session status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func jsonHas(body string, parts ...string) bool {
+	for _, part := range parts {
+		if !strings.Contains(body, part) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,36 @@
+package sessions
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session describes a login on one device for API responses.
+// UserAgent and IPAddress: the browser and address the user logged in from.
+// LastUsedAt: when the session was last refreshed.
+// ExpiresAt: when the session ends unless it is refreshed.
+// Current: whether this is the session of the request.
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// Tokens are the tokens issued to a session on login and on refresh.
+// RefreshToken is empty when the refresh token the client holds stays valid.
+type Tokens struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// RevokeAllResponse reports how many sessions were revoked.
+type RevokeAllResponse struct {
+	Revoked int64 `json:"revoked"`
+}
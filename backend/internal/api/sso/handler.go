@@ -0,0 +1,169 @@
+package sso
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apphandler"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/mfa"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/sessions"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/util"
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// StateCookie holds the state, nonce and PKCE verifier of a login in
+	// progress, binding the callback to the browser that started the login.
+	StateCookie = "oidc_login"
+	// stateCookiePath limits the state cookie to the login and callback routes.
+	stateCookiePath = "/auth/oidc"
+	// loginTimeout is how long the user has to log in at the identity provider.
+	loginTimeout = 10 * time.Minute
+)
+
+// Handler is the HTTP handler for single sign-on endpoints.
+type Handler struct {
+	service  *Service
+	sessions *sessions.Service
+	mfa      *mfa.Service
+}
+
+// NewHandler creates a new sso Handler with the given service, the sessions
+// service starting a session once the user logged in, and the mfa service
+// asking users with two-factor authentication for a code first.
+func NewHandler(service *Service, sessionService *sessions.Service, mfaService *mfa.Service) *Handler {
+	return &Handler{service: service, sessions: sessionService, mfa: mfaService}
+}
+
+// RegisterPublicRoutes registers the single sign-on routes, which are
+// reached by the browser before it has a session.
+func (h *Handler) RegisterPublicRoutes(r chi.Router) {
+	r.Get("/auth/oidc/login", apphandler.MakeHTTPHandler(h.Login))
+	r.Get("/auth/oidc/callback", h.Callback)
+}
+
+// Login handles GET /auth/oidc/login.
+// It redirects the browser to the identity provider, remembering the state,
+// nonce and PKCE verifier of the login in a cookie.
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) error {
+	if !h.service.Enabled() {
+		return ErrDisabled
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return apierror.NewInternalServerError("Failed to start login")
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return apierror.NewInternalServerError("Failed to start login")
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	authURL, err := h.service.AuthCodeURL(r.Context(), state, nonce, verifier)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     StateCookie,
+		Value:    strings.Join([]string{state, nonce, verifier}, "."),
+		Path:     stateCookiePath,
+		MaxAge:   int(loginTimeout.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		// The identity provider sends the browser back with a top-level
+		// navigation, which carries Lax cookies.
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+	return nil
+}
+
+// Callback handles GET /auth/oidc/callback.
+// It logs the user in with the authorization code sent by the identity
+// provider and redirects the browser to the frontend, to the login page with
+// an error message if the login failed.
+func (h *Handler) Callback(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(StateCookie)
+	if err != nil {
+		h.fail(w, r, apierror.New(http.StatusBadRequest, "Login expired, please try again"))
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     StateCookie,
+		Value:    "",
+		Path:     stateCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	query := r.URL.Query()
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 || subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(parts[0])) != 1 {
+		h.fail(w, r, apierror.New(http.StatusBadRequest, "Login expired, please try again"))
+		return
+	}
+	if errCode := query.Get("error"); errCode != "" {
+		log.Printf("Identity provider refused login: %s %s", errCode, query.Get("error_description"))
+		h.fail(w, r, ErrLoginFailed)
+		return
+	}
+
+	userID, err := h.service.Login(r.Context(), query.Get("code"), parts[1], parts[2])
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	// Users with two-factor authentication answer a challenge on the login page, as after a password login
+	challenge, required, err := h.mfa.StartChallenge(r.Context(), userID)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	if required {
+		http.Redirect(w, r, h.service.FrontendURL()+"/login?challenge="+url.QueryEscape(challenge.Challenge), http.StatusFound)
+		return
+	}
+
+	tokens, err := h.sessions.CreateSession(r.Context(), userID, r.UserAgent(), util.ClientIP(r), false)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	sessions.SetCookies(w, r, tokens)
+	http.Redirect(w, r, h.service.FrontendURL()+"/dashboard", http.StatusFound)
+}
+
+// fail redirects the browser to the frontend login page, which shows the
+// message of err.
+func (h *Handler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	message := "Single sign-on failed"
+	var apiErr *apierror.APIError
+	if errors.As(err, &apiErr) {
+		message = apiErr.Message
+	}
+	http.Redirect(w, r, h.service.FrontendURL()+"/login?error="+url.QueryEscape(message), http.StatusFound)
+}
+
+// randomString returns 32 random bytes, base64url encoded.
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
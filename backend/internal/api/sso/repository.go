@@ -0,0 +1,109 @@
+package sso
+
+import (
+	"context"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+)
+
+// Repository describes the database operations used by the sso service.
+// It is implemented on top of sqlc queries by NewRepository.
+type Repository interface {
+	GetUserIdentity(ctx context.Context, arg sqlc.GetUserIdentityParams) (sqlc.UserIdentity, error)
+	CreateUserIdentity(ctx context.Context, arg sqlc.CreateUserIdentityParams) (sqlc.UserIdentity, error)
+	GetUserByID(ctx context.Context, userID int64) (sqlc.User, error)
+	GetUserByEmailFold(ctx context.Context, email string) (sqlc.User, error)
+	UserNameExists(ctx context.Context, name string) (bool, error)
+	CreateUser(ctx context.Context, email, name string, passwordHash string, defaultStorageQuota int64) (sqlc.User, error)
+	UpdateUserRole(ctx context.Context, arg sqlc.UpdateUserRoleParams) error
+	UpdateUserPassword(ctx context.Context, arg sqlc.UpdateUserPasswordParams) error
+	VerifyUserEmail(ctx context.Context, userID int64) (int64, error)
+	RevokeAllSessions(ctx context.Context, userID int64) (int64, error)
+	RevokeAllPersonalAccessTokens(ctx context.Context, userID int64) (int64, error)
+	DeleteUserMFA(ctx context.Context, userID int64) error
+	DeleteMFARecoveryCodes(ctx context.Context, userID int64) error
+}
+
+// repository handles database operations related to single sign-on, backed by sqlc queries.
+type repository struct {
+	queries *sqlc.Queries
+}
+
+// NewRepository creates a new Repository instance with the provided database queries.
+func NewRepository(db *sqlc.Queries) Repository {
+	return &repository{
+		queries: db,
+	}
+}
+
+// GetUserIdentity returns the identity with the given issuer and subject.
+func (r *repository) GetUserIdentity(ctx context.Context, arg sqlc.GetUserIdentityParams) (sqlc.UserIdentity, error) {
+	return r.queries.GetUserIdentity(ctx, arg)
+}
+
+// CreateUserIdentity links an identity provider account to a user.
+func (r *repository) CreateUserIdentity(ctx context.Context, arg sqlc.CreateUserIdentityParams) (sqlc.UserIdentity, error) {
+	return r.queries.CreateUserIdentity(ctx, arg)
+}
+
+// GetUserByID retrieves a user by their unique ID.
+func (r *repository) GetUserByID(ctx context.Context, userID int64) (sqlc.User, error) {
+	return r.queries.GetUserByID(ctx, userID)
+}
+
+// GetUserByEmailFold retrieves a user by their email address, ignoring case.
+func (r *repository) GetUserByEmailFold(ctx context.Context, email string) (sqlc.User, error) {
+	return r.queries.GetUserByEmailFold(ctx, email)
+}
+
+// UserNameExists reports whether a user already has the given name.
+func (r *repository) UserNameExists(ctx context.Context, name string) (bool, error) {
+	return r.queries.UserNameExists(ctx, name)
+}
+
+// CreateUser creates a new user record with the provided email, name, password hash
+// and default storage quota.
+func (r *repository) CreateUser(ctx context.Context, email, name string, passwordHash string, defaultStorageQuota int64) (sqlc.User, error) {
+	return r.queries.CreateUser(ctx, sqlc.CreateUserParams{
+		Email:        email,
+		Name:         name,
+		Password:     passwordHash,
+		StorageQuota: defaultStorageQuota,
+	})
+}
+
+// UpdateUserRole changes the role of a user.
+func (r *repository) UpdateUserRole(ctx context.Context, arg sqlc.UpdateUserRoleParams) error {
+	return r.queries.UpdateUserRole(ctx, arg)
+}
+
+// UpdateUserPassword replaces the password hash of a user.
+func (r *repository) UpdateUserPassword(ctx context.Context, arg sqlc.UpdateUserPasswordParams) error {
+	return r.queries.UpdateUserPassword(ctx, arg)
+}
+
+// VerifyUserEmail marks the email address of a user as verified.
+// Returns 0 if it already was.
+func (r *repository) VerifyUserEmail(ctx context.Context, userID int64) (int64, error) {
+	return r.queries.VerifyUserEmail(ctx, userID)
+}
+
+// RevokeAllSessions revokes every active session of a user.
+func (r *repository) RevokeAllSessions(ctx context.Context, userID int64) (int64, error) {
+	return r.queries.RevokeAllSessions(ctx, userID)
+}
+
+// RevokeAllPersonalAccessTokens revokes every active personal access token of a user.
+func (r *repository) RevokeAllPersonalAccessTokens(ctx context.Context, userID int64) (int64, error) {
+	return r.queries.RevokeAllPersonalAccessTokens(ctx, userID)
+}
+
+// DeleteUserMFA removes the secret of a user, disabling two-factor authentication.
+func (r *repository) DeleteUserMFA(ctx context.Context, userID int64) error {
+	return r.queries.DeleteUserMFA(ctx, userID)
+}
+
+// DeleteMFARecoveryCodes deletes every recovery code of a user, used or not.
+func (r *repository) DeleteMFARecoveryCodes(ctx context.Context, userID int64) error {
+	return r.queries.DeleteMFARecoveryCodes(ctx, userID)
+}
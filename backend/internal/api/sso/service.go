@@ -0,0 +1,403 @@
+// Package sso logs users in with an OpenID Connect identity provider, using
+// the authorization code flow with PKCE. Users are created on their first
+// login, or linked to the account with their verified email address.
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/config"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/oauth2"
+)
+
+var (
+	// ErrDisabled is returned when no identity provider is configured.
+	ErrDisabled = apierror.New(http.StatusNotFound, "Single sign-on is not enabled")
+	// ErrEmailNotVerified is returned when the identity provider does not
+	// vouch for the email address of the user, which is then not trusted to
+	// find or create their account.
+	ErrEmailNotVerified = apierror.New(http.StatusForbidden, "Your identity provider has not verified your email address")
+	// ErrLoginFailed is returned when the code exchange or the ID token is rejected.
+	ErrLoginFailed = apierror.New(http.StatusUnauthorized, "Single sign-on failed")
+)
+
+// claims are the ID token claims used to find or create the user.
+type claims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// provider is the discovered identity provider.
+type provider struct {
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// Service handles logins through the identity provider.
+type Service struct {
+	repo                Repository
+	cfg                 config.OIDCConfig
+	defaultStorageQuota int64
+	frontendURL         string
+	audit               audit.Service
+
+	mu       sync.Mutex
+	provider *provider
+}
+
+// NewService creates a new instance of the sso Service.
+// - repo: repository providing database operations for users and their identities.
+// - cfg: configuration struct containing the identity provider settings, the default storage quota and the frontend URL.
+// - auditService: service used to record logins, linked identities and role changes.
+func NewService(repo Repository, cfg *config.Config, auditService audit.Service) *Service {
+	return &Service{
+		repo:                repo,
+		cfg:                 cfg.OIDC,
+		defaultStorageQuota: cfg.Server.DefaultStorageQuota,
+		frontendURL:         cfg.Server.FrontendURL,
+		audit:               auditService,
+	}
+}
+
+// Enabled reports whether an identity provider is configured.
+func (s *Service) Enabled() bool {
+	return s.cfg.IssuerURL != ""
+}
+
+// FrontendURL returns the URL the browser is sent to after logging in.
+func (s *Service) FrontendURL() string {
+	return s.frontendURL
+}
+
+// getProvider returns the identity provider, discovering its endpoints and
+// keys on first use so the API starts even when the provider is unreachable.
+// A failed discovery is retried on the next login.
+func (s *Service) getProvider(ctx context.Context) (*provider, error) {
+	if !s.Enabled() {
+		return nil, ErrDisabled
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.provider != nil {
+		return s.provider, nil
+	}
+
+	discovered, err := oidc.NewProvider(ctx, s.cfg.IssuerURL)
+	if err != nil {
+		log.Printf("Failed to discover identity provider %s: %v", s.cfg.IssuerURL, err)
+		return nil, apierror.New(http.StatusBadGateway, "Identity provider is unavailable")
+	}
+	scopes := []string{oidc.ScopeOpenID}
+	for _, scope := range s.cfg.Scopes {
+		if scope != oidc.ScopeOpenID {
+			scopes = append(scopes, scope)
+		}
+	}
+	s.provider = &provider{
+		oauth2: oauth2.Config{
+			ClientID:     s.cfg.ClientID,
+			ClientSecret: s.cfg.ClientSecret,
+			RedirectURL:  s.cfg.RedirectURL,
+			Endpoint:     discovered.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier: discovered.Verifier(&oidc.Config{ClientID: s.cfg.ClientID}),
+	}
+	return s.provider, nil
+}
+
+// AuthCodeURL returns the URL of the identity provider login page. The state
+// and nonce are echoed back to the callback and in the ID token, and the PKCE
+// verifier is needed to exchange the code.
+func (s *Service) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	p, err := s.getProvider(ctx)
+	if err != nil {
+		return "", err
+	}
+	return p.oauth2.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// Login exchanges the authorization code for an ID token and logs its user in.
+// - The user is found by the issuer and subject of the ID token.
+// - Failing that, the identity is linked to the user with its verified email address.
+// - Failing that, a user without a password is created.
+// - Either way, the email address of the user is verified.
+// - The role of the user follows their groups when admin groups are configured.
+// Returns the ID of the user.
+func (s *Service) Login(ctx context.Context, code, nonce, verifier string) (int64, error) {
+	p, err := s.getProvider(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	token, err := p.oauth2.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		log.Printf("Failed to exchange authorization code: %v", err)
+		return 0, ErrLoginFailed
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		log.Printf("Identity provider returned no ID token")
+		return 0, ErrLoginFailed
+	}
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		log.Printf("Failed to verify ID token: %v", err)
+		return 0, ErrLoginFailed
+	}
+	if idToken.Nonce != nonce {
+		log.Printf("ID token nonce does not match the login")
+		return 0, ErrLoginFailed
+	}
+
+	var c claims
+	if err := idToken.Claims(&c); err != nil {
+		log.Printf("Failed to parse ID token claims: %v", err)
+		return 0, ErrLoginFailed
+	}
+	user, err := s.findOrCreateUser(ctx, idToken.Issuer, idToken.Subject, c)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(s.cfg.AdminGroups) > 0 {
+		groups, err := s.groups(idToken)
+		if err != nil {
+			log.Printf("Failed to parse %s claim: %v", s.cfg.GroupsClaim, err)
+			return 0, ErrLoginFailed
+		}
+		if err := s.syncRole(ctx, user, groups); err != nil {
+			return 0, err
+		}
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID: user.ID,
+		Action: "USER_LOGGED_IN",
+		Details: map[string]interface{}{
+			"method": "oidc",
+			"issuer": idToken.Issuer,
+		},
+	})
+	return user.ID, nil
+}
+
+// findOrCreateUser returns the user an identity belongs to, linking the
+// identity to a user on its first login.
+func (s *Service) findOrCreateUser(ctx context.Context, issuer, subject string, c claims) (sqlc.User, error) {
+	identity, err := s.repo.GetUserIdentity(ctx, sqlc.GetUserIdentityParams{Issuer: issuer, Subject: subject})
+	if err == nil {
+		user, err := s.repo.GetUserByID(ctx, identity.UserID)
+		if err != nil {
+			return sqlc.User{}, apierror.NewInternalServerError("Failed to fetch user")
+		}
+		return user, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return sqlc.User{}, apierror.NewInternalServerError("Failed to fetch identity")
+	}
+
+	// The email address is only trusted to pick the account once the identity provider verified it
+	if c.Email == "" || !c.EmailVerified {
+		return sqlc.User{}, ErrEmailNotVerified
+	}
+
+	user, err := s.repo.GetUserByEmailFold(ctx, c.Email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		user, err = s.createUser(ctx, c)
+		if err != nil {
+			return sqlc.User{}, err
+		}
+	} else if err != nil {
+		return sqlc.User{}, apierror.NewInternalServerError("Failed to fetch user")
+	}
+	if !user.EmailVerifiedAt.Valid {
+		if err := s.claimUnverified(ctx, user); err != nil {
+			return sqlc.User{}, err
+		}
+	}
+
+	if _, err := s.repo.CreateUserIdentity(ctx, sqlc.CreateUserIdentityParams{
+		UserID:  user.ID,
+		Issuer:  issuer,
+		Subject: subject,
+		Email:   c.Email,
+	}); err != nil {
+		log.Printf("Failed to link identity %s of %s to user %d: %v", subject, issuer, user.ID, err)
+		return sqlc.User{}, apierror.NewInternalServerError("Failed to link identity")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID: user.ID,
+		Action: "IDENTITY_LINKED",
+		Details: map[string]interface{}{
+			"issuer":  issuer,
+			"subject": subject,
+		},
+	})
+	return user, nil
+}
+
+// createUser creates the user of an identity seen for the first time. The
+// user has no password, so they can only log in through the identity provider.
+func (s *Service) createUser(ctx context.Context, c claims) (sqlc.User, error) {
+	name, err := s.uniqueName(ctx, c)
+	if err != nil {
+		return sqlc.User{}, err
+	}
+
+	user, err := s.repo.CreateUser(ctx, c.Email, name, "", s.defaultStorageQuota)
+	if err != nil {
+		log.Printf("Failed to create user for %s: %v", c.Email, err)
+		return sqlc.User{}, apierror.NewInternalServerError("Failed to create user")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID: user.ID,
+		Action: "USER_REGISTERED",
+		Details: map[string]interface{}{
+			"email":  user.Email,
+			"method": "oidc",
+		},
+	})
+	return user, nil
+}
+
+// claimUnverified verifies the email address of a user, vouched for by the
+// identity provider. Someone else may have signed up with the address before
+// its owner, so everything they could have set up on an account that never
+// confirmed it is dropped: its password, sessions, personal access tokens and
+// two-factor enrollment. Only the identity provider logs in to it afterwards.
+func (s *Service) claimUnverified(ctx context.Context, user sqlc.User) error {
+	revokedSessions, err := s.repo.RevokeAllSessions(ctx, user.ID)
+	if err != nil {
+		log.Printf("Failed to revoke sessions of user %d: %v", user.ID, err)
+		return apierror.NewInternalServerError("Failed to link identity")
+	}
+	revokedTokens, err := s.repo.RevokeAllPersonalAccessTokens(ctx, user.ID)
+	if err != nil {
+		log.Printf("Failed to revoke personal access tokens of user %d: %v", user.ID, err)
+		return apierror.NewInternalServerError("Failed to link identity")
+	}
+	if err := s.repo.DeleteUserMFA(ctx, user.ID); err != nil {
+		log.Printf("Failed to remove two-factor authentication of user %d: %v", user.ID, err)
+		return apierror.NewInternalServerError("Failed to link identity")
+	}
+	if err := s.repo.DeleteMFARecoveryCodes(ctx, user.ID); err != nil {
+		log.Printf("Failed to delete recovery codes of user %d: %v", user.ID, err)
+		return apierror.NewInternalServerError("Failed to link identity")
+	}
+	if user.Password != "" {
+		if err := s.repo.UpdateUserPassword(ctx, sqlc.UpdateUserPasswordParams{ID: user.ID, Password: ""}); err != nil {
+			log.Printf("Failed to drop password of user %d: %v", user.ID, err)
+			return apierror.NewInternalServerError("Failed to link identity")
+		}
+	}
+	if _, err := s.repo.VerifyUserEmail(ctx, user.ID); err != nil {
+		log.Printf("Failed to verify email address of user %d: %v", user.ID, err)
+		return apierror.NewInternalServerError("Failed to link identity")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID: user.ID,
+		Action: "EMAIL_VERIFIED",
+		Details: map[string]interface{}{
+			"method":           "oidc",
+			"revoked_sessions": revokedSessions,
+			"revoked_tokens":   revokedTokens,
+		},
+	})
+	return nil
+}
+
+// uniqueName picks the name of a new user from their claims. User names are
+// unique, so the email address is added to a name that is already taken.
+func (s *Service) uniqueName(ctx context.Context, c claims) (string, error) {
+	name := strings.TrimSpace(c.Name)
+	if name == "" {
+		name = strings.TrimSpace(c.PreferredUsername)
+	}
+	if name == "" {
+		name, _, _ = strings.Cut(c.Email, "@")
+	}
+
+	exists, err := s.repo.UserNameExists(ctx, name)
+	if err != nil {
+		return "", apierror.NewInternalServerError("Failed to create user")
+	}
+	if exists {
+		name = fmt.Sprintf("%s (%s)", name, c.Email)
+	}
+	return name, nil
+}
+
+// groups returns the groups listed in the configured claim of an ID token,
+// which is either a list of groups or a single one.
+func (s *Service) groups(idToken *oidc.IDToken) ([]string, error) {
+	var all map[string]interface{}
+	if err := idToken.Claims(&all); err != nil {
+		return nil, err
+	}
+
+	switch value := all[s.cfg.GroupsClaim].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{value}, nil
+	case []interface{}:
+		groups := make([]string, 0, len(value))
+		for _, v := range value {
+			group, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected group %v", v)
+			}
+			groups = append(groups, group)
+		}
+		return groups, nil
+	default:
+		return nil, fmt.Errorf("unexpected type %T", value)
+	}
+}
+
+// syncRole makes the user an admin when they are in one of the admin groups,
+// and a regular user otherwise.
+func (s *Service) syncRole(ctx context.Context, user sqlc.User, groups []string) error {
+	role := "user"
+	if slices.ContainsFunc(groups, func(group string) bool { return slices.Contains(s.cfg.AdminGroups, group) }) {
+		role = "admin"
+	}
+	if role == user.Role {
+		return nil
+	}
+
+	if err := s.repo.UpdateUserRole(ctx, sqlc.UpdateUserRoleParams{ID: user.ID, Role: role}); err != nil {
+		log.Printf("Failed to change role of user %d: %v", user.ID, err)
+		return apierror.NewInternalServerError("Failed to update user role")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID: user.ID,
+		Action: "USER_ROLE_CHANGED",
+		Details: map[string]interface{}{
+			"from":   user.Role,
+			"to":     role,
+			"source": "oidc",
+		},
+	})
+	return nil
+}
@@ -0,0 +1,503 @@
+package sso_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/mfa"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/sessions"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/sso"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/tokens"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/config"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/memdb"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	clientID    = "filevault"
+	frontendURL = "http://app.test"
+	redirectURL = "http://api.test/auth/oidc/callback"
+)
+
+type nopAudit struct{}
+
+func (nopAudit) Log(ctx context.Context, params audit.LogParams) {}
+
+// mockIdP is an OpenID Connect provider. Its authorization endpoint logs in
+// whoever the test chose with next, and its token endpoint checks the PKCE
+// verifier before issuing their ID token.
+type mockIdP struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	next  jwt.MapClaims
+	codes map[string]grant
+}
+
+// grant is an authorization code issued by the mock provider.
+type grant struct {
+	claims    jwt.MapClaims
+	nonce     string
+	challenge string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{key: key, codes: make(map[string]grant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                idp.URL,
+			"authorization_endpoint":                idp.URL + "/authorize",
+			"token_endpoint":                        idp.URL + "/token",
+			"jwks_uri":                              idp.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"alg": "RS256",
+				"use": "sig",
+				"kid": "test",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("GET /authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("client_id") != clientID || q.Get("code_challenge_method") != "S256" || !strings.Contains(q.Get("scope"), "openid") {
+			http.Error(w, "invalid authorization request", http.StatusBadRequest)
+			return
+		}
+		code := rand.Text()
+		idp.mu.Lock()
+		idp.codes[code] = grant{claims: idp.next, nonce: q.Get("nonce"), challenge: q.Get("code_challenge")}
+		idp.mu.Unlock()
+		http.Redirect(w, r, q.Get("redirect_uri")+"?code="+code+"&state="+url.QueryEscape(q.Get("state")), http.StatusFound)
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		idp.mu.Lock()
+		g, ok := idp.codes[r.PostForm.Get("code")]
+		delete(idp.codes, r.PostForm.Get("code"))
+		idp.mu.Unlock()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss":   idp.URL,
+			"aud":   clientID,
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": g.nonce,
+		}
+		for k, v := range g.claims {
+			claims[k] = v
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "test"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idToken,
+		})
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+type testEnv struct {
+	db     *memdb.DB
+	idp    *mockIdP
+	mfa    *mfa.Service
+	router http.Handler
+	client *http.Client
+}
+
+func newTestEnv(t *testing.T, issuerURL string) *testEnv {
+	t.Helper()
+	db := memdb.New()
+	idp := newMockIdP(t)
+	if issuerURL == "" {
+		issuerURL = idp.URL
+	}
+	cfg := &config.Config{
+		Server: config.ServerConfig{DefaultStorageQuota: 1 << 20, AccessTokenTTLMinutes: 15, SessionTTLDays: 30, FrontendURL: frontendURL},
+		OIDC: config.OIDCConfig{
+			IssuerURL:   issuerURL,
+			ClientID:    clientID,
+			RedirectURL: redirectURL,
+			Scopes:      []string{"email", "profile"},
+			GroupsClaim: "groups",
+			AdminGroups: []string{"vault-admins"},
+		},
+	}
+	sessionService := sessions.NewService(db, "secret", cfg, nopAudit{})
+	mfaService := mfa.NewService(db, nopAudit{})
+	handler := sso.NewHandler(sso.NewService(db, cfg, nopAudit{}), sessionService, mfaService)
+
+	router := chi.NewRouter()
+	handler.RegisterPublicRoutes(router)
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	return &testEnv{db: db, idp: idp, mfa: mfaService, router: router, client: client}
+}
+
+// login goes through the login flow as the user with the given claims and
+// returns the response of the callback. tamper may change the callback
+// request before it is sent.
+func (env *testEnv) login(t *testing.T, claims jwt.MapClaims, tamper func(*http.Request)) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	env.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("login status = %d (%s), want %d", rec.Code, rec.Body, http.StatusFound)
+	}
+	var state *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == sso.StateCookie {
+			state = c
+		}
+	}
+	if state == nil {
+		t.Fatal("login did not set the state cookie")
+	}
+
+	env.idp.mu.Lock()
+	env.idp.next = claims
+	env.idp.mu.Unlock()
+	resp, err := env.client.Get(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback := resp.Header.Get("Location")
+	if !strings.HasPrefix(callback, redirectURL+"?") {
+		t.Fatalf("identity provider redirected to %q, want the callback", callback)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, callback, nil)
+	req.AddCookie(state)
+	if tamper != nil {
+		tamper(req)
+	}
+	rec = httptest.NewRecorder()
+	env.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// userWithEmail returns the user with the given email address.
+func (env *testEnv) userWithEmail(t *testing.T, email string) sqlc.User {
+	t.Helper()
+	user, err := env.db.GetUserByEmail(context.Background(), email)
+	if err != nil {
+		t.Fatalf("user %s: %v", email, err)
+	}
+	return *user
+}
+
+func loggedIn(t *testing.T, rec *httptest.ResponseRecorder) bool {
+	t.Helper()
+	if rec.Code != http.StatusFound {
+		t.Fatalf("callback status = %d (%s), want %d", rec.Code, rec.Body, http.StatusFound)
+	}
+	if rec.Header().Get("Location") != frontendURL+"/dashboard" {
+		return false
+	}
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == sessions.RefreshCookie && c.Value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// TestProvisioning logs in new users, returning users and users with an
+// account made with a password.
+func TestProvisioning(t *testing.T) {
+	env := newTestEnv(t, "")
+	ctx := context.Background()
+	alice := jwt.MapClaims{"sub": "alice-sub", "email": "alice@example.com", "email_verified": true, "name": "Alice"}
+
+	if rec := env.login(t, alice, nil); !loggedIn(t, rec) {
+		t.Fatalf("first login redirected to %q, want a session", rec.Header().Get("Location"))
+	}
+	user := env.userWithEmail(t, "alice@example.com")
+	if user.Name != "Alice" || user.Password != "" || user.StorageQuota != 1<<20 || !user.EmailVerifiedAt.Valid {
+		t.Errorf("provisioned user = %+v, want Alice without a password and with a verified email", user)
+	}
+
+	// the identity finds the user even after the email changes at the provider
+	alice["email"] = "alice@new.example.com"
+	if rec := env.login(t, alice, nil); !loggedIn(t, rec) {
+		t.Fatalf("second login redirected to %q, want a session", rec.Header().Get("Location"))
+	}
+	if got := len(env.db.Identities()); got != 1 {
+		t.Errorf("identities after second login = %d, want 1", got)
+	}
+
+	// an account made with a password is linked by its verified email, whatever the case
+	bob, err := env.db.CreateUser(ctx, "bob@example.com", "Bob", "hash", 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := env.login(t, jwt.MapClaims{"sub": "bob-sub", "email": "Bob@Example.com", "email_verified": true, "name": "Bob"}, nil); !loggedIn(t, rec) {
+		t.Fatalf("login of an existing user redirected to %q, want a session", rec.Header().Get("Location"))
+	}
+	identity, err := env.db.GetUserIdentity(ctx, sqlc.GetUserIdentityParams{Issuer: env.idp.URL, Subject: "bob-sub"})
+	if err != nil || identity.UserID != bob.ID {
+		t.Errorf("identity of bob = %+v, %v, want it linked to user %d", identity, err, bob.ID)
+	}
+	// the account of bob never confirmed its address, so whoever chose the password may not own it
+	if linked := env.userWithEmail(t, "bob@example.com"); linked.Password != "" || !linked.EmailVerifiedAt.Valid {
+		t.Errorf("linked unverified user = %+v, want their email verified and password dropped", linked)
+	}
+
+	// a name that is taken is made unique with the email address
+	if rec := env.login(t, jwt.MapClaims{"sub": "other-alice", "email": "alice@other.example.com", "email_verified": true, "name": "Alice"}, nil); !loggedIn(t, rec) {
+		t.Fatalf("login with a taken name redirected to %q, want a session", rec.Header().Get("Location"))
+	}
+	if got := env.userWithEmail(t, "alice@other.example.com").Name; got != "Alice (alice@other.example.com)" {
+		t.Errorf("name of second Alice = %q, want it made unique", got)
+	}
+
+	// an unverified email address neither links nor creates an account
+	mallory := jwt.MapClaims{"sub": "mallory", "email": "bob@example.com", "email_verified": false}
+	if rec := env.login(t, mallory, nil); loggedIn(t, rec) || !strings.HasPrefix(rec.Header().Get("Location"), frontendURL+"/login?error=") {
+		t.Errorf("login with an unverified email redirected to %q, want the login page with an error", rec.Header().Get("Location"))
+	}
+	if _, err := env.db.GetUserIdentity(ctx, sqlc.GetUserIdentityParams{Issuer: env.idp.URL, Subject: "mallory"}); err == nil {
+		t.Error("identity with an unverified email was linked")
+	}
+}
+
+// TestClaimUnverified checks that the owner of an email address, vouched for
+// by the identity provider, takes over an account someone else signed up with
+// and never confirmed, without anything that person set up on it.
+func TestClaimUnverified(t *testing.T) {
+	env := newTestEnv(t, "")
+	squatter, err := env.db.CreateUser(context.Background(), "erin@example.com", "Erin", "hash", 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := userctx.SetUserID(context.Background(), squatter.ID)
+
+	cfg := &config.Config{Server: config.ServerConfig{AccessTokenTTLMinutes: 15, SessionTTLDays: 30}}
+	sessionService := sessions.NewService(env.db, "secret", cfg, nopAudit{})
+	if _, err := sessionService.CreateSession(ctx, squatter.ID, "browser", "127.0.0.1", false); err != nil {
+		t.Fatal(err)
+	}
+	tokenService := tokens.NewService(env.db, env.db, nopAudit{})
+	pat, err := tokenService.CreateToken(ctx, tokens.CreateTokenRequest{Name: "sync", Scopes: []string{"files:read"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enrollment, err := env.mfa.Enroll(ctx)
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	code, err := mfa.Code(enrollment.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.mfa.Enable(ctx, code); err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+	sessionsBefore := env.db.Sessions()
+
+	erin := jwt.MapClaims{"sub": "erin", "email": "erin@example.com", "email_verified": true, "name": "Erin"}
+	if rec := env.login(t, erin, nil); !loggedIn(t, rec) {
+		t.Fatalf("login redirected to %q, want a session without the two-factor challenge of the squatter", rec.Header().Get("Location"))
+	}
+	for _, s := range env.db.Sessions() {
+		for _, before := range sessionsBefore {
+			if s.ID == before.ID && !s.RevokedAt.Valid {
+				t.Errorf("session %s of the squatter still active", s.ID)
+			}
+		}
+	}
+	if _, _, err := tokenService.AuthenticateToken(context.Background(), pat.Secret); !errors.Is(err, tokens.ErrInvalidToken) {
+		t.Errorf("personal access token of the squatter = %v, want ErrInvalidToken", err)
+	}
+	if enabled, err := env.db.UserHasMFA(context.Background(), squatter.ID); err != nil || enabled {
+		t.Errorf("two-factor authentication of the squatter = %v, %v, want it removed", enabled, err)
+	}
+	if codes, err := env.db.CountMFARecoveryCodes(context.Background(), squatter.ID); err != nil || codes != 0 {
+		t.Errorf("recovery codes of the squatter = %d, %v, want none", codes, err)
+	}
+}
+
+// TestRoleMapping checks that membership of an admin group makes the user an
+// admin, and that leaving it demotes them.
+func TestRoleMapping(t *testing.T) {
+	env := newTestEnv(t, "")
+	claims := jwt.MapClaims{"sub": "carol", "email": "carol@example.com", "email_verified": true, "groups": []string{"staff", "vault-admins"}}
+
+	tests := []struct {
+		name     string
+		groups   interface{}
+		wantRole string
+	}{
+		{name: "admin group", groups: []string{"staff", "vault-admins"}, wantRole: "admin"},
+		{name: "single group", groups: "vault-admins", wantRole: "admin"},
+		{name: "other groups", groups: []string{"staff"}, wantRole: "user"},
+		{name: "no groups", groups: nil, wantRole: "user"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims["groups"] = tt.groups
+			if rec := env.login(t, claims, nil); !loggedIn(t, rec) {
+				t.Fatalf("login redirected to %q, want a session", rec.Header().Get("Location"))
+			}
+			if got := env.userWithEmail(t, "carol@example.com").Role; got != tt.wantRole {
+				t.Errorf("role = %q, want %q", got, tt.wantRole)
+			}
+		})
+	}
+}
+
+// TestCallbackRejected checks the callback refuses logins it cannot tie to the
+// browser and code that started them.
+func TestCallbackRejected(t *testing.T) {
+	dave := jwt.MapClaims{"sub": "dave", "email": "dave@example.com", "email_verified": true}
+
+	tests := []struct {
+		name   string
+		tamper func(*http.Request)
+	}{
+		{name: "missing state cookie", tamper: func(r *http.Request) { r.Header.Del("Cookie") }},
+		{name: "other state", tamper: func(r *http.Request) {
+			q := r.URL.Query()
+			q.Set("state", "forged")
+			r.URL.RawQuery = q.Encode()
+		}},
+		{name: "other PKCE verifier", tamper: func(r *http.Request) {
+			c, _ := r.Cookie(sso.StateCookie)
+			parts := strings.Split(c.Value, ".")
+			r.Header.Del("Cookie")
+			r.AddCookie(&http.Cookie{Name: sso.StateCookie, Value: parts[0] + "." + parts[1] + "." + strings.Repeat("a", 43)})
+		}},
+		{name: "other nonce", tamper: func(r *http.Request) {
+			c, _ := r.Cookie(sso.StateCookie)
+			parts := strings.Split(c.Value, ".")
+			r.Header.Del("Cookie")
+			r.AddCookie(&http.Cookie{Name: sso.StateCookie, Value: parts[0] + ".forged." + parts[2]})
+		}},
+		{name: "provider error", tamper: func(r *http.Request) {
+			q := r.URL.Query()
+			q.Del("code")
+			q.Set("error", "access_denied")
+			r.URL.RawQuery = q.Encode()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, "")
+			rec := env.login(t, dave, tt.tamper)
+			if loggedIn(t, rec) || !strings.HasPrefix(rec.Header().Get("Location"), frontendURL+"/login?error=") {
+				t.Errorf("callback redirected to %q, want the login page with an error", rec.Header().Get("Location"))
+			}
+			if len(env.db.Identities()) != 0 {
+				t.Error("rejected login linked an identity")
+			}
+		})
+	}
+}
+
+// TestTwoFactor checks that users with two-factor authentication get a
+// challenge instead of a session, as after a password login.
+func TestTwoFactor(t *testing.T) {
+	env := newTestEnv(t, "")
+	dave := jwt.MapClaims{"sub": "dave", "email": "dave@example.com", "email_verified": true, "name": "Dave"}
+	if rec := env.login(t, dave, nil); !loggedIn(t, rec) {
+		t.Fatalf("first login redirected to %q, want a session", rec.Header().Get("Location"))
+	}
+
+	user := env.userWithEmail(t, "dave@example.com")
+	ctx := userctx.SetUserID(context.Background(), user.ID)
+	enrollment, err := env.mfa.Enroll(ctx)
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	code, err := mfa.Code(enrollment.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.mfa.Enable(ctx, code); err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+
+	sessionsBefore := len(env.db.Sessions())
+	rec := env.login(t, dave, nil)
+	if loggedIn(t, rec) {
+		t.Fatal("login with two-factor authentication started a session without a code")
+	}
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil || location.Path != "/login" || location.Query().Get("challenge") == "" {
+		t.Fatalf("login redirected to %q, want the login page with a challenge", rec.Header().Get("Location"))
+	}
+	if got := len(env.db.Sessions()); got != sessionsBefore {
+		t.Errorf("sessions after challenge = %d, want %d", got, sessionsBefore)
+	}
+
+	// the code of the next time step, as the one used to enable can't be replayed
+	next, err := mfa.Code(enrollment.Secret, time.Now().Add(30*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, err := env.mfa.VerifyChallenge(context.Background(), location.Query().Get("challenge"), next)
+	if err != nil || userID != user.ID {
+		t.Errorf("VerifyChallenge = %d, %v, want user %d", userID, err, user.ID)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	env := newTestEnv(t, "")
+	cfg := &config.Config{Server: config.ServerConfig{FrontendURL: frontendURL}}
+	handler := sso.NewHandler(sso.NewService(env.db, cfg, nopAudit{}), nil, nil)
+	router := chi.NewRouter()
+	handler.RegisterPublicRoutes(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("login status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
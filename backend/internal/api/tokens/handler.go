@@ -0,0 +1,73 @@
+package tokens
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apphandler"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Handler is the HTTP handler for personal access token endpoints.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new token Handler with the given service.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers the personal access token routes on the given router.
+// They should only be reachable with a session, see middleware.SessionOnly.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get("/auth/tokens", apphandler.MakeHTTPHandler(h.ListTokens))
+	r.Post("/auth/tokens", apphandler.MakeHTTPHandler(h.CreateToken))
+	r.Delete("/auth/tokens/{id}", apphandler.MakeHTTPHandler(h.RevokeToken))
+}
+
+// ListTokens handles GET /auth/tokens.
+// It returns the tokens of the authenticated user that are not revoked.
+func (h *Handler) ListTokens(w http.ResponseWriter, r *http.Request) error {
+	tokens, err := h.service.ListTokens(r.Context())
+	if err != nil {
+		return err
+	}
+
+	return util.WriteJSON(w, http.StatusOK, tokens)
+}
+
+// CreateToken handles POST /auth/tokens.
+// It creates a token and returns it, the only time it can be retrieved.
+func (h *Handler) CreateToken(w http.ResponseWriter, r *http.Request) error {
+	var req CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierror.NewBadRequestError("Invalid request body")
+	}
+
+	created, err := h.service.CreateToken(r.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return util.WriteJSON(w, http.StatusCreated, created)
+}
+
+// RevokeToken handles DELETE /auth/tokens/{id}.
+// It revokes a token of the authenticated user.
+func (h *Handler) RevokeToken(w http.ResponseWriter, r *http.Request) error {
+	tokenID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return apierror.NewBadRequestError("Invalid token ID")
+	}
+
+	if err := h.service.RevokeToken(r.Context(), tokenID); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,70 @@
+package tokens
+
+import (
+	"context"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/google/uuid"
+)
+
+// Repository describes the database operations used by the tokens service.
+// It is implemented on top of sqlc queries by NewRepository.
+type Repository interface {
+	CreatePersonalAccessToken(ctx context.Context, arg sqlc.CreatePersonalAccessTokenParams) (sqlc.PersonalAccessToken, error)
+	ListPersonalAccessTokens(ctx context.Context, userID int64) ([]sqlc.PersonalAccessToken, error)
+	CountActivePersonalAccessTokens(ctx context.Context, userID int64) (int64, error)
+	RevokePersonalAccessToken(ctx context.Context, arg sqlc.RevokePersonalAccessTokenParams) (int64, error)
+	GetActivePersonalAccessToken(ctx context.Context, tokenHash string) (sqlc.PersonalAccessToken, error)
+	TouchPersonalAccessToken(ctx context.Context, tokenID uuid.UUID) error
+	RoleRequiresMFA(ctx context.Context, role string) (bool, error)
+}
+
+// repository handles database operations related to personal access tokens, backed by sqlc queries.
+type repository struct {
+	queries *sqlc.Queries
+}
+
+// NewRepository creates a new Repository instance with the provided database queries.
+func NewRepository(db *sqlc.Queries) Repository {
+	return &repository{
+		queries: db,
+	}
+}
+
+// CreatePersonalAccessToken stores a new token with the hash of its secret.
+func (r *repository) CreatePersonalAccessToken(ctx context.Context, arg sqlc.CreatePersonalAccessTokenParams) (sqlc.PersonalAccessToken, error) {
+	return r.queries.CreatePersonalAccessToken(ctx, arg)
+}
+
+// ListPersonalAccessTokens returns the tokens of a user that are not revoked, newest first.
+func (r *repository) ListPersonalAccessTokens(ctx context.Context, userID int64) ([]sqlc.PersonalAccessToken, error) {
+	return r.queries.ListPersonalAccessTokens(ctx, userID)
+}
+
+// CountActivePersonalAccessTokens returns the number of tokens of a user that
+// are neither revoked nor expired.
+func (r *repository) CountActivePersonalAccessTokens(ctx context.Context, userID int64) (int64, error) {
+	return r.queries.CountActivePersonalAccessTokens(ctx, userID)
+}
+
+// RevokePersonalAccessToken revokes a token of a user.
+// Returns the number of revoked tokens, zero if the user has no such token.
+func (r *repository) RevokePersonalAccessToken(ctx context.Context, arg sqlc.RevokePersonalAccessTokenParams) (int64, error) {
+	return r.queries.RevokePersonalAccessToken(ctx, arg)
+}
+
+// GetActivePersonalAccessToken returns the token with the given hash,
+// or pgx.ErrNoRows if there is none or it was revoked or has expired.
+func (r *repository) GetActivePersonalAccessToken(ctx context.Context, tokenHash string) (sqlc.PersonalAccessToken, error) {
+	return r.queries.GetActivePersonalAccessToken(ctx, tokenHash)
+}
+
+// TouchPersonalAccessToken records that a token was used.
+func (r *repository) TouchPersonalAccessToken(ctx context.Context, tokenID uuid.UUID) error {
+	return r.queries.TouchPersonalAccessToken(ctx, tokenID)
+}
+
+// RoleRequiresMFA reports whether the users of a role must use two-factor authentication.
+func (r *repository) RoleRequiresMFA(ctx context.Context, role string) (bool, error) {
+	return r.queries.RoleRequiresMFA(ctx, role)
+}
@@ -0,0 +1,241 @@
+// Package tokens manages personal access tokens, which scripts and CI jobs
+// send as "Authorization: Bearer <token>" instead of a session cookie.
+package tokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/users"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Scopes a token can carry. Each restricts the token to a group of routes.
+const (
+	ScopeFilesRead  = "files:read"  // list, search and download files and folders, list users
+	ScopeFilesWrite = "files:write" // everything else on files and folders, implies files:read
+	ScopeAdmin      = "admin"       // the admin routes, for admins only
+)
+
+// Scopes lists the valid scopes.
+var Scopes = []string{ScopeFilesRead, ScopeFilesWrite, ScopeAdmin}
+
+const (
+	// tokenPrefix starts every token, so they are recognizable in logs and by secret scanners.
+	tokenPrefix = "pat_"
+	// displayPrefixLength is the length of the start of a token kept to tell tokens apart.
+	displayPrefixLength = len(tokenPrefix) + 8
+	maxNameLength       = 100
+	// maxTokens is the number of active tokens a user can have.
+	maxTokens = 50
+)
+
+// ErrInvalidToken is returned by AuthenticateToken for unknown, revoked and expired tokens.
+var ErrInvalidToken = errors.New("invalid personal access token")
+
+// Service handles the creation, listing, revocation and verification of personal access tokens.
+type Service struct {
+	repo     Repository
+	userRepo users.Repository
+	audit    audit.Service
+}
+
+// NewService creates a new instance of the tokens Service.
+// - repo: repository providing database operations for tokens.
+// - userRepo: repository used to check that only admins get the admin scope.
+// - auditService: service used to record created and revoked tokens.
+func NewService(repo Repository, userRepo users.Repository, auditService audit.Service) *Service {
+	return &Service{repo: repo, userRepo: userRepo, audit: auditService}
+}
+
+// hashToken returns the hex-encoded SHA-256 of a token, the form tokens are
+// stored and looked up in. Tokens are random, so a fast hash is enough.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateToken creates a personal access token for the authenticated user.
+// - Validates the name, the scopes and the expiry, which must be in the future.
+// - Only admins can create tokens with the admin scope, unless their role requires two-factor authentication.
+// Returns the token, which is not stored and cannot be retrieved again.
+func (s *Service) CreateToken(ctx context.Context, req CreateTokenRequest) (CreateTokenResponse, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return CreateTokenResponse{}, apierror.NewUnauthorizedError()
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxNameLength {
+		return CreateTokenResponse{}, apierror.NewBadRequestError(fmt.Sprintf("Token name must be 1 to %d characters long", maxNameLength))
+	}
+	if len(req.Scopes) == 0 {
+		return CreateTokenResponse{}, apierror.NewBadRequestError("Select at least one scope")
+	}
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if !slices.Contains(Scopes, scope) {
+			return CreateTokenResponse{}, apierror.NewBadRequestError(fmt.Sprintf("Unknown scope %q, must be one of %s", scope, strings.Join(Scopes, ", ")))
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	slices.Sort(scopes)
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return CreateTokenResponse{}, apierror.NewBadRequestError("Expiry must be in the future")
+	}
+
+	if slices.Contains(scopes, ScopeAdmin) {
+		user, err := s.userRepo.GetUserByID(ctx, userID)
+		if err != nil {
+			return CreateTokenResponse{}, apierror.NewInternalServerError("could not retrieve user")
+		}
+		if user.Role != "admin" {
+			return CreateTokenResponse{}, apierror.NewForbiddenError()
+		}
+		required, err := s.repo.RoleRequiresMFA(ctx, user.Role)
+		if err != nil {
+			return CreateTokenResponse{}, apierror.NewInternalServerError("could not retrieve two-factor authentication policy")
+		}
+		if required {
+			return CreateTokenResponse{}, apierror.New(http.StatusForbidden, "Admins must use two-factor authentication, which tokens with the admin scope would bypass")
+		}
+	}
+
+	active, err := s.repo.CountActivePersonalAccessTokens(ctx, userID)
+	if err != nil {
+		return CreateTokenResponse{}, apierror.NewInternalServerError("Failed to create token")
+	}
+	if active >= maxTokens {
+		return CreateTokenResponse{}, apierror.NewBadRequestError(fmt.Sprintf("You cannot have more than %d active tokens", maxTokens))
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return CreateTokenResponse{}, apierror.NewInternalServerError("Failed to create token")
+	}
+	token := tokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	params := sqlc.CreatePersonalAccessTokenParams{
+		UserID:      userID,
+		Name:        name,
+		TokenHash:   hashToken(token),
+		TokenPrefix: token[:displayPrefixLength],
+		Scopes:      scopes,
+	}
+	if req.ExpiresAt != nil {
+		params.ExpiresAt = pgtype.Timestamptz{Time: *req.ExpiresAt, Valid: true}
+	}
+	created, err := s.repo.CreatePersonalAccessToken(ctx, params)
+	if err != nil {
+		log.Printf("Failed to create token for user %d: %v", userID, err)
+		return CreateTokenResponse{}, apierror.NewInternalServerError("Failed to create token")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "TOKEN_CREATED",
+		TargetID: created.ID,
+		Details: map[string]interface{}{
+			"name":       created.Name,
+			"scopes":     created.Scopes,
+			"expires_at": req.ExpiresAt,
+		},
+	})
+	return CreateTokenResponse{Token: newToken(created), Secret: token}, nil
+}
+
+// ListTokens returns the tokens of the authenticated user that are not
+// revoked, newest first, expired ones included.
+func (s *Service) ListTokens(ctx context.Context) ([]Token, error) {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return nil, apierror.NewUnauthorizedError()
+	}
+
+	rows, err := s.repo.ListPersonalAccessTokens(ctx, userID)
+	if err != nil {
+		return nil, apierror.NewInternalServerError("Failed to list tokens")
+	}
+	tokens := make([]Token, len(rows))
+	for i, row := range rows {
+		tokens[i] = newToken(row)
+	}
+	return tokens, nil
+}
+
+// RevokeToken revokes a token of the authenticated user, which stops working at once.
+func (s *Service) RevokeToken(ctx context.Context, tokenID uuid.UUID) error {
+	userID, ok := userctx.GetUserID(ctx)
+	if !ok {
+		return apierror.NewUnauthorizedError()
+	}
+
+	revoked, err := s.repo.RevokePersonalAccessToken(ctx, sqlc.RevokePersonalAccessTokenParams{ID: tokenID, UserID: userID})
+	if err != nil {
+		return apierror.NewInternalServerError("Failed to revoke token")
+	}
+	if revoked == 0 {
+		return apierror.NewNotFoundError("Token")
+	}
+
+	s.audit.Log(ctx, audit.LogParams{
+		UserID:   userID,
+		Action:   "TOKEN_REVOKED",
+		TargetID: tokenID,
+	})
+	return nil
+}
+
+// AuthenticateToken returns the ID of the user a token belongs to and the
+// scopes it carries, and records that the token was used. It returns
+// ErrInvalidToken for unknown, revoked and expired tokens.
+func (s *Service) AuthenticateToken(ctx context.Context, token string) (int64, []string, error) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return 0, nil, ErrInvalidToken
+	}
+	row, err := s.repo.GetActivePersonalAccessToken(ctx, hashToken(token))
+	if err != nil {
+		return 0, nil, ErrInvalidToken
+	}
+
+	if err := s.repo.TouchPersonalAccessToken(ctx, row.ID); err != nil {
+		log.Printf("Failed to record the use of token %s: %v", row.ID, err)
+	}
+	return row.UserID, row.Scopes, nil
+}
+
+// newToken converts a token record into the Token returned to its owner.
+func newToken(row sqlc.PersonalAccessToken) Token {
+	token := Token{
+		ID:        row.ID,
+		Name:      row.Name,
+		Prefix:    row.TokenPrefix,
+		Scopes:    row.Scopes,
+		CreatedAt: row.CreatedAt.Time,
+	}
+	if row.ExpiresAt.Valid {
+		token.ExpiresAt = &row.ExpiresAt.Time
+		token.Expired = !row.ExpiresAt.Time.After(time.Now())
+	}
+	if row.LastUsedAt.Valid {
+		token.LastUsedAt = &row.LastUsedAt.Time
+	}
+	return token
+}
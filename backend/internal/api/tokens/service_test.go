@@ -0,0 +1,235 @@
+package tokens_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/middleware"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/tokens"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/memdb"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
+	"github.com/go-chi/chi/v5"
+)
+
+type nopAudit struct{}
+
+func (nopAudit) Log(ctx context.Context, params audit.LogParams) {}
+
+func statusOf(err error) int {
+	var apiErr *apierror.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// newTestService returns a token service on an empty database, along with
+// the contexts of a user and an admin.
+func newTestService(t *testing.T) (*tokens.Service, context.Context, context.Context) {
+	t.Helper()
+	db := memdb.New()
+	user, err := db.CreateUser(context.Background(), "user@example.com", "User", "hash", 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := db.CreateUser(context.Background(), "admin@example.com", "Admin", "hash", 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetUserRole(admin.ID, "admin")
+
+	service := tokens.NewService(db, db, nopAudit{})
+	return service, userctx.SetUserID(context.Background(), user.ID), userctx.SetUserID(context.Background(), admin.ID)
+}
+
+func TestCreateToken(t *testing.T) {
+	service, userCtx, adminCtx := newTestService(t)
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		req        tokens.CreateTokenRequest
+		wantStatus int
+		wantScopes []string
+	}{
+		{name: "read only", ctx: userCtx, req: tokens.CreateTokenRequest{Name: "backup script", Scopes: []string{"files:read"}}, wantScopes: []string{"files:read"}},
+		{name: "scopes deduplicated and sorted", ctx: userCtx, req: tokens.CreateTokenRequest{Name: "ci", Scopes: []string{"files:write", "files:read", "files:write"}, ExpiresAt: &future}, wantScopes: []string{"files:read", "files:write"}},
+		{name: "admin scope for an admin", ctx: adminCtx, req: tokens.CreateTokenRequest{Name: "ops", Scopes: []string{"admin"}}, wantScopes: []string{"admin"}},
+		{name: "admin scope for a user", ctx: userCtx, req: tokens.CreateTokenRequest{Name: "ops", Scopes: []string{"admin"}}, wantStatus: http.StatusForbidden},
+		{name: "no name", ctx: userCtx, req: tokens.CreateTokenRequest{Name: "  ", Scopes: []string{"files:read"}}, wantStatus: http.StatusBadRequest},
+		{name: "no scopes", ctx: userCtx, req: tokens.CreateTokenRequest{Name: "ci"}, wantStatus: http.StatusBadRequest},
+		{name: "unknown scope", ctx: userCtx, req: tokens.CreateTokenRequest{Name: "ci", Scopes: []string{"files:delete"}}, wantStatus: http.StatusBadRequest},
+		{name: "expiry in the past", ctx: userCtx, req: tokens.CreateTokenRequest{Name: "ci", Scopes: []string{"files:read"}, ExpiresAt: &past}, wantStatus: http.StatusBadRequest},
+		{name: "unauthenticated", ctx: context.Background(), req: tokens.CreateTokenRequest{Name: "ci", Scopes: []string{"files:read"}}, wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, err := service.CreateToken(tt.ctx, tt.req)
+			if tt.wantStatus != 0 {
+				if got := statusOf(err); got != tt.wantStatus {
+					t.Fatalf("CreateToken error = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateToken: %v", err)
+			}
+			if !slices.Equal(created.Scopes, tt.wantScopes) {
+				t.Errorf("scopes = %v, want %v", created.Scopes, tt.wantScopes)
+			}
+			if !strings.HasPrefix(created.Secret, "pat_") || !strings.HasPrefix(created.Secret, created.Prefix) || len(created.Secret) <= len(created.Prefix) {
+				t.Errorf("token %q with prefix %q, want a pat_ token starting with its prefix", created.Secret, created.Prefix)
+			}
+		})
+	}
+}
+
+// TestAdminScopeUnderMFAPolicy checks that admins can't create tokens with
+// the admin scope once their role requires two-factor authentication.
+func TestAdminScopeUnderMFAPolicy(t *testing.T) {
+	db := memdb.New()
+	admin, err := db.CreateUser(context.Background(), "admin@example.com", "Admin", "hash", 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetUserRole(admin.ID, "admin")
+	if _, err := db.SetMFAPolicy(context.Background(), sqlc.SetMFAPolicyParams{Role: "admin", Required: true}); err != nil {
+		t.Fatal(err)
+	}
+	service := tokens.NewService(db, db, nopAudit{})
+	ctx := userctx.SetUserID(context.Background(), admin.ID)
+
+	if _, err := service.CreateToken(ctx, tokens.CreateTokenRequest{Name: "ops", Scopes: []string{"admin"}}); statusOf(err) != http.StatusForbidden {
+		t.Errorf("admin scope under the policy error = %v, want status %d", err, http.StatusForbidden)
+	}
+	if _, err := service.CreateToken(ctx, tokens.CreateTokenRequest{Name: "backup", Scopes: []string{"files:read"}}); err != nil {
+		t.Errorf("files scope under the policy: %v", err)
+	}
+}
+
+// TestTokenLifecycle authenticates with a token until it is revoked or expires.
+func TestTokenLifecycle(t *testing.T) {
+	service, userCtx, adminCtx := newTestService(t)
+	userID, _ := userctx.GetUserID(userCtx)
+
+	created, err := service.CreateToken(userCtx, tokens.CreateTokenRequest{Name: "ci", Scopes: []string{"files:write"}})
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+	gotUserID, scopes, err := service.AuthenticateToken(context.Background(), created.Secret)
+	if err != nil || gotUserID != userID || !slices.Equal(scopes, []string{"files:write"}) {
+		t.Fatalf("AuthenticateToken = %d, %v, %v, want %d, [files:write]", gotUserID, scopes, err, userID)
+	}
+	for _, token := range []string{"", "pat_", created.Prefix, strings.TrimPrefix(created.Secret, "pat_"), created.Secret + "x"} {
+		if _, _, err := service.AuthenticateToken(context.Background(), token); !errors.Is(err, tokens.ErrInvalidToken) {
+			t.Errorf("AuthenticateToken(%q) error = %v, want %v", token, err, tokens.ErrInvalidToken)
+		}
+	}
+
+	list, err := service.ListTokens(userCtx)
+	if err != nil || len(list) != 1 || list[0].ID != created.ID || list[0].LastUsedAt == nil {
+		t.Fatalf("ListTokens = %+v, %v, want the used token", list, err)
+	}
+
+	if err := service.RevokeToken(adminCtx, created.ID); statusOf(err) != http.StatusNotFound {
+		t.Errorf("RevokeToken by another user error = %v, want status %d", err, http.StatusNotFound)
+	}
+	if err := service.RevokeToken(userCtx, created.ID); err != nil {
+		t.Fatalf("RevokeToken: %v", err)
+	}
+	if err := service.RevokeToken(userCtx, created.ID); statusOf(err) != http.StatusNotFound {
+		t.Errorf("RevokeToken twice error = %v, want status %d", err, http.StatusNotFound)
+	}
+	if _, _, err := service.AuthenticateToken(context.Background(), created.Secret); !errors.Is(err, tokens.ErrInvalidToken) {
+		t.Errorf("AuthenticateToken after revoking error = %v, want %v", err, tokens.ErrInvalidToken)
+	}
+	if list, err := service.ListTokens(userCtx); err != nil || len(list) != 0 {
+		t.Errorf("ListTokens after revoking = %+v, %v, want none", list, err)
+	}
+
+	expiresAt := time.Now().Add(50 * time.Millisecond)
+	expiring, err := service.CreateToken(userCtx, tokens.CreateTokenRequest{Name: "short lived", Scopes: []string{"files:read"}, ExpiresAt: &expiresAt})
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+	time.Sleep(time.Until(expiresAt))
+	if _, _, err := service.AuthenticateToken(context.Background(), expiring.Secret); !errors.Is(err, tokens.ErrInvalidToken) {
+		t.Errorf("AuthenticateToken after expiry error = %v, want %v", err, tokens.ErrInvalidToken)
+	}
+	if list, err := service.ListTokens(userCtx); err != nil || len(list) != 1 || !list[0].Expired {
+		t.Errorf("ListTokens after expiry = %+v, %v, want the expired token", list, err)
+	}
+}
+
+// TestBearerAuth sends requests through the middleware chain NewServer uses
+// for the file, user and token routes.
+func TestBearerAuth(t *testing.T) {
+	service, userCtx, adminCtx := newTestService(t)
+	newToken := func(ctx context.Context, scopes ...string) string {
+		created, err := service.CreateToken(ctx, tokens.CreateTokenRequest{Name: strings.Join(scopes, " "), Scopes: scopes})
+		if err != nil {
+			t.Fatalf("CreateToken: %v", err)
+		}
+		return created.Secret
+	}
+	readToken := newToken(userCtx, tokens.ScopeFilesRead)
+	writeToken := newToken(userCtx, tokens.ScopeFilesWrite)
+	adminToken := newToken(adminCtx, tokens.ScopeAdmin)
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router := chi.NewRouter()
+	router.Use(middleware.AuthMiddleware("secret", nil, service))
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.RequireMethodScopes(tokens.ScopeFilesRead, tokens.ScopeFilesWrite))
+		r.Get("/files", ok)
+		r.Post("/files", ok)
+		r.Get("/users", ok)
+		r.Get("/auth/me", ok)
+	})
+	router.With(middleware.SessionOnly).Get("/auth/tokens", ok)
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "read with files:read", method: http.MethodGet, path: "/files", authorization: "Bearer " + readToken, wantStatus: http.StatusOK},
+		{name: "write with files:read", method: http.MethodPost, path: "/files", authorization: "Bearer " + readToken, wantStatus: http.StatusForbidden},
+		{name: "read with files:write", method: http.MethodGet, path: "/files", authorization: "bearer " + writeToken, wantStatus: http.StatusOK},
+		{name: "write with files:write", method: http.MethodPost, path: "/files", authorization: "Bearer " + writeToken, wantStatus: http.StatusOK},
+		{name: "users with files:read", method: http.MethodGet, path: "/users", authorization: "Bearer " + readToken, wantStatus: http.StatusOK},
+		{name: "users with admin", method: http.MethodGet, path: "/users", authorization: "Bearer " + adminToken, wantStatus: http.StatusForbidden},
+		{name: "me with files:write", method: http.MethodGet, path: "/auth/me", authorization: "Bearer " + writeToken, wantStatus: http.StatusOK},
+		{name: "me with admin", method: http.MethodGet, path: "/auth/me", authorization: "Bearer " + adminToken, wantStatus: http.StatusForbidden},
+		{name: "token management", method: http.MethodGet, path: "/auth/tokens", authorization: "Bearer " + writeToken, wantStatus: http.StatusForbidden},
+		{name: "unknown token", method: http.MethodGet, path: "/files", authorization: "Bearer pat_unknown", wantStatus: http.StatusUnauthorized},
+		{name: "other scheme", method: http.MethodGet, path: "/files", authorization: "Basic " + readToken, wantStatus: http.StatusUnauthorized},
+		{name: "no credentials", method: http.MethodGet, path: "/files", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d (%s), want %d", rec.Code, rec.Body, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package tokens
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Token describes a personal access token for API responses. The token
+// itself is only returned once, when it is created.
+// Prefix: the first characters of the token, to tell tokens apart.
+// ExpiresAt: when the token stops working, nil if it never expires.
+// LastUsedAt: when the token was last used, nil if it never was.
+type Token struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Expired    bool       `json:"expired"`
+}
+
+// CreateTokenRequest is the JSON payload to create a personal access token.
+// A token without ExpiresAt never expires.
+type CreateTokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreateTokenResponse describes a new token along with the token itself,
+// which cannot be retrieved again.
+type CreateTokenResponse struct {
+	Token
+	Secret string `json:"token"`
+}
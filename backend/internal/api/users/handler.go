@@ -6,36 +6,48 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"time"
 
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/accounts"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apphandler"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/mfa"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/sessions"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/util"
 	"github.com/go-chi/chi/v5"
 )
 
 // Handler provides HTTP route handlers for user-related endpoints.
-// It delegates business logic to the underlying Service.
+// It delegates business logic to the underlying Service, to the sessions
+// Service to log users in, to the mfa Service to ask for a second factor,
+// and to the accounts Service to verify the email address of new users.
 type Handler struct {
-	service *Service
+	service  *Service
+	sessions *sessions.Service
+	mfa      *mfa.Service
+	accounts *accounts.Service
 }
 
-// NewHandler creates a new Handler instance with the provided Service.
-func NewHandler(service *Service) *Handler {
-	return &Handler{service: service}
+// NewHandler creates a new Handler instance with the provided Services.
+func NewHandler(service *Service, sessionService *sessions.Service, mfaService *mfa.Service, accountService *accounts.Service) *Handler {
+	return &Handler{service: service, sessions: sessionService, mfa: mfaService, accounts: accountService}
 }
 
-// RegisterRoutes registers the user-related routes (auth, users) on the router.
-// Currently includes: /auth/me and /users.
-func (h *Handler) RegisterRoutes(r chi.Router) {
+// RegisterAccountRoutes registers /auth/me, which stays reachable before the
+// email address of the user is verified.
+func (h *Handler) RegisterAccountRoutes(r chi.Router) {
 	r.Get("/auth/me", apphandler.MakeHTTPHandler(h.Me))
+}
+
+// RegisterRoutes registers the user-related routes on the router.
+// Currently includes: /users.
+func (h *Handler) RegisterRoutes(r chi.Router) {
 	r.Get("/users", apphandler.MakeHTTPHandler(h.GetOtherUsers))
 }
 
 // Signup handles user registration requests.
 // It decodes the request body, calls the service layer to create the user,
-// and responds with HTTP 201 on success.
+// emails them a link to verify their address, and responds with HTTP 201 on success.
 func (h *Handler) Signup(w http.ResponseWriter, r *http.Request) {
 	var req signupRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -44,18 +56,25 @@ func (h *Handler) Signup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// not returning User for now
-	_, err := h.service.Signup(context.Background(), req.Email, req.Name, req.Password)
+	user, err := h.service.Signup(context.Background(), req.Email, req.Name, req.Password)
 	if err != nil {
 		log.Printf("Sign Up Error: %v", err)
 		util.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("Sign Up Error: %s", err))
 		return
 	}
 
+	// The account exists either way, the user can ask for another email once logged in
+	if err := h.accounts.SendVerification(r.Context(), user); err != nil {
+		log.Printf("Sign Up Error: %v", err)
+	}
+
 	w.WriteHeader(http.StatusCreated)
 }
 
 // Login handles user login requests.
-// It authenticates the user, generates a JWT on success, and sets it as an HTTP-only cookie.
+// It authenticates the user, starts a session on success, and sets its
+// access and refresh tokens as HTTP-only cookies. Users with two-factor
+// authentication get an mfa.Challenge instead, answered at /auth/mfa/verify.
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	var req loginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -66,53 +85,32 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	log.Printf("Received request to Login user")
 	userID, err := h.service.AuthenticateUser(context.Background(), req.Email, req.Password)
 	if err != nil {
-		http.SetCookie(w, &http.Cookie{
-			Name:     "jwt",
-			Value:    "",
-			Path:     "/",
-			Expires:  time.Unix(0, 0),
-			HttpOnly: true,
-		})
+		sessions.ClearCookies(w, r)
 		util.WriteError(w, http.StatusUnauthorized, "Invalid Credentials")
 		return
 	}
 
-	token, err := h.service.GenerateToken(userID)
+	// Users with two-factor authentication get a challenge to answer with a code instead of a session
+	challenge, required, err := h.mfa.StartChallenge(r.Context(), userID)
+	if err != nil {
+		util.WriteError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+	if required {
+		util.WriteJSON(w, http.StatusOK, challenge)
+		return
+	}
+
+	tokens, err := h.sessions.CreateSession(r.Context(), userID, r.UserAgent(), util.ClientIP(r), false)
 	if err != nil {
 		util.WriteError(w, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "jwt",
-		Value:    token,
-		Path:     "/",
-		Domain:   "",
-		Expires:  time.Now().Add(24 * time.Hour),
-		HttpOnly: true,
-		Secure:   r.TLS != nil,
-		SameSite: http.SameSiteLaxMode,
-	})
+	sessions.SetCookies(w, r, tokens)
 	util.WriteJSON(w, http.StatusOK, "Login successful")
 }
 
-// Logout handles user logout requests.
-// It clears the JWT cookie, effectively logging the user out.
-func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
-	log.Printf("Received request to Logout user")
-	http.SetCookie(w, &http.Cookie{
-		Name:     "jwt",
-		Value:    "",
-		Path:     "/",
-		Domain:   "",
-		Expires:  time.Unix(0, 0),
-		HttpOnly: true,
-		Secure:   r.TLS != nil,
-		SameSite: http.SameSiteLaxMode,
-	})
-
-}
-
 // GetOtherUsers handles the /users endpoint.
 // It returns a list of all users except the currently authenticated user.
 func (h *Handler) GetOtherUsers(w http.ResponseWriter, r *http.Request) error {
@@ -4,33 +4,28 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"time"
 
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/apierror"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/audit"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/config"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/userctx"
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // Service handles user-related business logic, including signup, authentication, and user queries.
 type Service struct {
-	repo                *Repository
-	jwtSecret           []byte
+	repo                Repository
 	defaultStorageQuota int64
 	audit               audit.Service
 }
 
 // NewService creates a new instance of the users Service.
 // - repo: the user repository for database operations.
-// - jwtSecret: secret key used for signing JWT tokens.
 // - cfg: configuration struct containing server settings like default storage quota.
-func NewService(repo *Repository, jwtSecret string, cfg *config.Config, auditService audit.Service) *Service {
+func NewService(repo Repository, cfg *config.Config, auditService audit.Service) *Service {
 	return &Service{
 		repo:                repo,
-		jwtSecret:           []byte(jwtSecret),
 		defaultStorageQuota: cfg.Server.DefaultStorageQuota,
 		audit:               auditService,
 	}
@@ -92,18 +87,6 @@ func (s *Service) AuthenticateUser(ctx context.Context, email, password string)
 	return user.ID, nil
 }
 
-// GenerateToken generates a JWT for the given user ID.
-// The token is valid for 24 hours and signed using the service's jwtSecret.
-func (s *Service) GenerateToken(userID int64) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.jwtSecret)
-}
-
 // ListOtherUsers retrieves all users in the system except the one with the specified userID.
 // Returns a slice of User structs or an internal server error if the query fails.
 // Used to populate options in the ShareModal
@@ -160,6 +143,7 @@ func (s *Service) GetMe(ctx context.Context) (MeResponse, error) {
 		Email:                  user.Email,
 		Name:                   user.Name,
 		Role:                   user.Role,
+		EmailVerified:          user.EmailVerifiedAt.Valid,
 		StorageUsedBytes:       originalUsage,
 		DeduplicatedUsageBytes: deduplicatedUsage,
 		StorageQuotaBytes:      user.StorageQuota,
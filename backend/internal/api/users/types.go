@@ -25,6 +25,7 @@ type MeResponse struct {
 	Email                  string  `json:"email"`
 	Name                   string  `json:"name"`
 	Role                   string  `json:"role"`
+	EmailVerified          bool    `json:"email_verified"`           // unverified users are restricted
 	StorageUsedBytes       int64   `json:"storage_used_bytes"`       // "Original storage usage"
 	DeduplicatedUsageBytes int64   `json:"deduplicated_usage_bytes"` // "Total storage used (deduplicated)"
 	StorageQuotaBytes      int64   `json:"storage_quota_bytes"`
@@ -23,6 +23,8 @@ func NewService(repo sqlc.Querier) Service {
 	return &service{repo: repo}
 }
 
+// LogParams describes an audit entry. A zero UserID records an anonymous
+// action, such as a download through a public link.
 type LogParams struct {
 	UserID   int64
 	Action   string
@@ -40,7 +42,7 @@ func (s *service) Log(ctx context.Context, params LogParams) {
 		}
 
 		arg := sqlc.CreateAuditLogParams{
-			UserID:   sql.NullInt64{Int64: params.UserID, Valid: true},
+			UserID:   sql.NullInt64{Int64: params.UserID, Valid: params.UserID != 0},
 			Action:   sqlc.AuditAction(params.Action),
 			TargetID: pgtype.UUID{Bytes: params.TargetID, Valid: true},
 			Details:  detailsJSON,
@@ -6,6 +6,7 @@ import (
 	_ "log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/util"
 	_ "github.com/joho/godotenv"
@@ -15,8 +16,11 @@ import (
 type Config struct {
 	Server   ServerConfig
 	Database DBConfig
+	Storage  StorageConfig
 	Minio    MinioConfig
 	Redis    RedisConfig
+	OIDC     OIDCConfig
+	Mail     MailConfig
 }
 
 // ServerConfig holds HTTP server, rate limits, storage quota settings.
@@ -25,7 +29,13 @@ type ServerConfig struct {
 	DefaultStorageQuota    int64
 	RateLimit              int
 	RateLimitWindowSeconds int
+	PublicLinkRateLimit    int // requests per minute a client IP may make to public share links
 	JWTSecret              string
+	PublicURL              string // base URL of this API, used in public share links
+	TrashRetentionDays     int    // days trashed items are kept before they are purged
+	AccessTokenTTLMinutes  int    // minutes an access token is valid before it must be refreshed
+	SessionTTLDays         int    // days a session lasts without being refreshed
+	FrontendURL            string // base URL of the web app, used in redirects and links sent by email
 }
 
 // DBConfig holds database connection settings.
@@ -33,6 +43,14 @@ type DBConfig struct {
 	URL string
 }
 
+// StorageConfig selects the blob storage driver and holds local storage settings.
+type StorageConfig struct {
+	Driver        string // "minio" or "local"
+	LocalDir      string
+	LocalBaseURL  string
+	SigningSecret string
+}
+
 // MinioConfig holds MinIO storage settings.
 type MinioConfig struct {
 	Endpoint string
@@ -49,6 +67,29 @@ type RedisConfig struct {
 	DB       int
 }
 
+// OIDCConfig holds OpenID Connect single sign-on settings. Single sign-on is
+// disabled when IssuerURL is empty.
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string   // callback URL registered with the identity provider
+	Scopes       []string // scopes requested along with openid
+	GroupsClaim  string   // ID token claim listing the groups of the user
+	AdminGroups  []string // groups granting the admin role, roles are left alone when empty
+}
+
+// MailConfig selects how emails are sent and holds the SMTP settings.
+type MailConfig struct {
+	Driver       string // "smtp" or "log"
+	From         string // sender address, with an optional display name
+	Dir          string // directory the log driver writes messages to, logged when empty
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+}
+
 // LoadConfig reads configuration from environment variables.
 func LoadConfig() (*Config, error) {
 	// err := godotenv.Load("../.env")
@@ -57,12 +98,24 @@ func LoadConfig() (*Config, error) {
 	// 	log.Printf("couldnt find env vars..")
 	// }
 
+	storageDriver := os.Getenv("STORAGE_DRIVER")
+	if storageDriver == "" {
+		storageDriver = "minio"
+	}
+
 	requiredVars := []string{
-		"PORT", "MINIO_ENDPOINT", "MINIO_ACCESS", "MINIO_SECRET",
-		"MINIO_BUCKET", "DB_USER", "DB_PASSWORD", "DB_HOST", "DB_PORT",
+		"PORT", "DB_USER", "DB_PASSWORD", "DB_HOST", "DB_PORT",
 		"DB_NAME", "REDIS_ADDR", "DEFAULT_STORAGE_QUOTA", "API_RATE_LIMIT",
 		"API_RATE_LIMIT_WINDOW_SECONDS", "JWT_SECRET",
 	}
+	switch storageDriver {
+	case "minio":
+		requiredVars = append(requiredVars, "MINIO_ENDPOINT", "MINIO_ACCESS", "MINIO_SECRET", "MINIO_BUCKET")
+	case "local":
+		requiredVars = append(requiredVars, "LOCAL_STORAGE_DIR", "STORAGE_SIGNING_SECRET")
+	default:
+		return nil, fmt.Errorf("invalid value for STORAGE_DRIVER: %s", storageDriver)
+	}
 	for _, v := range requiredVars {
 		if os.Getenv(v) == "" {
 			return nil, fmt.Errorf("error: missing required environment variable: %s", v)
@@ -79,10 +132,35 @@ func LoadConfig() (*Config, error) {
 	)
 
 	// Load MinIO settings
-	minioSecureStr := os.Getenv("MINIO_SECURE")
-	minioSecure, err := strconv.ParseBool(minioSecureStr)
-	if err != nil {
-		return nil, errors.New("invalid value for MINIO_SECURE")
+	minioSecure := false
+	if storageDriver == "minio" {
+		var err error
+		minioSecure, err = strconv.ParseBool(os.Getenv("MINIO_SECURE"))
+		if err != nil {
+			return nil, errors.New("invalid value for MINIO_SECURE")
+		}
+	}
+
+	publicURL := os.Getenv("PUBLIC_URL")
+	if publicURL == "" {
+		publicURL = "http://localhost:" + os.Getenv("PORT")
+	}
+
+	// The web app, users are sent there by redirects and email links
+	frontendURL := os.Getenv("FRONTEND_URL")
+	if frontendURL == "" {
+		frontendURL = "http://localhost:3000"
+	}
+
+	// Load local storage settings, signed URLs are served by this API
+	localBaseURL := os.Getenv("LOCAL_STORAGE_BASE_URL")
+	if localBaseURL == "" {
+		localBaseURL = publicURL
+	}
+	// Kept apart from JWT_SECRET, so a leaked signed link says nothing about session tokens
+	signingSecret := os.Getenv("STORAGE_SIGNING_SECRET")
+	if signingSecret != "" && signingSecret == os.Getenv("JWT_SECRET") {
+		return nil, errors.New("STORAGE_SIGNING_SECRET must differ from JWT_SECRET")
 	}
 
 	defaultQuota, err := strconv.ParseInt(os.Getenv("DEFAULT_STORAGE_QUOTA"), 10, 64)
@@ -97,6 +175,63 @@ func LoadConfig() (*Config, error) {
 	if err != nil {
 		return nil, errors.New("invalid value for API_RATE_LIMIT_WINDOW_SECONDS")
 	}
+	publicLinkRateLimit := util.ParseIntOrDefault(os.Getenv("PUBLIC_LINK_RATE_LIMIT"), 20)
+	if publicLinkRateLimit < 1 {
+		return nil, errors.New("invalid value for PUBLIC_LINK_RATE_LIMIT")
+	}
+	trashRetentionDays := util.ParseIntOrDefault(os.Getenv("TRASH_RETENTION_DAYS"), 30)
+	if trashRetentionDays < 1 {
+		return nil, errors.New("invalid value for TRASH_RETENTION_DAYS")
+	}
+	accessTokenTTLMinutes := util.ParseIntOrDefault(os.Getenv("ACCESS_TOKEN_TTL_MINUTES"), 15)
+	if accessTokenTTLMinutes < 1 {
+		return nil, errors.New("invalid value for ACCESS_TOKEN_TTL_MINUTES")
+	}
+	sessionTTLDays := util.ParseIntOrDefault(os.Getenv("SESSION_TTL_DAYS"), 30)
+	if sessionTTLDays < 1 {
+		return nil, errors.New("invalid value for SESSION_TTL_DAYS")
+	}
+
+	// Load single sign-on settings
+	oidcIssuer := os.Getenv("OIDC_ISSUER_URL")
+	if oidcIssuer != "" && os.Getenv("OIDC_CLIENT_ID") == "" {
+		return nil, errors.New("error: missing required environment variable: OIDC_CLIENT_ID")
+	}
+	oidcRedirectURL := os.Getenv("OIDC_REDIRECT_URL")
+	if oidcRedirectURL == "" {
+		oidcRedirectURL = strings.TrimSuffix(publicURL, "/") + "/auth/oidc/callback"
+	}
+	oidcScopes := splitList(os.Getenv("OIDC_SCOPES"))
+	if len(oidcScopes) == 0 {
+		oidcScopes = []string{"email", "profile"}
+	}
+	oidcGroupsClaim := os.Getenv("OIDC_GROUPS_CLAIM")
+	if oidcGroupsClaim == "" {
+		oidcGroupsClaim = "groups"
+	}
+
+	// Load mail settings, emails are only logged unless SMTP is configured
+	mailDriver := os.Getenv("MAIL_DRIVER")
+	if mailDriver == "" {
+		mailDriver = "log"
+	}
+	switch mailDriver {
+	case "log":
+	case "smtp":
+		if os.Getenv("SMTP_HOST") == "" {
+			return nil, errors.New("error: missing required environment variable: SMTP_HOST")
+		}
+	default:
+		return nil, fmt.Errorf("invalid value for MAIL_DRIVER: %s", mailDriver)
+	}
+	mailFrom := os.Getenv("MAIL_FROM")
+	if mailFrom == "" {
+		mailFrom = "FileVault <no-reply@localhost>"
+	}
+	smtpPort := util.ParseIntOrDefault(os.Getenv("SMTP_PORT"), 587)
+	if smtpPort < 1 || smtpPort > 65535 {
+		return nil, errors.New("invalid value for SMTP_PORT")
+	}
 
 	cfg := &Config{
 		Server: ServerConfig{
@@ -104,11 +239,23 @@ func LoadConfig() (*Config, error) {
 			DefaultStorageQuota:    defaultQuota,
 			RateLimit:              RateLimit,
 			RateLimitWindowSeconds: RateLimitWindowSeconds,
+			PublicLinkRateLimit:    publicLinkRateLimit,
 			JWTSecret:              os.Getenv("JWT_SECRET"),
+			PublicURL:              strings.TrimSuffix(publicURL, "/"),
+			TrashRetentionDays:     trashRetentionDays,
+			AccessTokenTTLMinutes:  accessTokenTTLMinutes,
+			SessionTTLDays:         sessionTTLDays,
+			FrontendURL:            strings.TrimSuffix(frontendURL, "/"),
 		},
 		Database: DBConfig{
 			URL: dsn,
 		},
+		Storage: StorageConfig{
+			Driver:        storageDriver,
+			LocalDir:      os.Getenv("LOCAL_STORAGE_DIR"),
+			LocalBaseURL:  strings.TrimSuffix(localBaseURL, "/"),
+			SigningSecret: signingSecret,
+		},
 		Minio: MinioConfig{
 			Endpoint: os.Getenv("MINIO_ENDPOINT"),
 			Access:   os.Getenv("MINIO_ACCESS"),
@@ -121,7 +268,36 @@ func LoadConfig() (*Config, error) {
 			Password: os.Getenv("REDIS_PASSWORD"),
 			DB:       util.ParseIntOrDefault(os.Getenv("REDIS_DB"), 0),
 		},
+		OIDC: OIDCConfig{
+			IssuerURL:    oidcIssuer,
+			ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  oidcRedirectURL,
+			Scopes:       oidcScopes,
+			GroupsClaim:  oidcGroupsClaim,
+			AdminGroups:  splitList(os.Getenv("OIDC_ADMIN_GROUPS")),
+		},
+		Mail: MailConfig{
+			Driver:       mailDriver,
+			From:         mailFrom,
+			Dir:          os.Getenv("MAIL_DIR"),
+			SMTPHost:     os.Getenv("SMTP_HOST"),
+			SMTPPort:     smtpPort,
+			SMTPUsername: os.Getenv("SMTP_USERNAME"),
+			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		},
 	}
 
 	return cfg, nil
 }
+
+// splitList splits a comma separated list, dropping blank entries.
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,2292 @@
+// Package memdb provides an in-memory implementation of the files, folders and users
+// repositories for use in tests. It mirrors the behaviour of the PostgreSQL schema
+// that the services rely on: the files insert/delete triggers that maintain blob
+// refcounts and user storage usage, ON DELETE CASCADE between folders, files and
+// shares, and pgx.ErrNoRows for missing rows. It also implements tokens.Repository,
+// sessions.Repository, sso.Repository, mfa.Repository and accounts.Repository.
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/accounts"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/files"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/folders"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/mfa"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/sessions"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/sso"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/tokens"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/api/users"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/db/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrNotSupported is returned by queries the fake does not implement,
+// such as the filtered and paginated listing queries.
+var ErrNotSupported = errors.New("memdb: query not supported")
+
+// DB is an in-memory database. A single DB implements files.Repository,
+// folders.Repository and users.Repository, so the services share one state.
+type DB struct {
+	mu              sync.Mutex
+	nextUserID      int64
+	nextShareID     int64
+	nextFolderShare int64
+	users           map[int64]sqlc.User
+	blobs           map[uuid.UUID]sqlc.Blob
+	files           map[uuid.UUID]sqlc.File
+	folders         map[uuid.UUID]sqlc.Folder
+	shares          map[int64]sqlc.FileShare
+	folderShares    map[int64]sqlc.FolderShare
+	versions        map[uuid.UUID]sqlc.FileVersion
+	uploadSessions  map[uuid.UUID]sqlc.UploadSession
+	directUploads   map[uuid.UUID]sqlc.DirectUpload
+	blobTexts       map[uuid.UUID]sqlc.BlobText
+	tags            map[uuid.UUID][]string          // item ID -> sorted tags
+	metadata        map[uuid.UUID]map[string]string // item ID -> key -> value
+	tokens          map[uuid.UUID]sqlc.PersonalAccessToken
+	sessions        map[uuid.UUID]sqlc.Session
+	identities      map[uuid.UUID]sqlc.UserIdentity
+	userMFA         map[int64]sqlc.UserMfa
+	recoveryCodes   map[uuid.UUID]sqlc.MfaRecoveryCode
+	challenges      map[string]sqlc.MfaChallenge // token hash -> challenge
+	mfaPolicies     map[string]sqlc.MfaPolicy    // role -> policy
+	emailTokens     map[string]sqlc.EmailToken   // token hash -> token
+}
+
+var (
+	_ files.Repository    = (*DB)(nil)
+	_ folders.Repository  = (*DB)(nil)
+	_ users.Repository    = (*DB)(nil)
+	_ tokens.Repository   = (*DB)(nil)
+	_ sessions.Repository = (*DB)(nil)
+	_ sso.Repository      = (*DB)(nil)
+	_ mfa.Repository      = (*DB)(nil)
+	_ accounts.Repository = (*DB)(nil)
+)
+
+// New returns an empty DB.
+func New() *DB {
+	return &DB{
+		users:          make(map[int64]sqlc.User),
+		blobs:          make(map[uuid.UUID]sqlc.Blob),
+		files:          make(map[uuid.UUID]sqlc.File),
+		folders:        make(map[uuid.UUID]sqlc.Folder),
+		shares:         make(map[int64]sqlc.FileShare),
+		folderShares:   make(map[int64]sqlc.FolderShare),
+		versions:       make(map[uuid.UUID]sqlc.FileVersion),
+		uploadSessions: make(map[uuid.UUID]sqlc.UploadSession),
+		directUploads:  make(map[uuid.UUID]sqlc.DirectUpload),
+		blobTexts:      make(map[uuid.UUID]sqlc.BlobText),
+		tags:           make(map[uuid.UUID][]string),
+		metadata:       make(map[uuid.UUID]map[string]string),
+		tokens:         make(map[uuid.UUID]sqlc.PersonalAccessToken),
+		sessions:       make(map[uuid.UUID]sqlc.Session),
+		identities:     make(map[uuid.UUID]sqlc.UserIdentity),
+		userMFA:        make(map[int64]sqlc.UserMfa),
+		recoveryCodes:  make(map[uuid.UUID]sqlc.MfaRecoveryCode),
+		challenges:     make(map[string]sqlc.MfaChallenge),
+		mfaPolicies:    make(map[string]sqlc.MfaPolicy),
+		emailTokens:    make(map[string]sqlc.EmailToken),
+	}
+}
+
+func now() pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: time.Now(), Valid: true}
+}
+
+// --- Transactions ---
+
+// tx is a no-op transaction. Changes are applied immediately and Rollback does not undo them.
+type tx struct {
+	pgx.Tx
+}
+
+func (tx) Commit(ctx context.Context) error   { return nil }
+func (tx) Rollback(ctx context.Context) error { return nil }
+
+func (db *DB) BeginTx(ctx context.Context) (pgx.Tx, error) {
+	return tx{}, nil
+}
+
+func (db *DB) WithTx(tx pgx.Tx) files.Repository {
+	return db
+}
+
+// --- Users ---
+
+func (db *DB) CreateUser(ctx context.Context, email, name string, passwordHash string, defaultStorageQuota int64) (sqlc.User, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, u := range db.users {
+		if u.Email == email {
+			return sqlc.User{}, fmt.Errorf("duplicate key value violates unique constraint on email")
+		}
+		if u.Name == name {
+			return sqlc.User{}, fmt.Errorf("duplicate key value violates unique constraint on name")
+		}
+	}
+	db.nextUserID++
+	user := sqlc.User{
+		ID:           db.nextUserID,
+		Name:         name,
+		Email:        email,
+		Password:     passwordHash,
+		Role:         "user",
+		CreatedAt:    pgtype.Timestamp{Time: time.Now(), Valid: true},
+		StorageQuota: defaultStorageQuota,
+	}
+	db.users[user.ID] = user
+	return user, nil
+}
+
+func (db *DB) GetUserByEmail(ctx context.Context, email string) (*sqlc.User, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, u := range db.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (db *DB) ListOtherUsers(ctx context.Context, userID int64) ([]sqlc.ListOtherUsersRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	rows := []sqlc.ListOtherUsersRow{}
+	for _, u := range db.users {
+		if u.ID != userID {
+			rows = append(rows, sqlc.ListOtherUsersRow{ID: u.ID, Email: u.Email, Name: u.Name})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Name < rows[j].Name })
+	return rows, nil
+}
+
+func (db *DB) GetUserByID(ctx context.Context, userID int64) (sqlc.User, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	user, ok := db.users[userID]
+	if !ok {
+		return sqlc.User{}, pgx.ErrNoRows
+	}
+	return user, nil
+}
+
+func (db *DB) GetUserByEmailFold(ctx context.Context, email string) (sqlc.User, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, u := range db.users {
+		if strings.EqualFold(u.Email, email) {
+			return u, nil
+		}
+	}
+	return sqlc.User{}, pgx.ErrNoRows
+}
+
+func (db *DB) UserNameExists(ctx context.Context, name string) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, u := range db.users {
+		if u.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (db *DB) UserSharesWith(ctx context.Context, arg sqlc.UserSharesWithParams) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, share := range db.shares {
+		if share.SharedWith == arg.SharedWith && db.files[share.FileID].OwnerID == arg.OwnerID {
+			return true, nil
+		}
+	}
+	for _, share := range db.folderShares {
+		if share.SharedWith == arg.SharedWith && db.folders[share.FolderID].OwnerID == arg.OwnerID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (db *DB) UpdateUserRole(ctx context.Context, arg sqlc.UpdateUserRoleParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	user, ok := db.users[arg.ID]
+	if !ok {
+		return nil
+	}
+	user.Role = arg.Role
+	db.users[user.ID] = user
+	return nil
+}
+
+func (db *DB) UpdateUserPassword(ctx context.Context, arg sqlc.UpdateUserPasswordParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	user, ok := db.users[arg.ID]
+	if !ok {
+		return nil
+	}
+	user.Password = arg.Password
+	db.users[user.ID] = user
+	return nil
+}
+
+func (db *DB) VerifyUserEmail(ctx context.Context, userID int64) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	user, ok := db.users[userID]
+	if !ok || user.EmailVerifiedAt.Valid {
+		return 0, nil
+	}
+	user.EmailVerifiedAt = now()
+	db.users[user.ID] = user
+	return 1, nil
+}
+
+func (db *DB) GetDeduplicatedUsage(ctx context.Context, userID int64) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	seen := make(map[uuid.UUID]bool)
+	var total int64
+	for _, f := range db.files {
+		if f.OwnerID == userID && !seen[f.BlobID] {
+			seen[f.BlobID] = true
+			total += db.blobs[f.BlobID].Size
+		}
+	}
+	for _, v := range db.versions {
+		if v.OwnerID == userID && !seen[v.BlobID] {
+			seen[v.BlobID] = true
+			total += db.blobs[v.BlobID].Size
+		}
+	}
+	return total, nil
+}
+
+// --- Blobs ---
+
+func (db *DB) GetBlobByID(ctx context.Context, id uuid.UUID) (sqlc.Blob, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	blob, ok := db.blobs[id]
+	if !ok {
+		return sqlc.Blob{}, pgx.ErrNoRows
+	}
+	return blob, nil
+}
+
+func (db *DB) GetBlobBySha(ctx context.Context, sha string) (sqlc.Blob, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, b := range db.blobs {
+		if b.Sha256 == sha {
+			return b, nil
+		}
+	}
+	return sqlc.Blob{}, pgx.ErrNoRows
+}
+
+func (db *DB) CreateBlob(ctx context.Context, arg sqlc.CreateBlobParams) (sqlc.Blob, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, b := range db.blobs {
+		if b.Sha256 == arg.Sha256 {
+			return sqlc.Blob{}, fmt.Errorf("duplicate key value violates unique constraint on sha256")
+		}
+	}
+	blob := sqlc.Blob{
+		ID:          uuid.New(),
+		Sha256:      arg.Sha256,
+		StoragePath: arg.StoragePath,
+		Size:        arg.Size,
+		MimeType:    arg.MimeType,
+		Refcount:    arg.Refcount,
+		CreatedAt:   now(),
+	}
+	db.blobs[blob.ID] = blob
+	return blob, nil
+}
+
+// DeleteBlobIfUnused deletes the blob if its refcount is zero and returns its storage path and checksum.
+// It returns pgx.ErrNoRows if the blob is missing or still referenced.
+func (db *DB) DeleteBlobIfUnused(ctx context.Context, blobID uuid.UUID) (sqlc.DeleteBlobIfUnusedRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	blob, ok := db.blobs[blobID]
+	if !ok || blob.Refcount > 0 {
+		return sqlc.DeleteBlobIfUnusedRow{}, pgx.ErrNoRows
+	}
+	delete(db.blobs, blobID)
+	delete(db.blobTexts, blobID)
+	return sqlc.DeleteBlobIfUnusedRow{StoragePath: blob.StoragePath, Sha256: blob.Sha256}, nil
+}
+
+// --- Blob texts ---
+
+func (db *DB) ListBlobsPendingTextExtraction(ctx context.Context, limit int32) ([]sqlc.ListBlobsPendingTextExtractionRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	// the name of the first uploaded file of each blob
+	first := make(map[uuid.UUID]sqlc.File)
+	for _, f := range db.files {
+		if cur, ok := first[f.BlobID]; !ok || f.UploadedAt.Time.Before(cur.UploadedAt.Time) {
+			first[f.BlobID] = f
+		}
+	}
+	rows := []sqlc.ListBlobsPendingTextExtractionRow{}
+	for id, f := range first {
+		if _, done := db.blobTexts[id]; done {
+			continue
+		}
+		b := db.blobs[id]
+		rows = append(rows, sqlc.ListBlobsPendingTextExtractionRow{
+			ID:          b.ID,
+			StoragePath: b.StoragePath,
+			Size:        b.Size,
+			MimeType:    b.MimeType,
+			Filename:    f.Filename,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		bi, bj := db.blobs[rows[i].ID], db.blobs[rows[j].ID]
+		if !bi.CreatedAt.Time.Equal(bj.CreatedAt.Time) {
+			return bi.CreatedAt.Time.Before(bj.CreatedAt.Time)
+		}
+		return rows[i].ID.String() < rows[j].ID.String()
+	})
+	if len(rows) > int(limit) {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
+
+func (db *DB) SaveBlobText(ctx context.Context, arg sqlc.SaveBlobTextParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.blobs[arg.BlobID]; !ok {
+		return fmt.Errorf("insert or update on table blob_texts violates foreign key constraint")
+	}
+	db.blobTexts[arg.BlobID] = sqlc.BlobText{
+		BlobID:      arg.BlobID,
+		Status:      arg.Status,
+		Content:     arg.Content,
+		ExtractedAt: now(),
+	}
+	return nil
+}
+
+func (db *DB) SearchFileContents(ctx context.Context, arg sqlc.SearchFileContentsParams) ([]sqlc.SearchFileContentsRow, error) {
+	return nil, ErrNotSupported
+}
+
+// --- Files ---
+
+func (db *DB) GetFileByUUID(ctx context.Context, id uuid.UUID) (sqlc.File, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	file, ok := db.files[id]
+	if !ok || file.TrashedAt.Valid {
+		return sqlc.File{}, pgx.ErrNoRows
+	}
+	return file, nil
+}
+
+// CreateFile inserts a file and applies the insert trigger: the blob refcount and
+// the owner's storage usage are incremented.
+func (db *DB) CreateFile(ctx context.Context, arg sqlc.CreateFileParams) (sqlc.File, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	blob, ok := db.blobs[arg.BlobID]
+	if !ok {
+		return sqlc.File{}, fmt.Errorf("insert on files violates foreign key constraint on blob_id")
+	}
+	user, ok := db.users[arg.OwnerID]
+	if !ok {
+		return sqlc.File{}, fmt.Errorf("insert on files violates foreign key constraint on owner_id")
+	}
+	if arg.FolderID.Valid {
+		if _, ok := db.folders[arg.FolderID.Bytes]; !ok {
+			return sqlc.File{}, fmt.Errorf("insert on files violates foreign key constraint on folder_id")
+		}
+	}
+
+	file := sqlc.File{
+		ID:             uuid.New(),
+		OwnerID:        arg.OwnerID,
+		BlobID:         arg.BlobID,
+		Filename:       arg.Filename,
+		DeclaredMime:   arg.DeclaredMime,
+		Size:           arg.Size,
+		UploadedAt:     now(),
+		IsPublic:       pgtype.Bool{Bool: false, Valid: true},
+		DownloadCount:  sql.NullInt64{Int64: 0, Valid: true},
+		FolderID:       arg.FolderID,
+		CurrentVersion: 1,
+	}
+	db.files[file.ID] = file
+
+	user.StorageUsed += file.Size
+	db.users[user.ID] = user
+	blob.Refcount++
+	db.blobs[blob.ID] = blob
+	return file, nil
+}
+
+func (db *DB) DeleteFile(ctx context.Context, fileID uuid.UUID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.deleteFile(fileID)
+	return nil
+}
+
+// deleteFile removes a file with its shares and versions and applies the delete trigger.
+// The caller must hold db.mu.
+func (db *DB) deleteFile(fileID uuid.UUID) {
+	file, ok := db.files[fileID]
+	if !ok {
+		return
+	}
+	delete(db.files, fileID)
+	delete(db.tags, fileID)
+	delete(db.metadata, fileID)
+	for id, share := range db.shares {
+		if share.FileID == fileID {
+			delete(db.shares, id)
+		}
+	}
+	for _, v := range db.versions {
+		if v.FileID == fileID {
+			db.deleteVersion(v)
+		}
+	}
+
+	if user, ok := db.users[file.OwnerID]; ok {
+		user.StorageUsed -= file.Size
+		db.users[user.ID] = user
+	}
+	if blob, ok := db.blobs[file.BlobID]; ok {
+		blob.Refcount--
+		db.blobs[blob.ID] = blob
+	}
+}
+
+func (db *DB) UpdateFilename(ctx context.Context, arg sqlc.UpdateFilenameParams) (sqlc.File, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	file, ok := db.files[arg.ID]
+	if !ok {
+		return sqlc.File{}, pgx.ErrNoRows
+	}
+	file.Filename = arg.Filename
+	db.files[file.ID] = file
+	return file, nil
+}
+
+// UpdateFileBlob points the file at another blob as its next version and applies
+// the update trigger: refcounts move from the old blob to the new one and the
+// owner's storage usage changes by the difference in size.
+func (db *DB) UpdateFileBlob(ctx context.Context, arg sqlc.UpdateFileBlobParams) (sqlc.File, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	file, ok := db.files[arg.ID]
+	if !ok {
+		return sqlc.File{}, pgx.ErrNoRows
+	}
+	newBlob, ok := db.blobs[arg.BlobID]
+	if !ok {
+		return sqlc.File{}, fmt.Errorf("update on files violates foreign key constraint on blob_id")
+	}
+
+	user := db.users[file.OwnerID]
+	user.StorageUsed += arg.Size - file.Size
+	db.users[user.ID] = user
+	if arg.BlobID != file.BlobID {
+		oldBlob := db.blobs[file.BlobID]
+		oldBlob.Refcount--
+		db.blobs[oldBlob.ID] = oldBlob
+		newBlob.Refcount++
+		db.blobs[newBlob.ID] = newBlob
+	}
+
+	file.BlobID = arg.BlobID
+	file.Size = arg.Size
+	file.DeclaredMime = arg.DeclaredMime
+	file.UploadedAt = now()
+	file.CurrentVersion++
+	db.files[file.ID] = file
+	return file, nil
+}
+
+func (db *DB) UpdateFileFolder(ctx context.Context, arg sqlc.UpdateFileFolderParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	file, ok := db.files[arg.ID]
+	if !ok {
+		return nil
+	}
+	file.FolderID = arg.FolderID
+	db.files[file.ID] = file
+	return nil
+}
+
+func (db *DB) IncrementDownloadCount(ctx context.Context, fileID uuid.UUID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	file, ok := db.files[fileID]
+	if !ok {
+		return nil
+	}
+	file.DownloadCount.Int64++
+	db.files[file.ID] = file
+	return nil
+}
+
+func (db *DB) ListFolderContents(ctx context.Context, arg sqlc.ListFolderContentsParams) ([]sqlc.ListFolderContentsRow, error) {
+	return nil, ErrNotSupported
+}
+
+func (db *DB) ListRootContents(ctx context.Context, arg sqlc.ListRootContentsParams) ([]sqlc.ListRootContentsRow, error) {
+	return nil, ErrNotSupported
+}
+
+func (db *DB) SearchContents(ctx context.Context, arg sqlc.SearchContentsParams) ([]sqlc.SearchContentsRow, error) {
+	return nil, ErrNotSupported
+}
+
+func (db *DB) ListAllFiles(ctx context.Context, arg sqlc.ListAllFilesParams) ([]sqlc.ListAllFilesRow, error) {
+	return nil, ErrNotSupported
+}
+
+// --- Shares ---
+
+func (db *DB) GetSharePermission(ctx context.Context, fileID uuid.UUID, userID int64) (string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, share := range db.shares {
+		if share.FileID == fileID && share.SharedWith == userID {
+			return share.Permission, nil
+		}
+	}
+	return "", pgx.ErrNoRows
+}
+
+func (db *DB) ListUsersWithAccessToFile(ctx context.Context, fileID uuid.UUID) ([]sqlc.ListUsersWithAccessToFileRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	rows := []sqlc.ListUsersWithAccessToFileRow{}
+	for _, share := range db.sortedShares() {
+		if share.FileID != fileID {
+			continue
+		}
+		user := db.users[share.SharedWith]
+		rows = append(rows, sqlc.ListUsersWithAccessToFileRow{
+			ID:         user.ID,
+			Name:       user.Name,
+			Email:      user.Email,
+			Permission: share.Permission,
+		})
+	}
+	return rows, nil
+}
+
+func (db *DB) DeleteAllSharesForFile(ctx context.Context, fileID uuid.UUID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for id, share := range db.shares {
+		if share.FileID == fileID {
+			delete(db.shares, id)
+		}
+	}
+	return nil
+}
+
+func (db *DB) AddSharesToFile(ctx context.Context, arg []sqlc.AddSharesToFileParams) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, a := range arg {
+		if _, ok := db.files[a.FileID]; !ok {
+			return 0, fmt.Errorf("insert on file_shares violates foreign key constraint on file_id")
+		}
+		if _, ok := db.users[a.SharedWith]; !ok {
+			return 0, fmt.Errorf("insert on file_shares violates foreign key constraint on shared_with")
+		}
+		switch a.Permission {
+		case "read", "comment", "write", "reshare":
+		default:
+			return 0, fmt.Errorf("insert on file_shares violates check constraint on permission")
+		}
+		for _, share := range db.shares {
+			if share.FileID == a.FileID && share.SharedWith == a.SharedWith {
+				return 0, fmt.Errorf("insert on file_shares violates unique constraint on (file_id, shared_with)")
+			}
+		}
+	}
+	for _, a := range arg {
+		db.nextShareID++
+		db.shares[db.nextShareID] = sqlc.FileShare{
+			ID:         db.nextShareID,
+			FileID:     a.FileID,
+			SharedWith: a.SharedWith,
+			Permission: a.Permission,
+			CreatedAt:  now(),
+		}
+	}
+	return int64(len(arg)), nil
+}
+
+// sortedShares returns all shares in insertion order. The caller must hold db.mu.
+func (db *DB) sortedShares() []sqlc.FileShare {
+	shares := make([]sqlc.FileShare, 0, len(db.shares))
+	for _, share := range db.shares {
+		shares = append(shares, share)
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].ID < shares[j].ID })
+	return shares
+}
+
+// --- Public links ---
+
+func (db *DB) EnablePublicLink(ctx context.Context, arg sqlc.EnablePublicLinkParams) (sqlc.File, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	file, ok := db.files[arg.ID]
+	if !ok {
+		return sqlc.File{}, pgx.ErrNoRows
+	}
+	file.IsPublic = pgtype.Bool{Bool: true, Valid: true}
+	if !file.PublicToken.Valid {
+		file.PublicToken = pgtype.UUID{Bytes: arg.Token, Valid: true}
+	}
+	file.PublicExpiresAt = arg.ExpiresAt
+	file.PublicMaxDownloads = arg.MaxDownloads
+	file.PublicPasswordHash = arg.PasswordHash
+	file.PublicDownloadCount = 0
+	db.files[file.ID] = file
+	return file, nil
+}
+
+func (db *DB) RotatePublicToken(ctx context.Context, arg sqlc.RotatePublicTokenParams) (sqlc.File, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	file, ok := db.files[arg.ID]
+	if !ok || !file.IsPublic.Bool {
+		return sqlc.File{}, pgx.ErrNoRows
+	}
+	file.PublicToken = pgtype.UUID{Bytes: arg.Token, Valid: true}
+	db.files[file.ID] = file
+	return file, nil
+}
+
+func (db *DB) DisablePublicLink(ctx context.Context, fileID uuid.UUID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	file, ok := db.files[fileID]
+	if !ok {
+		return nil
+	}
+	file.IsPublic = pgtype.Bool{Bool: false, Valid: true}
+	file.PublicToken = pgtype.UUID{}
+	file.PublicExpiresAt = pgtype.Timestamptz{}
+	file.PublicMaxDownloads = pgtype.Int4{}
+	file.PublicPasswordHash = pgtype.Text{}
+	file.PublicDownloadCount = 0
+	db.files[file.ID] = file
+	return nil
+}
+
+func (db *DB) GetFileByPublicToken(ctx context.Context, token uuid.UUID) (sqlc.File, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, file := range db.files {
+		if file.IsPublic.Bool && file.PublicToken.Valid && file.PublicToken.Bytes == token && !file.TrashedAt.Valid {
+			return file, nil
+		}
+	}
+	return sqlc.File{}, pgx.ErrNoRows
+}
+
+// ClaimPublicDownload increments the link and file download counts unless the
+// link is gone, expired or exhausted, in which case pgx.ErrNoRows is returned.
+func (db *DB) ClaimPublicDownload(ctx context.Context, fileID uuid.UUID) (int32, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	file, ok := db.files[fileID]
+	if !ok || !file.IsPublic.Bool || file.TrashedAt.Valid {
+		return 0, pgx.ErrNoRows
+	}
+	if file.PublicExpiresAt.Valid && !file.PublicExpiresAt.Time.After(time.Now()) {
+		return 0, pgx.ErrNoRows
+	}
+	if file.PublicMaxDownloads.Valid && file.PublicDownloadCount >= file.PublicMaxDownloads.Int32 {
+		return 0, pgx.ErrNoRows
+	}
+	file.PublicDownloadCount++
+	file.DownloadCount.Int64++
+	db.files[file.ID] = file
+	return file.PublicDownloadCount, nil
+}
+
+// --- Versions ---
+
+// ArchiveCurrentVersion copies the current contents of the file into its history
+// and applies the version insert trigger.
+func (db *DB) ArchiveCurrentVersion(ctx context.Context, fileID uuid.UUID) (sqlc.FileVersion, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	file, ok := db.files[fileID]
+	if !ok {
+		return sqlc.FileVersion{}, pgx.ErrNoRows
+	}
+	for _, v := range db.versions {
+		if v.FileID == fileID && v.Version == file.CurrentVersion {
+			return sqlc.FileVersion{}, fmt.Errorf("insert on file_versions violates unique constraint on (file_id, version)")
+		}
+	}
+
+	v := sqlc.FileVersion{
+		ID:           uuid.New(),
+		FileID:       file.ID,
+		OwnerID:      file.OwnerID,
+		Version:      file.CurrentVersion,
+		BlobID:       file.BlobID,
+		Size:         file.Size,
+		DeclaredMime: file.DeclaredMime,
+		CreatedAt:    file.UploadedAt,
+	}
+	db.versions[v.ID] = v
+
+	user := db.users[v.OwnerID]
+	user.StorageUsed += v.Size
+	db.users[user.ID] = user
+	blob := db.blobs[v.BlobID]
+	blob.Refcount++
+	db.blobs[blob.ID] = blob
+	return v, nil
+}
+
+func (db *DB) ListFileVersions(ctx context.Context, fileID uuid.UUID) ([]sqlc.FileVersion, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.fileVersions(fileID), nil
+}
+
+func (db *DB) GetFileVersion(ctx context.Context, fileID uuid.UUID, version int32) (sqlc.FileVersion, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, v := range db.versions {
+		if v.FileID == fileID && v.Version == version {
+			return v, nil
+		}
+	}
+	return sqlc.FileVersion{}, pgx.ErrNoRows
+}
+
+func (db *DB) DeleteFileVersion(ctx context.Context, fileID uuid.UUID, version int32) (uuid.UUID, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, v := range db.versions {
+		if v.FileID == fileID && v.Version == version {
+			db.deleteVersion(v)
+			return v.BlobID, nil
+		}
+	}
+	return uuid.Nil, pgx.ErrNoRows
+}
+
+func (db *DB) PruneFileVersions(ctx context.Context, fileID uuid.UUID, keep int32) ([]uuid.UUID, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	blobIDs := []uuid.UUID{}
+	for i, v := range db.fileVersions(fileID) {
+		if i >= int(keep) {
+			db.deleteVersion(v)
+			blobIDs = append(blobIDs, v.BlobID)
+		}
+	}
+	return blobIDs, nil
+}
+
+// fileVersions returns the versions of a file, newest first. The caller must hold db.mu.
+func (db *DB) fileVersions(fileID uuid.UUID) []sqlc.FileVersion {
+	versions := []sqlc.FileVersion{}
+	for _, v := range db.versions {
+		if v.FileID == fileID {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version > versions[j].Version })
+	return versions
+}
+
+// deleteVersion removes a version and applies the version delete trigger.
+// The caller must hold db.mu.
+func (db *DB) deleteVersion(v sqlc.FileVersion) {
+	delete(db.versions, v.ID)
+	if user, ok := db.users[v.OwnerID]; ok {
+		user.StorageUsed -= v.Size
+		db.users[user.ID] = user
+	}
+	if blob, ok := db.blobs[v.BlobID]; ok {
+		blob.Refcount--
+		db.blobs[blob.ID] = blob
+	}
+}
+
+// --- Folders ---
+
+func (db *DB) CreateFolder(ctx context.Context, arg sqlc.CreateFolderParams) (sqlc.Folder, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.createFolder(arg)
+}
+
+// CreateFolderIfNotExists checks for the name and inserts the folder under one
+// lock, as LockFolderName and the query do in a transaction. Returns
+// pgx.ErrNoRows if the owner has a folder of that name in the parent.
+func (db *DB) CreateFolderIfNotExists(ctx context.Context, arg sqlc.CreateFolderIfNotExistsParams) (sqlc.Folder, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, f := range db.folders {
+		if f.OwnerID == arg.OwnerID && f.Name == arg.Name && f.ParentFolderID == arg.ParentFolderID && !f.TrashedAt.Valid {
+			return sqlc.Folder{}, pgx.ErrNoRows
+		}
+	}
+	return db.createFolder(sqlc.CreateFolderParams(arg))
+}
+
+// createFolder inserts a folder. The caller must hold db.mu.
+func (db *DB) createFolder(arg sqlc.CreateFolderParams) (sqlc.Folder, error) {
+	if arg.ParentFolderID.Valid {
+		if _, ok := db.folders[arg.ParentFolderID.Bytes]; !ok {
+			return sqlc.Folder{}, fmt.Errorf("insert on folders violates foreign key constraint on parent_folder_id")
+		}
+	}
+	folder := sqlc.Folder{
+		ID:             uuid.New(),
+		Name:           arg.Name,
+		OwnerID:        arg.OwnerID,
+		ParentFolderID: arg.ParentFolderID,
+		CreatedAt:      now(),
+	}
+	db.folders[folder.ID] = folder
+	return folder, nil
+}
+
+func (db *DB) GetFolderByID(ctx context.Context, folderID uuid.UUID) (sqlc.Folder, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	folder, ok := db.folders[folderID]
+	if !ok || folder.TrashedAt.Valid {
+		return sqlc.Folder{}, pgx.ErrNoRows
+	}
+	return folder, nil
+}
+
+func (db *DB) GetFolderByName(ctx context.Context, arg sqlc.GetFolderByNameParams) (sqlc.Folder, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var found *sqlc.Folder
+	for _, f := range db.folders {
+		if f.OwnerID != arg.OwnerID || f.Name != arg.Name || f.TrashedAt.Valid || f.ParentFolderID != arg.ParentFolderID {
+			continue
+		}
+		if found == nil || f.CreatedAt.Time.Before(found.CreatedAt.Time) {
+			found = &f
+		}
+	}
+	if found == nil {
+		return sqlc.Folder{}, pgx.ErrNoRows
+	}
+	return *found, nil
+}
+
+func (db *DB) UpdateFolder(ctx context.Context, arg sqlc.UpdateFolderParams) (sqlc.UpdateFolderRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	folder, ok := db.folders[arg.ID]
+	if !ok {
+		return sqlc.UpdateFolderRow{}, pgx.ErrNoRows
+	}
+	folder.Name = arg.Name
+	folder.ParentFolderID = arg.ParentFolderID
+	db.folders[folder.ID] = folder
+	return sqlc.UpdateFolderRow{
+		ID:             folder.ID,
+		Filename:       folder.Name,
+		OwnerID:        folder.OwnerID,
+		ParentFolderID: folder.ParentFolderID,
+		CreatedAt:      folder.CreatedAt,
+	}, nil
+}
+
+func (db *DB) UpdateFolderParentFolder(ctx context.Context, arg sqlc.UpdateFolderParentFolderParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	folder, ok := db.folders[arg.ID]
+	if !ok {
+		return nil
+	}
+	folder.ParentFolderID = arg.ParentFolderID
+	db.folders[folder.ID] = folder
+	return nil
+}
+
+// DeleteFolder deletes the folder and cascades to its subfolders, the files inside
+// them (running the delete trigger for each) and pending uploads targeting them.
+func (db *DB) DeleteFolder(ctx context.Context, folderID uuid.UUID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	hierarchy := db.folderHierarchy(folderID)
+	for id, file := range db.files {
+		if file.FolderID.Valid && hierarchy[file.FolderID.Bytes] {
+			db.deleteFile(id)
+		}
+	}
+	for id, session := range db.uploadSessions {
+		if session.FolderID.Valid && hierarchy[session.FolderID.Bytes] {
+			delete(db.uploadSessions, id)
+		}
+	}
+	for id, upload := range db.directUploads {
+		if upload.FolderID.Valid && hierarchy[upload.FolderID.Bytes] {
+			delete(db.directUploads, id)
+		}
+	}
+	for id, share := range db.folderShares {
+		if hierarchy[share.FolderID] {
+			delete(db.folderShares, id)
+		}
+	}
+	for id := range hierarchy {
+		delete(db.folders, id)
+		delete(db.tags, id)
+		delete(db.metadata, id)
+	}
+	return nil
+}
+
+func (db *DB) GetBlobIDsInFolderHierarchy(ctx context.Context, folderID uuid.UUID) ([]uuid.UUID, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	hierarchy := db.folderHierarchy(folderID)
+	seen := make(map[uuid.UUID]bool)
+	blobIDs := []uuid.UUID{}
+	for _, file := range db.files {
+		if file.FolderID.Valid && hierarchy[file.FolderID.Bytes] && !seen[file.BlobID] {
+			seen[file.BlobID] = true
+			blobIDs = append(blobIDs, file.BlobID)
+		}
+	}
+	for _, v := range db.versions {
+		file := db.files[v.FileID]
+		if file.FolderID.Valid && hierarchy[file.FolderID.Bytes] && !seen[v.BlobID] {
+			seen[v.BlobID] = true
+			blobIDs = append(blobIDs, v.BlobID)
+		}
+	}
+	return blobIDs, nil
+}
+
+func (db *DB) ListFolderArchiveEntries(ctx context.Context, folderID uuid.UUID) ([]sqlc.ListFolderArchiveEntriesRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	root, ok := db.folders[folderID]
+	if !ok || root.TrashedAt.Valid {
+		return []sqlc.ListFolderArchiveEntriesRow{}, nil
+	}
+	paths := map[uuid.UUID][]string{folderID: {root.Name}}
+	for grown := true; grown; {
+		grown = false
+		for _, f := range db.folders {
+			parent, ok := paths[f.ParentFolderID.Bytes]
+			if _, seen := paths[f.ID]; !seen && ok && f.ParentFolderID.Valid && !f.TrashedAt.Valid {
+				paths[f.ID] = append(append([]string{}, parent...), f.Name)
+				grown = true
+			}
+		}
+	}
+	rows := []sqlc.ListFolderArchiveEntriesRow{}
+	for id, path := range paths {
+		empty := true
+		for _, file := range db.files {
+			if !file.FolderID.Valid || file.FolderID.Bytes != id || file.TrashedAt.Valid {
+				continue
+			}
+			empty = false
+			rows = append(rows, sqlc.ListFolderArchiveEntriesRow{
+				FolderPath:  path,
+				FileID:      pgtype.UUID{Bytes: file.ID, Valid: true},
+				Filename:    pgtype.Text{String: file.Filename, Valid: true},
+				Size:        sql.NullInt64{Int64: file.Size, Valid: true},
+				UploadedAt:  file.UploadedAt,
+				StoragePath: pgtype.Text{String: db.blobs[file.BlobID].StoragePath, Valid: true},
+			})
+		}
+		if empty {
+			rows = append(rows, sqlc.ListFolderArchiveEntriesRow{FolderPath: path})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if c := slices.Compare(rows[i].FolderPath, rows[j].FolderPath); c != 0 {
+			return c < 0
+		}
+		if rows[i].Filename.String != rows[j].Filename.String {
+			return rows[i].Filename.String < rows[j].Filename.String
+		}
+		return rows[i].UploadedAt.Time.Before(rows[j].UploadedAt.Time)
+	})
+	return rows, nil
+}
+
+func (db *DB) CopyFolderTree(ctx context.Context, arg sqlc.CopyFolderTreeParams) (uuid.UUID, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	root, ok := db.folders[arg.SourceFolderID]
+	if !ok || root.TrashedAt.Valid {
+		return uuid.UUID{}, pgx.ErrNoRows
+	}
+	if arg.TargetFolderID.Valid {
+		if _, ok := db.folders[arg.TargetFolderID.Bytes]; !ok {
+			return uuid.UUID{}, fmt.Errorf("insert on folders violates foreign key constraint on parent_folder_id")
+		}
+	}
+	owner, ok := db.users[arg.OwnerID]
+	if !ok {
+		return uuid.UUID{}, fmt.Errorf("insert on folders violates foreign key constraint on owner_id")
+	}
+
+	// map the copied folders to their copies before inserting anything, so a
+	// folder copied into its own subtree is copied as it was
+	copies := map[uuid.UUID]uuid.UUID{root.ID: uuid.New()}
+	for grown := true; grown; {
+		grown = false
+		for _, f := range db.folders {
+			if _, seen := copies[f.ID]; !seen && f.ParentFolderID.Valid && !f.TrashedAt.Valid {
+				if _, ok := copies[f.ParentFolderID.Bytes]; ok {
+					copies[f.ID] = uuid.New()
+					grown = true
+				}
+			}
+		}
+	}
+	var files []sqlc.File
+	for _, file := range db.files {
+		if _, ok := copies[file.FolderID.Bytes]; ok && file.FolderID.Valid && !file.TrashedAt.Valid {
+			files = append(files, file)
+		}
+	}
+
+	for id, copyID := range copies {
+		folder := db.folders[id]
+		copied := sqlc.Folder{
+			ID:             copyID,
+			Name:           folder.Name,
+			OwnerID:        arg.OwnerID,
+			ParentFolderID: pgtype.UUID{Bytes: copies[folder.ParentFolderID.Bytes], Valid: true},
+			CreatedAt:      now(),
+		}
+		if id == root.ID {
+			copied.Name = arg.Name
+			copied.ParentFolderID = arg.TargetFolderID
+		}
+		db.folders[copyID] = copied
+	}
+	for _, file := range files {
+		copied := sqlc.File{
+			ID:             uuid.New(),
+			OwnerID:        arg.OwnerID,
+			BlobID:         file.BlobID,
+			Filename:       file.Filename,
+			DeclaredMime:   file.DeclaredMime,
+			Size:           file.Size,
+			UploadedAt:     now(),
+			IsPublic:       pgtype.Bool{Bool: false, Valid: true},
+			DownloadCount:  sql.NullInt64{Int64: 0, Valid: true},
+			FolderID:       pgtype.UUID{Bytes: copies[file.FolderID.Bytes], Valid: true},
+			CurrentVersion: 1,
+		}
+		db.files[copied.ID] = copied
+
+		owner.StorageUsed += copied.Size
+		blob := db.blobs[copied.BlobID]
+		blob.Refcount++
+		db.blobs[blob.ID] = blob
+	}
+	db.users[owner.ID] = owner
+	return copies[root.ID], nil
+}
+
+func (db *DB) ListSelectableFolders(ctx context.Context, arg sqlc.ListSelectableFoldersParams) ([]sqlc.ListSelectableFoldersRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	forbidden := map[uuid.UUID]bool{}
+	if arg.CurrentFolderID.Valid {
+		forbidden = db.folderHierarchy(arg.CurrentFolderID.Bytes)
+	}
+	rows := []sqlc.ListSelectableFoldersRow{}
+	for _, f := range db.folders {
+		if f.OwnerID == arg.OwnerID && !f.TrashedAt.Valid && !forbidden[f.ID] {
+			rows = append(rows, sqlc.ListSelectableFoldersRow{
+				ID:             f.ID,
+				Name:           f.Name,
+				CreatedAt:      f.CreatedAt,
+				ParentFolderID: f.ParentFolderID,
+			})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].CreatedAt.Time.After(rows[j].CreatedAt.Time) })
+	return rows, nil
+}
+
+func (db *DB) GetInheritedFolderPermissions(ctx context.Context, folderID uuid.UUID, userID int64) ([]string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	ancestors := map[uuid.UUID]bool{}
+	for folder, ok := db.folders[folderID]; ok && !ancestors[folder.ID]; {
+		ancestors[folder.ID] = true
+		if !folder.ParentFolderID.Valid {
+			break
+		}
+		folder, ok = db.folders[folder.ParentFolderID.Bytes]
+	}
+	permissions := []string{}
+	for _, share := range db.folderShares {
+		if ancestors[share.FolderID] && share.SharedWith == userID {
+			permissions = append(permissions, share.Permission)
+		}
+	}
+	return permissions, nil
+}
+
+func (db *DB) ListFolderShares(ctx context.Context, folderID uuid.UUID) ([]sqlc.ListFolderSharesRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	shares := make([]sqlc.FolderShare, 0, len(db.folderShares))
+	for _, share := range db.folderShares {
+		if share.FolderID == folderID {
+			shares = append(shares, share)
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].ID < shares[j].ID })
+	rows := []sqlc.ListFolderSharesRow{}
+	for _, share := range shares {
+		user := db.users[share.SharedWith]
+		rows = append(rows, sqlc.ListFolderSharesRow{
+			ID:         user.ID,
+			Name:       user.Name,
+			Email:      user.Email,
+			Permission: share.Permission,
+		})
+	}
+	return rows, nil
+}
+
+func (db *DB) ReplaceFolderShares(ctx context.Context, arg sqlc.ReplaceFolderSharesParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if len(arg.UserIds) != len(arg.Permissions) {
+		return fmt.Errorf("user_ids and permissions differ in length")
+	}
+	if _, ok := db.folders[arg.FolderID]; !ok && len(arg.UserIds) > 0 {
+		return fmt.Errorf("insert on folder_shares violates foreign key constraint on folder_id")
+	}
+	keep := make(map[int64]string, len(arg.UserIds))
+	for i, userID := range arg.UserIds {
+		if _, ok := db.users[userID]; !ok {
+			return fmt.Errorf("insert on folder_shares violates foreign key constraint on shared_with")
+		}
+		switch arg.Permissions[i] {
+		case "read", "comment", "write", "reshare":
+		default:
+			return fmt.Errorf("insert on folder_shares violates check constraint on permission")
+		}
+		keep[userID] = arg.Permissions[i]
+	}
+	for id, share := range db.folderShares {
+		if share.FolderID != arg.FolderID {
+			continue
+		}
+		if permission, ok := keep[share.SharedWith]; ok {
+			share.Permission = permission
+			db.folderShares[id] = share
+			delete(keep, share.SharedWith)
+		} else {
+			delete(db.folderShares, id)
+		}
+	}
+	for _, userID := range arg.UserIds {
+		permission, ok := keep[userID]
+		if !ok {
+			continue
+		}
+		db.nextFolderShare++
+		db.folderShares[db.nextFolderShare] = sqlc.FolderShare{
+			ID:         db.nextFolderShare,
+			FolderID:   arg.FolderID,
+			SharedWith: userID,
+			Permission: permission,
+			CreatedAt:  now(),
+		}
+		delete(keep, userID)
+	}
+	return nil
+}
+
+// folderHierarchy returns the IDs of the folder and all of its descendants.
+// The caller must hold db.mu.
+func (db *DB) folderHierarchy(folderID uuid.UUID) map[uuid.UUID]bool {
+	hierarchy := map[uuid.UUID]bool{}
+	if _, ok := db.folders[folderID]; !ok {
+		return hierarchy
+	}
+	hierarchy[folderID] = true
+	for grown := true; grown; {
+		grown = false
+		for _, f := range db.folders {
+			if f.ParentFolderID.Valid && hierarchy[f.ParentFolderID.Bytes] && !hierarchy[f.ID] {
+				hierarchy[f.ID] = true
+				grown = true
+			}
+		}
+	}
+	return hierarchy
+}
+
+// --- Trash ---
+
+func (db *DB) TrashFile(ctx context.Context, fileID uuid.UUID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	file, ok := db.files[fileID]
+	if !ok || file.TrashedAt.Valid {
+		return nil
+	}
+	file.TrashedAt = now()
+	file.TrashedWith = pgtype.UUID{}
+	db.files[file.ID] = file
+	return nil
+}
+
+// TrashFolder trashes the folder and its descendants that are not in the trash yet,
+// marking the descendants as trashed with the folder.
+func (db *DB) TrashFolder(ctx context.Context, folderID uuid.UUID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if folder, ok := db.folders[folderID]; !ok || folder.TrashedAt.Valid {
+		return nil
+	}
+	trashedAt := now()
+	with := pgtype.UUID{Bytes: folderID, Valid: true}
+	subtree := map[uuid.UUID]bool{folderID: true}
+	for grown := true; grown; {
+		grown = false
+		for _, f := range db.folders {
+			if f.ParentFolderID.Valid && subtree[f.ParentFolderID.Bytes] && !subtree[f.ID] && !f.TrashedAt.Valid {
+				subtree[f.ID] = true
+				grown = true
+			}
+		}
+	}
+	for id := range subtree {
+		folder := db.folders[id]
+		folder.TrashedAt = trashedAt
+		if id != folderID {
+			folder.TrashedWith = with
+		}
+		db.folders[id] = folder
+	}
+	for id, file := range db.files {
+		if file.FolderID.Valid && subtree[file.FolderID.Bytes] && !file.TrashedAt.Valid {
+			file.TrashedAt = trashedAt
+			file.TrashedWith = with
+			db.files[id] = file
+		}
+	}
+	return nil
+}
+
+func (db *DB) GetTrashedFile(ctx context.Context, fileID uuid.UUID) (sqlc.File, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	file, ok := db.files[fileID]
+	if !ok || !file.TrashedAt.Valid || file.TrashedWith.Valid {
+		return sqlc.File{}, pgx.ErrNoRows
+	}
+	return file, nil
+}
+
+func (db *DB) GetTrashedFolder(ctx context.Context, folderID uuid.UUID) (sqlc.Folder, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	folder, ok := db.folders[folderID]
+	if !ok || !folder.TrashedAt.Valid || folder.TrashedWith.Valid {
+		return sqlc.Folder{}, pgx.ErrNoRows
+	}
+	return folder, nil
+}
+
+// RestoreFile takes a trashed file out of the trash, moving it to the root
+// if its folder is in the trash.
+func (db *DB) RestoreFile(ctx context.Context, fileID uuid.UUID) (sqlc.File, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	file, ok := db.files[fileID]
+	if !ok || !file.TrashedAt.Valid || file.TrashedWith.Valid {
+		return sqlc.File{}, pgx.ErrNoRows
+	}
+	if file.FolderID.Valid && db.folders[file.FolderID.Bytes].TrashedAt.Valid {
+		file.FolderID = pgtype.UUID{}
+	}
+	file.TrashedAt = pgtype.Timestamptz{}
+	db.files[file.ID] = file
+	return file, nil
+}
+
+// RestoreFolder takes a trashed folder and everything trashed with it out of the
+// trash, moving the folder to the root if its parent is in the trash.
+func (db *DB) RestoreFolder(ctx context.Context, folderID uuid.UUID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	folder, ok := db.folders[folderID]
+	if !ok || !folder.TrashedAt.Valid || folder.TrashedWith.Valid {
+		return nil
+	}
+	for id, file := range db.files {
+		if file.TrashedWith.Valid && file.TrashedWith.Bytes == folderID {
+			file.TrashedAt = pgtype.Timestamptz{}
+			file.TrashedWith = pgtype.UUID{}
+			db.files[id] = file
+		}
+	}
+	for id, f := range db.folders {
+		if f.TrashedWith.Valid && f.TrashedWith.Bytes == folderID {
+			f.TrashedAt = pgtype.Timestamptz{}
+			f.TrashedWith = pgtype.UUID{}
+			db.folders[id] = f
+		}
+	}
+	if folder.ParentFolderID.Valid && db.folders[folder.ParentFolderID.Bytes].TrashedAt.Valid {
+		folder.ParentFolderID = pgtype.UUID{}
+	}
+	folder.TrashedAt = pgtype.Timestamptz{}
+	db.folders[folder.ID] = folder
+	return nil
+}
+
+func (db *DB) ListTrash(ctx context.Context, ownerID int64) ([]sqlc.ListTrashRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	rows := []sqlc.ListTrashRow{}
+	for _, f := range db.folders {
+		if f.OwnerID == ownerID && f.TrashedAt.Valid && !f.TrashedWith.Valid {
+			rows = append(rows, sqlc.ListTrashRow{
+				ID:        f.ID,
+				Filename:  f.Name,
+				ItemType:  "folder",
+				FolderID:  f.ParentFolderID,
+				TrashedAt: f.TrashedAt,
+			})
+		}
+	}
+	for _, f := range db.files {
+		if f.OwnerID == ownerID && f.TrashedAt.Valid && !f.TrashedWith.Valid {
+			rows = append(rows, sqlc.ListTrashRow{
+				ID:          f.ID,
+				Filename:    f.Filename,
+				ItemType:    "file",
+				Size:        sql.NullInt64{Int64: f.Size, Valid: true},
+				ContentType: f.DeclaredMime,
+				FolderID:    f.FolderID,
+				TrashedAt:   f.TrashedAt,
+			})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].TrashedAt.Time.After(rows[j].TrashedAt.Time) })
+	return rows, nil
+}
+
+func (db *DB) ListExpiredTrash(ctx context.Context, before pgtype.Timestamptz) ([]sqlc.ListExpiredTrashRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	rows := []sqlc.ListExpiredTrashRow{}
+	for _, f := range db.folders {
+		if f.TrashedAt.Valid && !f.TrashedWith.Valid && f.TrashedAt.Time.Before(before.Time) {
+			rows = append(rows, sqlc.ListExpiredTrashRow{ID: f.ID, OwnerID: f.OwnerID, ItemType: "folder"})
+		}
+	}
+	for _, f := range db.files {
+		if f.TrashedAt.Valid && !f.TrashedWith.Valid && f.TrashedAt.Time.Before(before.Time) {
+			rows = append(rows, sqlc.ListExpiredTrashRow{ID: f.ID, OwnerID: f.OwnerID, ItemType: "file"})
+		}
+	}
+	return rows, nil
+}
+
+// --- Uploads ---
+
+func (db *DB) CreateUploadSession(ctx context.Context, arg sqlc.CreateUploadSessionParams) (sqlc.UploadSession, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	session := sqlc.UploadSession{
+		ID:           uuid.New(),
+		OwnerID:      arg.OwnerID,
+		FolderID:     arg.FolderID,
+		Filename:     arg.Filename,
+		DeclaredMime: arg.DeclaredMime,
+		UploadLength: arg.UploadLength,
+		CreatedAt:    now(),
+		ChunkKeys:    []string{},
+		ExpiresAt:    arg.ExpiresAt,
+	}
+	db.uploadSessions[session.ID] = session
+	return session, nil
+}
+
+func (db *DB) GetUploadSession(ctx context.Context, id uuid.UUID) (sqlc.UploadSession, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	session, ok := db.uploadSessions[id]
+	if !ok {
+		return sqlc.UploadSession{}, pgx.ErrNoRows
+	}
+	return session, nil
+}
+
+func (db *DB) AdvanceUploadSession(ctx context.Context, arg sqlc.AdvanceUploadSessionParams) (sqlc.UploadSession, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	session, ok := db.uploadSessions[arg.ID]
+	if !ok || session.UploadOffset != arg.ExpectedOffset || !session.ExpiresAt.Time.After(time.Now()) {
+		return sqlc.UploadSession{}, pgx.ErrNoRows
+	}
+	session.UploadOffset += arg.ChunkSize
+	session.ChunkKeys = append(slices.Clip(session.ChunkKeys), arg.ChunkKey)
+	session.ExpiresAt = arg.ExpiresAt
+	db.uploadSessions[session.ID] = session
+	return session, nil
+}
+
+func (db *DB) ClaimUploadSession(ctx context.Context, id uuid.UUID) (sqlc.UploadSession, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	session, ok := db.uploadSessions[id]
+	if !ok || session.UploadOffset != session.UploadLength || session.CompletingAt.Valid || !session.ExpiresAt.Time.After(time.Now()) {
+		return sqlc.UploadSession{}, pgx.ErrNoRows
+	}
+	session.CompletingAt = now()
+	db.uploadSessions[id] = session
+	return session, nil
+}
+
+func (db *DB) ReleaseUploadSession(ctx context.Context, id uuid.UUID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if session, ok := db.uploadSessions[id]; ok {
+		session.CompletingAt = pgtype.Timestamptz{}
+		db.uploadSessions[id] = session
+	}
+	return nil
+}
+
+func (db *DB) AbortUploadSession(ctx context.Context, id uuid.UUID) (sqlc.UploadSession, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	session, ok := db.uploadSessions[id]
+	if !ok || session.CompletingAt.Valid {
+		return sqlc.UploadSession{}, pgx.ErrNoRows
+	}
+	delete(db.uploadSessions, id)
+	return session, nil
+}
+
+func (db *DB) DeleteUploadSession(ctx context.Context, id uuid.UUID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	delete(db.uploadSessions, id)
+	return nil
+}
+
+func (db *DB) DeleteExpiredUploadSessions(ctx context.Context) ([]sqlc.UploadSession, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	expired := []sqlc.UploadSession{}
+	for id, session := range db.uploadSessions {
+		if !session.ExpiresAt.Time.After(time.Now()) {
+			expired = append(expired, session)
+			delete(db.uploadSessions, id)
+		}
+	}
+	return expired, nil
+}
+
+func (db *DB) CreateDirectUpload(ctx context.Context, arg sqlc.CreateDirectUploadParams) (sqlc.DirectUpload, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	upload := sqlc.DirectUpload{
+		ID:           uuid.New(),
+		OwnerID:      arg.OwnerID,
+		FolderID:     arg.FolderID,
+		Filename:     arg.Filename,
+		DeclaredMime: arg.DeclaredMime,
+		Size:         arg.Size,
+		Sha256:       arg.Sha256,
+		StagingPath:  arg.StagingPath,
+		ExpiresAt:    arg.ExpiresAt,
+		CreatedAt:    now(),
+	}
+	db.directUploads[upload.ID] = upload
+	return upload, nil
+}
+
+func (db *DB) GetDirectUpload(ctx context.Context, id uuid.UUID) (sqlc.DirectUpload, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	upload, ok := db.directUploads[id]
+	if !ok {
+		return sqlc.DirectUpload{}, pgx.ErrNoRows
+	}
+	return upload, nil
+}
+
+func (db *DB) DeleteDirectUpload(ctx context.Context, id uuid.UUID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	delete(db.directUploads, id)
+	return nil
+}
+
+func (db *DB) ClaimDirectUpload(ctx context.Context, arg sqlc.ClaimDirectUploadParams) (sqlc.DirectUpload, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	upload, ok := db.directUploads[arg.ID]
+	if !ok || upload.OwnerID != arg.OwnerID || !upload.ExpiresAt.Time.After(time.Now()) || upload.ClaimedAt.Valid {
+		return sqlc.DirectUpload{}, pgx.ErrNoRows
+	}
+	upload.ClaimedAt = now()
+	db.directUploads[arg.ID] = upload
+	return upload, nil
+}
+
+func (db *DB) DeleteExpiredDirectUploads(ctx context.Context, expiredBefore pgtype.Timestamptz) ([]sqlc.DirectUpload, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	expired := []sqlc.DirectUpload{}
+	for id, upload := range db.directUploads {
+		if !upload.ExpiresAt.Time.After(expiredBefore.Time) {
+			expired = append(expired, upload)
+			delete(db.directUploads, id)
+		}
+	}
+	return expired, nil
+}
+
+// --- Tags and metadata ---
+
+func (db *DB) AddItemTags(ctx context.Context, arg sqlc.AddItemTagsParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, id := range append(slices.Clone(arg.FileIds), arg.FolderIds...) {
+		tags := db.tags[id]
+		for _, tag := range arg.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		slices.Sort(tags)
+		db.tags[id] = tags
+	}
+	return nil
+}
+
+func (db *DB) RemoveItemTags(ctx context.Context, arg sqlc.RemoveItemTagsParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, id := range arg.ItemIds {
+		tags := slices.DeleteFunc(db.tags[id], func(tag string) bool { return slices.Contains(arg.Tags, tag) })
+		if len(tags) == 0 {
+			delete(db.tags, id)
+		} else {
+			db.tags[id] = tags
+		}
+	}
+	return nil
+}
+
+func (db *DB) SetItemMetadata(ctx context.Context, arg sqlc.SetItemMetadataParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, id := range append(slices.Clone(arg.FileIds), arg.FolderIds...) {
+		if db.metadata[id] == nil {
+			db.metadata[id] = make(map[string]string)
+		}
+		for i, key := range arg.Keys {
+			db.metadata[id][key] = arg.Values[i]
+		}
+	}
+	return nil
+}
+
+func (db *DB) RemoveItemMetadata(ctx context.Context, arg sqlc.RemoveItemMetadataParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, id := range arg.ItemIds {
+		for _, key := range arg.Keys {
+			delete(db.metadata[id], key)
+		}
+		if len(db.metadata[id]) == 0 {
+			delete(db.metadata, id)
+		}
+	}
+	return nil
+}
+
+func (db *DB) ListTagSuggestions(ctx context.Context, arg sqlc.ListTagSuggestionsParams) ([]sqlc.ListTagSuggestionsRow, error) {
+	return nil, ErrNotSupported
+}
+
+// --- Personal access tokens ---
+
+// activeToken reports whether a token is neither revoked nor expired.
+func activeToken(t sqlc.PersonalAccessToken) bool {
+	return !t.RevokedAt.Valid && (!t.ExpiresAt.Valid || t.ExpiresAt.Time.After(time.Now()))
+}
+
+func (db *DB) CreatePersonalAccessToken(ctx context.Context, arg sqlc.CreatePersonalAccessTokenParams) (sqlc.PersonalAccessToken, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, t := range db.tokens {
+		if t.TokenHash == arg.TokenHash {
+			return sqlc.PersonalAccessToken{}, fmt.Errorf("duplicate key value violates unique constraint on token_hash")
+		}
+	}
+	token := sqlc.PersonalAccessToken{
+		ID:          uuid.New(),
+		UserID:      arg.UserID,
+		Name:        arg.Name,
+		TokenHash:   arg.TokenHash,
+		TokenPrefix: arg.TokenPrefix,
+		Scopes:      slices.Clone(arg.Scopes),
+		ExpiresAt:   arg.ExpiresAt,
+		CreatedAt:   now(),
+	}
+	db.tokens[token.ID] = token
+	return token, nil
+}
+
+func (db *DB) ListPersonalAccessTokens(ctx context.Context, userID int64) ([]sqlc.PersonalAccessToken, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	list := []sqlc.PersonalAccessToken{}
+	for _, t := range db.tokens {
+		if t.UserID == userID && !t.RevokedAt.Valid {
+			list = append(list, t)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Time.After(list[j].CreatedAt.Time) })
+	return list, nil
+}
+
+func (db *DB) CountActivePersonalAccessTokens(ctx context.Context, userID int64) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var count int64
+	for _, t := range db.tokens {
+		if t.UserID == userID && activeToken(t) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (db *DB) RevokePersonalAccessToken(ctx context.Context, arg sqlc.RevokePersonalAccessTokenParams) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	token, ok := db.tokens[arg.ID]
+	if !ok || token.UserID != arg.UserID || token.RevokedAt.Valid {
+		return 0, nil
+	}
+	token.RevokedAt = now()
+	db.tokens[token.ID] = token
+	return 1, nil
+}
+
+func (db *DB) RevokeAllPersonalAccessTokens(ctx context.Context, userID int64) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var revoked int64
+	for id, t := range db.tokens {
+		if t.UserID == userID && !t.RevokedAt.Valid {
+			t.RevokedAt = now()
+			db.tokens[id] = t
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func (db *DB) GetActivePersonalAccessToken(ctx context.Context, tokenHash string) (sqlc.PersonalAccessToken, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, t := range db.tokens {
+		if t.TokenHash == tokenHash && activeToken(t) {
+			return t, nil
+		}
+	}
+	return sqlc.PersonalAccessToken{}, pgx.ErrNoRows
+}
+
+func (db *DB) TouchPersonalAccessToken(ctx context.Context, tokenID uuid.UUID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	token, ok := db.tokens[tokenID]
+	if !ok {
+		return nil
+	}
+	if !token.LastUsedAt.Valid || token.LastUsedAt.Time.Before(time.Now().Add(-time.Minute)) {
+		token.LastUsedAt = now()
+		db.tokens[token.ID] = token
+	}
+	return nil
+}
+
+// --- Sessions ---
+
+// activeSession reports whether a session is neither revoked nor expired.
+func activeSession(s sqlc.Session) bool {
+	return !s.RevokedAt.Valid && s.ExpiresAt.Time.After(time.Now())
+}
+
+func (db *DB) CreateSession(ctx context.Context, arg sqlc.CreateSessionParams) (sqlc.Session, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	session := sqlc.Session{
+		ID:               uuid.New(),
+		UserID:           arg.UserID,
+		RefreshTokenHash: arg.RefreshTokenHash,
+		UserAgent:        arg.UserAgent,
+		IpAddress:        arg.IpAddress,
+		CreatedAt:        now(),
+		LastUsedAt:       now(),
+		ExpiresAt:        arg.ExpiresAt,
+		MfaVerified:      arg.MfaVerified,
+	}
+	db.sessions[session.ID] = session
+	return session, nil
+}
+
+func (db *DB) GetSession(ctx context.Context, sessionID uuid.UUID) (sqlc.Session, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	session, ok := db.sessions[sessionID]
+	if !ok {
+		return sqlc.Session{}, pgx.ErrNoRows
+	}
+	return session, nil
+}
+
+func (db *DB) ListActiveSessions(ctx context.Context, userID int64) ([]sqlc.Session, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	list := []sqlc.Session{}
+	for _, s := range db.sessions {
+		if s.UserID == userID && activeSession(s) {
+			list = append(list, s)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].LastUsedAt.Time.After(list[j].LastUsedAt.Time) })
+	return list, nil
+}
+
+func (db *DB) RotateSessionRefreshToken(ctx context.Context, arg sqlc.RotateSessionRefreshTokenParams) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	session, ok := db.sessions[arg.ID]
+	if !ok || session.RefreshTokenHash != arg.RefreshTokenHash || !activeSession(session) {
+		return 0, nil
+	}
+	session.PreviousRefreshTokenHash = pgtype.Text{String: session.RefreshTokenHash, Valid: true}
+	session.RefreshTokenHash = arg.NewRefreshTokenHash
+	session.RotatedAt = now()
+	session.LastUsedAt = now()
+	session.ExpiresAt = arg.ExpiresAt
+	db.sessions[session.ID] = session
+	return 1, nil
+}
+
+func (db *DB) RevokeSession(ctx context.Context, arg sqlc.RevokeSessionParams) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	session, ok := db.sessions[arg.ID]
+	if !ok || session.UserID != arg.UserID || session.RevokedAt.Valid {
+		return 0, nil
+	}
+	session.RevokedAt = now()
+	db.sessions[session.ID] = session
+	return 1, nil
+}
+
+func (db *DB) RevokeAllSessions(ctx context.Context, userID int64) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var revoked int64
+	for id, s := range db.sessions {
+		if s.UserID == userID && !s.RevokedAt.Valid {
+			s.RevokedAt = now()
+			db.sessions[id] = s
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func (db *DB) DeleteStaleSessions(ctx context.Context, before pgtype.Timestamptz) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var deleted int64
+	for id, s := range db.sessions {
+		if s.ExpiresAt.Time.Before(before.Time) || (s.RevokedAt.Valid && s.RevokedAt.Time.Before(before.Time)) {
+			delete(db.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// --- User identities ---
+
+func (db *DB) GetUserIdentity(ctx context.Context, arg sqlc.GetUserIdentityParams) (sqlc.UserIdentity, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, identity := range db.identities {
+		if identity.Issuer == arg.Issuer && identity.Subject == arg.Subject {
+			return identity, nil
+		}
+	}
+	return sqlc.UserIdentity{}, pgx.ErrNoRows
+}
+
+func (db *DB) CreateUserIdentity(ctx context.Context, arg sqlc.CreateUserIdentityParams) (sqlc.UserIdentity, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, identity := range db.identities {
+		if identity.Issuer == arg.Issuer && identity.Subject == arg.Subject {
+			return sqlc.UserIdentity{}, fmt.Errorf("duplicate key value violates unique constraint on issuer, subject")
+		}
+	}
+	identity := sqlc.UserIdentity{
+		ID:        uuid.New(),
+		UserID:    arg.UserID,
+		Issuer:    arg.Issuer,
+		Subject:   arg.Subject,
+		Email:     arg.Email,
+		CreatedAt: now(),
+	}
+	db.identities[identity.ID] = identity
+	return identity, nil
+}
+
+// --- Two-factor authentication ---
+
+func (db *DB) GetUserMFA(ctx context.Context, userID int64) (sqlc.UserMfa, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	settings, ok := db.userMFA[userID]
+	if !ok {
+		return sqlc.UserMfa{}, pgx.ErrNoRows
+	}
+	return settings, nil
+}
+
+func (db *DB) StartMFAEnrollment(ctx context.Context, arg sqlc.StartMFAEnrollmentParams) (sqlc.UserMfa, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if settings, ok := db.userMFA[arg.UserID]; ok && settings.EnabledAt.Valid {
+		return sqlc.UserMfa{}, pgx.ErrNoRows
+	}
+	settings := sqlc.UserMfa{UserID: arg.UserID, Secret: arg.Secret, CreatedAt: now()}
+	db.userMFA[arg.UserID] = settings
+	return settings, nil
+}
+
+func (db *DB) EnableUserMFA(ctx context.Context, userID int64) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	settings, ok := db.userMFA[userID]
+	if !ok || settings.EnabledAt.Valid {
+		return 0, nil
+	}
+	settings.EnabledAt = now()
+	db.userMFA[userID] = settings
+	return 1, nil
+}
+
+func (db *DB) DeleteUserMFA(ctx context.Context, userID int64) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	delete(db.userMFA, userID)
+	return nil
+}
+
+func (db *DB) UseMFAStep(ctx context.Context, arg sqlc.UseMFAStepParams) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	settings, ok := db.userMFA[arg.UserID]
+	if !ok || settings.LastUsedStep >= arg.LastUsedStep {
+		return 0, nil
+	}
+	settings.LastUsedStep = arg.LastUsedStep
+	db.userMFA[arg.UserID] = settings
+	return 1, nil
+}
+
+func (db *DB) CountMFAAttempt(ctx context.Context, arg sqlc.CountMFAAttemptParams) (sqlc.UserMfa, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	settings, ok := db.userMFA[arg.UserID]
+	if !ok || (settings.LockedUntil.Valid && settings.LockedUntil.Time.After(time.Now())) {
+		return sqlc.UserMfa{}, pgx.ErrNoRows
+	}
+	settings.FailedAttempts++
+	settings.LockedUntil = pgtype.Timestamptz{}
+	if settings.FailedAttempts >= arg.MaxAttempts {
+		settings.FailedAttempts = 0
+		settings.LockedUntil = arg.LockedUntil
+	}
+	db.userMFA[arg.UserID] = settings
+	return settings, nil
+}
+
+func (db *DB) ResetMFAAttempts(ctx context.Context, userID int64) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if settings, ok := db.userMFA[userID]; ok {
+		settings.FailedAttempts = 0
+		settings.LockedUntil = pgtype.Timestamptz{}
+		db.userMFA[userID] = settings
+	}
+	return nil
+}
+
+func (db *DB) UserHasMFA(ctx context.Context, userID int64) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.userMFA[userID].EnabledAt.Valid, nil
+}
+
+func (db *DB) CreateMFARecoveryCode(ctx context.Context, arg sqlc.CreateMFARecoveryCodeParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	code := sqlc.MfaRecoveryCode{ID: uuid.New(), UserID: arg.UserID, CodeHash: arg.CodeHash, CreatedAt: now()}
+	db.recoveryCodes[code.ID] = code
+	return nil
+}
+
+func (db *DB) DeleteMFARecoveryCodes(ctx context.Context, userID int64) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for id, code := range db.recoveryCodes {
+		if code.UserID == userID {
+			delete(db.recoveryCodes, id)
+		}
+	}
+	return nil
+}
+
+func (db *DB) UseMFARecoveryCode(ctx context.Context, arg sqlc.UseMFARecoveryCodeParams) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var used int64
+	for id, code := range db.recoveryCodes {
+		if code.UserID == arg.UserID && code.CodeHash == arg.CodeHash && !code.UsedAt.Valid {
+			code.UsedAt = now()
+			db.recoveryCodes[id] = code
+			used++
+		}
+	}
+	return used, nil
+}
+
+func (db *DB) CountMFARecoveryCodes(ctx context.Context, userID int64) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var count int64
+	for _, code := range db.recoveryCodes {
+		if code.UserID == userID && !code.UsedAt.Valid {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (db *DB) CreateMFAChallenge(ctx context.Context, arg sqlc.CreateMFAChallengeParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.challenges[arg.TokenHash] = sqlc.MfaChallenge{
+		TokenHash: arg.TokenHash,
+		UserID:    arg.UserID,
+		ExpiresAt: arg.ExpiresAt,
+		CreatedAt: now(),
+	}
+	return nil
+}
+
+func (db *DB) AttemptMFAChallenge(ctx context.Context, arg sqlc.AttemptMFAChallengeParams) (sqlc.MfaChallenge, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	challenge, ok := db.challenges[arg.TokenHash]
+	if !ok || !challenge.ExpiresAt.Time.After(time.Now()) || challenge.Attempts >= arg.MaxAttempts {
+		return sqlc.MfaChallenge{}, pgx.ErrNoRows
+	}
+	challenge.Attempts++
+	db.challenges[arg.TokenHash] = challenge
+	return challenge, nil
+}
+
+func (db *DB) DeleteMFAChallenge(ctx context.Context, tokenHash string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	delete(db.challenges, tokenHash)
+	return nil
+}
+
+func (db *DB) DeleteExpiredMFAChallenges(ctx context.Context) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var deleted int64
+	for hash, challenge := range db.challenges {
+		if challenge.ExpiresAt.Time.Before(time.Now()) {
+			delete(db.challenges, hash)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (db *DB) ListMFAPolicies(ctx context.Context) ([]sqlc.MfaPolicy, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	list := make([]sqlc.MfaPolicy, 0, len(db.mfaPolicies))
+	for _, policy := range db.mfaPolicies {
+		list = append(list, policy)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Role < list[j].Role })
+	return list, nil
+}
+
+func (db *DB) SetMFAPolicy(ctx context.Context, arg sqlc.SetMFAPolicyParams) (sqlc.MfaPolicy, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	policy := sqlc.MfaPolicy{Role: arg.Role, Required: arg.Required, UpdatedAt: now()}
+	db.mfaPolicies[arg.Role] = policy
+	return policy, nil
+}
+
+func (db *DB) RoleRequiresMFA(ctx context.Context, role string) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.mfaPolicies[role].Required, nil
+}
+
+// --- Email tokens ---
+
+func (db *DB) CreateEmailToken(ctx context.Context, arg sqlc.CreateEmailTokenParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.emailTokens[arg.TokenHash] = sqlc.EmailToken{
+		TokenHash: arg.TokenHash,
+		UserID:    arg.UserID,
+		Purpose:   arg.Purpose,
+		ExpiresAt: arg.ExpiresAt,
+		CreatedAt: now(),
+	}
+	return nil
+}
+
+func (db *DB) UseEmailToken(ctx context.Context, arg sqlc.UseEmailTokenParams) (sqlc.EmailToken, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	token, ok := db.emailTokens[arg.TokenHash]
+	if !ok || token.Purpose != arg.Purpose || token.UsedAt.Valid || !token.ExpiresAt.Time.After(time.Now()) {
+		return sqlc.EmailToken{}, pgx.ErrNoRows
+	}
+	token.UsedAt = now()
+	db.emailTokens[arg.TokenHash] = token
+	return token, nil
+}
+
+func (db *DB) DeleteEmailTokens(ctx context.Context, arg sqlc.DeleteEmailTokensParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for hash, token := range db.emailTokens {
+		if token.UserID == arg.UserID && token.Purpose == arg.Purpose && !token.UsedAt.Valid {
+			delete(db.emailTokens, hash)
+		}
+	}
+	return nil
+}
+
+func (db *DB) DeleteExpiredEmailTokens(ctx context.Context) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var deleted int64
+	for hash, token := range db.emailTokens {
+		if token.ExpiresAt.Time.Before(time.Now()) {
+			delete(db.emailTokens, hash)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (db *DB) EmailTokenSentSince(ctx context.Context, arg sqlc.EmailTokenSentSinceParams) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, token := range db.emailTokens {
+		if token.UserID == arg.UserID && token.Purpose == arg.Purpose && token.CreatedAt.Time.After(arg.Since.Time) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// --- Inspection helpers for assertions ---
+
+// ExpireEmailTokens moves every email token into the past, so they have
+// expired and were sent longer ago than any resend interval.
+func (db *DB) ExpireEmailTokens() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for hash, token := range db.emailTokens {
+		token.ExpiresAt = pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true}
+		token.CreatedAt = pgtype.Timestamptz{Time: time.Now().Add(-time.Hour), Valid: true}
+		db.emailTokens[hash] = token
+	}
+}
+
+// ExpireUploadSessions moves the expiry of every resumable upload session into the past.
+func (db *DB) ExpireUploadSessions() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for id, session := range db.uploadSessions {
+		session.ExpiresAt = pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true}
+		db.uploadSessions[id] = session
+	}
+}
+
+// ExpireDirectUploads moves the expiry of every pending direct upload back by the given duration.
+func (db *DB) ExpireDirectUploads(by time.Duration) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for id, upload := range db.directUploads {
+		upload.ExpiresAt.Time = upload.ExpiresAt.Time.Add(-by)
+		db.directUploads[id] = upload
+	}
+}
+
+// Identities returns all user identity records.
+func (db *DB) Identities() []sqlc.UserIdentity {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	list := make([]sqlc.UserIdentity, 0, len(db.identities))
+	for _, identity := range db.identities {
+		list = append(list, identity)
+	}
+	return list
+}
+
+// Sessions returns all session records.
+func (db *DB) Sessions() []sqlc.Session {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	list := make([]sqlc.Session, 0, len(db.sessions))
+	for _, s := range db.sessions {
+		list = append(list, s)
+	}
+	return list
+}
+
+// SetUserRole changes the role of a user, for example to make them an admin.
+func (db *DB) SetUserRole(userID int64, role string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	user := db.users[userID]
+	user.Role = role
+	db.users[userID] = user
+}
+
+// Blobs returns all blob records.
+func (db *DB) Blobs() []sqlc.Blob {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	blobs := make([]sqlc.Blob, 0, len(db.blobs))
+	for _, b := range db.blobs {
+		blobs = append(blobs, b)
+	}
+	return blobs
+}
+
+// BlobTexts returns the extracted texts by blob ID.
+func (db *DB) BlobTexts() map[uuid.UUID]sqlc.BlobText {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	texts := make(map[uuid.UUID]sqlc.BlobText, len(db.blobTexts))
+	for id, t := range db.blobTexts {
+		texts[id] = t
+	}
+	return texts
+}
+
+// Tags returns the sorted tags of a file or folder.
+func (db *DB) Tags(itemID uuid.UUID) []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return slices.Clone(db.tags[itemID])
+}
+
+// Metadata returns the metadata of a file or folder.
+func (db *DB) Metadata(itemID uuid.UUID) map[string]string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	metadata := make(map[string]string, len(db.metadata[itemID]))
+	for k, v := range db.metadata[itemID] {
+		metadata[k] = v
+	}
+	return metadata
+}
+
+// Files returns all file records.
+func (db *DB) Files() []sqlc.File {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	list := make([]sqlc.File, 0, len(db.files))
+	for _, f := range db.files {
+		list = append(list, f)
+	}
+	return list
+}
+
+// Folders returns all folder records.
+func (db *DB) Folders() []sqlc.Folder {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	list := make([]sqlc.Folder, 0, len(db.folders))
+	for _, f := range db.folders {
+		list = append(list, f)
+	}
+	return list
+}
@@ -0,0 +1,88 @@
+-- name: ListBlobsPendingTextExtraction :many
+-- Lists the blobs of current files that have no extracted text yet, oldest
+-- first, with the name of one of their files to tell the format by.
+SELECT b.id, b.storage_path, b.size, b.mime_type, f.filename
+FROM blobs b
+JOIN LATERAL (
+    SELECT fi.filename FROM files fi
+    WHERE fi.blob_id = b.id
+    ORDER BY fi.uploaded_at
+    LIMIT 1
+) f ON TRUE
+WHERE NOT EXISTS (SELECT 1 FROM blob_texts bt WHERE bt.blob_id = b.id)
+ORDER BY b.created_at, b.id
+LIMIT $1;
+
+-- name: SaveBlobText :exec
+INSERT INTO blob_texts (blob_id, status, content)
+VALUES ($1, $2, $3)
+ON CONFLICT (blob_id) DO UPDATE
+SET status = EXCLUDED.status, content = EXCLUDED.content, extracted_at = now();
+
+-- name: SearchFileContents :many
+-- Searches the extracted text of every file the user can see, with the same
+-- access rules and paths as SearchContents. The query uses the web search
+-- syntax: quoted phrases, OR and -excluded words. Each result carries a
+-- snippet of its text with the matching words between <mark> and </mark>;
+-- snippets are only built for the page of results returned.
+WITH RECURSIVE visible_folders AS (
+    SELECT fo.id, ARRAY[fo.id]::UUID[] AS path_ids, ARRAY[fo.name]::TEXT[] AS path_names
+    FROM folders fo
+    WHERE fo.trashed_at IS NULL
+      AND (
+        (fo.owner_id = sqlc.arg(user_id) AND fo.parent_folder_id IS NULL)
+        OR EXISTS (SELECT 1 FROM folder_shares fs WHERE fs.folder_id = fo.id AND fs.shared_with = sqlc.arg(user_id))
+      )
+
+    UNION ALL
+
+    SELECT fo.id, vf.path_ids || fo.id, vf.path_names || fo.name
+    FROM folders fo
+    JOIN visible_folders vf ON fo.parent_folder_id = vf.id
+    WHERE fo.trashed_at IS NULL
+),
+folder_paths AS (
+    SELECT DISTINCT ON (id) id, path_ids, path_names
+    FROM visible_folders
+    ORDER BY id, cardinality(path_ids)
+),
+visible_files AS (
+    SELECT f.id, f.filename, f.size, f.declared_mime, f.uploaded_at, f.owner_id, f.blob_id, fp.path_ids, fp.path_names
+    FROM files f
+    JOIN folder_paths fp ON f.folder_id = fp.id
+    WHERE f.trashed_at IS NULL
+
+    UNION ALL
+
+    SELECT f.id, f.filename, f.size, f.declared_mime, f.uploaded_at, f.owner_id, f.blob_id,
+        ARRAY[]::UUID[] AS path_ids, ARRAY[]::TEXT[] AS path_names
+    FROM files f
+    WHERE f.trashed_at IS NULL
+      AND (
+        (f.owner_id = sqlc.arg(user_id) AND f.folder_id IS NULL)
+        OR (
+            EXISTS (SELECT 1 FROM file_shares fs WHERE fs.file_id = f.id AND fs.shared_with = sqlc.arg(user_id))
+            AND NOT EXISTS (SELECT 1 FROM folder_paths fp WHERE fp.id = f.folder_id)
+        )
+      )
+),
+matches AS (
+    SELECT
+        vf.id, vf.filename, vf.size, vf.declared_mime, vf.uploaded_at, vf.owner_id, vf.path_ids, vf.path_names,
+        bt.content, q.query, ts_rank(bt.tsv, q.query) AS rank, COUNT(*) OVER() AS total_count
+    FROM visible_files vf
+    JOIN blob_texts bt ON bt.blob_id = vf.blob_id
+    CROSS JOIN websearch_to_tsquery('english', sqlc.arg(search)::TEXT) AS q(query)
+    WHERE bt.status = 'indexed' AND bt.tsv @@ q.query
+    ORDER BY rank DESC, vf.filename, vf.id
+    LIMIT $1 OFFSET $2
+)
+SELECT
+    m.id, m.filename, m.size, m.declared_mime AS content_type, m.uploaded_at,
+    (m.owner_id = sqlc.arg(user_id)) AS user_owns_file,
+    m.path_ids::UUID[] AS path_ids, m.path_names::TEXT[] AS path_names, m.rank::REAL AS rank,
+    ts_headline('english', m.content, m.query,
+        'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" … "')::TEXT AS snippet,
+    m.total_count
+FROM matches m
+ORDER BY m.rank DESC, m.filename, m.id;
@@ -0,0 +1,25 @@
+-- name: CreateEmailToken :exec
+INSERT INTO email_tokens (token_hash, user_id, purpose, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: UseEmailToken :one
+-- Marks a token that is neither used nor expired as used, and returns it.
+-- Returns no row otherwise, so each token is used once.
+UPDATE email_tokens SET used_at = now()
+WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > now()
+RETURNING *;
+
+-- name: DeleteEmailTokens :exec
+-- Deletes the unused tokens of a user for a purpose, so only the link sent
+-- last works.
+DELETE FROM email_tokens
+WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL;
+
+-- name: DeleteExpiredEmailTokens :execrows
+DELETE FROM email_tokens WHERE expires_at < now();
+
+-- name: EmailTokenSentSince :one
+SELECT EXISTS (
+    SELECT 1 FROM email_tokens
+    WHERE user_id = $1 AND purpose = $2 AND created_at > sqlc.arg(since)
+);
@@ -0,0 +1,35 @@
+-- name: ArchiveCurrentVersion :one
+-- Copies the current contents of a file into its version history. The row is
+-- locked so concurrent replacements archive each version exactly once.
+INSERT INTO file_versions (file_id, owner_id, version, blob_id, size, declared_mime, created_at)
+SELECT id, owner_id, current_version, blob_id, size, declared_mime, COALESCE(uploaded_at, now())
+FROM files
+WHERE id = $1
+FOR UPDATE
+RETURNING *;
+
+-- name: ListFileVersions :many
+SELECT * FROM file_versions
+WHERE file_id = $1
+ORDER BY version DESC;
+
+-- name: GetFileVersion :one
+SELECT * FROM file_versions
+WHERE file_id = $1 AND version = $2;
+
+-- name: DeleteFileVersion :one
+DELETE FROM file_versions
+WHERE file_id = $1 AND version = $2
+RETURNING blob_id;
+
+-- name: PruneFileVersions :many
+-- Deletes all but the newest keep versions of a file.
+DELETE FROM file_versions
+WHERE file_id = sqlc.arg(file_id)
+  AND version NOT IN (
+      SELECT v.version FROM file_versions v
+      WHERE v.file_id = sqlc.arg(file_id)
+      ORDER BY v.version DESC
+      LIMIT sqlc.arg(keep)
+  )
+RETURNING blob_id;
@@ -7,9 +7,74 @@ INSERT INTO folders (
     $1, $2, $3
 ) RETURNING *;
 
+-- name: CreateFolderIfNotExists :one
+-- Creates a folder unless the owner already has one of that name in the
+-- parent, in which case no row is returned. Run after LockFolderName in the
+-- same transaction, so that a folder created concurrently is seen.
+INSERT INTO folders (name, owner_id, parent_folder_id)
+SELECT sqlc.arg(name)::TEXT, sqlc.arg(owner_id)::BIGINT, sqlc.narg(parent_folder_id)::UUID
+WHERE NOT EXISTS (
+    SELECT 1 FROM folders f
+    WHERE f.owner_id = sqlc.arg(owner_id)
+      AND f.name = sqlc.arg(name)
+      AND f.parent_folder_id IS NOT DISTINCT FROM sqlc.narg(parent_folder_id)
+      AND f.trashed_at IS NULL
+)
+RETURNING *;
+
+-- name: LockFolderName :exec
+-- Locks a folder name of the owner within a parent folder, or at the root when
+-- parent_folder_id is NULL, until the transaction ends.
+SELECT pg_advisory_xact_lock(hashtextextended(
+    sqlc.arg(owner_id)::BIGINT::TEXT || '/' || COALESCE(sqlc.narg(parent_folder_id)::UUID::TEXT, '') || '/' || sqlc.arg(name)::TEXT,
+    0
+));
+
+-- name: CopyFolderTree :one
+-- Copies a folder with its subfolders and files into target_folder_id of
+-- owner_id, or to the root when target_folder_id is NULL, naming the copy of
+-- the folder itself after name. Trashed folders and files are left out. The
+-- copied files point at the blobs of the originals, so nothing is written to
+-- storage; the insert trigger counts the new references and charges the owner.
+-- Returns the ID of the new folder.
+WITH RECURSIVE tree AS (
+    SELECT fo.id, gen_random_uuid() AS new_id, sqlc.narg(target_folder_id)::UUID AS new_parent_id, sqlc.arg(name)::TEXT AS name
+    FROM folders fo
+    WHERE fo.id = sqlc.arg(source_folder_id) AND fo.trashed_at IS NULL
+
+    UNION ALL
+
+    SELECT fo.id, gen_random_uuid(), t.new_id, fo.name
+    FROM folders fo
+    JOIN tree t ON fo.parent_folder_id = t.id
+    WHERE fo.trashed_at IS NULL
+), copied_folders AS (
+    INSERT INTO folders (id, name, owner_id, parent_folder_id)
+    SELECT new_id, tree.name, sqlc.arg(owner_id), new_parent_id FROM tree
+), copied_files AS (
+    INSERT INTO files (owner_id, blob_id, filename, declared_mime, size, folder_id)
+    SELECT sqlc.arg(owner_id), f.blob_id, f.filename, f.declared_mime, f.size, t.new_id
+    FROM files f
+    JOIN tree t ON f.folder_id = t.id
+    WHERE f.trashed_at IS NULL
+)
+SELECT new_id FROM tree
+WHERE id = sqlc.arg(source_folder_id);
+
 -- name: GetFolderByID :one
 SELECT * FROM folders
-WHERE id = $1;
+WHERE id = $1 AND trashed_at IS NULL;
+
+-- name: GetFolderByName :one
+-- Finds a folder of the owner by its name within a parent folder, or at the
+-- root when parent_folder_id is NULL. The oldest one wins if names repeat.
+SELECT * FROM folders
+WHERE owner_id = $1
+  AND name = $2
+  AND parent_folder_id IS NOT DISTINCT FROM sqlc.narg(parent_folder_id)
+  AND trashed_at IS NULL
+ORDER BY created_at, id
+LIMIT 1;
 
 -- name: UpdateFolder :one
 UPDATE folders
@@ -49,7 +114,40 @@ SELECT
 FROM folders f
 WHERE
     f.owner_id = $1
+    AND f.trashed_at IS NULL
     -- exclude all folders that are in the forbidden list
     AND f.id NOT IN (SELECT id FROM forbidden_folders)
 ORDER BY
     f.created_at DESC;
+
+-- name: GetInheritedFolderPermissions :many
+WITH RECURSIVE ancestors AS (
+    SELECT id, parent_folder_id FROM folders WHERE id = sqlc.arg(folder_id)
+
+    UNION ALL
+
+    SELECT f.id, f.parent_folder_id
+    FROM folders f
+    INNER JOIN ancestors a ON f.id = a.parent_folder_id
+)
+SELECT fs.permission
+FROM folder_shares fs
+JOIN ancestors a ON a.id = fs.folder_id
+WHERE fs.shared_with = sqlc.arg(user_id);
+
+-- name: ListFolderShares :many
+SELECT u.id, u.name, u.email, fs.permission
+FROM folder_shares fs
+JOIN users u ON u.id = fs.shared_with
+WHERE fs.folder_id = $1
+ORDER BY fs.id;
+
+-- name: ReplaceFolderShares :exec
+WITH removed AS (
+    DELETE FROM folder_shares
+    WHERE folder_id = sqlc.arg(folder_id)
+      AND shared_with <> ALL(sqlc.arg(user_ids)::BIGINT[])
+)
+INSERT INTO folder_shares (folder_id, shared_with, permission)
+SELECT sqlc.arg(folder_id), unnest(sqlc.arg(user_ids)::BIGINT[]), unnest(sqlc.arg(permissions)::TEXT[])
+ON CONFLICT (folder_id, shared_with) DO UPDATE SET permission = EXCLUDED.permission;
@@ -0,0 +1,74 @@
+-- name: AddItemTags :exec
+-- Adds every tag to every file and folder, keeping the tags they already have.
+INSERT INTO item_tags (file_id, folder_id, tag)
+SELECT i.file_id, i.folder_id, t.tag
+FROM (
+    SELECT unnest(sqlc.arg(file_ids)::UUID[]) AS file_id, NULL::UUID AS folder_id
+    UNION ALL
+    SELECT NULL::UUID AS file_id, unnest(sqlc.arg(folder_ids)::UUID[]) AS folder_id
+) AS i
+CROSS JOIN unnest(sqlc.arg(tags)::TEXT[]) AS t(tag)
+ON CONFLICT (item_id, tag) DO NOTHING;
+
+-- name: RemoveItemTags :exec
+DELETE FROM item_tags
+WHERE item_id = ANY(sqlc.arg(item_ids)::UUID[]) AND tag = ANY(sqlc.arg(tags)::TEXT[]);
+
+-- name: SetItemMetadata :exec
+-- Sets every key to its value on every file and folder, replacing the values
+-- they already have for those keys.
+INSERT INTO item_metadata (file_id, folder_id, key, value)
+SELECT i.file_id, i.folder_id, m.key, m.value
+FROM (
+    SELECT unnest(sqlc.arg(file_ids)::UUID[]) AS file_id, NULL::UUID AS folder_id
+    UNION ALL
+    SELECT NULL::UUID AS file_id, unnest(sqlc.arg(folder_ids)::UUID[]) AS folder_id
+) AS i
+CROSS JOIN unnest(sqlc.arg(keys)::TEXT[], sqlc.arg(values)::TEXT[]) AS m(key, value)
+ON CONFLICT (item_id, key) DO UPDATE
+SET value = EXCLUDED.value, updated_at = now();
+
+-- name: RemoveItemMetadata :exec
+DELETE FROM item_metadata
+WHERE item_id = ANY(sqlc.arg(item_ids)::UUID[]) AND key = ANY(sqlc.arg(keys)::TEXT[]);
+
+-- name: ListTagSuggestions :many
+-- Lists the tags starting with prefix on the files and folders the user can
+-- see, the ones they own and the ones shared with them, most used first.
+WITH RECURSIVE visible_folders AS (
+    SELECT fo.id
+    FROM folders fo
+    WHERE fo.trashed_at IS NULL
+      AND (
+        (fo.owner_id = sqlc.arg(user_id) AND fo.parent_folder_id IS NULL)
+        OR EXISTS (SELECT 1 FROM folder_shares fs WHERE fs.folder_id = fo.id AND fs.shared_with = sqlc.arg(user_id))
+      )
+
+    UNION
+
+    SELECT fo.id
+    FROM folders fo
+    JOIN visible_folders vf ON fo.parent_folder_id = vf.id
+    WHERE fo.trashed_at IS NULL
+),
+visible_items AS (
+    SELECT id FROM visible_folders
+
+    UNION ALL
+
+    SELECT f.id
+    FROM files f
+    WHERE f.trashed_at IS NULL
+      AND (
+        f.folder_id IN (SELECT id FROM visible_folders)
+        OR (f.owner_id = sqlc.arg(user_id) AND f.folder_id IS NULL)
+        OR EXISTS (SELECT 1 FROM file_shares fs WHERE fs.file_id = f.id AND fs.shared_with = sqlc.arg(user_id))
+      )
+)
+SELECT it.tag, COUNT(*) AS uses
+FROM item_tags it
+WHERE it.item_id IN (SELECT id FROM visible_items)
+  AND left(it.tag, length(sqlc.arg(prefix)::TEXT)) = sqlc.arg(prefix)::TEXT
+GROUP BY it.tag
+ORDER BY uses DESC, it.tag
+LIMIT $1;
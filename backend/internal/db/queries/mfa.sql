@@ -0,0 +1,89 @@
+-- name: GetUserMFA :one
+SELECT * FROM user_mfa WHERE user_id = $1;
+
+-- name: StartMFAEnrollment :one
+-- Stores a new secret for a user, replacing the one of an enrollment they did
+-- not confirm. Returns no row if they already enabled two-factor authentication.
+INSERT INTO user_mfa (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, last_used_step = 0, created_at = now()
+WHERE user_mfa.enabled_at IS NULL
+RETURNING *;
+
+-- name: EnableUserMFA :execrows
+UPDATE user_mfa SET enabled_at = now()
+WHERE user_id = $1 AND enabled_at IS NULL;
+
+-- name: DeleteUserMFA :exec
+DELETE FROM user_mfa WHERE user_id = $1;
+
+-- name: UseMFAStep :execrows
+-- Records the time step of an accepted code. Updates no row if a code of the
+-- same or a later step was already accepted, so each code is used once.
+UPDATE user_mfa SET last_used_step = $2
+WHERE user_id = $1 AND last_used_step < $2;
+
+-- name: UserHasMFA :one
+SELECT EXISTS (SELECT 1 FROM user_mfa WHERE user_id = $1 AND enabled_at IS NOT NULL);
+
+-- name: CreateMFARecoveryCode :exec
+INSERT INTO mfa_recovery_codes (user_id, code_hash)
+VALUES ($1, $2);
+
+-- name: DeleteMFARecoveryCodes :exec
+DELETE FROM mfa_recovery_codes WHERE user_id = $1;
+
+-- name: UseMFARecoveryCode :execrows
+UPDATE mfa_recovery_codes SET used_at = now()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: CountMFARecoveryCodes :one
+SELECT COUNT(*) FROM mfa_recovery_codes
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: CountMFAAttempt :one
+-- Counts a code entered at login by a user who is not locked out. Once
+-- max_attempts codes in a row were counted the user is locked out until
+-- locked_until, and counting starts over. Returns no row while locked out.
+UPDATE user_mfa
+SET
+    failed_attempts = CASE WHEN failed_attempts + 1 >= sqlc.arg(max_attempts)::INT THEN 0 ELSE failed_attempts + 1 END,
+    locked_until = CASE WHEN failed_attempts + 1 >= sqlc.arg(max_attempts)::INT THEN sqlc.arg(locked_until)::TIMESTAMPTZ END
+WHERE user_id = sqlc.arg(user_id) AND (locked_until IS NULL OR locked_until <= now())
+RETURNING *;
+
+-- name: ResetMFAAttempts :exec
+-- Forgets the codes counted since the last accepted one.
+UPDATE user_mfa SET failed_attempts = 0, locked_until = NULL
+WHERE user_id = $1;
+
+-- name: CreateMFAChallenge :exec
+INSERT INTO mfa_challenges (token_hash, user_id, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: AttemptMFAChallenge :one
+-- Counts an attempt at a challenge that has neither expired nor run out of
+-- attempts, and returns it. Returns no row otherwise.
+UPDATE mfa_challenges SET attempts = attempts + 1
+WHERE token_hash = $1 AND expires_at > now() AND attempts < sqlc.arg(max_attempts)
+RETURNING *;
+
+-- name: DeleteMFAChallenge :exec
+DELETE FROM mfa_challenges WHERE token_hash = $1;
+
+-- name: DeleteExpiredMFAChallenges :execrows
+DELETE FROM mfa_challenges WHERE expires_at < now();
+
+-- name: ListMFAPolicies :many
+SELECT * FROM mfa_policies ORDER BY role;
+
+-- name: SetMFAPolicy :one
+INSERT INTO mfa_policies (role, required)
+VALUES ($1, $2)
+ON CONFLICT (role) DO UPDATE
+SET required = EXCLUDED.required, updated_at = now()
+RETURNING *;
+
+-- name: RoleRequiresMFA :one
+SELECT EXISTS (SELECT 1 FROM mfa_policies WHERE role = $1 AND required);
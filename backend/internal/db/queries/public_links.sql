@@ -0,0 +1,44 @@
+-- name: EnablePublicLink :one
+UPDATE files
+SET
+    is_public = TRUE,
+    public_token = COALESCE(public_token, sqlc.arg(token)::UUID),
+    public_expires_at = sqlc.narg(expires_at),
+    public_max_downloads = sqlc.narg(max_downloads),
+    public_password_hash = sqlc.narg(password_hash),
+    public_download_count = 0
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: RotatePublicToken :one
+UPDATE files
+SET public_token = sqlc.arg(token)::UUID
+WHERE id = sqlc.arg(id) AND is_public
+RETURNING *;
+
+-- name: DisablePublicLink :exec
+UPDATE files
+SET
+    is_public = FALSE,
+    public_token = NULL,
+    public_expires_at = NULL,
+    public_max_downloads = NULL,
+    public_password_hash = NULL,
+    public_download_count = 0
+WHERE id = $1;
+
+-- name: GetFileByPublicToken :one
+SELECT * FROM files
+WHERE public_token = $1 AND is_public AND trashed_at IS NULL;
+
+-- name: ClaimPublicDownload :one
+UPDATE files
+SET
+    public_download_count = public_download_count + 1,
+    download_count = download_count + 1
+WHERE id = $1
+  AND is_public
+  AND trashed_at IS NULL
+  AND (public_expires_at IS NULL OR public_expires_at > now())
+  AND (public_max_downloads IS NULL OR public_download_count < public_max_downloads)
+RETURNING public_download_count;
@@ -0,0 +1,42 @@
+-- name: CreateSession :one
+INSERT INTO sessions (user_id, refresh_token_hash, user_agent, ip_address, expires_at, mfa_verified)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetSession :one
+SELECT * FROM sessions
+WHERE id = $1;
+
+-- name: ListActiveSessions :many
+-- Lists the sessions of a user that are neither revoked nor expired, most recently used first.
+SELECT * FROM sessions
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > now()
+ORDER BY last_used_at DESC;
+
+-- name: RotateSessionRefreshToken :execrows
+-- Replaces the refresh token of a session, keeping the one it replaces for the
+-- grace period. Updates nothing if refresh_token_hash is no longer current,
+-- because a concurrent refresh rotated it first.
+UPDATE sessions
+SET previous_refresh_token_hash = refresh_token_hash,
+    refresh_token_hash = sqlc.arg(new_refresh_token_hash),
+    rotated_at = now(),
+    last_used_at = now(),
+    expires_at = sqlc.arg(expires_at)
+WHERE id = sqlc.arg(id) AND refresh_token_hash = sqlc.arg(refresh_token_hash)
+  AND revoked_at IS NULL AND expires_at > now();
+
+-- name: RevokeSession :execrows
+UPDATE sessions
+SET revoked_at = now()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeAllSessions :execrows
+UPDATE sessions
+SET revoked_at = now()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: DeleteStaleSessions :execrows
+-- Deletes the sessions that expired or were revoked before the given time.
+DELETE FROM sessions
+WHERE expires_at < $1 OR revoked_at < $1;
@@ -19,7 +19,7 @@ SELECT 1 FROM files WHERE owner_id = $1 AND blob_id = $2 LIMIT 1;
 -- name: DeleteBlobIfUnused :one
 DELETE FROM blobs 
 WHERE id = $1 AND refcount <= 0
-RETURNING storage_path;
+RETURNING storage_path, sha256;
 
 -- name: DeleteBlobsByStoragePaths :exec
 DELETE FROM blobs
@@ -42,7 +42,7 @@ LIMIT $3 OFFSET $4;
 -- name: GetFileByUUID :one
 SELECT *
 FROM files f
-WHERE f.id = $1;
+WHERE f.id = $1 AND f.trashed_at IS NULL;
 
 -- name: DeleteFile :exec
 DELETE FROM files
@@ -97,8 +97,19 @@ DELETE FROM file_shares
 WHERE file_id = $1;
 
 -- name: AddSharesToFile :copyfrom
-INSERT INTO file_shares (file_id, shared_with)
-VALUES ($1, $2);
+INSERT INTO file_shares (file_id, shared_with, permission)
+VALUES ($1, $2, $3);
+
+-- name: GetSharePermission :one
+SELECT permission FROM file_shares
+WHERE file_id = $1 AND shared_with = $2;
+
+-- name: UpdateFileBlob :one
+UPDATE files
+SET blob_id = $1, size = $2, declared_mime = $3,
+    uploaded_at = now(), current_version = current_version + 1
+WHERE id = $4
+RETURNING *;
 
 -----------------------------
 
@@ -116,8 +127,8 @@ WITH folder_contents AS (
         NULL::uuid AS folder_id
     FROM folders f
     WHERE 
-        f.owner_id = sqlc.arg(user_id)
-        AND f.parent_folder_id = sqlc.arg(parent_folder_id)::UUID
+        f.parent_folder_id = sqlc.arg(parent_folder_id)::UUID
+        AND f.trashed_at IS NULL
         AND (sqlc.arg(search)::TEXT = '' OR f.name ILIKE '%' || sqlc.arg(search)::TEXT || '%')
         AND (sqlc.arg(mime_type)::TEXT = 'folder/folder' OR sqlc.arg(mime_type)::TEXT = '')
 
@@ -135,8 +146,8 @@ WITH folder_contents AS (
         f.folder_id
     FROM files f
     WHERE
-        f.owner_id = sqlc.arg(user_id)
-        AND f.folder_id = sqlc.arg(parent_folder_id)::UUID
+        f.folder_id = sqlc.arg(parent_folder_id)::UUID
+        AND f.trashed_at IS NULL
         AND (sqlc.arg(search)::TEXT = '' OR f.filename ILIKE '%' || sqlc.arg(search)::TEXT || '%')
         AND (sqlc.arg(mime_type)::TEXT = '' OR f.declared_mime = sqlc.arg(mime_type)::TEXT)
         AND (sqlc.arg(uploaded_after)::TIMESTAMPTZ IS NULL OR f.uploaded_at > sqlc.arg(uploaded_after)::TIMESTAMPTZ)
@@ -144,8 +155,19 @@ WITH folder_contents AS (
         AND (sqlc.narg(min_size)::BIGINT IS NULL OR f.size >= sqlc.narg(min_size)::BIGINT)
         AND (sqlc.narg(max_size)::BIGINT IS NULL OR f.size <= sqlc.narg(max_size)::BIGINT)
 ) 
-SELECT *, COUNT(*) OVER() AS total_count 
-FROM folder_contents
+SELECT
+    c.*,
+    ARRAY(SELECT it.tag FROM item_tags it WHERE it.item_id = c.id ORDER BY it.tag)::TEXT[] AS tags,
+    COALESCE((SELECT jsonb_object_agg(im.key, im.value) FROM item_metadata im WHERE im.item_id = c.id), '{}')::JSONB AS metadata,
+    COUNT(*) OVER() AS total_count
+FROM folder_contents c
+-- every tag filtered by and every metadata predicate must hold
+WHERE (sqlc.arg(tags)::TEXT[] IS NULL OR sqlc.arg(tags)::TEXT[] <@ ARRAY(SELECT it.tag FROM item_tags it WHERE it.item_id = c.id))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest(sqlc.arg(metadata_keys)::TEXT[], sqlc.arg(metadata_ops)::TEXT[], sqlc.arg(metadata_values)::TEXT[]) AS p(key, op, value)
+    WHERE NOT item_metadata_matches(c.id, p.key, p.op, p.value)
+  )
 ORDER BY 
     item_type DESC,
     CASE WHEN sqlc.arg(sort_by)::text = 'filename' AND sqlc.arg(sort_order)::text = 'asc' THEN filename END ASC,
@@ -164,7 +186,7 @@ WITH root_contents AS (
         (f.owner_id = sqlc.arg(user_id)) AS user_owns_file,
         NULL::bigint AS download_count, NULL::uuid AS folder_id
     FROM folders f
-    WHERE f.owner_id = sqlc.arg(user_id) AND f.parent_folder_id IS NULL
+    WHERE f.owner_id = sqlc.arg(user_id) AND f.parent_folder_id IS NULL AND f.trashed_at IS NULL
       AND (sqlc.arg(search)::TEXT = '' OR f.name ILIKE '%' || sqlc.arg(search)::TEXT || '%')
       AND (sqlc.arg(mime_type)::TEXT = 'folder/folder' OR sqlc.arg(mime_type)::TEXT = '')
 
@@ -175,7 +197,7 @@ WITH root_contents AS (
         f.uploaded_at, (f.owner_id = sqlc.arg(user_id)) AS user_owns_file,
         f.download_count, f.folder_id
     FROM files f
-    WHERE f.owner_id = sqlc.arg(user_id) AND f.folder_id IS NULL
+    WHERE f.owner_id = sqlc.arg(user_id) AND f.folder_id IS NULL AND f.trashed_at IS NULL
         AND (sqlc.arg(search)::TEXT = '' OR f.filename ILIKE '%' || sqlc.arg(search)::TEXT || '%')
         AND (sqlc.arg(mime_type)::TEXT = '' OR f.declared_mime = sqlc.arg(mime_type)::TEXT)
         AND (sqlc.arg(uploaded_after)::TIMESTAMPTZ IS NULL OR f.uploaded_at > sqlc.arg(uploaded_after)::TIMESTAMPTZ)
@@ -196,16 +218,41 @@ WITH root_contents AS (
         f.download_count, NULL::uuid as folder_id
     FROM files f
     JOIN file_shares fs ON f.id = fs.file_id
-    WHERE fs.shared_with = sqlc.arg(user_id)
+    WHERE fs.shared_with = sqlc.arg(user_id) AND f.trashed_at IS NULL
       AND (sqlc.arg(search)::TEXT = '' OR f.filename ILIKE '%' || sqlc.arg(search)::TEXT || '%')
       AND (sqlc.arg(mime_type)::TEXT = '' OR f.declared_mime = sqlc.arg(mime_type)::TEXT)
       AND (sqlc.arg(uploaded_after)::TIMESTAMPTZ IS NULL OR f.uploaded_at > sqlc.arg(uploaded_after)::TIMESTAMPTZ)
       AND (sqlc.arg(uploaded_before)::TIMESTAMPTZ IS NULL OR f.uploaded_at < sqlc.arg(uploaded_before)::TIMESTAMPTZ)
       AND (sqlc.narg(min_size)::BIGINT IS NULL OR f.size >= sqlc.narg(min_size)::BIGINT)
       AND (sqlc.narg(max_size)::BIGINT IS NULL OR f.size <= sqlc.narg(max_size)::BIGINT)
+
+    UNION ALL
+
+    SELECT
+        f.id, f.name AS filename, 'folder' AS item_type, NULL::bigint AS size,
+        NULL::text AS content_type, f.created_at AS uploaded_at,
+        (f.owner_id = sqlc.arg(user_id)) AS user_owns_file,
+        NULL::bigint AS download_count, NULL::uuid AS folder_id
+    FROM folders f
+    JOIN folder_shares fs ON f.id = fs.folder_id
+    WHERE fs.shared_with = sqlc.arg(user_id) AND f.trashed_at IS NULL
+      AND (sqlc.arg(search)::TEXT = '' OR f.name ILIKE '%' || sqlc.arg(search)::TEXT || '%')
+      AND (sqlc.arg(mime_type)::TEXT = 'folder/folder' OR sqlc.arg(mime_type)::TEXT = '')
+      AND sqlc.arg(ownership_status)::int <> 1
 ) 
-SELECT *, COUNT(*) OVER() AS total_count 
-FROM root_contents
+SELECT
+    c.*,
+    ARRAY(SELECT it.tag FROM item_tags it WHERE it.item_id = c.id ORDER BY it.tag)::TEXT[] AS tags,
+    COALESCE((SELECT jsonb_object_agg(im.key, im.value) FROM item_metadata im WHERE im.item_id = c.id), '{}')::JSONB AS metadata,
+    COUNT(*) OVER() AS total_count
+FROM root_contents c
+-- every tag filtered by and every metadata predicate must hold
+WHERE (sqlc.arg(tags)::TEXT[] IS NULL OR sqlc.arg(tags)::TEXT[] <@ ARRAY(SELECT it.tag FROM item_tags it WHERE it.item_id = c.id))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest(sqlc.arg(metadata_keys)::TEXT[], sqlc.arg(metadata_ops)::TEXT[], sqlc.arg(metadata_values)::TEXT[]) AS p(key, op, value)
+    WHERE NOT item_metadata_matches(c.id, p.key, p.op, p.value)
+  )
 ORDER BY
     item_type DESC,
     CASE WHEN sqlc.arg(sort_by)::text = 'filename' AND sqlc.arg(sort_order)::text = 'asc' THEN filename END ASC,
@@ -216,6 +263,107 @@ ORDER BY
     CASE WHEN sqlc.arg(sort_by)::text = 'uploaded_at' AND sqlc.arg(sort_order)::text = 'desc' THEN uploaded_at END DESC
 LIMIT $1 OFFSET $2;
 
+-- name: SearchContents :many
+-- Searches by name across every file and folder the user can see: the ones
+-- they own and the ones shared with them, directly or through a shared folder.
+-- Each result carries the IDs and names of the folders leading to it as the
+-- user sees them, from their root for owned items and from the topmost shared
+-- folder for shared ones. Names equal to, starting with or containing the
+-- search term rank highest; with fuzzy set, names that are only similar to it
+-- by pg_trgm word similarity are included too, ranked by that similarity.
+WITH RECURSIVE visible_folders AS (
+    SELECT fo.id, fo.name, fo.owner_id, fo.created_at,
+        ARRAY[fo.id]::UUID[] AS path_ids, ARRAY[fo.name]::TEXT[] AS path_names
+    FROM folders fo
+    WHERE fo.trashed_at IS NULL
+      AND (
+        (fo.owner_id = sqlc.arg(user_id) AND fo.parent_folder_id IS NULL)
+        OR EXISTS (SELECT 1 FROM folder_shares fs WHERE fs.folder_id = fo.id AND fs.shared_with = sqlc.arg(user_id))
+      )
+
+    UNION ALL
+
+    SELECT fo.id, fo.name, fo.owner_id, fo.created_at, vf.path_ids || fo.id, vf.path_names || fo.name
+    FROM folders fo
+    JOIN visible_folders vf ON fo.parent_folder_id = vf.id
+    WHERE fo.trashed_at IS NULL
+),
+-- a folder shared within another shared folder is reached twice, the shorter path wins
+folder_paths AS (
+    SELECT DISTINCT ON (id) id, name, owner_id, created_at, path_ids, path_names
+    FROM visible_folders
+    ORDER BY id, cardinality(path_ids)
+),
+candidates AS (
+    SELECT
+        fp.id, fp.name AS filename, 'folder' AS item_type, NULL::BIGINT AS size,
+        NULL::TEXT AS content_type, fp.created_at AS uploaded_at,
+        (fp.owner_id = sqlc.arg(user_id)) AS user_owns_file, NULL::BIGINT AS download_count,
+        fp.path_ids[1:cardinality(fp.path_ids) - 1] AS path_ids,
+        fp.path_names[1:cardinality(fp.path_names) - 1] AS path_names
+    FROM folder_paths fp
+    WHERE (sqlc.arg(mime_type)::TEXT = 'folder/folder' OR sqlc.arg(mime_type)::TEXT = '')
+
+    UNION ALL
+
+    -- files inside the visible folders
+    SELECT
+        f.id, f.filename, 'file' AS item_type, f.size, f.declared_mime AS content_type,
+        f.uploaded_at, (f.owner_id = sqlc.arg(user_id)) AS user_owns_file, f.download_count,
+        fp.path_ids, fp.path_names
+    FROM files f
+    JOIN folder_paths fp ON f.folder_id = fp.id
+    WHERE f.trashed_at IS NULL
+
+    UNION ALL
+
+    -- files at the user's root and files shared with them outside the visible folders
+    SELECT
+        f.id, f.filename, 'file' AS item_type, f.size, f.declared_mime AS content_type,
+        f.uploaded_at, (f.owner_id = sqlc.arg(user_id)) AS user_owns_file, f.download_count,
+        ARRAY[]::UUID[] AS path_ids, ARRAY[]::TEXT[] AS path_names
+    FROM files f
+    WHERE f.trashed_at IS NULL
+      AND (
+        (f.owner_id = sqlc.arg(user_id) AND f.folder_id IS NULL)
+        OR (
+            EXISTS (SELECT 1 FROM file_shares fs WHERE fs.file_id = f.id AND fs.shared_with = sqlc.arg(user_id))
+            AND NOT EXISTS (SELECT 1 FROM folder_paths fp WHERE fp.id = f.folder_id)
+        )
+      )
+),
+matches AS (
+    SELECT c.*,
+        CASE WHEN sqlc.arg(search)::TEXT = '' THEN 0 ELSE
+            (lower(c.filename) = lower(sqlc.arg(search)::TEXT))::INT
+            + (c.filename ILIKE sqlc.arg(search)::TEXT || '%')::INT
+            + (c.filename ILIKE '%' || sqlc.arg(search)::TEXT || '%')::INT
+            + word_similarity(sqlc.arg(search)::TEXT, c.filename)
+        END::REAL AS rank
+    FROM candidates c
+    WHERE (
+        sqlc.arg(search)::TEXT = ''
+        OR c.filename ILIKE '%' || sqlc.arg(search)::TEXT || '%'
+        OR (sqlc.arg(fuzzy)::BOOLEAN AND sqlc.arg(search)::TEXT <% c.filename)
+      )
+      AND (c.item_type = 'folder' OR sqlc.arg(mime_type)::TEXT = '' OR c.content_type = sqlc.arg(mime_type)::TEXT)
+      AND (sqlc.arg(uploaded_after)::TIMESTAMPTZ IS NULL OR c.item_type = 'folder' OR c.uploaded_at > sqlc.arg(uploaded_after)::TIMESTAMPTZ)
+      AND (sqlc.arg(uploaded_before)::TIMESTAMPTZ IS NULL OR c.item_type = 'folder' OR c.uploaded_at < sqlc.arg(uploaded_before)::TIMESTAMPTZ)
+      AND (sqlc.narg(min_size)::BIGINT IS NULL OR c.item_type = 'folder' OR c.size >= sqlc.narg(min_size)::BIGINT)
+      AND (sqlc.narg(max_size)::BIGINT IS NULL OR c.item_type = 'folder' OR c.size <= sqlc.narg(max_size)::BIGINT)
+      AND (
+        sqlc.arg(ownership_status)::int = 0
+        OR (sqlc.arg(ownership_status)::int = 1 AND c.user_owns_file)
+        OR (sqlc.arg(ownership_status)::int = 2 AND NOT c.user_owns_file)
+      )
+)
+SELECT
+    id, filename, item_type, size, content_type, uploaded_at, user_owns_file, download_count,
+    path_ids::UUID[] AS path_ids, path_names::TEXT[] AS path_names, rank, COUNT(*) OVER() AS total_count
+FROM matches
+ORDER BY rank DESC, item_type DESC, filename, id
+LIMIT $1 OFFSET $2;
+
 
 -- name: IncrementFileDownloadCount :exec
 UPDATE files
@@ -230,10 +378,36 @@ WITH RECURSIVE folder_hierarchy AS (
     SELECT f.id FROM folders f
     INNER JOIN folder_hierarchy fh ON f.parent_folder_id = fh.id
 )
-SELECT DISTINCT f.blob_id
+SELECT f.blob_id
 FROM files f
+WHERE f.folder_id IN (SELECT id FROM folder_hierarchy)
+UNION
+SELECT v.blob_id
+FROM file_versions v
+JOIN files f ON f.id = v.file_id
 WHERE f.folder_id IN (SELECT id FROM folder_hierarchy);
 
+-- name: ListFolderArchiveEntries :many
+-- Lists every folder of a subtree with the names of the folders leading to it,
+-- starting with the subtree's root, along with the files it contains. Folders
+-- without files are returned once with NULL file columns. Trashed folders and
+-- files are skipped.
+WITH RECURSIVE folder_hierarchy AS (
+    SELECT fo.id, ARRAY[fo.name]::TEXT[] AS path
+    FROM folders fo
+    WHERE fo.id = $1 AND fo.trashed_at IS NULL
+    UNION ALL
+    SELECT f.id, fh.path || f.name
+    FROM folders f
+    INNER JOIN folder_hierarchy fh ON f.parent_folder_id = fh.id
+    WHERE f.trashed_at IS NULL
+)
+SELECT fh.path::TEXT[] AS folder_path, f.id AS file_id, f.filename, f.size, f.uploaded_at, b.storage_path
+FROM folder_hierarchy fh
+LEFT JOIN files f ON f.folder_id = fh.id AND f.trashed_at IS NULL
+LEFT JOIN blobs b ON b.id = f.blob_id
+ORDER BY fh.path, f.filename, f.uploaded_at, f.id;
+
 -- name: ListAllFiles :many
 SELECT
     f.id,
@@ -0,0 +1,95 @@
+-- name: TrashFile :exec
+-- Moves a file to the trash. The file keeps its folder so it can be restored there.
+UPDATE files
+SET trashed_at = now(), trashed_with = NULL
+WHERE id = $1 AND trashed_at IS NULL;
+
+-- name: TrashFolder :exec
+-- Moves a folder and everything below it to the trash. The descendants are
+-- marked with the folder's ID so they are restored and purged together with it.
+-- Descendants that were already in the trash keep their own entry.
+WITH RECURSIVE subtree AS (
+    SELECT fo.id FROM folders fo WHERE fo.id = sqlc.arg(id) AND fo.trashed_at IS NULL
+    UNION ALL
+    SELECT f.id FROM folders f
+    INNER JOIN subtree st ON f.parent_folder_id = st.id
+    WHERE f.trashed_at IS NULL
+),
+trashed_folders AS (
+    UPDATE folders
+    SET trashed_at = now(),
+        trashed_with = CASE WHEN id = sqlc.arg(id) THEN NULL ELSE sqlc.arg(id)::UUID END
+    WHERE id IN (SELECT id FROM subtree)
+)
+UPDATE files
+SET trashed_at = now(), trashed_with = sqlc.arg(id)::UUID
+WHERE folder_id IN (SELECT id FROM subtree) AND trashed_at IS NULL;
+
+-- name: GetTrashedFile :one
+SELECT * FROM files
+WHERE id = $1 AND trashed_at IS NOT NULL AND trashed_with IS NULL;
+
+-- name: GetTrashedFolder :one
+SELECT * FROM folders
+WHERE id = $1 AND trashed_at IS NOT NULL AND trashed_with IS NULL;
+
+-- name: RestoreFile :one
+-- Takes a file out of the trash. It goes back to its folder, or to the root
+-- if that folder has been trashed in the meantime.
+UPDATE files f
+SET trashed_at = NULL,
+    folder_id = CASE
+        WHEN EXISTS (SELECT 1 FROM folders p WHERE p.id = f.folder_id AND p.trashed_at IS NOT NULL) THEN NULL
+        ELSE f.folder_id
+    END
+WHERE f.id = $1 AND f.trashed_at IS NOT NULL AND f.trashed_with IS NULL
+RETURNING *;
+
+-- name: RestoreFolder :exec
+-- Takes a folder and the contents trashed with it out of the trash. The folder
+-- goes back to its parent, or to the root if the parent is in the trash.
+WITH restored_files AS (
+    UPDATE files
+    SET trashed_at = NULL, trashed_with = NULL
+    WHERE trashed_with = sqlc.arg(id)::UUID
+),
+restored_folders AS (
+    UPDATE folders
+    SET trashed_at = NULL, trashed_with = NULL
+    WHERE trashed_with = sqlc.arg(id)::UUID
+)
+UPDATE folders f
+SET trashed_at = NULL,
+    parent_folder_id = CASE
+        WHEN EXISTS (SELECT 1 FROM folders p WHERE p.id = f.parent_folder_id AND p.trashed_at IS NOT NULL) THEN NULL
+        ELSE f.parent_folder_id
+    END
+WHERE f.id = sqlc.arg(id) AND f.trashed_at IS NOT NULL AND f.trashed_with IS NULL;
+
+-- name: ListTrash :many
+-- Lists the items a user moved to the trash. Contents trashed along with a
+-- folder are not listed separately.
+SELECT
+    f.id, f.name AS filename, 'folder' AS item_type, NULL::bigint AS size,
+    NULL::text AS content_type, f.parent_folder_id AS folder_id, f.trashed_at
+FROM folders f
+WHERE f.owner_id = $1 AND f.trashed_at IS NOT NULL AND f.trashed_with IS NULL
+
+UNION ALL
+
+SELECT
+    f.id, f.filename, 'file' AS item_type, f.size,
+    f.declared_mime AS content_type, f.folder_id, f.trashed_at
+FROM files f
+WHERE f.owner_id = $1 AND f.trashed_at IS NOT NULL AND f.trashed_with IS NULL
+ORDER BY trashed_at DESC;
+
+-- name: ListExpiredTrash :many
+-- Lists the trashed items of all users that were moved to the trash before the given time.
+SELECT id, owner_id, 'folder' AS item_type FROM folders
+WHERE trashed_at < $1 AND trashed_with IS NULL
+
+UNION ALL
+
+SELECT id, owner_id, 'file' AS item_type FROM files
+WHERE trashed_at < $1 AND trashed_with IS NULL;
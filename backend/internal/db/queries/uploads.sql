@@ -0,0 +1,69 @@
+-- name: CreateUploadSession :one
+INSERT INTO upload_sessions (owner_id, folder_id, filename, declared_mime, upload_length, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetUploadSession :one
+SELECT * FROM upload_sessions
+WHERE id = $1;
+
+-- name: AdvanceUploadSession :one
+UPDATE upload_sessions
+SET
+    upload_offset = upload_offset + sqlc.arg(chunk_size)::BIGINT,
+    chunk_keys = array_append(chunk_keys, sqlc.arg(chunk_key)::TEXT),
+    expires_at = sqlc.arg(expires_at)
+WHERE id = sqlc.arg(id) AND upload_offset = sqlc.arg(expected_offset) AND expires_at > now()
+RETURNING *;
+
+-- name: ClaimUploadSession :one
+-- Marks an unexpired upload session that received all of its bytes as being
+-- completed. Only one caller gets the row until the claim is released.
+UPDATE upload_sessions
+SET completing_at = now()
+WHERE id = $1 AND upload_offset = upload_length AND completing_at IS NULL AND expires_at > now()
+RETURNING *;
+
+-- name: ReleaseUploadSession :exec
+UPDATE upload_sessions
+SET completing_at = NULL
+WHERE id = $1;
+
+-- name: AbortUploadSession :one
+-- Removes an upload session unless it is being completed.
+DELETE FROM upload_sessions
+WHERE id = $1 AND completing_at IS NULL
+RETURNING *;
+
+-- name: DeleteUploadSession :exec
+DELETE FROM upload_sessions
+WHERE id = $1;
+
+-- name: DeleteExpiredUploadSessions :many
+DELETE FROM upload_sessions
+WHERE expires_at <= now()
+RETURNING *;
+
+-- name: CreateDirectUpload :one
+INSERT INTO direct_uploads (owner_id, folder_id, filename, declared_mime, size, sha256, staging_path, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetDirectUpload :one
+SELECT * FROM direct_uploads
+WHERE id = $1;
+
+-- name: DeleteDirectUpload :exec
+DELETE FROM direct_uploads
+WHERE id = $1;
+
+-- name: ClaimDirectUpload :one
+UPDATE direct_uploads
+SET claimed_at = now()
+WHERE id = $1 AND owner_id = $2 AND expires_at > now() AND claimed_at IS NULL
+RETURNING *;
+
+-- name: DeleteExpiredDirectUploads :many
+DELETE FROM direct_uploads
+WHERE expires_at <= sqlc.arg(expired_before)
+RETURNING *;
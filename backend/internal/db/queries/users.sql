@@ -19,5 +19,39 @@ ORDER BY name;
 SELECT COALESCE(SUM(b.size), 0)::BIGINT
 FROM blobs b
 WHERE b.id IN (
-    SELECT DISTINCT blob_id FROM files WHERE owner_id = $1
+    SELECT blob_id FROM files WHERE owner_id = $1
+    UNION
+    SELECT blob_id FROM file_versions WHERE owner_id = $1
 );
+
+-- name: GetUserByEmailFold :one
+-- Gets a user by email ignoring case, as identity providers may not keep the
+-- case the user signed up with.
+SELECT * FROM users WHERE lower(email) = lower(sqlc.arg(email));
+
+-- name: UserNameExists :one
+SELECT EXISTS (SELECT 1 FROM users WHERE name = $1);
+
+-- name: UserSharesWith :one
+-- Reports whether the owner shares a file or folder with the other user.
+SELECT EXISTS (
+    SELECT 1 FROM file_shares s
+    JOIN files f ON f.id = s.file_id
+    WHERE f.owner_id = sqlc.arg(owner_id) AND s.shared_with = sqlc.arg(shared_with)
+    UNION ALL
+    SELECT 1 FROM folder_shares s
+    JOIN folders f ON f.id = s.folder_id
+    WHERE f.owner_id = sqlc.arg(owner_id) AND s.shared_with = sqlc.arg(shared_with)
+);
+
+-- name: UpdateUserRole :exec
+UPDATE users SET role = $2 WHERE id = $1;
+
+-- name: UpdateUserPassword :exec
+UPDATE users SET password = $2 WHERE id = $1;
+
+-- name: VerifyUserEmail :execrows
+-- Marks the email address of a user as verified. Updates no row if it
+-- already was.
+UPDATE users SET email_verified_at = now()
+WHERE id = $1 AND email_verified_at IS NULL;
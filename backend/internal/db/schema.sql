@@ -1,3 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
 CREATE TABLE users (
     id BIGSERIAL PRIMARY KEY,
     name VARCHAR(255) UNIQUE NOT NULL,
@@ -6,7 +8,8 @@ CREATE TABLE users (
     role TEXT NOT NULL DEFAULT 'user',
     created_at TIMESTAMP DEFAULT NOW(),
     storage_quota BIGINT NOT NULL DEFAULT 10000000,
-    storage_used BIGINT NOT NULL DEFAULT 0
+    storage_used BIGINT NOT NULL DEFAULT 0,
+    email_verified_at TIMESTAMPTZ
 );
 
 CREATE TABLE blobs (
@@ -30,14 +33,41 @@ CREATE TABLE files (
   is_public BOOLEAN DEFAULT FALSE,
   public_token UUID,
   download_count BIGINT DEFAULT 0,
-  folder_id UUID REFERENCES folders(id) ON DELETE SET NULL
+  folder_id UUID REFERENCES folders(id) ON DELETE SET NULL,
+  public_expires_at TIMESTAMPTZ,
+  public_max_downloads INTEGER,
+  public_download_count INTEGER NOT NULL DEFAULT 0,
+  public_password_hash TEXT,
+  current_version INTEGER NOT NULL DEFAULT 1,
+  trashed_at TIMESTAMPTZ,
+  trashed_with UUID
+);
+
+CREATE TABLE file_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    file_id UUID NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    owner_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    blob_id UUID NOT NULL REFERENCES blobs(id),
+    size BIGINT NOT NULL,
+    declared_mime TEXT,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE(file_id, version)
+);
+
+CREATE TABLE blob_texts (
+    blob_id UUID PRIMARY KEY REFERENCES blobs(id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('indexed', 'unsupported', 'failed')),
+    content TEXT NOT NULL DEFAULT '',
+    tsv TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', content)) STORED,
+    extracted_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
 CREATE TABLE file_shares (
     id BIGSERIAL PRIMARY KEY,
     file_id UUID NOT NULL REFERENCES files(id) ON DELETE CASCADE,
     shared_with BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    permission TEXT NOT NULL DEFAULT 'read',
+    permission TEXT NOT NULL DEFAULT 'read' CHECK (permission IN ('read', 'comment', 'write', 'reshare')),
     created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
     UNIQUE(file_id, shared_with)
 );
@@ -47,10 +77,21 @@ CREATE TABLE folders (
     name TEXT NOT NULL,
     owner_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     parent_folder_id UUID REFERENCES folders(id) ON DELETE CASCADE,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    trashed_at TIMESTAMPTZ,
+    trashed_with UUID
 );
 
 
+CREATE TABLE folder_shares (
+    id BIGSERIAL PRIMARY KEY,
+    folder_id UUID NOT NULL REFERENCES folders(id) ON DELETE CASCADE,
+    shared_with BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    permission TEXT NOT NULL DEFAULT 'read' CHECK (permission IN ('read', 'comment', 'write', 'reshare')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE(folder_id, shared_with)
+);
+
 CREATE TABLE audit_logs (
     id BIGSERIAL PRIMARY KEY,
     user_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
@@ -60,13 +101,191 @@ CREATE TABLE audit_logs (
     created_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
+CREATE TABLE upload_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    folder_id UUID REFERENCES folders(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL,
+    declared_mime TEXT,
+    upload_length BIGINT NOT NULL,
+    upload_offset BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    chunk_keys TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMPTZ NOT NULL DEFAULT now() + INTERVAL '24 hours',
+    completing_at TIMESTAMPTZ
+);
+
+CREATE TABLE direct_uploads (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    folder_id UUID REFERENCES folders(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL,
+    declared_mime TEXT,
+    size BIGINT NOT NULL,
+    sha256 TEXT NOT NULL,
+    staging_path TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    claimed_at TIMESTAMPTZ
+);
+
+CREATE TABLE item_tags (
+    file_id UUID REFERENCES files(id) ON DELETE CASCADE,
+    folder_id UUID REFERENCES folders(id) ON DELETE CASCADE,
+    item_id UUID GENERATED ALWAYS AS (COALESCE(file_id, folder_id)) STORED,
+    tag TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((file_id IS NULL) <> (folder_id IS NULL)),
+    UNIQUE (item_id, tag)
+);
+
+CREATE TABLE item_metadata (
+    file_id UUID REFERENCES files(id) ON DELETE CASCADE,
+    folder_id UUID REFERENCES folders(id) ON DELETE CASCADE,
+    item_id UUID GENERATED ALWAYS AS (COALESCE(file_id, folder_id)) STORED,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((file_id IS NULL) <> (folder_id IS NULL)),
+    UNIQUE (item_id, key)
+);
+
+CREATE OR REPLACE FUNCTION item_metadata_matches(item UUID, meta_key TEXT, op TEXT, meta_value TEXT)
+RETURNS BOOLEAN AS $$
+    SELECT CASE op
+        WHEN 'exists' THEN EXISTS (
+            SELECT 1 FROM item_metadata m WHERE m.item_id = item AND m.key = meta_key)
+        WHEN 'eq' THEN EXISTS (
+            SELECT 1 FROM item_metadata m WHERE m.item_id = item AND m.key = meta_key AND m.value = meta_value)
+        WHEN 'ne' THEN NOT EXISTS (
+            SELECT 1 FROM item_metadata m WHERE m.item_id = item AND m.key = meta_key AND m.value = meta_value)
+        WHEN 'contains' THEN EXISTS (
+            SELECT 1 FROM item_metadata m WHERE m.item_id = item AND m.key = meta_key
+              AND strpos(lower(m.value), lower(meta_value)) > 0)
+        ELSE FALSE
+    END;
+$$ LANGUAGE sql STABLE;
+
+CREATE TABLE personal_access_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    token_prefix TEXT NOT NULL,
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    refresh_token_hash TEXT NOT NULL,
+    previous_refresh_token_hash TEXT,
+    rotated_at TIMESTAMPTZ,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    mfa_verified BOOLEAN NOT NULL DEFAULT false
+);
+
+CREATE TABLE user_identities (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (issuer, subject)
+);
+
+CREATE TABLE user_mfa (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    enabled_at TIMESTAMPTZ,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    failed_attempts INT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ
+);
+
+CREATE TABLE mfa_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE mfa_challenges (
+    token_hash TEXT PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE mfa_policies (
+    role TEXT PRIMARY KEY,
+    required BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE email_tokens (
+    token_hash TEXT PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose TEXT NOT NULL CHECK (purpose IN ('verify_email', 'reset_password')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
 CREATE TYPE audit_action AS ENUM (
     'USER_REGISTERED',
     'USER_LOGGED_IN',
     'FILE_UPLOADED',
     'FILE_DOWNLOADED',
     'FILE_RENAMED',
-    'FILE_DELETED'
+    'FILE_DELETED',
+    'PUBLIC_LINK_CREATED',
+    'PUBLIC_LINK_ROTATED',
+    'PUBLIC_LINK_REVOKED',
+    'PUBLIC_LINK_USED',
+    'FILE_REPLACED',
+    'FILE_SHARED',
+    'FOLDER_SHARED',
+    'FILE_VERSION_RESTORED',
+    'FILE_VERSION_DELETED',
+    'FILE_RESTORED',
+    'FOLDER_DELETED',
+    'FOLDER_RESTORED',
+    'TRASH_PURGED',
+    'ARCHIVE_DOWNLOADED',
+    'ARCHIVE_EXTRACTED',
+    'FILE_COPIED',
+    'FOLDER_COPIED',
+    'TAGS_UPDATED',
+    'METADATA_UPDATED',
+    'TOKEN_CREATED',
+    'TOKEN_REVOKED',
+    'USER_LOGGED_OUT',
+    'SESSION_REVOKED',
+    'REFRESH_TOKEN_REUSED',
+    'IDENTITY_LINKED',
+    'USER_ROLE_CHANGED',
+    'MFA_ENABLED',
+    'MFA_DISABLED',
+    'MFA_RECOVERY_CODE_USED',
+    'MFA_RECOVERY_CODES_REGENERATED',
+    'MFA_POLICY_CHANGED',
+    'EMAIL_VERIFIED',
+    'PASSWORD_RESET_REQUESTED',
+    'PASSWORD_RESET'
 );
 
 CREATE INDEX idx_blobs_sha256 ON blobs(sha256);
@@ -74,6 +293,28 @@ CREATE INDEX idx_files_owner ON files(owner_id);
 CREATE INDEX idx_files_owner_filename ON files(owner_id, filename);
 CREATE INDEX idx_folders_owner_id_parent_id ON folders(owner_id, parent_folder_id);
 CREATE INDEX idx_files_folder_id ON files(folder_id);
+CREATE UNIQUE INDEX idx_files_public_token ON files(public_token) WHERE public_token IS NOT NULL;
 CREATE INDEX idx_audit_logs_user_id ON audit_logs(user_id);
 CREATE INDEX idx_audit_logs_action ON audit_logs(action);
 CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at);
+CREATE INDEX idx_upload_sessions_owner_id ON upload_sessions(owner_id);
+CREATE INDEX idx_upload_sessions_expires_at ON upload_sessions(expires_at);
+CREATE INDEX idx_direct_uploads_owner_id ON direct_uploads(owner_id);
+CREATE INDEX idx_folder_shares_shared_with ON folder_shares(shared_with);
+CREATE INDEX idx_file_versions_blob_id ON file_versions(blob_id);
+CREATE INDEX idx_file_versions_owner_id ON file_versions(owner_id);
+CREATE INDEX idx_files_trashed_with ON files(trashed_with) WHERE trashed_with IS NOT NULL;
+CREATE INDEX idx_folders_trashed_with ON folders(trashed_with) WHERE trashed_with IS NOT NULL;
+CREATE INDEX idx_files_trashed_at ON files(trashed_at) WHERE trashed_at IS NOT NULL AND trashed_with IS NULL;
+CREATE INDEX idx_folders_trashed_at ON folders(trashed_at) WHERE trashed_at IS NOT NULL AND trashed_with IS NULL;
+CREATE INDEX idx_files_filename_trgm ON files USING GIN (filename gin_trgm_ops);
+CREATE INDEX idx_folders_name_trgm ON folders USING GIN (name gin_trgm_ops);
+CREATE INDEX idx_blob_texts_tsv ON blob_texts USING GIN (tsv);
+CREATE INDEX idx_item_tags_tag ON item_tags(tag);
+CREATE INDEX idx_item_metadata_key_value ON item_metadata(key, value);
+CREATE INDEX idx_personal_access_tokens_user_id ON personal_access_tokens(user_id);
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX idx_user_identities_user_id ON user_identities(user_id);
+CREATE INDEX idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);
+CREATE INDEX idx_mfa_challenges_user_id ON mfa_challenges(user_id);
+CREATE INDEX idx_email_tokens_user_id ON email_tokens(user_id, purpose);
@@ -31,6 +31,7 @@ func (r iteratorForAddSharesToFile) Values() ([]interface{}, error) {
 	return []interface{}{
 		r.rows[0].FileID,
 		r.rows[0].SharedWith,
+		r.rows[0].Permission,
 	}, nil
 }
 
@@ -39,5 +40,5 @@ func (r iteratorForAddSharesToFile) Err() error {
 }
 
 func (q *Queries) AddSharesToFile(ctx context.Context, arg []AddSharesToFileParams) (int64, error) {
-	return q.db.CopyFrom(ctx, []string{"file_shares"}, []string{"file_id", "shared_with"}, &iteratorForAddSharesToFile{rows: arg})
+	return q.db.CopyFrom(ctx, []string{"file_shares"}, []string{"file_id", "shared_with", "permission"}, &iteratorForAddSharesToFile{rows: arg})
 }
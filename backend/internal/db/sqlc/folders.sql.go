@@ -12,6 +12,57 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const copyFolderTree = `-- name: CopyFolderTree :one
+WITH RECURSIVE tree AS (
+    SELECT fo.id, gen_random_uuid() AS new_id, $1::UUID AS new_parent_id, $2::TEXT AS name
+    FROM folders fo
+    WHERE fo.id = $3 AND fo.trashed_at IS NULL
+
+    UNION ALL
+
+    SELECT fo.id, gen_random_uuid(), t.new_id, fo.name
+    FROM folders fo
+    JOIN tree t ON fo.parent_folder_id = t.id
+    WHERE fo.trashed_at IS NULL
+), copied_folders AS (
+    INSERT INTO folders (id, name, owner_id, parent_folder_id)
+    SELECT new_id, tree.name, $4, new_parent_id FROM tree
+), copied_files AS (
+    INSERT INTO files (owner_id, blob_id, filename, declared_mime, size, folder_id)
+    SELECT $4, f.blob_id, f.filename, f.declared_mime, f.size, t.new_id
+    FROM files f
+    JOIN tree t ON f.folder_id = t.id
+    WHERE f.trashed_at IS NULL
+)
+SELECT new_id FROM tree
+WHERE id = $3
+`
+
+type CopyFolderTreeParams struct {
+	TargetFolderID pgtype.UUID `json:"target_folder_id"`
+	Name           string      `json:"name"`
+	SourceFolderID uuid.UUID   `json:"source_folder_id"`
+	OwnerID        int64       `json:"owner_id"`
+}
+
+// Copies a folder with its subfolders and files into target_folder_id of
+// owner_id, or to the root when target_folder_id is NULL, naming the copy of
+// the folder itself after name. Trashed folders and files are left out. The
+// copied files point at the blobs of the originals, so nothing is written to
+// storage; the insert trigger counts the new references and charges the owner.
+// Returns the ID of the new folder.
+func (q *Queries) CopyFolderTree(ctx context.Context, arg CopyFolderTreeParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, copyFolderTree,
+		arg.TargetFolderID,
+		arg.Name,
+		arg.SourceFolderID,
+		arg.OwnerID,
+	)
+	var new_id uuid.UUID
+	err := row.Scan(&new_id)
+	return new_id, err
+}
+
 const createFolder = `-- name: CreateFolder :one
 INSERT INTO folders (
     name,
@@ -19,7 +70,7 @@ INSERT INTO folders (
     parent_folder_id
 ) VALUES (
     $1, $2, $3
-) RETURNING id, name, owner_id, parent_folder_id, created_at
+) RETURNING id, name, owner_id, parent_folder_id, created_at, trashed_at, trashed_with
 `
 
 type CreateFolderParams struct {
@@ -37,6 +88,45 @@ func (q *Queries) CreateFolder(ctx context.Context, arg CreateFolderParams) (Fol
 		&i.OwnerID,
 		&i.ParentFolderID,
 		&i.CreatedAt,
+		&i.TrashedAt,
+		&i.TrashedWith,
+	)
+	return i, err
+}
+
+const createFolderIfNotExists = `-- name: CreateFolderIfNotExists :one
+INSERT INTO folders (name, owner_id, parent_folder_id)
+SELECT $1::TEXT, $2::BIGINT, $3::UUID
+WHERE NOT EXISTS (
+    SELECT 1 FROM folders f
+    WHERE f.owner_id = $2
+      AND f.name = $1
+      AND f.parent_folder_id IS NOT DISTINCT FROM $3
+      AND f.trashed_at IS NULL
+)
+RETURNING id, name, owner_id, parent_folder_id, created_at, trashed_at, trashed_with
+`
+
+type CreateFolderIfNotExistsParams struct {
+	Name           string      `json:"name"`
+	OwnerID        int64       `json:"owner_id"`
+	ParentFolderID pgtype.UUID `json:"parent_folder_id"`
+}
+
+// Creates a folder unless the owner already has one of that name in the
+// parent, in which case no row is returned. Run after LockFolderName in the
+// same transaction, so that a folder created concurrently is seen.
+func (q *Queries) CreateFolderIfNotExists(ctx context.Context, arg CreateFolderIfNotExistsParams) (Folder, error) {
+	row := q.db.QueryRow(ctx, createFolderIfNotExists, arg.Name, arg.OwnerID, arg.ParentFolderID)
+	var i Folder
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.OwnerID,
+		&i.ParentFolderID,
+		&i.CreatedAt,
+		&i.TrashedAt,
+		&i.TrashedWith,
 	)
 	return i, err
 }
@@ -52,8 +142,8 @@ func (q *Queries) DeleteFolder(ctx context.Context, id uuid.UUID) error {
 }
 
 const getFolderByID = `-- name: GetFolderByID :one
-SELECT id, name, owner_id, parent_folder_id, created_at FROM folders
-WHERE id = $1
+SELECT id, name, owner_id, parent_folder_id, created_at, trashed_at, trashed_with FROM folders
+WHERE id = $1 AND trashed_at IS NULL
 `
 
 func (q *Queries) GetFolderByID(ctx context.Context, id uuid.UUID) (Folder, error) {
@@ -65,10 +155,126 @@ func (q *Queries) GetFolderByID(ctx context.Context, id uuid.UUID) (Folder, erro
 		&i.OwnerID,
 		&i.ParentFolderID,
 		&i.CreatedAt,
+		&i.TrashedAt,
+		&i.TrashedWith,
 	)
 	return i, err
 }
 
+const getFolderByName = `-- name: GetFolderByName :one
+SELECT id, name, owner_id, parent_folder_id, created_at, trashed_at, trashed_with FROM folders
+WHERE owner_id = $1
+  AND name = $2
+  AND parent_folder_id IS NOT DISTINCT FROM $3
+  AND trashed_at IS NULL
+ORDER BY created_at, id
+LIMIT 1
+`
+
+type GetFolderByNameParams struct {
+	OwnerID        int64       `json:"owner_id"`
+	Name           string      `json:"name"`
+	ParentFolderID pgtype.UUID `json:"parent_folder_id"`
+}
+
+// Finds a folder of the owner by its name within a parent folder, or at the
+// root when parent_folder_id is NULL. The oldest one wins if names repeat.
+func (q *Queries) GetFolderByName(ctx context.Context, arg GetFolderByNameParams) (Folder, error) {
+	row := q.db.QueryRow(ctx, getFolderByName, arg.OwnerID, arg.Name, arg.ParentFolderID)
+	var i Folder
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.OwnerID,
+		&i.ParentFolderID,
+		&i.CreatedAt,
+		&i.TrashedAt,
+		&i.TrashedWith,
+	)
+	return i, err
+}
+
+const getInheritedFolderPermissions = `-- name: GetInheritedFolderPermissions :many
+WITH RECURSIVE ancestors AS (
+    SELECT id, parent_folder_id FROM folders WHERE id = $1
+
+    UNION ALL
+
+    SELECT f.id, f.parent_folder_id
+    FROM folders f
+    INNER JOIN ancestors a ON f.id = a.parent_folder_id
+)
+SELECT fs.permission
+FROM folder_shares fs
+JOIN ancestors a ON a.id = fs.folder_id
+WHERE fs.shared_with = $2
+`
+
+type GetInheritedFolderPermissionsParams struct {
+	FolderID uuid.UUID `json:"folder_id"`
+	UserID   int64     `json:"user_id"`
+}
+
+func (q *Queries) GetInheritedFolderPermissions(ctx context.Context, arg GetInheritedFolderPermissionsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, getInheritedFolderPermissions, arg.FolderID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		items = append(items, permission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFolderShares = `-- name: ListFolderShares :many
+SELECT u.id, u.name, u.email, fs.permission
+FROM folder_shares fs
+JOIN users u ON u.id = fs.shared_with
+WHERE fs.folder_id = $1
+ORDER BY fs.id
+`
+
+type ListFolderSharesRow struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Email      string `json:"email"`
+	Permission string `json:"permission"`
+}
+
+func (q *Queries) ListFolderShares(ctx context.Context, folderID uuid.UUID) ([]ListFolderSharesRow, error) {
+	rows, err := q.db.Query(ctx, listFolderShares, folderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFolderSharesRow{}
+	for rows.Next() {
+		var i ListFolderSharesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.Permission,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listSelectableFolders = `-- name: ListSelectableFolders :many
 WITH RECURSIVE forbidden_folders AS (
     SELECT id FROM folders WHERE id = $2::uuid
@@ -88,6 +294,7 @@ SELECT
 FROM folders f
 WHERE
     f.owner_id = $1
+    AND f.trashed_at IS NULL
     -- exclude all folders that are in the forbidden list
     AND f.id NOT IN (SELECT id FROM forbidden_folders)
 ORDER BY
@@ -131,6 +338,48 @@ func (q *Queries) ListSelectableFolders(ctx context.Context, arg ListSelectableF
 	return items, nil
 }
 
+const lockFolderName = `-- name: LockFolderName :exec
+SELECT pg_advisory_xact_lock(hashtextextended(
+    $1::BIGINT::TEXT || '/' || COALESCE($2::UUID::TEXT, '') || '/' || $3::TEXT,
+    0
+))
+`
+
+type LockFolderNameParams struct {
+	OwnerID        int64       `json:"owner_id"`
+	ParentFolderID pgtype.UUID `json:"parent_folder_id"`
+	Name           string      `json:"name"`
+}
+
+// Locks a folder name of the owner within a parent folder, or at the root when
+// parent_folder_id is NULL, until the transaction ends.
+func (q *Queries) LockFolderName(ctx context.Context, arg LockFolderNameParams) error {
+	_, err := q.db.Exec(ctx, lockFolderName, arg.OwnerID, arg.ParentFolderID, arg.Name)
+	return err
+}
+
+const replaceFolderShares = `-- name: ReplaceFolderShares :exec
+WITH removed AS (
+    DELETE FROM folder_shares
+    WHERE folder_id = $1
+      AND shared_with <> ALL($2::BIGINT[])
+)
+INSERT INTO folder_shares (folder_id, shared_with, permission)
+SELECT $1, unnest($2::BIGINT[]), unnest($3::TEXT[])
+ON CONFLICT (folder_id, shared_with) DO UPDATE SET permission = EXCLUDED.permission
+`
+
+type ReplaceFolderSharesParams struct {
+	FolderID    uuid.UUID `json:"folder_id"`
+	UserIds     []int64   `json:"user_ids"`
+	Permissions []string  `json:"permissions"`
+}
+
+func (q *Queries) ReplaceFolderShares(ctx context.Context, arg ReplaceFolderSharesParams) error {
+	_, err := q.db.Exec(ctx, replaceFolderShares, arg.FolderID, arg.UserIds, arg.Permissions)
+	return err
+}
+
 const updateFolder = `-- name: UpdateFolder :one
 UPDATE folders
 SET 
@@ -172,7 +421,7 @@ const updateFolderParentFolder = `-- name: UpdateFolderParentFolder :exec
 UPDATE folders
 SET parent_folder_id = $1
 WHERE id = $2
-RETURNING id, name, owner_id, parent_folder_id, created_at
+RETURNING id, name, owner_id, parent_folder_id, created_at, trashed_at, trashed_with
 `
 
 type UpdateFolderParentFolderParams struct {
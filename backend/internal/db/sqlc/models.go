@@ -16,12 +16,46 @@ import (
 type AuditAction string
 
 const (
-	AuditActionUSERREGISTERED AuditAction = "USER_REGISTERED"
-	AuditActionUSERLOGGEDIN   AuditAction = "USER_LOGGED_IN"
-	AuditActionFILEUPLOADED   AuditAction = "FILE_UPLOADED"
-	AuditActionFILEDOWNLOADED AuditAction = "FILE_DOWNLOADED"
-	AuditActionFILERENAMED    AuditAction = "FILE_RENAMED"
-	AuditActionFILEDELETED    AuditAction = "FILE_DELETED"
+	AuditActionUSERREGISTERED              AuditAction = "USER_REGISTERED"
+	AuditActionUSERLOGGEDIN                AuditAction = "USER_LOGGED_IN"
+	AuditActionFILEUPLOADED                AuditAction = "FILE_UPLOADED"
+	AuditActionFILEDOWNLOADED              AuditAction = "FILE_DOWNLOADED"
+	AuditActionFILERENAMED                 AuditAction = "FILE_RENAMED"
+	AuditActionFILEDELETED                 AuditAction = "FILE_DELETED"
+	AuditActionPUBLICLINKCREATED           AuditAction = "PUBLIC_LINK_CREATED"
+	AuditActionPUBLICLINKROTATED           AuditAction = "PUBLIC_LINK_ROTATED"
+	AuditActionPUBLICLINKREVOKED           AuditAction = "PUBLIC_LINK_REVOKED"
+	AuditActionPUBLICLINKUSED              AuditAction = "PUBLIC_LINK_USED"
+	AuditActionFILEREPLACED                AuditAction = "FILE_REPLACED"
+	AuditActionFILESHARED                  AuditAction = "FILE_SHARED"
+	AuditActionFOLDERSHARED                AuditAction = "FOLDER_SHARED"
+	AuditActionFILEVERSIONRESTORED         AuditAction = "FILE_VERSION_RESTORED"
+	AuditActionFILEVERSIONDELETED          AuditAction = "FILE_VERSION_DELETED"
+	AuditActionFILERESTORED                AuditAction = "FILE_RESTORED"
+	AuditActionFOLDERDELETED               AuditAction = "FOLDER_DELETED"
+	AuditActionFOLDERRESTORED              AuditAction = "FOLDER_RESTORED"
+	AuditActionTRASHPURGED                 AuditAction = "TRASH_PURGED"
+	AuditActionARCHIVEDOWNLOADED           AuditAction = "ARCHIVE_DOWNLOADED"
+	AuditActionARCHIVEEXTRACTED            AuditAction = "ARCHIVE_EXTRACTED"
+	AuditActionFILECOPIED                  AuditAction = "FILE_COPIED"
+	AuditActionFOLDERCOPIED                AuditAction = "FOLDER_COPIED"
+	AuditActionTAGSUPDATED                 AuditAction = "TAGS_UPDATED"
+	AuditActionMETADATAUPDATED             AuditAction = "METADATA_UPDATED"
+	AuditActionTOKENCREATED                AuditAction = "TOKEN_CREATED"
+	AuditActionTOKENREVOKED                AuditAction = "TOKEN_REVOKED"
+	AuditActionUSERLOGGEDOUT               AuditAction = "USER_LOGGED_OUT"
+	AuditActionSESSIONREVOKED              AuditAction = "SESSION_REVOKED"
+	AuditActionREFRESHTOKENREUSED          AuditAction = "REFRESH_TOKEN_REUSED"
+	AuditActionIDENTITYLINKED              AuditAction = "IDENTITY_LINKED"
+	AuditActionUSERROLECHANGED             AuditAction = "USER_ROLE_CHANGED"
+	AuditActionMFAENABLED                  AuditAction = "MFA_ENABLED"
+	AuditActionMFADISABLED                 AuditAction = "MFA_DISABLED"
+	AuditActionMFARECOVERYCODEUSED         AuditAction = "MFA_RECOVERY_CODE_USED"
+	AuditActionMFARECOVERYCODESREGENERATED AuditAction = "MFA_RECOVERY_CODES_REGENERATED"
+	AuditActionMFAPOLICYCHANGED            AuditAction = "MFA_POLICY_CHANGED"
+	AuditActionEMAILVERIFIED               AuditAction = "EMAIL_VERIFIED"
+	AuditActionPASSWORDRESETREQUESTED      AuditAction = "PASSWORD_RESET_REQUESTED"
+	AuditActionPASSWORDRESET               AuditAction = "PASSWORD_RESET"
 )
 
 func (e *AuditAction) Scan(src interface{}) error {
@@ -78,18 +112,56 @@ type Blob struct {
 	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 }
 
+type BlobText struct {
+	BlobID      uuid.UUID          `json:"blob_id"`
+	Status      string             `json:"status"`
+	Content     string             `json:"content"`
+	Tsv         interface{}        `json:"tsv"`
+	ExtractedAt pgtype.Timestamptz `json:"extracted_at"`
+}
+
+type DirectUpload struct {
+	ID           uuid.UUID          `json:"id"`
+	OwnerID      int64              `json:"owner_id"`
+	FolderID     pgtype.UUID        `json:"folder_id"`
+	Filename     string             `json:"filename"`
+	DeclaredMime pgtype.Text        `json:"declared_mime"`
+	Size         int64              `json:"size"`
+	Sha256       string             `json:"sha256"`
+	StagingPath  string             `json:"staging_path"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	ClaimedAt    pgtype.Timestamptz `json:"claimed_at"`
+}
+
+type EmailToken struct {
+	TokenHash string             `json:"token_hash"`
+	UserID    int64              `json:"user_id"`
+	Purpose   string             `json:"purpose"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
 type File struct {
-	ID            uuid.UUID          `json:"id"`
-	OwnerID       int64              `json:"owner_id"`
-	BlobID        uuid.UUID          `json:"blob_id"`
-	Filename      string             `json:"filename"`
-	DeclaredMime  pgtype.Text        `json:"declared_mime"`
-	Size          int64              `json:"size"`
-	UploadedAt    pgtype.Timestamptz `json:"uploaded_at"`
-	IsPublic      pgtype.Bool        `json:"is_public"`
-	PublicToken   pgtype.UUID        `json:"public_token"`
-	DownloadCount sql.NullInt64      `json:"download_count"`
-	FolderID      pgtype.UUID        `json:"folder_id"`
+	ID                  uuid.UUID          `json:"id"`
+	OwnerID             int64              `json:"owner_id"`
+	BlobID              uuid.UUID          `json:"blob_id"`
+	Filename            string             `json:"filename"`
+	DeclaredMime        pgtype.Text        `json:"declared_mime"`
+	Size                int64              `json:"size"`
+	UploadedAt          pgtype.Timestamptz `json:"uploaded_at"`
+	IsPublic            pgtype.Bool        `json:"is_public"`
+	PublicToken         pgtype.UUID        `json:"public_token"`
+	DownloadCount       sql.NullInt64      `json:"download_count"`
+	FolderID            pgtype.UUID        `json:"folder_id"`
+	PublicExpiresAt     pgtype.Timestamptz `json:"public_expires_at"`
+	PublicMaxDownloads  pgtype.Int4        `json:"public_max_downloads"`
+	PublicDownloadCount int32              `json:"public_download_count"`
+	PublicPasswordHash  pgtype.Text        `json:"public_password_hash"`
+	CurrentVersion      int32              `json:"current_version"`
+	TrashedAt           pgtype.Timestamptz `json:"trashed_at"`
+	TrashedWith         pgtype.UUID        `json:"trashed_with"`
 }
 
 type FileShare struct {
@@ -100,21 +172,143 @@ type FileShare struct {
 	CreatedAt  pgtype.Timestamptz `json:"created_at"`
 }
 
+type FileVersion struct {
+	ID           uuid.UUID          `json:"id"`
+	FileID       uuid.UUID          `json:"file_id"`
+	OwnerID      int64              `json:"owner_id"`
+	Version      int32              `json:"version"`
+	BlobID       uuid.UUID          `json:"blob_id"`
+	Size         int64              `json:"size"`
+	DeclaredMime pgtype.Text        `json:"declared_mime"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
 type Folder struct {
 	ID             uuid.UUID          `json:"id"`
 	Name           string             `json:"name"`
 	OwnerID        int64              `json:"owner_id"`
 	ParentFolderID pgtype.UUID        `json:"parent_folder_id"`
 	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	TrashedAt      pgtype.Timestamptz `json:"trashed_at"`
+	TrashedWith    pgtype.UUID        `json:"trashed_with"`
+}
+
+type FolderShare struct {
+	ID         int64              `json:"id"`
+	FolderID   uuid.UUID          `json:"folder_id"`
+	SharedWith int64              `json:"shared_with"`
+	Permission string             `json:"permission"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type ItemMetadatum struct {
+	FileID    pgtype.UUID        `json:"file_id"`
+	FolderID  pgtype.UUID        `json:"folder_id"`
+	ItemID    pgtype.UUID        `json:"item_id"`
+	Key       string             `json:"key"`
+	Value     string             `json:"value"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type ItemTag struct {
+	FileID    pgtype.UUID        `json:"file_id"`
+	FolderID  pgtype.UUID        `json:"folder_id"`
+	ItemID    pgtype.UUID        `json:"item_id"`
+	Tag       string             `json:"tag"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type MfaChallenge struct {
+	TokenHash string             `json:"token_hash"`
+	UserID    int64              `json:"user_id"`
+	Attempts  int32              `json:"attempts"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type MfaPolicy struct {
+	Role      string             `json:"role"`
+	Required  bool               `json:"required"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type MfaRecoveryCode struct {
+	ID        uuid.UUID          `json:"id"`
+	UserID    int64              `json:"user_id"`
+	CodeHash  string             `json:"code_hash"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type PersonalAccessToken struct {
+	ID          uuid.UUID          `json:"id"`
+	UserID      int64              `json:"user_id"`
+	Name        string             `json:"name"`
+	TokenHash   string             `json:"token_hash"`
+	TokenPrefix string             `json:"token_prefix"`
+	Scopes      []string           `json:"scopes"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt  pgtype.Timestamptz `json:"last_used_at"`
+	RevokedAt   pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type Session struct {
+	ID                       uuid.UUID          `json:"id"`
+	UserID                   int64              `json:"user_id"`
+	RefreshTokenHash         string             `json:"refresh_token_hash"`
+	PreviousRefreshTokenHash pgtype.Text        `json:"previous_refresh_token_hash"`
+	RotatedAt                pgtype.Timestamptz `json:"rotated_at"`
+	UserAgent                string             `json:"user_agent"`
+	IpAddress                string             `json:"ip_address"`
+	CreatedAt                pgtype.Timestamptz `json:"created_at"`
+	LastUsedAt               pgtype.Timestamptz `json:"last_used_at"`
+	ExpiresAt                pgtype.Timestamptz `json:"expires_at"`
+	RevokedAt                pgtype.Timestamptz `json:"revoked_at"`
+	MfaVerified              bool               `json:"mfa_verified"`
+}
+
+type UploadSession struct {
+	ID           uuid.UUID          `json:"id"`
+	OwnerID      int64              `json:"owner_id"`
+	FolderID     pgtype.UUID        `json:"folder_id"`
+	Filename     string             `json:"filename"`
+	DeclaredMime pgtype.Text        `json:"declared_mime"`
+	UploadLength int64              `json:"upload_length"`
+	UploadOffset int64              `json:"upload_offset"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	ChunkKeys    []string           `json:"chunk_keys"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	CompletingAt pgtype.Timestamptz `json:"completing_at"`
 }
 
 type User struct {
-	ID           int64            `json:"id"`
-	Name         string           `json:"name"`
-	Email        string           `json:"email"`
-	Password     string           `json:"password"`
-	Role         string           `json:"role"`
-	CreatedAt    pgtype.Timestamp `json:"created_at"`
-	StorageQuota int64            `json:"storage_quota"`
-	StorageUsed  int64            `json:"storage_used"`
+	ID              int64              `json:"id"`
+	Name            string             `json:"name"`
+	Email           string             `json:"email"`
+	Password        string             `json:"password"`
+	Role            string             `json:"role"`
+	CreatedAt       pgtype.Timestamp   `json:"created_at"`
+	StorageQuota    int64              `json:"storage_quota"`
+	StorageUsed     int64              `json:"storage_used"`
+	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
+}
+
+type UserIdentity struct {
+	ID        uuid.UUID          `json:"id"`
+	UserID    int64              `json:"user_id"`
+	Issuer    string             `json:"issuer"`
+	Subject   string             `json:"subject"`
+	Email     string             `json:"email"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type UserMfa struct {
+	UserID         int64              `json:"user_id"`
+	Secret         string             `json:"secret"`
+	EnabledAt      pgtype.Timestamptz `json:"enabled_at"`
+	LastUsedStep   int64              `json:"last_used_step"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	FailedAttempts int32              `json:"failed_attempts"`
+	LockedUntil    pgtype.Timestamptz `json:"locked_until"`
 }
@@ -8,48 +8,223 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	// Removes an upload session unless it is being completed.
+	AbortUploadSession(ctx context.Context, id uuid.UUID) (UploadSession, error)
+	// Adds every tag to every file and folder, keeping the tags they already have.
+	AddItemTags(ctx context.Context, arg AddItemTagsParams) error
 	AddSharesToFile(ctx context.Context, arg []AddSharesToFileParams) (int64, error)
+	AdvanceUploadSession(ctx context.Context, arg AdvanceUploadSessionParams) (UploadSession, error)
+	ArchiveCurrentVersion(ctx context.Context, id uuid.UUID) (FileVersion, error)
+	// Counts an attempt at a challenge that has neither expired nor run out of
+	// attempts, and returns it. Returns no row otherwise.
+	AttemptMFAChallenge(ctx context.Context, arg AttemptMFAChallengeParams) (MfaChallenge, error)
+	ClaimDirectUpload(ctx context.Context, arg ClaimDirectUploadParams) (DirectUpload, error)
+	ClaimPublicDownload(ctx context.Context, id uuid.UUID) (int32, error)
+	// Marks an unexpired upload session that received all of its bytes as being
+	// completed. Only one caller gets the row until the claim is released.
+	ClaimUploadSession(ctx context.Context, id uuid.UUID) (UploadSession, error)
+	// Copies a folder with its subfolders and files into target_folder_id of
+	// owner_id, or to the root when target_folder_id is NULL, naming the copy of
+	// the folder itself after name. Trashed folders and files are left out. The
+	// copied files point at the blobs of the originals, so nothing is written to
+	// storage; the insert trigger counts the new references and charges the owner.
+	// Returns the ID of the new folder.
+	CopyFolderTree(ctx context.Context, arg CopyFolderTreeParams) (uuid.UUID, error)
+	CountActivePersonalAccessTokens(ctx context.Context, userID int64) (int64, error)
+	// Counts a code entered at login by a user who is not locked out. Once
+	// max_attempts codes in a row were counted the user is locked out until
+	// locked_until, and counting starts over. Returns no row while locked out.
+	CountMFAAttempt(ctx context.Context, arg CountMFAAttemptParams) (UserMfa, error)
+	CountMFARecoveryCodes(ctx context.Context, userID int64) (int64, error)
 	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error)
 	CreateBlob(ctx context.Context, arg CreateBlobParams) (Blob, error)
+	CreateDirectUpload(ctx context.Context, arg CreateDirectUploadParams) (DirectUpload, error)
+	CreateEmailToken(ctx context.Context, arg CreateEmailTokenParams) error
 	CreateFile(ctx context.Context, arg CreateFileParams) (File, error)
 	CreateFolder(ctx context.Context, arg CreateFolderParams) (Folder, error)
+	// Creates a folder unless the owner already has one of that name in the
+	// parent, in which case no row is returned. Run after LockFolderName in the
+	// same transaction, so that a folder created concurrently is seen.
+	CreateFolderIfNotExists(ctx context.Context, arg CreateFolderIfNotExistsParams) (Folder, error)
+	CreateMFAChallenge(ctx context.Context, arg CreateMFAChallengeParams) error
+	CreateMFARecoveryCode(ctx context.Context, arg CreateMFARecoveryCodeParams) error
+	CreatePersonalAccessToken(ctx context.Context, arg CreatePersonalAccessTokenParams) (PersonalAccessToken, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateUploadSession(ctx context.Context, arg CreateUploadSessionParams) (UploadSession, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error)
 	DeleteAllSharesForFile(ctx context.Context, fileID uuid.UUID) error
 	DeleteBlob(ctx context.Context, id uuid.UUID) error
-	DeleteBlobIfUnused(ctx context.Context, id uuid.UUID) (string, error)
+	DeleteBlobIfUnused(ctx context.Context, id uuid.UUID) (DeleteBlobIfUnusedRow, error)
 	DeleteBlobsByStoragePaths(ctx context.Context, storagePaths []string) error
+	DeleteDirectUpload(ctx context.Context, id uuid.UUID) error
+	// Deletes the unused tokens of a user for a purpose, so only the link sent
+	// last works.
+	DeleteEmailTokens(ctx context.Context, arg DeleteEmailTokensParams) error
+	DeleteExpiredDirectUploads(ctx context.Context, expiredBefore pgtype.Timestamptz) ([]DirectUpload, error)
+	DeleteExpiredEmailTokens(ctx context.Context) (int64, error)
+	DeleteExpiredMFAChallenges(ctx context.Context) (int64, error)
+	DeleteExpiredUploadSessions(ctx context.Context) ([]UploadSession, error)
 	DeleteFile(ctx context.Context, id uuid.UUID) error
+	DeleteFileVersion(ctx context.Context, arg DeleteFileVersionParams) (uuid.UUID, error)
 	DeleteFolder(ctx context.Context, id uuid.UUID) error
+	DeleteMFAChallenge(ctx context.Context, tokenHash string) error
+	DeleteMFARecoveryCodes(ctx context.Context, userID int64) error
+	// Deletes the sessions that expired or were revoked before the given time.
+	DeleteStaleSessions(ctx context.Context, expiresAt pgtype.Timestamptz) (int64, error)
+	DeleteUploadSession(ctx context.Context, id uuid.UUID) error
+	DeleteUserMFA(ctx context.Context, userID int64) error
+	DisablePublicLink(ctx context.Context, id uuid.UUID) error
+	EmailTokenSentSince(ctx context.Context, arg EmailTokenSentSinceParams) (bool, error)
+	EnablePublicLink(ctx context.Context, arg EnablePublicLinkParams) (File, error)
+	EnableUserMFA(ctx context.Context, userID int64) (int64, error)
+	// Returns the token with the given hash unless it was revoked or has expired.
+	GetActivePersonalAccessToken(ctx context.Context, tokenHash string) (PersonalAccessToken, error)
 	GetAuditLogActivityByDay(ctx context.Context, arg GetAuditLogActivityByDayParams) ([]GetAuditLogActivityByDayRow, error)
 	GetBlobByID(ctx context.Context, id uuid.UUID) (Blob, error)
 	GetBlobBySha(ctx context.Context, sha256 string) (Blob, error)
 	GetBlobIDsInFolderHierarchy(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error)
 	GetDeduplicatedUsage(ctx context.Context, ownerID int64) (int64, error)
+	GetDirectUpload(ctx context.Context, id uuid.UUID) (DirectUpload, error)
+	GetFileByPublicToken(ctx context.Context, publicToken pgtype.UUID) (File, error)
 	GetFileByUUID(ctx context.Context, id uuid.UUID) (File, error)
+	GetFileVersion(ctx context.Context, arg GetFileVersionParams) (FileVersion, error)
 	GetFilesForUser(ctx context.Context, arg GetFilesForUserParams) ([]GetFilesForUserRow, error)
 	GetFilesForUserCount(ctx context.Context, arg GetFilesForUserCountParams) (int64, error)
 	GetFolderByID(ctx context.Context, id uuid.UUID) (Folder, error)
+	// Finds a folder of the owner by its name within a parent folder, or at the
+	// root when parent_folder_id is NULL. The oldest one wins if names repeat.
+	GetFolderByName(ctx context.Context, arg GetFolderByNameParams) (Folder, error)
+	GetInheritedFolderPermissions(ctx context.Context, arg GetInheritedFolderPermissionsParams) ([]string, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetSharePermission(ctx context.Context, arg GetSharePermissionParams) (string, error)
+	GetTrashedFile(ctx context.Context, id uuid.UUID) (File, error)
+	GetTrashedFolder(ctx context.Context, id uuid.UUID) (Folder, error)
+	GetUploadSession(ctx context.Context, id uuid.UUID) (UploadSession, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
+	// Gets a user by email ignoring case, as identity providers may not keep the
+	// case the user signed up with.
+	GetUserByEmailFold(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id int64) (User, error)
+	GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error)
+	GetUserMFA(ctx context.Context, userID int64) (UserMfa, error)
 	IncrementFileDownloadCount(ctx context.Context, id uuid.UUID) error
+	// Lists the sessions of a user that are neither revoked nor expired, most recently used first.
+	ListActiveSessions(ctx context.Context, userID int64) ([]Session, error)
 	ListAllFiles(ctx context.Context, arg ListAllFilesParams) ([]ListAllFilesRow, error)
 	ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error)
+	// Lists the blobs of current files that have no extracted text yet, oldest
+	// first, with the name of one of their files to tell the format by.
+	ListBlobsPendingTextExtraction(ctx context.Context, limit int32) ([]ListBlobsPendingTextExtractionRow, error)
+	// Lists the trashed items of all users that were moved to the trash before the given time.
+	ListExpiredTrash(ctx context.Context, trashedAt pgtype.Timestamptz) ([]ListExpiredTrashRow, error)
+	ListFileVersions(ctx context.Context, fileID uuid.UUID) ([]FileVersion, error)
 	ListFilesByOwner(ctx context.Context, arg ListFilesByOwnerParams) ([]ListFilesByOwnerRow, error)
+	// Lists every folder of a subtree with the names of the folders leading to it,
+	// starting with the subtree's root, along with the files it contains. Folders
+	// without files are returned once with NULL file columns. Trashed folders and
+	// files are skipped.
+	ListFolderArchiveEntries(ctx context.Context, id uuid.UUID) ([]ListFolderArchiveEntriesRow, error)
 	//---------------------------
 	ListFolderContents(ctx context.Context, arg ListFolderContentsParams) ([]ListFolderContentsRow, error)
+	ListFolderShares(ctx context.Context, folderID uuid.UUID) ([]ListFolderSharesRow, error)
+	ListMFAPolicies(ctx context.Context) ([]MfaPolicy, error)
 	ListOtherUsers(ctx context.Context, id int64) ([]ListOtherUsersRow, error)
+	// Lists the tokens of a user that are not revoked, newest first. Expired
+	// tokens are listed too, so their owner can tell why they stopped working.
+	ListPersonalAccessTokens(ctx context.Context, userID int64) ([]PersonalAccessToken, error)
 	ListRootContents(ctx context.Context, arg ListRootContentsParams) ([]ListRootContentsRow, error)
 	ListSelectableFolders(ctx context.Context, arg ListSelectableFoldersParams) ([]ListSelectableFoldersRow, error)
+	// Lists the tags starting with prefix on the files and folders the user can
+	// see, the ones they own and the ones shared with them, most used first.
+	ListTagSuggestions(ctx context.Context, arg ListTagSuggestionsParams) ([]ListTagSuggestionsRow, error)
+	// Lists the items a user moved to the trash. Contents trashed along with a
+	// folder are not listed separately.
+	ListTrash(ctx context.Context, ownerID int64) ([]ListTrashRow, error)
 	ListUsersWithAccessToFile(ctx context.Context, fileID uuid.UUID) ([]ListUsersWithAccessToFileRow, error)
+	// Locks a folder name of the owner within a parent folder, or at the root when
+	// parent_folder_id is NULL, until the transaction ends.
+	LockFolderName(ctx context.Context, arg LockFolderNameParams) error
+	PruneFileVersions(ctx context.Context, arg PruneFileVersionsParams) ([]uuid.UUID, error)
+	ReleaseUploadSession(ctx context.Context, id uuid.UUID) error
+	RemoveItemMetadata(ctx context.Context, arg RemoveItemMetadataParams) error
+	RemoveItemTags(ctx context.Context, arg RemoveItemTagsParams) error
+	ReplaceFolderShares(ctx context.Context, arg ReplaceFolderSharesParams) error
+	// Forgets the codes counted since the last accepted one.
+	ResetMFAAttempts(ctx context.Context, userID int64) error
+	// Takes a file out of the trash. It goes back to its folder, or to the root
+	// if that folder has been trashed in the meantime.
+	RestoreFile(ctx context.Context, id uuid.UUID) (File, error)
+	// Takes a folder and the contents trashed with it out of the trash. The folder
+	// goes back to its parent, or to the root if the parent is in the trash.
+	RestoreFolder(ctx context.Context, id uuid.UUID) error
+	RevokeAllPersonalAccessTokens(ctx context.Context, userID int64) (int64, error)
+	RevokeAllSessions(ctx context.Context, userID int64) (int64, error)
+	RevokePersonalAccessToken(ctx context.Context, arg RevokePersonalAccessTokenParams) (int64, error)
+	RevokeSession(ctx context.Context, arg RevokeSessionParams) (int64, error)
+	RoleRequiresMFA(ctx context.Context, role string) (bool, error)
+	RotatePublicToken(ctx context.Context, arg RotatePublicTokenParams) (File, error)
+	// Replaces the refresh token of a session, keeping the one it replaces for the
+	// grace period. Updates nothing if refresh_token_hash is no longer current,
+	// because a concurrent refresh rotated it first.
+	RotateSessionRefreshToken(ctx context.Context, arg RotateSessionRefreshTokenParams) (int64, error)
+	SaveBlobText(ctx context.Context, arg SaveBlobTextParams) error
+	// Searches by name across every file and folder the user can see: the ones
+	// they own and the ones shared with them, directly or through a shared folder.
+	// Each result carries the IDs and names of the folders leading to it as the
+	// user sees them, from their root for owned items and from the topmost shared
+	// folder for shared ones. Names equal to, starting with or containing the
+	// search term rank highest; with fuzzy set, names that are only similar to it
+	// by pg_trgm word similarity are included too, ranked by that similarity.
+	SearchContents(ctx context.Context, arg SearchContentsParams) ([]SearchContentsRow, error)
+	// Searches the extracted text of every file the user can see, with the same
+	// access rules and paths as SearchContents. The query uses the web search
+	// syntax: quoted phrases, OR and -excluded words. Each result carries a
+	// snippet of its text with the matching words between <mark> and </mark>;
+	// snippets are only built for the page of results returned.
+	SearchFileContents(ctx context.Context, arg SearchFileContentsParams) ([]SearchFileContentsRow, error)
+	// Sets every key to its value on every file and folder, replacing the values
+	// they already have for those keys.
+	SetItemMetadata(ctx context.Context, arg SetItemMetadataParams) error
+	SetMFAPolicy(ctx context.Context, arg SetMFAPolicyParams) (MfaPolicy, error)
+	// Stores a new secret for a user, replacing the one of an enrollment they did
+	// not confirm. Returns no row if they already enabled two-factor authentication.
+	StartMFAEnrollment(ctx context.Context, arg StartMFAEnrollmentParams) (UserMfa, error)
+	// Records that a token was used, at most once a minute to spare writes.
+	TouchPersonalAccessToken(ctx context.Context, id uuid.UUID) error
+	// Moves a file to the trash. The file keeps its folder so it can be restored there.
+	TrashFile(ctx context.Context, id uuid.UUID) error
+	// Moves a folder and everything below it to the trash. The descendants are
+	// marked with the folder's ID so they are restored and purged together with it.
+	// Descendants that were already in the trash keep their own entry.
+	TrashFolder(ctx context.Context, id uuid.UUID) error
+	UpdateFileBlob(ctx context.Context, arg UpdateFileBlobParams) (File, error)
 	UpdateFileFolder(ctx context.Context, arg UpdateFileFolderParams) error
 	UpdateFilename(ctx context.Context, arg UpdateFilenameParams) (File, error)
 	UpdateFolder(ctx context.Context, arg UpdateFolderParams) (UpdateFolderRow, error)
 	UpdateFolderParentFolder(ctx context.Context, arg UpdateFolderParentFolderParams) error
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) error
+	// Marks a token that is neither used nor expired as used, and returns it.
+	// Returns no row otherwise, so each token is used once.
+	UseEmailToken(ctx context.Context, arg UseEmailTokenParams) (EmailToken, error)
+	UseMFARecoveryCode(ctx context.Context, arg UseMFARecoveryCodeParams) (int64, error)
+	// Records the time step of an accepted code. Updates no row if a code of the
+	// same or a later step was already accepted, so each code is used once.
+	UseMFAStep(ctx context.Context, arg UseMFAStepParams) (int64, error)
 	UserHasAccess(ctx context.Context, arg UserHasAccessParams) (bool, error)
+	UserHasMFA(ctx context.Context, userID int64) (bool, error)
+	UserNameExists(ctx context.Context, name string) (bool, error)
 	UserOwnsBlob(ctx context.Context, arg UserOwnsBlobParams) (int32, error)
+	// Reports whether the owner shares a file or folder with the other user.
+	UserSharesWith(ctx context.Context, arg UserSharesWithParams) (bool, error)
+	// Marks the email address of a user as verified. Updates no row if it
+	// already was.
+	VerifyUserEmail(ctx context.Context, id int64) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
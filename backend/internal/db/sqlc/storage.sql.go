@@ -16,6 +16,7 @@ import (
 type AddSharesToFileParams struct {
 	FileID     uuid.UUID `json:"file_id"`
 	SharedWith int64     `json:"shared_with"`
+	Permission string    `json:"permission"`
 }
 
 const createBlob = `-- name: CreateBlob :one
@@ -56,7 +57,7 @@ func (q *Queries) CreateBlob(ctx context.Context, arg CreateBlobParams) (Blob, e
 const createFile = `-- name: CreateFile :one
 INSERT INTO files (owner_id, blob_id, filename, declared_mime, size, folder_id)
 VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, owner_id, blob_id, filename, declared_mime, size, uploaded_at, is_public, public_token, download_count, folder_id
+RETURNING id, owner_id, blob_id, filename, declared_mime, size, uploaded_at, is_public, public_token, download_count, folder_id, public_expires_at, public_max_downloads, public_download_count, public_password_hash, current_version, trashed_at, trashed_with
 `
 
 type CreateFileParams struct {
@@ -90,6 +91,13 @@ func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, e
 		&i.PublicToken,
 		&i.DownloadCount,
 		&i.FolderID,
+		&i.PublicExpiresAt,
+		&i.PublicMaxDownloads,
+		&i.PublicDownloadCount,
+		&i.PublicPasswordHash,
+		&i.CurrentVersion,
+		&i.TrashedAt,
+		&i.TrashedWith,
 	)
 	return i, err
 }
@@ -117,14 +125,19 @@ func (q *Queries) DeleteBlob(ctx context.Context, id uuid.UUID) error {
 const deleteBlobIfUnused = `-- name: DeleteBlobIfUnused :one
 DELETE FROM blobs 
 WHERE id = $1 AND refcount <= 0
-RETURNING storage_path
+RETURNING storage_path, sha256
 `
 
-func (q *Queries) DeleteBlobIfUnused(ctx context.Context, id uuid.UUID) (string, error) {
+type DeleteBlobIfUnusedRow struct {
+	StoragePath string `json:"storage_path"`
+	Sha256      string `json:"sha256"`
+}
+
+func (q *Queries) DeleteBlobIfUnused(ctx context.Context, id uuid.UUID) (DeleteBlobIfUnusedRow, error) {
 	row := q.db.QueryRow(ctx, deleteBlobIfUnused, id)
-	var storage_path string
-	err := row.Scan(&storage_path)
-	return storage_path, err
+	var i DeleteBlobIfUnusedRow
+	err := row.Scan(&i.StoragePath, &i.Sha256)
+	return i, err
 }
 
 const deleteBlobsByStoragePaths = `-- name: DeleteBlobsByStoragePaths :exec
@@ -193,9 +206,14 @@ WITH RECURSIVE folder_hierarchy AS (
     SELECT f.id FROM folders f
     INNER JOIN folder_hierarchy fh ON f.parent_folder_id = fh.id
 )
-SELECT DISTINCT f.blob_id
+SELECT f.blob_id
 FROM files f
 WHERE f.folder_id IN (SELECT id FROM folder_hierarchy)
+UNION
+SELECT v.blob_id
+FROM file_versions v
+JOIN files f ON f.id = v.file_id
+WHERE f.folder_id IN (SELECT id FROM folder_hierarchy)
 `
 
 func (q *Queries) GetBlobIDsInFolderHierarchy(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
@@ -219,9 +237,9 @@ func (q *Queries) GetBlobIDsInFolderHierarchy(ctx context.Context, id uuid.UUID)
 }
 
 const getFileByUUID = `-- name: GetFileByUUID :one
-SELECT id, owner_id, blob_id, filename, declared_mime, size, uploaded_at, is_public, public_token, download_count, folder_id
+SELECT id, owner_id, blob_id, filename, declared_mime, size, uploaded_at, is_public, public_token, download_count, folder_id, public_expires_at, public_max_downloads, public_download_count, public_password_hash, current_version, trashed_at, trashed_with
 FROM files f
-WHERE f.id = $1
+WHERE f.id = $1 AND f.trashed_at IS NULL
 `
 
 func (q *Queries) GetFileByUUID(ctx context.Context, id uuid.UUID) (File, error) {
@@ -239,6 +257,13 @@ func (q *Queries) GetFileByUUID(ctx context.Context, id uuid.UUID) (File, error)
 		&i.PublicToken,
 		&i.DownloadCount,
 		&i.FolderID,
+		&i.PublicExpiresAt,
+		&i.PublicMaxDownloads,
+		&i.PublicDownloadCount,
+		&i.PublicPasswordHash,
+		&i.CurrentVersion,
+		&i.TrashedAt,
+		&i.TrashedWith,
 	)
 	return i, err
 }
@@ -322,6 +347,23 @@ func (q *Queries) GetFilesForUserCount(ctx context.Context, arg GetFilesForUserC
 	return count, err
 }
 
+const getSharePermission = `-- name: GetSharePermission :one
+SELECT permission FROM file_shares
+WHERE file_id = $1 AND shared_with = $2
+`
+
+type GetSharePermissionParams struct {
+	FileID     uuid.UUID `json:"file_id"`
+	SharedWith int64     `json:"shared_with"`
+}
+
+func (q *Queries) GetSharePermission(ctx context.Context, arg GetSharePermissionParams) (string, error) {
+	row := q.db.QueryRow(ctx, getSharePermission, arg.FileID, arg.SharedWith)
+	var permission string
+	err := row.Scan(&permission)
+	return permission, err
+}
+
 const incrementFileDownloadCount = `-- name: IncrementFileDownloadCount :exec
 UPDATE files
 SET download_count = download_count + 1
@@ -492,6 +534,64 @@ func (q *Queries) ListFilesByOwner(ctx context.Context, arg ListFilesByOwnerPara
 	return items, nil
 }
 
+const listFolderArchiveEntries = `-- name: ListFolderArchiveEntries :many
+WITH RECURSIVE folder_hierarchy AS (
+    SELECT fo.id, ARRAY[fo.name]::TEXT[] AS path
+    FROM folders fo
+    WHERE fo.id = $1 AND fo.trashed_at IS NULL
+    UNION ALL
+    SELECT f.id, fh.path || f.name
+    FROM folders f
+    INNER JOIN folder_hierarchy fh ON f.parent_folder_id = fh.id
+    WHERE f.trashed_at IS NULL
+)
+SELECT fh.path::TEXT[] AS folder_path, f.id AS file_id, f.filename, f.size, f.uploaded_at, b.storage_path
+FROM folder_hierarchy fh
+LEFT JOIN files f ON f.folder_id = fh.id AND f.trashed_at IS NULL
+LEFT JOIN blobs b ON b.id = f.blob_id
+ORDER BY fh.path, f.filename, f.uploaded_at, f.id
+`
+
+type ListFolderArchiveEntriesRow struct {
+	FolderPath  []string           `json:"folder_path"`
+	FileID      pgtype.UUID        `json:"file_id"`
+	Filename    pgtype.Text        `json:"filename"`
+	Size        sql.NullInt64      `json:"size"`
+	UploadedAt  pgtype.Timestamptz `json:"uploaded_at"`
+	StoragePath pgtype.Text        `json:"storage_path"`
+}
+
+// Lists every folder of a subtree with the names of the folders leading to it,
+// starting with the subtree's root, along with the files it contains. Folders
+// without files are returned once with NULL file columns. Trashed folders and
+// files are skipped.
+func (q *Queries) ListFolderArchiveEntries(ctx context.Context, id uuid.UUID) ([]ListFolderArchiveEntriesRow, error) {
+	rows, err := q.db.Query(ctx, listFolderArchiveEntries, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFolderArchiveEntriesRow{}
+	for rows.Next() {
+		var i ListFolderArchiveEntriesRow
+		if err := rows.Scan(
+			&i.FolderPath,
+			&i.FileID,
+			&i.Filename,
+			&i.Size,
+			&i.UploadedAt,
+			&i.StoragePath,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listFolderContents = `-- name: ListFolderContents :many
 
 WITH folder_contents AS (
@@ -502,15 +602,15 @@ WITH folder_contents AS (
         NULL::bigint AS size,
         NULL::text AS content_type,
         f.created_at AS uploaded_at,
-        (f.owner_id = $5) AS user_owns_file,
+        (f.owner_id = $9) AS user_owns_file,
         NULL::bigint AS download_count,
         NULL::uuid AS folder_id
     FROM folders f
     WHERE 
-        f.owner_id = $5
-        AND f.parent_folder_id = $6::UUID
-        AND ($7::TEXT = '' OR f.name ILIKE '%' || $7::TEXT || '%')
-        AND ($8::TEXT = 'folder/folder' OR $8::TEXT = '')
+        f.parent_folder_id = $10::UUID
+        AND f.trashed_at IS NULL
+        AND ($11::TEXT = '' OR f.name ILIKE '%' || $11::TEXT || '%')
+        AND ($12::TEXT = 'folder/folder' OR $12::TEXT = '')
 
     UNION ALL
 
@@ -521,36 +621,51 @@ WITH folder_contents AS (
         f.size,
         f.declared_mime AS content_type,
         f.uploaded_at,
-        (f.owner_id = $5) AS user_owns_file,
+        (f.owner_id = $9) AS user_owns_file,
         f.download_count,
         f.folder_id
     FROM files f
     WHERE
-        f.owner_id = $5
-        AND f.folder_id = $6::UUID
-        AND ($7::TEXT = '' OR f.filename ILIKE '%' || $7::TEXT || '%')
-        AND ($8::TEXT = '' OR f.declared_mime = $8::TEXT)
-        AND ($9::TIMESTAMPTZ IS NULL OR f.uploaded_at > $9::TIMESTAMPTZ)
-        AND ($10::TIMESTAMPTZ IS NULL OR f.uploaded_at < $10::TIMESTAMPTZ)
-        AND ($11::BIGINT IS NULL OR f.size >= $11::BIGINT)
-        AND ($12::BIGINT IS NULL OR f.size <= $12::BIGINT)
+        f.folder_id = $10::UUID
+        AND f.trashed_at IS NULL
+        AND ($11::TEXT = '' OR f.filename ILIKE '%' || $11::TEXT || '%')
+        AND ($12::TEXT = '' OR f.declared_mime = $12::TEXT)
+        AND ($13::TIMESTAMPTZ IS NULL OR f.uploaded_at > $13::TIMESTAMPTZ)
+        AND ($14::TIMESTAMPTZ IS NULL OR f.uploaded_at < $14::TIMESTAMPTZ)
+        AND ($15::BIGINT IS NULL OR f.size >= $15::BIGINT)
+        AND ($16::BIGINT IS NULL OR f.size <= $16::BIGINT)
 ) 
-SELECT id, filename, item_type, size, content_type, uploaded_at, user_owns_file, download_count, folder_id, COUNT(*) OVER() AS total_count 
-FROM folder_contents
+SELECT
+    c.id, c.filename, c.item_type, c.size, c.content_type, c.uploaded_at, c.user_owns_file, c.download_count, c.folder_id,
+    ARRAY(SELECT it.tag FROM item_tags it WHERE it.item_id = c.id ORDER BY it.tag)::TEXT[] AS tags,
+    COALESCE((SELECT jsonb_object_agg(im.key, im.value) FROM item_metadata im WHERE im.item_id = c.id), '{}')::JSONB AS metadata,
+    COUNT(*) OVER() AS total_count
+FROM folder_contents c
+-- every tag filtered by and every metadata predicate must hold
+WHERE ($3::TEXT[] IS NULL OR $3::TEXT[] <@ ARRAY(SELECT it.tag FROM item_tags it WHERE it.item_id = c.id))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest($4::TEXT[], $5::TEXT[], $6::TEXT[]) AS p(key, op, value)
+    WHERE NOT item_metadata_matches(c.id, p.key, p.op, p.value)
+  )
 ORDER BY 
     item_type DESC,
-    CASE WHEN $3::text = 'filename' AND $4::text = 'asc' THEN filename END ASC,
-    CASE WHEN $3::text = 'filename' AND $4::text = 'desc' THEN filename END DESC,
-    CASE WHEN $3::text = 'size' AND $4::text = 'asc' THEN size END ASC NULLS FIRST,
-    CASE WHEN $3::text = 'size' AND $4::text = 'desc' THEN size END DESC NULLS LAST,
-    CASE WHEN $3::text = 'uploaded_at' AND $4::text = 'asc' THEN uploaded_at END ASC,
-    CASE WHEN $3::text = 'uploaded_at' AND $4::text = 'desc' THEN uploaded_at END DESC
+    CASE WHEN $7::text = 'filename' AND $8::text = 'asc' THEN filename END ASC,
+    CASE WHEN $7::text = 'filename' AND $8::text = 'desc' THEN filename END DESC,
+    CASE WHEN $7::text = 'size' AND $8::text = 'asc' THEN size END ASC NULLS FIRST,
+    CASE WHEN $7::text = 'size' AND $8::text = 'desc' THEN size END DESC NULLS LAST,
+    CASE WHEN $7::text = 'uploaded_at' AND $8::text = 'asc' THEN uploaded_at END ASC,
+    CASE WHEN $7::text = 'uploaded_at' AND $8::text = 'desc' THEN uploaded_at END DESC
 LIMIT $1 OFFSET $2
 `
 
 type ListFolderContentsParams struct {
 	Limit          int32              `json:"limit"`
 	Offset         int32              `json:"offset"`
+	Tags           []string           `json:"tags"`
+	MetadataKeys   []string           `json:"metadata_keys"`
+	MetadataOps    []string           `json:"metadata_ops"`
+	MetadataValues []string           `json:"metadata_values"`
 	SortBy         string             `json:"sort_by"`
 	SortOrder      string             `json:"sort_order"`
 	UserID         int64              `json:"user_id"`
@@ -573,6 +688,8 @@ type ListFolderContentsRow struct {
 	UserOwnsFile  bool               `json:"user_owns_file"`
 	DownloadCount sql.NullInt64      `json:"download_count"`
 	FolderID      pgtype.UUID        `json:"folder_id"`
+	Tags          []string           `json:"tags"`
+	Metadata      []byte             `json:"metadata"`
 	TotalCount    int64              `json:"total_count"`
 }
 
@@ -581,6 +698,10 @@ func (q *Queries) ListFolderContents(ctx context.Context, arg ListFolderContents
 	rows, err := q.db.Query(ctx, listFolderContents,
 		arg.Limit,
 		arg.Offset,
+		arg.Tags,
+		arg.MetadataKeys,
+		arg.MetadataOps,
+		arg.MetadataValues,
 		arg.SortBy,
 		arg.SortOrder,
 		arg.UserID,
@@ -609,6 +730,8 @@ func (q *Queries) ListFolderContents(ctx context.Context, arg ListFolderContents
 			&i.UserOwnsFile,
 			&i.DownloadCount,
 			&i.FolderID,
+			&i.Tags,
+			&i.Metadata,
 			&i.TotalCount,
 		); err != nil {
 			return nil, err
@@ -626,65 +749,94 @@ WITH root_contents AS (
     SELECT
         f.id, f.name AS filename, 'folder' AS item_type, NULL::bigint AS size,
         NULL::text AS content_type, f.created_at AS uploaded_at,
-        (f.owner_id = $5) AS user_owns_file,
+        (f.owner_id = $9) AS user_owns_file,
         NULL::bigint AS download_count, NULL::uuid AS folder_id
     FROM folders f
-    WHERE f.owner_id = $5 AND f.parent_folder_id IS NULL
-      AND ($6::TEXT = '' OR f.name ILIKE '%' || $6::TEXT || '%')
-      AND ($7::TEXT = 'folder/folder' OR $7::TEXT = '')
+    WHERE f.owner_id = $9 AND f.parent_folder_id IS NULL AND f.trashed_at IS NULL
+      AND ($10::TEXT = '' OR f.name ILIKE '%' || $10::TEXT || '%')
+      AND ($11::TEXT = 'folder/folder' OR $11::TEXT = '')
 
     UNION ALL
 
     SELECT
         f.id, f.filename, 'file' AS item_type, f.size, f.declared_mime AS content_type,
-        f.uploaded_at, (f.owner_id = $5) AS user_owns_file,
+        f.uploaded_at, (f.owner_id = $9) AS user_owns_file,
         f.download_count, f.folder_id
     FROM files f
-    WHERE f.owner_id = $5 AND f.folder_id IS NULL
-        AND ($6::TEXT = '' OR f.filename ILIKE '%' || $6::TEXT || '%')
-        AND ($7::TEXT = '' OR f.declared_mime = $7::TEXT)
-        AND ($8::TIMESTAMPTZ IS NULL OR f.uploaded_at > $8::TIMESTAMPTZ)
-        AND ($9::TIMESTAMPTZ IS NULL OR f.uploaded_at < $9::TIMESTAMPTZ)
-        AND ($10::BIGINT IS NULL OR f.size >= $10::BIGINT)
-        AND ($11::BIGINT IS NULL OR f.size <= $11::BIGINT)
+    WHERE f.owner_id = $9 AND f.folder_id IS NULL AND f.trashed_at IS NULL
+        AND ($10::TEXT = '' OR f.filename ILIKE '%' || $10::TEXT || '%')
+        AND ($11::TEXT = '' OR f.declared_mime = $11::TEXT)
+        AND ($12::TIMESTAMPTZ IS NULL OR f.uploaded_at > $12::TIMESTAMPTZ)
+        AND ($13::TIMESTAMPTZ IS NULL OR f.uploaded_at < $13::TIMESTAMPTZ)
+        AND ($14::BIGINT IS NULL OR f.size >= $14::BIGINT)
+        AND ($15::BIGINT IS NULL OR f.size <= $15::BIGINT)
         AND (
-            $12::int = 0
-            OR ($12::int = 1 AND f.owner_id = $5)
-            OR ($12::int = 2 AND f.owner_id <> $5)
+            $16::int = 0
+            OR ($16::int = 1 AND f.owner_id = $9)
+            OR ($16::int = 2 AND f.owner_id <> $9)
           )
 
     UNION ALL
 
     SELECT
         f.id, f.filename, 'file' AS item_type, f.size, f.declared_mime AS content_type,
-        f.uploaded_at, (f.owner_id = $5) AS user_owns_file,
+        f.uploaded_at, (f.owner_id = $9) AS user_owns_file,
         f.download_count, NULL::uuid as folder_id
     FROM files f
     JOIN file_shares fs ON f.id = fs.file_id
-    WHERE fs.shared_with = $5
-      AND ($6::TEXT = '' OR f.filename ILIKE '%' || $6::TEXT || '%')
-      AND ($7::TEXT = '' OR f.declared_mime = $7::TEXT)
-      AND ($8::TIMESTAMPTZ IS NULL OR f.uploaded_at > $8::TIMESTAMPTZ)
-      AND ($9::TIMESTAMPTZ IS NULL OR f.uploaded_at < $9::TIMESTAMPTZ)
-      AND ($10::BIGINT IS NULL OR f.size >= $10::BIGINT)
-      AND ($11::BIGINT IS NULL OR f.size <= $11::BIGINT)
+    WHERE fs.shared_with = $9 AND f.trashed_at IS NULL
+      AND ($10::TEXT = '' OR f.filename ILIKE '%' || $10::TEXT || '%')
+      AND ($11::TEXT = '' OR f.declared_mime = $11::TEXT)
+      AND ($12::TIMESTAMPTZ IS NULL OR f.uploaded_at > $12::TIMESTAMPTZ)
+      AND ($13::TIMESTAMPTZ IS NULL OR f.uploaded_at < $13::TIMESTAMPTZ)
+      AND ($14::BIGINT IS NULL OR f.size >= $14::BIGINT)
+      AND ($15::BIGINT IS NULL OR f.size <= $15::BIGINT)
+
+    UNION ALL
+
+    SELECT
+        f.id, f.name AS filename, 'folder' AS item_type, NULL::bigint AS size,
+        NULL::text AS content_type, f.created_at AS uploaded_at,
+        (f.owner_id = $9) AS user_owns_file,
+        NULL::bigint AS download_count, NULL::uuid AS folder_id
+    FROM folders f
+    JOIN folder_shares fs ON f.id = fs.folder_id
+    WHERE fs.shared_with = $9 AND f.trashed_at IS NULL
+      AND ($10::TEXT = '' OR f.name ILIKE '%' || $10::TEXT || '%')
+      AND ($11::TEXT = 'folder/folder' OR $11::TEXT = '')
+      AND $16::int <> 1
 ) 
-SELECT id, filename, item_type, size, content_type, uploaded_at, user_owns_file, download_count, folder_id, COUNT(*) OVER() AS total_count 
-FROM root_contents
+SELECT
+    c.id, c.filename, c.item_type, c.size, c.content_type, c.uploaded_at, c.user_owns_file, c.download_count, c.folder_id,
+    ARRAY(SELECT it.tag FROM item_tags it WHERE it.item_id = c.id ORDER BY it.tag)::TEXT[] AS tags,
+    COALESCE((SELECT jsonb_object_agg(im.key, im.value) FROM item_metadata im WHERE im.item_id = c.id), '{}')::JSONB AS metadata,
+    COUNT(*) OVER() AS total_count
+FROM root_contents c
+-- every tag filtered by and every metadata predicate must hold
+WHERE ($3::TEXT[] IS NULL OR $3::TEXT[] <@ ARRAY(SELECT it.tag FROM item_tags it WHERE it.item_id = c.id))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest($4::TEXT[], $5::TEXT[], $6::TEXT[]) AS p(key, op, value)
+    WHERE NOT item_metadata_matches(c.id, p.key, p.op, p.value)
+  )
 ORDER BY
     item_type DESC,
-    CASE WHEN $3::text = 'filename' AND $4::text = 'asc' THEN filename END ASC,
-    CASE WHEN $3::text = 'filename' AND $4::text = 'desc' THEN filename END DESC,
-    CASE WHEN $3::text = 'size' AND $4::text = 'asc' THEN size END ASC NULLS FIRST,
-    CASE WHEN $3::text = 'size' AND $4::text = 'desc' THEN size END DESC NULLS LAST,
-    CASE WHEN $3::text = 'uploaded_at' AND $4::text = 'asc' THEN uploaded_at END ASC,
-    CASE WHEN $3::text = 'uploaded_at' AND $4::text = 'desc' THEN uploaded_at END DESC
+    CASE WHEN $7::text = 'filename' AND $8::text = 'asc' THEN filename END ASC,
+    CASE WHEN $7::text = 'filename' AND $8::text = 'desc' THEN filename END DESC,
+    CASE WHEN $7::text = 'size' AND $8::text = 'asc' THEN size END ASC NULLS FIRST,
+    CASE WHEN $7::text = 'size' AND $8::text = 'desc' THEN size END DESC NULLS LAST,
+    CASE WHEN $7::text = 'uploaded_at' AND $8::text = 'asc' THEN uploaded_at END ASC,
+    CASE WHEN $7::text = 'uploaded_at' AND $8::text = 'desc' THEN uploaded_at END DESC
 LIMIT $1 OFFSET $2
 `
 
 type ListRootContentsParams struct {
 	Limit           int32              `json:"limit"`
 	Offset          int32              `json:"offset"`
+	Tags            []string           `json:"tags"`
+	MetadataKeys    []string           `json:"metadata_keys"`
+	MetadataOps     []string           `json:"metadata_ops"`
+	MetadataValues  []string           `json:"metadata_values"`
 	SortBy          string             `json:"sort_by"`
 	SortOrder       string             `json:"sort_order"`
 	UserID          int64              `json:"user_id"`
@@ -707,6 +859,8 @@ type ListRootContentsRow struct {
 	UserOwnsFile  bool               `json:"user_owns_file"`
 	DownloadCount sql.NullInt64      `json:"download_count"`
 	FolderID      pgtype.UUID        `json:"folder_id"`
+	Tags          []string           `json:"tags"`
+	Metadata      []byte             `json:"metadata"`
 	TotalCount    int64              `json:"total_count"`
 }
 
@@ -714,6 +868,10 @@ func (q *Queries) ListRootContents(ctx context.Context, arg ListRootContentsPara
 	rows, err := q.db.Query(ctx, listRootContents,
 		arg.Limit,
 		arg.Offset,
+		arg.Tags,
+		arg.MetadataKeys,
+		arg.MetadataOps,
+		arg.MetadataValues,
 		arg.SortBy,
 		arg.SortOrder,
 		arg.UserID,
@@ -742,6 +900,8 @@ func (q *Queries) ListRootContents(ctx context.Context, arg ListRootContentsPara
 			&i.UserOwnsFile,
 			&i.DownloadCount,
 			&i.FolderID,
+			&i.Tags,
+			&i.Metadata,
 			&i.TotalCount,
 		); err != nil {
 			return nil, err
@@ -793,6 +953,228 @@ func (q *Queries) ListUsersWithAccessToFile(ctx context.Context, fileID uuid.UUI
 	return items, nil
 }
 
+const searchContents = `-- name: SearchContents :many
+WITH RECURSIVE visible_folders AS (
+    SELECT fo.id, fo.name, fo.owner_id, fo.created_at,
+        ARRAY[fo.id]::UUID[] AS path_ids, ARRAY[fo.name]::TEXT[] AS path_names
+    FROM folders fo
+    WHERE fo.trashed_at IS NULL
+      AND (
+        (fo.owner_id = $3 AND fo.parent_folder_id IS NULL)
+        OR EXISTS (SELECT 1 FROM folder_shares fs WHERE fs.folder_id = fo.id AND fs.shared_with = $3)
+      )
+
+    UNION ALL
+
+    SELECT fo.id, fo.name, fo.owner_id, fo.created_at, vf.path_ids || fo.id, vf.path_names || fo.name
+    FROM folders fo
+    JOIN visible_folders vf ON fo.parent_folder_id = vf.id
+    WHERE fo.trashed_at IS NULL
+),
+-- a folder shared within another shared folder is reached twice, the shorter path wins
+folder_paths AS (
+    SELECT DISTINCT ON (id) id, name, owner_id, created_at, path_ids, path_names
+    FROM visible_folders
+    ORDER BY id, cardinality(path_ids)
+),
+candidates AS (
+    SELECT
+        fp.id, fp.name AS filename, 'folder' AS item_type, NULL::BIGINT AS size,
+        NULL::TEXT AS content_type, fp.created_at AS uploaded_at,
+        (fp.owner_id = $3) AS user_owns_file, NULL::BIGINT AS download_count,
+        fp.path_ids[1:cardinality(fp.path_ids) - 1] AS path_ids,
+        fp.path_names[1:cardinality(fp.path_names) - 1] AS path_names
+    FROM folder_paths fp
+    WHERE ($4::TEXT = 'folder/folder' OR $4::TEXT = '')
+
+    UNION ALL
+
+    -- files inside the visible folders
+    SELECT
+        f.id, f.filename, 'file' AS item_type, f.size, f.declared_mime AS content_type,
+        f.uploaded_at, (f.owner_id = $3) AS user_owns_file, f.download_count,
+        fp.path_ids, fp.path_names
+    FROM files f
+    JOIN folder_paths fp ON f.folder_id = fp.id
+    WHERE f.trashed_at IS NULL
+
+    UNION ALL
+
+    -- files at the user's root and files shared with them outside the visible folders
+    SELECT
+        f.id, f.filename, 'file' AS item_type, f.size, f.declared_mime AS content_type,
+        f.uploaded_at, (f.owner_id = $3) AS user_owns_file, f.download_count,
+        ARRAY[]::UUID[] AS path_ids, ARRAY[]::TEXT[] AS path_names
+    FROM files f
+    WHERE f.trashed_at IS NULL
+      AND (
+        (f.owner_id = $3 AND f.folder_id IS NULL)
+        OR (
+            EXISTS (SELECT 1 FROM file_shares fs WHERE fs.file_id = f.id AND fs.shared_with = $3)
+            AND NOT EXISTS (SELECT 1 FROM folder_paths fp WHERE fp.id = f.folder_id)
+        )
+      )
+),
+matches AS (
+    SELECT c.*,
+        CASE WHEN $5::TEXT = '' THEN 0 ELSE
+            (lower(c.filename) = lower($5::TEXT))::INT
+            + (c.filename ILIKE $5::TEXT || '%')::INT
+            + (c.filename ILIKE '%' || $5::TEXT || '%')::INT
+            + word_similarity($5::TEXT, c.filename)
+        END::REAL AS rank
+    FROM candidates c
+    WHERE (
+        $5::TEXT = ''
+        OR c.filename ILIKE '%' || $5::TEXT || '%'
+        OR ($6::BOOLEAN AND $5::TEXT <% c.filename)
+      )
+      AND (c.item_type = 'folder' OR $4::TEXT = '' OR c.content_type = $4::TEXT)
+      AND ($7::TIMESTAMPTZ IS NULL OR c.item_type = 'folder' OR c.uploaded_at > $7::TIMESTAMPTZ)
+      AND ($8::TIMESTAMPTZ IS NULL OR c.item_type = 'folder' OR c.uploaded_at < $8::TIMESTAMPTZ)
+      AND ($9::BIGINT IS NULL OR c.item_type = 'folder' OR c.size >= $9::BIGINT)
+      AND ($10::BIGINT IS NULL OR c.item_type = 'folder' OR c.size <= $10::BIGINT)
+      AND (
+        $11::int = 0
+        OR ($11::int = 1 AND c.user_owns_file)
+        OR ($11::int = 2 AND NOT c.user_owns_file)
+      )
+)
+SELECT
+    id, filename, item_type, size, content_type, uploaded_at, user_owns_file, download_count,
+    path_ids::UUID[] AS path_ids, path_names::TEXT[] AS path_names, rank, COUNT(*) OVER() AS total_count
+FROM matches
+ORDER BY rank DESC, item_type DESC, filename, id
+LIMIT $1 OFFSET $2
+`
+
+type SearchContentsParams struct {
+	Limit           int32              `json:"limit"`
+	Offset          int32              `json:"offset"`
+	UserID          int64              `json:"user_id"`
+	MimeType        string             `json:"mime_type"`
+	Search          string             `json:"search"`
+	Fuzzy           bool               `json:"fuzzy"`
+	UploadedAfter   pgtype.Timestamptz `json:"uploaded_after"`
+	UploadedBefore  pgtype.Timestamptz `json:"uploaded_before"`
+	MinSize         sql.NullInt64      `json:"min_size"`
+	MaxSize         sql.NullInt64      `json:"max_size"`
+	OwnershipStatus int32              `json:"ownership_status"`
+}
+
+type SearchContentsRow struct {
+	ID            uuid.UUID          `json:"id"`
+	Filename      string             `json:"filename"`
+	ItemType      string             `json:"item_type"`
+	Size          sql.NullInt64      `json:"size"`
+	ContentType   pgtype.Text        `json:"content_type"`
+	UploadedAt    pgtype.Timestamptz `json:"uploaded_at"`
+	UserOwnsFile  bool               `json:"user_owns_file"`
+	DownloadCount sql.NullInt64      `json:"download_count"`
+	PathIds       []uuid.UUID        `json:"path_ids"`
+	PathNames     []string           `json:"path_names"`
+	Rank          float32            `json:"rank"`
+	TotalCount    int64              `json:"total_count"`
+}
+
+// Searches by name across every file and folder the user can see: the ones
+// they own and the ones shared with them, directly or through a shared folder.
+// Each result carries the IDs and names of the folders leading to it as the
+// user sees them, from their root for owned items and from the topmost shared
+// folder for shared ones. Names equal to, starting with or containing the
+// search term rank highest; with fuzzy set, names that are only similar to it
+// by pg_trgm word similarity are included too, ranked by that similarity.
+func (q *Queries) SearchContents(ctx context.Context, arg SearchContentsParams) ([]SearchContentsRow, error) {
+	rows, err := q.db.Query(ctx, searchContents,
+		arg.Limit,
+		arg.Offset,
+		arg.UserID,
+		arg.MimeType,
+		arg.Search,
+		arg.Fuzzy,
+		arg.UploadedAfter,
+		arg.UploadedBefore,
+		arg.MinSize,
+		arg.MaxSize,
+		arg.OwnershipStatus,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchContentsRow{}
+	for rows.Next() {
+		var i SearchContentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Filename,
+			&i.ItemType,
+			&i.Size,
+			&i.ContentType,
+			&i.UploadedAt,
+			&i.UserOwnsFile,
+			&i.DownloadCount,
+			&i.PathIds,
+			&i.PathNames,
+			&i.Rank,
+			&i.TotalCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateFileBlob = `-- name: UpdateFileBlob :one
+UPDATE files
+SET blob_id = $1, size = $2, declared_mime = $3,
+    uploaded_at = now(), current_version = current_version + 1
+WHERE id = $4
+RETURNING id, owner_id, blob_id, filename, declared_mime, size, uploaded_at, is_public, public_token, download_count, folder_id, public_expires_at, public_max_downloads, public_download_count, public_password_hash, current_version, trashed_at, trashed_with
+`
+
+type UpdateFileBlobParams struct {
+	BlobID       uuid.UUID   `json:"blob_id"`
+	Size         int64       `json:"size"`
+	DeclaredMime pgtype.Text `json:"declared_mime"`
+	ID           uuid.UUID   `json:"id"`
+}
+
+func (q *Queries) UpdateFileBlob(ctx context.Context, arg UpdateFileBlobParams) (File, error) {
+	row := q.db.QueryRow(ctx, updateFileBlob,
+		arg.BlobID,
+		arg.Size,
+		arg.DeclaredMime,
+		arg.ID,
+	)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.BlobID,
+		&i.Filename,
+		&i.DeclaredMime,
+		&i.Size,
+		&i.UploadedAt,
+		&i.IsPublic,
+		&i.PublicToken,
+		&i.DownloadCount,
+		&i.FolderID,
+		&i.PublicExpiresAt,
+		&i.PublicMaxDownloads,
+		&i.PublicDownloadCount,
+		&i.PublicPasswordHash,
+		&i.CurrentVersion,
+		&i.TrashedAt,
+		&i.TrashedWith,
+	)
+	return i, err
+}
+
 const updateFileFolder = `-- name: UpdateFileFolder :exec
 UPDATE files
 SET folder_id = $1
@@ -813,7 +1195,7 @@ const updateFilename = `-- name: UpdateFilename :one
 UPDATE files
 SET filename = $1
 WHERE id = $2
-RETURNING id, owner_id, blob_id, filename, declared_mime, size, uploaded_at, is_public, public_token, download_count, folder_id
+RETURNING id, owner_id, blob_id, filename, declared_mime, size, uploaded_at, is_public, public_token, download_count, folder_id, public_expires_at, public_max_downloads, public_download_count, public_password_hash, current_version, trashed_at, trashed_with
 `
 
 type UpdateFilenameParams struct {
@@ -836,6 +1218,13 @@ func (q *Queries) UpdateFilename(ctx context.Context, arg UpdateFilenameParams)
 		&i.PublicToken,
 		&i.DownloadCount,
 		&i.FolderID,
+		&i.PublicExpiresAt,
+		&i.PublicMaxDownloads,
+		&i.PublicDownloadCount,
+		&i.PublicPasswordHash,
+		&i.CurrentVersion,
+		&i.TrashedAt,
+		&i.TrashedWith,
 	)
 	return i, err
 }
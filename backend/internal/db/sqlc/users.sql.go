@@ -12,7 +12,7 @@ import (
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (email, name, password, created_at, storage_quota)
 VALUES ($1, $2, $3, NOW(), $4)
-RETURNING id, name, email, password, role, created_at, storage_quota, storage_used
+RETURNING id, name, email, password, role, created_at, storage_quota, storage_used, email_verified_at
 `
 
 type CreateUserParams struct {
@@ -39,6 +39,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.StorageQuota,
 		&i.StorageUsed,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
@@ -47,7 +48,9 @@ const getDeduplicatedUsage = `-- name: GetDeduplicatedUsage :one
 SELECT COALESCE(SUM(b.size), 0)::BIGINT
 FROM blobs b
 WHERE b.id IN (
-    SELECT DISTINCT blob_id FROM files WHERE owner_id = $1
+    SELECT blob_id FROM files WHERE owner_id = $1
+    UNION
+    SELECT blob_id FROM file_versions WHERE owner_id = $1
 )
 `
 
@@ -59,7 +62,7 @@ func (q *Queries) GetDeduplicatedUsage(ctx context.Context, ownerID int64) (int6
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, name, email, password, role, created_at, storage_quota, storage_used FROM users WHERE email = $1
+SELECT id, name, email, password, role, created_at, storage_quota, storage_used, email_verified_at FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -74,12 +77,36 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.CreatedAt,
 		&i.StorageQuota,
 		&i.StorageUsed,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const getUserByEmailFold = `-- name: GetUserByEmailFold :one
+SELECT id, name, email, password, role, created_at, storage_quota, storage_used, email_verified_at FROM users WHERE lower(email) = lower($1)
+`
+
+// Gets a user by email ignoring case, as identity providers may not keep the
+// case the user signed up with.
+func (q *Queries) GetUserByEmailFold(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmailFold, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.Role,
+		&i.CreatedAt,
+		&i.StorageQuota,
+		&i.StorageUsed,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, name, email, password, role, created_at, storage_quota, storage_used FROM users WHERE id = $1
+SELECT id, name, email, password, role, created_at, storage_quota, storage_used, email_verified_at FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
@@ -94,6 +121,7 @@ func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
 		&i.CreatedAt,
 		&i.StorageQuota,
 		&i.StorageUsed,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
@@ -130,3 +158,82 @@ func (q *Queries) ListOtherUsers(ctx context.Context, id int64) ([]ListOtherUser
 	}
 	return items, nil
 }
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users SET password = $2 WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID       int64  `json:"id"`
+	Password string `json:"password"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.Exec(ctx, updateUserPassword, arg.ID, arg.Password)
+	return err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :exec
+UPDATE users SET role = $2 WHERE id = $1
+`
+
+type UpdateUserRoleParams struct {
+	ID   int64  `json:"id"`
+	Role string `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) error {
+	_, err := q.db.Exec(ctx, updateUserRole, arg.ID, arg.Role)
+	return err
+}
+
+const userNameExists = `-- name: UserNameExists :one
+SELECT EXISTS (SELECT 1 FROM users WHERE name = $1)
+`
+
+func (q *Queries) UserNameExists(ctx context.Context, name string) (bool, error) {
+	row := q.db.QueryRow(ctx, userNameExists, name)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const userSharesWith = `-- name: UserSharesWith :one
+SELECT EXISTS (
+    SELECT 1 FROM file_shares s
+    JOIN files f ON f.id = s.file_id
+    WHERE f.owner_id = $1 AND s.shared_with = $2
+    UNION ALL
+    SELECT 1 FROM folder_shares s
+    JOIN folders f ON f.id = s.folder_id
+    WHERE f.owner_id = $1 AND s.shared_with = $2
+)
+`
+
+type UserSharesWithParams struct {
+	OwnerID    int64 `json:"owner_id"`
+	SharedWith int64 `json:"shared_with"`
+}
+
+// Reports whether the owner shares a file or folder with the other user.
+func (q *Queries) UserSharesWith(ctx context.Context, arg UserSharesWithParams) (bool, error) {
+	row := q.db.QueryRow(ctx, userSharesWith, arg.OwnerID, arg.SharedWith)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :execrows
+UPDATE users SET email_verified_at = now()
+WHERE id = $1 AND email_verified_at IS NULL
+`
+
+// Marks the email address of a user as verified. Updates no row if it
+// already was.
+func (q *Queries) VerifyUserEmail(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, verifyUserEmail, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
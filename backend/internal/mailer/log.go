@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LogMailer is the mailer for development. It writes emails to a directory
+// as .eml files, which mail clients open, or to the log when it has none.
+type LogMailer struct {
+	dir  string
+	from *mail.Address
+}
+
+// NewLogMailer creates a LogMailer writing to dir, created if missing, or to
+// the log when dir is empty.
+func NewLogMailer(dir string, from *mail.Address) (*LogMailer, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("create mail directory: %w", err)
+		}
+	}
+	return &LogMailer{dir: dir, from: from}, nil
+}
+
+// Send writes msg to the directory or the log.
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient %q: %w", msg.To, err)
+	}
+	now := time.Now()
+	data, err := compose(m.from, to, msg, now)
+	if err != nil {
+		return err
+	}
+
+	if m.dir == "" {
+		log.Printf("Email to %s: %s\n%s", to.Address, msg.Subject, msg.Body)
+		return nil
+	}
+
+	// Emails carry secret links, so only the owner of the directory may read them
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+	if err := os.WriteFile(filepath.Join(m.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("write email: %w", err)
+	}
+	log.Printf("Email to %s written to %s", to.Address, name)
+	return nil
+}
@@ -0,0 +1,169 @@
+package mailer_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/config"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/mailer"
+)
+
+var message = mailer.Message{
+	To:      "Ada <ada@example.com>",
+	Subject: "Vérifiez votre adresse",
+	Body:    "Open this link:\nhttps://vault.example.com/verify-email?token=" + strings.Repeat("a", 80) + "\n",
+}
+
+// checkMessage parses a sent email and checks its headers and decoded body.
+func checkMessage(t *testing.T, data []byte) {
+	t.Helper()
+	parsed, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("parse email: %v", err)
+	}
+	if got := parsed.Header.Get("From"); got != `"FileVault" <no-reply@example.com>` {
+		t.Errorf("From = %q", got)
+	}
+	if got := parsed.Header.Get("To"); got != `"Ada" <ada@example.com>` {
+		t.Errorf("To = %q", got)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != message.Subject {
+		t.Errorf("Subject = %q, %v", subject, err)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	if err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if got := strings.ReplaceAll(string(body), "\r\n", "\n"); got != message.Body {
+		t.Errorf("body = %q, want %q", got, message.Body)
+	}
+}
+
+func TestLogMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	m, err := mailer.New(config.MailConfig{Driver: "log", From: "FileVault <no-reply@example.com>", Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Send(context.Background(), message); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !strings.HasSuffix(entries[0].Name(), ".eml") {
+		t.Fatalf("mail directory = %v, %v", entries, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMessage(t, data)
+
+	if err := m.Send(context.Background(), mailer.Message{To: "ada@example.com", Subject: "Hi\r\nBcc: eve@example.com"}); err == nil {
+		t.Error("subject with a line break was sent")
+	}
+	if err := m.Send(context.Background(), mailer.Message{To: "not an address", Subject: "Hi"}); err == nil {
+		t.Error("invalid recipient was accepted")
+	}
+}
+
+// smtpServer accepts one SMTP conversation without STARTTLS or
+// authentication, and sends the envelope and data it received.
+func smtpServer(t *testing.T) (string, <-chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+		var got []string
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch verb, _, _ := strings.Cut(line, " "); strings.ToUpper(verb) {
+			case "EHLO":
+				reply("250 localhost")
+			case "MAIL", "RCPT":
+				got = append(got, line)
+				reply("250 OK")
+			case "DATA":
+				reply("354 Go ahead")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(strings.TrimPrefix(line, "."))
+				}
+				got = append(got, data.String())
+				reply("250 Queued")
+			case "QUIT":
+				reply("221 Bye")
+				received <- got
+				return
+			default:
+				reply("502 Not implemented")
+			}
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestSMTPMailer(t *testing.T) {
+	addr, received := smtpServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+	smtpPort, _ := strconv.Atoi(port)
+
+	m, err := mailer.New(config.MailConfig{
+		Driver:   "smtp",
+		From:     "FileVault <no-reply@example.com>",
+		SMTPHost: host,
+		SMTPPort: smtpPort,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Send(context.Background(), message); err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-received
+	if len(got) != 3 {
+		t.Fatalf("server received %q", got)
+	}
+	if got[0] != "MAIL FROM:<no-reply@example.com>" || got[1] != "RCPT TO:<ada@example.com>" {
+		t.Errorf("envelope = %q", got[:2])
+	}
+	checkMessage(t, []byte(got[2]))
+}
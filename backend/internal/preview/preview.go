@@ -0,0 +1,217 @@
+// Package preview generates previews of documents: thumbnails of JPEG, PNG,
+// GIF and WebP images, scaled in pure Go, and the first page of text files.
+package preview
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif" // registers the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // registers the WebP decoder
+)
+
+// MaxSourceSize is the size of the largest image a thumbnail is made of.
+// Images are read into memory as a whole to be decoded.
+const MaxSourceSize = 32 << 20
+
+// MaxPixels is the largest number of pixels of an image a thumbnail is made
+// of, so a small file claiming huge dimensions cannot exhaust memory.
+const MaxPixels = 50_000_000
+
+// Text previews hold at most the first TextPageLines lines of a file and at
+// most TextPageSize bytes, about a printed page.
+const (
+	TextPageLines = 60
+	TextPageSize  = 4 << 10
+)
+
+// jpegQuality is the quality thumbnails of JPEG images are encoded with.
+const jpegQuality = 80
+
+// ErrUnsupported is returned for documents in a format there is no preview of.
+var ErrUnsupported = errors.New("preview: unsupported format")
+
+// ErrTooLarge is returned for images larger than MaxSourceSize or MaxPixels.
+var ErrTooLarge = errors.New("preview: image too large")
+
+// Sizes maps the names of the thumbnail sizes to the length in pixels of the
+// longest side of their thumbnails.
+var Sizes = map[string]int{
+	"small":  128,
+	"medium": 256,
+	"large":  512,
+}
+
+// DefaultSize is the name of the size thumbnails are made in by default.
+const DefaultSize = "medium"
+
+type kind int
+
+const (
+	kindNone kind = iota
+	kindJPEG
+	kindImage // PNG, GIF and WebP, which may be transparent
+	kindText
+)
+
+// mimeKinds maps the content types of the supported formats to their kind.
+var mimeKinds = map[string]kind{
+	"image/jpeg":       kindJPEG,
+	"image/png":        kindImage,
+	"image/gif":        kindImage,
+	"image/webp":       kindImage,
+	"application/json": kindText,
+}
+
+// extKinds maps file extensions to their kind, for documents stored with a
+// generic content type.
+var extKinds = map[string]kind{
+	".jpg":      kindJPEG,
+	".jpeg":     kindJPEG,
+	".png":      kindImage,
+	".gif":      kindImage,
+	".webp":     kindImage,
+	".txt":      kindText,
+	".text":     kindText,
+	".md":       kindText,
+	".markdown": kindText,
+	".csv":      kindText,
+	".json":     kindText,
+	".log":      kindText,
+}
+
+func kindOf(contentType, filename string) kind {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	if k, ok := mimeKinds[mediaType]; ok {
+		return k
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		return kindText
+	}
+	if mediaType == "" || mediaType == "application/octet-stream" {
+		return extKinds[strings.ToLower(filepath.Ext(filename))]
+	}
+	return kindNone
+}
+
+// ContentType returns the content type of the previews of a document with the
+// given content type and file name, or an empty string if there is no preview
+// of it. Thumbnails of JPEG images are JPEG images, thumbnails of other images
+// are PNG images, to keep their transparency, and text previews are plain text.
+func ContentType(contentType, filename string) string {
+	switch kindOf(contentType, filename) {
+	case kindJPEG:
+		return "image/jpeg"
+	case kindImage:
+		return "image/png"
+	case kindText:
+		return "text/plain; charset=utf-8"
+	default:
+		return ""
+	}
+}
+
+// IsText reports whether the preview of a document is a text preview, the
+// same for every size.
+func IsText(contentType, filename string) bool {
+	return kindOf(contentType, filename) == kindText
+}
+
+// Generate reads a document and returns its preview, in the content type
+// returned by ContentType. Images are scaled down to fit in a square of size
+// pixels, smaller images are kept at their size. It returns ErrUnsupported for
+// other formats and ErrTooLarge for images too large to be decoded.
+func Generate(r io.Reader, contentType, filename string, size int) ([]byte, error) {
+	switch k := kindOf(contentType, filename); k {
+	case kindJPEG, kindImage:
+		return thumbnail(r, size, k == kindJPEG)
+	case kindText:
+		return textPage(r)
+	default:
+		return nil, ErrUnsupported
+	}
+}
+
+func thumbnail(r io.Reader, size int, opaque bool) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxSourceSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxSourceSize {
+		return nil, ErrTooLarge
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) {
+		return nil, ErrUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	width, height := fit(src.Bounds().Dx(), src.Bounds().Dy(), size)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	if opaque {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		err = png.Encode(&buf, dst)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fit returns the dimensions of an image of width by height pixels scaled
+// down to fit in a square of size pixels, keeping its aspect ratio.
+func fit(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
+
+// textPage returns the first page of a text file as valid UTF-8.
+func textPage(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, TextPageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	lines := 0
+	for i, b := range data {
+		if b == '\n' {
+			if lines++; lines == TextPageLines {
+				data = data[:i+1]
+				break
+			}
+		}
+	}
+	// drops invalid UTF-8, such as a character the limit cut in two
+	return bytes.ToValidUTF8(data, nil), nil
+}
@@ -0,0 +1,117 @@
+package preview_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/preview"
+)
+
+// encode returns a width by height image in the given format.
+func encode(t *testing.T, format string, width, height int) []byte {
+	t.Helper()
+	img := image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{color.Black, color.White})
+	for x := 0; x < width; x += 2 {
+		img.SetColorIndex(x, x%height, 1)
+	}
+
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGenerate(t *testing.T) {
+	webp, err := os.ReadFile("testdata/gopher.webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		filename    string
+		size        int
+		wantType    string
+		wantWidth   int
+		wantHeight  int
+		wantText    string
+		wantErr     error
+	}{
+		{name: "landscape png", data: encode(t, "png", 400, 200), contentType: "image/png", filename: "a.png", size: 128, wantType: "image/png", wantWidth: 128, wantHeight: 64},
+		{name: "portrait jpeg", data: encode(t, "jpeg", 300, 600), contentType: "image/jpeg", filename: "a.jpg", size: 256, wantType: "image/jpeg", wantWidth: 128, wantHeight: 256},
+		{name: "gif by extension", data: encode(t, "gif", 512, 512), contentType: "application/octet-stream", filename: "a.GIF", size: 128, wantType: "image/png", wantWidth: 128, wantHeight: 128},
+		{name: "webp", data: webp, contentType: "image/webp", filename: "gopher.webp", size: 50, wantType: "image/png", wantWidth: 37, wantHeight: 50},
+		{name: "small image kept", data: encode(t, "png", 40, 30), contentType: "image/png", filename: "a.png", size: 128, wantType: "image/png", wantWidth: 40, wantHeight: 30},
+		{name: "text", data: []byte("first\nsecond\xff"), contentType: "text/markdown", filename: "a.md", wantType: "text/plain; charset=utf-8", wantText: "first\nsecond"},
+		{name: "long text", data: []byte(strings.Repeat("line\n", 100)), contentType: "text/plain", filename: "a.txt", wantType: "text/plain; charset=utf-8", wantText: strings.Repeat("line\n", preview.TextPageLines)},
+		{name: "unsupported", data: []byte("%PDF-1.7"), contentType: "application/pdf", filename: "a.pdf", wantErr: preview.ErrUnsupported},
+		{name: "not an image", data: []byte("plain text"), contentType: "image/png", filename: "a.png", size: 128, wantType: "image/png", wantErr: preview.ErrUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := preview.ContentType(tt.contentType, tt.filename); got != tt.wantType {
+				t.Errorf("ContentType = %q, want %q", got, tt.wantType)
+			}
+
+			data, err := preview.Generate(bytes.NewReader(tt.data), tt.contentType, tt.filename, tt.size)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Generate error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if tt.wantWidth == 0 {
+				if string(data) != tt.wantText {
+					t.Errorf("text preview = %q, want %q", data, tt.wantText)
+				}
+				return
+			}
+
+			config, format, err := image.DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("decoding the thumbnail: %v", err)
+			}
+			if "image/"+format != tt.wantType || config.Width != tt.wantWidth || config.Height != tt.wantHeight {
+				t.Errorf("thumbnail is a %dx%d %s, want %dx%d %s",
+					config.Width, config.Height, format, tt.wantWidth, tt.wantHeight, tt.wantType)
+			}
+		})
+	}
+}
+
+// TestGenerateTooLarge checks that the dimensions of an image are checked
+// before it is decoded.
+func TestGenerateTooLarge(t *testing.T) {
+	// a 1x1 PNG whose header claims 20000x20000 pixels
+	huge := encode(t, "png", 1, 1)
+	binary.BigEndian.PutUint32(huge[16:], 20000)
+	binary.BigEndian.PutUint32(huge[20:], 20000)
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+
+	if _, err := preview.Generate(bytes.NewReader(huge), "image/png", "huge.png", 128); !errors.Is(err, preview.ErrTooLarge) {
+		t.Errorf("Generate error = %v, want %v", err, preview.ErrTooLarge)
+	}
+}
@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/config"
+)
+
+// LocalBlobRoute is the API route that serves and accepts blobs through signed URLs
+// when the local storage driver is used.
+const LocalBlobRoute = "/storage/blobs"
+
+// LocalStorage stores blobs on the local filesystem under a root directory.
+// Object keys are hashed into two levels of shard directories, and every write goes
+// to a temporary file that is renamed into place, so readers never see partial blobs.
+// Presigned URLs point at LocalBlobRoute on the API itself and are signed with HMAC-SHA256.
+type LocalStorage struct {
+	Root    string
+	BaseURL string
+	secret  []byte
+}
+
+func NewLocalStorage(cfg config.StorageConfig) (*LocalStorage, error) {
+	if cfg.SigningSecret == "" {
+		return nil, errors.New("local storage requires a signing secret")
+	}
+	if err := os.MkdirAll(filepath.Join(cfg.LocalDir, ".tmp"), 0o750); err != nil {
+		return nil, err
+	}
+	log.Printf("Using local storage at %s", cfg.LocalDir)
+
+	return &LocalStorage{
+		Root:    cfg.LocalDir,
+		BaseURL: cfg.LocalBaseURL,
+		secret:  []byte(cfg.SigningSecret),
+	}, nil
+}
+
+// blobPath maps an object key to its sharded location on disk.
+// Keys are hashed, so arbitrary key contents can never escape the root directory.
+func (l *LocalStorage) blobPath(fileName string) string {
+	sum := sha256.Sum256([]byte(fileName))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(l.Root, name[0:2], name[2:4], name)
+}
+
+// writeAtomic streams r into a temporary file and renames it to the blob's path.
+// If expectedSHA is not empty the content must hash to it, or nothing is written.
+// Errors from r, such as an *http.MaxBytesError, are returned unwrapped.
+func (l *LocalStorage) writeAtomic(r io.Reader, fileName string, expectedSHA string) (int64, error) {
+	tmp, err := os.CreateTemp(filepath.Join(l.Root, ".tmp"), "upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	hasher := sha256.New()
+	n, err := io.Copy(tmp, io.TeeReader(r, hasher))
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if expectedSHA != "" && hex.EncodeToString(hasher.Sum(nil)) != expectedSHA {
+		return 0, errChecksumMismatch
+	}
+
+	dst := l.blobPath(fileName)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return 0, err
+	}
+	return n, os.Rename(tmp.Name(), dst)
+}
+
+var errChecksumMismatch = errors.New("content does not match the expected sha256")
+
+func (l *LocalStorage) UploadBlob(ctx context.Context, r io.Reader, fileName string, size int64, contentType string) (string, error) {
+	n, err := l.writeAtomic(r, fileName, "")
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Uploaded %d bytes\n", n)
+	return fileName, nil
+}
+
+func (l *LocalStorage) GetBlob(ctx context.Context, fileName string) (io.ReadCloser, error) {
+	return os.Open(l.blobPath(fileName))
+}
+
+// GetBlobRange returns a reader over length bytes of the object starting at offset.
+// A negative length reads until the end of the object.
+func (l *LocalStorage) GetBlobRange(ctx context.Context, fileName string, offset, length int64) (io.ReadCloser, error) {
+	f, err := os.Open(l.blobPath(fileName))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if length < 0 {
+		return f, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(f, length), f}, nil
+}
+
+// GetBlobURL returns a signed URL, valid for 24 hours, from which the API serves the object.
+func (l *LocalStorage) GetBlobURL(ctx context.Context, fileName string) (string, error) {
+	if _, err := os.Stat(l.blobPath(fileName)); err != nil {
+		return "", err
+	}
+	return l.signedURL(http.MethodGet, fileName, "", -1, time.Hour*24), nil
+}
+
+// PresignUpload returns a signed URL accepting a PUT of the object to the API.
+// The expected SHA-256 and size are part of the signature. The size bounds the body
+// that is read, and the checksum is verified while it is written.
+func (l *LocalStorage) PresignUpload(ctx context.Context, fileName string, sha256 string, size int64, expiry time.Duration) (string, map[string]string, error) {
+	return l.signedURL(http.MethodPut, fileName, sha256, size, expiry), map[string]string{}, nil
+}
+
+// StatBlob returns the size of the object. Checksums are not stored, so SHA256 is always empty.
+func (l *LocalStorage) StatBlob(ctx context.Context, fileName string) (BlobInfo, error) {
+	info, err := os.Stat(l.blobPath(fileName))
+	if err != nil {
+		return BlobInfo{}, err
+	}
+	return BlobInfo{Size: info.Size()}, nil
+}
+
+func (l *LocalStorage) MoveBlob(ctx context.Context, srcPath, dstPath string) error {
+	dst := l.blobPath(dstPath)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+	return os.Rename(l.blobPath(srcPath), dst)
+}
+
+// DeleteBlob removes the object. Like S3, deleting a missing object is not an error.
+func (l *LocalStorage) DeleteBlob(ctx context.Context, fileName string) error {
+	err := os.Remove(l.blobPath(fileName))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *LocalStorage) DeleteBlobs(ctx context.Context, storagePaths []string) error {
+	failed := false
+	for _, key := range storagePaths {
+		if err := l.DeleteBlob(ctx, key); err != nil {
+			log.Printf("Error deleting object %s: %v", key, err)
+			failed = true
+		}
+	}
+	if failed {
+		return fmt.Errorf("failed to delete one or more objects from storage")
+	}
+	return nil
+}
+
+// signedURL builds a URL to LocalBlobRoute that authorizes method on fileName until expiry.
+// A size of zero or more limits the body of an upload, a negative size is left out.
+func (l *LocalStorage) signedURL(method, fileName, sha string, size int64, expiry time.Duration) string {
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	sizeParam := ""
+	if size >= 0 {
+		sizeParam = strconv.FormatInt(size, 10)
+	}
+
+	query := url.Values{}
+	query.Set("key", fileName)
+	query.Set("expires", expires)
+	if sha != "" {
+		query.Set("sha256", sha)
+	}
+	if sizeParam != "" {
+		query.Set("size", sizeParam)
+	}
+	query.Set("signature", l.sign(method, fileName, expires, sha, sizeParam))
+
+	return l.BaseURL + LocalBlobRoute + "?" + query.Encode()
+}
+
+// sign computes the hex-encoded HMAC-SHA256 over the request parameters.
+func (l *LocalStorage) sign(method, fileName, expires, sha, size string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%s", method, fileName, expires, sha, size)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ServeHTTP serves LocalBlobRoute. GET requests download an object and PUT requests
+// upload one, both only with a valid, unexpired signature produced by this storage.
+func (l *LocalStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	fileName := query.Get("key")
+	expires := query.Get("expires")
+	sha := query.Get("sha256")
+	size := query.Get("size")
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		http.Error(w, "URL expired", http.StatusForbidden)
+		return
+	}
+	expected := l.sign(r.Method, fileName, expires, sha, size)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("signature"))) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		f, err := os.Open(l.blobPath(fileName))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			http.Error(w, "could not read object", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(w, r, "", info.ModTime(), f)
+
+	case http.MethodPut:
+		// Uploads are only signed with a size, the body may not grow past it
+		maxBytes, err := strconv.ParseInt(size, 10, 64)
+		if err != nil || maxBytes < 0 {
+			http.Error(w, "upload size missing", http.StatusForbidden)
+			return
+		}
+		if r.ContentLength > maxBytes {
+			http.Error(w, "body exceeds the signed size", http.StatusRequestEntityTooLarge)
+			return
+		}
+		body := http.MaxBytesReader(w, r.Body, maxBytes)
+		if _, err := l.writeAtomic(body, fileName, sha); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "body exceeds the signed size", http.StatusRequestEntityTooLarge)
+				return
+			}
+			if errors.Is(err, errChecksumMismatch) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Printf("error while writing object %s: %v", fileName, err)
+			http.Error(w, "could not write object", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
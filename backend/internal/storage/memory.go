@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStorage keeps blobs in memory. It is meant for tests and local experiments,
+// all data is lost when the process exits.
+type MemoryStorage struct {
+	mu      sync.RWMutex
+	objects map[string][]byte
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{objects: make(map[string][]byte)}
+}
+
+func (m *MemoryStorage) UploadBlob(ctx context.Context, r io.Reader, fileName string, size int64, contentType string) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	if size >= 0 && int64(len(data)) != size {
+		return "", fmt.Errorf("expected %d bytes, got %d", size, len(data))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[fileName] = data
+	return fileName, nil
+}
+
+func (m *MemoryStorage) GetBlob(ctx context.Context, fileName string) (io.ReadCloser, error) {
+	data, ok := m.Object(fileName)
+	if !ok {
+		return nil, fmt.Errorf("object %s does not exist", fileName)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *MemoryStorage) GetBlobRange(ctx context.Context, fileName string, offset, length int64) (io.ReadCloser, error) {
+	data, ok := m.Object(fileName)
+	if !ok {
+		return nil, fmt.Errorf("object %s does not exist", fileName)
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	data = data[offset:]
+	if length >= 0 && length < int64(len(data)) {
+		data = data[:length]
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *MemoryStorage) GetBlobURL(ctx context.Context, fileName string) (string, error) {
+	if _, ok := m.Object(fileName); !ok {
+		return "", fmt.Errorf("object %s does not exist", fileName)
+	}
+	return "memory://" + fileName, nil
+}
+
+// PresignUpload returns a placeholder URL. Objects can be put in place with UploadBlob.
+func (m *MemoryStorage) PresignUpload(ctx context.Context, fileName string, sha256 string, size int64, expiry time.Duration) (string, map[string]string, error) {
+	return "memory://" + fileName, map[string]string{}, nil
+}
+
+// StatBlob returns the size and the SHA-256 of the object.
+func (m *MemoryStorage) StatBlob(ctx context.Context, fileName string) (BlobInfo, error) {
+	data, ok := m.Object(fileName)
+	if !ok {
+		return BlobInfo{}, fmt.Errorf("object %s does not exist", fileName)
+	}
+	sum := sha256.Sum256(data)
+	return BlobInfo{Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])}, nil
+}
+
+func (m *MemoryStorage) MoveBlob(ctx context.Context, srcPath, dstPath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[srcPath]
+	if !ok {
+		return fmt.Errorf("object %s does not exist", srcPath)
+	}
+	m.objects[dstPath] = data
+	delete(m.objects, srcPath)
+	return nil
+}
+
+func (m *MemoryStorage) DeleteBlob(ctx context.Context, fileName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, fileName)
+	return nil
+}
+
+func (m *MemoryStorage) DeleteBlobs(ctx context.Context, storagePaths []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range storagePaths {
+		delete(m.objects, key)
+	}
+	return nil
+}
+
+// Object returns the content stored under fileName and whether it exists.
+func (m *MemoryStorage) Object(fileName string) ([]byte, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.objects[fileName]
+	return data, ok
+}
+
+// Keys returns the keys of all stored objects in sorted order.
+func (m *MemoryStorage) Keys() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := make([]string, 0, len(m.objects))
+	for key := range m.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,9 +2,13 @@ package storage
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/config"
@@ -12,6 +16,10 @@ import (
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
 
+// streamingPartSize is the multipart chunk size used when the object size is not
+// known up front. MinIO buffers one part at a time, so this bounds upload memory.
+const streamingPartSize = 16 << 20
+
 type MinioStorage struct {
 	Client     *minio.Client
 	BucketName string
@@ -50,14 +58,19 @@ func (m *MinioStorage) UploadBlob(ctx context.Context, r io.Reader, fileName str
 		size = -1
 	}
 
-	info, err := m.Client.PutObject(ctx, m.BucketName, fileName, r, size, minio.PutObjectOptions{
+	opts := minio.PutObjectOptions{
 		ContentType: contentType,
-	})
-	log.Printf("Uploaded %d bytes\n", info.Size)
+	}
+	if size < 0 {
+		opts.PartSize = streamingPartSize
+	}
 
+	info, err := m.Client.PutObject(ctx, m.BucketName, fileName, r, size, opts)
 	if err != nil {
 		return "", err
 	}
+	log.Printf("Uploaded %d bytes\n", info.Size)
+
 	return fileName, nil
 }
 
@@ -71,6 +84,46 @@ func (m *MinioStorage) GetBlobURL(ctx context.Context, fileName string) (string,
 	return url.String(), nil
 }
 
+// PresignUpload returns a presigned PUT URL for uploading an object directly to the bucket,
+// along with the headers the client must send with the request. The expected SHA-256 is
+// signed into the request as x-amz-checksum-sha256, so the server rejects mismatching content,
+// which also rules out a body of any other size.
+func (m *MinioStorage) PresignUpload(ctx context.Context, fileName string, sha256 string, size int64, expiry time.Duration) (string, map[string]string, error) {
+	checksum, err := hex.DecodeString(sha256)
+	if err != nil {
+		return "", nil, err
+	}
+	headers := map[string]string{
+		"x-amz-checksum-sha256": base64.StdEncoding.EncodeToString(checksum),
+	}
+
+	signed := make(http.Header)
+	for k, v := range headers {
+		signed.Set(k, v)
+	}
+	url, err := m.Client.PresignHeader(ctx, http.MethodPut, m.BucketName, fileName, expiry, nil, signed)
+	if err != nil {
+		return "", nil, err
+	}
+	return url.String(), headers, nil
+}
+
+// StatBlob returns the size of an object and, when one was stored with it, its SHA-256 checksum.
+func (m *MinioStorage) StatBlob(ctx context.Context, fileName string) (BlobInfo, error) {
+	info, err := m.Client.StatObject(ctx, m.BucketName, fileName, minio.StatObjectOptions{Checksum: true})
+	if err != nil {
+		return BlobInfo{}, err
+	}
+
+	blobInfo := BlobInfo{Size: info.Size}
+	if info.ChecksumSHA256 != "" {
+		if checksum, err := base64.StdEncoding.DecodeString(info.ChecksumSHA256); err == nil {
+			blobInfo.SHA256 = hex.EncodeToString(checksum)
+		}
+	}
+	return blobInfo, nil
+}
+
 func (m *MinioStorage) GetBlob(ctx context.Context, fileName string) (io.ReadCloser, error) {
 
 	obj, err := m.Client.GetObject(ctx, m.BucketName, fileName, minio.GetObjectOptions{})
@@ -86,6 +139,46 @@ func (m *MinioStorage) GetBlob(ctx context.Context, fileName string) (io.ReadClo
 	return obj, nil
 }
 
+// GetBlobRange returns a reader over length bytes of the object starting at offset.
+// A negative length reads until the end of the object.
+func (m *MinioStorage) GetBlobRange(ctx context.Context, fileName string, offset, length int64) (io.ReadCloser, error) {
+	if length == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+
+	opts := minio.GetObjectOptions{}
+	if offset > 0 || length > 0 {
+		end := int64(0) // 0 reads till the end of the object
+		if length > 0 {
+			end = offset + length - 1
+		}
+		if err := opts.SetRange(offset, end); err != nil {
+			return nil, err
+		}
+	}
+
+	obj, err := m.Client.GetObject(ctx, m.BucketName, fileName, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, err
+	}
+	return obj, nil
+}
+
+// MoveBlob copies the object at srcPath to dstPath server-side and removes the source.
+// ComposeObject is used instead of CopyObject so that objects larger than 5GiB can be moved.
+func (m *MinioStorage) MoveBlob(ctx context.Context, srcPath, dstPath string) error {
+	src := minio.CopySrcOptions{Bucket: m.BucketName, Object: srcPath}
+	dst := minio.CopyDestOptions{Bucket: m.BucketName, Object: dstPath}
+	if _, err := m.Client.ComposeObject(ctx, dst, src); err != nil {
+		return err
+	}
+	return m.Client.RemoveObject(ctx, m.BucketName, srcPath, minio.RemoveObjectOptions{})
+}
+
 func (m *MinioStorage) DeleteBlob(ctx context.Context, fileName string) error {
 	return m.Client.RemoveObject(ctx, m.BucketName, fileName, minio.RemoveObjectOptions{})
 }
@@ -3,12 +3,37 @@ package storage
 import (
 	"context"
 	"io"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/config"
 )
 
+// BlobInfo describes a stored object.
+// SHA256 is the hex-encoded checksum verified by the backend, or empty if the
+// backend does not know it.
+type BlobInfo struct {
+	Size   int64
+	SHA256 string
+}
+
+// Storage is implemented by the blob storage backends.
+// GetBlobRange reads length bytes starting at offset, a negative length reads to the end.
 type Storage interface {
 	UploadBlob(ctx context.Context, r io.Reader, fileName string, size int64, contentType string) (string, error)
 	GetBlob(ctx context.Context, fileName string) (io.ReadCloser, error)
+	GetBlobRange(ctx context.Context, fileName string, offset, length int64) (io.ReadCloser, error)
 	GetBlobURL(ctx context.Context, fileName string) (string, error)
+	PresignUpload(ctx context.Context, fileName string, sha256 string, size int64, expiry time.Duration) (string, map[string]string, error)
+	StatBlob(ctx context.Context, fileName string) (BlobInfo, error)
+	MoveBlob(ctx context.Context, srcPath, dstPath string) error
 	DeleteBlob(ctx context.Context, fileName string) error
 	DeleteBlobs(ctx context.Context, storagePaths []string) error
 }
+
+// New returns the Storage implementation selected by cfg.Storage.Driver.
+func New(cfg *config.Config) (Storage, error) {
+	if cfg.Storage.Driver == "local" {
+		return NewLocalStorage(cfg.Storage)
+	}
+	return NewMinioStorage(cfg.Minio)
+}
@@ -0,0 +1,95 @@
+package textextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// docxBody is the part of a DOCX package that holds the body of the document.
+const docxBody = "word/document.xml"
+
+// maxDocxBodySize bounds the decompressed size of the body of a DOCX document.
+// The package itself is limited to MaxSourceSize, but a few kilobytes of it
+// can inflate to gigabytes.
+const maxDocxBodySize = 16 << 20
+
+// wordNamespace is the XML namespace of the WordprocessingML elements.
+const wordNamespace = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+// extractDOCX returns the text of the runs in the body of a DOCX document,
+// one paragraph per line. It returns ErrTooLarge if the body inflates to
+// more than maxDocxBodySize bytes.
+func extractDOCX(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("reading docx package: %w", err)
+	}
+
+	var body *zip.File
+	for _, f := range zr.File {
+		if f.Name == docxBody {
+			body = f
+			break
+		}
+	}
+	if body == nil {
+		return "", errors.New("docx package has no " + docxBody)
+	}
+
+	rc, err := body.Open()
+	if err != nil {
+		return "", fmt.Errorf("opening %s: %w", docxBody, err)
+	}
+	defer rc.Close()
+
+	limited := &io.LimitedReader{R: rc, N: maxDocxBodySize + 1}
+	var text textBuilder
+	inText := false
+	decoder := xml.NewDecoder(limited)
+	for !text.full() {
+		token, err := decoder.Token()
+		if limited.N == 0 {
+			return "", ErrTooLarge
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("parsing %s: %w", docxBody, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space != wordNamespace {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				text.WriteByte('\t')
+			case "br", "cr":
+				text.newline()
+			}
+		case xml.EndElement:
+			if t.Name.Space != wordNamespace {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text.newline()
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(t)
+			}
+		}
+	}
+	return text.text(), nil
+}
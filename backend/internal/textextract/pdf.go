@@ -0,0 +1,369 @@
+package textextract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"strconv"
+	"unicode/utf16"
+)
+
+// maxStreamSize bounds the decompressed size of a single PDF stream.
+const maxStreamSize = 16 << 20
+
+// extractPDF returns the text shown by the content streams of a PDF document.
+// Streams are read where they appear in the file, uncompressed or Flate
+// encoded, and the strings drawn between BT and ET are decoded as Latin-1 or
+// UTF-16. This covers documents written with simple fonts; text drawn with
+// composite fonts, which needs their ToUnicode maps to be read, is skipped.
+func extractPDF(data []byte) (string, error) {
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return "", errors.New("not a PDF document")
+	}
+
+	var text textBuilder
+	for rest := data; !text.full(); {
+		dict, stream, next, ok := nextStream(rest)
+		if !ok {
+			break
+		}
+		rest = next
+
+		content, ok := decodeStream(dict, stream)
+		if !ok {
+			continue
+		}
+		extractContentText(content, &text)
+	}
+	return text.text(), nil
+}
+
+// nextStream finds the next stream in data and returns its dictionary, its raw
+// bytes and the data that follows it.
+func nextStream(data []byte) (dict, stream, rest []byte, ok bool) {
+	for {
+		i := bytes.Index(data, []byte("stream"))
+		if i < 0 {
+			return nil, nil, nil, false
+		}
+		// skip the "stream" of "endstream"
+		if i >= 3 && string(data[i-3:i]) == "end" {
+			data = data[i+len("stream"):]
+			continue
+		}
+
+		dictStart := bytes.LastIndex(data[:i], []byte("obj"))
+		if dictStart < 0 {
+			dictStart = 0
+		}
+		dict = data[dictStart:i]
+
+		start := i + len("stream")
+		if bytes.HasPrefix(data[start:], []byte("\r\n")) {
+			start += 2
+		} else if bytes.HasPrefix(data[start:], []byte("\n")) {
+			start++
+		}
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			return nil, nil, nil, false
+		}
+		return dict, data[start : start+end], data[start+end+len("endstream"):], true
+	}
+}
+
+// decodeStream returns the contents of a stream that may hold text: one that
+// is uncompressed or Flate encoded and is not an image or a font program.
+func decodeStream(dict, stream []byte) ([]byte, bool) {
+	for _, skip := range [][]byte{[]byte("/Image"), []byte("/Length1"), []byte("/FontFile"), []byte("/XRef")} {
+		if bytes.Contains(dict, skip) {
+			return nil, false
+		}
+	}
+	if !bytes.Contains(dict, []byte("/Filter")) {
+		return stream, true
+	}
+	if !bytes.Contains(dict, []byte("/FlateDecode")) || bytes.Contains(dict, []byte("/DecodeParms")) {
+		return nil, false
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(stream))
+	if err != nil {
+		return nil, false
+	}
+	defer zr.Close()
+	// keep what was inflated from streams that end early
+	content, _ := io.ReadAll(io.LimitReader(zr, maxStreamSize))
+	return content, len(content) > 0
+}
+
+// extractContentText writes the text shown by a content stream to text.
+func extractContentText(content []byte, text *textBuilder) {
+	lex := pdfLexer{data: content}
+	inText := false
+	var operands []pdfToken
+	for !text.full() {
+		tok, ok := lex.next()
+		if !ok {
+			return
+		}
+		if tok.kind != tokenOperator {
+			operands = append(operands, tok)
+			continue
+		}
+
+		switch string(tok.value) {
+		case "BT":
+			inText = true
+		case "ET":
+			inText = false
+			text.newline()
+		case "Tj":
+			if inText {
+				writeStrings(operands, text)
+			}
+		case "'", "\"":
+			if inText {
+				text.newline()
+				writeStrings(operands, text)
+			}
+		case "TJ":
+			if inText {
+				writeStrings(operands, text)
+			}
+		case "T*":
+			text.newline()
+		case "Td", "TD":
+			if len(operands) >= 2 && operands[len(operands)-1].number() != 0 {
+				text.newline()
+			} else {
+				text.space()
+			}
+		case "Tm":
+			text.space()
+		}
+		operands = operands[:0]
+	}
+}
+
+// writeStrings writes the strings among the operands of a text showing
+// operator. Large negative adjustments in a TJ array separate words.
+func writeStrings(operands []pdfToken, text *textBuilder) {
+	for _, op := range operands {
+		switch op.kind {
+		case tokenString:
+			text.WriteString(decodePDFString(op.value))
+		case tokenNumber:
+			if op.number() < -200 {
+				text.space()
+			}
+		}
+	}
+}
+
+// decodePDFString decodes a string as UTF-16 when it starts with a byte order
+// mark and as Latin-1 otherwise. Control characters are dropped, they show up
+// in the glyph codes of fonts that cannot be decoded this way.
+func decodePDFString(s []byte) string {
+	var runes []rune
+	if len(s) >= 2 && s[0] == 0xFE && s[1] == 0xFF {
+		units := make([]uint16, 0, len(s)/2)
+		for i := 2; i+1 < len(s); i += 2 {
+			units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+		}
+		runes = utf16.Decode(units)
+	} else {
+		runes = make([]rune, len(s))
+		for i, b := range s {
+			runes[i] = rune(b)
+		}
+	}
+
+	out := make([]rune, 0, len(runes))
+	for _, r := range runes {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			continue
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
+
+type tokenKind int
+
+const (
+	tokenOperator tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenOther
+)
+
+type pdfToken struct {
+	kind  tokenKind
+	value []byte
+}
+
+func (t pdfToken) number() float64 {
+	n, _ := strconv.ParseFloat(string(t.value), 64)
+	return n
+}
+
+// pdfLexer splits a content stream into tokens. Array brackets are dropped,
+// so the elements of a TJ array become operands of their own.
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func (l *pdfLexer) next() (pdfToken, bool) {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isPDFSpace(c) || c == '[' || c == ']' || c == '{' || c == '}':
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		case c == '(':
+			return pdfToken{kind: tokenString, value: l.literalString()}, true
+		case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+			l.pos += 2
+			return pdfToken{kind: tokenOther, value: []byte("<<")}, true
+		case c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+			l.pos += 2
+			return pdfToken{kind: tokenOther, value: []byte(">>")}, true
+		case c == '<':
+			return pdfToken{kind: tokenString, value: l.hexString()}, true
+		case c == '/':
+			l.pos++
+			return pdfToken{kind: tokenOther, value: l.regular()}, true
+		default:
+			word := l.regular()
+			if len(word) == 0 {
+				// a stray delimiter such as ')' or '>'
+				l.pos++
+				continue
+			}
+			if _, err := strconv.ParseFloat(string(word), 64); err == nil {
+				return pdfToken{kind: tokenNumber, value: word}, true
+			}
+			return pdfToken{kind: tokenOperator, value: word}, true
+		}
+	}
+	return pdfToken{}, false
+}
+
+// regular reads a run of regular characters.
+func (l *pdfLexer) regular() []byte {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return l.data[start:l.pos]
+}
+
+// literalString reads a string in parentheses, which may nest, and resolves
+// its escape sequences.
+func (l *pdfLexer) literalString() []byte {
+	l.pos++ // (
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				// a line continuation
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					n := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						n = n*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					out = append(out, byte(n))
+				} else {
+					out = append(out, e)
+				}
+			}
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// hexString reads a string of hexadecimal digits in angle brackets.
+func (l *pdfLexer) hexString() []byte {
+	l.pos++ // <
+	var out []byte
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if v, ok := hexValue(l.data[l.pos]); ok {
+			digits = append(digits, v)
+		}
+		l.pos++
+	}
+	l.pos++ // >
+	if len(digits)%2 == 1 {
+		digits = append(digits, 0)
+	}
+	for i := 0; i < len(digits); i += 2 {
+		out = append(out, digits[i]<<4|digits[i+1])
+	}
+	return out
+}
+
+func hexValue(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+// Package textextract extracts the plain text of documents for full-text search.
+// Plain text, Markdown, CSV, JSON, PDF and DOCX files are supported.
+package textextract
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxTextSize is the maximum number of bytes of text extracted from a document.
+// Text past it is dropped, the beginning of a document is enough to find it.
+const MaxTextSize = 512 << 10
+
+// MaxSourceSize is the size of the largest document text is extracted from.
+// PDF and DOCX files are read into memory as a whole to be parsed.
+const MaxSourceSize = 32 << 20
+
+// ErrUnsupported is returned for documents in a format text cannot be extracted from.
+var ErrUnsupported = errors.New("textextract: unsupported format")
+
+// ErrTooLarge is returned for documents larger than MaxSourceSize.
+var ErrTooLarge = errors.New("textextract: document too large")
+
+type format int
+
+const (
+	formatNone format = iota
+	formatText
+	formatPDF
+	formatDOCX
+)
+
+// mimeFormats maps the content types of the supported formats to their format.
+var mimeFormats = map[string]format{
+	"text/plain":       formatText,
+	"text/markdown":    formatText,
+	"text/x-markdown":  formatText,
+	"text/csv":         formatText,
+	"application/json": formatText,
+	"application/pdf":  formatPDF,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": formatDOCX,
+}
+
+// extFormats maps file extensions to their format, for documents stored
+// with a generic content type.
+var extFormats = map[string]format{
+	".txt":      formatText,
+	".text":     formatText,
+	".md":       formatText,
+	".markdown": formatText,
+	".csv":      formatText,
+	".json":     formatText,
+	".pdf":      formatPDF,
+	".docx":     formatDOCX,
+}
+
+// genericTypes are content types that say nothing about the format, in which
+// case it is told by the file extension. DOCX files are sniffed as ZIP archives.
+var genericTypes = map[string]bool{
+	"":                         true,
+	"application/octet-stream": true,
+	"application/zip":          true,
+}
+
+// Supported reports whether text can be extracted from a document with the
+// given content type and file name.
+func Supported(contentType, filename string) bool {
+	return formatOf(contentType, filename) != formatNone
+}
+
+// Extract reads a document and returns its text, at most MaxTextSize bytes of
+// valid UTF-8. The format is told by the content type, or by the extension of
+// filename when the content type is generic. It returns ErrUnsupported for
+// other formats and ErrTooLarge if r holds more than MaxSourceSize bytes.
+func Extract(r io.Reader, contentType, filename string) (string, error) {
+	switch formatOf(contentType, filename) {
+	case formatText:
+		data, err := io.ReadAll(io.LimitReader(r, MaxTextSize))
+		if err != nil {
+			return "", err
+		}
+		return clean(string(data)), nil
+	case formatPDF:
+		data, err := readSource(r)
+		if err != nil {
+			return "", err
+		}
+		return extractPDF(data)
+	case formatDOCX:
+		data, err := readSource(r)
+		if err != nil {
+			return "", err
+		}
+		return extractDOCX(data)
+	default:
+		return "", ErrUnsupported
+	}
+}
+
+func formatOf(contentType, filename string) format {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	if f, ok := mimeFormats[mediaType]; ok {
+		return f
+	}
+	if genericTypes[mediaType] {
+		return extFormats[strings.ToLower(filepath.Ext(filename))]
+	}
+	return formatNone
+}
+
+// readSource reads a whole document, failing if it is larger than MaxSourceSize.
+func readSource(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxSourceSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxSourceSize {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// clean drops invalid UTF-8 and NUL bytes, which PostgreSQL does not accept
+// in text, and truncates s to MaxTextSize bytes.
+func clean(s string) string {
+	s = strings.ToValidUTF8(s, "")
+	s = strings.ReplaceAll(s, "\x00", "")
+	if len(s) > MaxTextSize {
+		s = s[:MaxTextSize]
+		for !utf8.ValidString(s) {
+			s = s[:len(s)-1]
+		}
+	}
+	return s
+}
+
+// textBuilder collects extracted text until it holds MaxTextSize bytes.
+type textBuilder struct {
+	bytes.Buffer
+}
+
+func (b *textBuilder) full() bool {
+	return b.Len() >= MaxTextSize
+}
+
+// newline ends the current line unless the text is empty or already ends one.
+func (b *textBuilder) newline() {
+	if b.Len() > 0 && !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+		b.WriteByte('\n')
+	}
+}
+
+// space separates words unless the text is empty or already ends with a space.
+func (b *textBuilder) space() {
+	if data := b.Bytes(); len(data) > 0 && data[len(data)-1] != ' ' && data[len(data)-1] != '\n' {
+		b.WriteByte(' ')
+	}
+}
+
+func (b *textBuilder) text() string {
+	return strings.TrimSpace(clean(b.String()))
+}
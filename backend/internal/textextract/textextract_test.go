@@ -0,0 +1,128 @@
+package textextract_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-iolynx/internal/textextract"
+)
+
+const docxType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		filename    string
+		want        string
+		wantErr     error
+	}{
+		{name: "plain text", data: []byte("hello world"), contentType: "text/plain; charset=utf-8", filename: "a.txt", want: "hello world"},
+		{name: "markdown by extension", data: []byte("# Title\n\nbody"), contentType: "application/octet-stream", filename: "README.md", want: "# Title\n\nbody"},
+		{name: "json", data: []byte(`{"city":"Vellore"}`), contentType: "application/json", filename: "a.json", want: `{"city":"Vellore"}`},
+		{name: "invalid utf-8 and NUL bytes", data: []byte("ab\xff\x00cd"), contentType: "text/csv", filename: "a.csv", want: "abcd"},
+		{
+			name:        "docx",
+			data:        docx(t, `<w:p><w:r><w:t>Quarterly</w:t></w:r><w:r><w:t xml:space="preserve"> report</w:t></w:r></w:p><w:p><w:r><w:t>Revenue</w:t><w:tab/><w:t>up</w:t></w:r></w:p>`),
+			contentType: docxType,
+			filename:    "report.docx",
+			want:        "Quarterly report\nRevenue\tup",
+		},
+		{
+			name:        "docx sniffed as zip",
+			data:        docx(t, `<w:p><w:r><w:t>zipped</w:t></w:r></w:p>`),
+			contentType: "application/zip",
+			filename:    "notes.docx",
+			want:        "zipped",
+		},
+		{
+			name:        "pdf",
+			data:        pdf(t, "BT /F1 12 Tf 72 712 Td (Hello \\(PDF\\)) Tj 0 -14 Td [(Sec) 20 (ond) -250 (line)] TJ ET", "BT <FEFF0063006100660065> Tj ET"),
+			contentType: "application/pdf",
+			filename:    "doc.pdf",
+			want:        "Hello (PDF)\nSecond line\ncafe",
+		},
+		{name: "image", data: []byte("\x89PNG"), contentType: "image/png", filename: "a.txt", wantErr: textextract.ErrUnsupported},
+		{name: "unknown extension", data: []byte("data"), contentType: "application/octet-stream", filename: "a.bin", wantErr: textextract.ErrUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := textextract.Extract(bytes.NewReader(tt.data), tt.contentType, tt.filename)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("text = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractTruncates(t *testing.T) {
+	data := strings.Repeat("é", textextract.MaxTextSize) // two bytes each
+	got, err := textextract.Extract(strings.NewReader(data), "text/plain", "long.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) > textextract.MaxTextSize || !strings.HasPrefix(data, got) || len(got) < textextract.MaxTextSize-1 {
+		t.Errorf("got %d bytes, want the first %d bytes cut at a character", len(got), textextract.MaxTextSize)
+	}
+}
+
+func TestExtractDOCXTooLarge(t *testing.T) {
+	// empty paragraphs compress well, the package stays far below MaxSourceSize
+	data := docx(t, strings.Repeat("<w:p/>", 3<<20))
+	if len(data) > textextract.MaxSourceSize {
+		t.Fatalf("package is %d bytes, want it below MaxSourceSize", len(data))
+	}
+	_, err := textextract.Extract(bytes.NewReader(data), docxType, "bomb.docx")
+	if !errors.Is(err, textextract.ErrTooLarge) {
+		t.Errorf("Extract error = %v, want ErrTooLarge", err)
+	}
+}
+
+// docx builds a DOCX package with the given paragraphs as its body.
+func docx(t *testing.T, body string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>%s</w:body></w:document>`, body)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// pdf builds a PDF document with an uncompressed first content stream and
+// Flate encoded ones after it.
+func pdf(t *testing.T, contents ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+	for i, content := range contents {
+		stream := []byte(content)
+		filter := ""
+		if i > 0 {
+			var z bytes.Buffer
+			zw := zlib.NewWriter(&z)
+			zw.Write(stream)
+			zw.Close()
+			stream, filter = z.Bytes(), " /Filter /FlateDecode"
+		}
+		fmt.Fprintf(&buf, "%d 0 obj\n<< /Length %d%s >>\nstream\n%s\nendstream\nendobj\n", i+2, len(stream), filter, stream)
+	}
+	buf.WriteString("%%EOF\n")
+	return buf.Bytes()
+}
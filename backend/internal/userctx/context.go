@@ -2,6 +2,8 @@ package userctx
 
 import (
 	"context"
+
+	"github.com/google/uuid"
 )
 
 type ctxKey string
@@ -28,3 +30,63 @@ func GetUserID(ctx context.Context) (int64, bool) {
 
 	return idInt, true
 }
+
+const scopesKey ctxKey = "scopes"
+
+// SetScopes restricts the request to the given scopes. It is used for requests
+// authenticated with a personal access token.
+func SetScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, scopesKey, scopes)
+}
+
+// GetScopes returns the scopes the request is restricted to.
+// Returns (nil, false) for requests authenticated with a session, which are not restricted.
+func GetScopes(ctx context.Context) ([]string, bool) {
+	scopes, ok := ctx.Value(scopesKey).([]string)
+	return scopes, ok
+}
+
+// HasScope reports whether the request may use scope: it was authenticated
+// with a session, or with a token carrying the scope.
+func HasScope(ctx context.Context, scope string) bool {
+	scopes, ok := GetScopes(ctx)
+	if !ok {
+		return true
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+const sessionIDKey ctxKey = "session_id"
+
+// SetSessionID stores the ID of the session a request was authenticated with.
+func SetSessionID(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, sessionIDKey, id)
+}
+
+// GetSessionID returns the ID of the session a request was authenticated with.
+// Returns (uuid.Nil, false) for requests authenticated with a personal access token.
+func GetSessionID(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(sessionIDKey).(uuid.UUID)
+	return id, ok
+}
+
+const mfaVerifiedKey ctxKey = "mfa_verified"
+
+// SetMFAVerified records that the session a request was authenticated with
+// started with a login that answered a two-factor authentication challenge.
+func SetMFAVerified(ctx context.Context, verified bool) context.Context {
+	return context.WithValue(ctx, mfaVerifiedKey, verified)
+}
+
+// MFAVerified reports whether the session a request was authenticated with
+// started with a login that answered a two-factor authentication challenge.
+// It is false for requests authenticated with a personal access token.
+func MFAVerified(ctx context.Context) bool {
+	verified, _ := ctx.Value(mfaVerifiedKey).(bool)
+	return verified
+}
@@ -0,0 +1,16 @@
+package util
+
+import (
+	"net"
+	"net/http"
+)
+
+// ClientIP returns the IP address a request came from. It does not trust
+// forwarding headers, so behind a reverse proxy it is the proxy's address.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_upload_sessions_owner_id;
+DROP TABLE IF EXISTS upload_sessions;
@@ -0,0 +1,16 @@
+-- upload_sessions: in-progress resumable (tus) uploads.
+-- Each PATCH request is persisted to storage as a separate chunk object,
+-- the blob and file records are only created once upload_offset reaches upload_length.
+CREATE TABLE upload_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    folder_id UUID REFERENCES folders(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL,
+    declared_mime TEXT,
+    upload_length BIGINT NOT NULL,
+    upload_offset BIGINT NOT NULL DEFAULT 0,
+    chunk_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_upload_sessions_owner_id ON upload_sessions(owner_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_direct_uploads_owner_id;
+DROP TABLE IF EXISTS direct_uploads;
@@ -0,0 +1,17 @@
+-- direct_uploads: uploads sent straight to object storage through a presigned URL.
+-- The client declares size and sha256 up front, the server verifies both on completion
+-- before creating the blob and file records.
+CREATE TABLE direct_uploads (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    folder_id UUID REFERENCES folders(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL,
+    declared_mime TEXT,
+    size BIGINT NOT NULL,
+    sha256 TEXT NOT NULL,
+    staging_path TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_direct_uploads_owner_id ON direct_uploads(owner_id);
@@ -0,0 +1,8 @@
+-- Values cannot be removed from an enum, the PUBLIC_LINK_* audit actions are left in place.
+DROP INDEX IF EXISTS idx_files_public_token;
+
+ALTER TABLE files
+    DROP COLUMN IF EXISTS public_password_hash,
+    DROP COLUMN IF EXISTS public_download_count,
+    DROP COLUMN IF EXISTS public_max_downloads,
+    DROP COLUMN IF EXISTS public_expires_at;
@@ -0,0 +1,14 @@
+-- Public share links: a file with is_public set can be fetched without an account
+-- through its public_token. Expiry, download limit and password are optional.
+ALTER TABLE files
+    ADD COLUMN public_expires_at TIMESTAMPTZ,
+    ADD COLUMN public_max_downloads INTEGER,
+    ADD COLUMN public_download_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN public_password_hash TEXT;
+
+CREATE UNIQUE INDEX idx_files_public_token ON files(public_token) WHERE public_token IS NOT NULL;
+
+ALTER TYPE audit_action ADD VALUE 'PUBLIC_LINK_CREATED';
+ALTER TYPE audit_action ADD VALUE 'PUBLIC_LINK_ROTATED';
+ALTER TYPE audit_action ADD VALUE 'PUBLIC_LINK_REVOKED';
+ALTER TYPE audit_action ADD VALUE 'PUBLIC_LINK_USED';
@@ -0,0 +1,5 @@
+-- Values cannot be removed from an enum, FILE_REPLACED and FILE_SHARED are left in place.
+DROP TRIGGER IF EXISTS files_after_update_storage_trigger ON files;
+DROP FUNCTION IF EXISTS handle_file_blob_update();
+
+ALTER TABLE file_shares DROP CONSTRAINT IF EXISTS file_shares_permission_check;
@@ -0,0 +1,39 @@
+-- Share permission levels, ordered: read < comment < write < reshare.
+ALTER TABLE file_shares
+    ADD CONSTRAINT file_shares_permission_check
+    CHECK (permission IN ('read', 'comment', 'write', 'reshare'));
+
+-- Users with write access can replace the contents of a file, which points the
+-- file at another blob. Keep blob refcounts and the owner's storage usage in sync.
+CREATE OR REPLACE FUNCTION handle_file_blob_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE users
+    SET storage_used = storage_used - OLD.size
+    WHERE id = OLD.owner_id;
+
+    UPDATE users
+    SET storage_used = storage_used + NEW.size
+    WHERE id = NEW.owner_id;
+
+    IF NEW.blob_id <> OLD.blob_id THEN
+        UPDATE blobs
+        SET refcount = refcount - 1
+        WHERE id = OLD.blob_id;
+
+        UPDATE blobs
+        SET refcount = refcount + 1
+        WHERE id = NEW.blob_id;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER files_after_update_storage_trigger
+AFTER UPDATE OF blob_id, size, owner_id ON files
+FOR EACH ROW
+EXECUTE FUNCTION handle_file_blob_update();
+
+ALTER TYPE audit_action ADD VALUE 'FILE_REPLACED';
+ALTER TYPE audit_action ADD VALUE 'FILE_SHARED';
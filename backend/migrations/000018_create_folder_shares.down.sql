@@ -0,0 +1,3 @@
+-- Values cannot be removed from an enum, FOLDER_SHARED is left in place.
+DROP INDEX IF EXISTS idx_folder_shares_shared_with;
+DROP TABLE IF EXISTS folder_shares;
@@ -0,0 +1,13 @@
+-- folder_shares: access granted on a folder covers every file and subfolder below it.
+CREATE TABLE folder_shares (
+    id BIGSERIAL PRIMARY KEY,
+    folder_id UUID NOT NULL REFERENCES folders(id) ON DELETE CASCADE,
+    shared_with BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    permission TEXT NOT NULL DEFAULT 'read' CHECK (permission IN ('read', 'comment', 'write', 'reshare')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE(folder_id, shared_with)
+);
+
+CREATE INDEX idx_folder_shares_shared_with ON folder_shares(shared_with);
+
+ALTER TYPE audit_action ADD VALUE 'FOLDER_SHARED';
@@ -0,0 +1,12 @@
+-- Values cannot be removed from an enum, the FILE_VERSION_* actions are left in place.
+-- Dropping the table does not fire the delete trigger, so release the
+-- storage and blob references of the retained versions first.
+DELETE FROM file_versions;
+
+DROP TRIGGER IF EXISTS file_versions_after_delete_storage_trigger ON file_versions;
+DROP TRIGGER IF EXISTS file_versions_after_insert_storage_trigger ON file_versions;
+DROP FUNCTION IF EXISTS handle_file_version_deletion();
+DROP FUNCTION IF EXISTS handle_file_version_insert();
+
+DROP TABLE IF EXISTS file_versions;
+ALTER TABLE files DROP COLUMN IF EXISTS current_version;
@@ -0,0 +1,64 @@
+-- Replacing the contents of a file keeps the previous contents as a version.
+-- files holds the current version, file_versions the retained history.
+ALTER TABLE files ADD COLUMN current_version INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE file_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    file_id UUID NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    -- the owner of the file, kept here so storage can be released
+    -- when the versions are deleted along with their file
+    owner_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    blob_id UUID NOT NULL REFERENCES blobs(id),
+    size BIGINT NOT NULL,
+    declared_mime TEXT,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE(file_id, version)
+);
+
+CREATE INDEX idx_file_versions_blob_id ON file_versions(blob_id);
+CREATE INDEX idx_file_versions_owner_id ON file_versions(owner_id);
+
+-- Retained versions reference their blob and count towards the owner's storage.
+CREATE OR REPLACE FUNCTION handle_file_version_insert()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE users
+    SET storage_used = storage_used + NEW.size
+    WHERE id = NEW.owner_id;
+
+    UPDATE blobs
+    SET refcount = refcount + 1
+    WHERE id = NEW.blob_id;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER file_versions_after_insert_storage_trigger
+AFTER INSERT ON file_versions
+FOR EACH ROW
+EXECUTE FUNCTION handle_file_version_insert();
+
+CREATE OR REPLACE FUNCTION handle_file_version_deletion()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE users
+    SET storage_used = storage_used - OLD.size
+    WHERE id = OLD.owner_id;
+
+    UPDATE blobs
+    SET refcount = refcount - 1
+    WHERE id = OLD.blob_id;
+
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER file_versions_after_delete_storage_trigger
+AFTER DELETE ON file_versions
+FOR EACH ROW
+EXECUTE FUNCTION handle_file_version_deletion();
+
+ALTER TYPE audit_action ADD VALUE 'FILE_VERSION_RESTORED';
+ALTER TYPE audit_action ADD VALUE 'FILE_VERSION_DELETED';
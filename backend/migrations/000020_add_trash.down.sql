@@ -0,0 +1,14 @@
+-- Values cannot be removed from an enum, the trash actions are left in place.
+-- Trashed items are restored, as the trash no longer exists to hide them.
+DROP INDEX IF EXISTS idx_folders_trashed_at;
+DROP INDEX IF EXISTS idx_files_trashed_at;
+DROP INDEX IF EXISTS idx_folders_trashed_with;
+DROP INDEX IF EXISTS idx_files_trashed_with;
+
+ALTER TABLE folders
+    DROP COLUMN IF EXISTS trashed_with,
+    DROP COLUMN IF EXISTS trashed_at;
+
+ALTER TABLE files
+    DROP COLUMN IF EXISTS trashed_with,
+    DROP COLUMN IF EXISTS trashed_at;
@@ -0,0 +1,22 @@
+-- Deleted files and folders are moved to the trash and purged after a retention
+-- period. trashed_at is set on every trashed row. trashed_with holds the folder
+-- whose deletion trashed the row, and is NULL for the items the user deleted
+-- themselves, which are the ones listed in and restored from the trash.
+-- Trashed rows keep counting towards storage_used until they are purged.
+ALTER TABLE files
+    ADD COLUMN trashed_at TIMESTAMPTZ,
+    ADD COLUMN trashed_with UUID;
+
+ALTER TABLE folders
+    ADD COLUMN trashed_at TIMESTAMPTZ,
+    ADD COLUMN trashed_with UUID;
+
+CREATE INDEX idx_files_trashed_with ON files(trashed_with) WHERE trashed_with IS NOT NULL;
+CREATE INDEX idx_folders_trashed_with ON folders(trashed_with) WHERE trashed_with IS NOT NULL;
+CREATE INDEX idx_files_trashed_at ON files(trashed_at) WHERE trashed_at IS NOT NULL AND trashed_with IS NULL;
+CREATE INDEX idx_folders_trashed_at ON folders(trashed_at) WHERE trashed_at IS NOT NULL AND trashed_with IS NULL;
+
+ALTER TYPE audit_action ADD VALUE 'FILE_RESTORED';
+ALTER TYPE audit_action ADD VALUE 'FOLDER_DELETED';
+ALTER TYPE audit_action ADD VALUE 'FOLDER_RESTORED';
+ALTER TYPE audit_action ADD VALUE 'TRASH_PURGED';
@@ -0,0 +1,2 @@
+-- Downloading a folder or a selection of files as an archive is logged once per archive.
+ALTER TYPE audit_action ADD VALUE 'ARCHIVE_DOWNLOADED';
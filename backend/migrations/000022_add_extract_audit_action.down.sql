@@ -0,0 +1 @@
+-- Values cannot be removed from an enum, the ARCHIVE_EXTRACTED audit action is left in place.
@@ -0,0 +1,2 @@
+-- Extracting an archive is logged once, in addition to the upload of every extracted file.
+ALTER TYPE audit_action ADD VALUE 'ARCHIVE_EXTRACTED';
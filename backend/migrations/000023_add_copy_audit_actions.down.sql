@@ -0,0 +1 @@
+-- Values cannot be removed from an enum, the FILE_COPIED and FOLDER_COPIED audit actions are left in place.
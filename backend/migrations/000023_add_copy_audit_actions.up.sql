@@ -0,0 +1,3 @@
+-- Copies of files and folders, including copies sent to other users, are logged on the original.
+ALTER TYPE audit_action ADD VALUE 'FILE_COPIED';
+ALTER TYPE audit_action ADD VALUE 'FOLDER_COPIED';
@@ -0,0 +1,6 @@
+-- Trigram indexes serve both the ILIKE substring matches and the fuzzy
+-- word similarity matches of the global search.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_files_filename_trgm ON files USING GIN (filename gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_folders_name_trgm ON folders USING GIN (name gin_trgm_ops);
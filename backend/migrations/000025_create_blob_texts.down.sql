@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_blob_texts_tsv;
+
+DROP TABLE IF EXISTS blob_texts;
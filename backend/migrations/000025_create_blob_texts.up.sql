@@ -0,0 +1,14 @@
+-- Text extracted from the contents of a blob for full-text search. Extraction
+-- runs in the background once per blob, so deduplicated files share one row.
+-- status is 'indexed' when text was extracted, 'unsupported' for formats that
+-- carry no extractable text and 'failed' when extraction went wrong; content
+-- is empty unless the blob was indexed.
+CREATE TABLE blob_texts (
+    blob_id UUID PRIMARY KEY REFERENCES blobs(id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('indexed', 'unsupported', 'failed')),
+    content TEXT NOT NULL DEFAULT '',
+    tsv TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', content)) STORED,
+    extracted_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_blob_texts_tsv ON blob_texts USING GIN (tsv);
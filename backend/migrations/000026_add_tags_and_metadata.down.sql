@@ -0,0 +1,9 @@
+DROP FUNCTION IF EXISTS item_metadata_matches(UUID, TEXT, TEXT, TEXT);
+
+DROP INDEX IF EXISTS idx_item_metadata_key_value;
+DROP INDEX IF EXISTS idx_item_tags_tag;
+
+DROP TABLE IF EXISTS item_metadata;
+DROP TABLE IF EXISTS item_tags;
+
+-- Values cannot be removed from an enum, the TAGS_UPDATED and METADATA_UPDATED audit actions are left in place.
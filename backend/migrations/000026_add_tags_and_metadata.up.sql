@@ -0,0 +1,48 @@
+-- User-defined tags and key/value metadata on files and folders. A row belongs
+-- to either a file or a folder; item_id is whichever of the two is set, so
+-- listings that mix files and folders can look labels up by their ID.
+CREATE TABLE item_tags (
+    file_id UUID REFERENCES files(id) ON DELETE CASCADE,
+    folder_id UUID REFERENCES folders(id) ON DELETE CASCADE,
+    item_id UUID GENERATED ALWAYS AS (COALESCE(file_id, folder_id)) STORED,
+    tag TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((file_id IS NULL) <> (folder_id IS NULL)),
+    UNIQUE (item_id, tag)
+);
+
+CREATE TABLE item_metadata (
+    file_id UUID REFERENCES files(id) ON DELETE CASCADE,
+    folder_id UUID REFERENCES folders(id) ON DELETE CASCADE,
+    item_id UUID GENERATED ALWAYS AS (COALESCE(file_id, folder_id)) STORED,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((file_id IS NULL) <> (folder_id IS NULL)),
+    UNIQUE (item_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_tags_tag ON item_tags(tag);
+CREATE INDEX IF NOT EXISTS idx_item_metadata_key_value ON item_metadata(key, value);
+
+-- Reports whether the metadata of an item satisfies a predicate of a listing
+-- filter. op is 'eq', 'ne', 'contains' or 'exists'; 'ne' also holds for items
+-- without the key.
+CREATE OR REPLACE FUNCTION item_metadata_matches(item UUID, meta_key TEXT, op TEXT, meta_value TEXT)
+RETURNS BOOLEAN AS $$
+    SELECT CASE op
+        WHEN 'exists' THEN EXISTS (
+            SELECT 1 FROM item_metadata m WHERE m.item_id = item AND m.key = meta_key)
+        WHEN 'eq' THEN EXISTS (
+            SELECT 1 FROM item_metadata m WHERE m.item_id = item AND m.key = meta_key AND m.value = meta_value)
+        WHEN 'ne' THEN NOT EXISTS (
+            SELECT 1 FROM item_metadata m WHERE m.item_id = item AND m.key = meta_key AND m.value = meta_value)
+        WHEN 'contains' THEN EXISTS (
+            SELECT 1 FROM item_metadata m WHERE m.item_id = item AND m.key = meta_key
+              AND strpos(lower(m.value), lower(meta_value)) > 0)
+        ELSE FALSE
+    END;
+$$ LANGUAGE sql STABLE;
+
+ALTER TYPE audit_action ADD VALUE 'TAGS_UPDATED';
+ALTER TYPE audit_action ADD VALUE 'METADATA_UPDATED';
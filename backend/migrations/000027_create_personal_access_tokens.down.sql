@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_personal_access_tokens_user_id;
+
+DROP TABLE IF EXISTS personal_access_tokens;
+
+-- Values cannot be removed from an enum, the TOKEN_CREATED and TOKEN_REVOKED audit actions are left in place.
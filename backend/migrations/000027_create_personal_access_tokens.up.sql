@@ -0,0 +1,22 @@
+-- Long-lived tokens users create for scripts and CI jobs, sent as
+-- "Authorization: Bearer <token>". Only the SHA-256 of a token is stored, the
+-- token itself is shown once when it is created; token_prefix is kept to tell
+-- tokens apart in listings. scopes restrict what a token can be used for and
+-- a token without expires_at never expires. Revoked tokens are kept for audit.
+CREATE TABLE personal_access_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    token_prefix TEXT NOT NULL,
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user_id ON personal_access_tokens(user_id);
+
+ALTER TYPE audit_action ADD VALUE 'TOKEN_CREATED';
+ALTER TYPE audit_action ADD VALUE 'TOKEN_REVOKED';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_sessions_user_id;
+
+DROP TABLE IF EXISTS sessions;
+
+-- Values cannot be removed from an enum, the USER_LOGGED_OUT, SESSION_REVOKED and REFRESH_TOKEN_REUSED audit actions are left in place.
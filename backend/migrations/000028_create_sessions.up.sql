@@ -0,0 +1,25 @@
+-- A session is a login on one device. The browser holds a short-lived access
+-- token and a refresh token, which is exchanged for a new pair and rotated on
+-- every refresh. Only the SHA-256 of the current refresh token is stored,
+-- along with the one it replaced, which is accepted for a short grace period
+-- so concurrent refreshes do not end the session. Any older refresh token
+-- means it was stolen and replayed, and revokes the session.
+CREATE TABLE sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    refresh_token_hash TEXT NOT NULL,
+    previous_refresh_token_hash TEXT,
+    rotated_at TIMESTAMPTZ,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+
+ALTER TYPE audit_action ADD VALUE 'USER_LOGGED_OUT';
+ALTER TYPE audit_action ADD VALUE 'SESSION_REVOKED';
+ALTER TYPE audit_action ADD VALUE 'REFRESH_TOKEN_REUSED';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_user_identities_user_id;
+
+DROP TABLE IF EXISTS user_identities;
+
+-- Values cannot be removed from an enum, the IDENTITY_LINKED and USER_ROLE_CHANGED audit actions are left in place.
@@ -0,0 +1,17 @@
+-- An identity links a user to their account at an OpenID Connect provider,
+-- which is identified by the issuer and subject claims of its ID tokens.
+-- Users created on their first single sign-on login have no password.
+CREATE TABLE user_identities (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
+
+ALTER TYPE audit_action ADD VALUE 'IDENTITY_LINKED';
+ALTER TYPE audit_action ADD VALUE 'USER_ROLE_CHANGED';
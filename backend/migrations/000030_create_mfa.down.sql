@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_mfa_challenges_user_id;
+DROP INDEX IF EXISTS idx_mfa_recovery_codes_user_id;
+
+DROP TABLE IF EXISTS mfa_policies;
+DROP TABLE IF EXISTS mfa_challenges;
+DROP TABLE IF EXISTS mfa_recovery_codes;
+DROP TABLE IF EXISTS user_mfa;
+
+-- Values cannot be removed from an enum, the MFA_ENABLED, MFA_DISABLED, MFA_RECOVERY_CODE_USED, MFA_RECOVERY_CODES_REGENERATED and MFA_POLICY_CHANGED audit actions are left in place.
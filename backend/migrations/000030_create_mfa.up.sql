@@ -0,0 +1,47 @@
+-- Two-factor authentication with time-based one-time passwords (RFC 6238).
+-- The secret is stored when enrollment starts, and enabled_at is set once the
+-- user confirmed it with a first code. last_used_step is the time step of the
+-- last accepted code, so a code cannot be used twice.
+CREATE TABLE user_mfa (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    enabled_at TIMESTAMPTZ,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- One-time codes to log in when the authenticator is lost, stored as SHA-256.
+CREATE TABLE mfa_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- A challenge is issued by a password login of a user with two-factor
+-- authentication, and exchanged for a session with a valid code. Only the
+-- SHA-256 of the challenge token is stored.
+CREATE TABLE mfa_challenges (
+    token_hash TEXT PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Roles whose members must enable two-factor authentication.
+CREATE TABLE mfa_policies (
+    role TEXT PRIMARY KEY,
+    required BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);
+CREATE INDEX IF NOT EXISTS idx_mfa_challenges_user_id ON mfa_challenges(user_id);
+
+ALTER TYPE audit_action ADD VALUE 'MFA_ENABLED';
+ALTER TYPE audit_action ADD VALUE 'MFA_DISABLED';
+ALTER TYPE audit_action ADD VALUE 'MFA_RECOVERY_CODE_USED';
+ALTER TYPE audit_action ADD VALUE 'MFA_RECOVERY_CODES_REGENERATED';
+ALTER TYPE audit_action ADD VALUE 'MFA_POLICY_CHANGED';
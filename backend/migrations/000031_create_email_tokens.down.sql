@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_email_tokens_user_id;
+
+DROP TABLE IF EXISTS email_tokens;
+
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
+
+-- Values cannot be removed from an enum, the EMAIL_VERIFIED, PASSWORD_RESET_REQUESTED and PASSWORD_RESET audit actions are left in place.
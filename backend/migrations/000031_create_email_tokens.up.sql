@@ -0,0 +1,21 @@
+-- Accounts created from now on must confirm their email address, existing
+-- accounts are trusted as they are.
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+UPDATE users SET email_verified_at = now();
+
+-- Single-use tokens sent by email, to verify the address of an account or to
+-- reset its password. Only the SHA-256 of the token is stored.
+CREATE TABLE email_tokens (
+    token_hash TEXT PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose TEXT NOT NULL CHECK (purpose IN ('verify_email', 'reset_password')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_tokens_user_id ON email_tokens(user_id, purpose);
+
+ALTER TYPE audit_action ADD VALUE 'EMAIL_VERIFIED';
+ALTER TYPE audit_action ADD VALUE 'PASSWORD_RESET_REQUESTED';
+ALTER TYPE audit_action ADD VALUE 'PASSWORD_RESET';
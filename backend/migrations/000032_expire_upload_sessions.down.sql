@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_upload_sessions_expires_at;
+
+ALTER TABLE upload_sessions DROP COLUMN IF EXISTS expires_at;
+
+-- Chunks written under the old naming scheme can be counted again, sessions
+-- holding any other chunk keys cannot be resumed and are dropped.
+ALTER TABLE upload_sessions ADD COLUMN chunk_count INT NOT NULL DEFAULT 0;
+DELETE FROM upload_sessions
+WHERE chunk_keys <> ARRAY(
+    SELECT format('uploads/%s/%s', id, lpad(i::TEXT, 6, '0'))
+    FROM generate_series(0, cardinality(chunk_keys) - 1) AS i
+    ORDER BY i
+);
+UPDATE upload_sessions SET chunk_count = cardinality(chunk_keys);
+ALTER TABLE upload_sessions DROP COLUMN chunk_keys;
@@ -0,0 +1,15 @@
+-- The chunks of a resumable upload are stored under keys of their own, which
+-- are recorded on the session when its offset is advanced. Two requests writing
+-- at the same offset then never overwrite each other's object.
+ALTER TABLE upload_sessions ADD COLUMN chunk_keys TEXT[] NOT NULL DEFAULT '{}';
+UPDATE upload_sessions SET chunk_keys = ARRAY(
+    SELECT format('uploads/%s/%s', id, lpad(i::TEXT, 6, '0'))
+    FROM generate_series(0, chunk_count - 1) AS i
+    ORDER BY i
+);
+ALTER TABLE upload_sessions DROP COLUMN chunk_count;
+
+-- Sessions that make no progress until they expire are removed with their chunks.
+ALTER TABLE upload_sessions ADD COLUMN expires_at TIMESTAMPTZ NOT NULL DEFAULT now() + INTERVAL '24 hours';
+
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_expires_at ON upload_sessions(expires_at);
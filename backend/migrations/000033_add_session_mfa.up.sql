@@ -0,0 +1,4 @@
+-- Whether the login that started a session answered a two-factor authentication
+-- challenge. Roles that require two-factor authentication need such a session,
+-- being enrolled is not enough when the login skipped the challenge.
+ALTER TABLE sessions ADD COLUMN mfa_verified BOOLEAN NOT NULL DEFAULT false;
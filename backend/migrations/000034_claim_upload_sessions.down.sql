@@ -0,0 +1 @@
+ALTER TABLE upload_sessions DROP COLUMN IF EXISTS completing_at;
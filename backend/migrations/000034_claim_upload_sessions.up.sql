@@ -0,0 +1,3 @@
+-- A resumable upload that received its last byte is claimed before it is
+-- assembled, so a retried final chunk cannot complete it a second time.
+ALTER TABLE upload_sessions ADD COLUMN completing_at TIMESTAMPTZ;
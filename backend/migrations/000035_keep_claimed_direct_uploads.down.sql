@@ -0,0 +1,2 @@
+DELETE FROM direct_uploads WHERE claimed_at IS NOT NULL;
+ALTER TABLE direct_uploads DROP COLUMN IF EXISTS claimed_at;
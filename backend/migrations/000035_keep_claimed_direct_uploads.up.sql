@@ -0,0 +1,4 @@
+-- Completed and aborted direct uploads are kept, marked as claimed, until they
+-- are purged with the other expired ones. Their presigned URL stays valid until
+-- then, and the purge deletes whatever is PUT to the staging key in the meantime.
+ALTER TABLE direct_uploads ADD COLUMN claimed_at TIMESTAMPTZ;
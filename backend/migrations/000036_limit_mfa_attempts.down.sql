@@ -0,0 +1,2 @@
+ALTER TABLE user_mfa DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE user_mfa DROP COLUMN IF EXISTS failed_attempts;
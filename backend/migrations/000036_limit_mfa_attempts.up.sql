@@ -0,0 +1,4 @@
+-- Codes entered at login are counted per user, across login challenges, and
+-- too many wrong ones in a row lock two-factor login for a while.
+ALTER TABLE user_mfa ADD COLUMN failed_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE user_mfa ADD COLUMN locked_until TIMESTAMPTZ;
@@ -11,12 +11,14 @@ import { ReactNode } from "react";
 import { UserAccountPopover } from "@/components/UserAccountPopover";
 import { getCurrentUser } from "@/lib/auth";
 import AuthStoreInitializer from "@/components/AuthStoreInitializer";
+import { EmailVerificationBanner } from "@/components/EmailVerificationBanner";
 
 const AuthorizedLayout = async ({ children }: { children: ReactNode }) => {
   const user = await getCurrentUser();
   return (
     <div className="flex flex-col items-center h-screen w-full">
       <AuthStoreInitializer user={user} />
+      <EmailVerificationBanner />
       <div className="flex flex-row w-full place-content-around">
         <nav className="w-full">
           <NavigationMenu className="mx-2">
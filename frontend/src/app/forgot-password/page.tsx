@@ -0,0 +1,90 @@
+"use client";
+
+import {
+  Card,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+  CardContent,
+  CardFooter,
+} from "@/components/ui/card";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import React, { useState } from "react";
+import { toast } from "sonner";
+
+import api from '@/lib/axios'
+import Loader from "@/components/loader";
+import { APIError } from "@/types/APIError";
+
+const ForgotPasswordPage = () => {
+  const [email, setEmail] = useState("");
+  const [isLoading, setIsLoading] = useState(false);
+  const [sent, setSent] = useState(false);
+
+  // Asks for a reset link, the answer is the same whether or not the address has an account
+  const onSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (email == "") {
+      toast.error("Please fill the Email ID Field");
+      return;
+    }
+    setIsLoading(true);
+    try {
+      await api.post(
+        "/auth/password/forgot",
+        { email },
+        { headers: { "Content-Type": "application/json" } }
+      );
+      setSent(true);
+    } catch (error) {
+      toast.error((error as APIError)?.response?.data?.error || "Failed to send reset link");
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  return (
+    <div className="flex flex-col items-center justify-center h-screen w-screen">
+      <p className="text-6xl mb-6">FileVault</p>
+      <Card className="w-96">
+        <CardHeader className="flex flex-col items-center gap-y-2">
+          <CardTitle className="text-2xl">Forgot your password?</CardTitle>
+          <CardDescription className="text-center">
+            {sent
+              ? "If an account uses this email address, we sent it a link to reset your password. The link expires in 1 hour."
+              : "Enter your email address and we will send you a link to reset your password"}
+          </CardDescription>
+        </CardHeader>
+        <CardContent>
+          {!sent && (
+            <form className="space-y-4" onSubmit={onSubmit}>
+              <div className="space-y-2">
+                <Label htmlFor="email">Email Id</Label>
+                <Input
+                  type="email"
+                  id="email"
+                  value={email}
+                  onChange={(e) => setEmail(e.target.value)}
+                />
+              </div>
+              <CardFooter className="flex-col justify-between p-0 pt-4">
+                <Button type="submit">
+                  {isLoading ? <Loader /> : "Send Reset Link"}
+                </Button>
+              </CardFooter>
+            </form>
+          )}
+          <div className="mt-6 text-center text-sm">
+            <a href="/login" className="underline underline-offset-4 ">
+              Back to Sign In
+            </a>
+          </div>
+        </CardContent>
+      </Card>
+    </div>
+  );
+};
+
+export default ForgotPasswordPage;
@@ -11,7 +11,7 @@ import {
 import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
 import { Label } from "@/components/ui/label";
-import React, { useState } from "react";
+import React, { useEffect, useState } from "react";
 import { useForm } from "react-hook-form";
 import { toast } from "sonner";
 
@@ -20,6 +20,9 @@ import Loader from "@/components/loader";
 import { useRouter } from "next/navigation";
 import { APIError } from "@/types/APIError";
 
+// Single sign-on is offered when the backend has an identity provider configured
+const ssoEnabled = process.env.NEXT_PUBLIC_SSO_ENABLED === "true";
+
 const LoginPage = () => {
   const { register, handleSubmit } = useForm();
 
@@ -28,6 +31,24 @@ const LoginPage = () => {
   const [email, setEmail] = useState("");
   const [password, setPassword] = useState("");
   const [isLoading, setIsLoading] = useState(false);
+  // Set when the password was right and a two-factor authentication code is needed
+  const [challenge, setChallenge] = useState<string | null>(null);
+  const [code, setCode] = useState("");
+
+  // A failed single sign-on comes back here with the reason, and one of a
+  // user with two-factor authentication with a challenge to answer
+  useEffect(() => {
+    const params = new URLSearchParams(window.location.search);
+    const error = params.get("error");
+    if (error) {
+      toast.error(error);
+    }
+    const ssoChallenge = params.get("challenge");
+    if (ssoChallenge) {
+      setChallenge(ssoChallenge);
+      window.history.replaceState(null, "", window.location.pathname);
+    }
+  }, []);
 
   // Sign In Handler
   const onLogIn = async () => {
@@ -48,6 +69,10 @@ const LoginPage = () => {
         { headers: { "Content-Type": "application/json" }, withCredentials: true }
       );
       console.log(res);
+      if (res?.data?.mfa_required) {
+        setChallenge(res.data.challenge);
+        return;
+      }
       toast.success(res?.data?.message || "Logged In")
       router.push("/dashboard");
     } catch (error) {
@@ -57,6 +82,33 @@ const LoginPage = () => {
     }
   };
 
+  // Second step of the login, with a code from the authenticator app or a recovery code
+  const onVerify = async () => {
+    if (code == "") {
+      toast.error("Please enter your authentication code");
+      return;
+    }
+    setIsLoading(true);
+    try {
+      await api.post(
+        "/auth/mfa/verify",
+        { challenge, code },
+        { headers: { "Content-Type": "application/json" }, withCredentials: true }
+      );
+      toast.success("Logged In");
+      router.push("/dashboard");
+    } catch (error) {
+      const message = (error as APIError)?.response?.data?.error;
+      toast.error(message || "Verification failed");
+      if (message?.startsWith("Login expired")) {
+        setChallenge(null);
+      }
+      setCode("");
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
   return (
     <div className="flex flex-col items-center justify-center h-screen w-screen">
       <p className="text-6xl mb-6">FileVault</p>
@@ -66,37 +118,72 @@ const LoginPage = () => {
           <CardDescription>Sign In with your credentials</CardDescription>
         </CardHeader>
         <CardContent>
-          <form className="space-y-4" onSubmit={handleSubmit(onLogIn)}>
-            <div className="space-y-2">
-              <Label htmlFor="email">Email Id</Label>
-              <Input
-                type="email"
-                id="email"
-                value={email}
-                onChange={(e) => setEmail(e.target.value)}
-              />
-            </div>
-            <div className="space-y-2">
-              <Label htmlFor="password">Password</Label>
-              <Input
-                type="password"
-                id="password"
-                value={password}
-                onChange={(e) => setPassword(e.target.value)}
-              />
-            </div>
-            <CardFooter className="flex-col justify-between p-0 pt-4">
-              <Button type="submit">
-                {isLoading ? <Loader /> : "Sign In"}
-              </Button>
-              <div className="mt-6 text-center text-sm">
-                Don&apos;t have an account?{" "}
-                <a href="/signup" className="underline underline-offset-4 ">
-                  Sign up
-                </a>
+          {challenge ? (
+            <form className="space-y-4" onSubmit={handleSubmit(onVerify)}>
+              <div className="space-y-2">
+                <Label htmlFor="code">Authentication code</Label>
+                <Input
+                  id="code"
+                  autoComplete="one-time-code"
+                  autoFocus
+                  value={code}
+                  onChange={(e) => setCode(e.target.value)}
+                />
+                <p className="text-sm text-muted-foreground">
+                  Enter the code from your authenticator app, or one of your recovery codes.
+                </p>
+              </div>
+              <CardFooter className="flex-col justify-between p-0 pt-4">
+                <Button type="submit">
+                  {isLoading ? <Loader /> : "Verify"}
+                </Button>
+              </CardFooter>
+            </form>
+          ) : (
+            <form className="space-y-4" onSubmit={handleSubmit(onLogIn)}>
+              <div className="space-y-2">
+                <Label htmlFor="email">Email Id</Label>
+                <Input
+                  type="email"
+                  id="email"
+                  value={email}
+                  onChange={(e) => setEmail(e.target.value)}
+                />
+              </div>
+              <div className="space-y-2">
+                <div className="flex items-center justify-between">
+                  <Label htmlFor="password">Password</Label>
+                  <a href="/forgot-password" className="text-sm underline-offset-4 hover:underline">
+                    Forgot your password?
+                  </a>
+                </div>
+                <Input
+                  type="password"
+                  id="password"
+                  value={password}
+                  onChange={(e) => setPassword(e.target.value)}
+                />
               </div>
-            </CardFooter>
-          </form>
+              <CardFooter className="flex-col justify-between p-0 pt-4">
+                <Button type="submit">
+                  {isLoading ? <Loader /> : "Sign In"}
+                </Button>
+                {ssoEnabled && (
+                  <Button variant="outline" className="mt-2" asChild>
+                    <a href={`${process.env.NEXT_PUBLIC_API_URL}/auth/oidc/login`}>
+                      Sign In with SSO
+                    </a>
+                  </Button>
+                )}
+                <div className="mt-6 text-center text-sm">
+                  Don&apos;t have an account?{" "}
+                  <a href="/signup" className="underline underline-offset-4 ">
+                    Sign up
+                  </a>
+                </div>
+              </CardFooter>
+            </form>
+          )}
         </CardContent>
       </Card>
     </div>
@@ -0,0 +1,113 @@
+"use client";
+
+import {
+  Card,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+  CardContent,
+  CardFooter,
+} from "@/components/ui/card";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import React, { useEffect, useState } from "react";
+import { toast } from "sonner";
+
+import api from '@/lib/axios'
+import Loader from "@/components/loader";
+import { useRouter } from "next/navigation";
+import { APIError } from "@/types/APIError";
+
+const ResetPasswordPage = () => {
+  const router = useRouter();
+
+  const [token, setToken] = useState<string | null>(null);
+  const [password, setPassword] = useState("");
+  const [confirmPassword, setConfirmPassword] = useState("");
+  const [isLoading, setIsLoading] = useState(false);
+
+  // The token comes from the link in the password reset email
+  useEffect(() => {
+    setToken(new URLSearchParams(window.location.search).get("token"));
+  }, []);
+
+  const onSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (password == "") {
+      toast.error("Please fill the Password Field");
+      return;
+    } else if (password != confirmPassword) {
+      toast.error("Passwords do not match");
+      return;
+    }
+    setIsLoading(true);
+    try {
+      await api.post(
+        "/auth/password/reset",
+        { token, password },
+        { headers: { "Content-Type": "application/json" } }
+      );
+      toast.success("Password reset", { description: "Please sign in with your new password" });
+      router.push("/login");
+    } catch (error) {
+      toast.error((error as APIError)?.response?.data?.error || "Failed to reset password");
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  return (
+    <div className="flex flex-col items-center justify-center h-screen w-screen">
+      <p className="text-6xl mb-6">FileVault</p>
+      <Card className="w-96">
+        <CardHeader className="flex flex-col items-center gap-y-2">
+          <CardTitle className="text-2xl">Reset your password</CardTitle>
+          <CardDescription className="text-center">
+            {token === null
+              ? "This link is missing its token, please open the link from the email again"
+              : "Choose a new password, you will be signed out of all your devices"}
+          </CardDescription>
+        </CardHeader>
+        <CardContent>
+          {token !== null && (
+            <form className="space-y-4" onSubmit={onSubmit}>
+              <div className="space-y-2">
+                <Label htmlFor="password">New Password</Label>
+                <Input
+                  type="password"
+                  id="password"
+                  autoComplete="new-password"
+                  value={password}
+                  onChange={(e) => setPassword(e.target.value)}
+                />
+              </div>
+              <div className="space-y-2">
+                <Label htmlFor="confirm-password">Confirm Password</Label>
+                <Input
+                  type="password"
+                  id="confirm-password"
+                  autoComplete="new-password"
+                  value={confirmPassword}
+                  onChange={(e) => setConfirmPassword(e.target.value)}
+                />
+              </div>
+              <CardFooter className="flex-col justify-between p-0 pt-4">
+                <Button type="submit">
+                  {isLoading ? <Loader /> : "Reset Password"}
+                </Button>
+              </CardFooter>
+            </form>
+          )}
+          <div className="mt-6 text-center text-sm">
+            <a href="/forgot-password" className="underline underline-offset-4 ">
+              Request a new link
+            </a>
+          </div>
+        </CardContent>
+      </Card>
+    </div>
+  );
+};
+
+export default ResetPasswordPage;
@@ -52,7 +52,9 @@ const SignUpPage = () => {
         { headers: { "Content-Type": "application/json" }, withCredentials: true }
       );
       console.log(res);
-      toast.success(res?.data?.message || "Account Created")
+      toast.success("Account Created", {
+        description: "Check your inbox for a link to verify your email address",
+      })
       router.push("/login");
     } catch (error) {
       toast.error("Sign Up failed");
@@ -0,0 +1,78 @@
+"use client";
+
+import {
+  Card,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+  CardContent,
+} from "@/components/ui/card";
+import { Button } from "@/components/ui/button";
+import React, { useEffect, useRef, useState } from "react";
+
+import api from '@/lib/axios'
+import Loader from "@/components/loader";
+import { APIError } from "@/types/APIError";
+
+type Status = "verifying" | "verified" | "failed";
+
+const VerifyEmailPage = () => {
+  const [status, setStatus] = useState<Status>("verifying");
+  const [error, setError] = useState("");
+  // Links work once, so the token must not be sent twice when effects run twice in development
+  const submitted = useRef(false);
+
+  // The token comes from the link in the verification email
+  useEffect(() => {
+    if (submitted.current) {
+      return;
+    }
+    submitted.current = true;
+
+    const token = new URLSearchParams(window.location.search).get("token");
+    if (!token) {
+      setError("This link is missing its token, please open the link from the email again");
+      setStatus("failed");
+      return;
+    }
+    api.post(
+      "/auth/email/verify",
+      { token },
+      { headers: { "Content-Type": "application/json" } }
+    )
+      .then(() => setStatus("verified"))
+      .catch((error) => {
+        setError((error as APIError)?.response?.data?.error || "Failed to verify your email address");
+        setStatus("failed");
+      });
+  }, []);
+
+  return (
+    <div className="flex flex-col items-center justify-center h-screen w-screen">
+      <p className="text-6xl mb-6">FileVault</p>
+      <Card className="w-96">
+        <CardHeader className="flex flex-col items-center gap-y-2">
+          <CardTitle className="text-2xl">
+            {status === "verified" ? "Email verified" : "Verify your email"}
+          </CardTitle>
+          <CardDescription className="text-center">
+            {status === "verifying" && "Confirming your email address..."}
+            {status === "verified" && "Thank you, your account is ready to use."}
+            {status === "failed" && `${error}. You can ask for a new link from your dashboard.`}
+          </CardDescription>
+        </CardHeader>
+        <CardContent className="flex justify-center">
+          {status === "verifying" ? (
+            <Loader />
+          ) : (
+            <Button asChild>
+              <a href="/dashboard">Go to Dashboard</a>
+            </Button>
+          )}
+        </CardContent>
+      </Card>
+    </div>
+  );
+};
+
+export default VerifyEmailPage;
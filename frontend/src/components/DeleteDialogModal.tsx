@@ -25,8 +25,8 @@ export function DeleteDialogModal({ isOpen, isOpenChange, onConfirm, context }:
 						Are you sure you want to delete this {context}?
 					</AlertDialogTitle>
 					<AlertDialogDescription>
-						This will permanently delete the {context}, {context === "Folder" && (<p>and recursively delete all files and subfolders within this folder.</p>)}
-						This action cannot be undone.
+						This will move the {context} to the trash, {context === "Folder" && (<p>along with all files and subfolders within this folder.</p>)}
+						It can be restored from the trash until it is permanently deleted.
 					</AlertDialogDescription>
 				</AlertDialogHeader>
 				<AlertDialogFooter>
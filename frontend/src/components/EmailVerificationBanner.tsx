@@ -0,0 +1,47 @@
+'use client';
+
+import { useState } from 'react';
+import { toast } from 'sonner';
+
+import { Button } from '@/components/ui/button';
+import Loader from '@/components/loader';
+import api from '@/lib/axios';
+import { useAuthStore } from '@/stores/useAuthStore';
+import { APIError } from '@/types/APIError';
+
+/**
+ * Tells users who have not confirmed their email address that files and
+ * folders are unavailable until they do, and lets them ask for another link.
+ * @returns {JSX.Element | null} The banner, or null once the address is verified.
+ */
+export function EmailVerificationBanner() {
+	const user = useAuthStore((state) => state.user);
+	const [isSending, setIsSending] = useState(false);
+
+	if (!user || user.email_verified) {
+		return null;
+	}
+
+	const onResend = async () => {
+		setIsSending(true);
+		try {
+			await api.post('/auth/email/resend');
+			toast.success(`Verification email sent to ${user.email}`);
+		} catch (error) {
+			toast.error((error as APIError)?.response?.data?.error || 'Failed to send verification email');
+		} finally {
+			setIsSending(false);
+		}
+	};
+
+	return (
+		<div className="flex w-full items-center justify-center gap-4 border-b bg-muted px-4 py-2 text-sm">
+			<span>
+				Please verify your email address to use FileVault. We sent a link to <strong>{user.email}</strong>.
+			</span>
+			<Button size="sm" variant="outline" onClick={onResend} disabled={isSending}>
+				{isSending ? <Loader /> : 'Resend email'}
+			</Button>
+		</div>
+	);
+}
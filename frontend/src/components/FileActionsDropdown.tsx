@@ -7,10 +7,11 @@ import {
 	DropdownMenuTrigger,
 } from "@/components/ui/dropdown-menu"
 
-import { DownloadIcon, EllipsisVerticalIcon, FolderIcon, InfoIcon, PencilIcon, TrashIcon, UserRoundPlusIcon } from "lucide-react";
+import { CopyIcon, DownloadIcon, EllipsisVerticalIcon, FolderIcon, FolderOpenIcon, InfoIcon, PencilIcon, TrashIcon, UserRoundPlusIcon } from "lucide-react";
 import { Button } from "@/components/ui/button";
 import { toast } from "sonner";
 import api from "@/lib/axios";
+import axios from "axios";
 import { DeleteDialogModal } from "./DeleteDialogModal";
 import { useEffect, useState } from "react";
 import { RenameDialogModal } from "./RenameDialogModal";
@@ -43,6 +44,8 @@ interface ActionsDropDownProps {
  * 
  * Renders a dropdown menu with actions that can be performed on a file, Including:
  * - Download
+ * - Extract (ZIP and tar.gz archives)
+ * - Make a copy
  * - Rename
  * - Delete
  * - Move
@@ -56,6 +59,8 @@ interface ActionsDropDownProps {
  */
 export default function FileActionsDropdown({ file, onFileChange }: ActionsDropDownProps) {
 	const [isDeleteDialogOpen, setDeleteDialogOpen] = useState(false)
+	// the server checks the actual contents, this only decides whether to offer extraction
+	const isArchive = /\.(zip|tar\.gz|tgz)$/i.test(file.filename);
 	const [isRenameDialogOpen, setRenameDialogOpen] = useState(false)
 	const [isShareDialogOpen, setShareDialogOpen] = useState(false)
 	const [shareDialogDefautValue, setShareDialogDefaultValue] = useState<string[]>([]);
@@ -119,7 +124,7 @@ export default function FileActionsDropdown({ file, onFileChange }: ActionsDropD
 		try {
 			const res = await api.delete(`/files/${file.id}`, { withCredentials: true });
 			if (res.status === 204) {
-				toast.success("Deleted file successfully");
+				toast.success("Moved file to the trash");
 				fetchUser();
 				deleteItem(file.id);
 			} else {
@@ -163,6 +168,52 @@ export default function FileActionsDropdown({ file, onFileChange }: ActionsDropD
 		}
 	}
 
+	/**
+	 * Extracts the current archive into a new folder next to it.
+	 * - Sends POST request to API, which unpacks the archive on the server
+	 * - Reports entries that could not be extracted
+	 * - Refreshes the listing via `onFileChange` callback
+	 * 
+	 * @async
+	 * @function
+	 */
+	const handleExtract = async () => {
+		try {
+			const res = await api.post(`/files/${file.id}/extract`, {}, { withCredentials: true });
+			const failed = res.data.results.filter((r: { status: string }) => r.status === 'failed');
+			if (failed.length > 0) {
+				toast.warning(`Extracted ${file.filename} with ${failed.length} failed entries`);
+			} else {
+				toast.success(`Extracted ${file.filename}`);
+			}
+			onFileChange();
+		} catch (error) {
+			console.error(error);
+			toast.error((axios.isAxiosError(error) && error.response?.data?.error) || "Error while extracting archive");
+		}
+	}
+
+	/**
+	 * Copies the current file without uploading it again.
+	 * - Copies next to the original if the user owns it, otherwise into the user's root
+	 * - Refreshes the listing and storage usage
+	 * 
+	 * @async
+	 * @function
+	 */
+	const handleCopy = async () => {
+		const targetFolderId = file.user_owns_file ? path[path.length - 1].id : null;
+		try {
+			await api.post(`/files/${file.id}/copy`, { target_folder_id: targetFolderId }, { withCredentials: true });
+			toast.success(`Copied ${file.filename}`);
+			onFileChange();
+			fetchUser();
+		} catch (error) {
+			console.error(error);
+			toast.error((axios.isAxiosError(error) && error.response?.data?.error) || "Error while copying file");
+		}
+	}
+
 	/**
 	 * Renames the current file.
 	 * - Sends PATCH request with new filename
@@ -218,6 +269,36 @@ export default function FileActionsDropdown({ file, onFileChange }: ActionsDropD
 			toast.error("Failed to share file");
 		}
 	};
+
+	/**
+	 * Creates a public link for the current file, so it can be opened without an account.
+	 *
+	 * @async
+	 * @function
+	 * @returns {Promise<string>} The URL of the public link
+	 */
+	const createPublicLink = async (): Promise<string> => {
+		const res = await api.post(`/files/${file.id}/public-link`, {}, { withCredentials: true });
+		setShareDialogURL(res.data.url);
+		return res.data.url;
+	};
+
+	/**
+	 * Revokes the public link of the current file.
+	 *
+	 * @async
+	 * @function
+	 */
+	const revokePublicLink = async () => {
+		try {
+			await api.delete(`/files/${file.id}/public-link`, { withCredentials: true });
+			setShareDialogURL("");
+			toast.success("Public link disabled");
+		} catch (error) {
+			console.error(error);
+			toast.error("Failed to disable public link");
+		}
+	};
 	return (
 		<div className="">
 			<DropdownMenu>
@@ -231,6 +312,16 @@ export default function FileActionsDropdown({ file, onFileChange }: ActionsDropD
 							<DownloadIcon />
 							Download
 						</DropdownMenuItem>
+						{isArchive && (
+							<DropdownMenuItem onSelect={() => handleExtract()}>
+								<FolderOpenIcon />
+								Extract
+							</DropdownMenuItem>
+						)}
+						<DropdownMenuItem onSelect={() => handleCopy()}>
+							<CopyIcon />
+							Make a copy
+						</DropdownMenuItem>
 						<DropdownMenuItem onSelect={() => setRenameDialogOpen(true)} disabled={file.user_owns_file ? false : true}>
 							<PencilIcon />
 							Rename
@@ -287,6 +378,8 @@ export default function FileActionsDropdown({ file, onFileChange }: ActionsDropD
 				onConfirm={(usersToShare: string[]) => handleShare(usersToShare)}
 				defaultValue={shareDialogDefautValue}
 				fileURL={shareDialogURL}
+				onCreateLink={createPublicLink}
+				onRevokeLink={revokePublicLink}
 			/>
 
 			<InfoModal
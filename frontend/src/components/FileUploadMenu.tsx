@@ -10,7 +10,7 @@ import {
 	DropdownMenuItem,
 	DropdownMenuTrigger,
 } from '@/components/ui/dropdown-menu';
-import { FileUpIcon, FolderUpIcon, PlusIcon, Upload } from 'lucide-react';
+import { FileUpIcon, FolderInputIcon, FolderUpIcon, PlusIcon, Upload } from 'lucide-react';
 import api from '@/lib/axios';
 import { toast } from 'sonner';
 import { Progress } from '@/components/ui/progress';
@@ -38,6 +38,7 @@ interface FileUploadMenuProps {
  * 
  * Features:
  * - Upload files to the current folder
+ * - Upload a folder to the current folder, keeping its directory structure
  * - Create a new folder in the current folder
  * - Handles upload completion via callback
  * - Displays toast notifications for success/error
@@ -50,6 +51,8 @@ interface FileUploadMenuProps {
 export function FileUploadMenu({ onActionComplete, currentFolderID }: FileUploadMenuProps) {
 	/** Reference to the hidden file input element */
 	const inputRef = useRef<HTMLInputElement>(null);
+	/** Reference to the hidden directory input element */
+	const folderInputRef = useRef<HTMLInputElement>(null);
 
 	const [isFolderModalOpen, setFolderModalOpen] = useState(false);
 	const [newFolderName, setNewFolderName] = useState('');
@@ -72,6 +75,8 @@ export function FileUploadMenu({ onActionComplete, currentFolderID }: FileUpload
 		if (files && files.length > 0) {
 			uploadFiles(Array.from(files), currentFolderID);
 		}
+		// allow picking the same files again
+		event.target.value = '';
 	};
 
 	/**
@@ -101,7 +106,7 @@ export function FileUploadMenu({ onActionComplete, currentFolderID }: FileUpload
 
 		} catch (error) {
 			console.error('Failed to create folder:', error);
-			toast.error('Failed to create folder.');
+			toast.error((axios.isAxiosError(error) && error.response?.data?.error) || 'Failed to create folder.');
 		} finally {
 			setIsCreating(false);
 		}
@@ -120,6 +125,12 @@ export function FileUploadMenu({ onActionComplete, currentFolderID }: FileUpload
 						<FileUpIcon />
 						<span>Upload Files</span>
 					</DropdownMenuItem>
+					<DropdownMenuItem
+						onSelect={() => folderInputRef.current?.click()}
+					>
+						<FolderInputIcon />
+						<span>Upload Folder</span>
+					</DropdownMenuItem>
 					<DropdownMenuItem
 						onSelect={() => setFolderModalOpen(true)}
 					>
@@ -136,6 +147,14 @@ export function FileUploadMenu({ onActionComplete, currentFolderID }: FileUpload
 				onChange={handleFileChange}
 				style={{ display: 'none' }}
 			/>
+			<Input
+				type="file"
+				multiple
+				ref={folderInputRef}
+				onChange={handleFileChange}
+				style={{ display: 'none' }}
+				{...{ webkitdirectory: '' }}
+			/>
 			<Dialog open={isFolderModalOpen} onOpenChange={setFolderModalOpen}>
 				<DialogContent>
 					<DialogHeader>
@@ -7,18 +7,23 @@ import {
 	DropdownMenuTrigger,
 } from "@/components/ui/dropdown-menu"
 import { ContentItem } from "@/types/Content";
-import { DownloadIcon, EllipsisVerticalIcon, FolderIcon, InfoIcon, PencilIcon, TrashIcon, UserRoundPlusIcon } from "lucide-react";
+import { CopyIcon, DownloadIcon, EllipsisVerticalIcon, FolderIcon, InfoIcon, PencilIcon, TrashIcon, UserRoundPlusIcon } from "lucide-react";
 import { Button } from "@/components/ui/button";
-import { useState } from "react";
+import { useEffect, useState } from "react";
 import { RenameDialogModal } from "./RenameDialogModal";
 import { DeleteDialogModal } from "./DeleteDialogModal";
 import { StopPropagationWrapper } from "./StopPropagationWrapper";
 import api from "@/lib/axios";
+import axios from "axios";
 import { toast } from "sonner";
 import { useContentStore } from "@/stores/useContentStore";
 import { InfoModal } from "./InfoModal";
 import { useAuthStore } from "@/stores/useAuthStore";
 import { MoveDialogModal } from "./MoveDialogModal";
+import { ShareDialogModal } from "./ShareDialogModal";
+import { MultiSelectOption } from "./multi-select";
+import { mapUsersToOptions } from "@/lib/utils";
+import { User } from "@/types/User";
 
 interface ActionsDropDownProps {
 	folder: ContentItem;
@@ -38,9 +43,12 @@ interface ActionsDropDownProps {
  * FolderActionsDropdown component
  * 
  * Renders a dropdown menu with actions that can be performed on a folder, Including:
+ * - Download (as a ZIP archive)
+ * - Make a copy
  * - Rename
  * - Delete
  * - Move
+ * - Share
  * - Info
  * 
  * @param {ActionsDropDownProps} props - Component props
@@ -54,6 +62,9 @@ export default function FolderActionsDropdown({ folder, onFolderChange }: Action
 	const [isRenameDialogOpen, setRenameDialogOpen] = useState(false)
 	const [isInfoModalOpen, setIsInfoModalOpen] = useState(false);
 	const [isMoveDialogOpen, setMoveDialogOpen] = useState(false);
+	const [isShareDialogOpen, setShareDialogOpen] = useState(false);
+	const [shareDialogDefaultValue, setShareDialogDefaultValue] = useState<string[]>([]);
+	const [shareDialogOptions, setShareDialogOptions] = useState<MultiSelectOption[]>([]);
 	const { deleteItem, renameItem } = useContentStore();
 	const { fetchUser } = useAuthStore();
 	const { path } = useContentStore();
@@ -71,7 +82,7 @@ export default function FolderActionsDropdown({ folder, onFolderChange }: Action
 		try {
 			const res = await api.delete(`/folders/${folder.id}`, { withCredentials: true });
 			if (res.status === 204) {
-				toast.success("Deleted folder successfully");
+				toast.success("Moved folder to the trash");
 				deleteItem(folder.id)
 				fetchUser();
 			} else {
@@ -83,6 +94,89 @@ export default function FolderActionsDropdown({ folder, onFolderChange }: Action
 		}
 	}
 
+	/**
+	 * Downloads the current folder and everything inside it as a ZIP archive.
+	 * - Points a temporary link at the archive endpoint, so the browser streams
+	 *   the archive to disk while the server produces it
+	 * 
+	 * @function
+	 */
+	const handleDownload = () => {
+		const link = document.createElement('a');
+		link.href = api.getUri({ url: '/files/archive', params: { folder_id: folder.id, format: 'zip' } });
+		link.setAttribute('download', `${folder.filename}.zip`);
+		document.body.appendChild(link);
+		link.click();
+		link.parentNode?.removeChild(link);
+	}
+
+	/**
+	 * Copies the current folder with everything inside it without uploading it again.
+	 * - Copies next to the original if the user owns it, otherwise into the user's root
+	 * - Refreshes the listing and storage usage
+	 * 
+	 * @async
+	 * @function
+	 */
+	const handleCopy = async () => {
+		const targetFolderId = folder.user_owns_file ? path[path.length - 1].id : null;
+		try {
+			await api.post(`/folders/${folder.id}/copy`, { target_folder_id: targetFolderId }, { withCredentials: true });
+			toast.success(`Copied ${folder.filename}`);
+			onFolderChange();
+			fetchUser();
+		} catch (error) {
+			console.error(error);
+			toast.error((axios.isAxiosError(error) && error.response?.data?.error) || "Error while copying folder");
+		}
+	}
+
+	/**
+	 * Fetches share information for the folder.
+	 * - Retrieves the list of users the folder is already shared with
+	 * - Retrieves the list of all possible users the folder can be shared with
+	 * 
+	 * @async
+	 * @function
+	 */
+	const fetchShareInfo = async () => {
+		try {
+			const res = await api.get(`/folders/${folder.id}/share-info`, { withCredentials: true });
+			const usersWithAccess: User[] = res.data.sharedWith;
+			setShareDialogDefaultValue(usersWithAccess.map((user) => user.id));
+			setShareDialogOptions(mapUsersToOptions(res.data.allUsers));
+		} catch (error) {
+			console.log("error while fetching users with access to folder: ", error)
+		}
+	}
+	useEffect(() => {
+		if (folder.user_owns_file && isShareDialogOpen) {
+			fetchShareInfo()
+		}
+	}, [isShareDialogOpen])
+
+	/**
+	 * Shares the current folder, and everything inside it, with selected users.
+	 * - Sends PUT request to API to update folder shares
+	 * - Shows success or error toast notifications
+	 * 
+	 * @async
+	 * @function
+	 * @param {string[]} selectedUsers - Array of user IDs (as strings) to share the folder with
+	 */
+	const handleShare = async (selectedUsers: string[]) => {
+		try {
+			const res = await api.put(`/folders/${folder.id}/shares`,
+				{ user_ids: selectedUsers.map(id => parseInt(id, 10)) },
+				{ headers: { "Content-Type": "application/json" }, withCredentials: true }
+			);
+			toast.success(res.data.message);
+		} catch (error) {
+			console.error(error);
+			toast.error("Failed to share folder");
+		}
+	}
+
 	/**
 	 * Renames the current folder.
 	 * - Sends PATCH request with new folder name
@@ -120,6 +214,14 @@ export default function FolderActionsDropdown({ folder, onFolderChange }: Action
 					</DropdownMenuTrigger>
 					<DropdownMenuContent className="w-36" align="start">
 						<DropdownMenuGroup>
+							<DropdownMenuItem onSelect={() => handleDownload()}>
+								<DownloadIcon />
+								Download
+							</DropdownMenuItem>
+							<DropdownMenuItem onSelect={() => handleCopy()}>
+								<CopyIcon />
+								Make a copy
+							</DropdownMenuItem>
 							<DropdownMenuItem onSelect={() => setRenameDialogOpen(true)}>
 								<PencilIcon />
 								Rename
@@ -133,6 +235,10 @@ export default function FolderActionsDropdown({ folder, onFolderChange }: Action
 						<DropdownMenuSeparator />
 
 						<DropdownMenuGroup>
+							<DropdownMenuItem onSelect={() => setShareDialogOpen(true)} disabled={!folder.user_owns_file}>
+								<UserRoundPlusIcon />
+								Share
+							</DropdownMenuItem>
 							<DropdownMenuItem onSelect={() => setMoveDialogOpen(true)}>
 								<FolderIcon />
 								Move
@@ -171,6 +277,14 @@ export default function FolderActionsDropdown({ folder, onFolderChange }: Action
 					item={folder}
 				/>
 
+				<ShareDialogModal
+					isOpen={isShareDialogOpen}
+					isOpenChange={setShareDialogOpen}
+					userOptions={shareDialogOptions}
+					onConfirm={(usersToShare: string[]) => handleShare(usersToShare)}
+					defaultValue={shareDialogDefaultValue}
+				/>
+
 				<MoveDialogModal
 					currentFolderId={path[path.length - 1].id}
 					fileId={folder.id}
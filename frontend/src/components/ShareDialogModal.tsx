@@ -22,13 +22,18 @@ interface ShareDialogModalProps {
 	userOptions: MultiSelectOption[];
 	onConfirm: (usersToShare: string[]) => void;
 	defaultValue: string[]
-	fileURL: string;
+	// Public link props, folders don't have public links
+	fileURL?: string;
+	onCreateLink?: () => Promise<string>;
+	onRevokeLink?: () => void;
 }
 
-export function ShareDialogModal({ isOpen, isOpenChange, userOptions, onConfirm, defaultValue, fileURL }: ShareDialogModalProps) {
+export function ShareDialogModal({ isOpen, isOpenChange, userOptions, onConfirm, defaultValue, fileURL, onCreateLink, onRevokeLink }: ShareDialogModalProps) {
 	const handleCopy = async () => {
 		try {
-			await navigator.clipboard.writeText(fileURL);
+			// The file has no public link yet, create one first
+			const url = fileURL || await onCreateLink!();
+			await navigator.clipboard.writeText(url);
 			toast.success("Copied file URL to clipboard")
 		} catch (err) {
 			console.log("Failed to copy file URL", err)
@@ -61,7 +66,12 @@ export function ShareDialogModal({ isOpen, isOpenChange, userOptions, onConfirm,
 							Done
 						</Button>
 					</DialogClose>
-					<div>
+					{onCreateLink && (<div className="flex gap-2">
+						{fileURL && (
+							<Button variant="outline" onClick={onRevokeLink}>
+								Disable Link
+							</Button>
+						)}
 						<Tooltip>
 							<TooltipTrigger>
 								<Button variant="outline" onClick={handleCopy}>
@@ -72,7 +82,7 @@ export function ShareDialogModal({ isOpen, isOpenChange, userOptions, onConfirm,
 								Anyone with this link can view the file.
 							</TooltipContent>
 						</Tooltip>
-					</div>
+					</div>)}
 				</DialogFooter>
 			</DialogContent>
 		</Dialog>
@@ -6,6 +6,12 @@ import { toast } from 'sonner';
 import { Progress } from '@/components/ui/progress';
 import { useAuthStore } from '@/stores/useAuthStore';
 
+interface UploadResult {
+	path: string;
+	status: 'created' | 'deduplicated' | 'failed';
+	error?: string;
+}
+
 interface UseFileUploaderProps {
 	onUploadComplete: () => void;
 }
@@ -39,11 +45,14 @@ export const useFileUploader = ({ onUploadComplete }: UseFileUploaderProps) => {
 
 		setIsUploading(true);
 
+		// folder_id must precede the files, the server streams parts in order
 		const formData = new FormData();
-		files.forEach((file) => formData.append('files', file));
 		if (currentFolderId) {
 			formData.append('folder_id', currentFolderId);
 		}
+		// files picked from a directory keep their relative path, the server
+		// recreates the folders along it
+		files.forEach((file) => formData.append('files', file, file.webkitRelativePath || file.name));
 
 		try {
 			toastIdRef.current = toast.custom(() => (
@@ -53,7 +62,7 @@ export const useFileUploader = ({ onUploadComplete }: UseFileUploaderProps) => {
 				</div>
 			));
 
-			await api.post('/files/upload', formData, {
+			const res = await api.post('/files/upload', formData, {
 				headers: { 'Content-Type': 'multipart/form-data' },
 				withCredentials: true,
 				onUploadProgress: (progressEvent) => {
@@ -75,7 +84,15 @@ export const useFileUploader = ({ onUploadComplete }: UseFileUploaderProps) => {
 
 			if (toastIdRef.current) {
 				toast.dismiss(toastIdRef.current);
-				toast.success('Files uploaded successfully!');
+				// 207 Multi-Status: some files were uploaded, the others are listed as failed
+				const failed: UploadResult[] = (res.data.results ?? []).filter((r: UploadResult) => r.status === 'failed');
+				if (failed.length > 0) {
+					toast.warning(res.data.message, {
+						description: failed.map((r) => `${r.path}: ${r.error}`).join('\n'),
+					});
+				} else {
+					toast.success('Files uploaded successfully!');
+				}
 				setTimeout(fetchUser, 1200);
 			}
 		} catch (error) {
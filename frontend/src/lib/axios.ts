@@ -1,8 +1,46 @@
-import axios from 'axios';
+import axios, { AxiosError, InternalAxiosRequestConfig } from 'axios';
 
 const api = axios.create({
   baseURL: process.env.NEXT_PUBLIC_API_URL,
   withCredentials: true
 });
 
+// Requests that must not trigger a refresh when they fail with 401.
+const noRefreshPaths = ['/auth/login', '/auth/mfa/verify', '/auth/refresh', '/auth/logout'];
+
+// The refresh in flight, shared by the requests that fail at the same time so
+// the refresh token is rotated once.
+let refreshing: Promise<void> | null = null;
+
+const refreshSession = () => {
+  if (!refreshing) {
+    refreshing = api.post('/auth/refresh')
+      .then(() => undefined)
+      .finally(() => { refreshing = null; });
+  }
+  return refreshing;
+};
+
+// Access tokens are short-lived: when a request fails with 401, refresh the
+// session and retry it once.
+api.interceptors.response.use(undefined, async (error: AxiosError) => {
+  const config = error.config as (InternalAxiosRequestConfig & { _retried?: boolean }) | undefined;
+  if (
+    error.response?.status !== 401 ||
+    !config ||
+    config._retried ||
+    noRefreshPaths.some((path) => config.url?.startsWith(path))
+  ) {
+    return Promise.reject(error);
+  }
+
+  config._retried = true;
+  try {
+    await refreshSession();
+  } catch {
+    return Promise.reject(error);
+  }
+  return api(config);
+});
+
 export default api;
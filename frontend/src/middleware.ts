@@ -2,6 +2,7 @@ import { jwtVerify } from "jose";
 import { NextResponse, NextRequest } from "next/server";
 
 const JWT_SECRET_STRING = process.env.JWT_SECRET;
+const API_URL = process.env.NEXT_PUBLIC_API_URL ?? "http://localhost:8080";
 
 let JWT_SECRET_KEY: Uint8Array;
 
@@ -11,29 +12,76 @@ if (JWT_SECRET_STRING) {
   console.log("Critial Error: JWT_SECRET environment variable not defined!");
 }
 
+async function isValidToken(token: string | undefined) {
+  if (!token) {
+    return false;
+  }
+  try {
+    await jwtVerify(token, JWT_SECRET_KEY);
+    return true;
+  } catch (error) {
+    console.error("Invalid Token", error);
+    return false;
+  }
+}
+
+/**
+ * Exchanges the refresh token for new session cookies, since access tokens
+ * are short-lived. The new cookies are set on the request, so server
+ * components see them, and on the response, so the browser keeps them.
+ * @returns {Promise<NextResponse | null>} The response to continue with, or null if the session has ended.
+ */
+async function refreshSession(request: NextRequest, refreshToken: string) {
+  let response: Response;
+  try {
+    response = await fetch(`${API_URL}/auth/refresh`, {
+      method: "POST",
+      headers: { Cookie: `refresh_token=${refreshToken}` },
+      cache: "no-store",
+    });
+  } catch (error) {
+    console.error("Failed to refresh session", error);
+    return null;
+  }
+  if (!response.ok) {
+    return null;
+  }
+
+  const setCookies = response.headers.getSetCookie();
+  for (const setCookie of setCookies) {
+    const [pair] = setCookie.split(";");
+    const index = pair.indexOf("=");
+    request.cookies.set(pair.slice(0, index), pair.slice(index + 1));
+  }
+  const next = NextResponse.next({ request: { headers: request.headers } });
+  for (const setCookie of setCookies) {
+    next.headers.append("Set-Cookie", setCookie);
+  }
+  return next;
+}
+
 export async function middleware(request: NextRequest) {
   const token = request.cookies.get("jwt")?.value;
+  const refreshToken = request.cookies.get("refresh_token")?.value;
 
-  const protectedRoutes = ["/dashboard"];
+  const protectedRoutes = ["/dashboard", "/admin"];
 
   if (
     protectedRoutes.some((route) => request.nextUrl.pathname.startsWith(route))
   ) {
-    if (!token) {
-      const signInUrl = new URL("/login", request.url);
-      signInUrl.searchParams.set("redirect", request.nextUrl.pathname);
-      console.error("No Token");
-      return NextResponse.redirect(new URL("/login", request.url));
+    if (await isValidToken(token)) {
+      return NextResponse.next();
     }
 
-    try {
-      await jwtVerify(token, JWT_SECRET_KEY);
-    } catch (error) {
-      console.error("Invalid Token", error);
-      const signInUrl = new URL("/login", request.url);
-      signInUrl.searchParams.set("redirect", request.nextUrl.pathname);
-      return NextResponse.redirect(signInUrl);
+    const refreshed = refreshToken && (await refreshSession(request, refreshToken));
+    if (refreshed) {
+      return refreshed;
     }
+
+    console.error(token ? "Session has ended" : "No Token");
+    const signInUrl = new URL("/login", request.url);
+    signInUrl.searchParams.set("redirect", request.nextUrl.pathname);
+    return NextResponse.redirect(signInUrl);
   }
 
   console.log("redirecting to page...");
@@ -43,6 +91,6 @@ export async function middleware(request: NextRequest) {
 export const config = {
   matcher: [
     "/dashboard/:path*",
+    "/admin/:path*",
   ],
 };
-
@@ -3,6 +3,7 @@ export interface User {
 	name: string;
 	email: string;
 	role: string;
+	email_verified: boolean;
 	storage_used_bytes: number;
 	deduplicated_usage_bytes: number;
 	storage_quota_bytes: number;